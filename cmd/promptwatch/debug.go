@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// debugLogPath returns the location of the debug log (~/.cache/promptwatch/debug.log)
+func debugLogPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot get home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", "promptwatch", "debug.log"), nil
+}
+
+// setupDebugLog opens the debug log at path for appending and returns a logger writing to it
+// The returned file must be closed by the caller
+func setupDebugLog(path string) (*slog.Logger, *os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, fmt.Errorf("cannot create log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open debug log: %w", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logger.Info("promptwatch started", "pid", os.Getpid(), "args", os.Args[1:])
+	return logger, file, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/digest"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// runDigest implements the "digest" subcommand, printing one line per session of a
+// time range, oldest first, e.g. for standup notes
+func runDigest(args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	since := fs.String("since", "yesterday", "Start of the range: today, yesterday, a number of days (3d), a duration (36h) or a date (2006-01-02)")
+	project := fs.String("project", "", "Only include sessions for this project path")
+	markdown := fs.Bool("md", false, "Print a Markdown bulleted list")
+	asJSON := fs.Bool("json", false, "Print a JSON array with one object per session")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptwatch digest [-since yesterday] [-project path] [-md | -json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *markdown && *asJSON {
+		return errors.New("-md and -json cannot be combined")
+	}
+	start, err := digest.ParseSince(*since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	pricing.SetOverrides(cfg.Pricing.Overrides())
+	render.SetNumberFormat(cfg.Numbers, cfg.Cost)
+
+	sessions, err := digest.Collect(start, *project, pricing.MessageCost)
+	if err != nil {
+		return err
+	}
+	format := digest.FormatText
+	switch {
+	case *markdown:
+		format = digest.FormatMarkdown
+	case *asJSON:
+		format = digest.FormatJSON
+	}
+	return digest.Write(os.Stdout, sessions, format)
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// Outcomes of a doctor check
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// doctorCheck is the outcome of one environment check
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // checkPass, checkWarn or checkFail
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"` // What to do about a warning or failure
+}
+
+// doctorReport is everything "promptwatch doctor" found, shaped to paste into bug reports
+type doctorReport struct {
+	OS        string                   `json:"os"`
+	Checks    []doctorCheck            `json:"checks"`
+	Processes []monitor.ProcessVerdict `json:"processes"`
+}
+
+// runDoctor implements the "doctor" subcommand, checking the environment promptwatch
+// depends on and suggesting fixes for what is missing
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON, e.g. to paste into a bug report")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptwatch doctor [-json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	report := doctorReport{OS: runtime.GOOS + "/" + runtime.GOARCH}
+	cfg, check := checkConfig()
+	report.Checks = append(report.Checks, check, checkProjectsDir(), checkSessions())
+	verdicts, check := checkProcesses(cfg)
+	report.Processes = verdicts
+	report.Checks = append(report.Checks, check, checkClipboard(), checkColors(), checkUnicode())
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(report)
+	}
+
+	failed := 0
+	for _, c := range report.Checks {
+		if c.Status == checkFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+	}
+	return nil
+}
+
+// printDoctorReport prints one line per check, with hints and process verdicts indented below
+func printDoctorReport(report doctorReport) {
+	symbols := map[string]string{checkPass: "✓", checkWarn: "!", checkFail: "✗"}
+	for _, c := range report.Checks {
+		fmt.Printf("%s %-11s %s\n", symbols[c.Status], c.Name, c.Detail)
+		if c.Name == "processes" {
+			for _, v := range report.Processes {
+				state := "skipped"
+				if v.Listed {
+					state = "listed"
+				}
+				var where []string
+				if v.Exe != "" {
+					where = append(where, "exe "+v.Exe)
+				}
+				if v.WorkDir != "" {
+					where = append(where, "workdir "+v.WorkDir)
+				}
+				fmt.Printf("  PID %d %s: %s", v.PID, state, v.Reason)
+				if len(where) > 0 {
+					fmt.Printf(" [%s]", strings.Join(where, ", "))
+				}
+				fmt.Println()
+			}
+		}
+		if c.Hint != "" {
+			fmt.Printf("  → %s\n", c.Hint)
+		}
+	}
+}
+
+// checkConfig loads the config file; a broken one keeps promptwatch from starting
+func checkConfig() (*config.Config, doctorCheck) {
+	c := doctorCheck{Name: "config", Status: checkPass}
+	path, err := config.DefaultPath()
+	if err != nil {
+		c.Detail = "no config directory, using defaults"
+		return config.Default(), c
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Hint = "Fix or remove " + path + "; the README lists all settings"
+		return config.Default(), c
+	}
+	c.Detail = path
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		c.Detail = "no config file, using defaults"
+	}
+	return cfg, c
+}
+
+// checkProjectsDir checks that Claude's session directory exists and is readable
+func checkProjectsDir() doctorCheck {
+	c := doctorCheck{Name: "projects"}
+	dir, err := monitor.ProjectsDir()
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Hint = "Set HOME, or CLAUDE_CONFIG_DIR to Claude's config directory"
+		return c
+	}
+	source := ""
+	if os.Getenv("CLAUDE_CONFIG_DIR") != "" {
+		source = " (from CLAUDE_CONFIG_DIR)"
+	}
+
+	fi, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		c.Status, c.Detail = checkFail, dir+source+" does not exist"
+		c.Hint = "Run claude once so it creates it, or set CLAUDE_CONFIG_DIR if Claude's config lives elsewhere"
+	case err != nil:
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Hint = "Check the permissions of " + dir
+	case !fi.IsDir():
+		c.Status, c.Detail = checkFail, dir+source+" is not a directory"
+	default:
+		if _, err := os.ReadDir(dir); err != nil {
+			c.Status, c.Detail = checkFail, err.Error()
+			c.Hint = "Check the permissions of " + dir
+			break
+		}
+		c.Status, c.Detail = checkPass, dir+source
+	}
+	return c
+}
+
+// checkSessions counts the projects and session files promptwatch can list
+func checkSessions() doctorCheck {
+	c := doctorCheck{Name: "sessions"}
+	dir, err := monitor.ProjectsDir()
+	if err != nil {
+		c.Status, c.Detail = checkWarn, "skipped, no projects directory"
+		return c
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		c.Status, c.Detail = checkWarn, "skipped, projects directory unreadable"
+		return c
+	}
+
+	projects, sessions, unreadable := 0, 0, 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		projects++
+		files, err := os.ReadDir(filepath.Join(dir, e.Name()))
+		if err != nil {
+			unreadable++
+			continue
+		}
+		for _, f := range files {
+			if monitor.IsSessionFile(f.Name()) {
+				sessions++
+			}
+		}
+	}
+
+	c.Status, c.Detail = checkPass, fmt.Sprintf("%d projects, %d sessions", projects, sessions)
+	if unreadable > 0 {
+		c.Status = checkWarn
+		c.Detail += fmt.Sprintf(", %d project directories unreadable", unreadable)
+		c.Hint = "Check the permissions under " + dir
+	}
+	if sessions == 0 {
+		c.Status = checkWarn
+		c.Hint = "Start a conversation with claude; promptwatch lists processes only once their project has sessions"
+	}
+	return c
+}
+
+// checkProcesses runs process discovery and explains the verdict for each Claude-like process
+func checkProcesses(cfg *config.Config) ([]monitor.ProcessVerdict, doctorCheck) {
+	c := doctorCheck{Name: "processes"}
+	verdicts, err := monitor.ExplainProcesses(monitor.DiscoveryOptions{ShowSessionless: cfg.Processes.ShowSessionless})
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return nil, c
+	}
+
+	listed, sessionless, denied := 0, 0, 0
+	for _, v := range verdicts {
+		switch {
+		case v.Listed:
+			listed++
+		case strings.HasPrefix(v.Reason, "no sessions"):
+			sessionless++
+		case strings.Contains(v.Reason, "permission denied"):
+			denied++
+		}
+	}
+	c.Detail = fmt.Sprintf("%d Claude instances listed, %d Claude-like processes seen", listed, len(verdicts))
+	switch {
+	case len(verdicts) == 0:
+		c.Status = checkWarn
+		c.Detail = "no claude processes running"
+		c.Hint = "Start claude in a terminal; the desktop app is not monitored"
+	case listed > 0:
+		c.Status = checkPass
+	case sessionless > 0:
+		c.Status = checkWarn
+		c.Hint = "Instances without sessions are hidden until their first conversation; set processes.showSessionless to list them"
+	case denied > 0:
+		c.Status = checkWarn
+		c.Hint = "Processes of other users cannot be inspected; run promptwatch as the user running claude"
+	default:
+		c.Status = checkWarn
+	}
+	return verdicts, c
+}
+
+// checkClipboard checks whether copying (OSC 52 escape sequences) can reach the system clipboard
+func checkClipboard() doctorCheck {
+	c := doctorCheck{Name: "clipboard", Status: checkPass, Detail: "OSC 52 escape sequences, supported by most terminals, also over SSH"}
+	switch {
+	case !isTerminal(os.Stdout):
+		c.Status, c.Detail = checkWarn, "stdout is not a terminal, so copying cannot be verified"
+	case os.Getenv("TMUX") != "":
+		c.Status, c.Detail = checkWarn, "running inside tmux, which drops OSC 52 unless set-clipboard is on"
+		c.Hint = "Add \"set -g set-clipboard on\" to ~/.tmux.conf"
+	case os.Getenv("STY") != "":
+		c.Status, c.Detail = checkWarn, "running inside GNU screen, which does not pass OSC 52 on"
+		c.Hint = "Use tmux with set-clipboard on, or a terminal without screen"
+	case os.Getenv("TERM") == "dumb":
+		c.Status, c.Detail = checkWarn, "TERM=dumb, which cannot handle escape sequences"
+	}
+	return c
+}
+
+// checkColors reports the color support the terminal advertises. It goes by the
+// environment alone, so the report is the same when piped into a bug report.
+func checkColors() doctorCheck {
+	c := doctorCheck{Name: "colors"}
+	switch termenv.NewOutput(os.Stdout, termenv.WithUnsafe()).EnvColorProfile() {
+	case termenv.TrueColor:
+		c.Status, c.Detail = checkPass, "true color"
+	case termenv.ANSI256:
+		c.Status, c.Detail = checkPass, "256 colors"
+	case termenv.ANSI:
+		c.Status, c.Detail = checkWarn, "16 colors"
+		c.Hint = "Set TERM=xterm-256color if the terminal supports more"
+	default:
+		c.Status, c.Detail = checkWarn, "no colors (TERM="+os.Getenv("TERM")+")"
+		c.Hint = "Cost levels and badges are colored; unset NO_COLOR or use a color terminal"
+	}
+	return c
+}
+
+// checkUnicode checks that the locale is UTF-8, which badges, sparklines and borders need
+func checkUnicode() doctorCheck {
+	c := doctorCheck{Name: "unicode"}
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	lower := strings.ToLower(locale)
+	if strings.Contains(lower, "utf-8") || strings.Contains(lower, "utf8") {
+		c.Status, c.Detail = checkPass, locale
+		return c
+	}
+	if locale == "" {
+		locale = "no locale set"
+	}
+	c.Status, c.Detail = checkWarn, locale
+	c.Hint = "Set LANG to a UTF-8 locale, e.g. en_US.UTF-8, so symbols render"
+	return c
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/export"
+	"github.com/thieso2/promptwatch/internal/fsutil"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// runExport implements the "export" subcommand, writing a session file in one of the
+// registered export formats
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "", "Write to this file instead of stdout")
+	presetName := fs.String("preset", "", "Only export messages matching this filter preset from the config")
+	formatName := fs.String("format", "json", "Output format: "+export.NameList())
+	readOnly := fs.Bool("read-only", false, "Refuse to write files (also set by readOnly in the config); stdout still works")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptwatch export [-o file] [-format "+strings.Join(export.Names(), "|")+"] [-preset name] [-read-only] <session.jsonl>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one session file")
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	if *output != "" && (*readOnly || readOnlyConfigured()) {
+		return fmt.Errorf("read-only mode: not writing %s; leave out -o to export to stdout", *output)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	pricing.SetOverrides(cfg.Pricing.Overrides())
+	render.SetNumberFormat(cfg.Numbers, cfg.Cost)
+
+	var preset *config.FilterPreset
+	if *presetName != "" {
+		p, ok := cfg.FilterPreset(*presetName)
+		if !ok {
+			return fmt.Errorf("no filter preset named %q in the config", *presetName)
+		}
+		preset = &p
+	}
+
+	stats, err := monitor.ParseSessionFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, export.Build(stats, exportOptions(stats, preset)), format); err != nil {
+		return err
+	}
+	if *output != "" {
+		// Never leave a half-written export behind if interrupted
+		if err := fsutil.WriteAtomic(*output, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("cannot write output file: %w", err)
+		}
+		return nil
+	}
+	_, err = buf.WriteTo(os.Stdout)
+	return err
+}
+
+// exportOptions selects the messages matching the preset, if any. The preset's
+// definition is included in the export, so it means the same without the config it
+// came from.
+func exportOptions(stats *monitor.SessionStats, preset *config.FilterPreset) export.Options {
+	opts := export.Options{Cost: pricing.MessageCost, Preset: preset}
+	if preset != nil {
+		opts.Filter = "preset " + preset.Name
+		opts.Include = func(i int) bool { return preset.Match.Match(&stats.MessageHistory[i]) }
+	}
+	return opts
+}
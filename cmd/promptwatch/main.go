@@ -1,29 +1,146 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 	"text/tabwriter"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/demo"
 	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/state"
 	"github.com/thieso2/promptwatch/internal/ui"
+	"github.com/thieso2/promptwatch/internal/ui/render"
 )
 
 func main() {
+	// Dispatch subcommands before parsing the global flags
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := runReport(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "digest" {
+		if err := runDigest(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		if err := runDoctor(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "profile" {
+		if err := runProfile(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Parse CLI flags
 	interval := flag.Duration("interval", 1*time.Second, "Refresh interval")
 	showHelpers := flag.Bool("show-helpers", false, "Show MCP helper processes")
 	processMode := flag.Bool("p", false, "Show processes (CLI mode)")
 	sessionsDir := flag.String("d", "", "Show sessions for directory (CLI mode)")
 	inspectFile := flag.String("i", "", "Inspect session file (CLI mode)")
+	confirmQuit := flag.Bool("confirm-quit", false, "Ask before quitting while a session is still loading")
+	debug := flag.Bool("debug", false, "Write debug log to ~/.cache/promptwatch/debug.log")
+	demoMode := flag.Bool("demo", false, "Run against bundled demo data instead of ~/.claude and live processes")
+	demoSpeed := flag.Float64("demo-speed", 10, "Replay speed of the live demo session (with -demo)")
+	projectsDir := flag.String("projects-dir", "", "Read Claude sessions from this directory instead of ~/.claude/projects")
+	verboseProcesses := flag.Bool("verbose-processes", false, "List Claude-like processes that could not be inspected, with PIDs and errors")
+	noProcesses := flag.Bool("no-processes", false, "Skip process discovery for session-only use: start in the projects view, without the process view")
+	readOnly := flag.Bool("read-only", false, "Write no files: exports and opening files in the editor are disabled, UI state and the debug log go to a temporary directory")
+	theme := flag.String("theme", "", "Color theme: default, colorblind or high-contrast (overrides the config file)")
+	redact := flag.Bool("redact", false, "Start with prompts, replies and working directories masked for screen sharing (toggle with Z)")
+	view := flag.String("view", "", "View to start in: processes, projects or recent (overrides the config file and the last run)")
 	flag.Parse()
 
+	// Read-only mode keeps every write in a temporary directory, removed on exit
+	// unless it holds a debug log
+	var readOnlyDir string
+	if *readOnly || readOnlyConfigured() {
+		*readOnly = true
+		dir, err := os.MkdirTemp("", "promptwatch-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		readOnlyDir = dir
+		if !*debug {
+			defer os.RemoveAll(readOnlyDir)
+		}
+	}
+
+	var logger *slog.Logger
+	var logPath string
+	if *debug {
+		path, err := debugLogPath()
+		if readOnlyDir != "" {
+			path, err = filepath.Join(readOnlyDir, "debug.log"), nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		l, logFile, err := setupDebugLog(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
+		logger = l
+		logPath = logFile.Name()
+		monitor.SetLogger(logger)
+	}
+
+	if *projectsDir != "" {
+		monitor.SetProjectsDir(*projectsDir)
+	}
+
+	if *demoMode {
+		d, err := demo.Start(context.Background(), *demoSpeed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Remove the extracted demo data however main ends, also through exit
+		cleanups = append(cleanups, func() { d.Close() })
+		defer cleanup()
+		monitor.SetProjectsDir(d.ProjectsDir())
+		monitor.SetProcessProvider(d.Provider())
+	}
+
 	// Handle CLI modes
+	if *processMode && *noProcesses {
+		fmt.Fprintln(os.Stderr, "Error: -p cannot be used with -no-processes")
+		exit(1)
+	}
 	if *processMode {
-		cliShowProcesses(*showHelpers)
+		cliShowProcesses(*showHelpers, *verboseProcesses)
 		return
 	}
 
@@ -37,23 +154,139 @@ func main() {
 		return
 	}
 
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	if *demoMode {
+		// Demos look the same on every machine and leave no state behind
+		cfg = config.Default()
+	}
+	cfg.ReadOnly = *readOnly
+	cfg.NoProcesses = cfg.NoProcesses || *noProcesses
+	if *theme != "" {
+		if !slices.Contains(config.Themes, *theme) {
+			fmt.Fprintf(os.Stderr, "Error: -theme must be one of %q, got %q\n", config.Themes, *theme)
+			exit(1)
+		}
+		cfg.Theme = *theme
+	}
+	if *view != "" && !slices.Contains(config.Views, *view) {
+		fmt.Fprintf(os.Stderr, "Error: -view must be one of %q, got %q\n", config.Views, *view)
+		exit(1)
+	}
+	if *view == "processes" && cfg.NoProcesses {
+		fmt.Fprintln(os.Stderr, "Error: -view processes cannot be used without process discovery (-no-processes or noProcesses in the config file)")
+		exit(1)
+	}
+	render.SetTheme(cfg.Theme)
+	render.SetNumberFormat(cfg.Numbers, cfg.Cost)
+	monitor.SetContextWindows(cfg.Context.Windows)
+	monitor.SetRefusalPatterns(cfg.Refusals.Patterns)
+	pricing.SetOverrides(cfg.Pricing.Overrides())
+
+	// Restore the UI state of the previous run; an -interval flag wins over the saved interval
+	statePath, err := state.DefaultPath()
+	if *demoMode {
+		statePath, err = "", errors.New("state is not used in demo mode")
+	}
+	saved := &state.State{}
+	if err == nil {
+		// An unreadable or corrupt state file starts from defaults; the next save replaces it
+		saved, err = state.Load(statePath)
+		if err != nil && logger != nil {
+			logger.Warn("cannot load state, using defaults", "path", statePath, "err", err)
+		}
+	}
+	if saved.RefreshInterval > 0 && !flagWasSet("interval") {
+		*interval = time.Duration(saved.RefreshInterval)
+	}
+	// The start view comes from the flag, else the config file, else the last run
+	if *view == "" {
+		*view = cfg.DefaultView
+	}
+	if *view == "" && slices.Contains(config.Views, saved.View) {
+		*view = saved.View
+	}
+	if readOnlyDir != "" && statePath != "" {
+		// Start from the saved state but keep changes to this run
+		statePath = filepath.Join(readOnlyDir, "state.json")
+	}
+
+	// Sessions opened are recorded next to the state, for the recently viewed list
+	historyPath := ""
+	if statePath != "" {
+		historyPath = state.HistoryPath(statePath)
+	}
+
+	// Pick up projects and sessions as they are created; without a watcher they show
+	// up the next time a list is opened
+	watcher, err := monitor.NewWatcher(300 * time.Millisecond)
+	if err != nil && logger != nil {
+		logger.Warn("cannot watch the projects directory", "err", err)
+	}
+
 	// Run TUI mode
-	model := ui.NewModel(*interval, *showHelpers)
+	model := ui.NewModel(*interval, *showHelpers).
+		WithConfig(cfg).
+		WithStateFile(statePath).
+		WithHistoryFile(historyPath).
+		WithStartView(*view).
+		WithCompactHeader(saved.CompactHeader).
+		WithColumnShares(saved.ColumnShares).
+		WithQuitConfirmation(*confirmQuit).
+		WithVerboseProcesses(*verboseProcesses).
+		WithRedaction(*redact).
+		WithDebugLog(logger, logPath).
+		WithWatcher(watcher)
 	program := tea.NewProgram(model, tea.WithAltScreen())
 
-	if _, err := program.Run(); err != nil {
+	finalModel, err := program.Run()
+	// Stop background work however the program ended (quit key, signal or error)
+	if m, ok := finalModel.(ui.Model); ok {
+		m.Shutdown()
+	} else {
+		model.Shutdown()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+}
+
+// printDiscoveryReport tells the user about Claude-like processes that could not be inspected
+func printDiscoveryReport(report monitor.DiscoveryReport, verbose bool) {
+	summary := report.Summary()
+	if summary == "" {
+		return
+	}
+	if !verbose {
+		fmt.Fprintf(os.Stderr, "\n%s (use --verbose-processes for details)\n", summary)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n%s:\n", summary)
+	for _, s := range report.Skipped {
+		fmt.Fprintf(os.Stderr, "  PID %d: %s (%v)\n", s.PID, s.Reason, s.Err)
 	}
 }
 
 // cliShowProcesses displays all Claude processes in CLI mode
-func cliShowProcesses(showHelpers bool) {
-	processes, err := monitor.FindClaudeProcesses(showHelpers)
+func cliShowProcesses(showHelpers, verbose bool) {
+	cfg, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
+	processes, report, err := monitor.FindClaudeProcesses(monitor.DiscoveryOptions{
+		ShowHelpers:     showHelpers,
+		ShowSessionless: cfg.Processes.ShowSessionless,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	defer printDiscoveryReport(report, verbose)
 
 	if len(processes) == 0 {
 		fmt.Println("No Claude processes found")
@@ -65,12 +298,19 @@ func cliShowProcesses(showHelpers bool) {
 	fmt.Fprintln(w, "---\t----\t---\t------\t-------\t-------")
 
 	for _, proc := range processes {
+		workDir := proc.WorkingDir
+		switch {
+		case proc.WorkDirGone:
+			workDir = "[gone: " + workDir + "]"
+		case proc.NoSessions:
+			workDir += " (no sessions yet)"
+		}
 		fmt.Fprintf(w, "%d\t%.1f%%\t%.2fM\t%v\t%s\t%s\n",
 			proc.PID,
 			proc.CPUPercent,
 			proc.MemoryMB,
 			proc.Uptime,
-			proc.WorkingDir,
+			workDir,
 			truncateCmd(proc.Command, 50),
 		)
 	}
@@ -82,7 +322,7 @@ func cliShowSessions(dir string) {
 	sessions, err := monitor.FindSessionsForDirectory(dir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error finding sessions: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	if len(sessions) == 0 {
@@ -112,7 +352,7 @@ func cliInspectSession(filePath string) {
 	stats, err := monitor.ParseSessionFile(filePath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing session: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	fmt.Println("=== SESSION DETAILS ===")
@@ -150,10 +390,7 @@ func cliInspectSession(filePath string) {
 				role = "🤖 assistant"
 			}
 
-			content := msg.Content
-			if len(content) > 100 {
-				content = content[:97] + "..."
-			}
+			content := truncateCmd(msg.Content, 100)
 			// Replace newlines for display
 			for j := 0; j < len(content); j++ {
 				if content[j] == '\n' {
@@ -166,9 +403,56 @@ func cliInspectSession(filePath string) {
 	}
 }
 
+// cleanups run when main ends, in reverse order; exit runs them too
+var cleanups []func()
+
+// cleanup runs and clears the registered cleanups
+func cleanup() {
+	for len(cleanups) > 0 {
+		f := cleanups[len(cleanups)-1]
+		cleanups = cleanups[:len(cleanups)-1]
+		f()
+	}
+}
+
+// exit runs the cleanups, which os.Exit would skip along with deferred calls, and exits
+// with code
+func exit(code int) {
+	cleanup()
+	os.Exit(code)
+}
+
+// loadConfig loads the user config file, falling back to defaults when it doesn't exist
+func loadConfig() (*config.Config, error) {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return config.Default(), nil
+	}
+	return config.Load(path)
+}
+
+// readOnlyConfigured reports whether the config file turns on read-only mode. A config
+// file that cannot be loaded counts as no; the error is reported where it is used.
+func readOnlyConfigured() bool {
+	cfg, err := loadConfig()
+	return err == nil && cfg.ReadOnly
+}
+
+// flagWasSet reports whether the named flag was given on the command line
+func flagWasSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func truncateCmd(cmd string, maxLen int) string {
-	if len(cmd) <= maxLen {
+	runes := []rune(cmd)
+	if len(runes) <= maxLen {
 		return cmd
 	}
-	return cmd[:maxLen-3] + "..."
+	return string(runes[:maxLen-3]) + "..."
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/profile"
+	"github.com/thieso2/promptwatch/internal/state"
+)
+
+// runProfile implements the "profile" subcommand, carrying theme, filter presets and
+// UI state between machines
+func runProfile(args []string) error {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: promptwatch profile export > profile.json")
+		fmt.Fprintf(os.Stderr, "       promptwatch profile import [-replace %s] [-read-only] profile.json\n", strings.Join(profile.Sections, ","))
+	}
+	if len(args) == 0 {
+		usage()
+		return fmt.Errorf("expected export or import")
+	}
+
+	configPath, err := config.DefaultPath()
+	if err != nil {
+		return err
+	}
+	statePath, err := state.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "export":
+		p, err := profile.Export(configPath, statePath)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+
+	case "import":
+		fs := flag.NewFlagSet("profile import", flag.ExitOnError)
+		replace := fs.String("replace", "", "Comma-separated sections to replace rather than merge: "+strings.Join(profile.Sections, ", "))
+		readOnly := fs.Bool("read-only", false, "Refuse to import (also set by readOnly in the config), e.g. to guard scripts run on shared machines")
+		fs.Usage = func() {
+			usage()
+			fs.PrintDefaults()
+		}
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return fmt.Errorf("expected exactly one profile file")
+		}
+		if *readOnly || readOnlyConfigured() {
+			return fmt.Errorf("read-only mode: not importing a profile")
+		}
+		var sections []string
+		if *replace != "" {
+			sections = strings.Split(*replace, ",")
+		}
+		p, err := profile.Read(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		report, err := profile.Import(p, configPath, statePath, sections)
+		if err != nil {
+			return err
+		}
+		if len(report) == 0 {
+			fmt.Println("Nothing to import: the settings already match")
+		}
+		for _, line := range report {
+			fmt.Println(line)
+		}
+		return nil
+	}
+	usage()
+	return fmt.Errorf("unknown profile command %q (want export or import)", args[0])
+}
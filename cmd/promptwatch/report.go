@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// reportRow is a single session line in the projects report
+type reportRow struct {
+	project     string // Original project path if known, else the encoded directory
+	projectDir  string // Encoded directory under ~/.claude/projects
+	projectPath string // Original project path from sessions-index.json, if any
+	session     string
+	metadata    *monitor.SessionMetadata
+}
+
+// runReport implements the "report" subcommand, listing sessions across all projects
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	project := fs.String("project", "", "Only include sessions for this project path")
+	model := fs.String("model", "", "Only include sessions that used a model matching this substring (e.g. opus)")
+	onlyBypass := fs.Bool("only-bypass", false, "Only include sessions that ran in bypassPermissions mode, with their working directories")
+	format := fs.String("format", "table", "Output format: table, or csv or json with one record per session for further analysis")
+	duplicates := fs.Bool("duplicates", false, "List the sessions found in more than one project directory, e.g. after a repository was copied, instead of the report")
+	fs.Parse(args)
+	if *format != "table" && *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want table, csv or json)", *format)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	render.SetNumberFormat(cfg.Numbers, cfg.Cost)
+	pricing.SetOverrides(cfg.Pricing.Overrides())
+
+	projectsDir, err := monitor.ProjectsDir()
+	if err != nil {
+		return err
+	}
+
+	// Copies of a session in other project directories are counted once, from the
+	// copy written last, unless the report is for a single project
+	dups, err := monitor.FindAllDuplicates()
+	if err != nil {
+		return err
+	}
+	if *duplicates {
+		return printDuplicates(os.Stdout, dups)
+	}
+
+	entries, err := os.ReadDir(projectsDir)
+	if err != nil {
+		return fmt.Errorf("cannot read projects directory: %w", err)
+	}
+
+	var rows []reportRow
+	var projectDirs []string // Directories matching -project, for the stats summary
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dirPath := filepath.Join(projectsDir, entry.Name())
+
+		// Prefer the original project path from the index over the encoded directory name
+		projectPath, originalPath := entry.Name(), ""
+		if index, err := monitor.ParseSessionIndex(filepath.Join(dirPath, "sessions-index.json")); err == nil && index.OriginalPath != "" {
+			projectPath, originalPath = index.OriginalPath, index.OriginalPath
+		}
+		if *project != "" && filepath.Clean(*project) != filepath.Clean(projectPath) {
+			continue
+		}
+		if *project != "" {
+			projectDirs = append(projectDirs, dirPath)
+		}
+
+		files, err := os.ReadDir(dirPath)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() || !monitor.IsSessionFile(file.Name()) {
+				continue
+			}
+			path := filepath.Join(dirPath, file.Name())
+			if *project == "" && dups.Dropped(path) {
+				continue
+			}
+			metadata, err := monitor.GetSessionMetadata(path)
+			if err != nil {
+				continue
+			}
+			if !monitor.MatchesModel(metadata.Models, *model) {
+				continue
+			}
+			if *onlyBypass && !monitor.RanInBypassMode(metadata.PermissionModes) {
+				continue
+			}
+			rows = append(rows, reportRow{
+				project:     projectPath,
+				projectDir:  entry.Name(),
+				projectPath: originalPath,
+				session:     monitor.SessionFileID(file.Name()),
+				metadata:    metadata,
+			})
+		}
+	}
+
+	// Newest sessions first
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].metadata.Started.After(rows[j].metadata.Started)
+	})
+	switch *format {
+	case "csv":
+		return writeReportCSV(os.Stdout, rows)
+	case "json":
+		return writeReportJSON(os.Stdout, rows)
+	}
+
+	// A single project also gets the summary of the project stats view
+	for _, dir := range projectDirs {
+		if err := printProjectStats(*project, dir); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No sessions found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header, rule := "PROJECT\tSESSION\tSTARTED\tLEN\tMODEL\tMODE\tPROMPTS\tREWARM\tTOKENS IN/CACHE WRITE/CACHE READ/OUT", "-------\t-------\t-------\t---\t-----\t----\t-------\t------\t-----------------------------------"
+	if *onlyBypass {
+		header, rule = header+"\tWORKDIR", rule+"\t-------"
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, rule)
+
+	bypass, outside, rewarmed := 0, 0, 0
+	rewarmTotal := 0.0
+	for _, row := range rows {
+		md := row.metadata
+		started := "-"
+		if !md.Started.IsZero() {
+			started = md.Started.Format("2006-01-02 15:04")
+		}
+		modelLabel := monitor.ModelLabel(md.Models)
+		if modelLabel == "" {
+			modelLabel = "-"
+		}
+		mode := md.PermissionMode
+		if mode == "" {
+			mode = "-"
+		}
+		if monitor.RanInBypassMode(md.PermissionModes) {
+			bypass++
+		}
+		if len(md.OutsideWrites) > 0 {
+			outside++
+		}
+		rewarm := "-"
+		if len(md.Rewarms) > 0 {
+			cost := rewarmCost(md)
+			rewarm = render.FormatCost(cost, render.TotalPrecision)
+			if cfg.Cost.Hidden {
+				rewarm = strconv.Itoa(len(md.Rewarms))
+			}
+			rewarmed++
+			rewarmTotal += cost
+		}
+		sessionID := row.session
+		if len(sessionID) > 8 {
+			sessionID = sessionID[:8]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s/%s/%s/%s",
+			truncateCmd(row.project, 50),
+			sessionID,
+			started,
+			md.Duration.Round(time.Second),
+			modelLabel,
+			mode,
+			md.UserPrompts,
+			rewarm,
+			render.FormatTokenCount(md.Tokens.Input),
+			render.FormatTokenCount(md.Tokens.CacheWrite),
+			render.FormatTokenCount(md.Tokens.CacheRead),
+			render.FormatTokenCount(md.Tokens.Output),
+		)
+		if *onlyBypass {
+			workdir := md.WorkingDir
+			if workdir == "" {
+				workdir = "-"
+			}
+			fmt.Fprintf(w, "\t%s", workdir)
+		}
+		fmt.Fprintln(w)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var notes []string
+	if bypass > 0 && !*onlyBypass {
+		notes = append(notes, fmt.Sprintf("%d of %d sessions ran in bypassPermissions mode (list them with -only-bypass)", bypass, len(rows)))
+	}
+	if outside > 0 {
+		notes = append(notes, fmt.Sprintf("%d of %d sessions wrote files outside their working directory", outside, len(rows)))
+	}
+	if rewarmed > 0 && !cfg.Cost.Hidden {
+		notes = append(notes, fmt.Sprintf("Interruptions cost ≈ %s in cache rewrites across %d of %d sessions (REWARM)",
+			render.FormatCost(rewarmTotal, render.TotalPrecision), rewarmed, len(rows)))
+	}
+	if len(dups) > 0 && *project == "" {
+		notes = append(notes, fmt.Sprintf("%d sessions copied between project directories were counted once (list them with -duplicates)", len(dups)))
+	}
+	if len(notes) > 0 {
+		fmt.Printf("\n%s\n", strings.Join(notes, "\n"))
+	}
+	return nil
+}
+
+// printDuplicates writes the sessions found in more than one project directory, with
+// the copy the report counts and the copies it leaves out
+func printDuplicates(out io.Writer, dups monitor.Duplicates) error {
+	if len(dups) == 0 {
+		fmt.Fprintln(out, "No duplicate sessions found")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tCREATED\tKEPT\tCOPIES")
+	fmt.Fprintln(w, "-------\t-------\t----\t------")
+	for _, dup := range dups {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			dup.ID,
+			dup.Created.Local().Format("2006-01-02 15:04"),
+			dup.Kept,
+			strings.Join(dup.Copies, ", "),
+		)
+	}
+	return w.Flush()
+}
+
+// reportRecord is a session of the report in the CSV and JSON formats
+type reportRecord struct {
+	Project          string    `json:"project"`               // projectPath if known, else projectDir
+	ProjectDir       string    `json:"projectDir"`            // Encoded directory under ~/.claude/projects
+	ProjectPath      string    `json:"projectPath,omitempty"` // Original project path from sessions-index.json
+	Session          string    `json:"session"`
+	Started          time.Time `json:"started"`
+	DurationSeconds  int       `json:"durationSeconds"`
+	Models           []string  `json:"models"`
+	PermissionMode   string    `json:"permissionMode"`
+	Prompts          int       `json:"prompts"`
+	InputTokens      int       `json:"inputTokens"` // Fresh input, excluding cache writes and reads
+	CacheWriteTokens int       `json:"cacheWriteTokens"`
+	CacheReadTokens  int       `json:"cacheReadTokens"`
+	OutputTokens     int       `json:"outputTokens"`
+	OutputPerPrompt  float64   `json:"outputPerPrompt"` // Output tokens per prompt, excluding tool results
+	ContextPerTurn   float64   `json:"contextPerTurn"`  // Input context tokens per prompt-started turn
+	CacheRewarms     int       `json:"cacheRewarms"`    // Responses rewriting the prompt cache after gaps
+	RewarmCost       float64   `json:"rewarmCost"`      // What the rewrites cost over reading the cache, in USD
+}
+
+// newReportRecord collects the report columns of a session
+func newReportRecord(row reportRow) reportRecord {
+	md := row.metadata
+	return reportRecord{
+		Project:          row.project,
+		ProjectDir:       row.projectDir,
+		ProjectPath:      row.projectPath,
+		Session:          row.session,
+		Started:          md.Started,
+		DurationSeconds:  int(md.Duration.Seconds()),
+		Models:           md.Models,
+		PermissionMode:   md.PermissionMode,
+		Prompts:          md.UserPrompts,
+		InputTokens:      md.Tokens.Input,
+		CacheWriteTokens: md.Tokens.CacheWrite,
+		CacheReadTokens:  md.Tokens.CacheRead,
+		OutputTokens:     md.Tokens.Output,
+		OutputPerPrompt:  md.OutputPerPrompt,
+		ContextPerTurn:   md.ContextPerTurn,
+		CacheRewarms:     len(md.Rewarms),
+		RewarmCost:       rewarmCost(md),
+	}
+}
+
+// rewarmCost returns what a session's cache re-warms after gaps cost
+func rewarmCost(md *monitor.SessionMetadata) float64 {
+	cost := 0.0
+	for _, r := range md.Rewarms {
+		cost += pricing.RewarmCost(r)
+	}
+	return cost
+}
+
+// writeReportJSON writes the report as a JSON array with one object per session
+func writeReportJSON(w io.Writer, rows []reportRow) error {
+	records := make([]reportRecord, len(rows))
+	for i, row := range rows {
+		records[i] = newReportRecord(row)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// writeReportCSV writes the report as CSV with a header row and one row per session;
+// models are separated by spaces
+func writeReportCSV(w io.Writer, rows []reportRow) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"project", "projectDir", "projectPath", "session", "started", "durationSeconds", "models", "permissionMode",
+		"prompts", "inputTokens", "cacheWriteTokens", "cacheReadTokens", "outputTokens", "outputPerPrompt", "contextPerTurn",
+		"cacheRewarms", "rewarmCost"})
+	for _, row := range rows {
+		r := newReportRecord(row)
+		out.Write([]string{
+			r.Project,
+			r.ProjectDir,
+			r.ProjectPath,
+			r.Session,
+			r.Started.Format(time.RFC3339),
+			strconv.Itoa(r.DurationSeconds),
+			strings.Join(r.Models, " "),
+			r.PermissionMode,
+			strconv.Itoa(r.Prompts),
+			strconv.Itoa(r.InputTokens),
+			strconv.Itoa(r.CacheWriteTokens),
+			strconv.Itoa(r.CacheReadTokens),
+			strconv.Itoa(r.OutputTokens),
+			strconv.FormatFloat(r.OutputPerPrompt, 'f', 1, 64),
+			strconv.FormatFloat(r.ContextPerTurn, 'f', 1, 64),
+			strconv.Itoa(r.CacheRewarms),
+			strconv.FormatFloat(r.RewarmCost, 'f', 4, 64),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// printProjectStats prints the dashboard of the TUI's project stats view for a project
+// directory
+func printProjectStats(name, dir string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	pricing.SetOverrides(cfg.Pricing.Overrides())
+	render.SetTheme(cfg.Theme)
+	render.SetNumberFormat(cfg.Numbers, cfg.Cost)
+
+	// Count the responses priced at rates that have changed since
+	historical := 0
+	cost := func(msg *monitor.Message) float64 {
+		if _, old := pricing.Lookup(msg.Model, msg.Timestamp); old && msg.Type == "assistant_response" {
+			historical++
+		}
+		return pricing.MessageCost(msg)
+	}
+	stats, err := monitor.ScanProject(context.Background(), dir, cost, nil)
+	if err != nil {
+		return err
+	}
+	dashboard := render.ProjectStats(render.ProjectStatsData{Name: name, Dir: monitor.ShortenHomePath(dir), Stats: stats}, cfg.Cost)
+	for _, line := range strings.Split(dashboard, "\n") {
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	if historical > 0 && !cfg.Cost.Hidden {
+		responses := "responses"
+		if historical == 1 {
+			responses = "response"
+		}
+		fmt.Printf("Historical rates: %d %s priced at the rates in effect when sent (price table %s)\n",
+			historical, responses, pricing.Version())
+	}
+	return nil
+}
@@ -0,0 +1,521 @@
+// Package config loads the optional user configuration file
+// (~/.config/promptwatch/config.json). Every setting has a default, so the
+// file only needs to contain the values being changed.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+)
+
+// Config is the user configuration
+type Config struct {
+	Cost      CostConfig      `json:"cost"`
+	Context   ContextConfig   `json:"context"`
+	Recent    RecentConfig    `json:"recent"`
+	Detail    DetailConfig    `json:"detail"`
+	Cards     CardsConfig     `json:"cards"`
+	Summary   SummaryConfig   `json:"summary"`
+	Processes ProcessesConfig `json:"processes"`
+	Filters   FiltersConfig   `json:"filters"`
+	Export    ExportConfig    `json:"export"`
+	Sessions  SessionsConfig  `json:"sessions"`
+	Pricing   PricingConfig   `json:"pricing"`
+	Numbers   NumbersConfig   `json:"numbers"`
+	Refusals  RefusalsConfig  `json:"refusals"`
+	// Theme names the color theme of the views, one of Themes
+	Theme string `json:"theme"`
+	// ReadOnly disables everything that writes files, e.g. to browse someone else's
+	// session archive; UI state and logs go to a temporary directory instead
+	ReadOnly bool `json:"readOnly"`
+	// NoProcesses skips process discovery for session-only use: promptwatch starts in
+	// the projects view and offers no process view
+	NoProcesses bool `json:"noProcesses"`
+	// DefaultView names the view promptwatch starts in, one of Views; "" starts in the
+	// view of the previous run
+	DefaultView string `json:"defaultView"`
+}
+
+// Views are the views promptwatch can start in: the running Claude processes, the
+// projects under ~/.claude/projects, or the sessions of all projects from the last
+// recent.days days
+var Views = []string{"processes", "projects", "recent"}
+
+// Themes are the color themes: "default"; "colorblind", telling levels apart by blue
+// and orange rather than green and red; and "high-contrast", in bright colors. Both
+// accessible themes add glyphs wherever color alone would carry meaning.
+var Themes = []string{"default", "colorblind", "high-contrast"}
+
+// TokenFormats are the ways token counts can be written: "si" with suffixes, e.g.
+// "1.4M" and "212k", or "grouped" in full with separated digit groups, e.g. "1,432,191"
+var TokenFormats = []string{"si", "grouped"}
+
+// NumbersConfig controls how token counts are written in every view and report
+type NumbersConfig struct {
+	Tokens    string `json:"tokens"`    // One of TokenFormats
+	Separator string `json:"separator"` // Digit group separator of "grouped", e.g. "." or a thin space "\u2009"
+}
+
+// RefusalsConfig controls which assistant responses are flagged as refusals. Responses
+// the API stopped with the refusal stop_reason always are.
+type RefusalsConfig struct {
+	// Patterns are regular expressions (Go syntax) matched against the text of each
+	// response; one match flags it. The defaults (monitor.DefaultRefusalPatterns) only
+	// catch responses opening with an outright decline; [] turns matching off.
+	Patterns []string `json:"patterns"`
+}
+
+// SessionsConfig controls the session list
+type SessionsConfig struct {
+	// TitleFrom lists where session titles come from, in order of preference: "summary"
+	// (the conversation title Claude writes), "prompt" (the first prompt) and "id"
+	TitleFrom []string `json:"titleFrom"`
+	// ShowChanges adds FILES and LINES columns with the files each session's tool calls
+	// wrote and the lines they added and removed, approximately
+	ShowChanges bool `json:"showChanges"`
+}
+
+// ExportConfig controls exports written from the session detail view
+type ExportConfig struct {
+	// Format names a registered export format or one of its aliases, e.g. "markdown"
+	// or "md"; see SetExportFormatCheck
+	Format string `json:"format"`
+	// Dir is where exports are written; empty means the current directory
+	Dir string `json:"dir"`
+}
+
+// checkExportFormat validates export.format; nil accepts any format
+var checkExportFormat func(name string) error
+
+// SetExportFormatCheck installs the check of export.format against the registered
+// export formats. The export package installs it from init, as config cannot import
+// the format registry without an import cycle.
+func SetExportFormatCheck(check func(name string) error) {
+	checkExportFormat = check
+}
+
+// FiltersConfig holds the message filter presets of the session detail view
+type FiltersConfig struct {
+	// Presets are applied with <n>f, n being the 1-based position in the list
+	Presets []FilterPreset `json:"presets"`
+}
+
+// MaxFilterPresets is how many presets the number keys can reach
+const MaxFilterPresets = 9
+
+// FilterPreset is a named message filter, e.g.
+// {"name": "errors only", "match": {"isError": true, "contains": "Error"}}
+type FilterPreset struct {
+	Name  string    `json:"name"`
+	Match Predicate `json:"match"`
+}
+
+// Predicate selects session messages. Every criterion that is set must hold; Any and
+// Not combine predicates, so {"any": [{"tool": "Edit"}, {"tool": "Write"}]} selects edits
+// of either kind. The zero Predicate matches every message.
+type Predicate struct {
+	Role     string      `json:"role,omitempty"`     // "user" (prompts) or "assistant" (responses and tool results)
+	Tool     string      `json:"tool,omitempty"`     // Name of the tool called, e.g. "Bash"; "*" for any tool
+	IsError  bool        `json:"isError,omitempty"`  // Only tool results reported as errors
+	Contains string      `json:"contains,omitempty"` // Case-insensitive text in the content or tool input
+	Any      []Predicate `json:"any,omitempty"`      // At least one of these must hold
+	Not      *Predicate  `json:"not,omitempty"`      // This must not hold
+
+	// Permission is "allowed" or "denied": only tool calls the user answered that way at a
+	// permission prompt
+	Permission string `json:"permission,omitempty"`
+	// Refusal selects only responses flagged as refusals (see RefusalsConfig)
+	Refusal bool `json:"refusal,omitempty"`
+}
+
+// validate checks the roles used anywhere in the predicate
+func (p Predicate) validate() error {
+	if p.Role != "" && p.Role != "user" && p.Role != "assistant" {
+		return fmt.Errorf("role must be \"user\" or \"assistant\", got %q", p.Role)
+	}
+	if p.Permission != "" && p.Permission != "allowed" && p.Permission != "denied" {
+		return fmt.Errorf("permission must be \"allowed\" or \"denied\", got %q", p.Permission)
+	}
+	for _, q := range p.Any {
+		if err := q.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Not != nil {
+		return p.Not.validate()
+	}
+	return nil
+}
+
+// Match reports whether a message satisfies every criterion of the predicate. Roles
+// follow the u/a filters of the session detail view: "user" selects prompts and
+// "assistant" selects responses and tool results.
+func (p Predicate) Match(msg *monitor.Message) bool {
+	switch p.Role {
+	case "user":
+		if msg.Type != "prompt" {
+			return false
+		}
+	case "assistant":
+		if msg.Type != "assistant_response" && msg.Type != "tool_result" {
+			return false
+		}
+	}
+	switch {
+	case p.Tool == "*" && msg.ToolName == "":
+		return false
+	case p.Tool != "" && p.Tool != "*" && !slices.ContainsFunc(msg.Calls(), func(c monitor.ToolCall) bool { return strings.EqualFold(p.Tool, c.Name) }):
+		return false
+	}
+	if p.IsError && !msg.IsError {
+		return false
+	}
+	if p.Permission != "" && p.Permission != msg.PermissionDecision {
+		return false
+	}
+	if p.Refusal && !msg.Refusal {
+		return false
+	}
+	if p.Contains != "" {
+		needle := strings.ToLower(p.Contains)
+		inInput := slices.ContainsFunc(msg.Calls(), func(c monitor.ToolCall) bool { return strings.Contains(strings.ToLower(c.Input), needle) })
+		if !strings.Contains(strings.ToLower(msg.Content), needle) && !inInput {
+			return false
+		}
+	}
+	if len(p.Any) > 0 && !slices.ContainsFunc(p.Any, func(q Predicate) bool { return q.Match(msg) }) {
+		return false
+	}
+	return p.Not == nil || !p.Not.Match(msg)
+}
+
+// ProcessesConfig controls which Claude processes the process view lists
+type ProcessesConfig struct {
+	// ShowSessionless also lists instances without sessions under ~/.claude/projects, e.g.
+	// freshly started ones or ones using a relocated config directory
+	ShowSessionless bool `json:"showSessionless"`
+}
+
+// SummaryConfig controls the one-line session summary copied from the session detail view
+type SummaryConfig struct {
+	// Template is a Go text/template executed with render.SummaryData
+	Template string `json:"template"`
+}
+
+// DefaultSummaryTemplate renders e.g.
+// "Session 2h13m, 96 prompts, 1.4M tokens, $7.82, branch feature/auth"
+const DefaultSummaryTemplate = "Session {{.Duration}}, {{.Prompts}} prompts, {{.Tokens}} tokens, {{.Cost}}, branch {{.Branch}}"
+
+// DetailConfig controls the message detail view
+type DetailConfig struct {
+	// FullWidth wraps message content to the terminal width instead of at most 80 columns;
+	// narrower terminals always wrap to their width
+	FullWidth bool `json:"fullWidth"`
+	// SplitMinWidth is the narrowest terminal, in columns, in which "|" shows the message
+	// list and the selected message side by side
+	SplitMinWidth int `json:"splitMinWidth"`
+}
+
+// CardsConfig controls the message cards of the session detail view
+type CardsConfig struct {
+	// Preview chooses the content line of a card, one of PreviewModes
+	Preview string `json:"preview"`
+	// SlowBelow is the output rate in tokens per second below which a response card is
+	// marked 🐢, as for turns on a slower service tier or a bad connection; 0 marks none
+	SlowBelow float64 `json:"slowBelow"`
+}
+
+// PreviewModes are the ways a card previews its message: "first" shows the text from
+// its start, "last" its end, and "smart" strips markdown and shows whole sentences from
+// the first substantive one on, skipping lead-ins such as "Sure!", or the last lines of
+// tool output
+var PreviewModes = []string{"first", "last", "smart"}
+
+// RecentConfig controls the cross-project list of recent sessions
+type RecentConfig struct {
+	// Days is how far back the list reaches, counted from the last activity of a session
+	Days int `json:"days"`
+}
+
+// ContextConfig controls context window warnings
+type ContextConfig struct {
+	// WarnAt is the fraction of the context window (0–1) above which a session is flagged
+	// as close to auto-compaction
+	WarnAt float64 `json:"warnAt"`
+	// GrowthWarnAt is the context growth in tokens from one assistant response to the
+	// next above which a message card flags the jump (e.g. a huge file read into context).
+	// Unlike WarnAt it is an absolute count, independent of the context window.
+	GrowthWarnAt int `json:"growthWarnAt"`
+	// Windows overrides context window sizes in tokens, keyed by a model ID substring
+	// (e.g. {"claude-opus-5": 500000})
+	Windows map[string]int `json:"windows"`
+}
+
+// CostConfig controls how costs are displayed
+type CostConfig struct {
+	Hidden  bool       `json:"hidden"`  // Hide all cost figures
+	Message Thresholds `json:"message"` // Coloring for a single message
+	Session Thresholds `json:"session"` // Coloring for a whole session
+	Day     Thresholds `json:"day"`     // Coloring for a day's total
+
+	// Currency is the symbol written before costs. Prices are in USD unless
+	// pricing.models sets rates in another currency.
+	Currency string `json:"currency"`
+	// Decimals is the number of decimal places of totals; costs of single messages get
+	// two more, and the message detail view four more
+	Decimals int `json:"decimals"`
+}
+
+// MaxCostDecimals is the largest cost.decimals
+const MaxCostDecimals = 6
+
+// PricingConfig adjusts the built-in token prices
+type PricingConfig struct {
+	// Models sets the prices of models whose ID contains Match from a date on, on top
+	// of the built-in table, e.g. for new models or rates that differ from list prices
+	Models []PriceOverride `json:"models"`
+}
+
+// PriceOverride is a model's prices in USD per million tokens from a date on
+type PriceOverride struct {
+	Match string `json:"match"` // Model ID substring, e.g. "claude-opus-5"
+	From  string `json:"from"`  // First day the prices apply (YYYY-MM-DD, UTC); empty for all dates
+	pricing.Rates
+}
+
+// Overrides returns the configured prices for pricing.SetOverrides
+func (p PricingConfig) Overrides() []pricing.Override {
+	var overrides []pricing.Override
+	for _, m := range p.Models {
+		from, _ := time.Parse(time.DateOnly, m.From) // Checked by validate; "" is the zero time
+		overrides = append(overrides, pricing.Override{Match: m.Match, Period: pricing.Period{From: from, Rates: m.Rates}})
+	}
+	return overrides
+}
+
+// Thresholds are the USD amounts above which a cost is shown in warning (yellow)
+// or high (red) colors; anything at or below Warn is green
+type Thresholds struct {
+	Warn float64 `json:"warn"`
+	High float64 `json:"high"`
+}
+
+// Level classifies a cost against the thresholds
+type Level int
+
+const (
+	LevelLow Level = iota
+	LevelWarn
+	LevelHigh
+)
+
+// Days returns the day thresholds scaled to a total over n days, so that spending a
+// day's warning amount every day of a range colors its total like that of one such day
+func (c CostConfig) Days(n int) Thresholds {
+	n = max(n, 1)
+	return Thresholds{Warn: c.Day.Warn * float64(n), High: c.Day.High * float64(n)}
+}
+
+// Level returns how a cost compares to the thresholds
+func (t Thresholds) Level(cost float64) Level {
+	switch {
+	case cost > t.High:
+		return LevelHigh
+	case cost > t.Warn:
+		return LevelWarn
+	default:
+		return LevelLow
+	}
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Cost: CostConfig{
+			Message:  Thresholds{Warn: 0.01, High: 0.10},
+			Session:  Thresholds{Warn: 1, High: 10},
+			Day:      Thresholds{Warn: 10, High: 50},
+			Currency: "$",
+			Decimals: 2,
+		},
+		Context: ContextConfig{
+			WarnAt:       0.8,
+			GrowthWarnAt: 20_000,
+		},
+		Detail: DetailConfig{
+			SplitMinWidth: 160,
+		},
+		Cards: CardsConfig{
+			Preview:   "smart",
+			SlowBelow: 20,
+		},
+		Recent: RecentConfig{
+			Days: 7,
+		},
+		Summary: SummaryConfig{
+			Template: DefaultSummaryTemplate,
+		},
+		Export: ExportConfig{
+			Format: "markdown",
+		},
+		Sessions: SessionsConfig{
+			TitleFrom: []string{"summary", "prompt", "id"},
+		},
+		Numbers: NumbersConfig{
+			Tokens:    "si",
+			Separator: ",",
+		},
+		Refusals: RefusalsConfig{
+			Patterns: slices.Clone(monitor.DefaultRefusalPatterns),
+		},
+		Theme: "default",
+	}
+}
+
+// DefaultPath returns the location of the config file (~/.config/promptwatch/config.json)
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot get home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "promptwatch", "config.json"), nil
+}
+
+// Load reads the config file at path on top of the defaults
+// A missing file is not an error and yields the defaults.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %w", err)
+	}
+	return Parse(data, path)
+}
+
+// Parse reads the contents of a config file on top of the defaults and validates the
+// result; name identifies the file in errors
+func Parse(data []byte, name string) (*Config, error) {
+	cfg := Default()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse config file %s: %w", name, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// validate checks that settings are consistent
+func (c *Config) validate() error {
+	for name, t := range map[string]Thresholds{
+		"cost.message": c.Cost.Message,
+		"cost.session": c.Cost.Session,
+		"cost.day":     c.Cost.Day,
+	} {
+		if t.Warn < 0 || t.High < t.Warn {
+			return fmt.Errorf("%s: need 0 <= warn <= high, got warn=%g high=%g", name, t.Warn, t.High)
+		}
+	}
+	if c.Cost.Decimals < 0 || c.Cost.Decimals > MaxCostDecimals {
+		return fmt.Errorf("cost.decimals: must be between 0 and %d, got %d", MaxCostDecimals, c.Cost.Decimals)
+	}
+	if !slices.Contains(TokenFormats, c.Numbers.Tokens) {
+		return fmt.Errorf("numbers.tokens: must be one of %q, got %q", TokenFormats, c.Numbers.Tokens)
+	}
+	if c.Context.WarnAt <= 0 || c.Context.WarnAt > 1 {
+		return fmt.Errorf("context.warnAt: must be between 0 and 1, got %g", c.Context.WarnAt)
+	}
+	if c.Context.GrowthWarnAt < 1 {
+		return fmt.Errorf("context.growthWarnAt: must be at least 1, got %d", c.Context.GrowthWarnAt)
+	}
+	if c.Detail.SplitMinWidth < 80 {
+		return fmt.Errorf("detail.splitMinWidth: must be at least 80, got %d", c.Detail.SplitMinWidth)
+	}
+	if c.Recent.Days < 1 {
+		return fmt.Errorf("recent.days: must be at least 1, got %d", c.Recent.Days)
+	}
+	if _, err := template.New("summary").Parse(c.Summary.Template); err != nil {
+		return fmt.Errorf("summary.template: %w", err)
+	}
+	if len(c.Filters.Presets) > MaxFilterPresets {
+		return fmt.Errorf("filters.presets: at most %d presets, got %d", MaxFilterPresets, len(c.Filters.Presets))
+	}
+	for i, preset := range c.Filters.Presets {
+		if preset.Name == "" {
+			return fmt.Errorf("filters.presets[%d]: name is required", i)
+		}
+		if err := preset.Match.validate(); err != nil {
+			return fmt.Errorf("filters.presets[%d] (%s): %w", i, preset.Name, err)
+		}
+	}
+	if len(c.Sessions.TitleFrom) == 0 {
+		return fmt.Errorf("sessions.titleFrom: name at least one source")
+	}
+	for _, source := range c.Sessions.TitleFrom {
+		if source != "summary" && source != "prompt" && source != "id" {
+			return fmt.Errorf("sessions.titleFrom: unknown source %q (want \"summary\", \"prompt\" or \"id\")", source)
+		}
+	}
+	for i, m := range c.Pricing.Models {
+		if m.Match == "" {
+			return fmt.Errorf("pricing.models[%d]: match is required", i)
+		}
+		if m.From != "" {
+			if _, err := time.Parse(time.DateOnly, m.From); err != nil {
+				return fmt.Errorf("pricing.models[%d] (%s): from must be a date like 2026-01-31, got %q", i, m.Match, m.From)
+			}
+		}
+		if m.Input < 0 || m.Output < 0 || m.CacheWrite < 0 || m.CacheRead < 0 {
+			return fmt.Errorf("pricing.models[%d] (%s): prices cannot be negative", i, m.Match)
+		}
+	}
+	for i, p := range c.Refusals.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("refusals.patterns[%d]: %w", i, err)
+		}
+	}
+	if !slices.Contains(Themes, c.Theme) {
+		return fmt.Errorf("theme: must be one of %q, got %q", Themes, c.Theme)
+	}
+	if !slices.Contains(PreviewModes, c.Cards.Preview) {
+		return fmt.Errorf("cards.preview: must be one of %q, got %q", PreviewModes, c.Cards.Preview)
+	}
+	if c.Cards.SlowBelow < 0 {
+		return fmt.Errorf("cards.slowBelow: cannot be negative, got %g", c.Cards.SlowBelow)
+	}
+	if c.DefaultView != "" && !slices.Contains(Views, c.DefaultView) {
+		return fmt.Errorf("defaultView: must be one of %q, got %q", Views, c.DefaultView)
+	}
+	if c.NoProcesses && c.DefaultView == "processes" {
+		return fmt.Errorf("defaultView: cannot be %q with noProcesses", c.DefaultView)
+	}
+	if checkExportFormat != nil {
+		if err := checkExportFormat(c.Export.Format); err != nil {
+			return fmt.Errorf("export.format: %w", err)
+		}
+	}
+	return nil
+}
+
+// FilterPreset returns the preset with the given name, if there is one
+func (c *Config) FilterPreset(name string) (FilterPreset, bool) {
+	for _, preset := range c.Filters.Presets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return FilterPreset{}, false
+}
@@ -0,0 +1,384 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// TestLoad tests that config files are merged onto the defaults
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // "" = no file
+		check   func(*Config) bool
+		wantErr bool
+	}{
+		{
+			name:  "missing file uses defaults",
+			check: func(c *Config) bool { return reflect.DeepEqual(c, Default()) },
+		},
+		{
+			name:    "partial override keeps other defaults",
+			content: `{"cost":{"message":{"warn":0.5,"high":2}}}`,
+			check: func(c *Config) bool {
+				return c.Cost.Message == Thresholds{Warn: 0.5, High: 2} && c.Cost.Session == Default().Cost.Session
+			},
+		},
+		{
+			name:    "hide costs",
+			content: `{"cost":{"hidden":true}}`,
+			check:   func(c *Config) bool { return c.Cost.Hidden },
+		},
+		{
+			name:    "read-only mode",
+			content: `{"readOnly":true}`,
+			check:   func(c *Config) bool { return c.ReadOnly },
+		},
+		{
+			name:    "without processes",
+			content: `{"noProcesses":true,"defaultView":"recent"}`,
+			check:   func(c *Config) bool { return c.NoProcesses && c.DefaultView == "recent" },
+		},
+		{
+			name:    "process view without processes",
+			content: `{"noProcesses":true,"defaultView":"processes"}`,
+			wantErr: true,
+		},
+		{
+			name:    "colorblind theme",
+			content: `{"theme":"colorblind"}`,
+			check:   func(c *Config) bool { return c.Theme == "colorblind" },
+		},
+		{
+			name:    "unknown theme",
+			content: `{"theme":"solarized"}`,
+			wantErr: true,
+		},
+		{
+			name:    "card preview",
+			content: `{"cards":{"preview":"last"}}`,
+			check:   func(c *Config) bool { return c.Cards.Preview == "last" },
+		},
+		{
+			name:    "unknown card preview",
+			content: `{"cards":{"preview":"middle"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "slow responses",
+			content: `{"cards":{"slowBelow":8.5}}`,
+			check:   func(c *Config) bool { return c.Cards.SlowBelow == 8.5 && c.Cards.Preview == "smart" },
+		},
+		{
+			name:    "negative slow threshold",
+			content: `{"cards":{"slowBelow":-1}}`,
+			wantErr: true,
+		},
+		{
+			name:    "default view",
+			content: `{"defaultView":"projects"}`,
+			check:   func(c *Config) bool { return c.DefaultView == "projects" },
+		},
+		{
+			name:    "unknown default view",
+			content: `{"defaultView":"sessions"}`,
+			wantErr: true,
+		},
+		{
+			name:    "price overrides",
+			content: `{"pricing":{"models":[{"match":"claude-opus-5","from":"2026-03-01","input":4,"output":20,"cacheWrite":5,"cacheRead":0.4}]}}`,
+			check: func(c *Config) bool {
+				o := c.Pricing.Overrides()
+				return len(o) == 1 && o[0].Match == "claude-opus-5" && o[0].From.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) && o[0].Output == 20
+			},
+		},
+		{
+			name:    "price override without a date",
+			content: `{"pricing":{"models":[{"match":"opus","input":4}]}}`,
+			check:   func(c *Config) bool { return c.Pricing.Overrides()[0].From.IsZero() },
+		},
+		{
+			name:    "price override with a bad date",
+			content: `{"pricing":{"models":[{"match":"opus","from":"March 2026"}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative price",
+			content: `{"pricing":{"models":[{"match":"opus","cacheRead":-1}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "high below warn",
+			content: `{"cost":{"day":{"warn":10,"high":5}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "context window overrides",
+			content: `{"context":{"warnAt":0.9,"windows":{"claude-opus-5":500000}}}`,
+			check: func(c *Config) bool {
+				return c.Context.WarnAt == 0.9 && c.Context.Windows["claude-opus-5"] == 500000
+			},
+		},
+		{
+			name:    "warnAt out of range",
+			content: `{"context":{"warnAt":1.5}}`,
+			wantErr: true,
+		},
+		{
+			name:    "context growth threshold",
+			content: `{"context":{"growthWarnAt":50000}}`,
+			check: func(c *Config) bool {
+				return c.Context.GrowthWarnAt == 50_000 && c.Context.WarnAt == Default().Context.WarnAt
+			},
+		},
+		{
+			name:    "context growth threshold below one",
+			content: `{"context":{"growthWarnAt":0}}`,
+			wantErr: true,
+		},
+		{
+			name:    "full-width message detail",
+			content: `{"detail":{"fullWidth":true}}`,
+			check:   func(c *Config) bool { return c.Detail.FullWidth },
+		},
+		{
+			name:    "split view width",
+			content: `{"detail":{"splitMinWidth":200}}`,
+			check: func(c *Config) bool {
+				return c.Detail.SplitMinWidth == 200 && !c.Detail.FullWidth
+			},
+		},
+		{
+			name:    "split view width too narrow",
+			content: `{"detail":{"splitMinWidth":40}}`,
+			wantErr: true,
+		},
+		{
+			name:    "sessionless processes",
+			content: `{"processes":{"showSessionless":true}}`,
+			check:   func(c *Config) bool { return c.Processes.ShowSessionless },
+		},
+		{
+			name:    "recent days",
+			content: `{"recent":{"days":30}}`,
+			check:   func(c *Config) bool { return c.Recent.Days == 30 },
+		},
+		{
+			name:    "recent days below one",
+			content: `{"recent":{"days":0}}`,
+			wantErr: true,
+		},
+		{
+			name:    "summary template",
+			content: `{"summary":{"template":"{{.Duration}} on {{.Branch}}"}}`,
+			check:   func(c *Config) bool { return c.Summary.Template == "{{.Duration}} on {{.Branch}}" },
+		},
+		{
+			name:    "invalid summary template",
+			content: `{"summary":{"template":"{{.Duration"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "filter presets",
+			content: `{"filters":{"presets":[{"name":"errors only","match":{"role":"assistant","isError":true,"contains":"Error"}},{"name":"edits","match":{"any":[{"tool":"Edit"},{"tool":"Write"}]}}]}}`,
+			check: func(c *Config) bool {
+				p, ok := c.FilterPreset("edits")
+				return len(c.Filters.Presets) == 2 && c.Filters.Presets[0].Match.IsError && ok && len(p.Match.Any) == 2
+			},
+		},
+		{
+			name:    "filter preset without name",
+			content: `{"filters":{"presets":[{"match":{"tool":"Bash"}}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "filter preset with unknown nested role",
+			content: `{"filters":{"presets":[{"name":"x","match":{"not":{"role":"system"}}}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "filter preset with unknown permission decision",
+			content: `{"filters":{"presets":[{"name":"x","match":{"permission":"asked"}}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "refusal patterns",
+			content: `{"refusals":{"patterns":["^(?i)as an ai"]}}`,
+			check:   func(c *Config) bool { return reflect.DeepEqual(c.Refusals.Patterns, []string{"^(?i)as an ai"}) },
+		},
+		{
+			name:    "refusal matching off",
+			content: `{"refusals":{"patterns":[]}}`,
+			check:   func(c *Config) bool { return len(c.Refusals.Patterns) == 0 },
+		},
+		{
+			name:    "invalid refusal pattern",
+			content: `{"refusals":{"patterns":["I can(not"]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "export settings",
+			content: `{"export":{"format":"json","dir":"/tmp/exports"}}`,
+			check: func(c *Config) bool {
+				return c.Export == ExportConfig{Format: "json", Dir: "/tmp/exports"}
+			},
+		},
+		{
+			name:    "session titles from the first prompt",
+			content: `{"sessions":{"titleFrom":["prompt","id"]}}`,
+			check: func(c *Config) bool {
+				return reflect.DeepEqual(c.Sessions.TitleFrom, []string{"prompt", "id"})
+			},
+		},
+		{
+			name:    "session list with changes",
+			content: `{"sessions":{"showChanges":true}}`,
+			check: func(c *Config) bool {
+				return c.Sessions.ShowChanges && reflect.DeepEqual(c.Sessions.TitleFrom, Default().Sessions.TitleFrom)
+			},
+		},
+		{
+			name:    "unknown session title source",
+			content: `{"sessions":{"titleFrom":["branch"]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "grouped token counts and euro costs",
+			content: `{"numbers":{"tokens":"grouped","separator":"."},"cost":{"currency":"€","decimals":3}}`,
+			check: func(c *Config) bool {
+				return c.Numbers == NumbersConfig{Tokens: "grouped", Separator: "."} && c.Cost.Currency == "€" && c.Cost.Decimals == 3
+			},
+		},
+		{
+			name:    "whole currency units",
+			content: `{"cost":{"currency":"¥","decimals":0}}`,
+			check:   func(c *Config) bool { return c.Cost.Decimals == 0 },
+		},
+		{
+			name:    "unknown token format",
+			content: `{"numbers":{"tokens":"scientific"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "too many cost decimals",
+			content: `{"cost":{"decimals":9}}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed json",
+			content: `{"cost":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := Load(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("unexpected config: %+v", cfg)
+			}
+		})
+	}
+}
+
+// TestThresholdLevel tests cost classification at and around the thresholds
+func TestThresholdLevel(t *testing.T) {
+	th := Thresholds{Warn: 0.01, High: 0.10}
+	tests := []struct {
+		cost float64
+		want Level
+	}{
+		{0, LevelLow},
+		{0.01, LevelLow},
+		{0.02, LevelWarn},
+		{0.10, LevelWarn},
+		{0.30, LevelHigh},
+	}
+	for _, tt := range tests {
+		if got := th.Level(tt.cost); got != tt.want {
+			t.Errorf("Level(%g) = %v, want %v", tt.cost, got, tt.want)
+		}
+	}
+}
+
+// TestDayThresholds tests that day thresholds scale with the days a total covers
+func TestDayThresholds(t *testing.T) {
+	costs := CostConfig{Day: Thresholds{Warn: 10, High: 50}}
+	tests := []struct {
+		days int
+		want Thresholds
+	}{
+		{1, Thresholds{Warn: 10, High: 50}},
+		{7, Thresholds{Warn: 70, High: 350}},
+		{0, Thresholds{Warn: 10, High: 50}},
+	}
+	for _, tt := range tests {
+		if got := costs.Days(tt.days); got != tt.want {
+			t.Errorf("Days(%d) = %+v, want %+v", tt.days, got, tt.want)
+		}
+	}
+	if level := costs.Days(7).Level(60); level != LevelLow {
+		t.Errorf("a week at $60 = %v, want LevelLow", level)
+	}
+}
+
+// TestPredicateMatch tests the criteria of a filter predicate against a prompt, a tool
+// call and a failed tool result
+func TestPredicateMatch(t *testing.T) {
+	prompt := &monitor.Message{Type: "prompt", Role: "user", Content: "fix the build"}
+	call := &monitor.Message{Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash", ToolInput: `{"command":"go build"}`,
+		ToolCalls:          []monitor.ToolCall{{Name: "Bash", Input: `{"command":"go build"}`}, {Name: "Read", Input: `{"file_path":"main.go"}`}},
+		PermissionDecision: monitor.DecisionDenied}
+	failed := &monitor.Message{Type: "tool_result", Role: "user", Content: "Error: exit status 1", IsError: true}
+
+	tests := []struct {
+		name string
+		p    Predicate
+		want []bool // For prompt, call and failed
+	}{
+		{"zero matches all", Predicate{}, []bool{true, true, true}},
+		{"user role", Predicate{Role: "user"}, []bool{true, false, false}},
+		{"assistant role includes tool results", Predicate{Role: "assistant"}, []bool{false, true, true}},
+		{"tool", Predicate{Tool: "bash"}, []bool{false, true, false}},
+		{"any tool", Predicate{Tool: "*"}, []bool{false, true, false}},
+		{"errors containing text", Predicate{Role: "assistant", IsError: true, Contains: "error"}, []bool{false, false, true}},
+		{"tool of a later call", Predicate{Tool: "Read"}, []bool{false, true, false}},
+		{"contains searches tool input", Predicate{Contains: "GO BUILD"}, []bool{false, true, false}},
+		{"contains searches every call", Predicate{Contains: "main.go"}, []bool{false, true, false}},
+		{"any", Predicate{Any: []Predicate{{Role: "user", Contains: "fix"}, {IsError: true}}}, []bool{true, false, true}},
+		{"not", Predicate{Not: &Predicate{Role: "user"}}, []bool{false, true, true}},
+		{"denied at the permission prompt", Predicate{Permission: "denied"}, []bool{false, true, false}},
+		{"allowed at the permission prompt", Predicate{Permission: "allowed"}, []bool{false, false, false}},
+		{"refusals", Predicate{Refusal: true}, []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, msg := range []*monitor.Message{prompt, call, failed} {
+				if got := tt.p.Match(msg); got != tt.want[i] {
+					t.Errorf("%+v.Match(%q) = %v, want %v", tt.p, msg.Content, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package demo runs promptwatch against bundled fixture data instead of ~/.claude and
+// live processes. It is used for screenshots, demos, UI development and end-to-end tests.
+package demo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/fsutil"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+const (
+	// liveProject is the fixture project the replayed session is written into
+	liveProject = "-home-demo-acme-api"
+	// liveSessionID names the session file that grows while the demo runs
+	liveSessionID = "c0ffee00-9d2b-4a7e-b3f5-0e1d2c3b4a59"
+	// replayFixture is the recorded session that is replayed into the live session file
+	replayFixture = "fixtures/replay/live-session.jsonl"
+	// initialReplayLines are written before the demo starts so the live session is never empty
+	initialReplayLines = 3
+)
+
+// Demo is a running demo environment: fixture projects extracted to a temporary
+// directory, a fake process table and a session being replayed in the background
+type Demo struct {
+	dir      string
+	provider *Provider
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// Start extracts the fixtures and starts replaying the recorded session. speed
+// accelerates the replay (10 plays back ten times faster than recorded); 0 writes
+// the whole recording immediately.
+func Start(ctx context.Context, speed float64) (*Demo, error) {
+	dir, err := os.MkdirTemp("", "promptwatch-demo-")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create demo directory: %w", err)
+	}
+
+	if err := extractProjects(filepath.Join(dir, "projects")); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	lines, err := replayLines()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	livePath := filepath.Join(dir, "projects", liveProject, liveSessionID+".jsonl")
+	r := &replayer{path: livePath, lines: lines, speed: speed, now: time.Now}
+	if err := r.writeInitial(initialReplayLines); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	d := &Demo{
+		dir:      dir,
+		provider: NewProvider(time.Now()),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(d.done)
+		r.run(ctx)
+	}()
+	return d, nil
+}
+
+// ProjectsDir returns the directory that stands in for ~/.claude/projects
+func (d *Demo) ProjectsDir() string {
+	return filepath.Join(d.dir, "projects")
+}
+
+// Provider returns the fake process table matching the fixture projects
+func (d *Demo) Provider() *Provider {
+	return d.provider
+}
+
+// Close stops the replay and removes the extracted fixtures
+func (d *Demo) Close() error {
+	d.cancel()
+	<-d.done
+	return os.RemoveAll(d.dir)
+}
+
+// extractProjects copies the embedded fixture projects to dest
+func extractProjects(dest string) error {
+	root := "fixtures/projects"
+	return fs.WalkDir(fixtures, root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := fixtures.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return fsutil.WriteAtomic(target, data, 0o644)
+	})
+}
+
+// replayLines returns the lines of the recorded session
+func replayLines() ([][]byte, error) {
+	data, err := fixtures.ReadFile(replayFixture)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read replay fixture: %w", err)
+	}
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	return lines, scanner.Err()
+}
+
+// replayer appends recorded session lines to a file, keeping the recorded pacing
+// (divided by speed) and restamping each entry with the time it is written
+type replayer struct {
+	path    string
+	lines   [][]byte
+	speed   float64
+	now     func() time.Time
+	written int
+}
+
+// writeInitial writes the first n lines at once
+func (r *replayer) writeInitial(n int) error {
+	for r.written < n && r.written < len(r.lines) {
+		if err := r.writeNext(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run writes the remaining lines until the recording ends or ctx is cancelled
+func (r *replayer) run(ctx context.Context) {
+	for r.written < len(r.lines) {
+		if delay := r.nextDelay(); delay > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+		if err := r.writeNext(); err != nil {
+			return
+		}
+	}
+}
+
+// nextDelay returns the recorded gap before the next line, scaled by the replay speed
+func (r *replayer) nextDelay() time.Duration {
+	if r.speed <= 0 || r.written == 0 || r.written >= len(r.lines) {
+		return 0
+	}
+	prev, errPrev := entryTime(r.lines[r.written-1])
+	next, errNext := entryTime(r.lines[r.written])
+	if errPrev != nil || errNext != nil || !next.After(prev) {
+		return 0
+	}
+	return time.Duration(float64(next.Sub(prev)) / r.speed)
+}
+
+// writeNext appends the next recorded line with its timestamp set to now
+func (r *replayer) writeNext() error {
+	line, err := restamp(r.lines[r.written], r.now())
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot write live session: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("cannot write live session: %w", err)
+	}
+	r.written++
+	return nil
+}
+
+// entryTime returns the timestamp of a session line
+func entryTime(line []byte) (time.Time, error) {
+	var entry struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, entry.Timestamp)
+}
+
+// restamp replaces the timestamp of a session line
+func restamp(line []byte, t time.Time) ([]byte, error) {
+	var entry map[string]any
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, fmt.Errorf("invalid replay line: %w", err)
+	}
+	entry["timestamp"] = t.UTC().Format(time.RFC3339Nano)
+	return json.Marshal(entry)
+}
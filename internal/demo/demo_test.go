@@ -0,0 +1,156 @@
+package demo_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/thieso2/promptwatch/internal/demo"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/ui"
+)
+
+// replaySpeed replays the recorded minute of the live session in a few seconds
+const replaySpeed = 20
+
+// startDemo runs the demo replaying at replaySpeed and points monitor at it
+func startDemo(t *testing.T) *demo.Demo {
+	t.Helper()
+	d, err := demo.Start(context.Background(), replaySpeed)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// monitor stays pointed at the demo: commands of the quit program may still be
+	// running, and no other test here uses monitor's defaults
+	monitor.SetProjectsDir(d.ProjectsDir())
+	monitor.SetProcessProvider(d.Provider())
+	t.Cleanup(func() {
+		if err := d.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	})
+	return d
+}
+
+// waitView waits until the program has drawn all of wants and returns the output drawn
+// since the last wait, without escape sequences
+func waitView(t *testing.T, tm *teatest.TestModel, wants ...string) string {
+	t.Helper()
+	var view string
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		view = ansi.Strip(string(out))
+		for _, want := range wants {
+			if !strings.Contains(view, want) {
+				return false
+			}
+		}
+		return true
+	}, teatest.WithDuration(10*time.Second))
+	return view
+}
+
+func TestDemoViews(t *testing.T) {
+	startDemo(t)
+
+	tm := teatest.NewTestModel(t, ui.NewModel(100*time.Millisecond, false), teatest.WithInitialTermSize(200, 60))
+	t.Cleanup(func() {
+		tm.Quit()
+		m := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(ui.Model)
+		m.Shutdown()
+	})
+	enter, esc := tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEsc}
+
+	// Processes: both Claude instances, without the MCP helper or the shell
+	view := waitView(t, tm, "4242", "5150", "acme-api", "web-ui")
+	if strings.Contains(view, "4243") || strings.Contains(view, "811 ") {
+		t.Errorf("helper or unrelated process listed:\n%s", view)
+	}
+
+	// Projects
+	tm.Type("p")
+	waitView(t, tm, "/home/demo/acme-api", "/home/demo/web-ui")
+
+	// Sessions of acme-api, the first project; the subagent session stays hidden
+	tm.Send(enter)
+	waitView(t, tm, "Sessions for: /home/demo/acme-api", "fix/rate-limit", "1 subagent sessions hidden")
+
+	// The preview of the live session follows the replay up to its last message
+	tm.Type("v")
+	waitView(t, tm, "Done: the limiter now keys")
+
+	// Session detail of the live session, the newest one, fully replayed
+	tm.Type("o")
+	waitView(t, tm, "Session Details", "rate limited", "Messages: 14")
+
+	// Message detail of the newest message
+	tm.Send(enter)
+	waitView(t, tm, "CLAUDE RESPONSE", "Session: c0ffee00-9d2b-4a7e-b3f5-0e1d2c3b4a59")
+
+	// Back out to the processes view
+	tm.Send(esc)
+	tm.Send(esc)
+	tm.Send(esc)
+	tm.Type("p")
+	waitView(t, tm, "4242")
+}
+
+// replayedLines is the length of the recorded session fixture
+const replayedLines = 14
+
+// livePath returns the session file the recording is replayed into
+func livePath(d *demo.Demo) string {
+	return filepath.Join(d.ProjectsDir(), "-home-demo-acme-api", "c0ffee00-9d2b-4a7e-b3f5-0e1d2c3b4a59.jsonl")
+}
+
+// waitReplayed waits until the whole recording has been written to the live session
+func waitReplayed(t *testing.T, d *demo.Demo) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(livePath(d))
+		if err == nil && strings.Count(string(data), "\n") == replayedLines {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("live session not fully replayed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReplayAppendsLiveSession(t *testing.T) {
+	d, err := demo.Start(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer d.Close()
+	waitReplayed(t, d)
+
+	stats, err := monitor.ParseSessionFile(livePath(d))
+	if err != nil {
+		t.Fatalf("ParseSessionFile: %v", err)
+	}
+	if time.Since(stats.LastActivity) > time.Minute {
+		t.Errorf("replayed entries not restamped, last activity %v", stats.LastActivity)
+	}
+}
+
+func TestCloseRemovesFixtures(t *testing.T) {
+	d, err := demo.Start(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	dir := d.ProjectsDir()
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("demo directory still exists: %v", err)
+	}
+}
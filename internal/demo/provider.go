@@ -0,0 +1,110 @@
+package demo
+
+import (
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// fakeProcess describes one process of the demo process table
+type fakeProcess struct {
+	name    string
+	exe     string
+	cmdline string
+	cwd     string
+	cpuBase float64       // Average CPU percentage
+	rssMB   float64       // Resident memory in MB
+	age     time.Duration // How long the process had been running when the demo started
+}
+
+// demoProcesses matches the fixture projects: two Claude instances, an MCP helper and an
+// unrelated shell that discovery must ignore
+var demoProcesses = map[int32]fakeProcess{
+	4242: {name: "claude", exe: "/usr/local/bin/claude", cmdline: "claude --continue", cwd: "/home/demo/acme-api", cpuBase: 18, rssMB: 312, age: 2*time.Hour + 14*time.Minute},
+	4243: {name: "claude", exe: "/usr/local/bin/claude", cmdline: "claude --mcp", cwd: "/home/demo/acme-api", cpuBase: 0.4, rssMB: 48, age: 2*time.Hour + 13*time.Minute},
+	5150: {name: "claude", exe: "/usr/local/bin/claude", cmdline: "claude", cwd: "/home/demo/web-ui", cpuBase: 3, rssMB: 276, age: 26 * time.Hour},
+	811:  {name: "zsh", exe: "/bin/zsh", cmdline: "-zsh", cwd: "/home/demo", cpuBase: 0, rssMB: 6, age: 30 * time.Hour},
+}
+
+// Provider is a monitor.ProcessProvider serving the demo process table. CPU usage
+// drifts over time so the process view shows live updates.
+type Provider struct {
+	started time.Time
+	now     func() time.Time
+}
+
+// NewProvider returns a demo process table whose process ages are relative to started
+func NewProvider(started time.Time) *Provider {
+	return &Provider{started: started, now: time.Now}
+}
+
+var _ monitor.ProcessProvider = (*Provider)(nil)
+
+func (p *Provider) get(pid int32) (fakeProcess, error) {
+	proc, ok := demoProcesses[pid]
+	if !ok {
+		return proc, os.ErrNotExist
+	}
+	return proc, nil
+}
+
+func (p *Provider) List() ([]int32, error) {
+	pids := make([]int32, 0, len(demoProcesses))
+	for pid := range demoProcesses {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids, nil
+}
+
+func (p *Provider) Exists(pid int32) (bool, error) {
+	_, ok := demoProcesses[pid]
+	return ok, nil
+}
+
+func (p *Provider) Name(pid int32) (string, error) {
+	proc, err := p.get(pid)
+	return proc.name, err
+}
+
+func (p *Provider) Exe(pid int32) (string, error) {
+	proc, err := p.get(pid)
+	return proc.exe, err
+}
+
+func (p *Provider) Cmdline(pid int32) (string, error) {
+	proc, err := p.get(pid)
+	return proc.cmdline, err
+}
+
+// CPUPercent oscillates around the process's base load with a per-process phase
+func (p *Provider) CPUPercent(pid int32) (float64, error) {
+	proc, err := p.get(pid)
+	if err != nil {
+		return 0, err
+	}
+	elapsed := p.now().Sub(p.started).Seconds()
+	wave := math.Sin(elapsed/3 + float64(pid))
+	return math.Max(0, proc.cpuBase*(1+0.6*wave)), nil
+}
+
+func (p *Provider) MemoryInfo(pid int32) (monitor.MemoryInfo, error) {
+	proc, err := p.get(pid)
+	return monitor.MemoryInfo{RSS: uint64(proc.rssMB * 1024 * 1024)}, err
+}
+
+func (p *Provider) CreateTime(pid int32) (int64, error) {
+	proc, err := p.get(pid)
+	if err != nil {
+		return 0, err
+	}
+	return p.started.Add(-proc.age).UnixMilli(), nil
+}
+
+func (p *Provider) Cwd(pid int32) (string, error) {
+	proc, err := p.get(pid)
+	return proc.cwd, err
+}
@@ -0,0 +1,192 @@
+// Package diff computes line- and word-level differences between two texts
+package diff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind tells whether a piece of text is shared by both sides or only on one of them
+type Kind int
+
+const (
+	Equal  Kind = iota // In both texts
+	Delete             // Only in the old text
+	Insert             // Only in the new text
+)
+
+// Op is one line or word of the edit script turning the old text into the new one
+type Op struct {
+	Kind Kind
+	Text string
+}
+
+// maxEdits bounds the search for the shortest edit script. Beyond it the differing
+// middle of the texts is reported as deleted and re-inserted as a whole.
+const maxEdits = 2000
+
+// Lines diffs two texts line by line
+func Lines(a, b string) []Op {
+	return Diff(splitLines(a), splitLines(b))
+}
+
+// Words diffs two texts word by word. Whitespace runs are tokens of their own and
+// every line break is a separate "\n" token, so joining the texts of the ops on either
+// side gives back the original text.
+func Words(a, b string) []Op {
+	return Diff(splitWords(a), splitWords(b))
+}
+
+// Count returns the number of deleted and inserted ops
+func Count(ops []Op) (deleted, inserted int) {
+	for _, op := range ops {
+		switch op.Kind {
+		case Delete:
+			deleted++
+		case Insert:
+			inserted++
+		}
+	}
+	return deleted, inserted
+}
+
+// Diff returns a shortest edit script from a to b (Myers' algorithm). Deletions come
+// before insertions where both are possible.
+func Diff(a, b []string) []Op {
+	// Common prefix and suffix need no search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]Op, 0, len(a)+len(b))
+	for _, s := range a[:prefix] {
+		ops = append(ops, Op{Equal, s})
+	}
+	ops = append(ops, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, s := range a[len(a)-suffix:] {
+		ops = append(ops, Op{Equal, s})
+	}
+	return ops
+}
+
+// middle runs the greedy Myers search on texts without a common prefix or suffix
+func middle(a, b []string) []Op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replace(a, b)
+	}
+
+	// v[offset+k] is the furthest x reached on diagonal k = x-y; trace keeps v's
+	// diagonals -d..d as they were before round d, for walking the path back
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+	for d := 0; d <= min(n+m, maxEdits); d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Down: insert b[y-1]
+			} else {
+				x = v[offset+k-1] + 1 // Right: delete a[x-1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return replace(a, b)
+}
+
+// backtrack walks the rounds of the search back from the end of both texts
+func backtrack(a, b []string, trace [][]int) []Op {
+	var ops []Op
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		prev := func(k int) int {
+			if k < -d || k > d {
+				return 0
+			}
+			return trace[d][k+d]
+		}
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev(k-1) < prev(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, Op{Equal, a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			ops = append(ops, Op{Insert, b[prevY]})
+		} else {
+			ops = append(ops, Op{Delete, a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replace reports all of a as deleted and all of b as inserted
+func replace(a, b []string) []Op {
+	ops := make([]Op, 0, len(a)+len(b))
+	for _, s := range a {
+		ops = append(ops, Op{Delete, s})
+	}
+	for _, s := range b {
+		ops = append(ops, Op{Insert, s})
+	}
+	return ops
+}
+
+// splitLines splits text into lines; an empty text has no lines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// splitWords splits text into words, whitespace runs and line breaks
+func splitWords(text string) []string {
+	var tokens []string
+	start := 0
+	for i, r := range text {
+		if i == start {
+			continue
+		}
+		last, _ := utf8.DecodeLastRuneInString(text[start:i])
+		if r == '\n' || last == '\n' || unicode.IsSpace(r) != unicode.IsSpace(last) {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// script renders ops compactly, e.g. "=a -b +c", with spaces shown as "·"
+func script(ops []Op) string {
+	var parts []string
+	for _, op := range ops {
+		parts = append(parts, fmt.Sprintf("%c%s", "=-+"[op.Kind], strings.NewReplacer(" ", "·", "\n", `\n`).Replace(op.Text)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// side joins the texts of the ops one side of the diff consists of
+func side(ops []Op, skip Kind) string {
+	var b strings.Builder
+	for _, op := range ops {
+		if op.Kind != skip {
+			b.WriteString(op.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"identical", "a\nb", "a\nb", "=a =b"},
+		{"both empty", "", "", ""},
+		{"from empty", "", "a\nb", "+a +b"},
+		{"to empty", "a\nb", "", "-a -b"},
+		{"changed line", "a\nb\nc", "a\nB\nc", "=a -b +B =c"},
+		{"inserted line", "a\nc", "a\nb\nc", "=a +b =c"},
+		{"deleted line", "a\nb\nc", "a\nc", "=a -b =c"},
+		{"moved line", "a\nb\nc\nd", "b\nc\na\nd", "-a =b =c +a =d"},
+		{"trailing newline", "a\n", "a", "=a -"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := script(Lines(tt.a, tt.b)); got != tt.want {
+				t.Errorf("Lines(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"changed word", "fix the bug", "fix the crash", "=fix =· =the =· -bug +crash"},
+		{"line break", "one two", "one\ntwo", "=one -· +\\n =two"},
+		{"unicode", "größe ändern", "größe löschen", "=größe =· -ändern +löschen"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := Words(tt.a, tt.b)
+			if got := script(ops); got != tt.want {
+				t.Errorf("Words(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+			if got := side(ops, Insert); got != tt.a {
+				t.Errorf("old side = %q, want %q", got, tt.a)
+			}
+			if got := side(ops, Delete); got != tt.b {
+				t.Errorf("new side = %q, want %q", got, tt.b)
+			}
+		})
+	}
+}
+
+func TestDiffIsMinimal(t *testing.T) {
+	a := strings.Split("abcabba", "")
+	b := strings.Split("cbabac", "")
+	ops := Diff(a, b)
+	if d, i := Count(ops); d+i != 5 {
+		t.Errorf("Diff(abcabba, cbabac) = %s: %d edits, want 5", script(ops), d+i)
+	}
+	if got := side(ops, Insert); got != "abcabba" {
+		t.Errorf("old side = %q", got)
+	}
+	if got := side(ops, Delete); got != "cbabac" {
+		t.Errorf("new side = %q", got)
+	}
+}
+
+func TestDiffGivesUpOnHugeEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 3*maxEdits; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	a = append([]string{"same"}, a...)
+	b = append([]string{"same"}, b...)
+	ops := Diff(a, b)
+	want := append([]Op{{Equal, "same"}}, replace(a[1:], b[1:])...)
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("Diff of unrelated texts should delete and re-insert the differing middle")
+	}
+}
@@ -0,0 +1,247 @@
+// Package digest lists the sessions of a time range one line each, e.g. for standup
+// notes: when they started, how long they ran, where, at what cost and what about
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// Format is the output format of a digest
+type Format string
+
+const (
+	FormatText     Format = "text"     // Aligned columns
+	FormatMarkdown Format = "markdown" // A bulleted list
+	FormatJSON     Format = "json"     // An array with one object per session
+)
+
+// maxTitle is how many characters of a session title a text or Markdown line keeps
+const maxTitle = 100
+
+// Session is one line of the digest
+type Session struct {
+	ID       string
+	Project  string // Original project path, else the encoded project directory name
+	Branch   string // Git branch the session ended on; "" if none was recorded
+	Started  time.Time
+	Duration time.Duration
+	Cost     float64
+	Title    string // Claude's summary of the conversation, else its first prompt, else ""
+}
+
+// ParseSince reads the start of a digest range relative to now: "today" or
+// "yesterday" (from midnight), a number of days such as "3d" (from midnight that many
+// days ago), a duration such as "36h", a date such as "2026-01-09" or an RFC 3339 time
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return midnight.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if day, err := time.ParseInLocation(time.DateOnly, s, now.Location()); err == nil {
+		return day, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot read %q as a start time (want today, yesterday, e.g. 3d or 36h, a date or an RFC 3339 time)", s)
+}
+
+// Collect reads the sessions active since since, oldest first, pricing each message
+// with cost. Only session files written since then are opened. Subagent sessions and
+// side-chains are left out, as part of the work of the session that started them, and
+// a session copied between project directories is listed once. With project set, only
+// that project's sessions are listed.
+func Collect(since time.Time, project string, cost func(*monitor.Message) float64) ([]Session, error) {
+	files, err := monitor.FindRecentSessionFiles(since)
+	if err != nil {
+		return nil, err
+	}
+	dups, err := monitor.FindAllDuplicates()
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make(map[string]string) // Project path by project directory
+	var sessions []Session
+	for _, f := range files {
+		if monitor.IsAgentFile(f.Path) || dups.Dropped(f.Path) {
+			continue
+		}
+		path, ok := projects[f.ProjectDir]
+		if !ok {
+			path = filepath.Base(f.ProjectDir)
+			if index, err := monitor.ParseSessionIndex(filepath.Join(f.ProjectDir, "sessions-index.json")); err == nil && index.OriginalPath != "" {
+				path = index.OriginalPath
+			}
+			projects[f.ProjectDir] = path
+		}
+		if project != "" && filepath.Clean(project) != filepath.Clean(path) {
+			continue
+		}
+
+		stats, err := monitor.ParseSessionFile(f.Path)
+		if err != nil || len(stats.MessageHistory) == 0 || stats.MessageHistory[0].IsSidechain {
+			continue // Unreadable, empty or a side-chain
+		}
+		sessions = append(sessions, newSession(stats, path, cost))
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Started.Before(sessions[j].Started)
+	})
+	return sessions, nil
+}
+
+// newSession collects the digest line of a parsed session
+func newSession(stats *monitor.SessionStats, project string, cost func(*monitor.Message) float64) Session {
+	s := Session{
+		ID:       monitor.SessionFileID(stats.FilePath),
+		Project:  project,
+		Started:  stats.CreatedAt,
+		Duration: stats.Duration,
+		Title:    stats.Summary,
+	}
+	if n := len(stats.Branches); n > 0 {
+		s.Branch = stats.Branches[n-1].Branch
+	}
+	for i := range stats.MessageHistory {
+		msg := &stats.MessageHistory[i]
+		s.Cost += cost(msg)
+		if s.Title == "" && msg.Type == "prompt" {
+			s.Title = msg.Content
+		}
+	}
+	return s
+}
+
+// Write writes the digest in the given format
+func Write(w io.Writer, sessions []Session, format Format) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, sessions)
+	case FormatJSON:
+		return writeJSON(w, sessions)
+	}
+	return writeText(w, sessions)
+}
+
+// writeText writes one line per session in aligned columns
+func writeText(w io.Writer, sessions []Session) error {
+	if len(sessions) == 0 {
+		_, err := fmt.Fprintln(w, "No sessions in this range")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Started.Local().Format("2006-01-02 15:04"),
+			formatDuration(s.Duration),
+			s.Project,
+			orDash(s.Branch),
+			render.FormatCost(s.Cost, render.TotalPrecision),
+			orDash(title(s.Title)),
+		)
+	}
+	return tw.Flush()
+}
+
+// writeMarkdown writes a bulleted list with one item per session
+func writeMarkdown(w io.Writer, sessions []Session) error {
+	if len(sessions) == 0 {
+		_, err := fmt.Fprintln(w, "No sessions in this range")
+		return err
+	}
+	for _, s := range sessions {
+		line := fmt.Sprintf("- %s (%s) `%s`", s.Started.Local().Format("2006-01-02 15:04"), formatDuration(s.Duration), s.Project)
+		if s.Branch != "" {
+			line += " on `" + s.Branch + "`"
+		}
+		line += ", " + render.FormatCost(s.Cost, render.TotalPrecision)
+		if t := title(s.Title); t != "" {
+			line += ": " + t
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record is a session of the digest in JSON
+type record struct {
+	Session         string    `json:"session"`
+	Project         string    `json:"project"`
+	Branch          string    `json:"branch,omitempty"`
+	Started         time.Time `json:"started"`
+	DurationSeconds int       `json:"durationSeconds"`
+	Cost            float64   `json:"cost"` // USD
+	Title           string    `json:"title,omitempty"`
+}
+
+// writeJSON writes the digest as a JSON array; titles are whole
+func writeJSON(w io.Writer, sessions []Session) error {
+	records := make([]record, len(sessions))
+	for i, s := range sessions {
+		records[i] = record{
+			Session:         s.ID,
+			Project:         s.Project,
+			Branch:          s.Branch,
+			Started:         s.Started,
+			DurationSeconds: int(s.Duration.Seconds()),
+			Cost:            s.Cost,
+			Title:           s.Title,
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// title fits a session title on one line of at most maxTitle characters
+func title(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxTitle {
+		return string(runes[:maxTitle-1]) + "…"
+	}
+	return s
+}
+
+// formatDuration formats a session length to the minute, e.g. "1h05m" or "12m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package digest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/")
+
+func TestMain(m *testing.M) {
+	time.Local = time.UTC // Golden files hold start times in a fixed time zone
+	os.Exit(m.Run())
+}
+
+// TestParseSince tests the start times the -since flag accepts
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 1, 12, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"today", time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC), false},
+		{"Yesterday", time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC), false},
+		{"3d", time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), false},
+		{"0d", time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC), false},
+		{"36h", time.Date(2026, 1, 10, 21, 30, 0, 0, time.UTC), false},
+		{"2026-01-05", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), false},
+		{"2026-01-05T14:00:00Z", time.Date(2026, 1, 5, 14, 0, 0, 0, time.UTC), false},
+		{"last week", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+		{"-2h", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if (err != nil) != tt.err || !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// TestWriteGolden pins the text, Markdown and JSON layouts in testdata/digest.*.golden
+func TestWriteGolden(t *testing.T) {
+	day := time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{ID: "3f2a9c1e", Project: "/home/demo/acme-api", Branch: "feature/auth", Started: day.Add(9*time.Hour + 14*time.Minute),
+			Duration: 65 * time.Minute, Cost: 2.314, Title: "Add OAuth login to the API"},
+		{ID: "7b1d04aa", Project: "/home/demo/acme-web", Started: day.Add(11 * time.Hour), Duration: 40 * time.Second,
+			Cost: 0.02, Title: "why does\n  the build\tfail?"},
+		{ID: "c9e2f310", Project: "-home-demo-scratch", Branch: "main", Started: day.Add(16*time.Hour + 3*time.Minute),
+			Duration: 3*time.Hour + 2*time.Minute, Cost: 14.5, Title: strings.Repeat("refactor the parser ", 8)},
+		{ID: "d00d0001", Project: "/home/demo/acme-api", Branch: "main", Started: day.Add(17 * time.Hour), Duration: 12 * time.Minute},
+	}
+	for _, format := range []Format{FormatText, FormatMarkdown, FormatJSON} {
+		var b strings.Builder
+		if err := Write(&b, sessions, format); err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, fmt.Sprintf("digest.%s.golden", format), b.String())
+	}
+}
+
+// assertGolden compares got with testdata/name, rewriting the file with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./internal/digest -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs (run with -update to accept)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// TestCollect tests which sessions a digest lists and what it takes from them
+func TestCollect(t *testing.T) {
+	projects := t.TempDir()
+	monitor.SetProjectsDir(projects)
+	t.Cleanup(func() { monitor.SetProjectsDir("") })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	entry := func(at time.Time, extra, typ, content string) string {
+		return fmt.Sprintf(`{"type":%q,"timestamp":%q,"gitBranch":"main"%s,"message":{"role":%q,"content":%q}}`+"\n",
+			typ, at.Format(time.RFC3339), extra, typ, content)
+	}
+	answer := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"message":{"role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"done"}],"usage":{"input_tokens":10,"output_tokens":100}}}`+"\n",
+		now.Add(-time.Hour).Format(time.RFC3339))
+	files := []struct {
+		dir, name, data string
+		age             time.Duration
+	}{
+		{"-work-api", "late.jsonl", entry(now.Add(-2*time.Hour), "", "user", "fix the flaky test") + answer, 0},
+		{"-work-api", "early.jsonl", entry(now.Add(-5*time.Hour), "", "user", "add a health check") +
+			`{"type":"summary","summary":"Health check endpoint"}` + "\n", time.Hour},
+		{"-work-api", "old.jsonl", entry(now.Add(-72*time.Hour), "", "user", "from last week"), 72 * time.Hour},
+		{"-work-api", "side.jsonl", entry(now.Add(-time.Hour), `,"isSidechain":true`, "user", "explore the repo"), 0},
+		{"-work-api", "agent-1a2b.jsonl", entry(now.Add(-time.Hour), "", "user", "subagent work"), 0},
+		{"-work-web", "web.jsonl", entry(now.Add(-3*time.Hour), "", "user", "tweak the navbar"), 0},
+	}
+	for _, f := range files {
+		path := filepath.Join(projects, f.dir, f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-f.age), now.Add(-f.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := `{"originalPath":"/work/api","entries":[]}`
+	if err := os.WriteFile(filepath.Join(projects, "-work-api", "sessions-index.json"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	perMessage := func(msg *monitor.Message) float64 {
+		if msg.Type == "assistant_response" {
+			return 0.5
+		}
+		return 0
+	}
+	sessions, err := Collect(now.Add(-24*time.Hour), "", perMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range sessions {
+		got = append(got, fmt.Sprintf("%s %s %s $%.2f %q", s.ID, s.Project, s.Branch, s.Cost, s.Title))
+	}
+	want := []string{
+		`early /work/api main $0.00 "Health check endpoint"`,
+		`web -work-web main $0.00 "tweak the navbar"`,
+		`late /work/api main $0.50 "fix the flaky test"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("digest:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	sessions, err = Collect(now.Add(-24*time.Hour), "/work/api/", perMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "early" || sessions[1].ID != "late" {
+		t.Errorf("digest of /work/api = %+v, want early and late", sessions)
+	}
+}
@@ -0,0 +1,277 @@
+// Package export writes sessions, or a selection of their messages, in the registered
+// formats: JSON, Markdown and plain text
+package export
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// Session is the export document
+type Session struct {
+	Session     string               `json:"session"`
+	File        string               `json:"file"`
+	ProjectDir  string               `json:"projectDir"`            // Encoded directory under ~/.claude/projects
+	ProjectPath string               `json:"projectPath,omitempty"` // Original project path from sessions-index.json
+	Started     time.Time            `json:"started"`
+	Duration    string               `json:"duration"`
+	Version     string               `json:"version,omitempty"`
+	Partial     bool                 `json:"partial,omitempty"`
+	Filter      string               `json:"filter,omitempty"`    // Which messages are listed, e.g. "Claude responses"
+	Preset      *config.FilterPreset `json:"preset,omitempty"`    // Filter the messages were selected with
+	GroupedBy   string               `json:"groupedBy,omitempty"` // What the messages are sectioned by, e.g. "tool"
+	Cost        float64              `json:"cost"`                // USD
+	Changes     Changes              `json:"changes"`
+	TurnStats   TurnStats            `json:"turnStats"`
+	Turns       []Turn               `json:"turns"`
+	Messages    []Message            `json:"messages"`
+}
+
+// Changes mirrors monitor.DiffStat with a stable JSON shape. The line counts are
+// approximate, taken from the inputs of the session's Write and Edit calls.
+type Changes struct {
+	Files        int `json:"files"`
+	LinesAdded   int `json:"linesAdded"`
+	LinesRemoved int `json:"linesRemoved"`
+}
+
+// TurnStats mirrors monitor.TurnStats with a stable JSON shape
+type TurnStats struct {
+	Turns          int     `json:"turns"`
+	AvgCost        float64 `json:"avgCost"`    // USD
+	MedianCost     float64 `json:"medianCost"` // USD
+	AvgToolCalls   float64 `json:"avgToolCalls"`
+	AvgDuration    string  `json:"avgDuration"`
+	MedianDuration string  `json:"medianDuration"`
+	MostExpensive  int     `json:"mostExpensiveTurn,omitempty"` // 1-based turn number
+}
+
+// Turn is one per-turn row
+type Turn struct {
+	Turn          int       `json:"turn"`
+	Start         time.Time `json:"start"`
+	Duration      string    `json:"duration"`
+	Messages      int       `json:"messages"`
+	ToolCalls     int       `json:"toolCalls"`
+	InputTokens   int       `json:"inputTokens"`
+	OutputTokens  int       `json:"outputTokens"`
+	CacheCreation int       `json:"cacheCreationTokens"`
+	CacheRead     int       `json:"cacheReadTokens"`
+	Cost          float64   `json:"cost"` // USD
+}
+
+// Message is a single message in the export
+type Message struct {
+	Index         int       `json:"index"` // 1-based position in the session
+	Turn          int       `json:"turn"`
+	Type          string    `json:"type"`
+	Role          string    `json:"role"`
+	Timestamp     time.Time `json:"timestamp"`
+	Model         string    `json:"model,omitempty"`
+	Tool          string    `json:"tool,omitempty"`
+	ToolInput     string    `json:"toolInput,omitempty"`
+	IsError       bool      `json:"isError,omitempty"`
+	Content       string    `json:"content"`
+	InputTokens   int       `json:"inputTokens,omitempty"`
+	OutputTokens  int       `json:"outputTokens,omitempty"`
+	CacheCreation int       `json:"cacheCreationTokens,omitempty"`
+	CacheRead     int       `json:"cacheReadTokens,omitempty"`
+	Cost          float64   `json:"cost,omitempty"`  // USD
+	Group         string    `json:"group,omitempty"` // Section the message is listed in when grouped, e.g. "Bash"
+}
+
+// Options select what Build lists
+type Options struct {
+	Cost    func(*monitor.Message) float64 // Cost of a message in USD
+	Include func(historyIdx int) bool      // Messages to list, by MessageHistory index; nil lists all
+	Filter  string                         // Describes the selection for the export header
+	Preset  *config.FilterPreset           // Preset the selection was made with, if any
+
+	// Group sections the listed messages by name, e.g. by tool; nil keeps them in
+	// session order. GroupBy names what they are sectioned by for the export header.
+	Group   func(*monitor.Message) string
+	GroupBy string
+}
+
+// Build converts parsed session stats into the export document. Session totals and
+// turns always cover the whole session; only the message list follows the selection.
+func Build(stats *monitor.SessionStats, opts Options) Session {
+	ts := stats.TurnStats(opts.Cost)
+	doc := Session{
+		Session:    monitor.SessionFileID(stats.FilePath),
+		File:       stats.FilePath,
+		ProjectDir: filepath.Dir(stats.FilePath),
+		Started:    stats.CreatedAt,
+		Duration:   stats.Duration.Round(time.Second).String(),
+		Version:    stats.ClaudeVersion,
+		Partial:    stats.Partial,
+		Filter:     opts.Filter,
+		Preset:     opts.Preset,
+		Changes: Changes{
+			Files:        stats.DiffStat.Files,
+			LinesAdded:   stats.DiffStat.Added,
+			LinesRemoved: stats.DiffStat.Removed,
+		},
+		TurnStats: TurnStats{
+			Turns:          ts.Turns,
+			AvgCost:        ts.AvgCost,
+			MedianCost:     ts.MedianCost,
+			AvgToolCalls:   ts.AvgToolCalls,
+			AvgDuration:    ts.AvgDuration.Round(time.Second).String(),
+			MedianDuration: ts.MedianDuration.Round(time.Second).String(),
+		},
+		Turns:    []Turn{},
+		Messages: []Message{},
+	}
+	if index, err := monitor.ParseSessionIndex(filepath.Join(doc.ProjectDir, "sessions-index.json")); err == nil {
+		doc.ProjectPath = index.OriginalPath
+	}
+	if ts.MostExpensive >= 0 {
+		doc.TurnStats.MostExpensive = stats.Turns[ts.MostExpensive].Index
+	}
+
+	for _, turn := range stats.Turns {
+		cost := turn.Cost(stats.MessageHistory, opts.Cost)
+		doc.Cost += cost
+		doc.Turns = append(doc.Turns, Turn{
+			Turn:          turn.Index,
+			Start:         turn.StartTime,
+			Duration:      turn.Duration().Round(time.Second).String(),
+			Messages:      turn.End - turn.Start,
+			ToolCalls:     turn.ToolCalls,
+			InputTokens:   turn.Tokens.Input,
+			OutputTokens:  turn.Tokens.Output,
+			CacheCreation: turn.Tokens.CacheWrite,
+			CacheRead:     turn.Tokens.CacheRead,
+			Cost:          cost,
+		})
+
+		for i := range turn.Messages(stats.MessageHistory) {
+			h := turn.Start + i
+			if opts.Include != nil && !opts.Include(h) {
+				continue
+			}
+			msg := &stats.MessageHistory[h]
+			doc.Messages = append(doc.Messages, Message{
+				Index:         h + 1,
+				Turn:          turn.Index,
+				Type:          msg.Type,
+				Role:          msg.Role,
+				Timestamp:     msg.Timestamp,
+				Model:         msg.Model,
+				Tool:          msg.ToolName,
+				ToolInput:     msg.ToolInput,
+				IsError:       msg.IsError,
+				Content:       msg.Content,
+				InputTokens:   msg.InputTokens,
+				OutputTokens:  msg.OutputTokens,
+				CacheCreation: msg.CacheCreation,
+				CacheRead:     msg.CacheRead,
+				Cost:          opts.Cost(msg),
+			})
+			if opts.Group != nil {
+				doc.Messages[len(doc.Messages)-1].Group = opts.Group(msg)
+			}
+		}
+	}
+	if opts.Group != nil {
+		doc.GroupedBy = opts.GroupBy
+		groupMessages(doc.Messages)
+	}
+	return doc
+}
+
+// groupMessages orders messages by their group, the group with the most messages first
+// and ties by name, keeping the session order within each group
+func groupMessages(messages []Message) {
+	counts := groupCounts(messages)
+	slices.SortStableFunc(messages, func(a, b Message) int {
+		if c := cmp.Compare(counts[b.Group], counts[a.Group]); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Group, b.Group)
+	})
+}
+
+// groupCounts counts the messages in each group
+func groupCounts(messages []Message) map[string]int {
+	counts := make(map[string]int)
+	for _, msg := range messages {
+		counts[msg.Group]++
+	}
+	return counts
+}
+
+// header returns the label/value pairs describing the session and the selection
+func header(doc Session) [][2]string {
+	project := doc.ProjectPath
+	if project == "" {
+		project = doc.ProjectDir
+	}
+	lines := [][2]string{
+		{"File", doc.File},
+		{"Project", project},
+		{"Started", doc.Started.Format("2006-01-02 15:04:05 MST")},
+		{"Duration", doc.Duration},
+		{"Cost", render.FormatCost(doc.Cost, render.TotalPrecision)},
+	}
+	if doc.Changes.Files > 0 {
+		d := monitor.DiffStat{Files: doc.Changes.Files, Added: doc.Changes.LinesAdded, Removed: doc.Changes.LinesRemoved}
+		lines = append(lines, [2]string{"Changes", "≈ " + render.DiffStatText(d) + " (approximate, from Write and Edit calls)"})
+	}
+	if doc.Version != "" {
+		lines = append(lines, [2]string{"Claude Code", doc.Version})
+	}
+	if doc.Partial {
+		lines = append(lines, [2]string{"Partial", "parsing stopped before the end of the file"})
+	}
+	filter := doc.Filter
+	if filter == "" {
+		filter = "all messages"
+	}
+	lines = append(lines, [2]string{"Messages", fmt.Sprintf("%d (%s)", len(doc.Messages), filter)})
+	if doc.Preset != nil {
+		match, _ := json.Marshal(doc.Preset.Match)
+		lines = append(lines, [2]string{"Preset", fmt.Sprintf("%s %s", doc.Preset.Name, match)})
+	}
+	if doc.GroupedBy != "" {
+		lines = append(lines, [2]string{"Grouped by", doc.GroupedBy})
+	}
+	return lines
+}
+
+// groupName names a group in section headings; messages without one, e.g. a tool
+// call's result when grouping by tool, are listed under "other"
+func groupName(group string) string {
+	if group == "" {
+		return "other"
+	}
+	return group
+}
+
+// title returns a message's heading, e.g. "#12 · assistant · 14:03:09 · turn 3 · Bash · $0.0123"
+func title(msg Message) string {
+	parts := []string{fmt.Sprintf("#%d", msg.Index), msg.Role, msg.Timestamp.Format("15:04:05"), fmt.Sprintf("turn %d", msg.Turn)}
+	if msg.Type == "tool_result" {
+		parts[1] = "tool result"
+	}
+	if msg.Tool != "" {
+		parts = append(parts, msg.Tool)
+	}
+	if msg.IsError {
+		parts = append(parts, "error")
+	}
+	if msg.Cost > 0 {
+		parts = append(parts, render.FormatCost(msg.Cost, render.MessagePrecision))
+	}
+	return strings.Join(parts, " · ")
+}
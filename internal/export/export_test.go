@@ -0,0 +1,243 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/")
+
+// testStats returns a session of two turns, the first running a tool whose output
+// contains a code fence
+func testStats() *monitor.SessionStats {
+	start := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	return &monitor.SessionStats{
+		FilePath:  "/home/me/.claude/projects/-home-me-app/3f2a9c1e.jsonl",
+		CreatedAt: start,
+		MessageHistory: []monitor.Message{
+			{Type: "prompt", Role: "user", Content: "run the tests", Timestamp: start},
+			{Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash", ToolInput: `{"command":"go test"}`, OutputTokens: 100, Timestamp: start.Add(time.Second)},
+			{Type: "tool_result", Role: "user", Content: "FAIL\n```\nwant 1\n```", IsError: true, Timestamp: start.Add(2 * time.Second)},
+			{Type: "prompt", Role: "user", Content: "fix it", Timestamp: start.Add(time.Minute)},
+			{Type: "assistant_response", Role: "assistant", Content: "Fixed.", OutputTokens: 50, Timestamp: start.Add(61 * time.Second)},
+		},
+		Turns: []monitor.Turn{
+			{Index: 1, Start: 0, End: 3, StartTime: start},
+			{Index: 2, Start: 3, End: 5, StartTime: start.Add(time.Minute)},
+		},
+		DiffStat: monitor.DiffStat{Files: 1, Added: 3, Removed: 1},
+	}
+}
+
+func testCost(msg *monitor.Message) float64 {
+	return float64(msg.OutputTokens) / 100
+}
+
+// TestParseFormat tests that formats are found by name and alias, whatever the case
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "json", want: "json"},
+		{in: "Markdown", want: "markdown"},
+		{in: "md", want: "markdown"},
+		{in: "txt", want: "text"},
+		{in: "html", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if (err != nil) != tt.wantErr || got.Name != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q, error %v", tt.in, got.Name, err, tt.want, tt.wantErr)
+		}
+	}
+	if _, err := ParseFormat("html"); err == nil || !strings.Contains(err.Error(), "json, markdown or text") {
+		t.Errorf("error for an unknown format does not list the formats: %v", err)
+	}
+}
+
+// TestFormatsMatchConfig tests that the config accepts exactly the registered formats
+// and their aliases for export.format
+func TestFormatsMatchConfig(t *testing.T) {
+	accepted := []string{"md", "MARKDOWN"}
+	for _, f := range formats {
+		accepted = append(accepted, f.Name)
+	}
+	for _, name := range accepted {
+		if _, err := config.Parse([]byte(`{"export":{"format":"`+name+`"}}`), "config.json"); err != nil {
+			t.Errorf("config rejects export format %q: %v", name, err)
+		}
+	}
+	if _, err := config.Parse([]byte(`{"export":{"format":"html"}}`), "config.json"); err == nil || !strings.Contains(err.Error(), NameList()) {
+		t.Errorf("config with an unknown export format: %v, want an error listing %s", err, NameList())
+	}
+}
+
+// TestRegisterTwice tests that a format name or alias cannot be taken twice
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering the md alias again did not panic")
+		}
+	}()
+	Register(Format{Name: "org", Aliases: []string{"md"}, Ext: ".org", New: newTextExporter})
+}
+
+func TestBuildSelection(t *testing.T) {
+	stats := testStats()
+	doc := Build(stats, Options{
+		Cost:    testCost,
+		Include: func(i int) bool { return i == 1 || i == 2 },
+		Filter:  "message #2 with #3",
+	})
+
+	// Totals and turns cover the whole session, the message list only the selection
+	if doc.Session != "3f2a9c1e" || doc.Cost != 1.5 || len(doc.Turns) != 2 {
+		t.Errorf("session = %q, cost = %g, %d turns; want 3f2a9c1e, 1.5, 2", doc.Session, doc.Cost, len(doc.Turns))
+	}
+	if len(doc.Messages) != 2 || doc.Messages[0].Index != 2 || doc.Messages[1].Index != 3 || !doc.Messages[1].IsError {
+		t.Fatalf("unexpected messages: %+v", doc.Messages)
+	}
+
+	all := Build(stats, Options{Cost: testCost})
+	if len(all.Messages) != 5 || all.Filter != "" {
+		t.Errorf("without Include: %d messages, filter %q; want 5 and none", len(all.Messages), all.Filter)
+	}
+}
+
+// TestWriteGolden pins the layout of every registered format in
+// testdata/session.*.golden, written from the shared fixture session
+func TestWriteGolden(t *testing.T) {
+	doc := Build(testStats(), Options{Cost: testCost})
+	for _, format := range formats {
+		var buf bytes.Buffer
+		if err := Write(&buf, doc, format); err != nil {
+			t.Fatalf("%s: %v", format.Name, err)
+		}
+		if format.Name == "json" && !json.Valid(buf.Bytes()) {
+			t.Errorf("invalid JSON:\n%s", buf.String())
+		}
+		assertGolden(t, "session."+format.Name+".golden", buf.String())
+	}
+}
+
+// TestWriteSelection tests the header and messages of an export of one turn
+func TestWriteSelection(t *testing.T) {
+	doc := Build(testStats(), Options{
+		Cost:    testCost,
+		Include: func(i int) bool { return i < 3 },
+		Filter:  "turn 1",
+	})
+	format, _ := ParseFormat("markdown")
+	var buf bytes.Buffer
+	if err := Write(&buf, doc, format); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"- **Messages:** 3 (turn 1)", "## #3 · tool result", "\n````\nFAIL\n```\nwant 1\n```\n````\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "Fixed.") {
+		t.Errorf("output lists a message of turn 2:\n%s", buf.String())
+	}
+}
+
+// TestWriteGrouped tests that tool calls grouped by tool are listed tool by tool, the
+// most used first and in session order within each, under a heading per tool
+func TestWriteGrouped(t *testing.T) {
+	stats := testStats()
+	stats.MessageHistory[4].ToolName = "Edit"
+	stats.MessageHistory = append(stats.MessageHistory, monitor.Message{
+		Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash",
+		ToolInput: `{"command":"go test ./..."}`, OutputTokens: 20, Timestamp: stats.CreatedAt.Add(62 * time.Second),
+	})
+	stats.Turns[1].End = 6
+	doc := Build(stats, Options{
+		Cost:    testCost,
+		Include: func(i int) bool { return stats.MessageHistory[i].ToolName != "" },
+		Filter:  "tool calls",
+		Group:   func(msg *monitor.Message) string { return msg.ToolName },
+		GroupBy: "tool",
+	})
+
+	var order []string
+	for _, msg := range doc.Messages {
+		order = append(order, fmt.Sprintf("%s#%d", msg.Group, msg.Index))
+	}
+	if want := []string{"Bash#2", "Bash#6", "Edit#5"}; !slices.Equal(order, want) {
+		t.Errorf("messages %q, want %q", order, want)
+	}
+	for _, name := range []string{"markdown", "text"} {
+		format, _ := ParseFormat(name)
+		var buf bytes.Buffer
+		if err := Write(&buf, doc, format); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		assertGolden(t, "grouped."+name+".golden", buf.String())
+	}
+}
+
+// TestWriteReport pins the layout of the report exports in testdata/report.*.golden
+func TestWriteReport(t *testing.T) {
+	day := time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)
+	opus := monitor.TokenCounts{Input: 1_200, CacheWrite: 30_000, CacheRead: 410_000, Output: 8_000}
+	haiku := monitor.TokenCounts{Input: 800, CacheWrite: 4_000, CacheRead: 20_000, Output: 1_500}
+	total := monitor.TokenCounts{Input: 2_000, CacheWrite: 34_000, CacheRead: 430_000, Output: 9_500}
+	report := &monitor.Report{
+		Range: monitor.DateRange{From: day.AddDate(0, 0, -6), To: day.AddDate(0, 0, 1)},
+		Total: monitor.ProjectStats{
+			Sessions: 3, Agents: 1, Tokens: total, Cost: 1.62,
+			Models: []monitor.ModelUsage{
+				{Model: "claude-opus-4-5", Responses: 40, Tokens: opus, Cost: 1.5},
+				{Model: "claude-haiku-4-5", Responses: 12, Tokens: haiku, Cost: 0.12},
+			},
+			Days: []monitor.DayActivity{{Day: day.AddDate(0, 0, -2), Sessions: 1, Messages: 30}, {Day: day, Sessions: 2, Messages: 74}},
+		},
+		Projects: []monitor.ProjectReport{
+			{Name: "/home/me/app", Sessions: 2, Messages: 80, Tokens: opus, Cost: 1.5},
+			{Name: "/home/me/a|b", Sessions: 1, Messages: 24, Tokens: haiku, Cost: 0.12},
+		},
+	}
+
+	var md, csv bytes.Buffer
+	if err := ReportMarkdown(&md, report, "Last 7 days", 5); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "report.markdown.golden", md.String())
+	if err := ReportCSV(&csv, report, 5); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "report.csv.golden", csv.String())
+}
+
+// assertGolden compares got with testdata/name, rewriting the file with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./internal/export -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs (run with -update to accept)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/thieso2/promptwatch/internal/config"
+)
+
+func init() {
+	// The config accepts exactly the registered formats for export.format
+	config.SetExportFormatCheck(func(name string) error {
+		_, err := ParseFormat(name)
+		return err
+	})
+}
+
+// Exporter writes an export document in one format. Write calls Begin with the
+// session, whose header fields and message count describe the export, then Message for
+// each listed message in order, then End to finish the document.
+type Exporter interface {
+	Begin(doc Session) error
+	Message(msg Message) error
+	End() error
+}
+
+// Format is a registered export format
+type Format struct {
+	Name    string                     // Name selecting the format, e.g. "markdown"
+	Aliases []string                   // Other accepted names, e.g. "md"
+	Ext     string                     // File name extension, e.g. ".md"
+	New     func(w io.Writer) Exporter // Creates an exporter writing to w
+}
+
+// formats are the registered formats, sorted by name
+var formats []Format
+
+// Register adds an export format, which the CLI and the session detail view then
+// accept by name. Formats register themselves from init; a name that is already taken
+// panics.
+func Register(f Format) {
+	for _, name := range append([]string{f.Name}, f.Aliases...) {
+		if _, err := ParseFormat(name); err == nil {
+			panic(fmt.Sprintf("export: format %q registered twice", name))
+		}
+	}
+	formats = append(formats, f)
+	sort.Slice(formats, func(i, j int) bool { return formats[i].Name < formats[j].Name })
+}
+
+// Names returns the names of the registered formats, sorted
+func Names() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// ParseFormat looks up a registered format by its name or one of its aliases, ignoring
+// case
+func ParseFormat(s string) (Format, error) {
+	for _, f := range formats {
+		if strings.EqualFold(s, f.Name) {
+			return f, nil
+		}
+		for _, alias := range f.Aliases {
+			if strings.EqualFold(s, alias) {
+				return f, nil
+			}
+		}
+	}
+	return Format{}, fmt.Errorf("unknown export format %q (want %s)", s, NameList())
+}
+
+// NameList lists the format names for messages, e.g. "json, markdown or text"
+func NameList() string {
+	names := Names()
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// Write writes the document in the given format
+func Write(w io.Writer, doc Session, f Format) error {
+	e := f.New(w)
+	if err := e.Begin(doc); err != nil {
+		return err
+	}
+	for _, msg := range doc.Messages {
+		if err := e.Message(msg); err != nil {
+			return err
+		}
+	}
+	return e.End()
+}
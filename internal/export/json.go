@@ -0,0 +1,38 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func init() {
+	Register(Format{Name: "json", Ext: ".json", New: newJSONExporter})
+}
+
+// jsonExporter writes the whole document as one indented JSON object
+type jsonExporter struct {
+	w   io.Writer
+	doc Session
+}
+
+func newJSONExporter(w io.Writer) Exporter {
+	return &jsonExporter{w: w}
+}
+
+// Begin keeps the session, collecting the messages anew as they are written
+func (e *jsonExporter) Begin(doc Session) error {
+	e.doc = doc
+	e.doc.Messages = make([]Message, 0, len(doc.Messages))
+	return nil
+}
+
+func (e *jsonExporter) Message(msg Message) error {
+	e.doc.Messages = append(e.doc.Messages, msg)
+	return nil
+}
+
+func (e *jsonExporter) End() error {
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e.doc)
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	Register(Format{Name: "markdown", Aliases: []string{"md"}, Ext: ".md", New: newMarkdownExporter})
+}
+
+// markdownExporter writes a Markdown document, one section per message. Grouped
+// messages are listed under a "## Bash (23)" heading per group, their own headings a
+// level down.
+type markdownExporter struct {
+	w      io.Writer
+	counts map[string]int // Messages per group; nil when the document is not grouped
+	group  *string        // Group of the message written last; nil before the first
+}
+
+func newMarkdownExporter(w io.Writer) Exporter {
+	return &markdownExporter{w: w}
+}
+
+func (e *markdownExporter) Begin(doc Session) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Session %s\n\n", doc.Session)
+	for _, line := range header(doc) {
+		fmt.Fprintf(&b, "- **%s:** %s\n", line[0], line[1])
+	}
+	if doc.GroupedBy != "" {
+		e.counts = groupCounts(doc.Messages)
+	}
+	_, err := b.WriteTo(e.w)
+	return err
+}
+
+func (e *markdownExporter) Message(msg Message) error {
+	var b bytes.Buffer
+	heading := "##"
+	if e.counts != nil {
+		if e.group == nil || *e.group != msg.Group {
+			fmt.Fprintf(&b, "\n## %s (%d)\n", groupName(msg.Group), e.counts[msg.Group])
+			e.group = &msg.Group
+		}
+		heading = "###"
+	}
+	fmt.Fprintf(&b, "\n%s %s\n\n", heading, title(msg))
+	switch {
+	case msg.Type == "tool_result":
+		b.WriteString(fence(msg.Content, ""))
+	case msg.ToolInput != "" && strings.HasPrefix(msg.Content, "Called tool: "):
+		// The content only names the tool, which the heading already shows
+	default:
+		b.WriteString(strings.TrimRight(msg.Content, "\n") + "\n")
+		if msg.ToolInput != "" {
+			b.WriteString("\n")
+		}
+	}
+	if msg.ToolInput != "" {
+		b.WriteString(fence(msg.ToolInput, "json"))
+	}
+	_, err := b.WriteTo(e.w)
+	return err
+}
+
+func (e *markdownExporter) End() error {
+	return nil
+}
+
+// fence wraps text in a fenced code block longer than any backtick run inside it
+func fence(text, lang string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	marker := strings.Repeat("`", max(3, longest+1))
+	return marker + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + marker + "\n"
+}
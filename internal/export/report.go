@@ -0,0 +1,92 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// ReportMarkdown writes a report across projects as Markdown, with the sections of the
+// reports view: totals, projects, models and the busiest days, as many as busiest.
+// title names the range, e.g. "Last 7 days".
+func ReportMarkdown(w io.Writer, r *monitor.Report, title string, busiest int) error {
+	s := &r.Total
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Report: %s\n\n", title)
+	fmt.Fprintf(&b, "- **Days:** %s (%d days, %d active)\n", r.Range, r.Range.Days(), len(s.Days))
+	fmt.Fprintf(&b, "- **Projects:** %d\n", len(r.Projects))
+	fmt.Fprintf(&b, "- **Sessions:** %d (+%d agents)\n", s.Sessions, s.Agents)
+	fmt.Fprintf(&b, "- **Tokens:** %s\n", render.TokenBreakdown(s.Tokens))
+	fmt.Fprintf(&b, "- **Cost:** %s\n", render.FormatCost(s.Cost, render.TotalPrecision))
+
+	if len(r.Projects) > 0 {
+		b.WriteString("\n## Projects\n\n| Project | Share | Tokens | Sessions | Messages | Cost |\n|---|--:|--:|--:|--:|--:|\n")
+		for _, p := range r.Projects {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %s |\n", cell(p.Name), share(p.Tokens, s.Tokens),
+				p.Tokens.Total(), p.Sessions, p.Messages, render.FormatCost(p.Cost, render.TotalPrecision))
+		}
+	}
+	if len(s.Models) > 0 {
+		b.WriteString("\n## Models\n\n| Model | Share | Tokens | Responses | Cost |\n|---|--:|--:|--:|--:|\n")
+		for _, u := range s.Models {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %s |\n", cell(u.Model), share(u.Tokens, s.Tokens),
+				u.Tokens.Total(), u.Responses, render.FormatCost(u.Cost, render.TotalPrecision))
+		}
+	}
+	if days := s.BusiestDays(busiest); len(days) > 0 {
+		b.WriteString("\n## Busiest days\n\n| Day | Messages | Sessions |\n|---|--:|--:|\n")
+		for _, day := range days {
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", day.Day.Format("2006-01-02 Mon"), day.Messages, day.Sessions)
+		}
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// ReportCSV writes a report across projects as CSV, one record per row of the reports
+// view: the totals, then each project, model and busiest day, told apart by the
+// section column. Columns that do not apply to a section are left empty.
+func ReportCSV(w io.Writer, r *monitor.Report, busiest int) error {
+	s := &r.Total
+	out := csv.NewWriter(w)
+	out.Write([]string{"section", "name", "from", "to", "sessions", "responses", "messages",
+		"inputTokens", "cacheWriteTokens", "cacheReadTokens", "outputTokens", "cost"})
+	from, to := r.Range.From.Format("2006-01-02"), r.Range.To.AddDate(0, 0, -1).Format("2006-01-02")
+	record := func(section, name string, sessions, responses, messages string, tokens monitor.TokenCounts, cost float64) {
+		out.Write([]string{section, name, from, to, sessions, responses, messages,
+			strconv.Itoa(tokens.Input), strconv.Itoa(tokens.CacheWrite), strconv.Itoa(tokens.CacheRead), strconv.Itoa(tokens.Output),
+			strconv.FormatFloat(cost, 'f', 4, 64)})
+	}
+	record("total", "", strconv.Itoa(s.Sessions), "", "", s.Tokens, s.Cost)
+	for _, p := range r.Projects {
+		record("project", p.Name, strconv.Itoa(p.Sessions), "", strconv.Itoa(p.Messages), p.Tokens, p.Cost)
+	}
+	for _, u := range s.Models {
+		record("model", u.Model, "", strconv.Itoa(u.Responses), "", u.Tokens, u.Cost)
+	}
+	for _, day := range s.BusiestDays(busiest) {
+		out.Write([]string{"day", day.Day.Format("2006-01-02"), from, to, strconv.Itoa(day.Sessions), "", strconv.Itoa(day.Messages),
+			"", "", "", "", ""})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// share formats part's share of the tokens in total, e.g. "72%"
+func share(part, total monitor.TokenCounts) string {
+	if total.Total() == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(part.Total())*100/float64(total.Total()))
+}
+
+// cell escapes the pipes in text for a Markdown table cell
+func cell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	Register(Format{Name: "text", Aliases: []string{"txt"}, Ext: ".txt", New: newTextExporter})
+}
+
+// textExporter writes plain text, one block per message. Grouped messages are listed
+// under a "== Bash (23) ==" line per group.
+type textExporter struct {
+	w      io.Writer
+	counts map[string]int // Messages per group; nil when the document is not grouped
+	group  *string        // Group of the message written last; nil before the first
+}
+
+func newTextExporter(w io.Writer) Exporter {
+	return &textExporter{w: w}
+}
+
+func (e *textExporter) Begin(doc Session) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Session %s\n", doc.Session)
+	for _, line := range header(doc) {
+		fmt.Fprintf(&b, "%s: %s\n", line[0], line[1])
+	}
+	if doc.GroupedBy != "" {
+		e.counts = groupCounts(doc.Messages)
+	}
+	_, err := b.WriteTo(e.w)
+	return err
+}
+
+func (e *textExporter) Message(msg Message) error {
+	var b bytes.Buffer
+	if e.counts != nil && (e.group == nil || *e.group != msg.Group) {
+		fmt.Fprintf(&b, "\n== %s (%d) ==\n", groupName(msg.Group), e.counts[msg.Group])
+		e.group = &msg.Group
+	}
+	fmt.Fprintf(&b, "\n[%s]\n", title(msg))
+	if msg.ToolInput == "" || !strings.HasPrefix(msg.Content, "Called tool: ") {
+		b.WriteString(strings.TrimRight(msg.Content, "\n") + "\n")
+	}
+	if msg.ToolInput != "" {
+		b.WriteString(msg.ToolInput + "\n")
+	}
+	_, err := b.WriteTo(e.w)
+	return err
+}
+
+func (e *textExporter) End() error {
+	return nil
+}
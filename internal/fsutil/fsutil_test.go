@@ -0,0 +1,70 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteAtomic tests that files are created and replaced without leaving temporary files behind
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	for _, content := range []string{`{"a":1}`, `{"a":2}`} {
+		if err := WriteAtomic(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteAtomic(%s) failed: %v", content, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Errorf("file = %q, %v; want %q", data, err, content)
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only state.json", len(entries))
+	}
+}
+
+// TestWriteAtomicKeepsOldFileOnFailure tests that a failed write leaves the previous file intact
+func TestWriteAtomicKeepsOldFileOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := WriteAtomic(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in place of the target makes the final rename fail
+	target := filepath.Join(dir, "taken")
+	if err := os.MkdirAll(filepath.Join(target, "child"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAtomic(target, []byte("new"), 0o644); err == nil {
+		t.Error("WriteAtomic over a non-empty directory should fail")
+	}
+	if err := WriteAtomic(filepath.Join(dir, "missing", "state.json"), []byte("new"), 0o644); err == nil {
+		t.Error("WriteAtomic into a missing directory should fail")
+	}
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != "old" {
+		t.Errorf("old file = %q, %v; want it untouched", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("directory holds %d entries, want no temporary files left", len(entries))
+	}
+}
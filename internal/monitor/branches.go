@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// BranchChange is a git branch a session switched to and the time of its first
+// message on it
+type BranchChange struct {
+	Branch string
+	Since  time.Time
+}
+
+// branchChanges lists the git branches of the messages in order, one entry per switch.
+// Messages without a branch stay on the branch before them.
+func branchChanges(history []Message) []BranchChange {
+	var changes []BranchChange
+	for _, msg := range history {
+		if msg.GitBranch == "" || (len(changes) > 0 && changes[len(changes)-1].Branch == msg.GitBranch) {
+			continue
+		}
+		changes = append(changes, BranchChange{Branch: msg.GitBranch, Since: msg.Timestamp})
+	}
+	return changes
+}
+
+// addBranch appends a branch to the list unless it is empty or already listed
+func addBranch(branches []string, branch string) []string {
+	if branch == "" || slices.Contains(branches, branch) {
+		return branches
+	}
+	return append(branches, branch)
+}
+
+// BranchLabel names the branch a session ended on, followed by "+N" for the N other
+// branches it used, e.g. "main +1"
+func BranchLabel(last string, branches []string) string {
+	others := 0
+	for _, b := range branches {
+		if b != last {
+			others++
+		}
+	}
+	if last == "" || others == 0 {
+		return last
+	}
+	return fmt.Sprintf("%s +%d", last, others)
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBranchChanges(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "branches.jsonl")
+	testData := `{"type":"user","gitBranch":"main","timestamp":"2026-01-12T09:00:00Z","message":{"role":"user","content":"first"}}
+{"type":"assistant","gitBranch":"main","timestamp":"2026-01-12T09:01:00Z","message":{"role":"assistant","content":"ok"}}
+{"type":"user","gitBranch":"feature/x","timestamp":"2026-01-12T09:10:00Z","message":{"role":"user","content":"second"}}
+{"type":"assistant","timestamp":"2026-01-12T09:11:00Z","message":{"role":"assistant","content":"ok"}}
+{"type":"user","gitBranch":"main","timestamp":"2026-01-12T09:20:00Z","message":{"role":"user","content":"third"}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	var got []string
+	for _, c := range stats.Branches {
+		got = append(got, c.Branch+"@"+c.Since.Format("15:04"))
+	}
+	if want := []string{"main@09:00", "feature/x@09:10", "main@09:20"}; !slices.Equal(got, want) {
+		t.Errorf("Branches: got %q, want %q", got, want)
+	}
+
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if metadata.GitBranch != "main" || metadata.LastBranch != "main" {
+		t.Errorf("GitBranch, LastBranch: got %q, %q, want main, main", metadata.GitBranch, metadata.LastBranch)
+	}
+	if want := []string{"main", "feature/x"}; !slices.Equal(metadata.Branches, want) {
+		t.Errorf("metadata Branches: got %q, want %q", metadata.Branches, want)
+	}
+}
+
+func TestBranchLabel(t *testing.T) {
+	tests := []struct {
+		last     string
+		branches []string
+		want     string
+	}{
+		{"", nil, ""},
+		{"main", []string{"main"}, "main"},
+		{"main", []string{"main", "feature/x"}, "main +1"},
+		{"fix", []string{"main", "feature/x", "fix"}, "fix +2"},
+	}
+	for _, tt := range tests {
+		if got := BranchLabel(tt.last, tt.branches); got != tt.want {
+			t.Errorf("BranchLabel(%q, %q) = %q, want %q", tt.last, tt.branches, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Session files may be archived with gzip (<session-id>.jsonl.gz); they are read
+// transparently wherever plain session files are
+const (
+	sessionExt           = ".jsonl"
+	compressedSessionExt = ".jsonl.gz"
+)
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// IsSessionFile reports whether a file name is a session file, plain or gzip-compressed
+func IsSessionFile(name string) bool {
+	return strings.HasSuffix(name, sessionExt) || strings.HasSuffix(name, compressedSessionExt)
+}
+
+// IsCompressedSessionFile reports whether a session file name has the .jsonl.gz extension
+func IsCompressedSessionFile(name string) bool {
+	return strings.HasSuffix(name, compressedSessionExt)
+}
+
+// SessionFileID returns the session ID encoded in a session file name
+// ("<id>.jsonl" or "<id>.jsonl.gz")
+func SessionFileID(path string) string {
+	name := filepath.Base(path)
+	if IsCompressedSessionFile(name) {
+		return strings.TrimSuffix(name, compressedSessionExt)
+	}
+	return strings.TrimSuffix(name, sessionExt)
+}
+
+// sessionReader returns a reader of the uncompressed session data in r, which is
+// gzip-decompressed if the file name or the magic bytes say it is compressed, and
+// whether it was
+func sessionReader(r io.Reader, path string) (io.Reader, bool, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	isGzip := err == nil && magic[0] == gzipMagic[0] && magic[1] == gzipMagic[1]
+	if !isGzip && !IsCompressedSessionFile(path) {
+		return br, false, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, true, fmt.Errorf("cannot read compressed session file: %w", err)
+	}
+	return zr, true, nil
+}
+
+// isCompressedFile reports whether an open session file is gzip-compressed, judging
+// by its name or its first bytes
+func isCompressedFile(file *os.File, path string) bool {
+	if IsCompressedSessionFile(path) {
+		return true
+	}
+	magic := make([]byte, len(gzipMagic))
+	_, err := file.ReadAt(magic, 0)
+	return err == nil && magic[0] == gzipMagic[0] && magic[1] == gzipMagic[1]
+}
+
+// sessionFile is an open session file that reads uncompressed data
+type sessionFile struct {
+	io.Reader
+	file       *os.File
+	compressed bool
+}
+
+func (f *sessionFile) Close() error {
+	return f.file.Close()
+}
+
+// openSessionFile opens a session file for reading, decompressing it if the name or
+// the magic bytes say it is gzip-compressed
+func openSessionFile(path string) (*sessionFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, compressed, err := sessionReader(file, path)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sessionFile{Reader: r, file: file, compressed: compressed}, nil
+}
+
+// UncompressedSize returns the size of a session file's data once decompressed, and
+// whether the file is compressed at all. For gzip files it is taken from the size
+// recorded at the end of the stream (modulo 4 GiB, as gzip stores it).
+func UncompressedSize(path string) (size int64, compressed bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !isCompressedFile(file, path) {
+		return info.Size(), false, nil
+	}
+	trailer := make([]byte, 4)
+	if info.Size() < 4 {
+		return 0, true, fmt.Errorf("compressed session file is truncated")
+	}
+	if _, err := file.ReadAt(trailer, info.Size()-4); err != nil {
+		return 0, true, fmt.Errorf("cannot read compressed session size: %w", err)
+	}
+	return int64(binary.LittleEndian.Uint32(trailer)), true, nil
+}
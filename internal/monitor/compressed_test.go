@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeGzip writes data gzip-compressed to path
+func writeGzip(t *testing.T, path string, data []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionFileNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		session bool
+		id      string
+	}{
+		{"3f2a9c1e.jsonl", true, "3f2a9c1e"},
+		{"3f2a9c1e.jsonl.gz", true, "3f2a9c1e"},
+		{"agent-7b.jsonl.gz", true, "agent-7b"},
+		{"sessions-index.json", false, "sessions-index.json"},
+		{"notes.gz", false, "notes.gz"},
+	}
+	for _, tt := range tests {
+		if got := IsSessionFile(tt.name); got != tt.session {
+			t.Errorf("IsSessionFile(%q) = %v, want %v", tt.name, got, tt.session)
+		}
+		if got := SessionFileID(filepath.Join("/tmp", tt.name)); got != tt.id {
+			t.Errorf("SessionFileID(%q) = %q, want %q", tt.name, got, tt.id)
+		}
+	}
+	if !IsAgentFile("agent-7b.jsonl.gz") {
+		t.Error("IsAgentFile should accept compressed agent sessions")
+	}
+}
+
+// TestCompressedSessionFiles tests that gzip-compressed sessions read like plain ones
+func TestCompressedSessionFiles(t *testing.T) {
+	plain := filepath.Join("testdata", "sample_session.jsonl")
+	data, err := os.ReadFile(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	compressed := filepath.Join(dir, "sample.jsonl.gz")
+	writeGzip(t, compressed, data)
+	// Recognized by the magic bytes alone
+	misnamed := filepath.Join(dir, "misnamed.jsonl")
+	writeGzip(t, misnamed, data)
+
+	want, err := ParseSessionFile(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMeta, err := GetSessionMetadata(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{compressed, misnamed} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			stats, err := ParseSessionFile(path)
+			if err != nil {
+				t.Fatalf("ParseSessionFile failed: %v", err)
+			}
+			if len(stats.MessageHistory) != len(want.MessageHistory) || stats.TotalMessages != want.TotalMessages {
+				t.Errorf("ParseSessionFile: %d messages (%d entries), want %d (%d)",
+					len(stats.MessageHistory), stats.TotalMessages, len(want.MessageHistory), want.TotalMessages)
+			}
+
+			tail, err := ParseSessionTail(path, 64)
+			if err != nil {
+				t.Fatalf("ParseSessionTail failed: %v", err)
+			}
+			if tail.Partial || len(tail.MessageHistory) != len(want.MessageHistory) {
+				t.Errorf("ParseSessionTail: partial %v with %d messages, want the whole file", tail.Partial, len(tail.MessageHistory))
+			}
+
+			meta, err := GetSessionMetadata(path)
+			if err != nil {
+				t.Fatalf("GetSessionMetadata failed: %v", err)
+			}
+			if meta.MessageCount != wantMeta.MessageCount || meta.FirstPrompt != wantMeta.FirstPrompt {
+				t.Errorf("GetSessionMetadata = %d messages, %q; want %d, %q",
+					meta.MessageCount, meta.FirstPrompt, wantMeta.MessageCount, wantMeta.FirstPrompt)
+			}
+
+			size, isCompressed, err := UncompressedSize(path)
+			if err != nil || !isCompressed || size != int64(len(data)) {
+				t.Errorf("UncompressedSize = %d, %v, %v; want %d, true", size, isCompressed, err, len(data))
+			}
+		})
+	}
+
+	if size, isCompressed, err := UncompressedSize(plain); err != nil || isCompressed || size != int64(len(data)) {
+		t.Errorf("UncompressedSize(plain) = %d, %v, %v; want %d, false", size, isCompressed, err, len(data))
+	}
+
+	// A .gz name on plain data is an error rather than garbage
+	fake := filepath.Join(dir, "fake.jsonl.gz")
+	if err := os.WriteFile(fake, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSessionFile(fake); err == nil {
+		t.Error("ParseSessionFile should fail for a .gz file that is not compressed")
+	}
+}
+
+// TestFindSessionsIncludesCompressed tests that archived sessions are listed with their ID
+func TestFindSessionsIncludesCompressed(t *testing.T) {
+	projects := t.TempDir()
+	SetProjectsDir(projects)
+	t.Cleanup(func() { SetProjectsDir("") })
+
+	sessionDir := filepath.Join(projects, convertPathToSessionDirName("/home/demo/acme-api"))
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	line := []byte(`{"type":"user","timestamp":"2026-01-09T14:00:00.000Z","message":{"role":"user","content":"hello"}}` + "\n")
+	if err := os.WriteFile(filepath.Join(sessionDir, "plain.jsonl"), line, 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeGzip(t, filepath.Join(sessionDir, "archived.jsonl.gz"), line)
+
+	sessions, err := FindSessionsForDirectory("/home/demo/acme-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	for _, s := range sessions {
+		ids[s.ID] = s.Err == nil
+	}
+	if len(sessions) != 2 || !ids["plain"] || !ids["archived"] {
+		t.Errorf("sessions = %+v, want plain and archived, both readable", sessions)
+	}
+}
@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultContextWindow is the context window size assumed for models not listed in contextWindows
+const DefaultContextWindow = 200_000
+
+// contextWindows maps model ID substrings to their context window size in tokens
+// The first matching entry wins, so more specific entries come first.
+var contextWindows = []struct {
+	match  string
+	tokens int
+}{
+	{"[1m]", 1_000_000}, // Extended-context variants, e.g. "claude-sonnet-4-5[1m]"
+	{"claude-", DefaultContextWindow},
+}
+
+// contextWindowOverrides are user-configured window sizes, checked before the built-in table
+var contextWindowOverrides []struct {
+	match  string
+	tokens int
+}
+
+// SetContextWindows overrides context window sizes for models whose ID contains the given
+// substring, e.g. for new models not yet in the built-in table. Longer matches win.
+func SetContextWindows(windows map[string]int) {
+	contextWindowOverrides = nil
+	for match, tokens := range windows {
+		if match == "" || tokens <= 0 {
+			continue
+		}
+		contextWindowOverrides = append(contextWindowOverrides, struct {
+			match  string
+			tokens int
+		}{match, tokens})
+	}
+	sort.Slice(contextWindowOverrides, func(i, j int) bool {
+		return len(contextWindowOverrides[i].match) > len(contextWindowOverrides[j].match)
+	})
+}
+
+// ContextWindow returns the context window size in tokens for a model ID
+func ContextWindow(model string) int {
+	for _, w := range contextWindowOverrides {
+		if strings.Contains(model, w.match) {
+			return w.tokens
+		}
+	}
+	for _, w := range contextWindows {
+		if strings.Contains(model, w.match) {
+			return w.tokens
+		}
+	}
+	return DefaultContextWindow
+}
+
+// ContextTokens approximates how full the context window was when the message was sent:
+// everything the model read (fresh input plus cache reads and writes)
+func (m Message) ContextTokens() int {
+	return m.InputTokens + m.CacheRead + m.CacheCreation
+}
+
+// ContextGrowth returns, for each message of a session history, how many tokens the
+// context grew by since the previous message with usage data: the files read, tool
+// output and prompts added in between. It is negative after a compaction. Messages
+// without usage data and the first assistant response report 0.
+func ContextGrowth(history []Message) []int {
+	growth := make([]int, len(history))
+	prev := 0
+	for i, msg := range history {
+		tokens := msg.ContextTokens()
+		if tokens == 0 {
+			continue
+		}
+		if prev > 0 {
+			growth[i] = tokens - prev
+		}
+		prev = tokens
+	}
+	return growth
+}
+
+// ContextUsage returns the fraction (0–1+) of the model's context window used by the message
+// Messages without usage data (user prompts, tool results) report 0.
+func (m Message) ContextUsage() float64 {
+	tokens := m.ContextTokens()
+	if tokens == 0 {
+		return 0
+	}
+	return float64(tokens) / float64(ContextWindow(m.Model))
+}
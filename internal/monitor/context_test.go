@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestContextUsage tests context window lookup and usage calculation
+func TestContextUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want float64
+	}{
+		{"no usage", Message{Role: "user"}, 0},
+		{"half full", Message{Model: "claude-opus-4-5-20251101", InputTokens: 10, CacheRead: 90_000, CacheCreation: 9_990}, 0.5},
+		{"unknown model uses default window", Message{Model: "mystery", InputTokens: 190_000}, 0.95},
+		{"extended context", Message{Model: "claude-sonnet-4-5[1m]", CacheRead: 250_000}, 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.msg.ContextUsage(); got != tt.want {
+				t.Errorf("ContextUsage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextGrowth(t *testing.T) {
+	history := []Message{
+		{Role: "user", Content: "read main.go"},
+		{Role: "assistant", InputTokens: 10, CacheCreation: 20_000},
+		{Role: "assistant", ToolName: "Read", InputTokens: 10, CacheCreation: 20_000}, // Same response, split into a tool call
+		{Role: "user", Content: "tool result"},
+		{Role: "assistant", InputTokens: 5, CacheRead: 20_010, CacheCreation: 3_200},
+		{Role: "assistant", InputTokens: 8_000}, // After a compaction
+	}
+	want := []int{0, 0, 0, 0, 3_205, -15_215}
+	if got := ContextGrowth(history); !reflect.DeepEqual(got, want) {
+		t.Errorf("ContextGrowth() = %v, want %v", got, want)
+	}
+}
+
+// TestSetContextWindows tests that configured window sizes take precedence
+func TestSetContextWindows(t *testing.T) {
+	defer SetContextWindows(nil)
+
+	SetContextWindows(map[string]int{
+		"claude-opus-5":       500_000,
+		"claude-opus-5-turbo": 2_000_000,
+		"ignored":             0,
+	})
+
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"claude-opus-5-20270101", 500_000},
+		{"claude-opus-5-turbo-20270101", 2_000_000},
+		{"claude-sonnet-4-5[1m]", 1_000_000},
+		{"claude-haiku-4-5", DefaultContextWindow},
+	}
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/thieso2/promptwatch/internal/diff"
+)
+
+// DiffStat approximates what a session's tool calls changed in files, as in the
+// summary line of git diff --stat. Lines are counted from the tool inputs: an Edit
+// adds and removes the lines its new_string and old_string differ in, a Write adds
+// every line of its content. Writes replacing an existing file count none of its old
+// lines as removed, edits with replace_all count one replacement, and changes made
+// through other tools, such as Bash, are not seen at all.
+type DiffStat struct {
+	Files   int // Distinct files written, including notebooks
+	Added   int // Lines added
+	Removed int // Lines removed
+}
+
+// IsZero reports whether no file was written
+func (d DiffStat) IsZero() bool {
+	return d.Files == 0
+}
+
+// CallDiff returns the lines a Write, Edit or MultiEdit call adds and removes; none for
+// other tools. input is the tool input as JSON, as in Message.ToolInput.
+func CallDiff(tool, input string) (added, removed int) {
+	fields, ok := parseWriteInput(input)
+	if !ok {
+		return 0, 0
+	}
+	switch tool {
+	case "Write":
+		return lineDiff("", fields.Content)
+	case "Edit":
+		return lineDiff(fields.OldString, fields.NewString)
+	case "MultiEdit":
+		for _, e := range fields.Edits {
+			a, r := lineDiff(e.OldString, e.NewString)
+			added, removed = added+a, removed+r
+		}
+	}
+	return added, removed
+}
+
+// lineDiff counts the lines inserted and deleted turning old into new. A final line
+// break ends the last line rather than starting an empty one.
+func lineDiff(old, new string) (added, removed int) {
+	for _, op := range diff.Lines(strings.TrimSuffix(old, "\n"), strings.TrimSuffix(new, "\n")) {
+		switch op.Kind {
+		case diff.Insert:
+			added++
+		case diff.Delete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// diffStat sums the lines of the written files and counts the files. Relative paths
+// are taken from the directory the tool ran in, else from workDir; unlike in
+// outsideWorkDir, links are not followed, which would cost a lookup per write.
+func diffStat(workDir string, writes []fileWrite) DiffStat {
+	var d DiffStat
+	var files []string
+	for _, w := range writes {
+		path := w.path
+		if cwd := cmp.Or(w.cwd, workDir); !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		if path = filepath.Clean(path); !slices.Contains(files, path) {
+			files = append(files, path)
+		}
+		d.Added += w.added
+		d.Removed += w.removed
+	}
+	d.Files = len(files)
+	return d
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCallDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        string
+		input       string
+		wantAdded   int
+		wantRemoved int
+	}{
+		{"write counts every line", "Write", `{"file_path":"a.go","content":"package a\n\nfunc A() {}\n"}`, 3, 0},
+		{"empty write", "Write", `{"file_path":"a.go","content":""}`, 0, 0},
+		{"edit counts only changed lines", "Edit", `{"file_path":"a.go","old_string":"func A() {\n\treturn 1\n}","new_string":"func A() {\n\treturn 2\n}"}`, 1, 1},
+		{"edit adding lines", "Edit", `{"file_path":"a.go","old_string":"x := 1","new_string":"x := 1\ny := 2\nz := 3"}`, 2, 0},
+		{"edit deleting lines", "Edit", `{"file_path":"a.go","old_string":"// TODO\nx := 1\n","new_string":"x := 1\n"}`, 0, 1},
+		{"multi-edit sums its edits", "MultiEdit", `{"file_path":"a.go","edits":[{"old_string":"a","new_string":"b"},{"old_string":"c\nd","new_string":""}]}`, 1, 3},
+		{"notebook edits are not counted", "NotebookEdit", `{"notebook_path":"a.ipynb","new_source":"print(1)"}`, 0, 0},
+		{"other tools", "Bash", `{"command":"sed -i s/a/b/ a.go"}`, 0, 0},
+		{"not json", "Write", "not json", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := CallDiff(tt.tool, tt.input)
+			if added != tt.wantAdded || removed != tt.wantRemoved {
+				t.Errorf("CallDiff = +%d -%d, want +%d -%d", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+// TestDiffStat tests that files are counted once however they are named, and that
+// the full parse and the metadata scan agree
+func TestDiffStat(t *testing.T) {
+	history := []Message{
+		{WorkingDir: "/src/app", ToolName: "Write", ToolInput: `{"file_path":"main.go","content":"package main\n\nfunc main() {}\n"}`},
+		{ToolCalls: []ToolCall{
+			{Name: "Edit", Input: `{"file_path":"/src/app/./main.go","old_string":"func main() {}","new_string":"func main() {\n\trun()\n}"}`},
+			{Name: "Read", Input: `{"file_path":"/src/app/go.mod"}`},
+		}},
+		{WorkingDir: "/src/app/cmd", ToolName: "Write", ToolInput: `{"file_path":"../main.go","content":"package main\n"}`},
+		{ToolName: "NotebookEdit", ToolInput: `{"notebook_path":"a.ipynb","new_source":"print(1)"}`},
+	}
+	want := DiffStat{Files: 2, Added: 7, Removed: 1}
+	if got := diffStat("/src/app", historyWrites(history)); got != want {
+		t.Errorf("diffStat = %+v, want %+v", got, want)
+	}
+
+	path := filepath.Join("testdata", "sample_session.jsonl")
+	stats, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	metadata, err := GetSessionMetadata(path)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	want = DiffStat{Files: 1, Added: 362}
+	if stats.DiffStat != want || metadata.DiffStat != want {
+		t.Errorf("DiffStat = %+v, metadata %+v; want %+v", stats.DiffStat, metadata.DiffStat, want)
+	}
+}
@@ -0,0 +1,221 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Copying a repository to a new path makes Claude start a new project directory for
+// it, holding copies of the old sessions under their original IDs. Cross-project
+// views would count such sessions once per copy.
+
+// SessionCopy is a session file as found in one project directory
+type SessionCopy struct {
+	Path    string
+	ModTime time.Time
+}
+
+// DuplicateSession is a session found in more than one project directory: the same
+// session ID, started at the same time
+type DuplicateSession struct {
+	ID      string
+	Created time.Time
+	Kept    string   // The copy modified last, which cross-project views count
+	Copies  []string // The other copies, which cross-project views leave out
+}
+
+// Duplicates are the sessions found in more than one project directory
+type Duplicates []DuplicateSession
+
+// Dropped reports whether the session file at path is a copy that cross-project views
+// leave out
+func (d Duplicates) Dropped(path string) bool {
+	for _, dup := range d {
+		if slices.Contains(dup.Copies, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// OtherCopies returns the paths of the other copies of the session file at path, the
+// kept one first; nil if the session has no copies
+func (d Duplicates) OtherCopies(path string) []string {
+	for _, dup := range d {
+		if dup.Kept == path {
+			return dup.Copies
+		}
+		if slices.Contains(dup.Copies, path) {
+			others := []string{dup.Kept}
+			for _, c := range dup.Copies {
+				if c != path {
+					others = append(others, c)
+				}
+			}
+			return others
+		}
+	}
+	return nil
+}
+
+// FindDuplicates finds the sessions among files that exist in more than one project
+// directory. Only files sharing a session ID are opened, to compare when they started;
+// files that cannot be read are not counted as copies.
+func FindDuplicates(files []SessionCopy) Duplicates {
+	byID := make(map[string][]SessionCopy)
+	for _, f := range files {
+		id := SessionFileID(f.Path)
+		byID[id] = append(byID[id], f)
+	}
+
+	var dups Duplicates
+	for id, group := range byID {
+		if len(group) < 2 {
+			continue
+		}
+		byCreated := make(map[time.Time][]SessionCopy)
+		for _, f := range group {
+			created, err := sessionCreated(f.Path)
+			if err != nil {
+				logger.Debug("cannot read session start", "op", "find_duplicates", "path", f.Path, "err", err)
+				continue
+			}
+			byCreated[created] = append(byCreated[created], f)
+		}
+		for created, copies := range byCreated {
+			if len(copies) < 2 {
+				continue
+			}
+			sort.Slice(copies, func(i, j int) bool {
+				if !copies[i].ModTime.Equal(copies[j].ModTime) {
+					return copies[i].ModTime.After(copies[j].ModTime)
+				}
+				return copies[i].Path < copies[j].Path
+			})
+			dup := DuplicateSession{ID: id, Created: created, Kept: copies[0].Path}
+			for _, c := range copies[1:] {
+				dup.Copies = append(dup.Copies, c.Path)
+			}
+			dups = append(dups, dup)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].ID != dups[j].ID {
+			return dups[i].ID < dups[j].ID
+		}
+		return dups[i].Created.Before(dups[j].Created)
+	})
+	return dups
+}
+
+// FindAllDuplicates finds the sessions that exist in more than one project directory
+// under ProjectsDir
+func FindAllDuplicates() (Duplicates, error) {
+	files, err := allSessionCopies()
+	if err != nil {
+		return nil, err
+	}
+	return FindDuplicates(files), nil
+}
+
+// FindDuplicatesOf finds the sessions of the project directory dir that also exist in
+// other project directories
+func FindDuplicatesOf(dir string) (Duplicates, error) {
+	files, err := allSessionCopies()
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Clean(dir)
+	inDir := func(path string) bool { return filepath.Dir(path) == dir }
+	ids := make(map[string]bool)
+	for _, f := range files {
+		if inDir(f.Path) {
+			ids[SessionFileID(f.Path)] = true
+		}
+	}
+	var candidates []SessionCopy
+	for _, f := range files {
+		if ids[SessionFileID(f.Path)] {
+			candidates = append(candidates, f)
+		}
+	}
+	var dups Duplicates
+	for _, dup := range FindDuplicates(candidates) {
+		if inDir(dup.Kept) || slices.ContainsFunc(dup.Copies, inDir) {
+			dups = append(dups, dup)
+		}
+	}
+	return dups, nil
+}
+
+// allSessionCopies lists the session files of every project directory. Only directory
+// entries are read; project directories that cannot be read are skipped.
+func allSessionCopies() ([]SessionCopy, error) {
+	projectsDir, err := ProjectsDir()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := os.ReadDir(projectsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projects directory: %w", err)
+	}
+	var files []SessionCopy
+	for _, project := range projects {
+		if !project.IsDir() {
+			continue
+		}
+		projectDir := filepath.Join(projectsDir, project.Name())
+		entries, err := os.ReadDir(projectDir)
+		if err != nil {
+			logger.Debug("cannot read project directory", "op", "find_duplicates", "path", projectDir, "err", err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !IsSessionFile(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			files = append(files, SessionCopy{Path: filepath.Join(projectDir, entry.Name()), ModTime: info.ModTime()})
+		}
+	}
+	return files, nil
+}
+
+// sessionCreated returns the timestamp of the first entry of a session file that has one
+func sessionCreated(path string) (time.Time, error) {
+	file, err := openSessionFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // 10MB max
+	for scanner.Scan() {
+		var entry struct {
+			Timestamp string `json:"timestamp"`
+		}
+		if json.Unmarshal(cleanLine(scanner.Bytes()), &entry) != nil || entry.Timestamp == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+			return t, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("no timestamped entries")
+}
@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestFindDuplicates tests that a session copied along with its project directory is
+// found, that the copy modified last is kept, and that sessions which only share an ID
+// are not duplicates
+func TestFindDuplicates(t *testing.T) {
+	projects := t.TempDir()
+	SetProjectsDir(projects)
+	t.Cleanup(func() { SetProjectsDir("") })
+
+	now := time.Now()
+	files := []struct {
+		project, name, created string
+		age                    time.Duration
+	}{
+		{"-home-demo-api", "aaa.jsonl", "2026-03-01T10:00:00Z", 2 * time.Hour},
+		{"-home-demo-api-copy", "aaa.jsonl", "2026-03-01T10:00:00Z", time.Hour},
+		{"-home-demo-api-old", "aaa.jsonl.gz", "2026-03-01T10:00:00Z", 3 * time.Hour},
+		{"-home-demo-api", "bbb.jsonl", "2026-03-02T10:00:00Z", time.Hour},
+		{"-home-demo-web", "bbb.jsonl", "2026-03-05T10:00:00Z", time.Hour},
+		{"-home-demo-web", "ccc.jsonl", "2026-03-05T10:00:00Z", time.Hour},
+	}
+	for _, f := range files {
+		dir := filepath.Join(projects, f.project)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, f.name)
+		content := `{"type":"summary"}` + "\n" + `{"type":"user","timestamp":"` + f.created + `"}` + "\n"
+		if filepath.Ext(f.name) == ".gz" {
+			writeGzip(t, path, []byte(content))
+		} else if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-f.age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	at := func(project, name string) string { return filepath.Join(projects, project, name) }
+
+	dups, err := FindAllDuplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dups) != 1 {
+		t.Fatalf("got %d duplicates %+v, want 1", len(dups), dups)
+	}
+	dup := dups[0]
+	if dup.ID != "aaa" || !dup.Created.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("duplicate = %s started %v, want aaa started 2026-03-01 10:00", dup.ID, dup.Created)
+	}
+	if want := at("-home-demo-api-copy", "aaa.jsonl"); dup.Kept != want {
+		t.Errorf("Kept = %s, want the copy modified last, %s", dup.Kept, want)
+	}
+	wantCopies := []string{at("-home-demo-api", "aaa.jsonl"), at("-home-demo-api-old", "aaa.jsonl.gz")}
+	if !slices.Equal(dup.Copies, wantCopies) {
+		t.Errorf("Copies = %v, want %v", dup.Copies, wantCopies)
+	}
+
+	if !dups.Dropped(at("-home-demo-api", "aaa.jsonl")) || dups.Dropped(dup.Kept) || dups.Dropped(at("-home-demo-api", "bbb.jsonl")) {
+		t.Error("Dropped should hold for the older copies only")
+	}
+	wantOthers := []string{dup.Kept, at("-home-demo-api-old", "aaa.jsonl.gz")}
+	if got := dups.OtherCopies(at("-home-demo-api", "aaa.jsonl")); !slices.Equal(got, wantOthers) {
+		t.Errorf("OtherCopies = %v, want %v", got, wantOthers)
+	}
+	if got := dups.OtherCopies(at("-home-demo-web", "ccc.jsonl")); got != nil {
+		t.Errorf("OtherCopies of a single copy = %v, want nil", got)
+	}
+
+	of, err := FindDuplicatesOf(filepath.Join(projects, "-home-demo-api-old"))
+	if err != nil || len(of) != 1 || of[0].ID != "aaa" {
+		t.Errorf("FindDuplicatesOf = %+v, %v; want the aaa duplicate", of, err)
+	}
+	if of, err := FindDuplicatesOf(filepath.Join(projects, "-home-demo-web")); err != nil || len(of) != 0 {
+		t.Errorf("FindDuplicatesOf(web) = %+v, %v; want none", of, err)
+	}
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+// SessionEfficiency is how many tokens a session spent per prompt, to follow how
+// prompting habits change from session to session
+type SessionEfficiency struct {
+	Started         time.Time
+	Prompts         int     // User prompts, excluding tool results
+	OutputPerPrompt float64 // Output tokens per prompt
+	ContextPerTurn  float64 // Input context (fresh input, cache writes and reads) per prompt-started turn
+}
+
+// newSessionEfficiency derives the per-prompt metrics from a session's totals; with no
+// prompts both are 0
+func newSessionEfficiency(started time.Time, prompts, outputTokens, contextTokens int) SessionEfficiency {
+	e := SessionEfficiency{Started: started, Prompts: prompts}
+	if prompts > 0 {
+		e.OutputPerPrompt = float64(outputTokens) / float64(prompts)
+		e.ContextPerTurn = float64(contextTokens) / float64(prompts)
+	}
+	return e
+}
+
+// Efficiency returns the session's tokens per prompt
+func (s *SessionStats) Efficiency() SessionEfficiency {
+	prompts, output, context := 0, 0, 0
+	for i := range s.MessageHistory {
+		msg := &s.MessageHistory[i]
+		switch msg.Type {
+		case "prompt":
+			prompts++
+		case "assistant_response":
+			output += msg.OutputTokens
+			context += msg.ContextTokens()
+		}
+	}
+	return newSessionEfficiency(s.CreatedAt, prompts, output, context)
+}
+
+// RecentEfficiency returns the efficiency of the project's n latest sessions that had
+// prompts, oldest first
+func (p *ProjectStats) RecentEfficiency(n int) []SessionEfficiency {
+	return p.Efficiency[max(len(p.Efficiency)-n, 0):]
+}
+
+// sortEfficiency orders the per-session efficiency by start time
+func (p *ProjectStats) sortEfficiency() {
+	sort.SliceStable(p.Efficiency, func(i, j int) bool {
+		return p.Efficiency[i].Started.Before(p.Efficiency[j].Started)
+	})
+}
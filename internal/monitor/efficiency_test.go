@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestSessionEfficiency tests the tokens-per-prompt metrics of sessions and projects
+func TestSessionEfficiency(t *testing.T) {
+	start := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	session := func(started time.Time, prompts int) *SessionStats {
+		s := &SessionStats{CreatedAt: started}
+		for i := 0; i < prompts; i++ {
+			s.MessageHistory = append(s.MessageHistory,
+				Message{Type: "prompt"},
+				Message{Type: "assistant_response", OutputTokens: 300, InputTokens: 10, CacheRead: 990},
+				Message{Type: "tool_result"},
+				Message{Type: "assistant_response", OutputTokens: 100, InputTokens: 5, CacheCreation: 995})
+		}
+		return s
+	}
+
+	e := session(start, 2).Efficiency()
+	if e.Prompts != 2 || e.OutputPerPrompt != 400 || e.ContextPerTurn != 2000 || !e.Started.Equal(start) {
+		t.Errorf("Efficiency() = %+v, want 2 prompts, 400 output and 2000 context per prompt", e)
+	}
+	if e := session(start, 0).Efficiency(); e.OutputPerPrompt != 0 || e.ContextPerTurn != 0 {
+		t.Errorf("without prompts: %+v, want zero metrics", e)
+	}
+
+	// Projects keep sessions with prompts in start order, leaving out subagents
+	var p ProjectStats
+	cost := func(*Message) float64 { return 0 }
+	p.Add(session(start.Add(2*time.Hour), 1), false, cost)
+	p.Add(session(start, 1), false, cost)
+	p.Add(session(start.Add(time.Hour), 0), false, cost)
+	p.Add(session(start.Add(3*time.Hour), 1), true, cost)
+	p.Add(session(start.Add(time.Hour), 3), false, cost)
+	p.sort()
+	recent := p.RecentEfficiency(2)
+	if len(p.Efficiency) != 3 || len(recent) != 2 || !recent[0].Started.Equal(start.Add(time.Hour)) || recent[1].Prompts != 1 {
+		t.Errorf("RecentEfficiency(2) = %+v of %d, want the sessions at 15:00 and 16:00", recent, len(p.Efficiency))
+	}
+	if got := p.RecentEfficiency(10); len(got) != 3 {
+		t.Errorf("RecentEfficiency(10) returned %d sessions, want 3", len(got))
+	}
+}
+
+// TestSessionMetadataEfficiency tests that tool results do not count as prompts in the
+// metadata's efficiency metrics
+func TestSessionMetadataEfficiency(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "efficiency.jsonl")
+	testData := `{"type":"user","timestamp":"2026-01-12T09:14:00Z","message":{"role":"user","content":"add a test"}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:05Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{}}],"usage":{"input_tokens":100,"cache_read_input_tokens":900,"output_tokens":200}}}
+{"type":"user","timestamp":"2026-01-12T09:14:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"..."}]}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:09Z","message":{"role":"assistant","content":[{"type":"text","text":"Done."}],"usage":{"input_tokens":50,"cache_creation_input_tokens":1950,"output_tokens":400}}}
+{"type":"user","timestamp":"2026-01-12T09:15:00Z","message":{"role":"user","content":"thanks"}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	md, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if md.OutputPerPrompt != 300 || md.ContextPerTurn != 1500 {
+		t.Errorf("output per prompt = %g, context per turn = %g; want 300, 1500", md.OutputPerPrompt, md.ContextPerTurn)
+	}
+}
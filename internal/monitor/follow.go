@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SessionFollower reads a session file incrementally while Claude appends to it.
+// Each Update parses only the bytes written since the previous one. A trailing line
+// that is still being written is kept and retried on the next Update rather than
+// being dropped as malformed.
+type SessionFollower struct {
+	path    string
+	offset  int64  // Bytes of the file consumed so far, including pending
+	pending []byte // Incomplete last line, waiting for the rest of it
+	stats   *SessionStats
+}
+
+// NewSessionFollower returns a follower for the session file at path; call Update to
+// read what the file holds so far
+func NewSessionFollower(path string) *SessionFollower {
+	f := &SessionFollower{path: path}
+	f.reset()
+	return f
+}
+
+// reset drops everything read so far
+func (f *SessionFollower) reset() {
+	f.offset = 0
+	f.pending = nil
+	f.stats = &SessionStats{
+		FilePath:       f.path,
+		MessageHistory: []Message{},
+	}
+}
+
+// Stats returns the stats of everything read so far. They are updated in place by
+// Update and must not be used concurrently with it.
+func (f *SessionFollower) Stats() *SessionStats {
+	return f.stats
+}
+
+// Pending reports whether an incomplete last line is waiting for the rest of its bytes
+func (f *SessionFollower) Pending() bool {
+	return len(f.pending) > 0
+}
+
+// Update reads the bytes appended since the last call, typically after a change
+// notification for the file, and returns how many messages were added. If the file
+// shrank (rewritten or truncated) it is read again from the start.
+func (f *SessionFollower) Update() (added int, err error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open session file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat session file: %w", err)
+	}
+	if info.Size() < f.offset {
+		f.reset()
+	}
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek session file: %w", err)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return 0, fmt.Errorf("error reading session file: %w", err)
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	f.offset += int64(len(data))
+
+	before := len(f.stats.MessageHistory)
+	data = append(f.pending, data...)
+	f.pending = nil
+
+	lines := bytes.Split(data, []byte("\n"))
+	last := len(lines) - 1
+	for _, line := range lines[:last] {
+		if len(cleanLine(line)) > 0 && !f.stats.processLine(line) {
+			f.stats.MalformedLines++
+		}
+	}
+	// The part after the last newline is either empty, a complete entry written without
+	// a trailing newline, or the start of an entry still being written
+	if tail := lines[last]; len(tail) > 0 {
+		if json.Valid(tail) {
+			f.stats.processLine(tail)
+		} else {
+			f.pending = append([]byte(nil), tail...)
+		}
+	}
+
+	f.stats.finalize()
+	return len(f.stats.MessageHistory) - before, nil
+}
+
+// Drain returns the messages read since the previous Drain and drops them from Stats,
+// so that following a long session for what is new does not keep its whole history
+// in memory. The counters of Stats keep counting.
+func (f *SessionFollower) Drain() []Message {
+	messages := f.stats.MessageHistory
+	f.stats.MessageHistory = []Message{}
+	f.stats.finalize()
+	return messages
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSessionFollowerSplitWrite tests an entry whose bytes arrive in two writes, each
+// followed by an update as a file change notification would trigger
+func TestSessionFollowerSplitWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "live.jsonl")
+	prompt := `{"type":"user","timestamp":"2026-01-09T14:00:00.000Z","message":{"role":"user","content":"hello"}}` + "\n"
+	answer := `{"type":"assistant","timestamp":"2026-01-09T14:00:05.000Z","message":{"role":"assistant","content":[{"type":"text","text":"hi there"}]}}` + "\n"
+	split := len(answer) / 2
+
+	appendFile := func(s string) {
+		t.Helper()
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := NewSessionFollower(path)
+	steps := []struct {
+		name      string
+		write     string
+		added     int
+		pending   bool
+		malformed int
+	}{
+		{"prompt and half an answer", prompt + answer[:split], 1, true, 0},
+		{"nothing new", "", 0, true, 0},
+		{"rest of the answer", answer[split:], 1, false, 0},
+		{"garbage line", "not json\n", 0, false, 1},
+		{"entry without newline", prompt[:len(prompt)-1], 1, false, 1},
+		{"newline after it", "\n", 0, false, 1},
+	}
+	for _, s := range steps {
+		appendFile(s.write)
+		added, err := f.Update()
+		if err != nil {
+			t.Fatalf("%s: Update failed: %v", s.name, err)
+		}
+		stats := f.Stats()
+		if added != s.added || f.Pending() != s.pending || stats.MalformedLines != s.malformed {
+			t.Errorf("%s: added %d, pending %v, malformed %d; want %d, %v, %d",
+				s.name, added, f.Pending(), stats.MalformedLines, s.added, s.pending, s.malformed)
+		}
+	}
+	history := f.Stats().MessageHistory
+	if len(history) != 3 || history[1].Content != "hi there" {
+		t.Fatalf("history = %+v, want prompt, answer, prompt", history)
+	}
+	if len(f.Stats().Turns) != 2 {
+		t.Errorf("turns = %d, want 2", len(f.Stats().Turns))
+	}
+
+	// A rewritten, shorter file is read again from the start
+	if err := os.WriteFile(path, []byte(prompt), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if added, err := f.Update(); err != nil || added != 1 || len(f.Stats().MessageHistory) != 1 {
+		t.Errorf("after truncation: added %d (%v), history %d; want 1 message", added, err, len(f.Stats().MessageHistory))
+	}
+}
+
+// TestParseSessionFileTrailingPartialLine tests that a last line still being written
+// is not counted as malformed, while a broken line in the middle is
+func TestParseSessionFileTrailingPartialLine(t *testing.T) {
+	prompt := `{"type":"user","message":{"role":"user","content":"hello"}}` + "\n"
+	tests := []struct {
+		name      string
+		data      string
+		malformed int
+	}{
+		{"complete", prompt + prompt, 0},
+		{"trailing partial line", prompt + `{"type":"assistant","mess`, 0},
+		{"broken line in the middle", prompt + "{broken\n" + prompt, 1},
+		{"broken last line with newline", prompt + "{broken\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session.jsonl")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			stats, err := ParseSessionFile(path)
+			if err != nil {
+				t.Fatalf("ParseSessionFile failed: %v", err)
+			}
+			if stats.MalformedLines != tt.malformed {
+				t.Errorf("ParseSessionFile: MalformedLines = %d, want %d", stats.MalformedLines, tt.malformed)
+			}
+			tail, err := ParseSessionTail(path, 1<<20)
+			if err != nil {
+				t.Fatalf("ParseSessionTail failed: %v", err)
+			}
+			if tail.MalformedLines != tt.malformed {
+				t.Errorf("ParseSessionTail: MalformedLines = %d, want %d", tail.MalformedLines, tt.malformed)
+			}
+		})
+	}
+}
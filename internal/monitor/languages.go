@@ -0,0 +1,214 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// languageAliases maps code fence tags and their common spellings to one language name
+var languageAliases = map[string]string{
+	"golang":     "go",
+	"py":         "python",
+	"python3":    "python",
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"node":       "javascript",
+	"ts":         "typescript",
+	"tsx":        "typescript",
+	"sh":         "shell",
+	"bash":       "shell",
+	"zsh":        "shell",
+	"console":    "shell",
+	"shell":      "shell",
+	"yml":        "yaml",
+	"rs":         "rust",
+	"rb":         "ruby",
+	"c++":        "cpp",
+	"cc":         "cpp",
+	"cxx":        "cpp",
+	"cs":         "csharp",
+	"c#":         "csharp",
+	"kt":         "kotlin",
+	"md":         "markdown",
+	"hcl":        "terraform",
+	"tf":         "terraform",
+	"proto":      "protobuf",
+	"makefile":   "make",
+	"dockerfile": "dockerfile",
+}
+
+// notLanguages are fence tags that mark plain text or program output rather than code
+var notLanguages = map[string]bool{
+	"text": true, "txt": true, "plain": true, "plaintext": true,
+	"output": true, "log": true, "diff": true, "none": true,
+}
+
+// extensionLanguages maps file extensions to the language of the file
+var extensionLanguages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".jsx": "javascript", ".mjs": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".rs": "rust", ".rb": "ruby", ".java": "java",
+	".kt": "kotlin", ".swift": "swift", ".c": "c", ".h": "c", ".cpp": "cpp", ".cc": "cpp",
+	".hpp": "cpp", ".cs": "csharp", ".php": "php", ".sh": "shell", ".bash": "shell", ".zsh": "shell",
+	".sql": "sql", ".yaml": "yaml", ".yml": "yaml", ".json": "json", ".toml": "toml",
+	".html": "html", ".css": "css", ".scss": "scss", ".md": "markdown", ".proto": "protobuf",
+	".tf": "terraform", ".lua": "lua", ".ex": "elixir", ".exs": "elixir", ".dart": "dart",
+	".scala": "scala", ".vue": "vue", ".svelte": "svelte",
+}
+
+// fileNameLanguages maps file names without a telling extension to their language
+var fileNameLanguages = map[string]string{
+	"Dockerfile": "dockerfile",
+	"Makefile":   "make",
+}
+
+// fenceTag matches a fence tag that names a language rather than, say, a file path
+var fenceTag = regexp.MustCompile(`^[a-z][a-z0-9+#-]{0,19}$`)
+
+// FenceLanguage returns the language a code fence tag such as "golang" or
+// "ts title=app.ts" names, or "" if it names none
+func FenceLanguage(tag string) string {
+	fields := strings.Fields(strings.ToLower(tag))
+	if len(fields) == 0 {
+		return ""
+	}
+	lang, _, _ := strings.Cut(fields[0], ":")
+	lang = strings.Trim(lang, "{}.")
+	if alias, ok := languageAliases[lang]; ok {
+		return alias
+	}
+	if notLanguages[lang] || !fenceTag.MatchString(lang) {
+		return ""
+	}
+	return lang
+}
+
+// FileLanguage returns the language of a file by its extension or name, or "" if it
+// is not a known kind of source file
+func FileLanguage(path string) string {
+	base := filepath.Base(path)
+	if lang, ok := fileNameLanguages[base]; ok {
+		return lang
+	}
+	return extensionLanguages[strings.ToLower(filepath.Ext(base))]
+}
+
+// textLanguages returns the language of each tagged code fence in text, in order
+func textLanguages(text string) []string {
+	if !strings.Contains(text, "```") && !strings.Contains(text, "~~~") {
+		return nil
+	}
+	var langs []string
+	fence := "" // Marker of the open fence, "" outside code
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case fence == "" && (strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")):
+			fence = line[:3]
+			if lang := FenceLanguage(strings.TrimLeft(line, fence[:1])); lang != "" {
+				langs = append(langs, lang)
+			}
+		case fence != "" && strings.HasPrefix(line, fence):
+			fence = ""
+		}
+	}
+	return langs
+}
+
+// messageLanguages returns the languages of a message's code: one per tagged code
+// fence in its text and one per file its tool calls write. Tool output is left out,
+// as it shows code the session read rather than wrote about.
+func messageLanguages(msg Message) []string {
+	if msg.Type == "tool_result" {
+		return nil
+	}
+	langs := textLanguages(msg.Content)
+	for _, call := range msg.Calls() {
+		if lang := FileLanguage(WrittenFile(call.Name, call.Input)); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// entryLanguages returns the languages of the code in a user or assistant entry, as
+// messageLanguages does for the parsed message
+func entryLanguages(line []byte) []string {
+	if !bytes.Contains(line, []byte("```")) && !bytes.Contains(line, []byte("~~~")) && !bytes.Contains(line, []byte(`"tool_use"`)) {
+		return nil
+	}
+	var entry struct {
+		Message struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil
+	}
+	var text string
+	if json.Unmarshal(entry.Message.Content, &text) == nil {
+		return textLanguages(text)
+	}
+	var items []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	}
+	if json.Unmarshal(entry.Message.Content, &items) != nil {
+		return nil
+	}
+	var langs []string
+	for _, item := range items {
+		switch item.Type {
+		case "text":
+			langs = append(langs, textLanguages(item.Text)...)
+		case "tool_use":
+			if lang := FileLanguage(WrittenFile(item.Name, string(item.Input))); lang != "" {
+				langs = append(langs, lang)
+			}
+		}
+	}
+	return langs
+}
+
+// historyLanguages counts the languages of the code in a message history, most
+// frequent first
+func historyLanguages(history []Message) []EntryCount {
+	counts := make(map[string]int)
+	for _, msg := range history {
+		for _, lang := range messageLanguages(msg) {
+			counts[lang]++
+		}
+	}
+	return languageCounts(counts)
+}
+
+// languageCounts turns per-language counts into a list, most frequent first and by
+// name among equals
+func languageCounts(counts map[string]int) []EntryCount {
+	var langs []EntryCount
+	for lang, n := range counts {
+		langs = append(langs, EntryCount{Label: lang, Count: n})
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if langs[i].Count != langs[j].Count {
+			return langs[i].Count > langs[j].Count
+		}
+		return langs[i].Label < langs[j].Label
+	})
+	return langs
+}
+
+// HasLanguage reports whether lang is among the counted languages
+func HasLanguage(langs []EntryCount, lang string) bool {
+	for _, l := range langs {
+		if l.Label == lang {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// TestFenceLanguage tests fence tags as Claude and users write them
+func TestFenceLanguage(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"go", "go"},
+		{"golang", "go"},
+		{"Python3", "python"},
+		{"ts title=app.ts", "typescript"},
+		{"bash", "shell"},
+		{"yml", "yaml"},
+		{"sql", "sql"},
+		{"{.haskell}", "haskell"},
+		{"rust:src/main.rs", "rust"},
+		{"", ""},
+		{"text", ""},
+		{"diff", ""},
+		{"src/main.go", ""},
+	}
+	for _, tt := range tests {
+		if got := FenceLanguage(tt.tag); got != tt.want {
+			t.Errorf("FenceLanguage(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+// TestFileLanguage tests languages told by file extension and name
+func TestFileLanguage(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/work/api/main.go", "go"},
+		{"db/001_init.SQL", "sql"},
+		{".github/workflows/ci.yml", "yaml"},
+		{"deploy/Dockerfile", "dockerfile"},
+		{"web/src/App.tsx", "typescript"},
+		{"notes.txt", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := FileLanguage(tt.path); got != tt.want {
+			t.Errorf("FileLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestTextLanguages tests that only opening fences count, and that a fence line inside
+// a longer fence does not end it
+func TestTextLanguages(t *testing.T) {
+	text := "Run this:\n```bash\ngo test ./...\n```\nThen:\n````markdown\n```go\nfunc main() {}\n```\n````\n```\nplain\n```\n  ```sql\nSELECT 1;\n  ```"
+	want := []string{"shell", "markdown", "sql"}
+	if got := textLanguages(text); !slices.Equal(got, want) {
+		t.Errorf("textLanguages() = %v, want %v", got, want)
+	}
+}
+
+// TestSessionLanguages tests that the parsed session and its metadata count the same
+// code: fences in prompts and replies and files written by tool calls, but not tool output
+func TestSessionLanguages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	lines := `{"type":"user","timestamp":"2026-01-09T14:00:00Z","message":{"role":"user","content":"Why does this fail?\n` + "```sql" + `\nSELECT * FROM orders\n` + "```" + `"}}
+{"type":"assistant","timestamp":"2026-01-09T14:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"The column is missing:\n` + "```sql" + `\nALTER TABLE orders ADD total int;\n` + "```" + `"},{"type":"tool_use","id":"t1","name":"Write","input":{"file_path":"/work/api/orders.go","content":"package api"}},{"type":"tool_use","id":"t2","name":"Edit","input":{"file_path":"/work/api/store.go","old_string":"a","new_string":"b"}}]}}
+{"type":"user","timestamp":"2026-01-09T14:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"` + "```python" + `\nprint(1)\n` + "```" + `"}]}}
+{"type":"assistant","timestamp":"2026-01-09T14:00:09Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t3","name":"Write","input":{"file_path":"/work/api/ci.yaml","content":"on: push"}}]}}
+`
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := []EntryCount{{Label: "go", Count: 2}, {Label: "sql", Count: 2}, {Label: "yaml", Count: 1}}
+
+	stats, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stats.Languages, want) {
+		t.Errorf("SessionStats.Languages = %v, want %v", stats.Languages, want)
+	}
+	metadata, err := GetSessionMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(metadata.Languages, want) {
+		t.Errorf("SessionMetadata.Languages = %v, want %v", metadata.Languages, want)
+	}
+	if !HasLanguage(metadata.Languages, "sql") || HasLanguage(metadata.Languages, "python") {
+		t.Error("HasLanguage should find sql and not the python of the tool output")
+	}
+}
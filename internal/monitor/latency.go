@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// minLatencySamples is how many answers a session needs before its typical latency
+// is worth showing
+const minLatencySamples = 3
+
+// Waiting reports whether Claude has yet to answer the latest message of a history:
+// a prompt or tool result that nothing followed. It returns the time of that message
+// and its type, or a zero time when Claude is not being waited for.
+func Waiting(history []Message) (since time.Time, after string) {
+	if len(history) == 0 {
+		return time.Time{}, ""
+	}
+	last := history[len(history)-1]
+	if last.Role != "user" || strings.HasPrefix(last.Content, "[Request interrupted") {
+		return time.Time{}, ""
+	}
+	return last.Timestamp, last.Type
+}
+
+// TypicalLatency returns the median time Claude took to start answering messages of
+// a type ("prompt" or "tool_result") in a history, and false when it answered fewer
+// than minLatencySamples of them
+func TypicalLatency(history []Message, msgType string) (time.Duration, bool) {
+	var samples []time.Duration
+	for i := 1; i < len(history); i++ {
+		asked, answer := history[i-1], history[i]
+		if asked.Type != msgType || answer.Role != "assistant" || asked.Timestamp.IsZero() || answer.Timestamp.Before(asked.Timestamp) {
+			continue
+		}
+		samples = append(samples, answer.Timestamp.Sub(asked.Timestamp))
+	}
+	if len(samples) < minLatencySamples {
+		return 0, false
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2], true
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaiting(t *testing.T) {
+	at := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	prompt := Message{Type: "prompt", Role: "user", Content: "fix it", Timestamp: at}
+	toolResult := Message{Type: "tool_result", Role: "user", Content: "ok", Timestamp: at.Add(time.Minute)}
+	answer := Message{Type: "assistant_response", Role: "assistant", Content: "done", Timestamp: at.Add(2 * time.Minute)}
+	interrupted := Message{Type: "prompt", Role: "user", Content: "[Request interrupted by user]", Timestamp: at.Add(3 * time.Minute)}
+
+	tests := []struct {
+		name      string
+		history   []Message
+		wantSince time.Time
+		wantAfter string
+	}{
+		{"empty", nil, time.Time{}, ""},
+		{"prompt sent", []Message{prompt}, at, "prompt"},
+		{"tool result sent", []Message{prompt, answer, toolResult}, toolResult.Timestamp, "tool_result"},
+		{"answered", []Message{prompt, answer}, time.Time{}, ""},
+		{"interrupted", []Message{prompt, answer, interrupted}, time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, after := Waiting(tt.history)
+			if !since.Equal(tt.wantSince) || after != tt.wantAfter {
+				t.Errorf("Waiting() = %v, %q; want %v, %q", since, after, tt.wantSince, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestTypicalLatency(t *testing.T) {
+	at := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	var history []Message
+	add := func(msgType, role string, offset time.Duration) {
+		history = append(history, Message{Type: msgType, Role: role, Timestamp: at.Add(offset)})
+	}
+	add("prompt", "user", 0)
+	add("assistant_response", "assistant", 40*time.Second) // 40s
+	add("tool_result", "user", time.Minute)
+	add("assistant_response", "assistant", time.Minute+2*time.Second) // 2s
+	add("prompt", "user", 10*time.Minute)
+	add("assistant_response", "assistant", 10*time.Minute+20*time.Second) // 20s
+
+	if _, ok := TypicalLatency(history, "prompt"); ok {
+		t.Error("TypicalLatency with 2 answered prompts should report no typical latency")
+	}
+
+	add("prompt", "user", 20*time.Minute)
+	add("prompt", "user", 21*time.Minute) // Not answered directly, skipped
+	add("assistant_response", "assistant", 22*time.Minute)
+	add("prompt", "user", 30*time.Minute)
+	add("assistant_response", "assistant", 30*time.Minute+90*time.Second)
+
+	if got, ok := TypicalLatency(history, "prompt"); !ok || got != 60*time.Second {
+		t.Errorf("TypicalLatency(prompt) = %v, %v; want 1m0s, true", got, ok)
+	}
+	if _, ok := TypicalLatency(history, "tool_result"); ok {
+		t.Error("TypicalLatency(tool_result) with one answer should report no typical latency")
+	}
+}
@@ -0,0 +1,20 @@
+package monitor
+
+import "log/slog"
+
+// logger receives diagnostics for errors that are otherwise skipped over (unreadable
+// processes, broken session files). It discards everything unless SetLogger is called.
+var logger = slog.New(slog.DiscardHandler)
+
+// SetLogger sets the logger used by the monitor package
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	logger = l
+}
+
+// Logger returns the logger used by the monitor package
+func Logger() *slog.Logger {
+	return logger
+}
@@ -1,38 +1,56 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	gopsutil_process "github.com/shirou/gopsutil/v4/process"
 	"github.com/thieso2/promptwatch/internal/types"
 )
 
-// RefreshMetrics updates metrics for an existing process
+// RefreshMetrics updates the CPU, memory and working directory of a listed process,
+// failing if it has exited
 func RefreshMetrics(proc *types.ClaudeProcess) error {
-	gpProc, err := GetProcessByPID(proc.PID)
+	return refreshMetrics(currentProvider(), proc)
+}
+
+// refreshMetrics implements RefreshMetrics against the given process source
+func refreshMetrics(pp ProcessProvider, proc *types.ClaudeProcess) error {
+	// Fail early if the process is gone
+	exists, err := pp.Exists(proc.PID)
 	if err != nil {
 		return err
 	}
+	if !exists {
+		return fmt.Errorf("process %d: %w", proc.PID, ErrProcessGone)
+	}
 
 	// Update CPU: Get CPU percentage (non-blocking)
-	cpuPercent, err := gpProc.CPUPercent()
+	cpuPercent, err := pp.CPUPercent(proc.PID)
 	if err != nil {
 		cpuPercent = proc.CPUPercent // Keep old value on error
 	}
 	proc.CPUPercent = cpuPercent
 
 	// Update memory
-	memInfo, err := gpProc.MemoryInfo()
+	memInfo, err := pp.MemoryInfo(proc.PID)
 	if err == nil {
 		proc.MemoryMB = float64(memInfo.RSS) / 1024 / 1024
 	}
 
 	// Update working directory
-	workDir, err := getWorkingDir(proc.PID)
-	if err == nil {
-		proc.WorkingDir = workDir
+	workDir, err := pp.Cwd(proc.PID)
+	switch {
+	case err == nil:
+		proc.WorkingDir, proc.WorkDirGone = workDir, false
+	case errors.Is(err, ErrWorkDirGone):
+		proc.WorkDirGone = true
+		if workDir != "" {
+			proc.WorkingDir = workDir
+		}
+	default:
+		logger.Debug("cannot refresh working directory", "op", "refresh_metrics", "pid", proc.PID, "err", err)
 	}
 
 	return nil
@@ -68,30 +86,3 @@ func FormatUptime(d time.Duration) string {
 	}
 	return fmt.Sprintf("%ds", seconds)
 }
-
-// TruncatePath shortens a path for display, replacing home directory with ~
-func TruncatePath(path string, maxLen int) string {
-	// Simple home directory substitution
-	if strings.HasPrefix(path, "/Users/") {
-		parts := strings.Split(path, "/")
-		if len(parts) > 2 {
-			// Replace /Users/username with ~
-			path = "~" + "/" + strings.Join(parts[3:], "/")
-		}
-	}
-
-	if len(path) <= maxLen {
-		return path
-	}
-
-	// Middle truncation: keep beginning and end
-	if maxLen < 10 {
-		return path[:maxLen]
-	}
-
-	keepChars := maxLen - 3
-	keepLeft := (keepChars + 1) / 2
-	keepRight := keepChars - keepLeft
-
-	return path[:keepLeft] + "..." + path[len(path)-keepRight:]
-}
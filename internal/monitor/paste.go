@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PasteThreshold is the prompt size in bytes from which a prompt counts as pasted
+// content: few people type 8 KB, but logs and files pasted whole are often far larger
+const PasteThreshold = 8 * 1024
+
+// TopPastes is how many of a project's largest pastes ProjectStats keeps
+const TopPastes = 5
+
+// pastePreview is how many bytes of a pasted prompt a Paste keeps
+const pastePreview = 200
+
+// pasteMarker matches the placeholders Claude's input box shows for pasted text, e.g.
+// "[Pasted text #1 +120 lines]", which some versions leave in the prompt
+var pasteMarker = regexp.MustCompile(`\[Pasted text #\d+[^\]]*\]`)
+
+// pastedBytes returns the size of the content pasted into a user prompt: the whole
+// prompt if it is at least PasteThreshold bytes or carries a paste marker, else 0.
+// What was typed around a paste cannot be told apart, but it is small beside it.
+func pastedBytes(prompt string) int {
+	if len(prompt) >= PasteThreshold || pasteMarker.MatchString(prompt) {
+		return len(prompt)
+	}
+	return 0
+}
+
+// ContainsPaste reports whether the message is a user prompt with pasted content
+func (m Message) ContainsPaste() bool {
+	return m.PastedBytes > 0
+}
+
+// Paste is one prompt with pasted content, as listed among a project's largest
+type Paste struct {
+	Session string    // Session ID
+	At      time.Time // When the prompt was sent
+	Bytes   int       // Size of the pasted content
+	Prompt  string    // Start of the prompt
+}
+
+// addPaste keeps a prompt among the project's TopPastes largest pastes
+func (p *ProjectStats) addPaste(session string, msg *Message) {
+	p.Pastes++
+	p.PastedBytes += msg.PastedBytes
+	if len(p.LargestPastes) == TopPastes && msg.PastedBytes <= p.LargestPastes[TopPastes-1].Bytes {
+		return
+	}
+	prompt := msg.Content[:min(len(msg.Content), pastePreview)]
+	p.LargestPastes = append(p.LargestPastes, Paste{
+		Session: session,
+		At:      msg.Timestamp,
+		Bytes:   msg.PastedBytes,
+		Prompt:  strings.ToValidUTF8(prompt, ""),
+	})
+	sort.SliceStable(p.LargestPastes, func(i, j int) bool {
+		return p.LargestPastes[i].Bytes > p.LargestPastes[j].Bytes
+	})
+	p.LargestPastes = p.LargestPastes[:min(len(p.LargestPastes), TopPastes)]
+}
@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPastedBytes tests which prompts count as pasted
+func TestPastedBytes(t *testing.T) {
+	big := strings.Repeat("panic: index out of range\n", PasteThreshold/20)
+	tests := []struct {
+		name   string
+		prompt string
+		want   int
+	}{
+		{"typed", "fix the login test", 0},
+		{"just under the threshold", strings.Repeat("x", PasteThreshold-1), 0},
+		{"at the threshold", strings.Repeat("x", PasteThreshold), PasteThreshold},
+		{"pasted log", "why does this fail?\n" + big, len("why does this fail?\n" + big)},
+		{"paste marker", "look at [Pasted text #1 +120 lines]", 35},
+		{"bracketed text", "see [notes #1]", 0},
+	}
+	for _, tt := range tests {
+		if got := pastedBytes(tt.prompt); got != tt.want {
+			t.Errorf("%s: pastedBytes = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSessionPastes tests that parsing flags pasted prompts and sums them per session,
+// leaving out side-chain prompts and compaction summaries
+func TestSessionPastes(t *testing.T) {
+	big := strings.Repeat("x", 20_000)
+	prompt := func(text string, extra string) string {
+		content, _ := json.Marshal(text)
+		return fmt.Sprintf(`{"type":"user","timestamp":"2026-01-09T14:00:00Z"%s,"message":{"role":"user","content":%s}}`+"\n", extra, content)
+	}
+	path := filepath.Join(t.TempDir(), "pastes.jsonl")
+	lines := prompt("fix the test", "") +
+		prompt("here is the log:\n"+big, "") +
+		prompt(big, `,"isSidechain":true`) +
+		prompt(big, `,"isCompactSummary":true`) +
+		prompt("and [Pasted text #2 +3 lines]", "")
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flagged []int
+	for i, msg := range stats.MessageHistory {
+		if msg.ContainsPaste() {
+			flagged = append(flagged, i)
+		}
+	}
+	if fmt.Sprint(flagged) != "[1 4]" {
+		t.Errorf("pasted prompts %v, want [1 4]", flagged)
+	}
+	if want := len("here is the log:\n"+big) + len("and [Pasted text #2 +3 lines]"); stats.Pastes != 2 || stats.PastedBytes != want {
+		t.Errorf("session pastes = %d, %d bytes; want 2, %d bytes", stats.Pastes, stats.PastedBytes, want)
+	}
+}
+
+// TestLargestPastes tests that a project keeps its TopPastes largest pastes, largest first
+func TestLargestPastes(t *testing.T) {
+	var p ProjectStats
+	sizes := []int{9_000, 40_000, 12_000, 8_500, 100_000, 8_200, 20_000}
+	for i, size := range sizes {
+		stats := &SessionStats{
+			FilePath: fmt.Sprintf("/p/s%d.jsonl", i),
+			MessageHistory: []Message{{
+				Type: "prompt", Role: "user", Content: strings.Repeat("é", size/2),
+				Timestamp: time.Date(2026, 1, 9, 14, i, 0, 0, time.UTC), PastedBytes: size,
+			}},
+		}
+		p.Add(stats, false, func(*Message) float64 { return 0 })
+	}
+	if p.Pastes != len(sizes) || p.PastedBytes != 197_700 {
+		t.Errorf("pastes = %d, %d bytes; want %d, 197700 bytes", p.Pastes, p.PastedBytes, len(sizes))
+	}
+	var got []string
+	for _, paste := range p.LargestPastes {
+		got = append(got, fmt.Sprintf("%s:%d", paste.Session, paste.Bytes))
+		if len(paste.Prompt) > pastePreview || !strings.HasPrefix(paste.Prompt, "éé") || strings.ContainsRune(paste.Prompt, '�') {
+			t.Errorf("preview of %s is %d bytes, %q…", paste.Session, len(paste.Prompt), paste.Prompt[:4])
+		}
+	}
+	if want := "[s4:100000 s1:40000 s6:20000 s2:12000 s0:9000]"; fmt.Sprint(got) != want {
+		t.Errorf("largest pastes = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	homeDirOnce sync.Once
+	homeDir     string
+)
+
+// cachedHomeDir returns the current user's home directory, looked up once per process
+func cachedHomeDir() string {
+	homeDirOnce.Do(func() {
+		if home, err := os.UserHomeDir(); err == nil {
+			homeDir = home
+		}
+	})
+	return homeDir
+}
+
+// ShortenHomePath replaces the current user's home directory prefix with ~
+// Paths outside the home directory (including other users' homes) are returned unchanged
+func ShortenHomePath(path string) string {
+	caseInsensitive := runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+	return shortenHomePath(path, cachedHomeDir(), caseInsensitive)
+}
+
+// shortenHomePath is the testable core of ShortenHomePath
+// Both / and \ are accepted as separators so Windows-style paths are handled on any platform
+func shortenHomePath(path, home string, caseInsensitive bool) string {
+	home = strings.TrimRight(home, `/\`)
+	if home == "" || len(path) < len(home) {
+		return path
+	}
+
+	prefix := path[:len(home)]
+	if caseInsensitive {
+		if !strings.EqualFold(prefix, home) {
+			return path
+		}
+	} else if prefix != home {
+		return path
+	}
+
+	rest := path[len(home):]
+	if rest == "" {
+		return "~"
+	}
+	// Only match whole path components, so /home/al does not shorten /home/alice
+	if rest[0] != '/' && rest[0] != '\\' {
+		return path
+	}
+	if strings.TrimRight(rest, `/\`) == "" {
+		return "~"
+	}
+	return "~" + rest
+}
+
+// DisplayPath shortens a path for display: relative to workDir when inside it, else
+// with the home directory replaced by ~. Relative paths are returned unchanged.
+func DisplayPath(path, workDir string) string {
+	return displayPath(path, workDir, ShortenHomePath)
+}
+
+// displayPath is the testable core of DisplayPath; outside shortens paths outside workDir
+func displayPath(path, workDir string, outside func(string) string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	path = filepath.Clean(path)
+	if workDir != "" && filepath.IsAbs(workDir) && IsUnderDir(path, filepath.Clean(workDir)) {
+		if rel, err := filepath.Rel(workDir, path); err == nil {
+			return rel
+		}
+	}
+	return outside(path)
+}
+
+// TruncatePath shortens a path for display, replacing home directory with ~, to at most
+// maxLen terminal cells. Longer paths keep their beginning and end around "..."; wide
+// characters such as CJK take two cells and are never cut in half.
+func TruncatePath(path string, maxLen int) string {
+	path = ShortenHomePath(path)
+
+	width := ansi.StringWidth(path)
+	if width <= maxLen {
+		return path
+	}
+
+	// Middle truncation: keep beginning and end
+	if maxLen < 10 {
+		return ansi.Truncate(path, maxLen, "")
+	}
+
+	keepChars := maxLen - 3
+	keepLeft := (keepChars + 1) / 2
+	keepRight := keepChars - keepLeft
+
+	right := ansi.TruncateLeft(path, width-keepRight, "")
+	for ansi.StringWidth(right) > keepRight {
+		// The cut went through a wide character, which TruncateLeft keeps whole
+		_, size := utf8.DecodeRuneInString(right)
+		right = right[size:]
+	}
+	return ansi.Truncate(path, keepLeft, "") + "..." + right
+}
+
+// ResolvePath turns a path a tool call was given into an absolute path without
+// symlinks: "~" is expanded, relative paths are taken from dir, and links are
+// followed as far as the path exists
+func ResolvePath(path, dir string) string {
+	return resolvePath(path, dir, cachedHomeDir())
+}
+
+// resolvePath is the testable core of ResolvePath
+func resolvePath(path, dir, home string) string {
+	if home != "" && (path == "~" || strings.HasPrefix(path, "~/")) {
+		path = filepath.Join(home, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return resolveSymlinks(filepath.Clean(path))
+}
+
+// resolveSymlinks evaluates the symlinks in a path. Files that do not exist (any
+// more) are resolved through their nearest existing parent directory.
+func resolveSymlinks(path string) string {
+	rest := ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, rest)
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// IsUnderDir reports whether path is dir or inside it. Both should be resolved.
+func IsUnderDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,179 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// TestShortenHomePath tests home directory substitution across platform path styles
+func TestShortenHomePath(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		home            string
+		caseInsensitive bool
+		want            string
+	}{
+		// Linux
+		{"linux project", "/home/alice/src/app", "/home/alice", false, "~/src/app"},
+		{"linux home itself", "/home/alice", "/home/alice", false, "~"},
+		{"linux home trailing slash", "/home/alice/", "/home/alice", false, "~"},
+		{"linux home with trailing slash", "/home/alice/src", "/home/alice/", false, "~/src"},
+		{"linux other user", "/home/bob/src", "/home/alice", false, "/home/bob/src"},
+		{"linux prefix of other name", "/home/alicia/src", "/home/alice", false, "/home/alicia/src"},
+		{"linux case sensitive", "/home/Alice/src", "/home/alice", false, "/home/Alice/src"},
+		{"linux outside home", "/var/log/syslog", "/home/alice", false, "/var/log/syslog"},
+
+		// macOS
+		{"macos project", "/Users/thies/Projects/app", "/Users/thies", true, "~/Projects/app"},
+		{"macos other user", "/Users/jane/Projects/app", "/Users/thies", true, "/Users/jane/Projects/app"},
+		{"macos case difference", "/users/Thies/Projects", "/Users/thies", true, "~/Projects"},
+
+		// Windows
+		{"windows project", `C:\Users\alice\src\app`, `C:\Users\alice`, true, `~\src\app`},
+		{"windows drive case", `c:\users\ALICE\src`, `C:\Users\alice`, true, `~\src`},
+		{"windows other user", `C:\Users\bob\src`, `C:\Users\alice`, true, `C:\Users\bob\src`},
+
+		// Edge cases
+		{"empty home", "/home/alice/src", "", false, "/home/alice/src"},
+		{"empty path", "", "/home/alice", false, ""},
+		{"root home", "/etc/passwd", "/", false, "/etc/passwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shortenHomePath(tt.path, tt.home, tt.caseInsensitive); got != tt.want {
+				t.Errorf("shortenHomePath(%q, %q) = %q, want %q", tt.path, tt.home, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTruncatePath tests that long paths are cut in the middle to a display width,
+// without splitting multibyte or wide characters
+func TestTruncatePath(t *testing.T) {
+	long := "/srv/Projekte/Übersicht/äöüäöüäöü/日本語のプロジェクト/src"
+	tests := []struct {
+		name   string
+		path   string
+		maxLen int
+		want   string
+	}{
+		{"fits", "/srv/app", 20, "/srv/app"},
+		{"ascii middle", "/srv/projects/promptwatch/internal/ui", 20, "/srv/proj...ernal/ui"},
+		{"ascii narrow", "/srv/projects/promptwatch", 8, "/srv/pro"},
+		{"umlauts fit", "/srv/Übersicht/äöü", 18, "/srv/Übersicht/äöü"},
+		{"umlauts middle", "/srv/Projekte/Übersicht/äöüäöüäöü", 20, "/srv/Proj...öüäöüäöü"},
+		{"wide middle 20", long, 20, "/srv/Proj...クト/src"},
+		{"wide middle 25", long, 25, "/srv/Projek...ェクト/src"},
+		{"wide narrow", "/日本語/src", 6, "/日本"},
+		{"wide not split", "/日本語/src", 4, "/日"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncatePath(tt.path, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("TruncatePath(%q, %d) = %q, want %q", tt.path, tt.maxLen, got, tt.want)
+			}
+			if !utf8.ValidString(got) || ansi.StringWidth(got) > tt.maxLen {
+				t.Errorf("TruncatePath(%q, %d) = %q, %d cells wide", tt.path, tt.maxLen, got, ansi.StringWidth(got))
+			}
+		})
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir()) // The temp dir itself can be behind a link, as on macOS
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(root, "repo")
+	other := filepath.Join(root, "other")
+	for _, dir := range []string{repo, other} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(other, filepath.Join(repo, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(repo, "link"), filepath.Join(root, "repo-link")); err != nil {
+		t.Fatal(err)
+	}
+	home := filepath.Join(root, "home")
+
+	tests := []struct {
+		name string
+		path string
+		dir  string
+		want string
+	}{
+		{"absolute", filepath.Join(repo, "main.go"), "/elsewhere", filepath.Join(repo, "main.go")},
+		{"relative", "src/main.go", repo, filepath.Join(repo, "src/main.go")},
+		{"relative escaping", "../other/x.go", repo, filepath.Join(other, "x.go")},
+		{"home", "~/.bashrc", repo, filepath.Join(home, ".bashrc")},
+		{"home itself", "~", repo, home},
+		{"tilde in a name", "~backup/x", repo, filepath.Join(repo, "~backup/x")},
+		{"symlinked directory", filepath.Join(repo, "link", "x.go"), repo, filepath.Join(other, "x.go")},
+		{"relative through symlink", "link/new/x.go", repo, filepath.Join(other, "new/x.go")},
+		{"chained symlinks", filepath.Join(root, "repo-link", "x.go"), repo, filepath.Join(other, "x.go")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolvePath(tt.path, tt.dir, home); got != tt.want {
+				t.Errorf("resolvePath(%q, %q) = %q, want %q", tt.path, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUnderDir(t *testing.T) {
+	tests := []struct {
+		path string
+		dir  string
+		want bool
+	}{
+		{"/src/app", "/src/app", true},
+		{"/src/app/main.go", "/src/app", true},
+		{"/src/app/..data/x", "/src/app", true},
+		{"/src/application/x", "/src/app", false},
+		{"/src/x", "/src/app", false},
+		{"/etc/hosts", "/src/app", false},
+	}
+	for _, tt := range tests {
+		if got := IsUnderDir(tt.path, tt.dir); got != tt.want {
+			t.Errorf("IsUnderDir(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	home := func(p string) string { return shortenHomePath(p, "/home/alice", false) }
+	tests := []struct {
+		name    string
+		path    string
+		workDir string
+		want    string
+	}{
+		{"inside", "/home/alice/code/app/internal/foo/bar.go", "/home/alice/code/app", "internal/foo/bar.go"},
+		{"workdir with trailing slash", "/home/alice/code/app/main.go", "/home/alice/code/app/", "main.go"},
+		{"workdir itself", "/home/alice/code/app", "/home/alice/code/app", "."},
+		{"sibling with common prefix", "/home/alice/code/application/x.go", "/home/alice/code/app", "~/code/application/x.go"},
+		{"parent directory", "/home/alice/code/app/../other/x.go", "/home/alice/code/app", "~/code/other/x.go"},
+		{"outside home", "/etc/hosts", "/home/alice/code/app", "/etc/hosts"},
+		{"no workdir", "/home/alice/code/app/main.go", "", "~/code/app/main.go"},
+		{"relative workdir", "/home/alice/code/app/main.go", "app", "~/code/app/main.go"},
+		{"relative path", "internal/foo.go", "/home/alice/code/app", "internal/foo.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := displayPath(tt.path, tt.workDir, home); got != tt.want {
+				t.Errorf("displayPath(%q, %q) = %q, want %q", tt.path, tt.workDir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import "slices"
+
+// Permission modes Claude Code records in the permissionMode field of session entries
+const (
+	PermissionDefault     = "default"
+	PermissionPlan        = "plan"
+	PermissionAcceptEdits = "acceptEdits"
+	PermissionBypass      = "bypassPermissions"
+)
+
+// PermissionRank orders modes by how much Claude may do without asking, higher being
+// more permissive. Modes unknown to promptwatch rank between plan and acceptEdits.
+func PermissionRank(mode string) int {
+	switch mode {
+	case "":
+		return -1
+	case PermissionDefault:
+		return 0
+	case PermissionPlan:
+		return 1
+	case PermissionAcceptEdits:
+		return 3
+	case PermissionBypass:
+		return 4
+	}
+	return 2
+}
+
+// MostPermissiveMode returns the least restrictive of the modes, or "" if there are none
+func MostPermissiveMode(modes []string) string {
+	most := ""
+	for _, mode := range modes {
+		if PermissionRank(mode) > PermissionRank(most) {
+			most = mode
+		}
+	}
+	return most
+}
+
+// IsBypassMode reports whether a mode skips all permission prompts, which team policies
+// commonly forbid on shared repositories
+func IsBypassMode(mode string) bool {
+	return mode == PermissionBypass
+}
+
+// RanInBypassMode reports whether any of a session's modes skips all permission prompts
+func RanInBypassMode(modes []string) bool {
+	return slices.ContainsFunc(modes, IsBypassMode)
+}
+
+// PermissionModeLabel returns the badge shown for a mode, e.g. "⏸ plan", or "" for the
+// default mode
+func PermissionModeLabel(mode string) string {
+	switch mode {
+	case "", PermissionDefault:
+		return ""
+	case PermissionPlan:
+		return "⏸ plan"
+	case PermissionAcceptEdits:
+		return "⚡ auto"
+	case PermissionBypass:
+		return "‼ bypass"
+	}
+	return "◆ " + mode
+}
+
+// addPermissionMode appends mode to modes unless it is empty or already listed
+func addPermissionMode(modes []string, mode string) []string {
+	if mode == "" {
+		return modes
+	}
+	for _, m := range modes {
+		if m == mode {
+			return modes
+		}
+	}
+	return append(modes, mode)
+}
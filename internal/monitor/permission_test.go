@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestMostPermissiveMode(t *testing.T) {
+	tests := []struct {
+		modes []string
+		want  string
+	}{
+		{modes: nil, want: ""},
+		{modes: []string{"default"}, want: "default"},
+		{modes: []string{"plan", "default"}, want: "plan"},
+		{modes: []string{"acceptEdits", "bypassPermissions", "plan"}, want: "bypassPermissions"},
+		{modes: []string{"plan", "someNewMode"}, want: "someNewMode"},
+		{modes: []string{"someNewMode", "acceptEdits"}, want: "acceptEdits"},
+	}
+	for _, tt := range tests {
+		if got := MostPermissiveMode(tt.modes); got != tt.want {
+			t.Errorf("MostPermissiveMode(%v) = %q, want %q", tt.modes, got, tt.want)
+		}
+	}
+}
+
+// TestPermissionModeFixtures tests detection on a session recorded in each mode Claude
+// Code emits
+func TestPermissionModeFixtures(t *testing.T) {
+	tests := []struct {
+		mode   string
+		label  string
+		bypass bool
+	}{
+		{mode: "default", label: ""},
+		{mode: "plan", label: "⏸ plan"},
+		{mode: "acceptEdits", label: "⚡ auto"},
+		{mode: "bypassPermissions", label: "‼ bypass", bypass: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			metadata, err := GetSessionMetadata(filepath.Join("testdata", "permission_modes", tt.mode+".jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metadata.PermissionMode != tt.mode || metadata.WorkingDir != "/home/demo/acme-api" {
+				t.Errorf("mode = %q in %q, want %q in /home/demo/acme-api", metadata.PermissionMode, metadata.WorkingDir, tt.mode)
+			}
+			if got := PermissionModeLabel(metadata.PermissionMode); got != tt.label {
+				t.Errorf("label = %q, want %q", got, tt.label)
+			}
+			if got := RanInBypassMode(metadata.PermissionModes); got != tt.bypass {
+				t.Errorf("RanInBypassMode = %v, want %v", got, tt.bypass)
+			}
+		})
+	}
+
+	project, err := ScanProject(context.Background(), filepath.Join("testdata", "permission_modes"), func(*Message) float64 { return 0 }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.Sessions != 4 || project.Bypass != 1 {
+		t.Errorf("project has %d sessions, %d in bypass mode; want 4 and 1", project.Sessions, project.Bypass)
+	}
+}
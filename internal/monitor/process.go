@@ -1,9 +1,11 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,58 +13,234 @@ import (
 	"github.com/thieso2/promptwatch/internal/types"
 )
 
-// FindClaudeProcesses discovers all running Claude instances and returns their metrics
-func FindClaudeProcesses(showHelpers bool) ([]types.ClaudeProcess, error) {
-	processes, err := process.Processes()
+// SkippedProcess is a Claude-like process that could not be inspected
+type SkippedProcess struct {
+	PID    int32
+	Reason string // Short classification, e.g. "permission denied"
+	Err    error  // Underlying error, for verbose output
+}
+
+// DiscoveryReport summarizes the candidates FindClaudeProcesses had to skip
+type DiscoveryReport struct {
+	Skipped []SkippedProcess
+}
+
+// Summary returns e.g. "3 Claude-like processes skipped (permission denied)", or "" if none were skipped
+func (r DiscoveryReport) Summary() string {
+	if len(r.Skipped) == 0 {
+		return ""
+	}
+
+	counts := make(map[string]int)
+	var reasons []string
+	for _, s := range r.Skipped {
+		if counts[s.Reason] == 0 {
+			reasons = append(reasons, s.Reason)
+		}
+		counts[s.Reason]++
+	}
+	sort.Strings(reasons)
+	if len(reasons) > 1 {
+		for i, reason := range reasons {
+			reasons[i] = fmt.Sprintf("%d %s", counts[reason], reason)
+		}
+	}
+
+	noun := "processes"
+	if len(r.Skipped) == 1 {
+		noun = "process"
+	}
+	return fmt.Sprintf("%d Claude-like %s skipped (%s)", len(r.Skipped), noun, strings.Join(reasons, ", "))
+}
+
+// DiscoveryOptions selects which Claude processes FindClaudeProcesses returns
+type DiscoveryOptions struct {
+	ShowHelpers     bool // Include MCP helper processes
+	ShowSessionless bool // Include processes without sessions under ~/.claude/projects (marked NoSessions)
+}
+
+// FindClaudeProcesses discovers all running Claude instances and returns their metrics,
+// along with a report of Claude-like processes that had to be skipped
+func FindClaudeProcesses(opts DiscoveryOptions) ([]types.ClaudeProcess, DiscoveryReport, error) {
+	return findClaudeProcesses(currentProvider(), opts)
+}
+
+// findClaudeProcesses implements FindClaudeProcesses against the given process source
+func findClaudeProcesses(pp ProcessProvider, opts DiscoveryOptions) ([]types.ClaudeProcess, DiscoveryReport, error) {
+	var report DiscoveryReport
+	knownPaths := lazyProjectPaths()
+
+	pids, err := pp.List()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get processes: %w", err)
+		return nil, report, fmt.Errorf("failed to get processes: %w", err)
 	}
 
 	var claudeProcesses []types.ClaudeProcess
-
-	for _, proc := range processes {
-		// Skip processes that aren't Claude
-		if !isClaudeProcess(proc) {
-			continue
+	for _, pid := range pids {
+		v, proc, skipped := judgeProcess(pp, pid, opts, knownPaths)
+		if skipped != nil {
+			logger.Debug("cannot inspect Claude-like process", "op", "find_processes", "pid", pid, "err", skipped.Err)
+			report.Skipped = append(report.Skipped, *skipped)
 		}
-
-		// Get command line for helper detection
-		cmdline, err := proc.Cmdline()
-		if err != nil {
-			continue
+		if v.Listed {
+			claudeProcesses = append(claudeProcesses, proc)
 		}
+	}
+	return claudeProcesses, report, nil
+}
+
+// ProcessVerdict explains whether process discovery lists a Claude-like process, and why
+type ProcessVerdict struct {
+	PID     int32  `json:"pid"`
+	Exe     string `json:"exe,omitempty"`
+	WorkDir string `json:"workdir,omitempty"`
+	Listed  bool   `json:"listed"`
+	Reason  string `json:"reason"`
+}
 
-		isHelper := isClaudeHelperProcess(cmdline)
+// ExplainProcesses walks the same steps as FindClaudeProcesses for every process whose
+// name or executable mentions claude, and reports the outcome of each, for diagnosing
+// instances missing from the process list
+func ExplainProcesses(opts DiscoveryOptions) ([]ProcessVerdict, error) {
+	return explainProcesses(currentProvider(), opts)
+}
+
+// explainProcesses implements ExplainProcesses against the given process source
+func explainProcesses(pp ProcessProvider, opts DiscoveryOptions) ([]ProcessVerdict, error) {
+	knownPaths := lazyProjectPaths()
+
+	pids, err := pp.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processes: %w", err)
+	}
 
-		// Skip helpers unless explicitly requested
-		if isHelper && !showHelpers {
+	var verdicts []ProcessVerdict
+	for _, pid := range pids {
+		name, _ := pp.Name(pid)
+		exe, _ := pp.Exe(pid)
+		if !strings.Contains(strings.ToLower(name+" "+filepath.Base(exe)), "claude") {
 			continue
 		}
-
-		// Collect metrics
-		claudeProc, err := collectMetrics(proc, isHelper)
-		if err != nil {
-			continue // Skip processes we can't collect metrics for
+		v, _, _ := judgeProcess(pp, pid, opts, knownPaths)
+		v.Exe = exe
+		if v.Reason == reasonNotClaude && strings.Contains(exe, "Claude.app") {
+			v.Reason = "Claude desktop app, which keeps no sessions under the projects directory"
 		}
+		verdicts = append(verdicts, v)
+	}
+	return verdicts, nil
+}
 
-		// Only include processes that have sessions in ~/.claude
-		if !hasActiveSessions(claudeProc.WorkingDir) {
-			continue
+// reasonNotClaude is the verdict on processes whose executable is not a Claude instance
+const reasonNotClaude = "executable is not named claude"
+
+// judgeProcess applies the discovery rules to one process: it must be a Claude instance
+// that can be inspected, not a helper unless those are shown, and have sessions for its
+// working directory unless sessionless ones are shown. The verdict says whether it is
+// listed and why. proc holds its metrics once they were collected; skipped is set for a
+// Claude-like process that could not be inspected, for the discovery report.
+// knownPaths returns the working directories with a project.
+func judgeProcess(pp ProcessProvider, pid int32, opts DiscoveryOptions, knownPaths func() map[string]bool) (v ProcessVerdict, proc types.ClaudeProcess, skipped *SkippedProcess) {
+	v.PID = pid
+	skip := func(err error) {
+		v.Reason = err.Error()
+		if reason := skipReason(err); reason != "" {
+			skipped = &SkippedProcess{PID: pid, Reason: reason, Err: err}
 		}
+	}
 
-		claudeProcesses = append(claudeProcesses, claudeProc)
+	cmdline, isClaude, err := classifyProcess(pp, pid)
+	if err != nil {
+		skip(err)
+		return v, proc, skipped
+	}
+	if !isClaude {
+		v.Reason = reasonNotClaude
+		return v, proc, nil
+	}
+	isHelper := isClaudeHelperProcess(cmdline)
+	if isHelper && !opts.ShowHelpers {
+		v.Reason = "MCP helper process, hidden unless helpers are shown"
+		return v, proc, nil
 	}
 
-	return claudeProcesses, nil
+	proc, err = collectMetrics(pp, pid, isHelper)
+	if err != nil {
+		skip(err)
+		return v, proc, skipped
+	}
+	v.WorkDir = proc.WorkingDir
+	switch {
+	case proc.WorkingDir == permissionDeniedDir:
+		// Without a working directory the process cannot be matched to its sessions
+		v.WorkDir = ""
+		skip(fmt.Errorf("cannot read working directory: %w", os.ErrPermission))
+	case proc.WorkDirGone:
+		// A process whose directory is gone can no longer be matched, but it still runs
+		// (and bills), so it stays
+		v.Listed = true
+		v.Reason = "working directory was removed"
+	case hasActiveSessions(proc.WorkingDir, knownPaths()):
+		v.Listed = true
+		v.Reason = "has sessions"
+	default:
+		v.Listed = opts.ShowSessionless
+		v.Reason = "no sessions for its working directory"
+		proc.NoSessions = true
+	}
+	return v, proc, skipped
+}
+
+// lazyProjectPaths returns a function looking up the working directories with a
+// project on its first call, so that scans finding no Claude process skip the lookup
+func lazyProjectPaths() func() map[string]bool {
+	var paths map[string]bool
+	return func() map[string]bool {
+		if paths == nil {
+			paths = projectPaths.paths()
+		}
+		return paths
+	}
 }
 
-// isClaudeProcess checks if a process is a Claude instance
-func isClaudeProcess(proc *process.Process) bool {
-	exe, err := proc.Exe()
+// classifyProcess decides whether a process is a Claude instance and returns its command
+// line. A non-nil error means the process looks like Claude but could not be inspected.
+func classifyProcess(pp ProcessProvider, pid int32) (cmdline string, isClaude bool, err error) {
+	exe, err := pp.Exe(pid)
 	if err != nil {
-		return false
+		// The executable path is often unreadable for other users' processes; fall back
+		// to the process name to decide whether this is worth reporting
+		if name, nameErr := pp.Name(pid); nameErr != nil || name != "claude" {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("cannot read executable: %w", err)
+	}
+	if !isClaudeExe(exe) {
+		return "", false, nil
+	}
+
+	cmdline, err = pp.Cmdline(pid)
+	if err != nil {
+		return "", false, fmt.Errorf("cannot read command line: %w", err)
 	}
+	return cmdline, true, nil
+}
+
+// skipReason classifies why a process could not be inspected. Processes that exited
+// during the scan return "" and are not reported.
+func skipReason(err error) string {
+	switch {
+	case errors.Is(err, os.ErrPermission):
+		return "permission denied"
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, process.ErrorProcessNotRunning):
+		return ""
+	default:
+		return "unreadable"
+	}
+}
 
+// isClaudeExe checks if an executable path is a Claude instance
+func isClaudeExe(exe string) bool {
 	// Skip the desktop app
 	if strings.Contains(exe, "Claude.app") {
 		return false
@@ -79,106 +257,58 @@ func isClaudeHelperProcess(cmdline string) bool {
 		strings.Contains(cmdline, "--mcp")
 }
 
-// collectMetrics gathers CPU, memory, and other metrics for a process
-func collectMetrics(proc *process.Process, isHelper bool) (types.ClaudeProcess, error) {
-	pid := proc.Pid
+// permissionDeniedDir stands in for a working directory that could not be read
+const permissionDeniedDir = "[Permission Denied]"
 
+// collectMetrics gathers CPU, memory, and other metrics for a process
+func collectMetrics(pp ProcessProvider, pid int32, isHelper bool) (types.ClaudeProcess, error) {
 	// CPU: Get CPU percentage (non-blocking)
-	cpuPercent, err := proc.CPUPercent()
+	cpuPercent, err := pp.CPUPercent(pid)
 	if err != nil {
 		cpuPercent = 0
 	}
 
 	// Memory: Get RSS in bytes and convert to MB
-	memInfo, err := proc.MemoryInfo()
+	memInfo, err := pp.MemoryInfo(pid)
 	var memoryMB float64
 	if err == nil {
 		memoryMB = float64(memInfo.RSS) / 1024 / 1024
 	}
 
 	// Working directory: Use CGo proc_pidinfo on macOS
-	workDir, err := getWorkingDir(pid)
-	if err != nil {
-		workDir = "[Permission Denied]"
+	workDir, err := pp.Cwd(pid)
+	workDirGone := errors.Is(err, ErrWorkDirGone)
+	if err != nil && !workDirGone {
+		logger.Debug("cannot read working directory", "op", "collect_metrics", "pid", pid, "err", err)
+		workDir = permissionDeniedDir
 	}
 
 	// Command line and timing info
-	cmdline, _ := proc.Cmdline()
-	createTime, _ := proc.CreateTime()
+	cmdline, _ := pp.Cmdline(pid)
+	createTime, _ := pp.CreateTime(pid)
 	var uptime time.Duration
 	if createTime > 0 {
 		uptime = time.Since(time.UnixMilli(createTime))
 	}
 
 	return types.ClaudeProcess{
-		PID:        pid,
-		CPUPercent: cpuPercent,
-		MemoryMB:   memoryMB,
-		WorkingDir: workDir,
-		Command:    cmdline,
-		Uptime:     uptime,
-		StartTime:  time.UnixMilli(createTime),
-		IsHelper:   isHelper,
+		PID:         pid,
+		CPUPercent:  cpuPercent,
+		MemoryMB:    memoryMB,
+		WorkingDir:  workDir,
+		Command:     cmdline,
+		Uptime:      uptime,
+		StartTime:   time.UnixMilli(createTime),
+		IsHelper:    isHelper,
+		WorkDirGone: workDirGone,
 	}, nil
 }
 
-// hasActiveSessions checks if a working directory has any active Claude sessions
-func hasActiveSessions(workingDir string) bool {
-	if workingDir == "[Permission Denied]" || workingDir == "" {
-		return false
-	}
-
-	// Get the Claude projects directory
-	home, err := os.UserHomeDir()
-	if err != nil {
+// hasActiveSessions checks if a working directory has any Claude sessions, i.e. whether
+// a project's index names it
+func hasActiveSessions(workingDir string, projectPaths map[string]bool) bool {
+	if workingDir == permissionDeniedDir || workingDir == "" {
 		return false
 	}
-
-	claudeProjectsDir := filepath.Join(home, ".claude", "projects")
-
-	// Check if projects directory exists
-	if _, err := os.Stat(claudeProjectsDir); err != nil {
-		return false
-	}
-
-	// List all directories in projects
-	entries, err := os.ReadDir(claudeProjectsDir)
-	if err != nil {
-		return false
-	}
-
-	// Check each project directory for sessions matching this working directory
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		projectDir := filepath.Join(claudeProjectsDir, entry.Name())
-
-		// Check for sessions-index.json to verify the originalPath matches
-		indexPath := filepath.Join(projectDir, "sessions-index.json")
-		if indexData, err := os.ReadFile(indexPath); err == nil {
-			// Simple check: if the index file contains the working directory path, it's a match
-			if strings.Contains(string(indexData), workingDir) {
-				return true
-			}
-		}
-
-		// Fallback: if index doesn't exist or doesn't match, check for any .jsonl files
-		// This handles cases where sessions-index.json might be missing
-		sessionEntries, err := os.ReadDir(projectDir)
-		if err != nil {
-			continue
-		}
-
-		for _, sessionEntry := range sessionEntries {
-			if !sessionEntry.IsDir() && strings.HasSuffix(sessionEntry.Name(), ".jsonl") {
-				// If we can't verify via index, assume it's valid if sessions exist
-				// This is a conservative approach that might include unrelated sessions
-				continue
-			}
-		}
-	}
-
-	return false
+	return projectPaths[workingDir]
 }
@@ -0,0 +1,314 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/types"
+)
+
+// fakeProcess holds canned answers for one PID of a fakeProvider
+type fakeProcess struct {
+	exe, name, cmdline, cwd string
+	cpu                     float64
+	rss                     uint64
+	created                 int64
+	exeErr, nameErr, cmdErr error
+	cwdErr                  error
+}
+
+// fakeProvider is a ProcessProvider backed by a fixed process table
+type fakeProvider map[int32]fakeProcess
+
+func (f fakeProvider) List() ([]int32, error) {
+	pids := make([]int32, 0, len(f))
+	for pid := range f {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids, nil
+}
+
+func (f fakeProvider) get(pid int32) (fakeProcess, error) {
+	p, ok := f[pid]
+	if !ok {
+		return p, os.ErrNotExist
+	}
+	return p, nil
+}
+
+func (f fakeProvider) Exists(pid int32) (bool, error) {
+	_, ok := f[pid]
+	return ok, nil
+}
+
+func (f fakeProvider) Name(pid int32) (string, error) {
+	p, err := f.get(pid)
+	if err != nil {
+		return "", err
+	}
+	return p.name, p.nameErr
+}
+
+func (f fakeProvider) Exe(pid int32) (string, error) {
+	p, err := f.get(pid)
+	if err != nil {
+		return "", err
+	}
+	return p.exe, p.exeErr
+}
+
+func (f fakeProvider) Cmdline(pid int32) (string, error) {
+	p, err := f.get(pid)
+	if err != nil {
+		return "", err
+	}
+	return p.cmdline, p.cmdErr
+}
+
+func (f fakeProvider) CPUPercent(pid int32) (float64, error) {
+	p, err := f.get(pid)
+	return p.cpu, err
+}
+
+func (f fakeProvider) MemoryInfo(pid int32) (MemoryInfo, error) {
+	p, err := f.get(pid)
+	return MemoryInfo{RSS: p.rss}, err
+}
+
+func (f fakeProvider) CreateTime(pid int32) (int64, error) {
+	p, err := f.get(pid)
+	return p.created, err
+}
+
+func (f fakeProvider) Cwd(pid int32) (string, error) {
+	p, err := f.get(pid)
+	if err != nil {
+		return "", err
+	}
+	return p.cwd, p.cwdErr
+}
+
+// TestClassifyProcess tests recognizing Claude instances and reporting the ones that cannot be inspected
+func TestClassifyProcess(t *testing.T) {
+	tests := []struct {
+		name       string
+		proc       fakeProcess
+		wantClaude bool
+		wantReason string // "" when the process is not reported as skipped
+	}{
+		{"claude binary", fakeProcess{exe: "/usr/local/bin/claude", cmdline: "claude"}, true, ""},
+		{"desktop app", fakeProcess{exe: "/Applications/Claude.app/Contents/MacOS/claude"}, false, ""},
+		{"other program", fakeProcess{exe: "/usr/bin/vim"}, false, ""},
+		{"unreadable non-claude", fakeProcess{exeErr: os.ErrPermission, name: "sshd"}, false, ""},
+		{"unreadable name", fakeProcess{exeErr: os.ErrPermission, nameErr: os.ErrPermission}, false, ""},
+		{"unreadable claude", fakeProcess{exeErr: os.ErrPermission, name: "claude"}, false, "permission denied"},
+		{"claude exited mid-scan", fakeProcess{exe: "/usr/local/bin/claude", cmdErr: os.ErrNotExist}, false, ""},
+		{"claude cmdline error", fakeProcess{exe: "/usr/local/bin/claude", cmdErr: errors.New("boom")}, false, "unreadable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmdline, isClaude, err := classifyProcess(fakeProvider{1: tt.proc}, 1)
+			if isClaude != tt.wantClaude {
+				t.Errorf("isClaude = %v, want %v", isClaude, tt.wantClaude)
+			}
+			if isClaude && cmdline != tt.proc.cmdline {
+				t.Errorf("cmdline = %q, want %q", cmdline, tt.proc.cmdline)
+			}
+			reason := ""
+			if err != nil {
+				reason = skipReason(err)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("skip reason = %q (err %v), want %q", reason, err, tt.wantReason)
+			}
+		})
+	}
+}
+
+// TestDiscoveryReportSummary tests the header summary of skipped processes
+func TestDiscoveryReportSummary(t *testing.T) {
+	denied := SkippedProcess{Reason: "permission denied", Err: fmt.Errorf("x: %w", os.ErrPermission)}
+	unreadable := SkippedProcess{Reason: "unreadable"}
+
+	tests := []struct {
+		name    string
+		skipped []SkippedProcess
+		want    string
+	}{
+		{"none", nil, ""},
+		{"one", []SkippedProcess{denied}, "1 Claude-like process skipped (permission denied)"},
+		{"same reason", []SkippedProcess{denied, denied, denied}, "3 Claude-like processes skipped (permission denied)"},
+		{"mixed", []SkippedProcess{denied, unreadable, denied}, "3 Claude-like processes skipped (2 permission denied, 1 unreadable)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (DiscoveryReport{Skipped: tt.skipped}).Summary(); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// withProjects points the Claude projects directory at a temp dir with one project for workDir
+func withProjects(t *testing.T, workDir string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	project := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	index := fmt.Sprintf(`{"originalPath": %q}`, workDir)
+	if err := os.WriteFile(filepath.Join(project, "sessions-index.json"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestFindClaudeProcesses tests discovery end to end against a fake process table
+func TestFindClaudeProcesses(t *testing.T) {
+	withProjects(t, "/work/app")
+	created := time.Now().Add(-time.Hour).UnixMilli()
+
+	pp := fakeProvider{
+		10: {exe: "/usr/local/bin/claude", cmdline: "claude", cwd: "/work/app", cpu: 12.5, rss: 256 << 20, created: created},
+		11: {exe: "/usr/local/bin/claude", cmdline: "claude --mcp", cwd: "/work/app"},
+		12: {exe: "/usr/local/bin/claude", cmdline: "claude", cwd: "/elsewhere"},
+		13: {exe: "/usr/local/bin/claude", cmdline: "claude", cwdErr: os.ErrPermission},
+		14: {exe: "/usr/bin/zsh", cmdline: "zsh", cwd: "/work/app"},
+		15: {exe: "/usr/local/bin/claude", cmdline: "claude", cwd: "/work/removed", cwdErr: fmt.Errorf("%w: /work/removed", ErrWorkDirGone)},
+	}
+
+	tests := []struct {
+		name     string
+		opts     DiscoveryOptions
+		wantPIDs []int32
+	}{
+		{"default", DiscoveryOptions{}, []int32{10, 15}},
+		{"with helpers", DiscoveryOptions{ShowHelpers: true}, []int32{10, 11, 15}},
+		{"with session-less", DiscoveryOptions{ShowSessionless: true}, []int32{10, 12, 15}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			procs, report, err := findClaudeProcesses(pp, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var pids []int32
+			for _, p := range procs {
+				pids = append(pids, p.PID)
+			}
+			if fmt.Sprint(pids) != fmt.Sprint(tt.wantPIDs) {
+				t.Errorf("PIDs = %v, want %v", pids, tt.wantPIDs)
+			}
+			if len(report.Skipped) != 1 || report.Skipped[0].PID != 13 {
+				t.Errorf("skipped = %+v, want PID 13", report.Skipped)
+			}
+		})
+	}
+
+	procs, _, _ := findClaudeProcesses(pp, DiscoveryOptions{ShowSessionless: true})
+	if p := procs[0]; p.CPUPercent != 12.5 || p.MemoryMB != 256 || p.Uptime < time.Hour || p.IsHelper || p.WorkDirGone || p.NoSessions {
+		t.Errorf("metrics = %+v", p)
+	}
+	// A process whose directory is gone skips the session check but keeps its old path
+	if p := procs[1]; p.WorkingDir != "/elsewhere" || !p.NoSessions {
+		t.Errorf("process without sessions = %+v", p)
+	}
+	if p := procs[2]; p.WorkingDir != "/work/removed" || !p.WorkDirGone || p.NoSessions {
+		t.Errorf("process with a removed directory = %+v", p)
+	}
+}
+
+// TestExplainProcesses tests that every Claude-like process gets a verdict that agrees
+// with what discovery lists
+func TestExplainProcesses(t *testing.T) {
+	withProjects(t, "/work/app")
+	pp := fakeProvider{
+		10: {exe: "/usr/local/bin/claude", cmdline: "claude", cwd: "/work/app"},
+		11: {exe: "/usr/local/bin/claude", cmdline: "claude --mcp", cwd: "/work/app"},
+		12: {exe: "/usr/local/bin/claude", cmdline: "claude", cwd: "/elsewhere"},
+		13: {exe: "/usr/local/bin/claude", cmdline: "claude", cwdErr: os.ErrPermission},
+		14: {exe: "/usr/bin/zsh", name: "zsh", cmdline: "zsh", cwd: "/work/app"},
+		16: {exe: "/Applications/Claude.app/Contents/MacOS/Claude", name: "Claude"},
+		17: {name: "claude", exeErr: os.ErrPermission},
+	}
+
+	verdicts, err := explainProcesses(pp, DiscoveryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		pid    int32
+		listed bool
+		reason string
+	}{
+		{10, true, "has sessions"},
+		{11, false, "MCP helper"},
+		{12, false, "no sessions"},
+		{13, false, "permission denied"},
+		{16, false, "desktop app"},
+		{17, false, "cannot read executable"},
+	}
+	if len(verdicts) != len(want) {
+		t.Fatalf("verdicts = %+v, want %d", verdicts, len(want))
+	}
+	for i, w := range want {
+		v := verdicts[i]
+		if v.PID != w.pid || v.Listed != w.listed || !strings.Contains(v.Reason, w.reason) {
+			t.Errorf("verdict %d = %+v, want PID %d listed=%v mentioning %q", i, v, w.pid, w.listed, w.reason)
+		}
+	}
+
+	// Listing session-less processes flips only their verdict
+	verdicts, _ = explainProcesses(pp, DiscoveryOptions{ShowSessionless: true})
+	procs, _, _ := findClaudeProcesses(pp, DiscoveryOptions{ShowSessionless: true})
+	var listed, found []int32
+	for _, v := range verdicts {
+		if v.Listed {
+			listed = append(listed, v.PID)
+		}
+	}
+	for _, p := range procs {
+		found = append(found, p.PID)
+	}
+	if fmt.Sprint(listed) != fmt.Sprint(found) {
+		t.Errorf("listed = %v, discovery found %v", listed, found)
+	}
+}
+
+// TestRefreshMetrics tests updating a known process and detecting one that exited
+func TestRefreshMetrics(t *testing.T) {
+	pp := fakeProvider{10: {cpu: 50, rss: 1 << 20, cwd: "/work/new"}}
+
+	proc := types.ClaudeProcess{PID: 10, CPUPercent: 1, MemoryMB: 1, WorkingDir: "/work/old"}
+	if err := refreshMetrics(pp, &proc); err != nil {
+		t.Fatal(err)
+	}
+	if proc.CPUPercent != 50 || proc.MemoryMB != 1 || proc.WorkingDir != "/work/new" {
+		t.Errorf("refreshed = %+v", proc)
+	}
+
+	// The directory disappears, then the process moves on to a new one
+	pp[10] = fakeProcess{cwd: "/work/new", cwdErr: fmt.Errorf("%w: /work/new", ErrWorkDirGone)}
+	if err := refreshMetrics(pp, &proc); err != nil || !proc.WorkDirGone || proc.WorkingDir != "/work/new" {
+		t.Errorf("after removal = %+v, %v", proc, err)
+	}
+	pp[10] = fakeProcess{cwd: "/work/other"}
+	if err := refreshMetrics(pp, &proc); err != nil || proc.WorkDirGone || proc.WorkingDir != "/work/other" {
+		t.Errorf("after cd = %+v, %v", proc, err)
+	}
+
+	gone := types.ClaudeProcess{PID: 99}
+	if err := refreshMetrics(pp, &gone); !errors.Is(err, ErrProcessGone) {
+		t.Errorf("refreshMetrics() on an exited process = %v, want ErrProcessGone", err)
+	}
+}
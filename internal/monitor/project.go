@@ -0,0 +1,234 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ProjectStats summarizes all session files of a project directory
+type ProjectStats struct {
+	Sessions int // Session files, excluding subagent files
+	Agents   int // Subagent (agent-*.jsonl) files; their tokens, models and tools are included
+	Failed   int // Session files that could not be parsed
+	Bypass   int // Sessions that ran in bypassPermissions mode, excluding subagent files
+	Outside  int // Sessions whose tool calls wrote outside their working directory
+
+	First time.Time // Earliest message of any session
+	Last  time.Time // Latest message of any session
+
+	Tokens TokenCounts
+	Cost   float64
+
+	TotalDuration time.Duration // Sum of the session durations, excluding subagent files
+
+	Models    []ModelUsage   // Per-model split, most tokens first
+	Tools     []EntryCount   // Tool calls by tool name, most used first
+	Languages []EntryCount   // Code fences and file writes by language, most frequent first
+	Days      []DayActivity  // Activity per calendar day, oldest first
+	models    map[string]int // Index into Models by model ID
+	tools     map[string]int // Index into Tools by tool name
+	languages map[string]int // Index into Languages by language
+	days      map[string]int // Index into Days by date
+
+	// Prompts with pasted content, the bytes pasted and the TopPastes largest
+	// pastes, largest first; subagent files have none
+	Pastes        int
+	PastedBytes   int
+	LargestPastes []Paste
+
+	// Efficiency has the tokens per prompt of each session with prompts, oldest first
+	Efficiency []SessionEfficiency
+}
+
+// ModelUsage is the share of one model in a project's assistant responses
+type ModelUsage struct {
+	Model     string
+	Responses int
+	Tokens    TokenCounts
+	Cost      float64
+}
+
+// DayActivity counts the sessions started and messages sent on a calendar day
+type DayActivity struct {
+	Day      time.Time // Local midnight
+	Sessions int
+	Messages int
+}
+
+// AvgDuration returns the average length of the project's sessions
+func (p *ProjectStats) AvgDuration() time.Duration {
+	if p.Sessions == 0 {
+		return 0
+	}
+	return p.TotalDuration / time.Duration(p.Sessions)
+}
+
+// TopTools returns the n most used tools
+func (p *ProjectStats) TopTools(n int) []EntryCount {
+	return p.Tools[:min(n, len(p.Tools))]
+}
+
+// BusiestDays returns the n days with the most messages, busiest first
+func (p *ProjectStats) BusiestDays(n int) []DayActivity {
+	days := append([]DayActivity(nil), p.Days...)
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Messages > days[j].Messages
+	})
+	return days[:min(n, len(days))]
+}
+
+// Add folds a parsed session into the project totals. Pricing lives outside this
+// package, so the per-message cost function is supplied by the caller.
+func (p *ProjectStats) Add(stats *SessionStats, agent bool, cost func(*Message) float64) {
+	if p.models == nil {
+		p.models = make(map[string]int)
+		p.tools = make(map[string]int)
+		p.languages = make(map[string]int)
+		p.days = make(map[string]int)
+	}
+	for _, lang := range stats.Languages {
+		if i, ok := p.languages[lang.Label]; ok {
+			p.Languages[i].Count += lang.Count
+		} else {
+			p.languages[lang.Label] = len(p.Languages)
+			p.Languages = append(p.Languages, lang)
+		}
+	}
+
+	if agent {
+		p.Agents++
+	} else {
+		p.Sessions++
+		p.TotalDuration += stats.Duration
+		if RanInBypassMode(stats.PermissionModes) {
+			p.Bypass++
+		}
+		if len(stats.OutsideWrites) > 0 {
+			p.Outside++
+		}
+		if e := stats.Efficiency(); e.Prompts > 0 {
+			p.Efficiency = append(p.Efficiency, e)
+		}
+	}
+
+	var started bool
+	for i := range stats.MessageHistory {
+		msg := &stats.MessageHistory[i]
+		if !msg.Timestamp.IsZero() {
+			if p.First.IsZero() || msg.Timestamp.Before(p.First) {
+				p.First = msg.Timestamp
+			}
+			if msg.Timestamp.After(p.Last) {
+				p.Last = msg.Timestamp
+			}
+			day := p.day(msg.Timestamp)
+			day.Messages++
+			if !started && !agent {
+				day.Sessions++
+				started = true
+			}
+		}
+
+		if msg.ContainsPaste() {
+			p.addPaste(SessionFileID(stats.FilePath), msg)
+		}
+
+		for _, call := range msg.Calls() {
+			if i, ok := p.tools[call.Name]; ok {
+				p.Tools[i].Count++
+			} else {
+				p.tools[call.Name] = len(p.Tools)
+				p.Tools = append(p.Tools, EntryCount{Label: call.Name, Count: 1})
+			}
+		}
+
+		if msg.Type != "assistant_response" {
+			continue
+		}
+		c := cost(msg)
+		p.Tokens.Add(*msg)
+		p.Cost += c
+		if msg.Model == "" || msg.Model[0] == '<' {
+			continue // Synthetic responses carry no real model
+		}
+		i, ok := p.models[msg.Model]
+		if !ok {
+			i = len(p.Models)
+			p.models[msg.Model] = i
+			p.Models = append(p.Models, ModelUsage{Model: msg.Model})
+		}
+		p.Models[i].Responses++
+		p.Models[i].Tokens.Add(*msg)
+		p.Models[i].Cost += c
+	}
+}
+
+// day returns the activity entry for the calendar day of t, creating it if needed
+func (p *ProjectStats) day(t time.Time) *DayActivity {
+	t = t.Local()
+	key := t.Format("2006-01-02")
+	if i, ok := p.days[key]; ok {
+		return &p.Days[i]
+	}
+	p.days[key] = len(p.Days)
+	p.Days = append(p.Days, DayActivity{Day: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)})
+	return &p.Days[len(p.Days)-1]
+}
+
+// sort orders the per-model, per-tool, per-language, per-day and per-session lists for display. The lookup maps
+// are dropped, as they no longer match the reordered lists.
+func (p *ProjectStats) sort() {
+	sort.SliceStable(p.Models, func(i, j int) bool {
+		return p.Models[i].Tokens.Total() > p.Models[j].Tokens.Total()
+	})
+	sort.SliceStable(p.Tools, func(i, j int) bool {
+		return p.Tools[i].Count > p.Tools[j].Count
+	})
+	sort.SliceStable(p.Languages, func(i, j int) bool {
+		return p.Languages[i].Count > p.Languages[j].Count
+	})
+	sort.Slice(p.Days, func(i, j int) bool {
+		return p.Days[i].Day.Before(p.Days[j].Day)
+	})
+	p.sortEfficiency()
+	p.models, p.tools, p.languages, p.days = nil, nil, nil, nil
+}
+
+// ScanProject parses every session file in a project directory and sums them up,
+// calling progress (if non-nil) after each file. If ctx is cancelled, scanning stops
+// and an error wrapping ctx.Err() is returned.
+func ScanProject(ctx context.Context, dir string, cost func(*Message) float64, progress func(done, total int)) (*ProjectStats, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read project directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && IsSessionFile(entry.Name()) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	stats := &ProjectStats{}
+	for i, path := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("project scan stopped: %w", err)
+		}
+		session, err := ParseSessionFile(path)
+		if err != nil {
+			logger.Debug("cannot parse session file", "op", "scan_project", "path", path, "err", err)
+			stats.Failed++
+		} else {
+			stats.Add(session, IsAgentFile(path), cost)
+		}
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+	stats.sort()
+	return stats, nil
+}
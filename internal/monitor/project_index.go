@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// projectPathCache remembers which working directories the projects under
+// ~/.claude/projects belong to, so process discovery does not re-read every
+// sessions-index.json on each refresh. The listing is re-read only when the projects
+// directory changes; projects whose index could not be read yet are retried each time.
+type projectPathCache struct {
+	mu       sync.Mutex
+	dir      string              // Projects directory the cache was built from
+	modTime  time.Time           // Its modification time when last listed
+	projects map[string][]string // Paths named by each project's index, by directory name; nil until read
+	set      map[string]bool     // Union of all projects' paths; replaced, never modified, when it changes
+}
+
+// projectPaths is the cache used by FindClaudeProcesses
+var projectPaths projectPathCache
+
+// paths returns the set of working directories that have a project, refreshing the
+// cache as needed. The set must not be modified.
+func (c *projectPathCache) paths() map[string]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir, err := ProjectsDir()
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		c.dir, c.projects, c.set = "", nil, nil
+		return nil
+	}
+
+	if dir != c.dir || !info.ModTime().Equal(c.modTime) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil
+		}
+		projects := make(map[string][]string, len(entries))
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if dir == c.dir {
+				projects[entry.Name()] = c.projects[entry.Name()] // Paths of a project never change
+			} else {
+				projects[entry.Name()] = nil
+			}
+		}
+		c.dir, c.modTime, c.projects, c.set = dir, info.ModTime(), projects, nil
+	}
+
+	for name, projectPaths := range c.projects {
+		if projectPaths == nil {
+			if projectPaths = readProjectPaths(filepath.Join(dir, name)); projectPaths != nil {
+				c.projects[name], c.set = projectPaths, nil
+			}
+		}
+	}
+	if c.set == nil {
+		c.set = make(map[string]bool)
+		for _, projectPaths := range c.projects {
+			for _, p := range projectPaths {
+				c.set[p] = true
+			}
+		}
+	}
+	return c.set
+}
+
+// reset drops everything cached, so the next lookup reads the projects directory afresh
+func (c *projectPathCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dir, c.modTime, c.projects, c.set = "", time.Time{}, nil, nil
+}
+
+// readProjectPaths returns the original path and session project paths named by a
+// project's sessions-index.json, or nil if it has none (yet)
+func readProjectPaths(projectDir string) []string {
+	index, err := ParseSessionIndex(filepath.Join(projectDir, "sessions-index.json"))
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	if index.OriginalPath != "" {
+		paths = append(paths, index.OriginalPath)
+	}
+	for _, e := range index.Entries {
+		if e.ProjectPath != "" && e.ProjectPath != index.OriginalPath {
+			paths = append(paths, e.ProjectPath)
+		}
+	}
+	if paths == nil {
+		return []string{} // Read, but names no path; not worth retrying
+	}
+	return paths
+}
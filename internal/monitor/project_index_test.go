@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeProject creates a project directory under projectsDir, with a sessions index
+// naming originalPath unless it is ""
+func writeProject(t testing.TB, projectsDir, name, originalPath string) {
+	t.Helper()
+	dir := filepath.Join(projectsDir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if originalPath == "" {
+		return
+	}
+	index := fmt.Sprintf(`{"originalPath": %q, "entries": [{"projectPath": %q}]}`, originalPath, originalPath)
+	if err := os.WriteFile(filepath.Join(dir, "sessions-index.json"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withProjectsDir points ProjectsDir at a fresh temp dir and clears the path cache
+func withProjectsDir(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	SetProjectsDir(dir)
+	projectPaths.reset()
+	t.Cleanup(func() {
+		SetProjectsDir("")
+		projectPaths.reset()
+	})
+	return dir
+}
+
+// touch gives dir a modification time distinct from any earlier listing
+func touch(t *testing.T, dir string, n int) {
+	t.Helper()
+	mtime := time.Date(2026, 1, 1, 0, 0, n, 0, time.UTC)
+	if err := os.Chtimes(dir, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sortedPaths(set map[string]bool) string {
+	var paths []string
+	for p := range set {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return strings.Join(paths, " ")
+}
+
+// TestProjectPathCache tests that the cache picks up new projects and late indexes but
+// does not re-read indexes it already has
+func TestProjectPathCache(t *testing.T) {
+	dir := withProjectsDir(t)
+	writeProject(t, dir, "-work-a", "/work/a")
+	writeProject(t, dir, "-work-b", "") // Claude has not written the index yet
+	touch(t, dir, 1)
+
+	steps := []struct {
+		name   string
+		change func()
+		want   string
+	}{
+		{"initial listing", func() {}, "/work/a"},
+		{"late index is picked up", func() { writeProject(t, dir, "-work-b", "/work/b") }, "/work/a /work/b"},
+		{"known index is not re-read", func() { writeProject(t, dir, "-work-a", "/work/renamed") }, "/work/a /work/b"},
+		{"new project", func() { writeProject(t, dir, "-work-c", "/work/c"); touch(t, dir, 2) }, "/work/a /work/b /work/c"},
+		{"removed project", func() { os.RemoveAll(filepath.Join(dir, "-work-b")); touch(t, dir, 3) }, "/work/a /work/c"},
+	}
+	for _, step := range steps {
+		step.change()
+		if got := sortedPaths(projectPaths.paths()); got != step.want {
+			t.Errorf("%s: paths = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+// BenchmarkFindClaudeProcesses measures a discovery pass over 10 processes and 100
+// projects, re-reading every sessions index ("uncached") versus reusing the listing
+// of the previous pass ("cached")
+func BenchmarkFindClaudeProcesses(b *testing.B) {
+	dir := withProjectsDir(b)
+	pp := fakeProvider{}
+	for i := range 100 {
+		writeProject(b, dir, fmt.Sprintf("-work-p%d", i), fmt.Sprintf("/work/p%d", i))
+	}
+	for i := range 10 {
+		pp[int32(100+i)] = fakeProcess{exe: "/usr/local/bin/claude", cmdline: "claude", cwd: fmt.Sprintf("/work/p%d", i*10)}
+	}
+
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if !cached {
+					projectPaths.reset()
+				}
+				procs, _, err := findClaudeProcesses(pp, DiscoveryOptions{})
+				if err != nil || len(procs) != 10 {
+					b.Fatalf("found %d processes, err %v", len(procs), err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestProjectStats tests that sessions are summed into project totals
+func TestProjectStats(t *testing.T) {
+	day1 := time.Date(2026, 1, 9, 14, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+	cost := func(m *Message) float64 { return float64(m.OutputTokens) / 100 }
+
+	var p ProjectStats
+	p.Add(&SessionStats{Duration: time.Hour, MessageHistory: []Message{
+		{Type: "prompt", Timestamp: day1},
+		{Type: "assistant_response", Timestamp: day1, Model: "claude-opus-4-5", ToolName: "Bash", InputTokens: 10, CacheCreation: 5, OutputTokens: 100},
+		{Type: "assistant_response", Timestamp: day1, Model: "claude-opus-4-5", ToolName: "Read", OutputTokens: 50, CacheRead: 1000},
+		{Type: "assistant_response", Timestamp: day1, Model: "<synthetic>", ToolName: "Bash"},
+	}}, false, cost)
+	p.Add(&SessionStats{Duration: 3 * time.Hour, MessageHistory: []Message{
+		{Type: "prompt", Timestamp: day2},
+		{Type: "assistant_response", Timestamp: day2, Model: "claude-haiku-4-5", OutputTokens: 400},
+	}}, false, cost)
+	p.Add(&SessionStats{Duration: 5 * time.Hour, MessageHistory: []Message{
+		{Type: "assistant_response", Timestamp: day2, Model: "claude-haiku-4-5", ToolName: "Grep", OutputTokens: 10},
+	}}, true, cost)
+	p.sort()
+
+	if p.Sessions != 2 || p.Agents != 1 || p.AvgDuration() != 2*time.Hour {
+		t.Errorf("sessions = %d, agents = %d, avg = %v; want 2, 1, 2h", p.Sessions, p.Agents, p.AvgDuration())
+	}
+	if !p.First.Equal(day1) || !p.Last.Equal(day2) {
+		t.Errorf("range = %v – %v, want %v – %v", p.First, p.Last, day1, day2)
+	}
+	if p.Tokens.Total() != 575 || p.Tokens.CacheRead != 1000 || p.Cost != 5.6 {
+		t.Errorf("tokens = %d, cache read = %d, cost = %g; want 575, 1000, 5.6", p.Tokens.Total(), p.Tokens.CacheRead, p.Cost)
+	}
+	if len(p.Models) != 2 || p.Models[0].Model != "claude-haiku-4-5" || p.Models[0].Responses != 2 || p.Models[1].Tokens.Total() != 165 {
+		t.Errorf("models = %+v, want haiku (2 responses) before opus (165 tokens)", p.Models)
+	}
+	if top := p.TopTools(2); len(top) != 2 || top[0] != (EntryCount{Label: "Bash", Count: 2}) {
+		t.Errorf("TopTools(2) = %+v, want Bash first with 2 calls", top)
+	}
+	busiest := p.BusiestDays(5)
+	if len(busiest) != 2 || !busiest[0].Day.Equal(time.Date(2026, 1, 9, 0, 0, 0, 0, time.Local)) || busiest[0].Messages != 4 || busiest[1].Sessions != 1 {
+		t.Errorf("BusiestDays = %+v, want Jan 9 (4 messages) then Jan 10 (1 session)", busiest)
+	}
+}
+
+// TestScanProject tests scanning a project directory with progress reports
+func TestScanProject(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "sample_session.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.jsonl", "agent-1.jsonl", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var calls [][2]int
+	stats, err := ScanProject(context.Background(), dir, func(*Message) float64 { return 0 }, func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Sessions != 1 || stats.Agents != 1 || stats.Failed != 0 {
+		t.Errorf("stats = %d sessions, %d agents, %d failed; want 1, 1, 0", stats.Sessions, stats.Agents, stats.Failed)
+	}
+	if len(calls) != 2 || calls[1] != [2]int{2, 2} {
+		t.Errorf("progress calls = %v, want 1/2 and 2/2", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ScanProject(ctx, dir, func(*Message) float64 { return 0 }, nil); err == nil {
+		t.Error("ScanProject with a cancelled context should fail")
+	}
+}
@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// MemoryInfo holds the memory figures promptwatch reports for a process
+type MemoryInfo struct {
+	RSS uint64 // Resident set size in bytes
+}
+
+// ProcessProvider is the source of process information used by discovery and metric
+// refreshes. The default implementation reads live processes through gopsutil; tests
+// and replays substitute their own.
+type ProcessProvider interface {
+	List() ([]int32, error)
+	Exists(pid int32) (bool, error) // Whether the process is still running
+	Name(pid int32) (string, error)
+	Exe(pid int32) (string, error)
+	Cmdline(pid int32) (string, error)
+	CPUPercent(pid int32) (float64, error)
+	MemoryInfo(pid int32) (MemoryInfo, error)
+	CreateTime(pid int32) (int64, error) // Milliseconds since the Unix epoch
+	Cwd(pid int32) (string, error)       // The last known path with ErrWorkDirGone if it was deleted
+}
+
+// ErrProcessGone reports that a process has exited
+var ErrProcessGone = errors.New("process has exited")
+
+// ErrWorkDirGone reports that a process's working directory was deleted or unmounted.
+// Cwd returns it together with the directory's last known path.
+var ErrWorkDirGone = errors.New("working directory is gone")
+
+// provider is the process source used by FindClaudeProcesses and RefreshMetrics; nil
+// until first needed, so runs that never discover processes never set up gopsutil
+var (
+	providerMu sync.Mutex
+	provider   ProcessProvider
+)
+
+// SetProcessProvider replaces the process source; nil restores reading live processes.
+// It is meant to be called once at startup, before any discovery runs.
+func SetProcessProvider(p ProcessProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	provider = p
+}
+
+// currentProvider returns the process source, reading live processes unless replaced
+func currentProvider() ProcessProvider {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	if provider == nil {
+		provider = newGopsutilProvider()
+	}
+	return provider
+}
+
+// gopsutilProvider reads live processes. Handles from the latest List are kept so
+// per-process lookups don't re-check that the PID exists.
+type gopsutilProvider struct {
+	mu    sync.Mutex
+	procs map[int32]*process.Process
+}
+
+func newGopsutilProvider() *gopsutilProvider {
+	return &gopsutilProvider{procs: make(map[int32]*process.Process)}
+}
+
+func (g *gopsutilProvider) List() ([]int32, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	pids := make([]int32, len(procs))
+	byPID := make(map[int32]*process.Process, len(procs))
+	for i, p := range procs {
+		pids[i] = p.Pid
+		byPID[p.Pid] = p
+	}
+
+	g.mu.Lock()
+	g.procs = byPID
+	g.mu.Unlock()
+	return pids, nil
+}
+
+// process returns the handle for pid, creating one if it was not in the last listing
+func (g *gopsutilProvider) process(pid int32) (*process.Process, error) {
+	g.mu.Lock()
+	p, ok := g.procs[pid]
+	g.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+	return process.NewProcess(pid)
+}
+
+// Exists checks the PID itself rather than the handle from the last List, which
+// caches what it has read and so goes on answering for a process that has exited
+func (g *gopsutilProvider) Exists(pid int32) (bool, error) {
+	return process.PidExists(pid)
+}
+
+func (g *gopsutilProvider) Name(pid int32) (string, error) {
+	p, err := g.process(pid)
+	if err != nil {
+		return "", err
+	}
+	return p.Name()
+}
+
+func (g *gopsutilProvider) Exe(pid int32) (string, error) {
+	p, err := g.process(pid)
+	if err != nil {
+		return "", err
+	}
+	return p.Exe()
+}
+
+func (g *gopsutilProvider) Cmdline(pid int32) (string, error) {
+	p, err := g.process(pid)
+	if err != nil {
+		return "", err
+	}
+	return p.Cmdline()
+}
+
+func (g *gopsutilProvider) CPUPercent(pid int32) (float64, error) {
+	p, err := g.process(pid)
+	if err != nil {
+		return 0, err
+	}
+	return p.CPUPercent()
+}
+
+func (g *gopsutilProvider) MemoryInfo(pid int32) (MemoryInfo, error) {
+	p, err := g.process(pid)
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	mem, err := p.MemoryInfo()
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	return MemoryInfo{RSS: mem.RSS}, nil
+}
+
+func (g *gopsutilProvider) CreateTime(pid int32) (int64, error) {
+	p, err := g.process(pid)
+	if err != nil {
+		return 0, err
+	}
+	return p.CreateTime()
+}
+
+// Cwd uses the platform-specific lookup (proc_pidinfo on macOS, /proc on Linux)
+func (g *gopsutilProvider) Cwd(pid int32) (string, error) {
+	return getWorkingDir(pid)
+}
@@ -0,0 +1,182 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateMinutes is how far back TokenRates remembers the tokens added to a session
+const RateMinutes = 15
+
+// TokenRate is the tokens (TokenCounts.Total) added to a session per minute over the
+// last RateMinutes minutes, oldest first; the last minute is the current one
+type TokenRate [RateMinutes]int
+
+// Last returns the tokens added in the last minutes minutes, including the current one
+func (r TokenRate) Last(minutes int) int {
+	sum := 0
+	for _, n := range r[RateMinutes-min(max(minutes, 0), RateMinutes):] {
+		sum += n
+	}
+	return sum
+}
+
+// Idle reports whether no tokens were added in the last RateMinutes minutes
+func (r TokenRate) Idle() bool {
+	return r.Last(RateMinutes) == 0
+}
+
+// Plus returns the per-minute sums of both rates, e.g. for the sessions of one process
+func (r TokenRate) Plus(other TokenRate) TokenRate {
+	for i := range r {
+		r[i] += other[i]
+	}
+	return r
+}
+
+// TokenRates tracks the tokens added to live session files. Each sample reads only
+// what was appended since the previous one, with a SessionFollower that forgets the
+// messages once counted, and files their tokens in buckets of one minute by message
+// timestamp; so a session costs a fixed ring of counts however long it runs. Files not
+// written in the last RateMinutes minutes are not read at all. It is safe for
+// concurrent use.
+type TokenRates struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionRate
+}
+
+// sessionRate is the ring of per-minute token counts of one session file
+type sessionRate struct {
+	follower *SessionFollower
+	compacts int       // CompactCount at the previous sample
+	buckets  TokenRate // buckets[m%RateMinutes] holds the tokens of unix minute m
+	minute   int64     // Newest unix minute the ring holds
+	sampled  time.Time // Time of the last sample, to drop sessions no longer asked about
+}
+
+// NewTokenRates returns an empty tracker
+func NewTokenRates() *TokenRates {
+	return &TokenRates{sessions: make(map[string]*sessionRate)}
+}
+
+// Sample reads what was appended to the session files at paths and returns the rates
+// of those that added tokens in the last RateMinutes minutes. A file that shrank or was
+// compacted starts over, as the tokens read again or spent on the summary are not new
+// work. Sessions idle or not sampled for RateMinutes minutes are forgotten.
+func (r *TokenRates) Sample(paths []string, now time.Time) map[string]TokenRate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	since := now.Add(-RateMinutes * time.Minute)
+	rates := make(map[string]TokenRate)
+	for _, path := range paths {
+		rate, ok := r.sample(path, now, since)
+		if ok && !rate.Idle() {
+			rates[path] = rate
+		}
+	}
+	for path, s := range r.sessions {
+		if s.sampled.Before(since) {
+			delete(r.sessions, path)
+		}
+	}
+	return rates
+}
+
+// SampleProject samples the session files of the project directory dir, as Sample,
+// and returns their sum
+func (r *TokenRates) SampleProject(dir string, now time.Time) TokenRate {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return TokenRate{}
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	var sum TokenRate
+	for _, rate := range r.Sample(paths, now) {
+		sum = sum.Plus(rate)
+	}
+	return sum
+}
+
+// sample updates the ring of one session file; ok is false for files not followed
+func (r *TokenRates) sample(path string, now, since time.Time) (rate TokenRate, ok bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Before(since) {
+		// Removed, or idle for the whole window: nothing to read, and a session written
+		// again later starts afresh
+		delete(r.sessions, path)
+		return TokenRate{}, false
+	}
+	s := r.sessions[path]
+	if s == nil {
+		s = &sessionRate{follower: NewSessionFollower(path)}
+		r.sessions[path] = s
+	}
+	s.sampled = now
+
+	offset := s.follower.offset
+	if _, err := s.follower.Update(); err != nil {
+		delete(r.sessions, path) // Removed or unreadable
+		return TokenRate{}, false
+	}
+	messages := s.follower.Drain()
+	compacts := s.follower.Stats().CompactCount
+	switch {
+	case s.follower.offset < offset:
+		s.buckets = TokenRate{} // Rewritten from the start: count it anew
+	case compacts > s.compacts && offset > 0:
+		s.buckets = TokenRate{} // Compacted: what follows is a new stretch of work
+		messages = nil
+	}
+	s.compacts = compacts
+
+	s.advance(now)
+	for _, msg := range messages {
+		at := msg.Timestamp
+		if at.IsZero() || at.After(now) {
+			at = now
+		}
+		s.add(at, msg.Tokens().Total())
+	}
+
+	return s.rate(), true
+}
+
+// advance moves the ring on to the minute of now, clearing the minutes passed
+func (s *sessionRate) advance(now time.Time) {
+	minute := now.Unix() / 60
+	if s.minute == 0 || minute-s.minute >= RateMinutes {
+		s.buckets = TokenRate{}
+		s.minute = minute
+		return
+	}
+	for ; s.minute < minute; s.minute++ {
+		s.buckets[(s.minute+1)%RateMinutes] = 0
+	}
+}
+
+// add counts tokens in the minute of at, if the ring still holds it
+func (s *sessionRate) add(at time.Time, tokens int) {
+	minute := at.Unix() / 60
+	if minute > s.minute || minute <= s.minute-RateMinutes {
+		return
+	}
+	s.buckets[minute%RateMinutes] += tokens
+}
+
+// rate returns the ring in order, oldest minute first
+func (s *sessionRate) rate() TokenRate {
+	var rate TokenRate
+	for i := range rate {
+		rate[i] = s.buckets[(s.minute+1+int64(i))%RateMinutes]
+	}
+	return rate
+}
@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTokenRates tests the per-minute counts of a live session as it is appended to,
+// compacted, rewritten and left idle
+func TestTokenRates(t *testing.T) {
+	now := time.Date(2026, 1, 9, 14, 30, 30, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "live.jsonl")
+	answer := func(at time.Time, output int) string {
+		return fmt.Sprintf(`{"type":"assistant","timestamp":%q,"message":{"role":"assistant","content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":10,"cache_read_input_tokens":5000,"output_tokens":%d}}}`+"\n",
+			at.Format(time.RFC3339), output)
+	}
+	write := func(flag int, lines ...string) {
+		t.Helper()
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|flag, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range lines {
+			file.WriteString(line)
+		}
+		file.Close()
+		if err := os.Chtimes(path, now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewTokenRates()
+	// An hour-old answer is outside the window; the others land in their minutes
+	write(os.O_TRUNC,
+		answer(now.Add(-time.Hour), 990),
+		answer(now.Add(-10*time.Minute), 90),
+		answer(now.Add(-3*time.Minute), 190),
+		answer(now, 290))
+	rate := r.Sample([]string{path}, now)[path]
+	if rate.Last(1) != 300 || rate.Last(5) != 500 || rate.Last(15) != 600 {
+		t.Errorf("first sample: last 1/5/15 minutes = %d/%d/%d, want 300/500/600", rate.Last(1), rate.Last(5), rate.Last(15))
+	}
+
+	// Two minutes on, only the new answer is read; the old minutes have moved along
+	now = now.Add(2 * time.Minute)
+	write(os.O_APPEND, answer(now, 40))
+	rate = r.Sample([]string{path}, now)[path]
+	if rate.Last(1) != 50 || rate.Last(3) != 350 || rate.Last(15) != 650 {
+		t.Errorf("after appending: last 1/3/15 minutes = %d/%d/%d, want 50/350/650", rate.Last(1), rate.Last(3), rate.Last(15))
+	}
+
+	// Compaction clears the ring; what follows counts again
+	write(os.O_APPEND, `{"type":"compact","timestamp":"`+now.Format(time.RFC3339)+`"}`+"\n", answer(now, 4990))
+	if rate, ok := r.Sample([]string{path}, now)[path]; ok {
+		t.Errorf("after compaction: %v, want no rate", rate)
+	}
+	write(os.O_APPEND, answer(now, 90))
+	if rate := r.Sample([]string{path}, now)[path]; rate.Last(15) != 100 {
+		t.Errorf("after compaction and an answer: %d tokens, want 100", rate.Last(15))
+	}
+
+	// A rewritten file is counted from the start
+	write(os.O_TRUNC, answer(now, 10))
+	if rate := r.Sample([]string{path}, now)[path]; rate.Last(15) != 20 {
+		t.Errorf("after rewriting: %d tokens, want 20", rate.Last(15))
+	}
+
+	// Idle for the whole window: dropped and no longer read
+	now = now.Add(RateMinutes*time.Minute + time.Second)
+	if rates := r.Sample([]string{path}, now); len(rates) != 0 {
+		t.Errorf("idle session rates = %v, want none", rates)
+	}
+	if len(r.sessions) != 0 {
+		t.Errorf("%d sessions still tracked after going idle", len(r.sessions))
+	}
+}
+
+// TestTokenRateLast tests window sums at and beyond the ends of the ring
+func TestTokenRateLast(t *testing.T) {
+	var rate TokenRate
+	for i := range rate {
+		rate[i] = i + 1
+	}
+	tests := []struct {
+		minutes int
+		want    int
+	}{
+		{0, 0},
+		{1, 15},
+		{5, 15 + 14 + 13 + 12 + 11},
+		{RateMinutes, 120},
+		{60, 120},
+		{-1, 0},
+	}
+	for _, tt := range tests {
+		if got := rate.Last(tt.minutes); got != tt.want {
+			t.Errorf("Last(%d) = %d, want %d", tt.minutes, got, tt.want)
+		}
+	}
+}
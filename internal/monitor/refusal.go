@@ -0,0 +1,52 @@
+package monitor
+
+import "regexp"
+
+// stopReasonRefusal is the stop_reason of an assistant response the API's safety
+// system cut off
+const stopReasonRefusal = "refusal"
+
+// DefaultRefusalPatterns are the regular expressions that flag an assistant response as
+// a refusal by its wording. They are anchored at the start of the response and only
+// catch outright declines, as coding sessions rarely refuse and a response merely
+// mentioning what it cannot do is not one.
+var DefaultRefusalPatterns = []string{
+	`^(?i)I (can['’]t|cannot|won['’]t|will not) (help|assist) with (that|this)`,
+	`^(?i)I['’]m (sorry|afraid),? but I (can['’]t|cannot|won['’]t|will not) (help|assist)`,
+	`^(?i)I['’]m not (able|going) to (help|assist) with (that|this)`,
+}
+
+// refusalPatterns are the compiled patterns in use (see SetRefusalPatterns)
+var refusalPatterns = compileRefusalPatterns(DefaultRefusalPatterns)
+
+// SetRefusalPatterns replaces the patterns that flag a response as a refusal, e.g. from
+// the user's configuration; patterns that do not compile are skipped, and none turns
+// detection by wording off, leaving the refusal stop_reason
+func SetRefusalPatterns(patterns []string) {
+	refusalPatterns = compileRefusalPatterns(patterns)
+}
+
+// compileRefusalPatterns compiles the patterns that are valid regular expressions
+func compileRefusalPatterns(patterns []string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		if re, err := regexp.Compile(p); err == nil {
+			compiled = append(compiled, re)
+		}
+	}
+	return compiled
+}
+
+// isRefusal reports whether an assistant response refused: the API stopped it with the
+// refusal stop_reason, or its text matches one of the refusal patterns
+func isRefusal(stopReason, text string) bool {
+	if stopReason == stopReasonRefusal {
+		return true
+	}
+	for _, re := range refusalPatterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
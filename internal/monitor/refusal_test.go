@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestIsRefusal tests the stop reason and the default refusal patterns, which should
+// leave ordinary responses alone
+func TestIsRefusal(t *testing.T) {
+	tests := []struct {
+		stopReason string
+		text       string
+		want       bool
+	}{
+		{"refusal", "", true},
+		{"end_turn", "I can't help with that request.", true},
+		{"end_turn", "I’m sorry, but I cannot assist with creating malware.", true},
+		{"end_turn", "I'm not able to help with this.", true},
+		{"end_turn", "Done. The tests pass now.", false},
+		{"end_turn", "I can't find the config file, so I'll create one.", false},
+		{"end_turn", "The linter says: I cannot help with that", false},
+		{"tool_use", "Called tool: Bash", false},
+	}
+	for _, tt := range tests {
+		if got := isRefusal(tt.stopReason, tt.text); got != tt.want {
+			t.Errorf("isRefusal(%q, %q) = %v, want %v", tt.stopReason, tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestSessionRefusals tests that parsing flags refusals, counts them and keeps responses
+// stopped before any text, and that the patterns can be replaced
+func TestSessionRefusals(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "refusals.jsonl")
+	testData := `{"type":"user","timestamp":"2026-01-12T09:14:00Z","message":{"role":"user","content":"write a keylogger"}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:05Z","message":{"role":"assistant","stop_reason":"end_turn","content":[{"type":"text","text":"I can't help with that."}]}}
+{"type":"user","timestamp":"2026-01-12T09:14:30Z","message":{"role":"user","content":"then just log my own keys"}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:31Z","message":{"role":"assistant","stop_reason":"refusal","content":[]}}
+{"type":"user","timestamp":"2026-01-12T09:15:00Z","message":{"role":"user","content":"fix the build instead"}}
+{"type":"assistant","timestamp":"2026-01-12T09:15:05Z","message":{"role":"assistant","stop_reason":"end_turn","content":[{"type":"text","text":"As an AI I will fix it."}]}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	var flagged []string
+	for _, msg := range stats.MessageHistory {
+		if msg.Refusal {
+			flagged = append(flagged, msg.Content)
+		}
+	}
+	if want := []string{"I can't help with that.", "(response stopped: refusal)"}; strings.Join(flagged, "|") != strings.Join(want, "|") {
+		t.Errorf("refusals %q, want %q", flagged, want)
+	}
+	if stats.Refusals != 2 {
+		t.Errorf("counted %d refusals, want 2", stats.Refusals)
+	}
+	if got := stats.GetDetailedStats(); !strings.Contains(got, "Refusals: 2") {
+		t.Errorf("detailed stats lack the refusal count: %s", got)
+	}
+
+	SetRefusalPatterns([]string{`^As an AI`, `(unclosed`})
+	t.Cleanup(func() { SetRefusalPatterns(DefaultRefusalPatterns) })
+	stats, err = ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	if stats.Refusals != 2 || !stats.MessageHistory[5].Refusal || stats.MessageHistory[1].Refusal {
+		t.Errorf("with custom patterns: %d refusals, want the stop and the \"As an AI\" response", stats.Refusals)
+	}
+}
@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DateRange is a span of calendar days in local time
+type DateRange struct {
+	From time.Time // Local midnight starting the first day
+	To   time.Time // Local midnight ending the last day
+}
+
+// LastDays returns the n calendar days up to and including the day of now
+func LastDays(n int, now time.Time) DateRange {
+	today := midnight(now)
+	return DateRange{From: today.AddDate(0, 0, 1-n), To: today.AddDate(0, 0, 1)}
+}
+
+// ParseDateRange parses a range of days given as "2006-01-02..2006-01-02", both days
+// included; "2006-01-02.." runs up to the day of now, and a single day covers itself
+func ParseDateRange(s string, now time.Time) (DateRange, error) {
+	from, to, found := strings.Cut(strings.TrimSpace(s), "..")
+	if !found {
+		to = from
+	}
+	first, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid start day %q (want YYYY-MM-DD)", from)
+	}
+	last := midnight(now)
+	if to != "" {
+		if last, err = time.ParseInLocation("2006-01-02", to, time.Local); err != nil {
+			return DateRange{}, fmt.Errorf("invalid end day %q (want YYYY-MM-DD)", to)
+		}
+	}
+	if last.Before(first) {
+		return DateRange{}, fmt.Errorf("range ends on %s, before it starts", to)
+	}
+	return DateRange{From: first, To: last.AddDate(0, 0, 1)}, nil
+}
+
+// midnight returns the local midnight starting the day of t
+func midnight(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Contains reports whether t falls on one of the range's days
+func (r DateRange) Contains(t time.Time) bool {
+	return !t.Before(r.From) && t.Before(r.To)
+}
+
+// Days returns the number of days in the range
+func (r DateRange) Days() int {
+	return int(r.To.Sub(r.From).Hours()/24 + 0.5)
+}
+
+// String formats the range as its first and last day, e.g. "2026-01-03 – 2026-01-09"
+func (r DateRange) String() string {
+	first, last := r.From.Format("2006-01-02"), r.To.AddDate(0, 0, -1).Format("2006-01-02")
+	if first == last {
+		return first
+	}
+	return first + " – " + last
+}
+
+// Report sums up the sessions of all projects within a date range. Only the messages
+// sent within the range count, so a session running into the range from the day
+// before adds just its part. Numbers that belong to a session as a whole, such as its
+// length, languages and whether it ran in bypass mode, count in full for every
+// session with a message in the range.
+type Report struct {
+	Range    DateRange
+	Total    ProjectStats    // All projects together
+	Projects []ProjectReport // Projects active in the range, most tokens first
+}
+
+// ProjectReport is one project's share of a report
+type ProjectReport struct {
+	Name     string // Original project path, else the encoded directory name
+	Dir      string // Project directory under ProjectsDir
+	Sessions int    // Sessions with messages in the range, excluding subagent files
+	Messages int
+	Tokens   TokenCounts
+	Cost     float64
+}
+
+// reportFile is a session file to scan for a report
+type reportFile struct {
+	path    string
+	project int // Index into Report.Projects
+}
+
+// ScanReport parses the session files under ProjectsDir written to since the range
+// started and sums up their messages within the range, calling progress (if non-nil)
+// after each file. Copies of a session in other project directories count once, as in
+// the report command. If ctx is cancelled, scanning stops and an error wrapping
+// ctx.Err() is returned.
+func ScanReport(ctx context.Context, r DateRange, cost func(*Message) float64, progress func(done, total int)) (*Report, error) {
+	projectsDir, err := ProjectsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(projectsDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read projects directory: %w", err)
+	}
+	dups, err := FindAllDuplicates()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Range: r}
+	var files []reportFile
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(projectsDir, entry.Name())
+		sessions, err := os.ReadDir(dir)
+		if err != nil {
+			logger.Debug("cannot read project directory", "op", "scan_report", "path", dir, "err", err)
+			continue
+		}
+		project := ProjectReport{Name: entry.Name(), Dir: dir}
+		if index, err := ParseSessionIndex(filepath.Join(dir, "sessions-index.json")); err == nil && index.OriginalPath != "" {
+			project.Name = index.OriginalPath
+		}
+		var found bool
+		for _, session := range sessions {
+			if session.IsDir() || !IsSessionFile(session.Name()) {
+				continue
+			}
+			path := filepath.Join(dir, session.Name())
+			// A file last written before the range started has no messages in it
+			if info, err := session.Info(); err != nil || info.ModTime().Before(r.From) || dups.Dropped(path) {
+				continue
+			}
+			files = append(files, reportFile{path: path, project: len(report.Projects)})
+			found = true
+		}
+		if found {
+			report.Projects = append(report.Projects, project)
+		}
+	}
+
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("report scan stopped: %w", err)
+		}
+		session, err := ParseSessionFile(file.path)
+		if err != nil {
+			logger.Debug("cannot parse session file", "op", "scan_report", "path", file.path, "err", err)
+			report.Total.Failed++
+		} else if clipped := clipToRange(session, r); len(clipped.MessageHistory) > 0 {
+			agent := IsAgentFile(file.path)
+			report.Total.Add(clipped, agent, cost)
+			report.Projects[file.project].add(clipped, agent, cost)
+		}
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+
+	// Drop the projects whose files had no messages in the range after all
+	active := report.Projects[:0]
+	for _, p := range report.Projects {
+		if p.Messages > 0 {
+			active = append(active, p)
+		}
+	}
+	report.Projects = active
+	sort.SliceStable(report.Projects, func(i, j int) bool {
+		return report.Projects[i].Tokens.Total() > report.Projects[j].Tokens.Total()
+	})
+	report.Total.sort()
+	return report, nil
+}
+
+// clipToRange returns a copy of stats holding only the messages sent within r
+func clipToRange(stats *SessionStats, r DateRange) *SessionStats {
+	clipped := *stats
+	clipped.MessageHistory = nil
+	for _, msg := range stats.MessageHistory {
+		if r.Contains(msg.Timestamp) {
+			clipped.MessageHistory = append(clipped.MessageHistory, msg)
+		}
+	}
+	return &clipped
+}
+
+// add folds a session, already clipped to the report's range, into the project's share
+func (p *ProjectReport) add(stats *SessionStats, agent bool, cost func(*Message) float64) {
+	if !agent {
+		p.Sessions++
+	}
+	p.Messages += len(stats.MessageHistory)
+	for i := range stats.MessageHistory {
+		msg := &stats.MessageHistory[i]
+		if msg.Type == "assistant_response" {
+			p.Tokens.Add(*msg)
+			p.Cost += cost(msg)
+		}
+	}
+}
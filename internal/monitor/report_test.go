@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	now := time.Date(2026, 1, 12, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		input   string
+		want    string // DateRange.String of the result
+		days    int
+		wantErr bool
+	}{
+		{input: "2026-01-03..2026-01-09", want: "2026-01-03 – 2026-01-09", days: 7},
+		{input: " 2026-01-10.. ", want: "2026-01-10 – 2026-01-12", days: 3},
+		{input: "2026-01-05", want: "2026-01-05", days: 1},
+		{input: "2026-01-09..2026-01-03", wantErr: true},
+		{input: "last week", wantErr: true},
+		{input: "2026-01-03..soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := ParseDateRange(tt.input, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDateRange(%q) = %v, want an error", tt.input, r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDateRange(%q) failed: %v", tt.input, err)
+			}
+			if r.String() != tt.want || r.Days() != tt.days {
+				t.Errorf("ParseDateRange(%q) = %s (%d days), want %s (%d days)", tt.input, r, r.Days(), tt.want, tt.days)
+			}
+		})
+	}
+
+	if got := LastDays(7, now); got.String() != "2026-01-06 – 2026-01-12" || !got.Contains(now) || got.Contains(got.To) {
+		t.Errorf("LastDays(7) = %s, want 2026-01-06 – 2026-01-12 with today but not tomorrow", got)
+	}
+}
+
+// TestScanReport tests that a report sums up the messages of all projects within its
+// range, once per copied session, skipping files last written before the range
+func TestScanReport(t *testing.T) {
+	projects := t.TempDir()
+	SetProjectsDir(projects)
+	t.Cleanup(func() { SetProjectsDir("") })
+
+	now := time.Now()
+	files := []struct {
+		project, name, fixture string
+		modified               time.Time
+	}{
+		{"-home-demo-api", "a.jsonl", "sample_session.jsonl", now},                                      // 2026-01-09
+		{"-home-demo-api-copy", "a.jsonl", "sample_session.jsonl", now.Add(-time.Hour)},                 // Older copy
+		{"-home-demo-web", "b.jsonl", "cache_gap.jsonl", now},                                           // 2026-01-12
+		{"-home-demo-old", "c.jsonl", "cache_gap.jsonl", time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)}, // Written before the range
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join("testdata", f.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := filepath.Join(projects, f.project)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, f.modified, f.modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cost := func(*Message) float64 { return 0.5 }
+
+	tests := []struct {
+		name     string
+		from, to string
+		sessions int
+		projects []string // Most tokens first
+	}{
+		{"both days", "2026-01-09", "2026-01-12", 2, []string{"-home-demo-api", "-home-demo-web"}},
+		{"one day", "2026-01-12", "2026-01-12", 1, []string{"-home-demo-web"}},
+		{"no activity", "2026-01-10", "2026-01-11", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseDateRange(tt.from+".."+tt.to, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var done, total int
+			report, err := ScanReport(context.Background(), r, cost, func(d, n int) { done, total = d, n })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Total.Sessions != tt.sessions || done != total || total != 2 {
+				t.Errorf("sessions = %d, progress %d/%d; want %d, 2/2", report.Total.Sessions, done, total, tt.sessions)
+			}
+			var names []string
+			var tokens int
+			for _, p := range report.Projects {
+				names = append(names, p.Name)
+				tokens += p.Tokens.Total()
+			}
+			if len(names) != len(tt.projects) || (len(names) > 0 && names[0] != tt.projects[0]) {
+				t.Errorf("projects = %v, want %v", names, tt.projects)
+			}
+			if tokens != report.Total.Tokens.Total() {
+				t.Errorf("project tokens add up to %d, want the total %d", tokens, report.Total.Tokens.Total())
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ScanReport(ctx, LastDays(7, now), cost, nil); err == nil {
+		t.Error("ScanReport with a cancelled context succeeded, want an error")
+	}
+}
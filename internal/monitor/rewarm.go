@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+// Lifetimes of the prompt cache: entries expire this long after they were last read
+// or written
+const (
+	CacheTTL5m = 5 * time.Minute
+	CacheTTL1h = time.Hour
+)
+
+// CacheRewarm is the first response after a gap long enough for the prompt cache to
+// expire, which wrote the context the cache held before the gap again
+type CacheRewarm struct {
+	At      time.Time     // When the response was sent
+	Gap     time.Duration // Time since the message before it
+	Tokens  int           // Context tokens written to the cache again
+	OneHour bool          // The cache written before the gap was the 1-hour cache
+	Model   string        // Model of the response, which prices the rewrite
+}
+
+// CacheRewarms estimates what the gaps of a session cost in cache rewrites. The logs
+// do not say which cache writes replaced expired entries, so the heuristic is:
+//
+//   - A gap is a pause between two messages longer than the cache's lifetime: an hour
+//     when the latest cache write before it went to the 1-hour cache, else 5 minutes.
+//   - The first response with usage data after the gap re-warms the cache if it read
+//     less than half of the context the response before the gap was sent with; had the
+//     cache survived, it would have read nearly all of it.
+//   - Its cache writes count as rewrites up to the size of that earlier context. Writes
+//     beyond it are new context, such as the prompt that ended the gap, which would
+//     have been written anyway.
+//
+// Messages are taken in time order, as entries of resumed sessions can be out of order.
+func CacheRewarms(history []Message) []CacheRewarm {
+	order := make([]int, len(history))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return history[order[a]].Timestamp.Before(history[order[b]].Timestamp)
+	})
+
+	var rewarms []CacheRewarm
+	var last time.Time    // Previous message
+	var cached int        // Context of the latest response with usage data
+	var oneHour bool      // The latest cache write went to the 1-hour cache
+	var gap time.Duration // Gap awaiting the next response; 0 if none
+	for _, i := range order {
+		msg := &history[i]
+		if msg.Timestamp.IsZero() {
+			continue
+		}
+		ttl := CacheTTL5m
+		if oneHour {
+			ttl = CacheTTL1h
+		}
+		if d := msg.Timestamp.Sub(last); !last.IsZero() && d > ttl {
+			gap = d
+		}
+		last = msg.Timestamp
+
+		context := msg.ContextTokens()
+		if context == 0 {
+			continue
+		}
+		if gap > 0 && cached > 0 && msg.CacheRead < cached/2 {
+			if tokens := min(msg.CacheCreation, cached); tokens > 0 {
+				rewarms = append(rewarms, CacheRewarm{At: msg.Timestamp, Gap: gap, Tokens: tokens, OneHour: oneHour, Model: msg.Model})
+			}
+		}
+		gap, cached = 0, context
+		if msg.CacheCreation > 0 {
+			oneHour = msg.CacheWrite1h > 0
+		}
+	}
+	return rewarms
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCacheRewarmsFixture tests the fixture session, which pauses for lunch with the
+// 5-minute cache and later for 17 minutes with the 1-hour cache: only the lunch break
+// expires the cache, and only the context cached before it counts as rewritten
+func TestCacheRewarmsFixture(t *testing.T) {
+	path := filepath.Join("testdata", "cache_gap.jsonl")
+	stats, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	if got := stats.MessageHistory[7].CacheWrite1h; got != 300 {
+		t.Errorf("1-hour cache writes = %d, want 300", got)
+	}
+
+	want := CacheRewarm{
+		At:     time.Date(2026, 1, 12, 12, 30, 5, 0, time.UTC),
+		Gap:    3*time.Hour + 27*time.Minute + 55*time.Second,
+		Tokens: 20505, // The context of the response before the break, not the prompt after it
+		Model:  "claude-sonnet-4-5-20250929",
+	}
+	rewarms := CacheRewarms(stats.MessageHistory)
+	if len(rewarms) != 1 || rewarms[0] != want {
+		t.Errorf("CacheRewarms = %+v, want [%+v]", rewarms, want)
+	}
+
+	metadata, err := GetSessionMetadata(path)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if len(metadata.Rewarms) != 1 || metadata.Rewarms[0] != want {
+		t.Errorf("metadata rewarms = %+v, want [%+v]", metadata.Rewarms, want)
+	}
+}
+
+// TestCacheRewarms tests the heuristic's edge cases
+func TestCacheRewarms(t *testing.T) {
+	start := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	tests := []struct {
+		name    string
+		history []Message
+		want    int // Tokens rewritten
+	}{
+		{
+			name: "short pause keeps the cache",
+			history: []Message{
+				{Timestamp: at(0), CacheCreation: 10_000},
+				{Timestamp: at(4), CacheRead: 10_000, CacheCreation: 200},
+			},
+		},
+		{
+			name: "long pause with the cache still read",
+			history: []Message{
+				{Timestamp: at(0), CacheCreation: 10_000},
+				{Timestamp: at(30), CacheRead: 9_000, CacheCreation: 1_200},
+			},
+		},
+		{
+			name: "gap between prompt and response",
+			history: []Message{
+				{Timestamp: at(0), CacheCreation: 10_000},
+				{Timestamp: at(20), Type: "prompt"},
+				{Timestamp: at(21), CacheCreation: 10_400},
+			},
+			want: 10_000,
+		},
+		{
+			name: "out of order entries",
+			history: []Message{
+				{Timestamp: at(40), CacheCreation: 8_000},
+				{Timestamp: at(0), InputTokens: 5, CacheCreation: 6_000},
+			},
+			want: 6_005,
+		},
+		{
+			name: "1-hour cache outlives the pause",
+			history: []Message{
+				{Timestamp: at(0), CacheCreation: 10_000, CacheWrite1h: 10_000},
+				{Timestamp: at(50), CacheCreation: 10_000},
+			},
+		},
+		{
+			name: "first response has nothing to rewrite",
+			history: []Message{
+				{Timestamp: at(0), Type: "prompt"},
+				{Timestamp: at(30), CacheCreation: 10_000},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			for _, r := range CacheRewarms(tt.history) {
+				got += r.Tokens
+			}
+			if got != tt.want {
+				t.Errorf("rewritten %d tokens, want %d", got, tt.want)
+			}
+		})
+	}
+}
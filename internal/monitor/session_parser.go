@@ -2,9 +2,14 @@ package monitor
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -34,6 +39,15 @@ import (
 // - file-history-snapshot: File state backup
 // - system: System event
 // - queue-operation: Task queue operation
+//
+// Permission prompts: tool_use items carry an "id" that the tool_result answering them
+// names in "tool_use_id". When the user denies a tool use at the permission prompt, the
+// result is an error whose content starts with "The user doesn't want to proceed with
+// this tool use" (toolUseResult: "User rejected tool use"). Newer versions also record
+// the answer on the result entry as "permissionDecision": "allow" or "deny".
+//
+// Refusals: assistant entries carry the API's message.stop_reason; "refusal" means the
+// safety system stopped the response, possibly before any text was written.
 
 // SessionEntry represents a single entry in a session JSONL file
 type SessionEntry struct {
@@ -42,26 +56,48 @@ type SessionEntry struct {
 	Version     string `json:"version"`
 	GitBranch   string `json:"gitBranch"`
 	IsSidechain bool   `json:"isSidechain"`
+	SessionID   string `json:"sessionId"`
+	AgentID     string `json:"agentId"`
 	Message     *struct {
 		Role    string      `json:"role"`
 		Content interface{} `json:"content"` // Can be string or array
 	} `json:"message"`
 	Data map[string]interface{} `json:"data"`
+	// PermissionMode is the mode the entry was written in, e.g. "plan" (not recorded on every entry)
+	PermissionMode string `json:"permissionMode"`
+	Cwd            string `json:"cwd"`
+	Summary        string `json:"summary"` // Conversation title of a summary entry
 }
 
 // Message represents a user message or response
 type Message struct {
-	Role          string
-	Content       string
-	Timestamp     time.Time
-	Type          string // "prompt", "assistant_response", or "tool_result"
-	ToolName      string // Name of tool that was called
-	ToolInput     string // Input passed to tool
+	Role      string
+	Content   string
+	Timestamp time.Time
+	Type      string // "prompt", "assistant_response", or "tool_result"
+	ToolName  string // Name of tool that was called; the first of ToolCalls
+	ToolInput string // Input passed to tool
+	ToolUseID string // Links a tool call and its result
+	// ToolCalls lists every tool_use item of an assistant entry in order; ToolName,
+	// ToolInput and ToolUseID repeat the first. Use Calls, which also covers messages
+	// built with the single-call fields only.
+	ToolCalls     []ToolCall
+	IsError       bool   // Tool result reported as an error (tool_result messages only)
 	Model         string // Claude model used (assistant messages only)
 	InputTokens   int    // Number of input tokens (assistant messages)
 	OutputTokens  int    // Number of output tokens (assistant messages)
 	CacheCreation int    // Tokens used for cache creation
 	CacheRead     int    // Tokens read from cache
+	CacheWrite1h  int    // Of CacheCreation, the tokens written to the 1-hour cache
+	// EstimatedTokens approximates the size of user prompts, which carry no usage data.
+	// It is never included in InputTokens or cost totals.
+	EstimatedTokens int
+	// PastedBytes is the size of the content pasted into a user prompt (see pastedBytes);
+	// 0 for typed prompts and other messages
+	PastedBytes int
+	// ResponseTime is how long an assistant message took: the entry's durationMs, else
+	// the time since the request it answers (see responseTimes); 0 if unknown
+	ResponseTime time.Duration
 	// Additional session metadata
 	UUID        string // Unique message identifier
 	WorkingDir  string // Current working directory when message was sent
@@ -71,6 +107,30 @@ type Message struct {
 	UserType    string // Type of user (e.g., "external")
 	ParentUUID  string // Parent message UUID (for branching)
 	IsSidechain bool   // Whether this is a side/branch conversation
+	// PermissionDecision is the user's answer when the tool call needed permission:
+	// DecisionAllowed or DecisionDenied; "" when no permission prompt was recorded
+	PermissionDecision string
+	// Refusal flags an assistant response that declined the request: stopped with the
+	// refusal stop_reason or worded like a refusal (see SetRefusalPatterns)
+	Refusal bool
+}
+
+// ToolCall is one tool_use item of an assistant entry
+type ToolCall struct {
+	Name  string // Tool name, e.g. "Edit"
+	Input string // Arguments as JSON
+	ID    string // tool_use ID, repeated by the tool_result answering the call
+}
+
+// Calls returns the tool calls the message makes, none for messages other than tool calls
+func (m Message) Calls() []ToolCall {
+	if len(m.ToolCalls) > 0 {
+		return m.ToolCalls
+	}
+	if m.ToolName == "" {
+		return nil
+	}
+	return []ToolCall{{Name: m.ToolName, Input: m.ToolInput, ID: m.ToolUseID}}
 }
 
 // SessionStats contains aggregated session statistics
@@ -82,260 +142,697 @@ type SessionStats struct {
 	TotalMessages     int
 	UserMessages      int
 	AssistantMessages int
+	ToolResults       int // User messages that carry tool results (included in UserMessages)
 	ProgressEvents    int
 	SystemEvents      int
 	FileSnapshots     int
 	QueueOperations   int
 	CompactCount      int
+	UnknownEntries    int // Entries of a type not listed above
+	MalformedLines    int // Lines that are not valid JSON, except a last line still being written
 	MessageHistory    []Message
 	ErrorCount        int
-	ClaudeVersion     string // Version from the session file
+	ClaudeVersion     string      // Version from the session file
+	Partial           bool        // True when parsing stopped before the end of the file
+	Turns             []Turn      // Prompt-to-prompt groups of MessageHistory, computed after parsing
+	PermissionModes   []string    // Permission modes recorded in the entries, in order of first use
+	PermissionMode    string      // Most permissive of PermissionModes, computed after parsing
+	Summaries         int         // Summary entries, written when Claude titles the conversation
+	Summary           string      // Text of the latest summary entry
+	Tokens            TokenCounts // Usage summed over MessageHistory, computed after parsing
+
+	// Prompts with pasted content and the bytes pasted, computed after parsing
+	Pastes      int
+	PastedBytes int
+
+	// Tool uses the user answered at a permission prompt
+	PermissionsAllowed int
+	PermissionsDenied  int
+
+	// Refusals counts the assistant responses flagged as refusals, computed after parsing
+	Refusals int
+
+	// Branches are the git branches of MessageHistory in order, one entry per switch,
+	// computed after parsing
+	Branches []BranchChange
+
+	// OutsideWrites are the resolved files Write, Edit and similar tool calls wrote
+	// outside the session's working directory, computed after parsing
+	OutsideWrites []string
+
+	// Languages counts the code fences and file writes of MessageHistory by programming
+	// language, most frequent first, computed after parsing
+	Languages []EntryCount
+
+	// DiffStat approximates the lines the tool calls of MessageHistory added and
+	// removed, computed after parsing
+	DiffStat DiffStat
+
+	// OutOfOrder counts entries timestamped well before an entry earlier in the file,
+	// as in files stitched together by resume or compaction. MessageHistory is then
+	// sorted by timestamp rather than kept in file order.
+	OutOfOrder int
+}
+
+// outOfOrderTolerance is how far an entry's timestamp may go back without counting
+// as out of order: attachments and tool results are often written a little before
+// the entry preceding them
+const outOfOrderTolerance = 2 * time.Minute
+
+// ProgressFunc receives the number of bytes processed so far and the total file size
+type ProgressFunc func(processed, total int64)
+
+// progressReader counts bytes as they are pulled through the scanner
+type progressReader struct {
+	r    io.Reader
+	read int64
+	last byte // Last byte read, to tell whether the data ends with a complete line
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 {
+		p.last = b[n-1]
+	}
+	return n, err
 }
 
 // ParseSessionFile reads and parses a JSONL session file
 func ParseSessionFile(filePath string) (*SessionStats, error) {
+	return ParseSessionFileWithProgress(context.Background(), filePath, nil)
+}
+
+// ParseSessionFileWithProgress parses a session file like ParseSessionFile, reporting
+// bytes processed to progress (if non-nil) each time another percent of the file is read.
+// If ctx is cancelled, parsing stops and the stats gathered so far are returned together
+// with an error wrapping ctx.Err().
+func ParseSessionFileWithProgress(ctx context.Context, filePath string, progress ProgressFunc) (*SessionStats, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open session file: %w", err)
 	}
 	defer file.Close()
 
+	var totalBytes int64
+	if info, err := file.Stat(); err == nil {
+		totalBytes = info.Size()
+	}
+
 	stats := &SessionStats{
 		FilePath:       filePath,
 		MessageHistory: []Message{},
 	}
 
-	scanner := bufio.NewScanner(file)
+	// Progress counts bytes of the file as stored, compressed or not
+	reader := &progressReader{r: file}
+	src, _, err := sessionReader(reader, filePath)
+	if err != nil {
+		return nil, err
+	}
+	content := &progressReader{r: src}
+	var lastReported int64
+	reportStep := totalBytes / 100
+	if reportStep < 64*1024 {
+		reportStep = 64 * 1024
+	}
+
+	scanner := bufio.NewScanner(content)
 	// Increase buffer size for large JSONL lines (some can be > 64KB)
 	buf := make([]byte, 0, 512*1024)  // 512KB buffer
 	scanner.Buffer(buf, 10*1024*1024) // 10MB max token size
 	lineNum := 0
+	// A malformed line is only counted once another line follows it or the file ends
+	// with a newline: the last line of a session that is being written may be incomplete
+	lastMalformed := false
 
 	for scanner.Scan() {
 		lineNum++
-		var entry SessionEntry
-		var rawData map[string]interface{}
 
-		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
-			continue // Skip malformed lines
+		// Check for cancellation periodically rather than on every line
+		if lineNum%100 == 0 {
+			if err := ctx.Err(); err != nil {
+				stats.Partial = true
+				stats.finalize()
+				return stats, fmt.Errorf("parse cancelled after %d of %d bytes (%d messages read): %w",
+					reader.read, totalBytes, len(stats.MessageHistory), err)
+			}
+		}
+		if progress != nil && reader.read-lastReported >= reportStep {
+			lastReported = reader.read
+			progress(reader.read, totalBytes)
 		}
 
-		// Also parse raw data for extracting version
-		json.Unmarshal(scanner.Bytes(), &rawData)
+		if lastMalformed {
+			stats.MalformedLines++
+		}
+		lastMalformed = !stats.processLine(scanner.Bytes())
+	}
 
-		// Parse timestamp
-		var timestamp time.Time
-		if entry.Timestamp != "" {
-			if t, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
-				timestamp = t
-			} else if t, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
-				timestamp = t
-			}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading session file: %w", err)
+	}
+	if lastMalformed && content.last == '\n' {
+		stats.MalformedLines++
+	}
+
+	if progress != nil {
+		progress(totalBytes, totalBytes)
+	}
+
+	stats.finalize()
+	return stats, nil
+}
+
+// ParseSessionTail parses only the last maxBytes of a session file so the newest
+// messages can be shown before the whole file has been read. The first (possibly
+// truncated) line of the tail window is skipped. The returned stats are marked
+// Partial unless the window covered the entire file. Compressed files cannot be
+// read from the middle and are parsed whole.
+func ParseSessionTail(filePath string, maxBytes int64) (*SessionStats, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session file: %w", err)
+	}
+	defer file.Close()
+	if isCompressedFile(file, filePath) {
+		return ParseSessionFile(filePath)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat session file: %w", err)
+	}
+
+	stats := &SessionStats{
+		FilePath:       filePath,
+		MessageHistory: []Message{},
+	}
+
+	offset := info.Size() - maxBytes
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek session file: %w", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session file: %w", err)
+	}
+
+	if offset > 0 {
+		// Drop the partial line we landed in the middle of
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			data = nil
+		} else {
+			data = data[idx+1:]
 		}
+		stats.Partial = true
+	}
 
-		// Extract Claude version from first entry that has it
-		if stats.ClaudeVersion == "" {
-			if version, ok := rawData["version"].(string); ok && version != "" {
-				stats.ClaudeVersion = version
-			}
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(cleanLine(line)) == 0 {
+			continue
 		}
+		// The last line lacks its newline only while it is still being written
+		if !stats.processLine(line) && i < len(lines)-1 {
+			stats.MalformedLines++
+		}
+	}
 
-		// Update creation and activity times
-		if stats.CreatedAt.IsZero() || timestamp.Before(stats.CreatedAt) {
-			stats.CreatedAt = timestamp
+	stats.finalize()
+	return stats, nil
+}
+
+// utf8BOM is the byte order mark some sync tools write at the start of a file
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// cleanLine strips what editors and sync tools add to a JSONL line: a byte order mark
+// and the carriage return of a CRLF line ending
+func cleanLine(line []byte) []byte {
+	return bytes.TrimSuffix(bytes.TrimPrefix(line, utf8BOM), []byte("\r"))
+}
+
+// cleanContent turns carriage returns in message text into plain newlines; a stray
+// "\r" sends the cursor back to the start of the line and garbles the cards
+func cleanContent(text string) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// processLine parses a single JSONL entry and folds it into the stats, reporting
+// false for a malformed line
+func (s *SessionStats) processLine(line []byte) bool {
+	line = cleanLine(line)
+	var entry SessionEntry
+	var rawData map[string]interface{}
+
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return false // Skip malformed lines
+	}
+
+	// Also parse raw data for extracting version
+	json.Unmarshal(line, &rawData)
+
+	// Parse timestamp
+	var timestamp time.Time
+	if entry.Timestamp != "" {
+		if t, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+			timestamp = t
+		} else if t, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+			timestamp = t
 		}
-		if timestamp.After(stats.LastActivity) {
-			stats.LastActivity = timestamp
+	}
+
+	// Extract Claude version from first entry that has it
+	if s.ClaudeVersion == "" {
+		if version, ok := rawData["version"].(string); ok && version != "" {
+			s.ClaudeVersion = version
 		}
+	}
 
-		// Process different entry types
-		switch entry.Type {
-		case "user", "assistant":
-			// Message entry
-			if entry.Message != nil && entry.Message.Role != "" {
-				stats.TotalMessages++
-				if entry.Message.Role == "user" {
-					stats.UserMessages++
-				} else if entry.Message.Role == "assistant" {
-					stats.AssistantMessages++
-				}
+	s.PermissionModes = addPermissionMode(s.PermissionModes, entry.PermissionMode)
 
-				// Extract message content - can be string or array
-				var contentStr string
-				var toolName string
-				var toolInput string
-				var msgType string
-				var model string
-				var inputTokens, outputTokens, cacheCreation, cacheRead int
+	// Update creation and activity times, the earliest and latest timestamps; entries
+	// without one (file history snapshots, summaries) leave them alone
+	if !timestamp.IsZero() {
+		if timestamp.Before(s.LastActivity.Add(-outOfOrderTolerance)) {
+			s.OutOfOrder++
+		}
+		if s.CreatedAt.IsZero() || timestamp.Before(s.CreatedAt) {
+			s.CreatedAt = timestamp
+		}
+		if timestamp.After(s.LastActivity) {
+			s.LastActivity = timestamp
+		}
+	}
 
-				// For assistant messages, try to extract token usage from full JSON
-				if entry.Message.Role == "assistant" {
-					var detailedEntry struct {
-						Message struct {
-							Model string `json:"model"`
-							Usage struct {
-								InputTokens              int `json:"input_tokens"`
-								CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
-								CacheReadInputTokens     int `json:"cache_read_input_tokens"`
-								OutputTokens             int `json:"output_tokens"`
-							} `json:"usage"`
-						} `json:"message"`
-					}
-					if err := json.Unmarshal(scanner.Bytes(), &detailedEntry); err == nil {
-						model = detailedEntry.Message.Model
-						inputTokens = detailedEntry.Message.Usage.InputTokens
-						outputTokens = detailedEntry.Message.Usage.OutputTokens
-						cacheCreation = detailedEntry.Message.Usage.CacheCreationInputTokens
-						cacheRead = detailedEntry.Message.Usage.CacheReadInputTokens
-					}
+	// Process different entry types
+	switch entry.Type {
+	case "user", "assistant":
+		// Message entry
+		if entry.Message != nil && entry.Message.Role != "" {
+			s.TotalMessages++
+			if entry.Message.Role == "user" {
+				s.UserMessages++
+			} else if entry.Message.Role == "assistant" {
+				s.AssistantMessages++
+			}
+
+			// Extract message content - can be string or array
+			var contentStr string
+			var toolName string
+			var toolInput string
+			var toolCalls []ToolCall
+			var toolUseID string
+			var isError bool
+			var msgType string
+			var model, stopReason string
+			var inputTokens, outputTokens, cacheCreation, cacheRead, cacheWrite1h int
+
+			// For assistant messages, try to extract token usage from full JSON
+			if entry.Message.Role == "assistant" {
+				var detailedEntry struct {
+					Message struct {
+						Model      string `json:"model"`
+						StopReason string `json:"stop_reason"`
+						Usage      struct {
+							InputTokens              int            `json:"input_tokens"`
+							CacheCreationInputTokens int            `json:"cache_creation_input_tokens"`
+							CacheReadInputTokens     int            `json:"cache_read_input_tokens"`
+							OutputTokens             int            `json:"output_tokens"`
+							CacheCreation            CacheBreakdown `json:"cache_creation"`
+						} `json:"usage"`
+					} `json:"message"`
+				}
+				if err := json.Unmarshal(line, &detailedEntry); err == nil {
+					model = detailedEntry.Message.Model
+					stopReason = detailedEntry.Message.StopReason
+					inputTokens = detailedEntry.Message.Usage.InputTokens
+					outputTokens = detailedEntry.Message.Usage.OutputTokens
+					cacheCreation = detailedEntry.Message.Usage.CacheCreationInputTokens
+					cacheRead = detailedEntry.Message.Usage.CacheReadInputTokens
+					cacheWrite1h = detailedEntry.Message.Usage.CacheCreation.Ephemeral1h
 				}
+			}
 
-				if content, ok := entry.Message.Content.(string); ok {
-					contentStr = content
-					msgType = "prompt"
-				} else if contentArr, ok := entry.Message.Content.([]interface{}); ok {
-					// For array content, extract based on item type
-					if entry.Message.Role == "user" {
-						// User messages in array form contain tool_result items
-						for _, item := range contentArr {
-							if itemMap, ok := item.(map[string]interface{}); ok {
-								if itemType, ok := itemMap["type"].(string); ok && itemType == "tool_result" {
-									if itemContent, ok := itemMap["content"].(string); ok {
-										contentStr = itemContent
-										msgType = "tool_result"
-										break
-									}
+			if content, ok := entry.Message.Content.(string); ok {
+				contentStr = content
+				msgType = "prompt"
+			} else if contentArr, ok := entry.Message.Content.([]interface{}); ok {
+				// For array content, extract based on item type
+				if entry.Message.Role == "user" {
+					// User messages in array form contain tool_result items
+					if hasToolResult(contentArr) {
+						s.ToolResults++
+					}
+					for _, item := range contentArr {
+						if itemMap, ok := item.(map[string]interface{}); ok {
+							if itemType, ok := itemMap["type"].(string); ok && itemType == "tool_result" {
+								if itemContent, ok := itemMap["content"].(string); ok {
+									contentStr = itemContent
+									msgType = "tool_result"
+									isError, _ = itemMap["is_error"].(bool)
+									toolUseID, _ = itemMap["tool_use_id"].(string)
+									break
 								}
 							}
 						}
-					} else if entry.Message.Role == "assistant" {
-						// Assistant messages contain text, thinking, and tool_use items
-						for _, item := range contentArr {
-							if itemMap, ok := item.(map[string]interface{}); ok {
-								if itemType, ok := itemMap["type"].(string); ok {
-									switch itemType {
-									case "text":
-										if text, ok := itemMap["text"].(string); ok {
-											contentStr = text
-											msgType = "assistant_response"
-											break
-										}
-									case "tool_use":
-										// Extract tool information
-										if name, ok := itemMap["name"].(string); ok {
-											toolName = name
-											msgType = "assistant_response"
-											// Try to extract input
-											if input, ok := itemMap["input"]; ok {
-												if inputMap, ok := input.(map[string]interface{}); ok {
-													// Convert input map to JSON string for display
-													if inputBytes, err := json.Marshal(inputMap); err == nil {
-														toolInput = string(inputBytes)
-													}
+					}
+				} else if entry.Message.Role == "assistant" {
+					// Assistant messages contain text, thinking, and tool_use items; every
+					// text block and tool call is kept
+					var texts []string
+					for _, item := range contentArr {
+						if itemMap, ok := item.(map[string]interface{}); ok {
+							if itemType, ok := itemMap["type"].(string); ok {
+								switch itemType {
+								case "text":
+									if text, ok := itemMap["text"].(string); ok && text != "" {
+										texts = append(texts, text)
+										msgType = "assistant_response"
+									}
+								case "tool_use":
+									// Extract tool information
+									if name, ok := itemMap["name"].(string); ok {
+										call := ToolCall{Name: name}
+										call.ID, _ = itemMap["id"].(string)
+										msgType = "assistant_response"
+										// Try to extract input
+										if input, ok := itemMap["input"]; ok {
+											if inputMap, ok := input.(map[string]interface{}); ok {
+												// Convert input map to JSON string for display
+												if inputBytes, err := json.Marshal(inputMap); err == nil {
+													call.Input = string(inputBytes)
 												}
 											}
-											// For tool_use, use the tool name as content if no text found yet
-											if contentStr == "" {
-												contentStr = fmt.Sprintf("Called tool: %s", toolName)
-											}
 										}
-									case "thinking":
-										// Skip thinking blocks
-										continue
+										toolCalls = append(toolCalls, call)
 									}
+								case "thinking":
+									// Skip thinking blocks
+									continue
 								}
 							}
 						}
 					}
-				}
-
-				if contentStr != "" {
-					// Set default message type if not already set
-					if msgType == "" {
-						msgType = "assistant_response"
-						if entry.Message.Role == "user" {
-							msgType = "prompt"
+					contentStr = strings.Join(texts, "\n\n")
+					if len(toolCalls) > 0 {
+						toolName, toolInput, toolUseID = toolCalls[0].Name, toolCalls[0].Input, toolCalls[0].ID
+						// For tool_use, use the tool name as content if there is no text
+						if contentStr == "" {
+							contentStr = fmt.Sprintf("Called tool: %s", toolName)
 						}
 					}
+				}
+			}
 
-					// Extract additional metadata from entry
-					uuid := ""
-					workingDir := ""
-					sessionID := ""
-					userType := ""
-					parentUUID := ""
-					if u, ok := rawData["uuid"].(string); ok {
-						uuid = u
-					}
-					if cwd, ok := rawData["cwd"].(string); ok {
-						workingDir = cwd
-					}
-					if sid, ok := rawData["sessionId"].(string); ok {
-						sessionID = sid
-					}
-					if ut, ok := rawData["userType"].(string); ok {
-						userType = ut
-					}
-					if pu, ok := rawData["parentUuid"].(string); ok {
-						parentUUID = pu
+			if contentStr == "" && stopReason == stopReasonRefusal {
+				// A response the safety system stopped before any text is still shown
+				contentStr = "(response stopped: refusal)"
+			}
+
+			if contentStr != "" {
+				// Set default message type if not already set
+				if msgType == "" {
+					msgType = "assistant_response"
+					if entry.Message.Role == "user" {
+						msgType = "prompt"
 					}
+				}
+
+				// Extract additional metadata from entry
+				uuid := ""
+				workingDir := ""
+				sessionID := ""
+				userType := ""
+				parentUUID := ""
+				if u, ok := rawData["uuid"].(string); ok {
+					uuid = u
+				}
+				if cwd, ok := rawData["cwd"].(string); ok {
+					workingDir = cwd
+				}
+				if sid, ok := rawData["sessionId"].(string); ok {
+					sessionID = sid
+				}
+				if ut, ok := rawData["userType"].(string); ok {
+					userType = ut
+				}
+				if pu, ok := rawData["parentUuid"].(string); ok {
+					parentUUID = pu
+				}
 
-					msg := Message{
-						Role:          entry.Message.Role,
-						Content:       contentStr,
-						Timestamp:     timestamp,
-						Type:          msgType,
-						ToolName:      toolName,
-						ToolInput:     toolInput,
-						Model:         model,
-						InputTokens:   inputTokens,
-						OutputTokens:  outputTokens,
-						CacheCreation: cacheCreation,
-						CacheRead:     cacheRead,
-						// Additional metadata
-						UUID:        uuid,
-						WorkingDir:  workingDir,
-						SessionID:   sessionID,
-						Version:     entry.Version,
-						GitBranch:   entry.GitBranch,
-						UserType:    userType,
-						ParentUUID:  parentUUID,
-						IsSidechain: entry.IsSidechain,
+				contentStr = cleanContent(contentStr)
+				msg := Message{
+					Role:          entry.Message.Role,
+					Content:       contentStr,
+					Timestamp:     timestamp,
+					Type:          msgType,
+					ToolName:      toolName,
+					ToolInput:     toolInput,
+					ToolUseID:     toolUseID,
+					ToolCalls:     toolCalls,
+					IsError:       isError,
+					Model:         model,
+					InputTokens:   inputTokens,
+					OutputTokens:  outputTokens,
+					CacheCreation: cacheCreation,
+					CacheRead:     cacheRead,
+					CacheWrite1h:  cacheWrite1h,
+					// Additional metadata
+					UUID:        uuid,
+					WorkingDir:  workingDir,
+					SessionID:   sessionID,
+					Version:     entry.Version,
+					GitBranch:   entry.GitBranch,
+					UserType:    userType,
+					ParentUUID:  parentUUID,
+					IsSidechain: entry.IsSidechain,
+				}
+				if msgType == "prompt" {
+					msg.EstimatedTokens = EstimateTokens(contentStr)
+					// Side-chain prompts are written by Claude, and compaction summaries by
+					// Claude Code; neither was pasted
+					if compacted, _ := rawData["isCompactSummary"].(bool); !entry.IsSidechain && !compacted {
+						msg.PastedBytes = pastedBytes(contentStr)
 					}
-					stats.MessageHistory = append(stats.MessageHistory, msg)
 				}
+				if msg.Role == "assistant" {
+					msg.Refusal = isRefusal(stopReason, contentStr)
+				}
+				if ms, ok := rawData["durationMs"].(float64); ok && ms > 0 && msg.Role == "assistant" {
+					msg.ResponseTime = time.Duration(ms * float64(time.Millisecond))
+				}
+				if msgType == "tool_result" {
+					s.recordPermissionDecision(permissionDecision(rawData, contentStr, isError), toolUseID)
+				}
+				s.MessageHistory = append(s.MessageHistory, msg)
 			}
+		}
 
-		case "progress":
-			stats.ProgressEvents++
+	case "progress":
+		s.ProgressEvents++
 
-		case "system":
-			stats.SystemEvents++
+	case "system":
+		s.SystemEvents++
 
-		case "file-history-snapshot":
-			stats.FileSnapshots++
+	case "file-history-snapshot":
+		s.FileSnapshots++
 
-		case "queue-operation":
-			stats.QueueOperations++
+	case "queue-operation":
+		s.QueueOperations++
 
-		case "compact":
-			stats.CompactCount++
+	case "compact":
+		s.CompactCount++
 
-		case "error":
-			stats.ErrorCount++
+	case "summary":
+		s.Summaries++
+		if entry.Summary != "" {
+			s.Summary = cleanContent(entry.Summary) // Re-summarized sessions keep the latest
 		}
+
+	case "error":
+		s.ErrorCount++
+
+	default:
+		s.UnknownEntries++
 	}
+	return true
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading session file: %w", err)
+// Answers to a permission prompt, as recorded on the tool call (Message.PermissionDecision)
+const (
+	DecisionAllowed = "allowed"
+	DecisionDenied  = "denied"
+)
+
+// toolRejectedPrefix starts the tool result Claude Code writes when the user denies a tool use
+const toolRejectedPrefix = "The user doesn't want to proceed with this tool use"
+
+// permissionDecision returns the user's answer to a permission prompt recorded on a tool
+// result entry, or "" if the tool ran without asking
+func permissionDecision(rawData map[string]interface{}, content string, isError bool) string {
+	switch rawData["permissionDecision"] {
+	case "allow":
+		return DecisionAllowed
+	case "deny":
+		return DecisionDenied
+	}
+	if rawData["toolUseResult"] == "User rejected tool use" || (isError && strings.HasPrefix(content, toolRejectedPrefix)) {
+		return DecisionDenied
+	}
+	return ""
+}
+
+// recordPermissionDecision counts a permission decision and attaches it to the tool call
+// with the given ID, if that call has been read (a tail load may have missed it)
+func (s *SessionStats) recordPermissionDecision(decision, toolUseID string) {
+	switch decision {
+	case DecisionAllowed:
+		s.PermissionsAllowed++
+	case DecisionDenied:
+		s.PermissionsDenied++
+	default:
+		return
+	}
+	if toolUseID == "" {
+		return
+	}
+	for i := len(s.MessageHistory) - 1; i >= 0; i-- {
+		msg := &s.MessageHistory[i]
+		for _, call := range msg.Calls() {
+			if call.ID == toolUseID {
+				msg.PermissionDecision = decision
+				return
+			}
+		}
 	}
+}
 
-	// Calculate duration
-	if !stats.CreatedAt.IsZero() && !stats.LastActivity.IsZero() {
-		stats.Duration = stats.LastActivity.Sub(stats.CreatedAt)
+// ToolResultsOf returns, for each tool call the message at callIdx makes, the index in
+// MessageHistory of its result, or -1 if it has none (yet)
+func (s *SessionStats) ToolResultsOf(callIdx int) []int {
+	if callIdx < 0 || callIdx >= len(s.MessageHistory) {
+		return nil
+	}
+	calls := s.MessageHistory[callIdx].Calls()
+	results := make([]int, len(calls))
+	for c, call := range calls {
+		results[c] = -1
+		if call.ID == "" {
+			continue
+		}
+		for i := callIdx + 1; i < len(s.MessageHistory); i++ {
+			if msg := s.MessageHistory[i]; msg.Type == "tool_result" && msg.ToolUseID == call.ID {
+				results[c] = i
+				break
+			}
+		}
 	}
+	return results
+}
 
-	return stats, nil
+// hasToolResult reports whether array message content holds a tool_result item
+func hasToolResult(content []interface{}) bool {
+	for _, item := range content {
+		if itemMap, ok := item.(map[string]interface{}); ok && itemMap["type"] == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReply reports whether assistant message content holds text or a tool call, the
+// items that make an entry a message of SessionStats.MessageHistory
+func hasReply(content interface{}) bool {
+	if text, ok := content.(string); ok {
+		return text != ""
+	}
+	arr, _ := content.([]interface{})
+	for _, item := range arr {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch itemMap["type"] {
+		case "text":
+			if text, _ := itemMap["text"].(string); text != "" {
+				return true
+			}
+		case "tool_use":
+			if name, _ := itemMap["name"].(string); name != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isToolResultContent reports whether message content carries tool results rather than
+// a prompt typed by the user
+func isToolResultContent(content interface{}) bool {
+	arr, ok := content.([]interface{})
+	return ok && hasToolResult(arr)
+}
+
+// finalize computes derived fields once all entries have been read
+func (s *SessionStats) finalize() {
+	if !s.CreatedAt.IsZero() && !s.LastActivity.IsZero() {
+		s.Duration = s.LastActivity.Sub(s.CreatedAt)
+	}
+	if s.OutOfOrder > 0 {
+		sortByTimestamp(s.MessageHistory)
+	}
+	responseTimes(s.MessageHistory)
+	s.Turns = buildTurns(s.MessageHistory)
+	s.Tokens = TokenCounts{}
+	s.Pastes, s.PastedBytes, s.Refusals = 0, 0, 0
+	for _, msg := range s.MessageHistory {
+		s.Tokens.Add(msg)
+		if msg.ContainsPaste() {
+			s.Pastes++
+			s.PastedBytes += msg.PastedBytes
+		}
+		if msg.Refusal {
+			s.Refusals++
+		}
+	}
+	s.Branches = branchChanges(s.MessageHistory)
+	writes := historyWrites(s.MessageHistory)
+	s.OutsideWrites = outsideWorkDir(s.workingDir(), writes)
+	s.DiffStat = diffStat(s.workingDir(), writes)
+	s.Languages = historyLanguages(s.MessageHistory)
+	s.PermissionMode = MostPermissiveMode(s.PermissionModes)
+}
+
+// sortByTimestamp orders messages by timestamp, keeping file order for equal times.
+// A message without a timestamp stays after the message it followed.
+func sortByTimestamp(messages []Message) {
+	type timedMessage struct {
+		at  time.Time
+		msg Message
+	}
+	timed := make([]timedMessage, len(messages))
+	var at time.Time
+	for i, msg := range messages {
+		if !msg.Timestamp.IsZero() {
+			at = msg.Timestamp
+		}
+		timed[i] = timedMessage{at, msg}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].at.Before(timed[j].at)
+	})
+	for i := range timed {
+		messages[i] = timed[i].msg
+	}
 }
 
 // GetSummary returns a human-readable summary of session stats
@@ -359,7 +856,7 @@ func (s *SessionStats) GetSummary() string {
 
 // GetDetailedStats returns a detailed breakdown of all session events
 func (s *SessionStats) GetDetailedStats() string {
-	return fmt.Sprintf(
+	detailed := fmt.Sprintf(
 		"Messages: %d (User: %d, AI: %d) | Events: Progress: %d, System: %d, File Snapshots: %d, Queue: %d | Errors: %d",
 		s.TotalMessages,
 		s.UserMessages,
@@ -370,6 +867,38 @@ func (s *SessionStats) GetDetailedStats() string {
 		s.QueueOperations,
 		s.ErrorCount,
 	)
+	if s.PermissionsAllowed > 0 || s.PermissionsDenied > 0 {
+		detailed += fmt.Sprintf(" | Permissions: %d allowed, %d denied", s.PermissionsAllowed, s.PermissionsDenied)
+	}
+	if s.Refusals > 0 {
+		detailed += fmt.Sprintf(" | Refusals: %d", s.Refusals)
+	}
+	if s.MalformedLines > 0 {
+		detailed += fmt.Sprintf(" | Malformed lines: %d", s.MalformedLines)
+	}
+	return detailed
+}
+
+// EntryCount is the number of session file entries of one kind
+type EntryCount struct {
+	Label string
+	Count int
+}
+
+// Composition breaks the session file's entries down by kind, in a fixed order:
+// user prompts, assistant messages, tool results, progress, system, file snapshots,
+// other known entries (queue operations, compactions, summaries, errors) and unknown types
+func (s *SessionStats) Composition() []EntryCount {
+	return []EntryCount{
+		{"user", s.UserMessages - s.ToolResults},
+		{"assistant", s.AssistantMessages},
+		{"tool results", s.ToolResults},
+		{"progress", s.ProgressEvents},
+		{"system", s.SystemEvents},
+		{"snapshots", s.FileSnapshots},
+		{"other", s.QueueOperations + s.CompactCount + s.Summaries + s.ErrorCount},
+		{"unknown", s.UnknownEntries},
+	}
 }
 
 // formatDuration converts a duration to human-readable format
@@ -396,8 +925,8 @@ func formatDuration(d time.Duration) string {
 func (m Message) GetMessageSummary() string {
 	// Truncate content to 80 characters
 	content := m.Content
-	if len(content) > 80 {
-		content = content[:77] + "..."
+	if runes := []rune(content); len(runes) > 80 {
+		content = string(runes[:77]) + "..."
 	}
 
 	// Replace newlines with spaces for single-line display
@@ -413,12 +942,18 @@ func (m Message) GetMessageSummary() string {
 
 // TokenUsage represents token usage information from an API response
 type TokenUsage struct {
-	InputTokens              int `json:"input_tokens"`
-	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
-	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
-	OutputTokens             int `json:"output_tokens"`
-	CacheCreationEphemeral5m int `json:"ephemeral_5m_input_tokens,omitempty"`
-	CacheCreationEphemeral1h int `json:"ephemeral_1h_input_tokens,omitempty"`
+	InputTokens              int            `json:"input_tokens"`
+	CacheCreationInputTokens int            `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int            `json:"cache_read_input_tokens"`
+	OutputTokens             int            `json:"output_tokens"`
+	CacheCreation            CacheBreakdown `json:"cache_creation"`
+}
+
+// CacheBreakdown splits a response's cache writes by the lifetime of the cache
+// written to, as recorded in the usage's "cache_creation" object
+type CacheBreakdown struct {
+	Ephemeral5m int `json:"ephemeral_5m_input_tokens"`
+	Ephemeral1h int `json:"ephemeral_1h_input_tokens"`
 }
 
 // SessionMetadata contains quick metadata about a session without full parsing
@@ -429,12 +964,30 @@ type SessionMetadata struct {
 	MessageCount      int
 	UserPrompts       int
 	Interruptions     int
-	TotalInputTokens  int
-	TotalOutputTokens int
-	Version           string // Claude version from first message
-	FirstPrompt       string // First user message
-	GitBranch         string // Git branch from first message
-	IsSidechain       bool   // Whether this is a side-chain conversation
+	Rewarms           []CacheRewarm // Cache rewrites after gaps, see CacheRewarms
+	Tokens            TokenCounts   // Usage of the assistant entries that make up messages
+	Version           string        // Claude version from first message
+	FirstPrompt       string        // First user message
+	GitBranch         string        // Git branch from first message
+	LastBranch        string        // Git branch of the latest entry that has one
+	Branches          []string      // Git branches recorded in the entries, in order of first use
+	OutsideWrites     []string      // Files tool calls wrote outside WorkingDir, resolved
+	DiffStat          DiffStat      // Lines tool calls added and removed, as in SessionStats.DiffStat
+	Languages         []EntryCount  // Code fences and file writes by language, as in SessionStats.Languages
+	IsSidechain       bool          // Whether this is a side-chain conversation
+	SessionID         string        // Session ID recorded in the entries; for side-chains, the owning session
+	AgentID           string        // Subagent ID for sessions written by Task-tool subagents
+	Tasks             []TaskCall    // Task calls the session made, which started its subagents
+	Model             string        // Model of the first assistant response
+	Models            []string      // All models seen in the session, in order of first use
+	LastContextTokens int           // Context size of the latest assistant turn
+	LastContextUsage  float64       // LastContextTokens as a fraction of the model's context window
+	PermissionModes   []string      // Permission modes recorded in the entries, in order of first use
+	PermissionMode    string        // Most permissive of PermissionModes, e.g. "acceptEdits"
+	WorkingDir        string        // Working directory recorded by the first entry that has one
+	Summary           string        // Text of the latest summary entry, Claude's title for the conversation
+	OutputPerPrompt   float64       // Output tokens per user prompt, excluding tool results
+	ContextPerTurn    float64       // Input context tokens per prompt-started turn
 }
 
 // SessionIndexEntry represents a single entry in sessions-index.json
@@ -443,6 +996,7 @@ type SessionIndexEntry struct {
 	FullPath     string `json:"fullPath"`
 	FileMtime    int64  `json:"fileMtime"`
 	FirstPrompt  string `json:"firstPrompt"`
+	Summary      string `json:"summary"`
 	MessageCount int    `json:"messageCount"`
 	Created      string `json:"created"`
 	Modified     string `json:"modified"`
@@ -459,9 +1013,12 @@ type SessionIndex struct {
 }
 
 // GetSessionMetadata extracts quick metadata from a session file
-// It reads the file to get first/last timestamps and count messages/gaps
+// It reads the file to get first/last timestamps and count messages/gaps. A freshly
+// created session may hold only entries without timestamps, such as file history
+// snapshots; it has no messages, and the file's modification time stands in for its
+// start and end.
 func GetSessionMetadata(filePath string) (*SessionMetadata, error) {
-	file, err := os.Open(filePath)
+	file, err := openSessionFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open session file: %w", err)
 	}
@@ -473,21 +1030,39 @@ func GetSessionMetadata(filePath string) (*SessionMetadata, error) {
 	scanner.Buffer(buf, 10*1024*1024) // 10MB max
 
 	var firstTime, lastTime time.Time
-	var messageCount int
+	var entries, messageCount int
 	var userPrompts int
-	var lastMessageTime time.Time
-	var interruptions int
-	var totalInputTokens, totalOutputTokens int
+	var messageTimes []time.Time // Sorted afterwards, as entries can be out of order
+	var timeline []Message       // Times and usage of the messages, for CacheRewarms
+	var lastBranch string
+	var lastBranchTime time.Time
+	var branches []string
+	var writes []fileWrite
+	languages := make(map[string]int)
+	var tokens TokenCounts
 	var version, firstPrompt, gitBranch string
 	var isSidechain bool
+	var sessionID, agentID, workingDir, summary string
+	var tasks []TaskCall
+	var models, permissionModes []string
+	seenModels := make(map[string]bool)
+	var lastTurnTime time.Time
+	var lastTurn Message                  // Latest assistant turn with usage data
+	var prompts, contextTokens int        // For the efficiency metrics
 	const interruptionGap = 1 * time.Hour // Consider >1 hour gap as interruption
 
 	for scanner.Scan() {
-		line := scanner.Bytes()
+		line := cleanLine(scanner.Bytes())
 		var entry SessionEntry
 		if err := json.Unmarshal(line, &entry); err != nil {
 			continue
 		}
+		entries++
+
+		permissionModes = addPermissionMode(permissionModes, entry.PermissionMode)
+		if entry.Type == "summary" && entry.Summary != "" {
+			summary = cleanContent(entry.Summary) // Summary entries carry no timestamp
+		}
 
 		if entry.Timestamp == "" {
 			continue
@@ -499,31 +1074,60 @@ func GetSessionMetadata(filePath string) (*SessionMetadata, error) {
 			continue
 		}
 
-		// Track first and last times
+		// Track the earliest and latest times, which need not be the first and last entries
 		if firstTime.IsZero() {
 			firstTime = ts
 			version = entry.Version         // Get version from first entry
 			gitBranch = entry.GitBranch     // Get git branch from first entry
 			isSidechain = entry.IsSidechain // Get sidechain flag from first entry
 		}
-		lastTime = ts
+		if ts.Before(firstTime) {
+			firstTime = ts
+		}
+		if ts.After(lastTime) {
+			lastTime = ts
+		}
+		if entry.GitBranch != "" && !ts.Before(lastBranchTime) {
+			lastBranch, lastBranchTime = entry.GitBranch, ts
+		}
+		branches = addBranch(branches, entry.GitBranch)
+		if sessionID == "" {
+			sessionID = entry.SessionID
+		}
+		if agentID == "" {
+			agentID = entry.AgentID
+		}
+		if workingDir == "" {
+			workingDir = entry.Cwd
+		}
 
 		// Count messages (user and assistant only, not system events)
 		if entry.Type == "user" || entry.Type == "assistant" {
 			messageCount++
+			usage := Message{Timestamp: ts}
+			if entry.Message != nil && (entry.Type == "assistant" || !isToolResultContent(entry.Message.Content)) {
+				for _, lang := range entryLanguages(line) {
+					languages[lang]++
+				}
+			}
 
 			// Count user prompts separately and capture first prompt
 			if entry.Type == "user" {
 				userPrompts++
-				if firstPrompt == "" && entry.Message != nil {
-					if content, ok := entry.Message.Content.(string); ok {
-						firstPrompt = content
+				if entry.Message != nil && !isToolResultContent(entry.Message.Content) {
+					prompts++
+				}
+				if firstPrompt == "" && entry.Message != nil && !isToolResultContent(entry.Message.Content) {
+					if content := contentText(entry.Message.Content); content != "" {
+						firstPrompt = cleanContent(content)
 					}
 				}
 			}
 
 			// Extract token usage from assistant messages
 			if entry.Type == "assistant" && entry.Message != nil {
+				writes = append(writes, entryWrites(line, entry.Cwd)...)
+				tasks = append(tasks, contentTaskCalls(entry.Message.Content)...)
 				// Try to unmarshal the message to get usage data
 				msgData := entry.Message
 				if msgData.Content != nil {
@@ -532,25 +1136,49 @@ func GetSessionMetadata(filePath string) (*SessionMetadata, error) {
 					// This is handled by re-parsing the line with more detail
 					var detailedEntry struct {
 						Message struct {
+							Model string `json:"model"`
 							Usage struct {
-								InputTokens              int `json:"input_tokens"`
-								CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
-								OutputTokens             int `json:"output_tokens"`
+								InputTokens              int            `json:"input_tokens"`
+								CacheCreationInputTokens int            `json:"cache_creation_input_tokens"`
+								CacheReadInputTokens     int            `json:"cache_read_input_tokens"`
+								OutputTokens             int            `json:"output_tokens"`
+								CacheCreation            CacheBreakdown `json:"cache_creation"`
 							} `json:"usage"`
 						} `json:"message"`
 					}
 					if err := json.Unmarshal(line, &detailedEntry); err == nil {
-						totalInputTokens += detailedEntry.Message.Usage.InputTokens + detailedEntry.Message.Usage.CacheCreationInputTokens
-						totalOutputTokens += detailedEntry.Message.Usage.OutputTokens
+						// Remember the latest turn's context size
+						turn := Message{
+							Model:         detailedEntry.Message.Model,
+							InputTokens:   detailedEntry.Message.Usage.InputTokens,
+							OutputTokens:  detailedEntry.Message.Usage.OutputTokens,
+							CacheCreation: detailedEntry.Message.Usage.CacheCreationInputTokens,
+							CacheRead:     detailedEntry.Message.Usage.CacheReadInputTokens,
+							CacheWrite1h:  detailedEntry.Message.Usage.CacheCreation.Ephemeral1h,
+						}
+						if hasReply(msgData.Content) {
+							tokens.Add(turn) // Counted like SessionStats.Tokens, which skips thinking-only entries
+						}
+						usage = turn
+						usage.Timestamp = ts
+						// The latest by time: entries of resumed sessions can be out of order
+						if turn.ContextTokens() > 0 && !ts.Before(lastTurnTime) {
+							lastTurn, lastTurnTime = turn, ts
+						}
+						contextTokens += turn.ContextTokens()
+
+						// Track models, ignoring placeholders like "<synthetic>"
+						model := detailedEntry.Message.Model
+						if model != "" && !strings.HasPrefix(model, "<") && !seenModels[model] {
+							seenModels[model] = true
+							models = append(models, model)
+						}
 					}
 				}
 			}
 
-			// Detect interruptions (gaps > 1 hour between messages)
-			if !lastMessageTime.IsZero() && ts.Sub(lastMessageTime) > interruptionGap {
-				interruptions++
-			}
-			lastMessageTime = ts
+			messageTimes = append(messageTimes, ts)
+			timeline = append(timeline, usage)
 		}
 	}
 
@@ -559,9 +1187,26 @@ func GetSessionMetadata(filePath string) (*SessionMetadata, error) {
 	}
 
 	if firstTime.IsZero() {
-		return nil, fmt.Errorf("no valid timestamps found in session")
+		if entries == 0 {
+			return nil, fmt.Errorf("no valid timestamps found in session")
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot stat session file: %w", err)
+		}
+		firstTime, lastTime = info.ModTime(), info.ModTime()
+	}
+
+	// Detect interruptions (gaps > 1 hour between messages)
+	sort.Slice(messageTimes, func(i, j int) bool { return messageTimes[i].Before(messageTimes[j]) })
+	interruptions := 0
+	for i := 1; i < len(messageTimes); i++ {
+		if messageTimes[i].Sub(messageTimes[i-1]) > interruptionGap {
+			interruptions++
+		}
 	}
 
+	efficiency := newSessionEfficiency(firstTime, prompts, tokens.Output, contextTokens)
 	return &SessionMetadata{
 		Started:           firstTime,
 		Ended:             lastTime,
@@ -569,15 +1214,83 @@ func GetSessionMetadata(filePath string) (*SessionMetadata, error) {
 		MessageCount:      messageCount,
 		UserPrompts:       userPrompts,
 		Interruptions:     interruptions,
-		TotalInputTokens:  totalInputTokens,
-		TotalOutputTokens: totalOutputTokens,
+		Rewarms:           CacheRewarms(timeline),
+		Tokens:            tokens,
 		Version:           version,
 		FirstPrompt:       firstPrompt,
 		GitBranch:         gitBranch,
+		LastBranch:        lastBranch,
+		Branches:          branches,
+		OutsideWrites:     outsideWorkDir(workingDir, writes),
+		DiffStat:          diffStat(workingDir, writes),
+		Languages:         languageCounts(languages),
 		IsSidechain:       isSidechain,
+		SessionID:         sessionID,
+		AgentID:           agentID,
+		Tasks:             tasks,
+		Model:             firstModel(models),
+		Models:            models,
+		LastContextTokens: lastTurn.ContextTokens(),
+		LastContextUsage:  lastTurn.ContextUsage(),
+		PermissionModes:   permissionModes,
+		PermissionMode:    MostPermissiveMode(permissionModes),
+		WorkingDir:        workingDir,
+		Summary:           summary,
+		OutputPerPrompt:   efficiency.OutputPerPrompt,
+		ContextPerTurn:    efficiency.ContextPerTurn,
 	}, nil
 }
 
+// firstModel returns the first model in the list, or "" if there is none
+func firstModel(models []string) string {
+	if len(models) == 0 {
+		return ""
+	}
+	return models[0]
+}
+
+// ShortModelName reduces a full model ID to its family name
+// e.g. "claude-opus-4-5-20251101" -> "opus", "claude-3-5-haiku-20241022" -> "haiku"
+func ShortModelName(model string) string {
+	lower := strings.ToLower(model)
+	for _, family := range []string{"opus", "sonnet", "haiku"} {
+		if strings.Contains(lower, family) {
+			return family
+		}
+	}
+	return strings.TrimPrefix(lower, "claude-")
+}
+
+// ModelLabel builds a compact label for the models used in a session, such as "opus+haiku"
+func ModelLabel(models []string) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, model := range models {
+		name := ShortModelName(model)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return strings.Join(names, "+")
+}
+
+// MatchesModel reports whether any of the models contains the given substring (case-insensitive).
+// An empty filter matches everything.
+func MatchesModel(models []string, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	for _, model := range models {
+		if strings.Contains(strings.ToLower(model), filter) {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseSessionIndex reads and parses a sessions-index.json file
 func ParseSessionIndex(filePath string) (*SessionIndex, error) {
 	data, err := os.ReadFile(filePath)
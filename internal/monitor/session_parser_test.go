@@ -1,9 +1,17 @@
 package monitor
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestSessionMetadataExtraction tests parsing of session metadata
@@ -39,6 +47,9 @@ func TestSessionMetadataExtraction(t *testing.T) {
 		{"Interruptions", metadata.Interruptions, 0},
 		{"GitBranch", metadata.GitBranch, "main"},
 		{"IsSidechain", metadata.IsSidechain, false},
+		{"Model", metadata.Model, "claude-sonnet-4-5-20250929"},
+		{"ModelLabel", ModelLabel(metadata.Models), "sonnet"},
+		{"LastContextTokens", metadata.LastContextTokens, 10},
 	}
 
 	for _, tt := range tests {
@@ -136,3 +147,565 @@ func TestEventTypeDetection(t *testing.T) {
 		t.Errorf("Message count: got %d, want 2", metadata.MessageCount)
 	}
 }
+
+// TestParseSessionFileWithProgress verifies progress reporting and cancellation
+func TestParseSessionFileWithProgress(t *testing.T) {
+	sessionFile := filepath.Join("testdata", "sample_session.jsonl")
+	info, err := os.Stat(sessionFile)
+	if err != nil {
+		t.Fatalf("Failed to stat fixture: %v", err)
+	}
+
+	var lastProcessed, lastTotal int64
+	stats, err := ParseSessionFileWithProgress(context.Background(), sessionFile, func(processed, total int64) {
+		lastProcessed, lastTotal = processed, total
+	})
+	if err != nil {
+		t.Fatalf("ParseSessionFileWithProgress failed: %v", err)
+	}
+	if lastTotal != info.Size() || lastProcessed != lastTotal {
+		t.Errorf("Final progress: got %d/%d, want %d/%d", lastProcessed, lastTotal, info.Size(), info.Size())
+	}
+	if stats.Partial {
+		t.Error("Complete parse should not be marked partial")
+	}
+
+	// Build a file large enough to hit the periodic cancellation check
+	tmpdir := t.TempDir()
+	bigFile := filepath.Join(tmpdir, "big.jsonl")
+	line := `{"type":"user","timestamp":"2026-01-09T14:00:00.000Z","message":{"role":"user","content":"hello"}}` + "\n"
+	if err := os.WriteFile(bigFile, []byte(strings.Repeat(line, 500)), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stats, err = ParseSessionFileWithProgress(ctx, bigFile, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if stats == nil || !stats.Partial {
+		t.Fatal("Cancelled parse should return partial stats")
+	}
+	if len(stats.MessageHistory) >= 500 {
+		t.Errorf("Cancelled parse read all %d messages", len(stats.MessageHistory))
+	}
+}
+
+// TestParseSessionTail verifies that tail parsing returns the newest complete entries
+func TestParseSessionTail(t *testing.T) {
+	tmpdir := t.TempDir()
+	sessionFile := filepath.Join(tmpdir, "tail.jsonl")
+
+	var sb strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&sb, `{"type":"user","timestamp":"2026-01-09T14:%02d:00.000Z","message":{"role":"user","content":"prompt %02d"}}`+"\n", i, i)
+	}
+	if err := os.WriteFile(sessionFile, []byte(sb.String()), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionTail(sessionFile, 1024)
+	if err != nil {
+		t.Fatalf("ParseSessionTail failed: %v", err)
+	}
+	if !stats.Partial {
+		t.Error("Tail smaller than the file should be marked partial")
+	}
+	if len(stats.MessageHistory) == 0 || len(stats.MessageHistory) >= 50 {
+		t.Fatalf("Expected a subset of messages, got %d", len(stats.MessageHistory))
+	}
+	if last := stats.MessageHistory[len(stats.MessageHistory)-1].Content; last != "prompt 49" {
+		t.Errorf("Last message: got %q, want %q", last, "prompt 49")
+	}
+
+	// A window larger than the file parses everything
+	stats, err = ParseSessionTail(sessionFile, 1<<20)
+	if err != nil {
+		t.Fatalf("ParseSessionTail failed: %v", err)
+	}
+	if stats.Partial || len(stats.MessageHistory) != 50 {
+		t.Errorf("Full window: got partial=%v messages=%d, want false/50", stats.Partial, len(stats.MessageHistory))
+	}
+}
+
+// TestSessionModelTracking tests model capture for sessions that switch models
+func TestSessionModelTracking(t *testing.T) {
+	tmpdir := t.TempDir()
+	sessionFile := filepath.Join(tmpdir, "mixed-models.jsonl")
+
+	testData := `{"type":"user","uuid":"msg1","timestamp":"2026-01-09T14:00:00.000Z","message":{"role":"user","content":"plan this"}}
+{"type":"assistant","uuid":"msg2","timestamp":"2026-01-09T14:00:05.000Z","message":{"model":"claude-opus-4-5-20251101","role":"assistant","content":[]}}
+{"type":"assistant","uuid":"msg3","timestamp":"2026-01-09T14:00:10.000Z","message":{"model":"<synthetic>","role":"assistant","content":[]}}
+{"type":"assistant","uuid":"msg4","timestamp":"2026-01-09T14:00:15.000Z","message":{"model":"claude-haiku-4-5-20251001","role":"assistant","content":[]}}
+{"type":"assistant","uuid":"msg5","timestamp":"2026-01-09T14:00:20.000Z","message":{"model":"claude-opus-4-5-20251101","role":"assistant","content":[]}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+
+	if metadata.Model != "claude-opus-4-5-20251101" {
+		t.Errorf("Model: got %q, want first assistant model", metadata.Model)
+	}
+	if len(metadata.Models) != 2 {
+		t.Fatalf("Models: got %v, want 2 unique models", metadata.Models)
+	}
+	if label := ModelLabel(metadata.Models); label != "opus+haiku" {
+		t.Errorf("ModelLabel: got %q, want %q", label, "opus+haiku")
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"", true},
+		{"opus", true},
+		{"HAIKU", true},
+		{"sonnet", false},
+	}
+	for _, tt := range tests {
+		if got := MatchesModel(metadata.Models, tt.filter); got != tt.want {
+			t.Errorf("MatchesModel(%q): got %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+// TestSessionComposition tests the breakdown of a session file's entries by kind
+func TestSessionComposition(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "composition.jsonl")
+	testData := `{"type":"user","message":{"role":"user","content":"hello"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","name":"Bash","input":{}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"done"}]}}
+{"type":"progress"}
+{"type":"progress"}
+{"type":"system","subtype":"turn_duration"}
+{"type":"file-history-snapshot"}
+{"type":"queue-operation"}
+{"type":"summary","summary":"Greeting"}
+{"type":"custom-title"}
+not json
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+
+	want := []EntryCount{
+		{"user", 1}, {"assistant", 2}, {"tool results", 1}, {"progress", 2},
+		{"system", 1}, {"snapshots", 1}, {"other", 2}, {"unknown", 1},
+	}
+	got := stats.Composition()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Composition() = %v, want %v", got, want)
+	}
+}
+
+// TestToolResultErrors tests that failed tool results are flagged
+func TestToolResultErrors(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "errors.jsonl")
+	testData := `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"Error: exit status 1","is_error":true}]}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	if len(stats.MessageHistory) != 2 || stats.MessageHistory[0].IsError || !stats.MessageHistory[1].IsError {
+		t.Errorf("MessageHistory = %+v, want only the second result flagged as an error", stats.MessageHistory)
+	}
+}
+
+// TestToolResultsOf tests pairing tool calls with their results by tool_use ID
+func TestToolResultsOf(t *testing.T) {
+	stats := &SessionStats{MessageHistory: []Message{
+		{Type: "assistant_response", ToolName: "Bash", ToolUseID: "a"}, // 0
+		{Type: "assistant_response", ToolName: "Read", ToolUseID: "b"}, // 1
+		{Type: "tool_result", ToolUseID: "b"},                          // 2
+		{Type: "tool_result", ToolUseID: "a", IsError: true},           // 3
+		{Type: "assistant_response", ToolName: "Grep", ToolUseID: "c"}, // 4: still running
+		{Type: "assistant_response", Content: "done"},                  // 5
+		{Type: "assistant_response", ToolName: "Edit"},                 // 6: no ID
+		{Type: "tool_result"},                                          // 7
+		{Type: "assistant_response", ToolName: "Edit", ToolUseID: "d", ToolCalls: []ToolCall{
+			{Name: "Edit", ID: "d"}, {Name: "Edit", ID: "e"}, {Name: "Bash", ID: "f"},
+		}}, // 8
+		{Type: "tool_result", ToolUseID: "f"}, // 9
+		{Type: "tool_result", ToolUseID: "d"}, // 10
+	}}
+	tests := []struct {
+		call int
+		want []int
+	}{
+		{0, []int{3}},
+		{1, []int{2}},
+		{4, []int{-1}},
+		{5, []int{}},
+		{6, []int{-1}},
+		{8, []int{10, -1, 9}},
+		{99, nil},
+	}
+	for _, tt := range tests {
+		if got := stats.ToolResultsOf(tt.call); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ToolResultsOf(%d) = %v, want %v", tt.call, got, tt.want)
+		}
+	}
+}
+
+// TestMultipleToolCalls tests that every text block and tool call of an assistant entry
+// is kept, and that statistics count each call
+func TestMultipleToolCalls(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "calls.jsonl")
+	testData := `{"type":"user","timestamp":"2026-01-12T09:14:00Z","message":{"role":"user","content":"rename the handler"}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Renaming it in both files."},{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"a.go"}},{"type":"tool_use","id":"t2","name":"Edit","input":{"file_path":"b.go"}},{"type":"text","text":"Then the tests."},{"type":"tool_use","id":"t3","name":"Bash","input":{"command":"go test ./..."}}]}}
+{"type":"user","timestamp":"2026-01-12T09:14:09Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"ok"}]}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+
+	msg := stats.MessageHistory[1]
+	want := []ToolCall{
+		{Name: "Edit", Input: `{"file_path":"a.go"}`, ID: "t1"},
+		{Name: "Edit", Input: `{"file_path":"b.go"}`, ID: "t2"},
+		{Name: "Bash", Input: `{"command":"go test ./..."}`, ID: "t3"},
+	}
+	if !reflect.DeepEqual(msg.Calls(), want) {
+		t.Errorf("Calls() = %+v, want %+v", msg.Calls(), want)
+	}
+	if msg.ToolName != "Edit" || msg.ToolUseID != "t1" {
+		t.Errorf("first call fields = %s %s, want Edit t1", msg.ToolName, msg.ToolUseID)
+	}
+	if msg.Content != "Renaming it in both files.\n\nThen the tests." {
+		t.Errorf("Content = %q, want both text blocks", msg.Content)
+	}
+	if got := stats.Turns[0].ToolCalls; got != 3 {
+		t.Errorf("turn tool calls = %d, want 3", got)
+	}
+	if got := stats.ToolResultsOf(1); !reflect.DeepEqual(got, []int{-1, 2, -1}) {
+		t.Errorf("ToolResultsOf(1) = %v, want the result of the second call", got)
+	}
+}
+
+func TestPermissionModes(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "modes.jsonl")
+	testData := `{"type":"user","timestamp":"2026-01-12T09:14:00Z","permissionMode":"plan","message":{"role":"user","content":"plan the fix"}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Plan: ..."}]}}
+{"type":"user","timestamp":"2026-01-12T09:15:00Z","permissionMode":"acceptEdits","message":{"role":"user","content":"go ahead"}}
+{"type":"user","timestamp":"2026-01-12T09:16:00Z","permissionMode":"plan","message":{"role":"user","content":"and plan the tests"}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	want := []string{"plan", "acceptEdits"}
+	if !slices.Equal(stats.PermissionModes, want) || stats.PermissionMode != "acceptEdits" {
+		t.Errorf("stats modes = %v (%q), want %v (acceptEdits)", stats.PermissionModes, stats.PermissionMode, want)
+	}
+	if !slices.Equal(metadata.PermissionModes, want) || metadata.PermissionMode != "acceptEdits" {
+		t.Errorf("metadata modes = %v (%q), want %v (acceptEdits)", metadata.PermissionModes, metadata.PermissionMode, want)
+	}
+}
+
+func TestSessionSummaries(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "summaries.jsonl")
+	testData := `{"type":"summary","summary":"Fix flaky login test","leafUuid":"a1"}
+{"type":"user","timestamp":"2026-01-12T09:14:00Z","message":{"role":"user","content":"the login test fails sometimes"}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Looking."}]}}
+{"type":"summary","summary":"Stabilize login and signup tests","leafUuid":"b2"}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	const want = "Stabilize login and signup tests"
+	if stats.Summaries != 2 || stats.Summary != want || stats.UnknownEntries != 0 {
+		t.Errorf("stats: %d summaries, latest %q, %d unknown; want 2, %q, 0", stats.Summaries, stats.Summary, stats.UnknownEntries, want)
+	}
+	if metadata.Summary != want {
+		t.Errorf("metadata summary = %q, want %q", metadata.Summary, want)
+	}
+}
+
+// TestPermissionDecisions tests that answers to permission prompts are attached to the
+// tool calls they answer and counted
+func TestPermissionDecisions(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "permissions.jsonl")
+	testData := `{"type":"user","timestamp":"2026-01-12T09:14:00Z","message":{"role":"user","content":"clean up the build"}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:05Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"rm -rf build"}}]}}
+{"type":"user","timestamp":"2026-01-12T09:14:09Z","toolUseResult":"User rejected tool use","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","is_error":true,"content":"The user doesn't want to proceed with this tool use. The tool use was rejected."}]}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:12Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_2","name":"Bash","input":{"command":"make clean"}}]}}
+{"type":"user","timestamp":"2026-01-12T09:14:15Z","permissionDecision":"allow","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_2","content":"removed build/"}]}}
+{"type":"assistant","timestamp":"2026-01-12T09:14:18Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_3","name":"Read","input":{"file_path":"Makefile"}}]}}
+{"type":"user","timestamp":"2026-01-12T09:14:19Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_3","content":"clean:\n\trm -rf build"}]}}
+{"type":"user","timestamp":"2026-01-12T09:14:30Z","permissionDecision":"deny","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_0","is_error":true,"content":"denied"}]}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	want := map[string]string{"toolu_1": DecisionDenied, "toolu_2": DecisionAllowed, "toolu_3": ""}
+	for _, msg := range stats.MessageHistory {
+		if msg.ToolName == "" {
+			continue
+		}
+		if got := msg.PermissionDecision; got != want[msg.ToolUseID] {
+			t.Errorf("tool call %s: decision %q, want %q", msg.ToolUseID, got, want[msg.ToolUseID])
+		}
+	}
+	// The last denial answers a tool call outside the file, so it is only counted
+	if stats.PermissionsAllowed != 1 || stats.PermissionsDenied != 2 {
+		t.Errorf("counted %d allowed, %d denied; want 1, 2", stats.PermissionsAllowed, stats.PermissionsDenied)
+	}
+	if got := stats.GetDetailedStats(); !strings.Contains(got, "Permissions: 1 allowed, 2 denied") {
+		t.Errorf("detailed stats lack the permission counts: %s", got)
+	}
+}
+
+// TestOutOfOrderTimestamps verifies that a session whose entries go back in time is
+// flagged and read in timestamp order
+func TestOutOfOrderTimestamps(t *testing.T) {
+	sessionFile := filepath.Join("testdata", "out_of_order.jsonl")
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	if stats.OutOfOrder != 2 {
+		t.Errorf("OutOfOrder: got %d, want 2", stats.OutOfOrder)
+	}
+	if want := 71 * time.Minute; stats.Duration != want {
+		t.Errorf("Duration: got %v, want %v", stats.Duration, want)
+	}
+	var prompts []string
+	for i, msg := range stats.MessageHistory {
+		if i > 0 && msg.Timestamp.Before(stats.MessageHistory[i-1].Timestamp) {
+			t.Errorf("message %d at %v precedes message %d at %v", i, msg.Timestamp, i-1, stats.MessageHistory[i-1].Timestamp)
+		}
+		if msg.Type == "prompt" {
+			prompts = append(prompts, msg.Content)
+		}
+	}
+	if want := []string{"First prompt", "Second prompt, written first after a resume", "Third prompt"}; !slices.Equal(prompts, want) {
+		t.Errorf("prompts: got %q, want %q", prompts, want)
+	}
+	if len(stats.Turns) != 3 || stats.Turns[0].Duration() != time.Minute {
+		t.Errorf("Turns: got %+v, want 3 turns in time order", stats.Turns)
+	}
+
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if metadata.Duration != 71*time.Minute {
+		t.Errorf("metadata Duration: got %v, want 71m", metadata.Duration)
+	}
+	// In file order, the jump back to 08:31 and on to 09:40 looks like a 69 minute break
+	if metadata.Interruptions != 0 {
+		t.Errorf("metadata Interruptions: got %d, want 0", metadata.Interruptions)
+	}
+
+	// A few seconds back, as attachments often are, is not out of order
+	jitter := filepath.Join(t.TempDir(), "jitter.jsonl")
+	testData := `{"type":"user","timestamp":"2026-01-12T09:14:00Z","message":{"role":"user","content":"hello"}}
+{"type":"attachment","timestamp":"2026-01-12T09:13:59Z"}
+`
+	if err := os.WriteFile(jitter, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	stats, err = ParseSessionFile(jitter)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	if stats.OutOfOrder != 0 {
+		t.Errorf("jitter: got OutOfOrder %d, want 0", stats.OutOfOrder)
+	}
+}
+
+// TestUntimedEntries tests that entries without a timestamp, such as file history
+// snapshots and summaries, do not move the start of a session in either parser
+func TestUntimedEntries(t *testing.T) {
+	sessionFile := filepath.Join("testdata", "untimed_entries.jsonl")
+	want := time.Hour + 5*time.Second
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	start := time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC)
+	if !stats.CreatedAt.Equal(start) || stats.Duration != want {
+		t.Errorf("ParseSessionFile: CreatedAt %v, Duration %v; want %v, %v", stats.CreatedAt, stats.Duration, start, want)
+	}
+
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if !metadata.Started.Equal(start) || metadata.Duration != want {
+		t.Errorf("GetSessionMetadata: Started %v, Duration %v; want %v, %v", metadata.Started, metadata.Duration, start, want)
+	}
+}
+
+// TestBOMAndCRLF tests that a session rewritten with a byte order mark and CRLF line
+// endings parses exactly like the original
+func TestBOMAndCRLF(t *testing.T) {
+	plain := filepath.Join("testdata", "sample_session.jsonl")
+	data, err := os.ReadFile(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewritten := filepath.Join(t.TempDir(), "rewritten.jsonl")
+	crlf := append([]byte("\xef\xbb\xbf"), bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))...)
+	if err := os.WriteFile(rewritten, crlf, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ParseSessionFile(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseSessionFile(rewritten)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	got.FilePath = want.FilePath
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSessionFile: stats differ from the clean file (%d messages, %d malformed; want %d, %d)",
+			len(got.MessageHistory), got.MalformedLines, len(want.MessageHistory), want.MalformedLines)
+	}
+
+	tail, err := ParseSessionTail(rewritten, 1<<20)
+	if err != nil {
+		t.Fatalf("ParseSessionTail failed: %v", err)
+	}
+	tail.FilePath = want.FilePath
+	if !reflect.DeepEqual(tail, want) {
+		t.Errorf("ParseSessionTail: stats differ from the clean file (%d messages, %d malformed; want %d, %d)",
+			len(tail.MessageHistory), tail.MalformedLines, len(want.MessageHistory), want.MalformedLines)
+	}
+
+	wantMeta, err := GetSessionMetadata(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := GetSessionMetadata(rewritten)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if !reflect.DeepEqual(meta, wantMeta) {
+		t.Errorf("GetSessionMetadata = %+v\nwant %+v", meta, wantMeta)
+	}
+}
+
+// TestCleanContent tests that carriage returns in message text become newlines
+func TestCleanContent(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"two\r\nlines", "two\nlines"},
+		{"stray\rreturn", "stray\nreturn"},
+		{"mixed\r\n\r\nend\r", "mixed\n\nend\n"},
+	}
+	for _, tt := range tests {
+		if got := cleanContent(tt.in); got != tt.want {
+			t.Errorf("cleanContent(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	stats := &SessionStats{}
+	stats.processLine([]byte(`{"type":"user","message":{"role":"user","content":"fix\r\nthis"}}` + "\r"))
+	if len(stats.MessageHistory) != 1 || stats.MessageHistory[0].Content != "fix\nthis" {
+		t.Errorf("processLine kept carriage returns: %+v", stats.MessageHistory)
+	}
+}
+
+// TestSessionMetadataWithoutMessages tests that a freshly created session holding only
+// entries without timestamps is read as a session with no messages, timed by its file,
+// while a file with no readable entry at all is still an error
+func TestSessionMetadataWithoutMessages(t *testing.T) {
+	dir := t.TempDir()
+	fresh := filepath.Join(dir, "fresh.jsonl")
+	testData := `{"type":"file-history-snapshot","messageId":"m1","snapshot":{"messageId":"m1","trackedFileBackups":{},"timestamp":"2026-01-12T09:14:00Z"},"isSnapshotUpdate":false}
+{"type":"queue-operation","operation":"enqueue"}
+`
+	if err := os.WriteFile(fresh, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	mtime := time.Date(2026, 1, 12, 9, 14, 3, 0, time.UTC)
+	if err := os.Chtimes(fresh, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := GetSessionMetadata(fresh)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if metadata.MessageCount != 0 || !metadata.Started.Equal(mtime) || !metadata.Ended.Equal(mtime) || metadata.Duration != 0 {
+		t.Errorf("metadata = %d messages from %v to %v, want none at the file's mtime %v", metadata.MessageCount, metadata.Started, metadata.Ended, mtime)
+	}
+
+	garbage := filepath.Join(dir, "garbage.jsonl")
+	if err := os.WriteFile(garbage, []byte("not json\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if _, err := GetSessionMetadata(garbage); err == nil {
+		t.Error("GetSessionMetadata accepted a file without a single entry")
+	}
+}
+
+// TestSessionMetadataLastContext tests that the context size is taken from the latest
+// response by time, also when entries are out of order
+func TestSessionMetadataLastContext(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "resumed.jsonl")
+	testData := `{"type":"assistant","timestamp":"2026-01-12T10:00:00Z","message":{"model":"claude-sonnet-4-5-20250929","role":"assistant","content":[{"type":"text","text":"late"}],"usage":{"input_tokens":10,"cache_read_input_tokens":180000,"output_tokens":5}}}
+{"type":"assistant","timestamp":"2026-01-12T09:00:00Z","message":{"model":"claude-sonnet-4-5-20250929","role":"assistant","content":[{"type":"text","text":"early"}],"usage":{"input_tokens":10,"cache_read_input_tokens":40000,"output_tokens":5}}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if metadata.LastContextTokens != 180010 || metadata.LastContextUsage != 0.90005 {
+		t.Errorf("last context = %d tokens (%g), want 180010 (0.90005)", metadata.LastContextTokens, metadata.LastContextUsage)
+	}
+}
@@ -3,9 +3,11 @@ package monitor
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -17,6 +19,7 @@ type Session struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 	Title     string    `json:"title"`
 	FilePath  string    // Full path to the session file
+	Err       error     // Set when the file could not be read; ID and times then come from the directory entry
 }
 
 // SessionInfo represents session metadata
@@ -27,26 +30,63 @@ type SessionInfo struct {
 	Title     string    `json:"title"`
 }
 
-// FindSessionsForDirectory finds all sessions for a given working directory
-func FindSessionsForDirectory(workingDir string) ([]Session, error) {
-	// Convert working directory path to the format used in .claude/projects
-	// /Users/thies/Projects/foo -> -Users-thies-Projects-foo
-	dirName := convertPathToSessionDirName(workingDir)
+// projectsDirOverride replaces ~/.claude/projects when set (e.g. by demo mode)
+var projectsDirOverride string
 
-	// Look for sessions in ~/.claude/projects/<dirName>/
+// SetProjectsDir makes ProjectsDir return dir instead of ~/.claude/projects.
+// An empty dir restores the default. Call it before any sessions are loaded.
+func SetProjectsDir(dir string) {
+	projectsDirOverride = dir
+}
+
+// ProjectsDir returns the directory where Claude stores per-project session files
+// (~/.claude/projects, or projects under $CLAUDE_CONFIG_DIR when that is set)
+func ProjectsDir() (string, error) {
+	if projectsDirOverride != "" {
+		return projectsDirOverride, nil
+	}
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects"), nil
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
+	return filepath.Join(homeDir, ".claude", "projects"), nil
+}
+
+// IsAgentFile reports whether a session file name belongs to a Task-tool subagent
+// (agent-<id>.jsonl) rather than a conversation started by the user
+func IsAgentFile(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, "agent-") && IsSessionFile(name)
+}
 
-	sessionDir := filepath.Join(homeDir, ".claude", "projects", dirName)
+// ProjectDirFor returns the project directory under ProjectsDir holding the sessions
+// started in workingDir, whether or not it exists yet
+func ProjectDirFor(workingDir string) (string, error) {
+	projectsDir, err := ProjectsDir()
+	if err != nil {
+		return "", err
+	}
+	// /Users/thies/Projects/foo -> -Users-thies-Projects-foo
+	return filepath.Join(projectsDir, convertPathToSessionDirName(workingDir)), nil
+}
+
+// FindSessionsForDirectory finds all sessions for a given working directory
+func FindSessionsForDirectory(workingDir string) ([]Session, error) {
+	// Look for sessions in ~/.claude/projects/<dirName>/
+	sessionDir, err := ProjectDirFor(workingDir)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if directory exists
 	if _, err := os.Stat(sessionDir); os.IsNotExist(err) {
 		return nil, nil // No sessions found, but not an error
 	}
 
-	// Read all .jsonl files in the directory
+	// Read all session files (.jsonl and .jsonl.gz) in the directory
 	entries, err := os.ReadDir(sessionDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read session directory: %w", err)
@@ -55,11 +95,21 @@ func FindSessionsForDirectory(workingDir string) ([]Session, error) {
 	var sessions []Session
 
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
+		if !entry.IsDir() && IsSessionFile(entry.Name()) {
 			sessionPath := filepath.Join(sessionDir, entry.Name())
 			session, err := readSessionFile(sessionPath)
 			if err != nil {
-				continue // Skip files we can't read
+				// Keep unreadable files listed so the error can be shown and retried
+				logger.Debug("cannot read session file", "op", "find_sessions", "path", sessionPath, "err", err)
+				session = Session{
+					ID:       SessionFileID(entry.Name()),
+					FilePath: sessionPath,
+					Err:      err,
+				}
+				if info, err := entry.Info(); err == nil {
+					session.CreatedAt = info.ModTime()
+					session.UpdatedAt = info.ModTime()
+				}
 			}
 			sessions = append(sessions, session)
 		}
@@ -68,9 +118,65 @@ func FindSessionsForDirectory(workingDir string) ([]Session, error) {
 	return sessions, nil
 }
 
+// RecentSessionFile is a session file found by FindRecentSessionFiles
+type RecentSessionFile struct {
+	Path       string    // Full path to the session file
+	ProjectDir string    // Project directory under ProjectsDir holding the file
+	ModTime    time.Time // Last modification, i.e. the session's last activity
+}
+
+// FindRecentSessionFiles lists the session files of all projects modified at or after
+// since, newest first. Only directory entries are read, so it stays fast for large
+// session histories; project directories that cannot be read are skipped.
+func FindRecentSessionFiles(since time.Time) ([]RecentSessionFile, error) {
+	projectsDir, err := ProjectsDir()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := os.ReadDir(projectsDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil // Claude has not run yet: no sessions, but not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projects directory: %w", err)
+	}
+
+	var files []RecentSessionFile
+	for _, project := range projects {
+		if !project.IsDir() {
+			continue
+		}
+		projectDir := filepath.Join(projectsDir, project.Name())
+		entries, err := os.ReadDir(projectDir)
+		if err != nil {
+			logger.Debug("cannot read project directory", "op", "find_recent_sessions", "path", projectDir, "err", err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !IsSessionFile(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.ModTime().Before(since) {
+				continue
+			}
+			files = append(files, RecentSessionFile{
+				Path:       filepath.Join(projectDir, entry.Name()),
+				ProjectDir: projectDir,
+				ModTime:    info.ModTime(),
+			})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, nil
+}
+
 // readSessionFile reads a JSONL session file and extracts metadata
 func readSessionFile(filePath string) (Session, error) {
-	file, err := os.Open(filePath)
+	file, err := openSessionFile(filePath)
 	if err != nil {
 		return Session{}, fmt.Errorf("failed to open session file: %w", err)
 	}
@@ -81,7 +187,7 @@ func readSessionFile(filePath string) (Session, error) {
 	session.FilePath = filePath
 
 	// Extract ID from filename if present
-	sessionID := strings.TrimSuffix(filepath.Base(filePath), ".jsonl")
+	sessionID := SessionFileID(filePath)
 	session.ID = sessionID
 
 	lineNum := 0
@@ -90,7 +196,7 @@ func readSessionFile(filePath string) (Session, error) {
 	for scanner.Scan() && lineNum < 10 {
 		lineNum++
 		var data map[string]interface{}
-		if err := json.Unmarshal(scanner.Bytes(), &data); err != nil {
+		if err := json.Unmarshal(cleanLine(scanner.Bytes()), &data); err != nil {
 			continue
 		}
 
@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFindRecentSessionFiles tests that sessions of all projects are merged, newest
+// first, and that files older than the cutoff are left out
+func TestFindRecentSessionFiles(t *testing.T) {
+	projects := t.TempDir()
+	SetProjectsDir(projects)
+	t.Cleanup(func() { SetProjectsDir("") })
+
+	now := time.Now()
+	files := []struct {
+		project, name string
+		age           time.Duration
+	}{
+		{"-home-demo-api", "old.jsonl", 10 * 24 * time.Hour},
+		{"-home-demo-api", "recent.jsonl", time.Hour},
+		{"-home-demo-web", "newest.jsonl.gz", time.Minute},
+		{"-home-demo-web", "notes.txt", time.Minute},
+		{"-home-demo-web", "older.jsonl", 2 * 24 * time.Hour},
+	}
+	for _, f := range files {
+		dir := filepath.Join(projects, f.project)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-f.age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := FindRecentSessionFiles(now.Add(-7 * 24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"newest.jsonl.gz", "recent.jsonl", "older.jsonl"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d files %+v, want %v", len(got), got, want)
+	}
+	for i, f := range got {
+		if filepath.Base(f.Path) != want[i] || filepath.Dir(f.Path) != f.ProjectDir {
+			t.Errorf("file %d = %+v, want %s inside its project directory", i, f, want[i])
+		}
+	}
+}
+
+// TestProjectsDir tests that CLAUDE_CONFIG_DIR relocates the projects directory
+func TestProjectsDir(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	if dir, _ := ProjectsDir(); dir != "/home/me/.claude/projects" {
+		t.Errorf("default ProjectsDir = %q", dir)
+	}
+	t.Setenv("CLAUDE_CONFIG_DIR", "/srv/claude")
+	if dir, _ := ProjectsDir(); dir != "/srv/claude/projects" {
+		t.Errorf("with CLAUDE_CONFIG_DIR: ProjectsDir = %q, want /srv/claude/projects", dir)
+	}
+}
+
+// TestFindRecentSessionFilesMissingDir tests that a projects directory Claude has not
+// created yet means no sessions rather than an error
+func TestFindRecentSessionFilesMissingDir(t *testing.T) {
+	SetProjectsDir(filepath.Join(t.TempDir(), "projects"))
+	t.Cleanup(func() { SetProjectsDir("") })
+
+	files, err := FindRecentSessionFiles(time.Now().Add(-time.Hour))
+	if err != nil || len(files) != 0 {
+		t.Errorf("FindRecentSessionFiles = %v, %v; want no files and no error", files, err)
+	}
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import "strings"
+
+// TaskCall is a Task tool call, with which Claude starts a subagent. The subagent's
+// session file opens with Prompt as its first user entry.
+type TaskCall struct {
+	Description  string // Short summary of the task, e.g. "Find the auth handlers"
+	Prompt       string // Instructions the subagent was given
+	SubagentType string // Kind of agent, e.g. "Explore"; "" if not recorded
+}
+
+// Label names the task for a session list: its description, else its prompt, prefixed
+// with the agent type, e.g. "Explore: Find the auth handlers"
+func (t TaskCall) Label() string {
+	label := t.Description
+	if label == "" {
+		label = t.Prompt
+	}
+	if t.SubagentType != "" && label != "" {
+		return t.SubagentType + ": " + label
+	}
+	return label
+}
+
+// Started reports whether the task is the one that started a side-chain whose first
+// prompt is prompt
+func (t TaskCall) Started(prompt string) bool {
+	return t.Prompt != "" && strings.TrimSpace(t.Prompt) == strings.TrimSpace(prompt)
+}
+
+// contentTaskCalls returns the Task tool calls in assistant message content
+func contentTaskCalls(content interface{}) []TaskCall {
+	items, _ := content.([]interface{})
+	var calls []TaskCall
+	for _, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok || itemMap["type"] != "tool_use" || itemMap["name"] != "Task" {
+			continue
+		}
+		input, _ := itemMap["input"].(map[string]interface{})
+		var call TaskCall
+		call.Description, _ = input["description"].(string)
+		call.Prompt, _ = input["prompt"].(string)
+		call.SubagentType, _ = input["subagent_type"].(string)
+		call.Prompt = cleanContent(call.Prompt)
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// contentText returns the text of user message content: the string itself, or the text
+// items of array content joined by blank lines, as side-chains write their first prompt
+func contentText(content interface{}) string {
+	if text, ok := content.(string); ok {
+		return text
+	}
+	items, _ := content.([]interface{})
+	var texts []string
+	for _, item := range items {
+		if itemMap, ok := item.(map[string]interface{}); ok && itemMap["type"] == "text" {
+			if text, _ := itemMap["text"].(string); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSessionMetadataTasks tests that the Task calls of a session are collected, and
+// that a subagent file's first prompt is read from array content
+func TestSessionMetadataTasks(t *testing.T) {
+	dir := t.TempDir()
+	parent := filepath.Join(dir, "main.jsonl")
+	agent := filepath.Join(dir, "agent-7b.jsonl")
+	if err := os.WriteFile(parent, []byte(
+		`{"type":"user","timestamp":"2026-01-09T14:00:00Z","sessionId":"main","message":{"role":"user","content":"review the auth code"}}`+"\n"+
+			`{"type":"assistant","timestamp":"2026-01-09T14:00:05Z","sessionId":"main","message":{"role":"assistant","content":[`+
+			`{"type":"text","text":"Starting two agents."},`+
+			`{"type":"tool_use","id":"toolu_1","name":"Task","input":{"description":"Find the auth handlers","prompt":"List every HTTP handler\r\nthat checks a token.","subagent_type":"Explore"}},`+
+			`{"type":"tool_use","id":"toolu_2","name":"Task","input":{"description":"Check the tests","prompt":"Run the auth tests."}},`+
+			`{"type":"tool_use","id":"toolu_3","name":"Bash","input":{"command":"ls"}}]}}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(agent, []byte(
+		`{"type":"user","timestamp":"2026-01-09T14:00:06Z","sessionId":"main","agentId":"7b","isSidechain":true,"message":{"role":"user","content":[{"type":"text","text":"List every HTTP handler\nthat checks a token."}]}}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := GetSessionMetadata(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Tasks) != 2 {
+		t.Fatalf("tasks = %+v, want the two Task calls", meta.Tasks)
+	}
+	if got := meta.Tasks[0].Label(); got != "Explore: Find the auth handlers" {
+		t.Errorf("first task label = %q", got)
+	}
+	if got := meta.Tasks[1].Label(); got != "Check the tests" {
+		t.Errorf("second task label = %q", got)
+	}
+
+	child, err := GetSessionMetadata(agent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.Tasks[0].Started(child.FirstPrompt) || meta.Tasks[1].Started(child.FirstPrompt) {
+		t.Errorf("subagent prompt %q matched the wrong task", child.FirstPrompt)
+	}
+}
+
+// TestTaskLabel tests the fallbacks of a task's list label
+func TestTaskLabel(t *testing.T) {
+	tests := []struct {
+		task TaskCall
+		want string
+	}{
+		{TaskCall{Description: "Find handlers", Prompt: "List the handlers", SubagentType: "Explore"}, "Explore: Find handlers"},
+		{TaskCall{Prompt: "List the handlers", SubagentType: "Explore"}, "Explore: List the handlers"},
+		{TaskCall{Prompt: "List the handlers"}, "List the handlers"},
+		{TaskCall{SubagentType: "Explore"}, ""},
+		{TaskCall{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.task.Label(); got != tt.want {
+			t.Errorf("%+v: Label() = %q, want %q", tt.task, got, tt.want)
+		}
+	}
+}
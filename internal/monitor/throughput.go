@@ -0,0 +1,54 @@
+package monitor
+
+import "time"
+
+// maxResponseGap is the longest gap between a request and the response to it that
+// counts as generation time; longer gaps span a suspended laptop or a resumed session
+const maxResponseGap = 10 * time.Minute
+
+// responseTimes sets the ResponseTime of assistant messages whose entry recorded no
+// durationMs to the time since the prompt or tool result they answer. The entries of one
+// response share that request, so its text and tool calls are timed alike.
+func responseTimes(history []Message) {
+	var request time.Time
+	for i := range history {
+		msg := &history[i]
+		if msg.Role != "assistant" {
+			request = msg.Timestamp
+			continue
+		}
+		if msg.ResponseTime > 0 || request.IsZero() || msg.Timestamp.IsZero() {
+			continue
+		}
+		if gap := msg.Timestamp.Sub(request); gap > 0 && gap <= maxResponseGap {
+			msg.ResponseTime = gap
+		}
+	}
+}
+
+// TokensPerSecond returns the output tokens an assistant message generated per second
+// of its ResponseTime; ok is false when either is unknown
+func (m Message) TokensPerSecond() (rate float64, ok bool) {
+	if m.Role != "assistant" || m.OutputTokens <= 0 || m.ResponseTime <= 0 {
+		return 0, false
+	}
+	return float64(m.OutputTokens) / m.ResponseTime.Seconds(), true
+}
+
+// TokensPerSecond returns the session's average output rate: the output tokens of the
+// timed assistant messages over their summed response times; ok is false when no
+// message was timed
+func (s *SessionStats) TokensPerSecond() (rate float64, ok bool) {
+	var tokens int
+	var elapsed time.Duration
+	for _, msg := range s.MessageHistory {
+		if _, ok := msg.TokensPerSecond(); ok {
+			tokens += msg.OutputTokens
+			elapsed += msg.ResponseTime
+		}
+	}
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(tokens) / elapsed.Seconds(), true
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTokensPerSecond tests message and session output rates, timed by durationMs or by
+// the gap to the request, and left unknown rather than infinite without either
+func TestTokensPerSecond(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.jsonl")
+	lines := `{"type":"user","timestamp":"2026-01-09T14:00:00Z","message":{"role":"user","content":"fix the test"}}
+{"type":"assistant","timestamp":"2026-01-09T14:00:10Z","message":{"role":"assistant","content":[{"type":"text","text":"Looking."}],"usage":{"output_tokens":400}}}
+{"type":"assistant","timestamp":"2026-01-09T14:00:10Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"go test"}}],"usage":{"output_tokens":400}}}
+{"type":"user","timestamp":"2026-01-09T14:00:20Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok"}]}}
+{"type":"assistant","timestamp":"2026-01-09T14:00:30Z","durationMs":2000,"message":{"role":"assistant","content":[{"type":"text","text":"Passes."}],"usage":{"output_tokens":100}}}
+{"type":"user","timestamp":"2026-01-09T14:00:40Z","message":{"role":"user","content":"thanks"}}
+{"type":"assistant","timestamp":"2026-01-09T14:00:40Z","message":{"role":"assistant","content":[{"type":"text","text":"Sure."}],"usage":{"output_tokens":5}}}
+{"type":"user","timestamp":"2026-01-09T15:00:00Z","message":{"role":"user","content":"back again"}}
+{"type":"assistant","timestamp":"2026-01-09T16:00:00Z","message":{"role":"assistant","content":[{"type":"text","text":"Hi."}],"usage":{"output_tokens":5}}}
+`
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := ParseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		idx    int
+		want   float64
+		wantOK bool
+	}{
+		{"prompt", 0, 0, false},
+		{"text answering the prompt", 1, 40, true},
+		{"tool call of the same response", 2, 40, true},
+		{"durationMs over the gap", 4, 50, true},
+		{"same timestamp as the request", 6, 0, false},
+		{"gap too long to be generation", 8, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := stats.MessageHistory[tt.idx].TokensPerSecond()
+		if ok != tt.wantOK || math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("%s: TokensPerSecond = %g, %v; want %g, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if rate, ok := stats.TokensPerSecond(); !ok || math.Abs(rate-900.0/22) > 0.01 {
+		t.Errorf("session rate = %g, %v; want %g", rate, ok, 900.0/22)
+	}
+
+	empty := &SessionStats{MessageHistory: []Message{{Role: "assistant", OutputTokens: 10, Timestamp: time.Now()}}}
+	if rate, ok := empty.TokensPerSecond(); ok || rate != 0 {
+		t.Errorf("untimed session rate = %g, %v; want unknown", rate, ok)
+	}
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// EstimateTokens approximates how many tokens a piece of text uses
+// User prompts carry no usage data in session files, so their size is estimated with a
+// BPE-like heuristic: short words are one token, longer words split every ~5 characters,
+// digits group in threes, and each punctuation mark or CJK character is its own token.
+// The result is typically within 25% of the real count for English prose and code.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLen := 0
+	digitLen := 0
+	newlines := false
+
+	flushWord := func() {
+		if wordLen > 0 {
+			if wordLen <= 6 {
+				tokens++
+			} else {
+				tokens += (wordLen + 4) / 5
+			}
+			wordLen = 0
+		}
+	}
+	flushDigits := func() {
+		if digitLen > 0 {
+			tokens += (digitLen + 2) / 3
+			digitLen = 0
+		}
+	}
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		switch {
+		case isCJK(r):
+			flushWord()
+			flushDigits()
+			tokens++
+		case unicode.IsLetter(r):
+			flushDigits()
+			wordLen++
+		case unicode.IsDigit(r):
+			flushWord()
+			digitLen++
+		case r == '\n':
+			flushWord()
+			flushDigits()
+			// A run of newlines is a single token
+			if !newlines {
+				tokens++
+			}
+			newlines = true
+			continue
+		case unicode.IsSpace(r):
+			// Spaces are merged into the following word
+			flushWord()
+			flushDigits()
+		default:
+			flushWord()
+			flushDigits()
+			tokens++
+		}
+		newlines = false
+	}
+	flushWord()
+	flushDigits()
+
+	return tokens
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character, which tokenizers
+// encode at roughly one token per character
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// TokenCounts splits tokens the way the API bills them. Input is fresh input only:
+// input tokens written to or read from the prompt cache are counted apart from it.
+type TokenCounts struct {
+	Input      int
+	Output     int
+	CacheWrite int
+	CacheRead  int
+}
+
+// TotalLabel says what TokenCounts.Total includes, for labels next to it
+const TotalLabel = "in+cache write+out"
+
+// Tokens returns the message's usage (assistant messages only)
+func (m Message) Tokens() TokenCounts {
+	return TokenCounts{Input: m.InputTokens, Output: m.OutputTokens, CacheWrite: m.CacheCreation, CacheRead: m.CacheRead}
+}
+
+// Add adds the message's usage to the counts
+func (t *TokenCounts) Add(msg Message) {
+	t.Merge(msg.Tokens())
+}
+
+// Merge adds other counts to these
+func (t *TokenCounts) Merge(o TokenCounts) {
+	t.Input += o.Input
+	t.Output += o.Output
+	t.CacheWrite += o.CacheWrite
+	t.CacheRead += o.CacheRead
+}
+
+// Total returns the tokens processed: fresh input, cache writes and output. Cache reads
+// are left out, as every call re-reads the cached context and they would dwarf the rest.
+func (t TokenCounts) Total() int {
+	return t.Input + t.CacheWrite + t.Output
+}
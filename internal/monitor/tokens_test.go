@@ -0,0 +1,138 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEstimateTokens checks the estimator against typical BPE tokenizations of known samples
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		actual int // Token count of a typical BPE tokenization
+	}{
+		{"empty", "", 0},
+		{"greeting", "Hello, world!", 4},
+		{"pangram", "The quick brown fox jumps over the lazy dog.", 10},
+		{"prompt", "Can you refactor the session parser so that it reports progress while reading large files?", 17},
+		{"code", `func main() { fmt.Println("hello") }`, 12},
+		{"path", "Please look at internal/monitor/session_parser.go line 120", 15},
+		{"numbers", "Set the timeout to 30000 ms and retry 5 times", 11},
+		{"multiline", "First line\n\nSecond line\nThird line", 8},
+		{"cjk", "你好世界", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateTokens(tt.text)
+			// Allow 30% (at least 2 tokens) of error either way
+			tolerance := tt.actual * 30 / 100
+			if tolerance < 2 {
+				tolerance = 2
+			}
+			if got < tt.actual-tolerance || got > tt.actual+tolerance {
+				t.Errorf("EstimateTokens(%q) = %d, want %d±%d", tt.text, got, tt.actual, tolerance)
+			}
+		})
+	}
+}
+
+// TestEstimateTokensScales tests that long prose lands near the chars/4 rule of thumb
+func TestEstimateTokensScales(t *testing.T) {
+	text := strings.Repeat("The parser reads each line of the session file and records the message. ", 100)
+	got := EstimateTokens(text)
+	charsPerToken := float64(len(text)) / float64(got)
+	if charsPerToken < 3 || charsPerToken > 6 {
+		t.Errorf("long prose: %.1f chars per token, want between 3 and 6", charsPerToken)
+	}
+}
+
+// TestPromptTokenEstimate tests that parsed user prompts get an estimate kept apart from real usage
+func TestPromptTokenEstimate(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "session.jsonl")
+	testData := `{"type":"user","uuid":"u1","timestamp":"2026-01-09T14:00:00.000Z","message":{"role":"user","content":"The quick brown fox jumps over the lazy dog."}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-01-09T14:00:05.000Z","message":{"model":"claude-sonnet-4-5-20250929","role":"assistant","content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":12,"output_tokens":1}}}
+`
+	if err := os.WriteFile(sessionFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	if len(stats.MessageHistory) != 2 {
+		t.Fatalf("got %d messages, want 2", len(stats.MessageHistory))
+	}
+
+	prompt, reply := stats.MessageHistory[0], stats.MessageHistory[1]
+	if prompt.EstimatedTokens == 0 || prompt.InputTokens != 0 {
+		t.Errorf("prompt: EstimatedTokens=%d InputTokens=%d, want an estimate only", prompt.EstimatedTokens, prompt.InputTokens)
+	}
+	if reply.EstimatedTokens != 0 || reply.InputTokens != 12 {
+		t.Errorf("reply: EstimatedTokens=%d InputTokens=%d, want real usage only", reply.EstimatedTokens, reply.InputTokens)
+	}
+}
+
+// TestTokenTotalsReconcile tests that the session, turn, metadata and project token
+// counts all add up to the usage of the individual messages
+func TestTokenTotalsReconcile(t *testing.T) {
+	dir := t.TempDir()
+	thinking := filepath.Join(dir, "thinking.jsonl")
+	lines := []string{
+		`{"type":"user","timestamp":"2026-01-09T14:00:00Z","message":{"role":"user","content":"hi"}}`,
+		`{"type":"assistant","timestamp":"2026-01-09T14:00:01Z","message":{"role":"assistant","model":"claude-opus-4-5","content":[{"type":"thinking","thinking":"hm"}],"usage":{"input_tokens":3,"output_tokens":9}}}`,
+		`{"type":"assistant","timestamp":"2026-01-09T14:00:02Z","message":{"role":"assistant","model":"claude-opus-4-5","content":[{"type":"text","text":"hello"}],"usage":{"input_tokens":4,"cache_creation_input_tokens":50,"cache_read_input_tokens":700,"output_tokens":20}}}`,
+		`{"type":"assistant","timestamp":"2026-01-09T14:00:03Z","message":{"role":"assistant","model":"claude-opus-4-5","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{}}],"usage":{"input_tokens":1,"cache_read_input_tokens":750,"output_tokens":30}}}`,
+	}
+	if err := os.WriteFile(thinking, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{filepath.Join("testdata", "sample_session.jsonl"), thinking} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			stats, err := ParseSessionFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var messages, turns TokenCounts
+			for _, msg := range stats.MessageHistory {
+				messages.Add(msg)
+			}
+			for _, turn := range stats.Turns {
+				turns.Merge(turn.Tokens)
+			}
+			if messages.Total() == 0 {
+				t.Fatal("no usage parsed")
+			}
+			if stats.Tokens != messages || turns != messages {
+				t.Errorf("session %+v, turns %+v; want the message sum %+v", stats.Tokens, turns, messages)
+			}
+
+			meta, err := GetSessionMetadata(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if meta.Tokens != messages {
+				t.Errorf("metadata %+v, want the message sum %+v", meta.Tokens, messages)
+			}
+
+			var project ProjectStats
+			project.Add(stats, false, func(*Message) float64 { return 0 })
+			var models TokenCounts
+			for _, u := range project.Models {
+				models.Merge(u.Tokens)
+			}
+			if project.Tokens != messages || models != messages {
+				t.Errorf("project %+v, models %+v; want the message sum %+v", project.Tokens, models, messages)
+			}
+		})
+	}
+
+	if got := (TokenCounts{Input: 1, Output: 2, CacheWrite: 4, CacheRead: 8}).Total(); got != 7 {
+		t.Errorf("Total = %d, want 7: cache reads are left out", got)
+	}
+}
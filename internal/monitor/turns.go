@@ -0,0 +1,181 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+// Turn is one exchange in a session: a user prompt plus all assistant and tool
+// activity until the next prompt
+type Turn struct {
+	Index     int // 1-based turn number
+	Start     int // Index of the turn's first message in MessageHistory
+	End       int // Index one past the turn's last message
+	StartTime time.Time
+	EndTime   time.Time
+	ToolCalls int
+	Tokens    TokenCounts
+}
+
+// Duration returns the time from the turn's first to its last message
+func (t Turn) Duration() time.Duration {
+	if t.StartTime.IsZero() || t.EndTime.IsZero() {
+		return 0
+	}
+	return t.EndTime.Sub(t.StartTime)
+}
+
+// Messages returns the turn's messages from the session history
+func (t Turn) Messages(history []Message) []Message {
+	if t.Start < 0 || t.End > len(history) || t.Start > t.End {
+		return nil
+	}
+	return history[t.Start:t.End]
+}
+
+// Cost returns the turn's cost using the given per-message pricing function
+func (t Turn) Cost(history []Message, cost func(*Message) float64) float64 {
+	msgs := t.Messages(history)
+	var total float64
+	for i := range msgs {
+		total += cost(&msgs[i])
+	}
+	return total
+}
+
+// TurnStats aggregates per-turn metrics for a session
+type TurnStats struct {
+	Turns          int
+	AvgCost        float64
+	MedianCost     float64
+	AvgToolCalls   float64
+	AvgDuration    time.Duration
+	MedianDuration time.Duration
+	MostExpensive  int // Index into SessionStats.Turns of the costliest turn, -1 if there are no turns
+	MaxCost        float64
+}
+
+// TurnStats computes aggregate turn statistics. Pricing lives outside this package,
+// so the per-message cost function is supplied by the caller.
+func (s *SessionStats) TurnStats(cost func(*Message) float64) TurnStats {
+	ts := TurnStats{Turns: len(s.Turns), MostExpensive: -1}
+	if len(s.Turns) == 0 {
+		return ts
+	}
+
+	costs := make([]float64, len(s.Turns))
+	durations := make([]time.Duration, len(s.Turns))
+	var totalCost float64
+	var totalTools int
+	var totalDuration time.Duration
+	for i, turn := range s.Turns {
+		costs[i] = turn.Cost(s.MessageHistory, cost)
+		durations[i] = turn.Duration()
+		totalCost += costs[i]
+		totalTools += turn.ToolCalls
+		totalDuration += durations[i]
+		if ts.MostExpensive < 0 || costs[i] > ts.MaxCost {
+			ts.MostExpensive = i
+			ts.MaxCost = costs[i]
+		}
+	}
+
+	n := len(s.Turns)
+	ts.AvgCost = totalCost / float64(n)
+	ts.AvgToolCalls = float64(totalTools) / float64(n)
+	ts.AvgDuration = totalDuration / time.Duration(n)
+
+	sort.Float64s(costs)
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	if n%2 == 1 {
+		ts.MedianCost = costs[n/2]
+		ts.MedianDuration = durations[n/2]
+	} else {
+		ts.MedianCost = (costs[n/2-1] + costs[n/2]) / 2
+		ts.MedianDuration = (durations[n/2-1] + durations[n/2]) / 2
+	}
+	return ts
+}
+
+// buildTurns splits a message history into turns. A new turn starts at every user
+// prompt; messages before the first prompt (e.g. in a tail-loaded session) form their own turn.
+func buildTurns(history []Message) []Turn {
+	var turns []Turn
+	for i, msg := range history {
+		if len(turns) == 0 || msg.Type == "prompt" {
+			if len(turns) > 0 {
+				turns[len(turns)-1].End = i
+			}
+			turns = append(turns, Turn{
+				Index:     len(turns) + 1,
+				Start:     i,
+				StartTime: msg.Timestamp,
+			})
+		}
+
+		turn := &turns[len(turns)-1]
+		if !msg.Timestamp.IsZero() {
+			turn.EndTime = msg.Timestamp
+		}
+		turn.ToolCalls += len(msg.Calls())
+		turn.Tokens.Add(msg)
+	}
+	if len(turns) > 0 {
+		turns[len(turns)-1].End = len(history)
+	}
+	return turns
+}
+
+// TurnAt returns the index into Turns of the turn containing the message at historyIdx, or -1
+func (s *SessionStats) TurnAt(historyIdx int) int {
+	for i, t := range s.Turns {
+		if historyIdx >= t.Start && historyIdx < t.End {
+			return i
+		}
+	}
+	return -1
+}
+
+// Activity is what dominated a turn, as shown on the session minimap
+type Activity int
+
+const (
+	ActivityUser  Activity = iota // Claude has not answered the prompt yet
+	ActivityText                  // Mostly text replies
+	ActivityTools                 // Mostly tool calls
+	ActivityError                 // At least one tool call failed
+)
+
+// Activity classifies the turn by its messages: an error if any tool result failed,
+// tool-heavy if tool calls outnumber text replies, a text reply if Claude answered
+// at all, and a user turn otherwise
+func (t Turn) Activity(history []Message) Activity {
+	var texts, tools int
+	for _, msg := range t.Messages(history) {
+		switch {
+		case msg.IsError:
+			return ActivityError
+		case msg.Type != "assistant_response":
+		case msg.ToolName != "":
+			tools += len(msg.Calls())
+		default:
+			texts++
+		}
+	}
+	switch {
+	case tools > texts:
+		return ActivityTools
+	case texts+tools > 0:
+		return ActivityText
+	}
+	return ActivityUser
+}
+
+// Activities returns the activity of each of the session's turns
+func (s *SessionStats) Activities() []Activity {
+	activities := make([]Activity, len(s.Turns))
+	for i, t := range s.Turns {
+		activities[i] = t.Activity(s.MessageHistory)
+	}
+	return activities
+}
@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBuildTurns tests turn boundaries and per-turn aggregates
+func TestBuildTurns(t *testing.T) {
+	base := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	history := []Message{
+		{Type: "assistant_response", Timestamp: at(0), OutputTokens: 5},                     // before any prompt
+		{Type: "prompt", Role: "user", Timestamp: at(10)},                                   // turn 2
+		{Type: "assistant_response", Timestamp: at(12), ToolName: "Read", InputTokens: 100}, //
+		{Type: "tool_result", Role: "user", Timestamp: at(13)},                              // not a boundary
+		{Type: "assistant_response", Timestamp: at(48), OutputTokens: 50, CacheRead: 1000},  //
+		{Type: "prompt", Role: "user", Timestamp: at(60)},                                   // turn 3
+		{Type: "assistant_response", Timestamp: at(61), ToolName: "Bash", CacheCreation: 7}, //
+	}
+
+	turns := buildTurns(history)
+	if len(turns) != 3 {
+		t.Fatalf("got %d turns, want 3", len(turns))
+	}
+
+	tests := []struct {
+		name                  string
+		turn                  Turn
+		start, end, toolCalls int
+		tokens                int
+		duration              time.Duration
+	}{
+		{"leading", turns[0], 0, 1, 0, 5, 0},
+		{"with tools", turns[1], 1, 5, 1, 150, 38 * time.Second},
+		{"last", turns[2], 5, 7, 1, 7, time.Second},
+	}
+	for i, tt := range tests {
+		if tt.turn.Index != i+1 {
+			t.Errorf("%s: Index = %d, want %d", tt.name, tt.turn.Index, i+1)
+		}
+		if tt.turn.Start != tt.start || tt.turn.End != tt.end {
+			t.Errorf("%s: range [%d,%d), want [%d,%d)", tt.name, tt.turn.Start, tt.turn.End, tt.start, tt.end)
+		}
+		if tt.turn.ToolCalls != tt.toolCalls {
+			t.Errorf("%s: ToolCalls = %d, want %d", tt.name, tt.turn.ToolCalls, tt.toolCalls)
+		}
+		if tt.turn.Tokens.Total() != tt.tokens {
+			t.Errorf("%s: Tokens = %d, want %d", tt.name, tt.turn.Tokens.Total(), tt.tokens)
+		}
+		if tt.turn.Duration() != tt.duration {
+			t.Errorf("%s: Duration = %v, want %v", tt.name, tt.turn.Duration(), tt.duration)
+		}
+	}
+
+	stats := &SessionStats{MessageHistory: history, Turns: turns}
+	if got := stats.TurnAt(3); got != 1 {
+		t.Errorf("TurnAt(3) = %d, want 1", got)
+	}
+	if got := stats.TurnAt(99); got != -1 {
+		t.Errorf("TurnAt(99) = %d, want -1", got)
+	}
+	if len(buildTurns(nil)) != 0 {
+		t.Error("empty history should have no turns")
+	}
+}
+
+// TestTurnStats tests averages, medians and the most expensive turn
+func TestTurnStats(t *testing.T) {
+	base := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	stats := &SessionStats{MessageHistory: []Message{
+		{Type: "prompt", Timestamp: at(0)},
+		{Type: "assistant_response", Timestamp: at(10), ToolName: "Read", OutputTokens: 100},
+		{Type: "prompt", Timestamp: at(20)},
+		{Type: "assistant_response", Timestamp: at(50), ToolName: "Edit", OutputTokens: 400},
+		{Type: "assistant_response", Timestamp: at(60), ToolName: "Bash", OutputTokens: 100},
+		{Type: "prompt", Timestamp: at(100)},
+		{Type: "assistant_response", Timestamp: at(120), OutputTokens: 200},
+	}}
+	stats.Turns = buildTurns(stats.MessageHistory)
+
+	// One dollar per output token keeps the arithmetic readable
+	cost := func(m *Message) float64 { return float64(m.OutputTokens) }
+	got := stats.TurnStats(cost)
+
+	want := TurnStats{
+		Turns:          3,
+		AvgCost:        800.0 / 3,
+		MedianCost:     200,
+		AvgToolCalls:   1,
+		AvgDuration:    70 * time.Second / 3,
+		MedianDuration: 20 * time.Second,
+		MostExpensive:  1,
+		MaxCost:        500,
+	}
+	if got != want {
+		t.Errorf("TurnStats() = %+v, want %+v", got, want)
+	}
+
+	if empty := (&SessionStats{}).TurnStats(cost); empty.Turns != 0 || empty.MostExpensive != -1 {
+		t.Errorf("TurnStats() on empty session = %+v", empty)
+	}
+}
+
+// TestTurnActivity tests the classification of turns for the minimap
+func TestTurnActivity(t *testing.T) {
+	prompt := Message{Type: "prompt", Role: "user"}
+	text := Message{Type: "assistant_response"}
+	tool := Message{Type: "assistant_response", ToolName: "Bash"}
+	result := Message{Type: "tool_result", Role: "user"}
+	failed := Message{Type: "tool_result", Role: "user", IsError: true}
+
+	tests := []struct {
+		name    string
+		history []Message
+		want    Activity
+	}{
+		{"unanswered", []Message{prompt}, ActivityUser},
+		{"text reply", []Message{prompt, text}, ActivityText},
+		{"tool-heavy", []Message{prompt, tool, result, tool, result, text}, ActivityTools},
+		{"as many tools as texts", []Message{prompt, tool, result, text}, ActivityText},
+		{"failed tool call", []Message{prompt, tool, failed, text}, ActivityError},
+	}
+	for _, tt := range tests {
+		turn := Turn{Start: 0, End: len(tt.history)}
+		if got := turn.Activity(tt.history); got != tt.want {
+			t.Errorf("%s: Activity = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
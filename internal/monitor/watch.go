@@ -0,0 +1,196 @@
+package monitor
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchChange is a batch of changes seen by a Watcher
+type WatchChange struct {
+	Projects bool     // Project directories were created, removed or renamed
+	Dirs     []string // Watched project directories whose session files were created, removed or renamed
+}
+
+// Watcher reports projects and session files appearing, disappearing or being renamed
+// under ProjectsDir. It watches the projects directory itself and the project
+// directories set with SetDirs, not the whole tree: on macOS each watched directory
+// also holds a descriptor for every file in it. Writes to session files are not
+// reported. Changes arriving within the debounce interval are sent as one.
+type Watcher struct {
+	fs       *fsnotify.Watcher
+	debounce time.Duration
+	changes  chan WatchChange
+	errs     chan error
+	done     chan struct{}
+
+	mu         sync.Mutex
+	root       string          // Projects directory, once it could be watched
+	dirs       map[string]bool // Watched project directories
+	errsClosed bool            // errs is closed; no more errors may be sent
+	closing    sync.Once
+}
+
+// watchErrorBuffer is how many errors may wait to be read from Errors before further
+// ones are dropped
+const watchErrorBuffer = 8
+
+// NewWatcher starts watching the projects directory. If it does not exist yet, it is
+// picked up by the next SetDirs.
+func NewWatcher(debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		fs:       fsw,
+		debounce: debounce,
+		changes:  make(chan WatchChange),
+		errs:     make(chan error, watchErrorBuffer),
+		done:     make(chan struct{}),
+		dirs:     make(map[string]bool),
+	}
+	w.SetDirs(nil)
+	go w.run()
+	return w, nil
+}
+
+// Changes delivers the batches of changes; it is closed by Close
+func (w *Watcher) Changes() <-chan WatchChange {
+	return w.changes
+}
+
+// Errors delivers failures to watch, e.g. a project directory that cannot be watched
+// or an overflowing event queue; it is closed by Close. Errors nobody reads are dropped.
+func (w *Watcher) Errors() <-chan error {
+	return w.errs
+}
+
+// SetDirs watches exactly the given project directories besides the projects
+// directory. A nil Watcher ignores the call.
+func (w *Watcher) SetDirs(dirs []string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.root == "" {
+		if root, err := ProjectsDir(); err == nil && w.fs.Add(root) == nil {
+			w.root = filepath.Clean(root)
+		}
+	}
+	want := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		want[filepath.Clean(dir)] = true
+	}
+	for dir := range w.dirs {
+		if !want[dir] {
+			w.fs.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+	for dir := range want {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.fs.Add(dir); err != nil {
+			logger.Debug("cannot watch project directory", "op", "watch", "path", dir, "err", err)
+			if !w.errsClosed {
+				w.sendError(fmt.Errorf("cannot watch %s: %w", dir, err))
+			}
+			continue
+		}
+		w.dirs[dir] = true
+	}
+}
+
+// Close stops watching and closes Changes. A nil Watcher ignores the call.
+func (w *Watcher) Close() error {
+	if w == nil {
+		return nil
+	}
+	var err error
+	w.closing.Do(func() {
+		close(w.done)
+		err = w.fs.Close()
+	})
+	return err
+}
+
+// run collects events until the debounce interval has passed without new ones, then
+// offers the batch on changes, merging further events into it while it waits
+func (w *Watcher) run() {
+	defer close(w.changes)
+	defer func() {
+		w.mu.Lock()
+		close(w.errs)
+		w.errsClosed = true
+		w.mu.Unlock()
+	}()
+	var pending WatchChange
+	var settle <-chan time.Time
+	ready := false
+	for {
+		var out chan WatchChange
+		if ready {
+			out = w.changes
+		}
+		select {
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if w.record(&pending, ev) {
+				settle, ready = time.After(w.debounce), false
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			logger.Debug("watch error", "op", "watch", "err", err)
+			w.sendError(err)
+		case <-settle:
+			settle, ready = nil, true
+		case out <- pending:
+			pending, ready = WatchChange{}, false
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// sendError offers err on errs, dropping it if the buffer is full; errs must be open
+func (w *Watcher) sendError(err error) {
+	select {
+	case w.errs <- err:
+	default:
+	}
+}
+
+// record adds an event to the batch and reports whether it is one to tell about
+func (w *Watcher) record(batch *WatchChange, ev fsnotify.Event) bool {
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+		return false
+	}
+	dir := filepath.Dir(ev.Name)
+	w.mu.Lock()
+	root, watched := w.root, w.dirs[dir]
+	w.mu.Unlock()
+
+	switch {
+	case dir == root:
+		batch.Projects = true
+	case watched && IsSessionFile(filepath.Base(ev.Name)):
+		if !slices.Contains(batch.Dirs, dir) {
+			batch.Dirs = append(batch.Dirs, dir)
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextChange waits for the watcher's next batch of changes
+func nextChange(t *testing.T, w *Watcher) WatchChange {
+	t.Helper()
+	select {
+	case change := <-w.Changes():
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+	return WatchChange{}
+}
+
+// TestWatcher tests that new projects and session files are reported, debounced into
+// one batch, and that writes and files in unwatched projects are not
+func TestWatcher(t *testing.T) {
+	projects := t.TempDir()
+	SetProjectsDir(projects)
+	t.Cleanup(func() { SetProjectsDir("") })
+	api := filepath.Join(projects, "-work-api")
+	web := filepath.Join(projects, "-work-web")
+	for _, dir := range []string{api, web} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := NewWatcher(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.SetDirs([]string{api})
+
+	if err := os.Mkdir(filepath.Join(projects, "-work-new"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if change := nextChange(t, w); !change.Projects || len(change.Dirs) != 0 {
+		t.Errorf("new project = %+v, want Projects only", change)
+	}
+
+	// Two sessions and a note in the watched project, a session in an unwatched one
+	for _, path := range []string{
+		filepath.Join(api, "a.jsonl"),
+		filepath.Join(api, "b.jsonl"),
+		filepath.Join(api, "notes.txt"),
+		filepath.Join(web, "c.jsonl"),
+	} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if change := nextChange(t, w); change.Projects || !slices.Equal(change.Dirs, []string{api}) {
+		t.Errorf("new sessions = %+v, want one batch for %s", change, api)
+	}
+
+	// Appending to a session is not a change to the list
+	f, err := os.OpenFile(filepath.Join(api, "a.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{}\n")
+	f.Close()
+	if err := os.Remove(filepath.Join(api, "b.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+	if change := nextChange(t, w); !slices.Equal(change.Dirs, []string{api}) {
+		t.Errorf("removed session = %+v, want %s", change, api)
+	}
+
+	w.Close()
+	if _, ok := <-w.Changes(); ok {
+		t.Error("Changes still open after Close")
+	}
+}
+
+// TestWatcherErrors tests that a project directory that cannot be watched is reported
+// on Errors, and that Close closes it
+func TestWatcherErrors(t *testing.T) {
+	projects := t.TempDir()
+	SetProjectsDir(projects)
+	t.Cleanup(func() { SetProjectsDir("") })
+
+	w, err := NewWatcher(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetDirs([]string{filepath.Join(projects, "-work-gone")})
+	select {
+	case err := <-w.Errors():
+		if err == nil || !strings.Contains(err.Error(), "-work-gone") {
+			t.Errorf("error = %v, want one naming the directory", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no error reported")
+	}
+
+	w.Close()
+	select {
+	case _, ok := <-w.Errors():
+		if ok {
+			t.Error("Errors delivered an error after Close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Errors not closed")
+	}
+	w.SetDirs([]string{filepath.Join(projects, "-work-gone")}) // Must not send on the closed channel
+}
@@ -8,7 +8,9 @@ package monitor
 import "C"
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"unsafe"
 )
 
@@ -33,5 +35,10 @@ func getWorkingDir(pid int32) (string, error) {
 
 	// Extract the current working directory path from vip_cdir
 	cwd := C.GoString(&pathInfo.pvi_cdir.vip_path[0])
+
+	// The kernel keeps reporting the old path of a deleted or unmounted directory
+	if _, err := os.Stat(cwd); errors.Is(err, os.ErrNotExist) {
+		return cwd, fmt.Errorf("%w: %s", ErrWorkDirGone, cwd)
+	}
 	return cwd, nil
 }
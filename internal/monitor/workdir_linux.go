@@ -3,8 +3,10 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // getWorkingDir retrieves the current working directory of a process on Linux
@@ -22,8 +24,16 @@ func getWorkingDir(pid int32) (string, error) {
 		return "", fmt.Errorf("cannot read cwd: %w", err)
 	}
 
+	// The kernel marks a deleted directory with a suffix; an unmounted one fails to stat
+	if path, ok := strings.CutSuffix(cwd, " (deleted)"); ok {
+		return path, fmt.Errorf("%w: %s", ErrWorkDirGone, path)
+	}
+
 	// Verify it's actually a directory
 	info, err := os.Stat(cwd)
+	if errors.Is(err, os.ErrNotExist) {
+		return cwd, fmt.Errorf("%w: %s", ErrWorkDirGone, cwd)
+	}
 	if err != nil {
 		return "", fmt.Errorf("cannot stat cwd: %w", err)
 	}
@@ -0,0 +1,200 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// writeTools maps the tools that write files to the input field naming the file
+var writeTools = map[string]string{
+	"Write":        "file_path",
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"NotebookEdit": "notebook_path",
+}
+
+// WrittenFile returns the file a tool call writes as given to the tool, or "" if the
+// tool writes none. input is the tool input as JSON, as in Message.ToolInput.
+func WrittenFile(tool, input string) string {
+	field, ok := writeTools[tool]
+	if !ok || input == "" {
+		return ""
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(input), &fields); err != nil {
+		return ""
+	}
+	path, _ := fields[field].(string)
+	return path
+}
+
+// writeInput holds the fields of Write, Edit and MultiEdit inputs that carry text
+type writeInput struct {
+	Content   string `json:"content"`
+	OldString string `json:"old_string"`
+	NewString string `json:"new_string"`
+	Edits     []struct {
+		OldString string `json:"old_string"`
+		NewString string `json:"new_string"`
+	} `json:"edits"`
+}
+
+// parseWriteInput parses a tool input as JSON, or returns false if it is none
+func parseWriteInput(input string) (writeInput, bool) {
+	var fields writeInput
+	if input == "" || json.Unmarshal([]byte(input), &fields) != nil {
+		return writeInput{}, false
+	}
+	return fields, true
+}
+
+// EditLine returns the 1-based line of content, a file's current text, at which a
+// Write, Edit or MultiEdit call left its change: the first line holding the first
+// non-blank line of the new text, of the last edit for MultiEdit; 1 for a Write. It
+// returns 0 when the line cannot be found, e.g. after the text was changed again or
+// for edits that only delete.
+func EditLine(tool, input string, content []byte) int {
+	fields, ok := parseWriteInput(input)
+	if !ok {
+		return 0
+	}
+	text := ""
+	switch tool {
+	case "Write":
+		return 1
+	case "Edit":
+		text = fields.NewString
+	case "MultiEdit":
+		if len(fields.Edits) > 0 {
+			text = fields.Edits[len(fields.Edits)-1].NewString
+		}
+	}
+	needle := ""
+	for _, line := range strings.Split(text, "\n") {
+		if needle = strings.TrimSpace(line); needle != "" {
+			break
+		}
+	}
+	if needle == "" {
+		return 0
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.Contains(line, needle) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// ToolPath returns the file or directory a tool call works on as given to the tool,
+// from the file_path, notebook_path or path field of its input, or "" if it names none
+func ToolPath(input string) string {
+	if input == "" {
+		return ""
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(input), &fields); err != nil {
+		return ""
+	}
+	for _, field := range []string{"file_path", "notebook_path", "path"} {
+		if path, ok := fields[field].(string); ok && path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// fileWrite is a file a tool call wrote, the working directory it ran in and the
+// lines it added and removed (see CallDiff)
+type fileWrite struct {
+	path    string
+	cwd     string
+	added   int
+	removed int
+}
+
+// callWrite returns the file a tool call writes, or false if it writes none
+func callWrite(tool, input, cwd string) (fileWrite, bool) {
+	path := WrittenFile(tool, input)
+	if path == "" {
+		return fileWrite{}, false
+	}
+	added, removed := CallDiff(tool, input)
+	return fileWrite{path: path, cwd: cwd, added: added, removed: removed}, true
+}
+
+// entryWrites returns the files written by the tool calls of an assistant entry
+func entryWrites(line []byte, cwd string) []fileWrite {
+	if !bytes.Contains(line, []byte(`"tool_use"`)) {
+		return nil
+	}
+	var entry struct {
+		Message struct {
+			Content []struct {
+				Type  string          `json:"type"`
+				Name  string          `json:"name"`
+				Input json.RawMessage `json:"input"`
+			} `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil // Content is plain text
+	}
+	var writes []fileWrite
+	for _, item := range entry.Message.Content {
+		if item.Type != "tool_use" {
+			continue
+		}
+		if w, ok := callWrite(item.Name, string(item.Input), cwd); ok {
+			writes = append(writes, w)
+		}
+	}
+	return writes
+}
+
+// historyWrites returns the files written by the tool calls in a message history
+func historyWrites(history []Message) []fileWrite {
+	var writes []fileWrite
+	for _, msg := range history {
+		for _, call := range msg.Calls() {
+			if w, ok := callWrite(call.Name, call.Input, msg.WorkingDir); ok {
+				writes = append(writes, w)
+			}
+		}
+	}
+	return writes
+}
+
+// outsideWorkDir resolves the written files and returns those outside the session's
+// working directory, each once in order of first write. Relative paths are taken
+// from the directory the tool ran in, else from workDir.
+func outsideWorkDir(workDir string, writes []fileWrite) []string {
+	if workDir == "" {
+		return nil
+	}
+	root := ResolvePath(workDir, "")
+	var outside []string
+	for _, w := range writes {
+		cwd := w.cwd
+		if cwd == "" {
+			cwd = workDir
+		}
+		path := ResolvePath(w.path, cwd)
+		if !IsUnderDir(path, root) && !slices.Contains(outside, path) {
+			outside = append(outside, path)
+		}
+	}
+	return outside
+}
+
+// workingDir returns the working directory of the first message that records one
+func (s *SessionStats) workingDir() string {
+	for _, msg := range s.MessageHistory {
+		if msg.WorkingDir != "" {
+			return msg.WorkingDir
+		}
+	}
+	return ""
+}
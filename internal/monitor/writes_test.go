@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWrittenFile(t *testing.T) {
+	tests := []struct {
+		tool  string
+		input string
+		want  string
+	}{
+		{"Write", `{"file_path":"/src/app/main.go","content":"package main"}`, "/src/app/main.go"},
+		{"Edit", `{"file_path":"main.go","old_string":"a","new_string":"b"}`, "main.go"},
+		{"MultiEdit", `{"file_path":"~/.bashrc","edits":[]}`, "~/.bashrc"},
+		{"NotebookEdit", `{"notebook_path":"/src/app/a.ipynb","new_source":""}`, "/src/app/a.ipynb"},
+		{"Read", `{"file_path":"/etc/hosts"}`, ""},
+		{"Bash", `{"command":"echo > /etc/hosts"}`, ""},
+		{"Write", "", ""},
+		{"Write", "not json", ""},
+	}
+	for _, tt := range tests {
+		if got := WrittenFile(tt.tool, tt.input); got != tt.want {
+			t.Errorf("WrittenFile(%q, %q) = %q, want %q", tt.tool, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestEditLine(t *testing.T) {
+	content := []byte("package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n")
+	tests := []struct {
+		name  string
+		tool  string
+		input string
+		want  int
+	}{
+		{"edit", "Edit", `{"file_path":"main.go","old_string":"\tprintln()","new_string":"\n\tfmt.Println(\"hi\")"}`, 6},
+		{"last edit of a multi-edit", "MultiEdit", `{"file_path":"main.go","edits":[{"old_string":"a","new_string":"func main() {"},{"old_string":"b","new_string":"import \"fmt\""}]}`, 3},
+		{"write starts at the top", "Write", `{"file_path":"main.go","content":"package main\n"}`, 1},
+		{"edit that only deletes", "Edit", `{"file_path":"main.go","old_string":"// TODO\n","new_string":""}`, 0},
+		{"text changed since", "Edit", `{"file_path":"main.go","old_string":"a","new_string":"fmt.Printf(\"%d\", n)"}`, 0},
+		{"not a write", "Read", `{"file_path":"main.go"}`, 0},
+		{"not json", "Edit", "not json", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EditLine(tt.tool, tt.input, content); got != tt.want {
+				t.Errorf("EditLine = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolPath(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"file_path":"/src/app/main.go"}`, "/src/app/main.go"},
+		{`{"notebook_path":"a.ipynb"}`, "a.ipynb"},
+		{`{"pattern":"TODO","path":"/src/app/internal"}`, "/src/app/internal"},
+		{`{"command":"ls"}`, ""},
+		{`{"file_path":""}`, ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ToolPath(tt.input); got != tt.want {
+			t.Errorf("ToolPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestOutsideWrites(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(root, "repo")
+	other := filepath.Join(root, "other")
+	for _, dir := range []string{repo, other} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(other, filepath.Join(repo, "vendor")); err != nil {
+		t.Fatal(err)
+	}
+
+	toolUse := func(tool, path string) string {
+		return fmt.Sprintf(`{"type":"assistant","cwd":%q,"timestamp":"2026-01-12T09:00:00Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t","name":%q,"input":{"file_path":%q}}]}}`, repo, tool, path)
+	}
+	lines := []string{
+		toolUse("Write", "main.go"),                             // Inside, relative
+		toolUse("Edit", filepath.Join(repo, "a", "b.go")),       // Inside, absolute
+		toolUse("Edit", "../other/x.go"),                        // Outside, relative
+		toolUse("Write", filepath.Join(repo, "vendor", "y.go")), // Outside, through a symlink
+		toolUse("Read", "/etc/hosts"),                           // Not a write
+		toolUse("Edit", "../other/x.go"),                        // Listed once
+	}
+	sessionFile := filepath.Join(root, "session.jsonl")
+	var data []byte
+	for _, line := range lines {
+		data = append(data, line+"\n"...)
+	}
+	if err := os.WriteFile(sessionFile, data, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	want := []string{filepath.Join(other, "x.go"), filepath.Join(other, "y.go")}
+
+	stats, err := ParseSessionFile(sessionFile)
+	if err != nil {
+		t.Fatalf("ParseSessionFile failed: %v", err)
+	}
+	if !slices.Equal(stats.OutsideWrites, want) {
+		t.Errorf("OutsideWrites: got %q, want %q", stats.OutsideWrites, want)
+	}
+
+	metadata, err := GetSessionMetadata(sessionFile)
+	if err != nil {
+		t.Fatalf("GetSessionMetadata failed: %v", err)
+	}
+	if !slices.Equal(metadata.OutsideWrites, want) {
+		t.Errorf("metadata OutsideWrites: got %q, want %q", metadata.OutsideWrites, want)
+	}
+
+	var p ProjectStats
+	cost := func(*Message) float64 { return 0 }
+	p.Add(stats, false, cost)
+	p.Add(&SessionStats{}, false, cost)
+	if p.Outside != 1 {
+		t.Errorf("ProjectStats.Outside = %d, want 1", p.Outside)
+	}
+}
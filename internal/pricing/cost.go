@@ -0,0 +1,84 @@
+package pricing
+
+import "time"
+
+// Usage is the token usage of one API response
+type Usage struct {
+	Input      int // Uncached input tokens
+	Output     int
+	CacheWrite int // Tokens written to the prompt cache
+	// CacheWrite1h is the part of CacheWrite written to the 1-hour cache rather than
+	// the 5-minute one
+	CacheWrite1h int
+	CacheRead    int // Tokens read from the prompt cache
+}
+
+// Cost breaks down what a response cost in USD by kind of token
+type Cost struct {
+	Input      float64
+	Output     float64
+	CacheWrite float64
+	CacheRead  float64
+	// Savings is what the cache reads saved over paying the input rate for them
+	Savings float64
+}
+
+// Total returns the cost of all the response's tokens
+func (c Cost) Total() float64 {
+	return c.Input + c.Output + c.CacheWrite + c.CacheRead
+}
+
+// perMillion converts a count of tokens and a price per million tokens to USD
+func perMillion(tokens int, price float64) float64 {
+	return float64(tokens) * price / 1_000_000
+}
+
+// CacheWriteRate returns the price per million tokens written to the 5-minute prompt
+// cache, or with oneHour to the 1-hour cache, which costs twice the input rate
+func (r Rates) CacheWriteRate(oneHour bool) float64 {
+	if oneHour {
+		return 2 * r.Input
+	}
+	return r.CacheWrite
+}
+
+// Price returns the cost of usage at these rates. Cache writes are priced at the
+// write rate of their cache, not the input rate.
+func (r Rates) Price(u Usage) Cost {
+	c := Cost{
+		Input:  perMillion(u.Input, r.Input),
+		Output: perMillion(u.Output, r.Output),
+		CacheWrite: perMillion(u.CacheWrite-u.CacheWrite1h, r.CacheWriteRate(false)) +
+			perMillion(u.CacheWrite1h, r.CacheWriteRate(true)),
+		CacheRead: perMillion(u.CacheRead, r.CacheRead),
+	}
+	c.Savings = perMillion(u.CacheRead, r.Input) - c.CacheRead
+	return c
+}
+
+// Price returns the cost of a response's usage at the rates of its model at a time;
+// see Table.Lookup
+func (t Table) Price(u Usage, model string, at time.Time) Cost {
+	rates, _ := t.Lookup(model, at)
+	return rates.Price(u)
+}
+
+// PriceMessage returns the cost of a response's usage at the rates of its model on the
+// day it was sent, from the built-in table with the user's overrides
+func PriceMessage(u Usage, model string, at time.Time) Cost {
+	return current.Price(u, model, at)
+}
+
+// RewriteCost returns what writing tokens to the prompt cache again cost over reading
+// them from it, at these rates; see CacheWriteRate
+func (r Rates) RewriteCost(tokens int, oneHour bool) float64 {
+	return perMillion(tokens, r.CacheWriteRate(oneHour)-r.CacheRead)
+}
+
+// RewriteCost returns what rewriting tokens to the prompt cache cost over reading them,
+// at the rates of a model on the day of the rewrite, from the built-in table with the
+// user's overrides; see Rates.RewriteCost
+func RewriteCost(tokens int, oneHour bool, model string, at time.Time) float64 {
+	rates, _ := current.Lookup(model, at)
+	return rates.RewriteCost(tokens, oneHour)
+}
@@ -0,0 +1,108 @@
+package pricing
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/")
+
+// TestPriceGolden pins the exact cost of representative responses under the built-in
+// table, so that price changes show up as a reviewed diff of testdata/costs.golden
+func TestPriceGolden(t *testing.T) {
+	turn := Usage{Input: 12, Output: 340, CacheWrite: 1_200, CacheRead: 48_000}
+	tests := []struct {
+		name  string
+		model string
+		at    time.Time
+		usage Usage
+	}{
+		{"typical turn", "claude-opus-4-5-20251101", day(2026, time.January, 12), turn},
+		{"same turn on opus 4.1", "claude-opus-4-1-20250805", day(2026, time.January, 12), turn},
+		{"unknown opus before the cut", "claude-opus-5", day(2025, time.November, 23), turn},
+		{"unknown opus after the cut", "claude-opus-5", day(2025, time.November, 24), turn},
+		{"fresh context", "claude-sonnet-4-5-20250929", day(2026, time.January, 12), Usage{Input: 3, Output: 1_800, CacheWrite: 62_000}},
+		{"fresh context in the 1-hour cache", "claude-sonnet-4-5-20250929", day(2026, time.January, 12), Usage{Input: 3, Output: 1_800, CacheWrite: 62_000, CacheWrite1h: 62_000}},
+		{"long answer", "claude-sonnet-4-5-20250929", day(2026, time.January, 12), Usage{Input: 8, Output: 32_000, CacheRead: 140_000}},
+		{"subagent", "claude-haiku-4-5-20251001", day(2026, time.January, 12), Usage{Input: 2_400, Output: 610, CacheRead: 18_000}},
+		{"unknown model", "<synthetic>", day(2026, time.January, 12), turn},
+		{"no usage", "claude-opus-4-5-20251101", day(2026, time.January, 12), Usage{}},
+	}
+	var b strings.Builder
+	for _, tt := range tests {
+		c := Builtin.Price(tt.usage, tt.model, tt.at)
+		fmt.Fprintf(&b, "%s: %s on %s, %+v\n", tt.name, tt.model, tt.at.Format(time.DateOnly), tt.usage)
+		fmt.Fprintf(&b, "  input $%.6f  output $%.6f  cache write $%.6f  cache read $%.6f\n", c.Input, c.Output, c.CacheWrite, c.CacheRead)
+		fmt.Fprintf(&b, "  total $%.6f  saved $%.6f\n", c.Total(), c.Savings)
+	}
+
+	path := filepath.Join("testdata", "costs.golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./internal/pricing -update to create it)", err)
+	}
+	if b.String() != string(want) {
+		t.Errorf("costs differ from %s (run with -update to accept)\ngot:\n%s\nwant:\n%s", path, b.String(), want)
+	}
+}
+
+// TestPriceMessage tests that costs follow the user's overrides and price cache writes
+// at the rate of their cache
+func TestPriceMessage(t *testing.T) {
+	t.Cleanup(func() { SetOverrides(nil) })
+	SetOverrides([]Override{{Match: "claude-next", Period: Period{Rates: Rates{Input: 2, Output: 10, CacheWrite: 4, CacheRead: 0.5}}}})
+
+	c := PriceMessage(Usage{Input: 1_000_000, Output: 100_000, CacheWrite: 500_000, CacheRead: 2_000_000}, "claude-next-1", time.Time{})
+	want := Cost{Input: 2, Output: 1, CacheWrite: 2, CacheRead: 1, Savings: 3}
+	if c != want {
+		t.Errorf("PriceMessage = %+v, want %+v", c, want)
+	}
+	if c.Total() != 6 {
+		t.Errorf("Total = %v, want 6", c.Total())
+	}
+
+	// A fifth of the writes go to the 1-hour cache at twice the input rate, like their rewrites
+	c = PriceMessage(Usage{CacheWrite: 500_000, CacheWrite1h: 100_000}, "claude-next-1", time.Time{})
+	if want := 1.6 + 0.4; fmt.Sprintf("%.6f", c.CacheWrite) != fmt.Sprintf("%.6f", want) {
+		t.Errorf("cache writes with 1-hour writes cost %v, want %v", c.CacheWrite, want)
+	}
+	if rewrite := RewriteCost(100_000, true, "claude-next-1", time.Time{}) + perMillion(100_000, 0.5); fmt.Sprintf("%.6f", rewrite) != "0.400000" {
+		t.Errorf("1-hour rewrite plus read = %v, want the 1-hour write price 0.4", rewrite)
+	}
+}
+
+// TestRewriteCost tests that rewrites cost the write rate of their cache less the read
+// rate, twice the input rate for the 1-hour cache
+func TestRewriteCost(t *testing.T) {
+	t.Cleanup(func() { SetOverrides(nil) })
+	SetOverrides([]Override{{Match: "claude-next", Period: Period{Rates: Rates{Input: 2, Output: 10, CacheWrite: 2.5, CacheRead: 0.2}}}})
+
+	tests := []struct {
+		tokens  int
+		oneHour bool
+		want    float64
+	}{
+		{1_000_000, false, 2.3},
+		{1_000_000, true, 3.8},
+		{0, false, 0},
+	}
+	for _, tt := range tests {
+		if got := RewriteCost(tt.tokens, tt.oneHour, "claude-next-1", time.Time{}); fmt.Sprintf("%.6f", got) != fmt.Sprintf("%.6f", tt.want) {
+			t.Errorf("RewriteCost(%d, %v) = %v, want %v", tt.tokens, tt.oneHour, got, tt.want)
+		}
+	}
+}
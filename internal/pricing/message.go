@@ -0,0 +1,50 @@
+package pricing
+
+import "github.com/thieso2/promptwatch/internal/monitor"
+
+// PriceResponse returns what a parsed message cost at the rates of its model on the
+// day it was sent. Only API responses carry usage; other messages cost nothing.
+func PriceResponse(msg *monitor.Message) Cost {
+	if msg.Type != "assistant_response" {
+		return Cost{}
+	}
+	return PriceMessage(Usage{
+		Input:        msg.InputTokens,
+		Output:       msg.OutputTokens,
+		CacheWrite:   msg.CacheCreation,
+		CacheWrite1h: msg.CacheWrite1h,
+		CacheRead:    msg.CacheRead,
+	}, msg.Model, msg.Timestamp)
+}
+
+// MessageCost returns the cost of a single message in USD; see PriceResponse
+func MessageCost(msg *monitor.Message) float64 {
+	return PriceResponse(msg).Total()
+}
+
+// SessionCost sums the cost of all messages in a session
+func SessionCost(stats *monitor.SessionStats) float64 {
+	var total float64
+	for i := range stats.MessageHistory {
+		total += MessageCost(&stats.MessageHistory[i])
+	}
+	return total
+}
+
+// CumulativeCosts returns, for every MessageHistory index, the session cost up to and
+// including that message
+func CumulativeCosts(stats *monitor.SessionStats) []float64 {
+	totals := make([]float64, len(stats.MessageHistory))
+	total := 0.0
+	for i := range stats.MessageHistory {
+		total += MessageCost(&stats.MessageHistory[i])
+		totals[i] = total
+	}
+	return totals
+}
+
+// RewarmCost returns what a cache re-warm after a gap cost over reading the cache, at
+// the rates of its model on the day it happened
+func RewarmCost(r monitor.CacheRewarm) float64 {
+	return RewriteCost(r.Tokens, r.OneHour, r.Model, r.At)
+}
@@ -0,0 +1,39 @@
+package pricing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// TestSessionCost tests that only responses are priced, including their 1-hour cache
+// writes, and that the running totals add up to the session cost
+func TestSessionCost(t *testing.T) {
+	t.Cleanup(func() { SetOverrides(nil) })
+	SetOverrides([]Override{{Match: "claude-next", Period: Period{Rates: Rates{Input: 2, Output: 10, CacheWrite: 2.5, CacheRead: 0.2}}}})
+
+	at := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	stats := &monitor.SessionStats{MessageHistory: []monitor.Message{
+		{Type: "prompt", Model: "claude-next-1", Timestamp: at, InputTokens: 1_000_000},
+		{Type: "assistant_response", Model: "claude-next-1", Timestamp: at, InputTokens: 1_000_000, OutputTokens: 100_000},
+		{Type: "assistant_response", Model: "claude-next-1", Timestamp: at, CacheCreation: 1_000_000, CacheWrite1h: 1_000_000},
+	}}
+
+	costs := []float64{0, 3, 4}
+	for i, want := range costs {
+		if got := MessageCost(&stats.MessageHistory[i]); fmt.Sprintf("%.6f", got) != fmt.Sprintf("%.6f", want) {
+			t.Errorf("MessageCost of message %d = %v, want %v", i, got, want)
+		}
+	}
+	if got := SessionCost(stats); fmt.Sprintf("%.6f", got) != "7.000000" {
+		t.Errorf("SessionCost = %v, want 7", got)
+	}
+	if got := CumulativeCosts(stats); len(got) != 3 || fmt.Sprintf("%.6f %.6f", got[1], got[2]) != "3.000000 7.000000" {
+		t.Errorf("CumulativeCosts = %v, want [0 3 7]", got)
+	}
+	if got := RewarmCost(monitor.CacheRewarm{Tokens: 1_000_000, OneHour: true, Model: "claude-next-1", At: at}); fmt.Sprintf("%.6f", got) != "3.800000" {
+		t.Errorf("RewarmCost = %v, want 3.8", got)
+	}
+}
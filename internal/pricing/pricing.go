@@ -0,0 +1,160 @@
+// Package pricing holds Claude API token prices per model together with the dates
+// they took effect, so that a message is costed at the rates of the day it was sent.
+package pricing
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rates are prices in USD per million tokens
+type Rates struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cacheWrite"` // Writes to the 5-minute prompt cache
+	CacheRead  float64 `json:"cacheRead"`
+}
+
+// Period is a model's rates from a date on
+type Period struct {
+	From time.Time // First day the rates apply (UTC midnight); zero for "since the start"
+	Rates
+}
+
+// Model is the price history of the models whose ID contains Match
+type Model struct {
+	Match   string
+	Periods []Period // Oldest first
+}
+
+// Table is a versioned set of price histories. The entry with the longest Match
+// contained in a model ID prices it; models matching no entry get Fallback.
+type Table struct {
+	Version  string // Date of the latest price change the table knows about
+	Models   []Model
+	Fallback Rates
+}
+
+// Override sets the rates of the models whose ID contains Match from a date on
+type Override struct {
+	Match string
+	Period
+}
+
+// day returns midnight UTC of a date given as year, month and day
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Rates of the model generations, shared by the entries below
+var (
+	opus3     = Rates{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50}
+	opus45    = Rates{Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50}
+	sonnet    = Rates{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30}
+	haiku3    = Rates{Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03}
+	haiku35   = Rates{Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08}
+	haiku45   = Rates{Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10}
+	opus45Day = day(2025, time.November, 24)
+)
+
+// Builtin is the price table shipped with promptwatch. Known model IDs have fixed
+// prices; the family entries ("opus", "haiku") price model IDs not listed yet at the
+// family's rates of the time.
+var Builtin = Table{
+	Version: "2025-11-24",
+	Models: []Model{
+		{Match: "claude-3-opus", Periods: []Period{{Rates: opus3}}},
+		{Match: "claude-opus-4-2025", Periods: []Period{{Rates: opus3}}}, // claude-opus-4-20250514
+		{Match: "claude-opus-4-1", Periods: []Period{{Rates: opus3}}},
+		{Match: "claude-opus-4-5", Periods: []Period{{Rates: opus45}}},
+		{Match: "opus", Periods: []Period{{Rates: opus3}, {From: opus45Day, Rates: opus45}}},
+		{Match: "sonnet", Periods: []Period{{Rates: sonnet}}},
+		{Match: "claude-3-haiku", Periods: []Period{{Rates: haiku3}}},
+		{Match: "claude-3-5-haiku", Periods: []Period{{Rates: haiku35}}},
+		{Match: "claude-haiku-4-5", Periods: []Period{{Rates: haiku45}}},
+		{Match: "haiku", Periods: []Period{{Rates: haiku35}, {From: day(2025, time.October, 15), Rates: haiku45}}},
+	},
+	Fallback: sonnet,
+}
+
+// Lookup returns the rates for a model at a time, and whether they are historical:
+// superseded by a later period of the same model. A zero time gets the current
+// rates; a time before a model's first period gets the earliest rates known.
+func (t Table) Lookup(model string, at time.Time) (rates Rates, historical bool) {
+	best := -1
+	for i, m := range t.Models {
+		if strings.Contains(model, m.Match) && (best < 0 || len(m.Match) > len(t.Models[best].Match)) {
+			best = i
+		}
+	}
+	if best < 0 || len(t.Models[best].Periods) == 0 {
+		return t.Fallback, false
+	}
+
+	periods := t.Models[best].Periods
+	i := len(periods) - 1
+	if !at.IsZero() {
+		for i > 0 && at.Before(periods[i].From) {
+			i--
+		}
+	}
+	return periods[i].Rates, i < len(periods)-1
+}
+
+// With returns a copy of the table with the overrides layered on top. An override
+// replaces the period of its model starting on the same date, or adds a period; an
+// override for a Match not in the table adds a model.
+func (t Table) With(overrides []Override) Table {
+	layered := Table{Version: t.Version, Fallback: t.Fallback, Models: make([]Model, len(t.Models))}
+	for i, m := range t.Models {
+		layered.Models[i] = Model{Match: m.Match, Periods: append([]Period(nil), m.Periods...)}
+	}
+
+	for _, o := range overrides {
+		mi := -1
+		for i, m := range layered.Models {
+			if m.Match == o.Match {
+				mi = i
+			}
+		}
+		if mi < 0 {
+			layered.Models = append(layered.Models, Model{Match: o.Match})
+			mi = len(layered.Models) - 1
+		}
+
+		m := &layered.Models[mi]
+		replaced := false
+		for i := range m.Periods {
+			if m.Periods[i].From.Equal(o.From) {
+				m.Periods[i] = o.Period
+				replaced = true
+			}
+		}
+		if !replaced {
+			m.Periods = append(m.Periods, o.Period)
+			sort.SliceStable(m.Periods, func(i, j int) bool { return m.Periods[i].From.Before(m.Periods[j].From) })
+		}
+	}
+	return layered
+}
+
+// current is the table costs are computed with: Builtin with the user's overrides
+var current = Builtin
+
+// SetOverrides layers the user's price overrides on top of the built-in table for
+// all later lookups
+func SetOverrides(overrides []Override) {
+	current = Builtin.With(overrides)
+}
+
+// Lookup returns the rates for a model at a time from the built-in table with the
+// user's overrides; see Table.Lookup
+func Lookup(model string, at time.Time) (rates Rates, historical bool) {
+	return current.Lookup(model, at)
+}
+
+// Version returns the version of the built-in price table
+func Version() string {
+	return current.Version
+}
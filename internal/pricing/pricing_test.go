@@ -0,0 +1,110 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	cut := day(2026, time.March, 1)
+	table := Table{
+		Version: "test",
+		Models: []Model{
+			{Match: "opus", Periods: []Period{{Rates: Rates{Input: 15}}, {From: cut, Rates: Rates{Input: 5}}}},
+			{Match: "claude-opus-9", Periods: []Period{{From: cut, Rates: Rates{Input: 9}}}},
+			{Match: "empty"},
+		},
+		Fallback: Rates{Input: 3},
+	}
+	tests := []struct {
+		name           string
+		model          string
+		at             time.Time
+		wantInput      float64
+		wantHistorical bool
+	}{
+		{name: "before the change", model: "claude-opus-5", at: cut.Add(-time.Nanosecond), wantInput: 15, wantHistorical: true},
+		{name: "on the first day", model: "claude-opus-5", at: cut, wantInput: 5},
+		{name: "after the change", model: "claude-opus-5", at: cut.AddDate(1, 0, 0), wantInput: 5},
+		{name: "zero time is current", model: "claude-opus-5", wantInput: 5},
+		{name: "longest match wins", model: "claude-opus-9-20260301", at: cut, wantInput: 9},
+		{name: "before the first period", model: "claude-opus-9", at: cut.AddDate(0, 0, -1), wantInput: 9},
+		{name: "unknown model", model: "gpt-4", at: cut, wantInput: 3},
+		{name: "no model", wantInput: 3},
+		{name: "model without periods", model: "empty", wantInput: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates, historical := table.Lookup(tt.model, tt.at)
+			if rates.Input != tt.wantInput || historical != tt.wantHistorical {
+				t.Errorf("Lookup(%q, %v) = %v, %v; want input %v, %v", tt.model, tt.at, rates, historical, tt.wantInput, tt.wantHistorical)
+			}
+		})
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	tests := []struct {
+		model      string
+		at         time.Time
+		wantOutput float64
+	}{
+		{"claude-opus-4-20250514", day(2025, time.June, 1), 75},
+		{"claude-opus-4-1-20250805", day(2025, time.December, 1), 75},
+		{"claude-opus-4-5-20251101", day(2025, time.December, 1), 25},
+		{"claude-opus-5", day(2025, time.November, 23), 75}, // Unknown opus, family rates of the day
+		{"claude-opus-5", day(2025, time.November, 24), 25},
+		{"claude-sonnet-4-5-20250929", day(2025, time.October, 1), 15},
+		{"claude-3-5-haiku-20241022", day(2025, time.October, 1), 4},
+		{"claude-haiku-4-5-20251001", day(2025, time.October, 20), 5},
+		{"<synthetic>", day(2025, time.October, 20), 15},
+	}
+	for _, tt := range tests {
+		if rates, _ := Builtin.Lookup(tt.model, tt.at); rates.Output != tt.wantOutput {
+			t.Errorf("Lookup(%q, %s): output $%v, want $%v", tt.model, tt.at.Format(time.DateOnly), rates.Output, tt.wantOutput)
+		}
+	}
+
+	for _, m := range Builtin.Models {
+		for i := 1; i < len(m.Periods); i++ {
+			if !m.Periods[i-1].From.Before(m.Periods[i].From) {
+				t.Errorf("%s: periods out of order", m.Match)
+			}
+		}
+	}
+}
+
+func TestWith(t *testing.T) {
+	cut := day(2026, time.March, 1)
+	base := Table{Models: []Model{
+		{Match: "opus", Periods: []Period{{Rates: Rates{Input: 15}}, {From: cut, Rates: Rates{Input: 5}}}},
+	}}
+	layered := base.With([]Override{
+		{Match: "opus", Period: Period{From: cut, Rates: Rates{Input: 4}}},                    // Replaces
+		{Match: "opus", Period: Period{From: cut.AddDate(0, 6, 0), Rates: Rates{Input: 2}}},   // Adds a later period
+		{Match: "opus", Period: Period{From: cut.AddDate(0, -6, 0), Rates: Rates{Input: 10}}}, // Adds an earlier one
+		{Match: "claude-next", Period: Period{Rates: Rates{Input: 50}}},                       // Adds a model
+	})
+
+	tests := []struct {
+		model     string
+		at        time.Time
+		wantInput float64
+	}{
+		{"claude-opus-5", cut.AddDate(-1, 0, 0), 15},
+		{"claude-opus-5", cut.AddDate(0, -1, 0), 10},
+		{"claude-opus-5", cut, 4},
+		{"claude-opus-5", cut.AddDate(1, 0, 0), 2},
+		{"claude-next-1", cut, 50},
+	}
+	for _, tt := range tests {
+		if rates, _ := layered.Lookup(tt.model, tt.at); rates.Input != tt.wantInput {
+			t.Errorf("Lookup(%q, %s): input %v, want %v", tt.model, tt.at.Format(time.DateOnly), rates.Input, tt.wantInput)
+		}
+	}
+
+	// The base table is left alone
+	if rates, _ := base.Lookup("claude-opus-5", cut); rates.Input != 5 || len(base.Models) != 1 {
+		t.Errorf("With changed the base table: %+v", base)
+	}
+}
@@ -0,0 +1,241 @@
+// Package profile bundles the settings a user builds up — color theme, message filter
+// presets and UI state — into one portable file, to carry them to another machine.
+// Caches and logs are left out.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/fsutil"
+	"github.com/thieso2/promptwatch/internal/state"
+)
+
+// Version is the profile format written by Export
+const Version = 1
+
+// Sections of a profile, which Import merges or replaces one by one
+var Sections = []string{"theme", "presets", "state"}
+
+// Profile is the portable file written by Export
+type Profile struct {
+	Version int                   `json:"version"`
+	Theme   string                `json:"theme,omitempty"`   // Only if the config file sets one
+	Presets []config.FilterPreset `json:"presets,omitempty"` // filters.presets of the config file
+	State   *state.State          `json:"state,omitempty"`
+}
+
+// Export builds the profile from the config file at configPath and the state file at
+// statePath. Missing files contribute nothing.
+func Export(configPath, statePath string) (*Profile, error) {
+	p := &Profile{Version: Version}
+	raw, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := raw["theme"]; ok {
+		if err := json.Unmarshal(data, &p.Theme); err != nil {
+			return nil, fmt.Errorf("cannot read theme from %s: %w", configPath, err)
+		}
+	}
+	if p.Presets, err = presets(raw); err != nil {
+		return nil, fmt.Errorf("cannot read filter presets from %s: %w", configPath, err)
+	}
+	st, err := state.Load(statePath)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(*st, state.State{}) {
+		p.State = st
+	}
+	return p, nil
+}
+
+// Read parses a profile file written by Export
+func Read(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read profile: %w", err)
+	}
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("cannot parse profile %s: %w", path, err)
+	}
+	if p.Version < 1 || p.Version > Version {
+		return nil, fmt.Errorf("profile %s has version %d, this promptwatch reads version %d", path, p.Version, Version)
+	}
+	return &p, nil
+}
+
+// Import applies a profile to the config file at configPath and the state file at
+// statePath. Sections listed in replace overwrite the local settings; the others are
+// merged, keeping local settings where both differ. It returns a line for each change
+// and each conflict. Nothing is written if the merged config would be invalid.
+func Import(p *Profile, configPath, statePath string, replace []string) ([]string, error) {
+	for _, section := range replace {
+		if !slices.Contains(Sections, section) {
+			return nil, fmt.Errorf("unknown section %q (want one of %s)", section, strings.Join(Sections, ", "))
+		}
+	}
+	raw, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var report []string
+	configChanged := false
+
+	// Theme
+	if p.Theme != "" {
+		var local string
+		if data, ok := raw["theme"]; ok {
+			json.Unmarshal(data, &local)
+		}
+		switch {
+		case local == p.Theme:
+		case local == "" || slices.Contains(replace, "theme"):
+			raw["theme"], _ = json.Marshal(p.Theme)
+			configChanged = true
+			report = append(report, fmt.Sprintf("theme: set to %s", p.Theme))
+		default:
+			report = append(report, fmt.Sprintf("theme: kept %s, the profile has %s (replace with -replace theme)", local, p.Theme))
+		}
+	}
+
+	// Filter presets, matched by name
+	if len(p.Presets) > 0 {
+		local, err := presets(raw)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read filter presets from %s: %w", configPath, err)
+		}
+		var merged []config.FilterPreset
+		if slices.Contains(replace, "presets") {
+			merged = p.Presets
+			report = append(report, fmt.Sprintf("presets: replaced %d with %d", len(local), len(p.Presets)))
+		} else {
+			merged = slices.Clone(local)
+			for _, preset := range p.Presets {
+				i := slices.IndexFunc(merged, func(f config.FilterPreset) bool { return f.Name == preset.Name })
+				switch {
+				case i < 0:
+					merged = append(merged, preset)
+					report = append(report, fmt.Sprintf("presets: added %q", preset.Name))
+				case !reflect.DeepEqual(merged[i], preset):
+					report = append(report, fmt.Sprintf("presets: kept the local %q, which differs from the profile's (replace with -replace presets)", preset.Name))
+				}
+			}
+		}
+		if !reflect.DeepEqual(merged, local) {
+			if err := setPresets(raw, merged); err != nil {
+				return nil, err
+			}
+			configChanged = true
+		}
+	}
+
+	// UI state
+	var newState *state.State
+	if p.State != nil {
+		local, _ := state.Load(statePath) // A corrupt state file is replaced, as state.Update does
+		merged := *p.State
+		if !slices.Contains(replace, "state") {
+			merged = mergeState(*local, *p.State)
+		}
+		if !reflect.DeepEqual(merged, *local) {
+			newState = &merged
+			report = append(report, "state: updated")
+		}
+	}
+
+	if configChanged {
+		data, err := json.MarshalIndent(raw, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode config: %w", err)
+		}
+		if _, err := config.Parse(data, configPath); err != nil {
+			return nil, fmt.Errorf("profile not imported: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+			return nil, fmt.Errorf("cannot create config directory: %w", err)
+		}
+		if err := fsutil.WriteAtomic(configPath, append(data, '\n'), 0644); err != nil {
+			return nil, fmt.Errorf("cannot save config: %w", err)
+		}
+	}
+	if newState != nil {
+		if err := state.Save(statePath, newState); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// mergeState fills the settings local leaves unset from imported; column widths are
+// taken per table
+func mergeState(local, imported state.State) state.State {
+	merged := local
+	if merged.RefreshInterval == 0 {
+		merged.RefreshInterval = imported.RefreshInterval
+	}
+	merged.CompactHeader = local.CompactHeader || imported.CompactHeader
+	if merged.View == "" {
+		merged.View = imported.View
+	}
+	if len(imported.ColumnShares) > 0 {
+		merged.ColumnShares = maps.Clone(imported.ColumnShares)
+		maps.Copy(merged.ColumnShares, local.ColumnShares)
+	}
+	return merged
+}
+
+// readConfig reads the config file at path as its top-level keys, leaving the values
+// as written so that an import changes only what it sets; a missing file has no keys
+func readConfig(path string) (map[string]json.RawMessage, error) {
+	raw := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return raw, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot parse config file %s: %w", path, err)
+	}
+	return raw, nil
+}
+
+// presets returns the filters.presets of a config file's top-level keys
+func presets(raw map[string]json.RawMessage) ([]config.FilterPreset, error) {
+	var filters config.FiltersConfig
+	if data, ok := raw["filters"]; ok {
+		if err := json.Unmarshal(data, &filters); err != nil {
+			return nil, err
+		}
+	}
+	return filters.Presets, nil
+}
+
+// setPresets replaces filters.presets in a config file's top-level keys, keeping any
+// other filters settings
+func setPresets(raw map[string]json.RawMessage, presets []config.FilterPreset) error {
+	filters := make(map[string]json.RawMessage)
+	if data, ok := raw["filters"]; ok {
+		if err := json.Unmarshal(data, &filters); err != nil {
+			return fmt.Errorf("cannot read filters from config: %w", err)
+		}
+	}
+	var err error
+	if filters["presets"], err = json.Marshal(presets); err != nil {
+		return fmt.Errorf("cannot encode filter presets: %w", err)
+	}
+	raw["filters"], err = json.Marshal(filters)
+	return err
+}
@@ -0,0 +1,161 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/state"
+)
+
+// machine is a config and state file pair, as on one computer
+type machine struct {
+	config, state string
+}
+
+func newMachine(t *testing.T, configJSON string, st *state.State) machine {
+	t.Helper()
+	dir := t.TempDir()
+	m := machine{config: filepath.Join(dir, "config.json"), state: filepath.Join(dir, "state.json")}
+	if configJSON != "" {
+		if err := os.WriteFile(m.config, []byte(configJSON), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st != nil {
+		if err := state.Save(m.state, st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+// roundTrip exports the profile of from and reads it back as the other machine would
+func roundTrip(t *testing.T, from machine) *Profile {
+	t.Helper()
+	p, err := Export(from.config, from.state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "profile.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return read
+}
+
+// TestImportMerge tests that a merge adds what the other machine lacks, keeps its own
+// settings where both differ, and leaves the rest of its config file alone
+func TestImportMerge(t *testing.T) {
+	laptop := newMachine(t, `{
+		"theme": "colorblind",
+		"filters": {"presets": [{"name": "errors", "match": {"isError": true}}, {"name": "edits", "match": {"tool": "Edit"}}]}
+	}`, &state.State{RefreshInterval: state.Duration(5 * time.Second), View: "recent", ColumnShares: map[string]int{"processes": 40}})
+	desktop := newMachine(t, `{
+		"theme": "high-contrast",
+		"recent": {"days": 14},
+		"filters": {"presets": [{"name": "edits", "match": {"tool": "Write"}}]}
+	}`, &state.State{ColumnShares: map[string]int{"processes": 30}})
+
+	report, err := Import(roundTrip(t, laptop), desktop.config, desktop.state, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"theme: kept high-contrast, the profile has colorblind (replace with -replace theme)",
+		`presets: added "errors"`,
+		`presets: kept the local "edits", which differs from the profile's (replace with -replace presets)`,
+		"state: updated",
+	}
+	if !slices.Equal(report, want) {
+		t.Errorf("report = %q, want %q", report, want)
+	}
+
+	cfg, err := config.Load(desktop.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme != "high-contrast" || cfg.Recent.Days != 14 {
+		t.Errorf("theme %q, recent.days %d; want the desktop's high-contrast and 14", cfg.Theme, cfg.Recent.Days)
+	}
+	var names []string
+	for _, p := range cfg.Filters.Presets {
+		names = append(names, p.Name+"="+p.Match.Tool)
+	}
+	if want := []string{"edits=Write", "errors="}; !slices.Equal(names, want) {
+		t.Errorf("presets = %v, want %v", names, want)
+	}
+
+	st, err := state.Load(desktop.state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(st.RefreshInterval) != 5*time.Second || st.View != "recent" || st.ColumnShares["processes"] != 30 {
+		t.Errorf("state = %+v, want the laptop's interval and view with the desktop's column width", st)
+	}
+
+	// Importing again changes nothing but reports the same conflicts
+	report, err = Import(roundTrip(t, laptop), desktop.config, desktop.state, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 {
+		t.Errorf("second import = %q, want only the two conflicts", report)
+	}
+}
+
+// TestImportReplace tests that replaced sections take the profile's settings
+func TestImportReplace(t *testing.T) {
+	laptop := newMachine(t, `{"theme": "colorblind", "filters": {"presets": [{"name": "errors", "match": {"isError": true}}]}}`, nil)
+	desktop := newMachine(t, `{"theme": "high-contrast", "filters": {"presets": [{"name": "edits", "match": {"tool": "Edit"}}]}}`, nil)
+
+	if _, err := Import(roundTrip(t, laptop), desktop.config, desktop.state, []string{"theme", "presets"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(desktop.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme != "colorblind" || len(cfg.Filters.Presets) != 1 || cfg.Filters.Presets[0].Name != "errors" {
+		t.Errorf("config = theme %q, presets %+v; want the laptop's", cfg.Theme, cfg.Filters.Presets)
+	}
+	if _, err := os.Stat(desktop.state); !os.IsNotExist(err) {
+		t.Errorf("state file written although the profile has no state: %v", err)
+	}
+
+	if _, err := Import(roundTrip(t, laptop), desktop.config, desktop.state, []string{"keymap"}); err == nil || !strings.Contains(err.Error(), "unknown section") {
+		t.Errorf("Import with an unknown section = %v, want an error", err)
+	}
+}
+
+// TestImportInvalid tests that an import that would break the config file writes nothing
+func TestImportInvalid(t *testing.T) {
+	var presets []string
+	for i := range config.MaxFilterPresets {
+		presets = append(presets, fmt.Sprintf(`{"name": "p%d", "match": {}}`, i))
+	}
+	original := `{"filters": {"presets": [` + strings.Join(presets, ",") + `]}}`
+	laptop := newMachine(t, `{"filters": {"presets": [{"name": "extra", "match": {}}]}}`, nil)
+	desktop := newMachine(t, original, nil)
+
+	if _, err := Import(roundTrip(t, laptop), desktop.config, desktop.state, nil); err == nil {
+		t.Fatal("Import past the preset limit succeeded")
+	}
+	if data, _ := os.ReadFile(desktop.config); string(data) != original {
+		t.Errorf("config file changed to %s", data)
+	}
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/fsutil"
+)
+
+// HistoryLimit is how many session opens the history file keeps; older ones are dropped
+// as new ones are recorded
+const HistoryLimit = 500
+
+// Visit is one opening of a session in promptwatch
+type Visit struct {
+	Path string    `json:"path"` // Session file
+	At   time.Time `json:"at"`
+}
+
+// HistoryPath returns the location of the history file, next to the state file at
+// statePath (~/.local/state/promptwatch/history.json)
+func HistoryPath(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), "history.json")
+}
+
+// LoadHistory reads the session opens recorded at path, oldest first. A missing file
+// yields none. A file that cannot be parsed also yields none, together with an error
+// wrapping ErrCorrupt.
+func LoadHistory(path string) ([]Visit, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read history file: %w", err)
+	}
+	var visits []Visit
+	if err := json.Unmarshal(data, &visits); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrCorrupt, path, err)
+	}
+	return visits, nil
+}
+
+// RecordVisit appends a session open to the history file at path, keeping the latest
+// HistoryLimit, and replaces the file atomically. Like Update it holds a lock, so
+// concurrent opens are all recorded. A file that cannot be parsed is started over.
+func RecordVisit(path string, v Visit) error {
+	return locked(path, func() error { return recordVisit(path, v) })
+}
+
+// recordVisit implements RecordVisit under the lock
+func recordVisit(path string, v Visit) error {
+	visits, _ := LoadHistory(path)
+	visits = append(visits, v)
+	visits = visits[max(len(visits)-HistoryLimit, 0):]
+
+	data, err := json.Marshal(visits)
+	if err != nil {
+		return fmt.Errorf("cannot encode history: %w", err)
+	}
+	if err := fsutil.WriteAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("cannot save history: %w", err)
+	}
+	return nil
+}
+
+// RecentVisits returns the latest open of each session, newest first, at most n
+func RecentVisits(visits []Visit, n int) []Visit {
+	var recent []Visit
+	seen := make(map[string]bool)
+	for i := len(visits) - 1; i >= 0 && len(recent) < n; i-- {
+		if !seen[visits[i].Path] {
+			seen[visits[i].Path] = true
+			recent = append(recent, visits[i])
+		}
+	}
+	return recent
+}
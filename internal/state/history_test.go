@@ -0,0 +1,87 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRecordVisit tests that session opens are appended, capped at HistoryLimit, and
+// that a corrupt history file is started over
+func TestRecordVisit(t *testing.T) {
+	path := HistoryPath(filepath.Join(t.TempDir(), "nested", "state.json"))
+	start := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	for i := range HistoryLimit + 3 {
+		v := Visit{Path: fmt.Sprintf("/p/s%d.jsonl", i), At: start.Add(time.Duration(i) * time.Minute)}
+		if err := RecordVisit(path, v); err != nil {
+			t.Fatalf("RecordVisit %d: %v", i, err)
+		}
+	}
+	visits, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visits) != HistoryLimit || visits[0].Path != "/p/s3.jsonl" || !visits[len(visits)-1].At.Equal(start.Add((HistoryLimit+2)*time.Minute)) {
+		t.Errorf("history holds %d visits from %+v to %+v", len(visits), visits[0], visits[len(visits)-1])
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"path":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if visits, err := LoadHistory(path); !errors.Is(err, ErrCorrupt) || visits != nil {
+		t.Errorf("LoadHistory of a corrupt file = %v, %v; want none and ErrCorrupt", visits, err)
+	}
+	if err := RecordVisit(path, Visit{Path: "/p/new.jsonl", At: start}); err != nil {
+		t.Fatal(err)
+	}
+	if visits, err := LoadHistory(path); err != nil || len(visits) != 1 {
+		t.Errorf("history after a corrupt file = %v, %v; want the new visit only", visits, err)
+	}
+}
+
+// TestConcurrentVisits tests that sessions opened at the same time are all recorded
+func TestConcurrentVisits(t *testing.T) {
+	path := HistoryPath(filepath.Join(t.TempDir(), "state.json"))
+	start := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := RecordVisit(path, Visit{Path: fmt.Sprintf("/p/s%d.jsonl", i), At: start}); err != nil {
+				t.Errorf("RecordVisit %d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if visits, err := LoadHistory(path); err != nil || len(visits) != 20 {
+		t.Errorf("history holds %d of 20 visits: %v", len(visits), err)
+	}
+}
+
+// TestRecentVisits tests that each session is listed once, at its latest open
+func TestRecentVisits(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2026, 1, 12, 9, minute, 0, 0, time.UTC) }
+	visits := []Visit{{"/a", at(1)}, {"/b", at(2)}, {"/a", at(3)}, {"/c", at(4)}, {"/b", at(5)}}
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{20, "[/b@5 /c@4 /a@3]"},
+		{2, "[/b@5 /c@4]"},
+		{0, "[]"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, v := range RecentVisits(visits, tt.n) {
+			got = append(got, fmt.Sprintf("%s@%d", v.Path, v.At.Minute()))
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("RecentVisits(%d) = %v, want %s", tt.n, got, tt.want)
+		}
+	}
+}
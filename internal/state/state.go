@@ -0,0 +1,129 @@
+// Package state persists small bits of UI state (such as the chosen refresh interval)
+// between promptwatch runs.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/thieso2/promptwatch/internal/fsutil"
+)
+
+// State is the persisted UI state
+type State struct {
+	RefreshInterval Duration `json:"refreshInterval,omitempty"`
+	CompactHeader   bool     `json:"compactHeader,omitempty"` // Session detail header collapsed to one line
+	// ColumnShares is the percentage of the width given to each table's adjustable
+	// column (WORKDIR, PROJECT), keyed by table ("processes", "projects")
+	ColumnShares map[string]int `json:"columnShares,omitempty"`
+	// View is the last top-level view shown ("processes", "projects" or "recent"), to
+	// start in next time
+	View string `json:"view,omitempty"`
+}
+
+// Duration is a time.Duration stored as a string such as "2s" in JSON
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string such as "1.5s"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultPath returns the location of the state file (~/.local/state/promptwatch/state.json)
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot get home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "promptwatch", "state.json"), nil
+}
+
+// ErrCorrupt marks a state file that exists but cannot be parsed, e.g. one cut short by
+// a crash of an older version that did not write it atomically
+var ErrCorrupt = errors.New("corrupt state file")
+
+// Load reads the state file at path. A missing file yields an empty state. A file that
+// cannot be read or parsed also yields an empty state, together with the error, so
+// callers can warn and carry on.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return &State{}, fmt.Errorf("cannot read state file: %w", err)
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return &State{}, fmt.Errorf("%w %s: %v", ErrCorrupt, path, err)
+	}
+	return &st, nil
+}
+
+// Save writes the state to path, replacing the previous file atomically
+func Save(path string, st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create state directory: %w", err)
+	}
+
+	if err := fsutil.WriteAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("cannot save state: %w", err)
+	}
+	return nil
+}
+
+// updateMu serializes the read-modify-write cycles of this process; the file lock in
+// locked serializes them with other promptwatch instances
+var updateMu sync.Mutex
+
+// locked runs fn while holding both the package lock and the lock file next to path,
+// so concurrent updates of one file cannot drop each other's changes
+func locked(path string, fn func() error) error {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create state directory: %w", err)
+	}
+	unlock, err := fsutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+// Update loads the state at path, applies fn and saves the result, holding a lock so
+// that concurrent updates, e.g. from two quick key presses, each keep the other's
+// change. A state file that cannot be parsed is replaced rather than blocking the
+// update.
+func Update(path string, fn func(*State)) error {
+	return locked(path, func() error {
+		st, _ := Load(path)
+		fn(st)
+		return Save(path, st)
+	})
+}
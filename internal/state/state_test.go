@@ -0,0 +1,142 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLoadMissingFile tests that a missing state file yields an empty state
+func TestLoadMissingFile(t *testing.T) {
+	st, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if st.RefreshInterval != 0 {
+		t.Errorf("RefreshInterval: got %v, want 0", time.Duration(st.RefreshInterval))
+	}
+}
+
+// TestUpdateRoundTrip tests that updates are persisted and survive a reload
+func TestUpdateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	if err := Update(path, func(st *State) { st.RefreshInterval = Duration(5 * time.Second) }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+	if want := `"refreshInterval": "5s"`; !strings.Contains(string(data), want) {
+		t.Errorf("state file %s does not contain %s", data, want)
+	}
+
+	st, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := time.Duration(st.RefreshInterval); got != 5*time.Second {
+		t.Errorf("RefreshInterval: got %v, want 5s", got)
+	}
+
+	// A later update keeps what it does not touch
+	if err := Update(path, func(st *State) { st.ColumnShares = map[string]int{"processes": 45} }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	st, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if st.ColumnShares["processes"] != 45 || time.Duration(st.RefreshInterval) != 5*time.Second {
+		t.Errorf("state after second update = %+v", st)
+	}
+}
+
+// TestConcurrentUpdates tests that updates of different fields running at the same time,
+// as the UI's save commands do, all end up in the file
+func TestConcurrentUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, func(st *State) {
+				if st.ColumnShares == nil {
+					st.ColumnShares = make(map[string]int)
+				}
+				st.ColumnShares[fmt.Sprintf("table%d", i)] = i
+			})
+			if err != nil {
+				t.Errorf("Update %d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	st, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.ColumnShares) != 20 {
+		t.Errorf("state holds %d of 20 shares: %v", len(st.ColumnShares), st.ColumnShares)
+	}
+}
+
+// TestUpdateReplacesCorruptFile tests that a corrupt state file does not block saving
+func TestUpdateReplacesCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if st, err := Load(path); !errors.Is(err, ErrCorrupt) || st == nil || !reflect.DeepEqual(*st, State{}) {
+		t.Errorf("Load = %+v, %v; want an empty state and ErrCorrupt", st, err)
+	}
+	if err := Update(path, func(st *State) { st.RefreshInterval = Duration(time.Second) }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if st, err := Load(path); err != nil || time.Duration(st.RefreshInterval) != time.Second {
+		t.Errorf("state not replaced: %+v, %v", st, err)
+	}
+}
+
+// TestLoadPartiallyWrittenFile tests that a state file cut off mid-write falls back to an
+// empty state instead of failing, and that the next save replaces it
+func TestLoadPartiallyWrittenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := Save(path, &State{RefreshInterval: Duration(2 * time.Second), CompactHeader: true}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{0, 1, len(data) / 2, len(data) - 2} {
+		if err := os.WriteFile(path, data[:n], 0644); err != nil {
+			t.Fatal(err)
+		}
+		st, err := Load(path)
+		if st == nil || !reflect.DeepEqual(*st, State{}) {
+			t.Errorf("Load of %d of %d bytes = %+v, want an empty state", n, len(data), st)
+		}
+		if err == nil {
+			t.Errorf("Load of %d of %d bytes: want an error to warn about", n, len(data))
+		}
+	}
+
+	if err := Update(path, func(st *State) { st.CompactHeader = true }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if st, err := Load(path); err != nil || !st.CompactHeader {
+		t.Errorf("state after Update = %+v, %v; want CompactHeader", st, err)
+	}
+}
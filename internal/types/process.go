@@ -4,12 +4,14 @@ import "time"
 
 // ClaudeProcess represents a monitored Claude instance with its metrics
 type ClaudeProcess struct {
-	PID        int32
-	CPUPercent float64
-	MemoryMB   float64
-	WorkingDir string
-	Command    string
-	Uptime     time.Duration
-	StartTime  time.Time
-	IsHelper   bool // MCP helper vs main instance
+	PID         int32
+	CPUPercent  float64
+	MemoryMB    float64
+	WorkingDir  string
+	Command     string
+	Uptime      time.Duration
+	StartTime   time.Time
+	IsHelper    bool // MCP helper vs main instance
+	WorkDirGone bool // WorkingDir was deleted or unmounted while the process kept running
+	NoSessions  bool // No project under ~/.claude/projects belongs to WorkingDir yet
 }
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// confirmation is a yes/no question shown over the current view, e.g. before an action
+// that cannot be undone. While it is showing it receives every key: one of keys runs
+// onConfirm, n or esc dismisses it, and anything else is swallowed.
+type confirmation struct {
+	message     string               // Question, e.g. "Overwrite session.md?"
+	keys        []string             // Keys that confirm; the first is shown in the prompt
+	destructive bool                 // Shown in alert colors
+	onConfirm   func(*Model) tea.Cmd // Runs the action once confirmed
+}
+
+// askConfirmation shows c until it is answered, replacing any question still open
+func (m *Model) askConfirmation(c confirmation) {
+	if len(c.keys) == 0 {
+		c.keys = []string{"y"}
+	}
+	m.confirm = &c
+}
+
+// updateConfirmation handles a key while a confirmation is showing. ctrl+c still quits.
+func (m *Model) updateConfirmation(msg tea.KeyMsg) tea.Cmd {
+	c := m.confirm
+	switch key := msg.String(); {
+	case slices.Contains(c.keys, key):
+		m.confirm = nil
+		return c.onConfirm(m)
+	case key == "n" || key == "esc":
+		m.confirm = nil
+	case key == "ctrl+c":
+		m.confirm = nil
+		m.Shutdown()
+		m.quitting = true
+		return tea.Quit
+	}
+	return nil
+}
+
+// renderConfirmation renders the open question below view
+func (m Model) renderConfirmation(view string) string {
+	return render.Confirmation(view, m.confirm.message, m.confirm.keys[0], m.confirm.destructive)
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// runProgram runs m in a program through teatest
+func runProgram(t *testing.T, m Model) *teatest.TestModel {
+	t.Helper()
+	return teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+}
+
+// waitOutput waits until the program has drawn all of wants since the last wait
+func waitOutput(t *testing.T, tm *teatest.TestModel, wants ...string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		view := ansi.Strip(string(out))
+		for _, want := range wants {
+			if !strings.Contains(view, want) {
+				return false
+			}
+		}
+		return true
+	}, teatest.WithDuration(5*time.Second))
+}
+
+// finalModel returns the model the program ended with and stops its background work
+func finalModel(t *testing.T, tm *teatest.TestModel) Model {
+	t.Helper()
+	m := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(Model)
+	m.Shutdown()
+	return m
+}
+
+// TestConfirmQuitProgram tests the quit question in a running program: it swallows
+// other keys until n dismisses it, and y ends the program
+func TestConfirmQuitProgram(t *testing.T) {
+	// No process discovery or ticks redrawing the view in between
+	cfg := config.Default()
+	cfg.NoProcesses = true
+	m := NewModel(time.Hour, false).WithConfig(cfg).WithQuitConfirmation(true)
+	m.loadingSession = true
+	tm := runProgram(t, m)
+
+	tm.Type("q")
+	waitOutput(t, tm, "Really quit? (y/N)")
+	tm.Type("?") // Swallowed: would show the full help
+	tm.Type("n")
+	tm.Type("?") // Reaches the view again
+	waitOutput(t, tm, "?: Less")
+	tm.Type("q")
+	waitOutput(t, tm, "Really quit? (y/N)")
+	tm.Type("y")
+
+	final := finalModel(t, tm)
+	if !final.quitting || final.confirm != nil || !final.showFullHelp {
+		t.Errorf("after y: quitting %v, confirmation %+v, full help %v; want quitting with only the second ? shown",
+			final.quitting, final.confirm, final.showFullHelp)
+	}
+}
+
+// TestConfirmOverwriteProgram tests the overwrite question of an export in a running
+// program: it swallows other keys until esc dismisses it without leaving the view, and y
+// writes the export over the existing file
+func TestConfirmOverwriteProgram(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.NoProcesses = true
+	cfg.Export = config.ExportConfig{Format: "markdown", Dir: dir}
+	m := NewModel(time.Hour, false).WithConfig(cfg)
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{
+		FilePath:       "/projects/-app/3f2a9c1e.jsonl",
+		MessageHistory: []monitor.Message{{Type: "prompt", Role: "user", Content: "run the tests"}},
+		Turns:          []monitor.Turn{{Index: 1, Start: 0, End: 1}},
+	}
+	m.updateMessageTable()
+
+	// Exports are named by the second; claim this one and the next
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(time.Second)} {
+		path := filepath.Join(dir, "3f2a9c1e-"+at.Format("20060102-150405")+".md")
+		if err := os.WriteFile(path, []byte("keep me"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tm := runProgram(t, m)
+
+	tm.Type("e")
+	waitOutput(t, tm, "⚠ Overwrite")
+	tm.Type("?")                          // Swallowed: would show the full help
+	tm.Send(tea.KeyMsg{Type: tea.KeyEsc}) // Dismisses the question, stays in the view
+	tm.Type("?")                          // Reaches the view again
+	waitOutput(t, tm, "?: Less")
+	tm.Type("e")
+	waitOutput(t, tm, "⚠ Overwrite")
+	tm.Type("y")
+	waitOutput(t, tm, "✓ Exported 1 messages to")
+	tm.Quit()
+
+	final := finalModel(t, tm)
+	if final.confirm != nil || !final.showFullHelp || final.viewMode != ViewSessionDetail {
+		t.Errorf("after y: confirmation %+v, full help %v, view %v; want the question answered in session detail with only the second ? shown",
+			final.confirm, final.showFullHelp, final.viewMode)
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	var written int
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "run the tests") {
+			written++
+		}
+	}
+	if written != 1 {
+		t.Errorf("%d of %d files hold the export, want 1", written, len(paths))
+	}
+}
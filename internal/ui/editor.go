@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// editorDoneMsg reports that the editor opened on a written file has exited
+type editorDoneMsg struct {
+	path string
+	err  error
+}
+
+// editorFromEnv returns the user's editor: $VISUAL, else $EDITOR, else vi
+func editorFromEnv() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// editorCommand builds the command opening path in editor, which may carry arguments
+// such as "code -w", at a 1-based line; 0 opens the file at the top. Editors are told
+// the line the way they take it: "--goto path:line" for VS Code and its forks,
+// "path:line" for Sublime Text, Helix and Zed, and "+line path" for the rest, such as
+// vi, nano and emacs.
+func editorCommand(editor, path string, line int) *exec.Cmd {
+	args := strings.Fields(editor)
+	name, args := args[0], args[1:]
+	switch base := filepath.Base(name); {
+	case line <= 0:
+		args = append(args, path)
+	case slices.Contains([]string{"code", "code-insiders", "codium", "cursor", "windsurf"}, base):
+		args = append(args, "--goto", fmt.Sprintf("%s:%d", path, line))
+	case slices.Contains([]string{"subl", "hx", "helix", "zed"}, base):
+		args = append(args, fmt.Sprintf("%s:%d", path, line))
+	default:
+		args = append(args, fmt.Sprintf("+%d", line), path)
+	}
+	return exec.Command(name, args...)
+}
+
+// writeCall returns the last call of a message that writes a file and the file as
+// given to the tool, or false if the message writes none
+func writeCall(msg *monitor.Message) (monitor.ToolCall, string, bool) {
+	if msg == nil {
+		return monitor.ToolCall{}, "", false
+	}
+	calls := msg.Calls()
+	for i := len(calls) - 1; i >= 0; i-- {
+		if path := monitor.WrittenFile(calls[i].Name, calls[i].Input); path != "" {
+			return calls[i], path, true
+		}
+	}
+	return monitor.ToolCall{}, "", false
+}
+
+// openWrittenFile suspends the UI to open the file the message's last write call
+// wrote in the user's editor, at the line of the change when it can still be found,
+// else at the top. A file that no longer exists is reported as an error instead, and
+// in read-only mode nothing is opened, as the editor could change the file.
+func (m *Model) openWrittenFile(msg *monitor.Message) tea.Cmd {
+	call, path, ok := writeCall(msg)
+	if !ok {
+		return nil
+	}
+	if m.cfg.ReadOnly {
+		m.sessionNote = "✗ Read-only mode: opening files in the editor is disabled"
+		return nil
+	}
+	path = monitor.ResolvePath(path, msg.WorkingDir)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		m.recordError("open in editor", errors.New("the file no longer exists"), "path", path)
+		return nil
+	}
+	if err != nil {
+		m.recordError("open in editor", err, "path", path)
+		return nil
+	}
+	cmd := editorCommand(editorFromEnv(), path, monitor.EditLine(call.Name, call.Input, content))
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorDoneMsg{path: path, err: err}
+	})
+}
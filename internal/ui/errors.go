@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// errorLogSize is how many errors the errors view keeps; older ones are dropped
+const errorLogSize = 200
+
+// errorReportBuffer is how many errors from background work may wait for the UI before
+// further ones are dropped
+const errorReportBuffer = 64
+
+// errMsg reports an error met by background work, e.g. a session file that could not be
+// read while loading a list. Update logs it and lists it in the errors view.
+type errMsg struct {
+	op    string // What was being done, e.g. "load sessions"
+	err   error
+	attrs []any // Context as key/value pairs, e.g. "path", "/tmp/a.jsonl"
+}
+
+// errorReports carries errMsgs from background work to the UI
+type errorReports chan errMsg
+
+// report hands an error to the UI without blocking, so it is safe to call from
+// commands; it is dropped when errorReportBuffer errors are already waiting
+func (r errorReports) report(op string, err error, attrs ...any) {
+	select {
+	case r <- errMsg{op: op, err: err, attrs: attrs}:
+	default:
+	}
+}
+
+// waitForErrors waits for the next error reported by background work
+func (m Model) waitForErrors() tea.Cmd {
+	reports, done := m.errReports, m.ctx.Done()
+	return func() tea.Msg {
+		select {
+		case msg := <-reports:
+			return msg
+		case <-done:
+			return nil // Shut down
+		}
+	}
+}
+
+// forwardWatchErrors reports the watcher's errors until it is closed
+func (r errorReports) forwardWatchErrors(w *monitor.Watcher) {
+	for err := range w.Errors() {
+		r.report("watch", err)
+	}
+}
+
+// errorLog keeps the latest errorLogSize errors in a ring
+type errorLog struct {
+	entries []render.ErrorEntry
+	next    int // Oldest entry, overwritten next once the ring is full
+	dropped int // Entries overwritten so far
+}
+
+// add records an error, dropping the oldest one if the ring is full
+func (l *errorLog) add(e render.ErrorEntry) {
+	if len(l.entries) < errorLogSize {
+		l.entries = append(l.entries, e)
+		return
+	}
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % errorLogSize
+	l.dropped++
+}
+
+// newestFirst returns the kept errors, newest first
+func (l errorLog) newestFirst() []render.ErrorEntry {
+	n := len(l.entries)
+	out := make([]render.ErrorEntry, n)
+	for i := range out {
+		out[i] = l.entries[((l.next-1-i)%n+n)%n]
+	}
+	return out
+}
+
+// recordError logs an error, remembers it for the status line and adds it to the
+// errors view, counting it as unseen until the view is opened
+func (m *Model) recordError(op string, err error, attrs ...any) {
+	m.logger.Warn(err.Error(), append([]any{"op", op}, attrs...)...)
+	if m.lastError == "" {
+		// The status line takes a row from the views from now on
+		defer m.resizePages()
+	}
+	m.lastError = fmt.Sprintf("%s: %v", op, err)
+	m.errors.add(render.ErrorEntry{
+		Time:    time.Now(),
+		Op:      op,
+		Message: err.Error(),
+		Context: formatAttrs(attrs),
+	})
+	m.unseenErrors++
+}
+
+// formatAttrs formats key/value pairs as "key=value", separated by spaces
+func formatAttrs(attrs []any) string {
+	var parts []string
+	for i := 0; i+1 < len(attrs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%v=%v", attrs[i], attrs[i+1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// toggleErrors opens the errors view over the current view, or closes it again
+func (m *Model) toggleErrors() {
+	if m.viewMode == ViewErrors {
+		m.viewMode = m.errorsReturn
+		return
+	}
+	m.errorsReturn, m.viewMode = m.viewMode, ViewErrors
+	m.errorsScrollOffset, m.unseenErrors = 0, 0
+}
+
+// updateErrorsView scrolls the errors view
+func (m *Model) updateErrorsView(msg tea.KeyMsg) {
+	pageHeight := render.ErrorPageHeight(m.viewHeight())
+	maxScroll := max(len(render.ErrorLines(m.errors.newestFirst(), m.termWidth))-pageHeight, 0)
+	switch msg.String() {
+	case "up", "k":
+		m.errorsScrollOffset = max(m.errorsScrollOffset-1, 0)
+	case "down", "j":
+		m.errorsScrollOffset = min(m.errorsScrollOffset+1, maxScroll)
+	case "home":
+		m.errorsScrollOffset = 0
+	case "end":
+		m.errorsScrollOffset = maxScroll
+	case "pgup":
+		m.errorsScrollOffset = max(m.errorsScrollOffset-pageHeight, 0)
+	case "pgdn":
+		m.errorsScrollOffset = min(m.errorsScrollOffset+pageHeight, maxScroll)
+	}
+}
+
+// renderErrorsView renders the errors met this run
+func (m Model) renderErrorsView() string {
+	logPath := ""
+	if m.debugLogPath != "" {
+		logPath = monitor.ShortenHomePath(m.debugLogPath)
+	}
+	return render.ErrorList(render.ErrorListData{
+		Entries:      m.errors.newestFirst(),
+		Dropped:      m.errors.dropped,
+		LogPath:      logPath,
+		Width:        m.termWidth,
+		Height:       m.viewHeight(),
+		ScrollOffset: m.errorsScrollOffset,
+		Help:         m.renderHelp(),
+	})
+}
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// hint is shorthand for building the key hint tables below
+func hint(key, desc string, priority render.Priority) render.KeyHint {
+	return render.KeyHint{Key: key, Desc: desc, Priority: priority}
+}
+
+var (
+	quitHint = hint("q", "Quit", render.PriorityEssential)
+	backHint = hint("esc", "Back", render.PriorityEssential)
+)
+
+// keyHints returns the key hints for the current view and state, in display order
+func (m Model) keyHints() []render.KeyHint {
+	switch m.viewMode {
+	case ViewProcesses:
+		if len(m.processes) == 0 {
+			return []render.KeyHint{
+				hint("r", "Refresh", render.PriorityHigh),
+				hint("A", sessionlessHint(m.showSessionless), render.PriorityHigh),
+				quitHint,
+			}
+		}
+		return []render.KeyHint{
+			hint("↑/↓", "Navigate", render.PriorityNormal),
+			hint("enter", "View sessions", render.PriorityHigh),
+			hint("p", "Projects", render.PriorityHigh),
+			hint("r", "Refresh", render.PriorityNormal),
+			hint("+/-", "Interval", render.PriorityLow),
+			hint("space", "Pause", render.PriorityLow),
+			hint("f", "Toggle helpers", render.PriorityLow),
+			hint("A", sessionlessHint(m.showSessionless), render.PriorityLow),
+			hint("</>", "WORKDIR width", render.PriorityLow),
+			quitHint,
+		}
+
+	case ViewProjects:
+		if m.projectsError != "" {
+			return []render.KeyHint{backHint, quitHint}
+		}
+		hints := []render.KeyHint{
+			hint("↑/↓", "Navigate", render.PriorityNormal),
+			hint("enter", "View sessions", render.PriorityHigh),
+		}
+		if m.selectedProjIdx >= 0 && m.selectedProjIdx < len(m.projects) && len(m.runningPIDs(m.projects[m.selectedProjIdx])) > 0 {
+			hints = append(hints, hint("P", "Go to process", render.PriorityHigh))
+		}
+		hints = append(hints,
+			hint("i", "Stats", render.PriorityNormal),
+			hint("D", "Reports", render.PriorityNormal),
+			hint("R", "Recent sessions", render.PriorityNormal),
+			hint("H", "Recently viewed", render.PriorityNormal),
+			hint("y/Y", "Copy path/dir", render.PriorityLow),
+		)
+		if !m.cfg.NoProcesses {
+			hints = append(hints, hint("p", "Processes", render.PriorityHigh))
+		}
+		return append(hints,
+			hint("</>", "PROJECT width", render.PriorityLow),
+			quitHint,
+		)
+
+	case ViewSessions:
+		if m.sessionError != "" {
+			return []render.KeyHint{backHint, quitHint}
+		}
+		sidechainTokens := "excl."
+		if m.includeSidechains {
+			sidechainTokens = "incl."
+		}
+		return []render.KeyHint{
+			hint("↑/↓", "Navigate", render.PriorityNormal),
+			hint("enter", "Open/expand", render.PriorityHigh),
+			hint("o", "Open", render.PriorityNormal),
+			hint("v", "Preview", render.PriorityNormal),
+			hint("S", "Side-chain tokens ("+sidechainTokens+")", render.PriorityLow),
+			hint("A", "Agents", render.PriorityLow),
+			hint("m", "Model filter", render.PriorityNormal),
+			hint("M", "Mode filter", render.PriorityLow),
+			hint("L", "Language filter", render.PriorityLow),
+			backHint,
+			quitHint,
+		}
+
+	case ViewSessionDetail:
+		if m.sessionStats == nil && m.loadingSession {
+			return []render.KeyHint{hint("esc", "Stop (keep partial)", render.PriorityEssential), quitHint}
+		}
+		if m.sessionStats == nil {
+			return []render.KeyHint{hint("r", "Retry", render.PriorityHigh), backHint, quitHint}
+		}
+		if m.splitShown() && m.splitFocusDetail {
+			return []render.KeyHint{
+				hint("↑/↓", "Scroll message", render.PriorityNormal),
+				hint("PgUp/PgDn", "Page", render.PriorityLow),
+				hint("Home/End", "Jump", render.PriorityLow),
+				hint("tab", "Focus list", render.PriorityHigh),
+				hint("|", "Unsplit", render.PriorityNormal),
+				hint("esc", "Back to list", render.PriorityEssential),
+				quitHint,
+			}
+		}
+		headerAction := "Collapse header"
+		if m.compactHeader {
+			headerAction = "Expand header"
+		}
+		sortIndicator := "oldest→newest"
+		if m.messageSortNewestFirst {
+			sortIndicator = "newest→oldest"
+		}
+		splitAction := "Split"
+		if m.splitShown() {
+			splitAction = "Unsplit"
+		}
+		jumpUnit := "Turn"
+		if m.groupByTool {
+			jumpUnit = "Tool"
+		}
+		hints := []render.KeyHint{
+			hint("↑/↓", "Scroll", render.PriorityNormal),
+			hint("PgUp/PgDn", "Page", render.PriorityLow),
+			hint("Home/End", "Jump", render.PriorityLow),
+			hint("|", splitAction, render.PriorityLow),
+			hint("u", "User", render.PriorityNormal),
+			hint("a", "Assistant", render.PriorityNormal),
+			hint("b", "Both", render.PriorityNormal),
+			hint("d", "Denied", render.PriorityLow),
+			hint("x", "Refusals", render.PriorityLow),
+			hint("t", "Tools", render.PriorityLow),
+			hint("s", "Sort ("+sortIndicator+")", render.PriorityLow),
+			hint("G", "Group", render.PriorityNormal),
+			hint("<n>f", "Filter preset", render.PriorityLow),
+			hint("n/N", jumpUnit, render.PriorityLow),
+			hint("$", "Top turn", render.PriorityLow),
+			hint("X", "Next refusal", render.PriorityLow),
+			hint("<n>%", "Jump to n% of turns", render.PriorityLow),
+			hint(":/<n>G", "Go to message", render.PriorityLow),
+			hint("z", headerAction, render.PriorityLow),
+			hint("y/Y", "Copy ID/summary", render.PriorityLow),
+			hint("e/E", "Export filtered/selected", render.PriorityLow),
+			hint("c", "Running total", render.PriorityLow),
+			hint("m", "Mark", render.PriorityLow),
+			hint("=", "Diff with mark", render.PriorityLow),
+			backHint,
+			quitHint,
+		}
+		if _, _, ok := writeCall(m.messageAtRow(m.selectedMessageIdx)); ok && !m.cfg.ReadOnly {
+			hints = slices.Insert(hints, len(hints)-2, hint("o", "Open file", render.PriorityNormal))
+		}
+		if m.messageFilter == FilterTools {
+			groupAction := "Group by tool"
+			if m.groupByTool {
+				groupAction = "Ungroup tools"
+			}
+			hints = slices.Insert(hints, slices.IndexFunc(hints, func(h render.KeyHint) bool { return h.Key == "t" })+1,
+				hint("T", groupAction, render.PriorityNormal))
+		}
+		if m.splitShown() {
+			// Focus switching goes next to "|"
+			hints = slices.Insert(hints, 4, hint("tab", "Focus message", render.PriorityHigh))
+		}
+		return hints
+
+	case ViewViewed:
+		if len(m.viewed) == 0 {
+			return []render.KeyHint{backHint, quitHint}
+		}
+		return []render.KeyHint{
+			hint("↑/↓", "Navigate", render.PriorityNormal),
+			hint("enter", "Reopen", render.PriorityHigh),
+			hint("r", "Refresh", render.PriorityLow),
+			backHint,
+			quitHint,
+		}
+
+	case ViewProjectStats:
+		return []render.KeyHint{
+			hint("y", "Copy path", render.PriorityLow),
+			hint("Y", "Copy dir", render.PriorityLow),
+			backHint,
+			quitHint,
+		}
+
+	case ViewReport:
+		return []render.KeyHint{
+			hint("←/→", "Range", render.PriorityHigh),
+			hint("c", "Custom range", render.PriorityNormal),
+			hint("r", "Rescan", render.PriorityLow),
+			hint("e/E", "Export Markdown/CSV", render.PriorityNormal),
+			backHint,
+			quitHint,
+		}
+
+	case ViewMessageDetail:
+		hints := []render.KeyHint{
+			hint("↑/↓", "Scroll", render.PriorityNormal),
+			hint("←/→", "Prev/Next", render.PriorityHigh),
+			hint("shift+←/→", "±10", render.PriorityLow),
+			hint("PgUp/PgDn", "Page", render.PriorityLow),
+			hint("Home/End", "Jump", render.PriorityLow),
+		}
+		if m.detailPaired {
+			hints = []render.KeyHint{
+				hint("↑/↓", "Scroll", render.PriorityNormal),
+				hint("←/→", "Prev/Next tool call", render.PriorityHigh),
+				hint("shift+←/→", "±10", render.PriorityLow),
+				hint("a", "Fold arguments", render.PriorityNormal),
+				hint("r", "Fold result", render.PriorityNormal),
+				hint("PgUp/PgDn", "Page", render.PriorityLow),
+				hint("Home/End", "Jump", render.PriorityLow),
+			}
+		}
+		if _, _, ok := writeCall(m.detailMessage); ok && !m.cfg.ReadOnly {
+			hints = append(hints, hint("o", "Open file", render.PriorityHigh))
+		}
+		return append(hints, backHint, quitHint)
+
+	case ViewErrors:
+		return []render.KeyHint{
+			hint("↑/↓", "Scroll", render.PriorityNormal),
+			hint("PgUp/PgDn", "Page", render.PriorityLow),
+			hint("Home/End", "Jump", render.PriorityLow),
+			hint("esc/!", "Back", render.PriorityEssential),
+			quitHint,
+		}
+
+	case ViewDiff:
+		granularity := "Words"
+		if m.diffWords {
+			granularity = "Lines"
+		}
+		return []render.KeyHint{
+			hint("↑/↓", "Scroll", render.PriorityNormal),
+			hint("PgUp/PgDn", "Page", render.PriorityLow),
+			hint("Home/End", "Jump", render.PriorityLow),
+			hint("w", granularity, render.PriorityHigh),
+			backHint,
+			quitHint,
+		}
+	}
+	return []render.KeyHint{quitHint}
+}
+
+// sessionlessHint describes what "A" does to the process list
+func sessionlessHint(shown bool) string {
+	if shown {
+		return "Hide session-less"
+	}
+	return "Show all instances"
+}
+
+// redactHint describes what "Z" does, in every view
+func redactHint(redacting bool) string {
+	if redacting {
+		return "Show content"
+	}
+	return "Redact content"
+}
+
+// renderHelp renders the help bar for the current view, fitted to the terminal width,
+// or every hint of the view while the full help is toggled on with "?"
+func (m Model) renderHelp() string {
+	if m.jumpPrompt {
+		return m.renderJumpPrompt()
+	}
+	if m.reportPrompt {
+		return m.renderReportPrompt()
+	}
+	hints := m.keyHints()
+	if m.showFullHelp {
+		return render.FullHelp(append(hints, hint("Z", redactHint(m.redact), render.PriorityLow), hint("?", "Less", render.PriorityEssential)), m.termWidth)
+	}
+	return render.HelpBar(hints, m.termWidth)
+}
+
+// renderJumpPrompt renders the ":" prompt that takes the number of the message to jump to
+func (m Model) renderJumpPrompt() string {
+	prompt := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("11")).
+		Render(fmt.Sprintf("Go to message (1-%d): %s█", m.filteredMessageCount, m.jumpInput))
+	width := 0
+	if m.termWidth > 0 {
+		width = max(m.termWidth-lipgloss.Width(prompt)-2, 1)
+	}
+	return prompt + "  " + render.HelpBar([]render.KeyHint{
+		hint("enter", "Go", render.PriorityEssential),
+		hint("esc", "Cancel", render.PriorityEssential),
+	}, width)
+}
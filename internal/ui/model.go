@@ -1,17 +1,30 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/evertras/bubble-table/table"
+	"github.com/muesli/termenv"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/diff"
 	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/state"
 	"github.com/thieso2/promptwatch/internal/types"
+	"github.com/thieso2/promptwatch/internal/ui/render"
 )
 
 // SessionInfo represents session information for display
@@ -20,24 +33,63 @@ type SessionInfo struct {
 	Title           string
 	Updated         string
 	Path            string
-	Started         string // When the session started
-	Duration        string // Total session duration
-	UserPrompts     int    // Number of user prompts
-	Interruptions   int    // Number of resumptions/interruptions
-	GitBranch       string // Git branch when session was created
-	IsSidechain     bool   // Whether this is a side/branching conversation
-	Version         string // Claude version (e.g., "2.1.1")
-	FirstPrompt     string // The initial prompt that started the session
-	TotalTokens     int    // Total tokens used in session (input + output)
-	InputTokens     int    // Total input tokens
-	OutputTokens    int    // Total output tokens
-	LastMessage     string // Last message in the session
-	LastMessageTime int64  // Unix timestamp of last message
+	Started         string               // When the session started
+	Duration        string               // Total session duration
+	UserPrompts     int                  // Number of user prompts
+	Interruptions   int                  // Number of resumptions/interruptions
+	GitBranch       string               // Git branch when session was created
+	LastBranch      string               // Git branch of the latest entry
+	Branches        []string             // Git branches the session used, in order of first use
+	OutsideWrites   []string             // Files tool calls wrote outside the working directory
+	Languages       []monitor.EntryCount // Code fences and file writes by language, most frequent first
+	DiffStat        monitor.DiffStat     // Lines tool calls added and removed, approximately
+	IsSidechain     bool                 // Whether this is a side/branching conversation
+	Version         string               // Claude version (e.g., "2.1.1")
+	FirstPrompt     string               // The initial prompt that started the session
+	Summary         string               // Claude's title for the conversation, from its latest summary entry
+	Tokens          monitor.TokenCounts  // Usage summed over the session's messages
+	LastMessage     string               // Last message in the session
+	LastMessageTime int64                // Unix timestamp of last message
+	Model           string               // Model label, e.g. "opus" or "opus+haiku" for mixed sessions
+	Models          []string             // All model IDs seen in the session
+	ContextUsage    float64              // Context window usage of the latest assistant turn (0–1)
+	PermissionModes []string             // Permission modes the session ran in, in order of first use
+	PermissionMode  string               // Most permissive of PermissionModes ("" if none was recorded)
+	Project         string               // Project name, only set in the cross-project recent list
+	Copies          []string             // Projects holding other copies of the session, e.g. after the repository was copied
+	Rate            monitor.TokenRate    // Tokens added per minute lately, while the session is live
+
+	// Side-chain nesting (see linkSidechains)
+	SessionID string             // Session ID recorded inside the file; side-chains carry their owner's
+	IsAgent   bool               // Written by a Task-tool subagent (agent-*.jsonl or agentId entries)
+	Tasks     []monitor.TaskCall // Task calls the session made, which start subagents
+	Task      monitor.TaskCall   // Task call of the owning session that started this side-chain, if found
+
+	Size             int64               // File size in bytes
+	UncompressedSize int64               // Size of the data in a gzip-compressed file; 0 for plain files
+	LoadError        string              // Why the file could not be fully read ("" when it loaded fine)
+	NoMessages       bool                // The file holds no messages yet, e.g. only file history snapshots
+	ParentID         string              // ID of the owning session for nested side-chains, "" otherwise
+	Sidechains       int                 // Number of side-chains nested under this session
+	SidechainTokens  monitor.TokenCounts // Usage of the nested side-chains
+}
+
+// BranchLabel is the BRANCH column text: the branch the session ended on, with "+N"
+// for the other branches it used, or "-" if none was recorded
+func (s SessionInfo) BranchLabel() string {
+	last := s.LastBranch
+	if last == "" {
+		last = s.GitBranch // Sessions listed from the index only know their first branch
+	}
+	if last == "" {
+		return "-"
+	}
+	return monitor.BranchLabel(last, s.Branches)
 }
 
 // MessageRow represents a message for display in the message card view
 type MessageRow struct {
-	Index            int     // Message sequence number
+	Index            int     // Message sequence number among the filtered messages
 	Role             string  // "user" or "assistant"
 	Content          string  // Message text
 	Time             string  // Timestamp (ISO8601)
@@ -46,12 +98,43 @@ type MessageRow struct {
 	OutputTokens     int     // Output tokens (assistant only)
 	CacheCreation    int     // Tokens written to cache (assistant only)
 	CacheRead        int     // Tokens read from cache (assistant only)
+	EstimatedTokens  int     // Approximate prompt size (user prompts only, excluded from costs)
+	PastedBytes      int     // Size of the content pasted into the prompt (user prompts only)
+	ContextUsage     float64 // Fraction of the model's context window in use (assistant only)
+	ContextGrowth    int     // Context tokens added since the previous assistant response
+	Throughput       float64 // Output tokens per second (assistant only; 0 if unknown)
 	Cost             float64 // Estimated cost in USD
+	CumulativeCost   float64 // Session cost up to and including this message, in chronological order
 	RelativeTime     string  // Time since previous message (e.g., "+2s")
 	InputOutputRatio float64 // Input tokens / Output tokens
 	OutputPercentage int     // Output tokens as % of total (0-100)
 	CacheSavings     float64 // Estimated savings from cache hits (USD)
 	UUID             string  // Unique message identifier
+	HistoryIdx       int     // Index of the message in SessionStats.MessageHistory
+	TurnIdx          int     // Index of the message's turn in SessionStats.Turns
+
+	// PermissionDecision answers the permission prompt of a tool call: monitor.DecisionAllowed
+	// or monitor.DecisionDenied; "" if the tool ran without asking
+	PermissionDecision string
+	Refusal            bool               // The response is flagged as a refusal
+	ToolResult         bool               // The message is a tool's output
+	ToolCalls          []monitor.ToolCall // Tool calls the message makes (assistant only)
+
+	// Turn header rows (grouped mode) stand in for a whole turn instead of a message
+	IsTurnHeader bool
+	Turn         monitor.Turn
+	TurnExpanded bool
+
+	// Tool group header rows (grouped by tool) stand in for the calls of one tool
+	ToolGroup         string    // Tool the calls are made with; "" for other rows
+	ToolGroupCalls    int       // Filtered calls of the tool
+	ToolGroupFirst    time.Time // Earliest of the calls
+	ToolGroupLast     time.Time // Latest of the calls
+	ToolGroupExpanded bool
+
+	// Branch switch rows divide messages on different git branches (ungrouped mode)
+	BranchSwitch string // Branch switched to; "" for other rows
+	BranchFrom   string // Branch switched from
 }
 
 // ViewMode represents the current view being displayed
@@ -63,15 +146,32 @@ const (
 	ViewSessions
 	ViewSessionDetail
 	ViewMessageDetail
+	ViewProjectStats
+	ViewDiff
+	// ViewViewed lists the sessions last opened in promptwatch, from the history file; as
+	// a sessionSourceMode it marks a session opened from that list
+	ViewViewed
+	// ViewRecent is only used as a sessionSourceMode: the sessions of all projects from
+	// the last days, shown in ViewSessions with a PROJECT column
+	ViewRecent
+	// ViewErrors lists the errors met this run, over the view it was opened from
+	ViewErrors
+	// ViewReport sums up the sessions of all projects within a range of days
+	ViewReport
 )
 
 // ProjectDir represents a project directory with metadata
 type ProjectDir struct {
-	Name        string
-	Path        string
-	DisplayName string // Human-readable project name
-	Modified    time.Time
-	Sessions    int // Count of session files
+	Name         string // Encoded directory name, e.g. "-home-demo-acme-api"
+	Path         string // Directory under ~/.claude/projects
+	DisplayName  string // Human-readable project name
+	OriginalPath string // Project path from sessions-index.json; "" if unknown
+	Modified     time.Time
+	Sessions     int // Count of session files, excluding subagent files
+	Agents       int // Count of subagent (agent-*.jsonl) files
+
+	LatestSession string // Most recently modified session file, excluding subagent files
+	LastPrompt    string // First prompt of LatestSession, loaded after the list (see loadProjectPrompts)
 }
 
 type MessageFilter int
@@ -80,35 +180,139 @@ const (
 	FilterAll MessageFilter = iota
 	FilterUserOnly
 	FilterAssistantOnly
+	FilterDenied   // Tool calls the user denied at a permission prompt
+	FilterRefusals // Responses flagged as refusals
+	FilterTools    // Responses calling tools
 )
 
+// label names the filter for status lines; empty for FilterAll
+func (f MessageFilter) label() string {
+	switch f {
+	case FilterUserOnly:
+		return "user filter"
+	case FilterAssistantOnly:
+		return "assistant filter"
+	case FilterDenied:
+		return "denied filter"
+	case FilterRefusals:
+		return "refusal filter"
+	case FilterTools:
+		return "tool filter"
+	}
+	return ""
+}
+
 // Model represents the main UI state
 type Model struct {
 	// Main view
-	table          table.Model
-	processes      []types.ClaudeProcess
-	lastUpdate     time.Time
-	updateInterval time.Duration
-	showHelpers    bool
-	quitting       bool
-	sortColumn     string
-	sortAscending  bool
+	table              table.Model
+	processColumns     render.ProcessColumns // Widths the process rows are truncated to
+	columnShares       map[string]int        // Adjusted shares of the tables' wide columns, by table
+	processes          []types.ClaudeProcess
+	processRates       map[int32]monitor.TokenRate // Tokens added lately to each process's sessions, by PID
+	tokenRates         *monitor.TokenRates         // Follows live sessions for processRates and SessionInfo.Rate
+	lastUpdate         time.Time
+	updateInterval     time.Duration
+	tickGen            int            // Generation of the active tick chain
+	paused             bool           // Periodic refresh is paused
+	showFullHelp       bool           // Show every key hint instead of the fitted help bar
+	redact             bool           // Mask message content and paths for screen sharing (see redact.go)
+	statePath          string         // Where UI state is persisted ("" = don't persist)
+	historyPath        string         // Where session opens are recorded ("" = not recorded)
+	cfg                *config.Config // User configuration (defaults when no config file exists)
+	showHelpers        bool
+	showSessionless    bool // List processes without sessions under ~/.claude/projects
+	quitting           bool
+	ctx                context.Context    // Root context for background work, cancelled on shutdown
+	shutdown           context.CancelFunc // Cancels ctx and everything derived from it
+	stateWrites        *pendingWrites     // State saves not written yet, flushed on shutdown
+	historyWrites      *pendingWrites     // Session opens not recorded yet, flushed on shutdown
+	confirmQuit        bool               // Ask before quitting while background work is running
+	confirm            *confirmation      // Question showing over the view (nil = none)
+	logger             *slog.Logger       // Debug logger for errors that would otherwise be swallowed
+	debugLogPath       string             // Where the debug log is written ("" when --debug is off)
+	lastError          string             // Most recent background error, shown until the next one
+	errReports         errorReports       // Errors reported by background work (see errMsg)
+	errors             errorLog           // Errors met this run, listed in the errors view
+	unseenErrors       int                // Errors recorded since the errors view was last opened
+	errorsReturn       ViewMode           // View the errors view was opened from
+	errorsScrollOffset int                // First line shown in the errors view
+	watcher            *monitor.Watcher   // Reports projects and session files coming and going (nil = not watching)
+	sortColumn         string
+	sortAscending      bool
 
 	// Projects view
-	projectsTable   table.Model
-	projects        []ProjectDir
-	selectedProjIdx int
-	projectsError   string
+	projectsTable      table.Model
+	projectNameWidth   int // Width of the projects table's PROJECT column
+	projectPromptWidth int // Width of the projects table's LAST PROMPT column
+	projects           []ProjectDir
+	selectedProjIdx    int
+	projectsError      string
+	projectsDir        string // Where projects were looked for
+	projectsDirMissing bool   // projectsDir does not exist (yet); not an error
 
 	// Session view
 	viewMode           ViewMode
 	selectedProcIdx    int
-	selectedProc       *types.ClaudeProcess
+	processNote        string                  // Status note about the process selection (e.g. the selected process exited)
+	sessionNote        string                  // Status note in the session detail view (e.g. what was copied)
+	projectNote        string                  // Status note in the projects and project stats views
+	clipboard          func(string)            // Copies text to the system clipboard
+	discovery          monitor.DiscoveryReport // Claude-like processes skipped by the last refresh
+	verboseProcesses   bool                    // List skipped processes with PIDs and errors
+	selectedProc       *types.ClaudeProcess    // Process whose sessions are listed; a copy, refreshed on each tick
+	selectedProcExited bool                    // The process has exited since its sessions were opened
 	sessionTable       table.Model
-	sessions           []SessionInfo
+	sessions           []SessionInfo   // Sessions currently shown (after the model filter)
+	allSessions        []SessionInfo   // All loaded sessions before filtering
+	sessionModelFilter string          // Model substring the session list is filtered by ("" = all)
+	sessionModeFilter  string          // Permission mode the session list is filtered by ("" = all)
+	sessionLangFilter  string          // Language the session list is filtered by ("" = all)
+	expandedSessions   map[string]bool // Parent session IDs whose side-chains are shown
+	includeSidechains  bool            // Add side-chain tokens to their parent's totals
+	showAgents         bool            // List subagent sessions (dimmed, at the bottom)
 	sessionError       string
 	selectedSessionIdx int
-	sessionSourceMode  ViewMode // Track whether ViewSessions came from ViewProcesses or ViewProjects
+	sessionSourceMode  ViewMode   // Track whether ViewSessions came from ViewProcesses, ViewProjects or ViewRecent, or the session from ViewViewed
+	sessionsProject    ProjectDir // Project whose sessions are listed when sessionSourceMode is ViewProjects
+
+	// Session list preview pane
+	sessionPreview  bool              // Split the session list with a preview of the highlighted session
+	previewSeq      int               // Identifies the latest preview request; results of older ones are dropped
+	previewPath     string            // Session file the preview shows or is loading
+	previewMessages []monitor.Message // Its latest messages, oldest first
+	previewLoading  bool
+	previewError    string
+
+	// Recently viewed list
+	viewed            []viewedSession
+	selectedViewedIdx int
+	viewedError       string
+	viewedNote        string
+
+	// Project stats view
+	projectStatsPath     string                           // Project directory shown in ViewProjectStats
+	projectStatsName     string                           // Its human-readable name
+	projectStatsOriginal string                           // Its original path; "" if unknown
+	projectStatsCache    map[string]*monitor.ProjectStats // Finished scans by project directory
+	projectStatsError    string
+	scanningProject      bool               // True while the project's session files are being scanned
+	projectScanDone      int                // Session files scanned so far
+	projectScanTotal     int                // Session files to scan
+	scanCancel           context.CancelFunc // Cancels the in-flight project scan
+
+	// Reports view
+	reportRange     int                        // Index into reportRanges of the range chosen
+	reportCustom    monitor.DateRange          // Range typed into the range prompt; zero until one is
+	reportShown     monitor.DateRange          // Range shown, as of the latest scan
+	reportCache     map[string]*monitor.Report // Finished scans by DateRange.String
+	reportError     string
+	scanningReport  bool               // True while the range's session files are being scanned
+	reportScanDone  int                // Session files scanned so far
+	reportScanTotal int                // Session files to scan
+	reportCancel    context.CancelFunc // Cancels the in-flight report scan
+	reportPrompt    bool               // True while the range prompt is open
+	reportInput     string             // Text typed into the range prompt
 
 	// Session detail view
 	selectedSession      *SessionInfo
@@ -116,10 +320,22 @@ type Model struct {
 	messageTable         table.Model
 	messages             []MessageRow
 	messageError         string
-	messageViewport      viewport.Model // Viewport for message card scrolling
-	messageFilter        MessageFilter  // Filter for messages
-	filteredMessageCount int            // Count of currently filtered messages
-	selectedMessageIdx   int            // Index of selected message for detail view
+	messageViewport      viewport.Model      // Viewport for message card scrolling
+	messageLines         int                 // Lines of card content in messageViewport
+	messageFilter        MessageFilter       // Filter for messages
+	filterPreset         int                 // Active filter preset, 1-based into cfg.Filters.Presets; 0 for none
+	filteredMessageCount int                 // Count of currently filtered messages
+	filteredTokens       monitor.TokenCounts // Tokens of the filtered messages
+	filteredCost         float64             // Estimated cost of the filtered messages
+	sessionTotals        sessionTotals       // Whole-session figures of the header, computed once per load
+	selectedMessageIdx   int                 // Index of selected message for detail view
+
+	// Session loading state
+	loadingSession bool               // True while a session file is being parsed
+	loadProgress   float64            // Fraction of the session file parsed (0-1)
+	loadSpinner    spinner.Model      // Spinner shown while loading
+	loadCancel     context.CancelFunc // Cancels the in-flight session parse
+	loadSeq        int                // Identifies the current load so stale results can be dropped
 
 	// Terminal dimensions
 	termWidth  int
@@ -128,41 +344,235 @@ type Model struct {
 	// Message detail view
 	detailMessage      *monitor.Message // Full message being displayed
 	detailScrollOffset int              // Scroll position in message detail
+	// A tool call opened with enter is shown with its result; ←/→ then step through
+	// tool calls and "a"/"r" collapse the arguments and the result
+	detailPaired          bool
+	detailResults         []*monitor.Message // Result of each paired tool call, nil for those with none yet
+	detailArgsCollapsed   bool
+	detailResultCollapsed bool
+	splitView             bool // Session detail shows the selected message beside the cards ("|")
+	splitFocusDetail      bool // Keys scroll the split view's detail pane instead of the cards
+
+	// Diff view: the message marked with "m" compared with another one via "="
+	diffMark         *monitor.Message // Marked message in session detail view; nil when none
+	diffMarkPosition int              // Its position among the listed messages
+	diffOld          string           // Label of the old (marked) side
+	diffNew          string           // Label of the new side
+	diffOldContent   string
+	diffNewContent   string
+	diffOps          []diff.Op // Edit script between the two contents
+	diffWords        bool      // Word-level instead of line-level granularity
+	diffScrollOffset int       // First diff line shown
 
 	// Scroll tracking
 	lastMessageIdx int // Track last selected message for stable scrolling
 
 	// Message sorting
-	messageSortNewestFirst bool // true = newest first, false = oldest first
+	messageSortNewestFirst bool            // true = newest first, false = oldest first
+	groupByTurn            bool            // Show messages grouped under turn header cards
+	runningTotal           bool            // Assistant cards show the cumulative session cost ("Σ $2.31")
+	expandedTurns          map[int]bool    // Turn numbers whose messages are shown in grouped mode
+	groupByTool            bool            // Show tool calls in a section per tool (under the tool filter only)
+	collapsedTools         map[string]bool // Tools whose sections are collapsed when grouped by tool
+	compactHeader          bool            // Session detail header collapsed to a single line
+
+	// Jumping to a message: a vim-style count typed before a key, or the ":<n>" prompt
+	countPrefix int    // Count typed so far in session detail view; 0 when none
+	jumpPrompt  bool   // True while the ":" prompt is open
+	jumpInput   string // Digits typed into the ":" prompt
+
+	// Live sessions: the latest prompt or tool result Claude has yet to answer, checked
+	// on every tick in session detail view
+	waitingSince time.Time // Zero when Claude is not being waited for
+	waitingAfter string    // Type of that message, "prompt" or "tool_result"
 }
 
 // tickMsg is used for periodic updates
-type tickMsg time.Time
+// gen identifies the tick chain that produced it, so chains replaced after an
+// interval change or pause are dropped instead of stacking up
+type tickMsg struct {
+	gen int
+}
+
+// stateSavedMsg reports the result of persisting UI state
+type stateSavedMsg struct {
+	err error
+}
+
+// pendingWrites queues file writes made in the background, so that Shutdown can finish
+// the ones whose commands have not run yet, e.g. a save right before quitting
+type pendingWrites struct {
+	mu     sync.Mutex // Held while writing, so flushes run one at a time and in order
+	writes []func() error
+}
+
+// add queues write and returns a command that performs the queued writes
+func (p *pendingWrites) add(write func() error, done func(error) tea.Msg) tea.Cmd {
+	p.mu.Lock()
+	p.writes = append(p.writes, write)
+	p.mu.Unlock()
+	return func() tea.Msg { return done(p.flush()) }
+}
+
+// flush performs the queued writes, waiting for a flush in progress to finish first
+func (p *pendingWrites) flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for _, write := range p.writes {
+		errs = append(errs, write())
+	}
+	p.writes = nil
+	return errors.Join(errs...)
+}
+
+// Bounds and steps for adjusting the refresh interval at runtime
+var refreshIntervalSteps = []time.Duration{
+	500 * time.Millisecond,
+	1 * time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	60 * time.Second,
+}
 
 // processesMsg carries refreshed process data
 type processesMsg struct {
 	processes []types.ClaudeProcess
+	report    monitor.DiscoveryReport
+	rates     map[int32]monitor.TokenRate // Token rates of the processes' sessions, by PID
 	err       error
 }
 
+// processMetricsMsg carries the refreshed metrics of the process whose sessions are listed
+type processMetricsMsg struct {
+	proc types.ClaudeProcess
+	err  error // Wraps monitor.ErrProcessGone once the process has exited
+}
+
+// sessionRatesMsg carries the token rates of the live sessions of a session list
+type sessionRatesMsg struct {
+	rates  map[string]monitor.TokenRate // By session path; idle sessions are left out
+	source string                       // The list the rates were sampled for (see sessionListSource)
+}
+
 // sessionsMsg carries loaded session data
 type sessionsMsg struct {
 	sessions []SessionInfo
 	err      error
+	source   string // The list the sessions were loaded for (see sessionListSource)
+}
+
+// watchMsg reports projects or session files created, removed or renamed on disk
+type watchMsg struct {
+	change monitor.WatchChange
+}
+
+// sessionRowMsg carries a re-read row of the session list
+type sessionRowMsg struct {
+	session SessionInfo
 }
 
 // sessionDetailMsg carries loaded session detail data
 type sessionDetailMsg struct {
+	seq   int
 	stats interface{} // *monitor.SessionStats
 	err   error
 }
 
+// sessionTailMsg carries the newest messages of a session while older history is still loading
+type sessionTailMsg struct {
+	seq     int
+	stats   *monitor.SessionStats
+	updates <-chan tea.Msg
+}
+
+// projectScanProgressMsg reports how many session files of a project have been scanned
+type projectScanProgressMsg struct {
+	path    string
+	done    int
+	total   int
+	updates <-chan tea.Msg // Channel to keep listening on for further updates
+}
+
+// projectStatsMsg carries the finished scan of a project
+type projectStatsMsg struct {
+	path  string
+	stats *monitor.ProjectStats
+	err   error
+}
+
+// sessionProgressMsg reports how much of a session file has been parsed
+type sessionProgressMsg struct {
+	seq       int
+	processed int64
+	total     int64
+	updates   <-chan tea.Msg // Channel to keep listening on for further updates
+}
+
+// exportedMsg reports the result of writing an export from the session detail view
+type exportedMsg struct {
+	path     string
+	messages int
+	err      error
+}
+
 // projectsMsg carries loaded project directory data
 type projectsMsg struct {
-	projects []ProjectDir
+	projects  []ProjectDir
+	processes []types.ClaudeProcess // Claude instances, to show which projects they work in; nil if discovery failed
+	dir       string                // Projects directory that was read
+	missing   bool                  // dir does not exist, e.g. before Claude's first run
+	err       error
+}
+
+// projectPromptsMsg carries the LastPrompt of each project, keyed by project path
+type projectPromptsMsg struct {
+	prompts map[string]string
+}
+
+// previewMsg carries the latest messages of the session shown in the preview pane
+type previewMsg struct {
+	seq      int
+	messages []monitor.Message
 	err      error
 }
 
+// waitMsg reports whether Claude has yet to answer the latest entry of the open session
+type waitMsg struct {
+	seq      int // loadSeq of the session checked
+	since    time.Time
+	after    string
+	messages int // Messages in the end of the file read
+}
+
+// previewDebounceMsg fires once the selection has rested on a session long enough to preview it
+type previewDebounceMsg struct {
+	seq int
+}
+
+// Preview pane settings: the selection must rest this long before a session is read,
+// only this much of the end of its file is parsed, and below this terminal size the
+// session list falls back to a single pane
+const (
+	previewDebounce      = 150 * time.Millisecond
+	previewTailBytes     = 64 * 1024
+	minPreviewTermWidth  = 100
+	minPreviewTermHeight = 16
+)
+
+// sessionTailBytes is how much of the end of a session file is parsed up front so the
+// newest messages can be shown while the rest of the file loads
+const sessionTailBytes = 256 * 1024
+
+// Waiting for Claude: how much of the end of the open session file is read on each
+// tick, and how long a wait may last before the session counts as abandoned
+const (
+	waitTailBytes = 16 * 1024
+	maxWaitShown  = 10 * time.Minute
+)
+
 // scrollToSelection scrolls the viewport to center the selected card vertically
 // Each message card is exactly 4 lines (header + content + metrics + separator)
 func (m *Model) scrollToSelection() {
@@ -170,7 +580,7 @@ func (m *Model) scrollToSelection() {
 		return
 	}
 
-	const linesPerCard = 4
+	linesPerCard := render.CardLines
 
 	// Calculate the line offset where the selected card starts
 	selectedCardLineOffset := m.selectedMessageIdx * linesPerCard
@@ -197,7 +607,10 @@ func (m *Model) scrollToSelection() {
 	m.messageViewport.GotoTop()
 	m.messageViewport.LineDown(targetTopLine)
 
-	// Update last position
+	// Update last position; the split view shows a newly selected message from the top
+	if m.selectedMessageIdx != m.lastMessageIdx {
+		m.detailScrollOffset = 0
+	}
 	m.lastMessageIdx = m.selectedMessageIdx
 }
 
@@ -211,46 +624,434 @@ func NewModel(updateInterval time.Duration, showHelpers bool) Model {
 		viewMode:               ViewProcesses,
 		selectedProcIdx:        0,
 		messageFilter:          FilterAll,
-		messageSortNewestFirst: true, // Default: show newest messages first
-		termWidth:              80,   // Default terminal width
-		termHeight:             24,   // Default terminal height
+		messageSortNewestFirst: true,         // Default: show newest messages first
+		termWidth:              80,           // Default terminal width
+		termHeight:             24,           // Default terminal height
+		clipboard:              termenv.Copy, // OSC 52, which also works over SSH
+		tokenRates:             monitor.NewTokenRates(),
+		cfg:                    config.Default(),
 	}
 
-	m.table = createTableWithWidth(m.termWidth)
-	m.projectsTable = createProjectsTableWithWidth(m.termWidth)
+	m.resizeProcessTable()
+	m.resizeProjectsTable()
 	m.sessionTable = createSessionTableWithWidth(m.termWidth)
 	m.messageTable = createMessageTableWithWidth(m.termWidth)
 
 	// Initialize viewport for message cards
-	m.messageViewport = viewport.New(m.termWidth, m.termHeight-8)
+	m.messageViewport = viewport.New(m.termWidth-scrollbarWidth, m.termHeight-8)
 	m.messageViewport.YPosition = 0
 
+	m.loadSpinner = spinner.New()
+	m.loadSpinner.Spinner = spinner.Dot
+
+	m.ctx, m.shutdown = context.WithCancel(context.Background())
+	m.stateWrites, m.historyWrites = &pendingWrites{}, &pendingWrites{}
+	m.logger = slog.New(slog.DiscardHandler)
+	m.errReports = make(errorReports, errorReportBuffer)
+
 	return m
 }
 
+// WithConfig applies the user configuration
+func (m Model) WithConfig(cfg *config.Config) Model {
+	if cfg != nil {
+		m.cfg = cfg
+		m.showSessionless = cfg.Processes.ShowSessionless
+		if cfg.NoProcesses && m.viewMode == ViewProcesses {
+			m.viewMode = ViewProjects
+		}
+		m.resizeProjectsTable() // Without processes there is no RUNNING column
+	}
+	return m
+}
+
+// WithDebugLog routes diagnostics for background errors to logger, which writes to path
+func (m Model) WithDebugLog(logger *slog.Logger, path string) Model {
+	if logger != nil {
+		m.logger = logger
+		m.debugLogPath = path
+	}
+	return m
+}
+
+// WithWatcher refreshes the projects and session lists as w reports projects and
+// session files coming and going. Shutdown closes w.
+func (m Model) WithWatcher(w *monitor.Watcher) Model {
+	m.watcher = w
+	if w != nil {
+		go m.errReports.forwardWatchErrors(w)
+	}
+	return m
+}
+
+// WithQuitConfirmation enables a "really quit?" prompt when quitting while background work is running
+func (m Model) WithQuitConfirmation(enabled bool) Model {
+	m.confirmQuit = enabled
+	return m
+}
+
+// Shutdown cancels all background work started by the model and finishes the state
+// saves and session opens still being written, so that none is lost on quit
+// It is safe to call more than once, and is called both on quit and by main after the program exits
+func (m *Model) Shutdown() {
+	m.cancelSessionLoad()
+	m.cancelProjectScan()
+	m.cancelReportScan()
+	m.watcher.Close()
+	if m.shutdown != nil {
+		m.shutdown()
+	}
+	for _, p := range []*pendingWrites{m.stateWrites, m.historyWrites} {
+		if p == nil {
+			continue
+		}
+		if err := p.flush(); err != nil {
+			m.logger.Error("cannot finish writes on shutdown", "err", err)
+		}
+	}
+}
+
+// hasBackgroundWork reports whether quitting now would abandon work in progress
+func (m Model) hasBackgroundWork() bool {
+	return m.loadingSession
+}
+
 // Init initializes the model and sets up background tasks
 func (m Model) Init() tea.Cmd {
+	load := m.refreshProcesses()
+	switch {
+	case m.viewMode == ViewProjects:
+		load = m.loadProjects()
+	case m.viewMode == ViewSessions && m.sessionSourceMode == ViewRecent:
+		load = m.loadRecentSessions()
+	}
 	return tea.Batch(
-		m.refreshProcesses(),
+		load,
 		m.tick(),
+		m.waitForWatch(),
+		m.waitForErrors(),
 	)
 }
 
-// refreshProcesses kicks off an asynchronous process discovery
+// waitForWatch waits for the next batch of changes from the watcher
+func (m Model) waitForWatch() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	changes := m.watcher.Changes()
+	return func() tea.Msg {
+		change, ok := <-changes
+		if !ok {
+			return nil // Watcher closed on shutdown
+		}
+		return watchMsg{change: change}
+	}
+}
+
+// sessionListSource identifies the session list being shown: "recent", the project
+// directory or the process's working directory; "" when no list is open
+func (m Model) sessionListSource() string {
+	switch {
+	case m.viewMode == ViewProcesses || m.viewMode == ViewProjects || m.viewMode == ViewProjectStats || m.viewMode == ViewReport:
+		return ""
+	case m.viewMode == ViewViewed || m.sessionSourceMode == ViewViewed:
+		return ""
+	case m.sessionSourceMode == ViewRecent:
+		return "recent"
+	case m.sessionSourceMode == ViewProjects:
+		return m.sessionsProject.Path
+	case m.selectedProc != nil:
+		return m.selectedProc.WorkingDir
+	}
+	return ""
+}
+
+// reloadSessions reloads the session list being shown
+func (m Model) reloadSessions() tea.Cmd {
+	switch {
+	case m.sessionListSource() == "":
+		return nil
+	case m.sessionSourceMode == ViewRecent:
+		return m.loadRecentSessions()
+	case m.sessionSourceMode == ViewProjects:
+		return m.loadSessionsFromProject(m.sessionsProject)
+	}
+	return m.loadSessions()
+}
+
+// sessionDirs returns the project directories the session list being shown comes from,
+// for the watcher
+func (m Model) sessionDirs() []string {
+	switch {
+	case m.sessionListSource() == "":
+		return nil
+	case m.sessionSourceMode == ViewRecent:
+		var dirs []string
+		for _, session := range m.allSessions {
+			if dir := filepath.Dir(session.Path); !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+		return dirs
+	case m.sessionSourceMode == ViewProjects:
+		return []string{m.sessionsProject.Path}
+	}
+	dir, err := monitor.ProjectDirFor(m.selectedProc.WorkingDir)
+	if err != nil {
+		return nil
+	}
+	return []string{dir}
+}
+
+// watchConcerns reports whether a batch of changes affects the session list being shown
+func (m Model) watchConcerns(change monitor.WatchChange) bool {
+	if m.sessionListSource() == "recent" {
+		return change.Projects || len(change.Dirs) > 0
+	}
+	dirs := m.sessionDirs()
+	if len(dirs) == 0 && m.sessionListSource() != "" {
+		// The list's project directory did not exist yet: it may just have been created
+		return change.Projects
+	}
+	for _, dir := range dirs {
+		if slices.Contains(change.Dirs, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshProcesses kicks off an asynchronous process discovery; nil when the config
+// turns process discovery off
 func (m Model) refreshProcesses() tea.Cmd {
+	if m.cfg.NoProcesses {
+		return nil
+	}
 	return func() tea.Msg {
-		processes, err := monitor.FindClaudeProcesses(m.showHelpers)
+		processes, report, err := monitor.FindClaudeProcesses(monitor.DiscoveryOptions{
+			ShowHelpers:     m.showHelpers,
+			ShowSessionless: m.showSessionless,
+		})
+		rates := make(map[int32]monitor.TokenRate)
+		now := time.Now()
+		for _, proc := range processes {
+			if proc.IsHelper || proc.WorkDirGone || proc.NoSessions {
+				continue
+			}
+			dir, err := monitor.ProjectDirFor(proc.WorkingDir)
+			if err != nil {
+				continue
+			}
+			if rate := m.tokenRates.SampleProject(dir, now); !rate.Idle() {
+				rates[proc.PID] = rate
+			}
+		}
 		return processesMsg{
 			processes: processes,
+			report:    report,
+			rates:     rates,
 			err:       err,
 		}
 	}
 }
 
+// refreshSelectedProcess updates the CPU and memory of the process whose sessions are
+// listed
+func (m Model) refreshSelectedProcess() tea.Cmd {
+	if m.selectedProc == nil || m.selectedProcExited {
+		return nil
+	}
+	proc := *m.selectedProc
+	return func() tea.Msg {
+		err := monitor.RefreshMetrics(&proc)
+		return processMetricsMsg{proc: proc, err: err}
+	}
+}
+
+// sampleSessionRates reads what was appended to the listed sessions for their token
+// rates; only the files written in the last monitor.RateMinutes minutes are read
+func (m Model) sampleSessionRates() tea.Cmd {
+	if len(m.allSessions) == 0 {
+		return nil
+	}
+	paths := make([]string, len(m.allSessions))
+	for i, session := range m.allSessions {
+		paths[i] = session.Path
+	}
+	rates, source := m.tokenRates, m.sessionListSource()
+	return func() tea.Msg {
+		return sessionRatesMsg{rates: rates.Sample(paths, time.Now()), source: source}
+	}
+}
+
+// refreshEmptySessions re-reads the listed sessions that had no messages yet once their
+// files have grown, so they turn into regular rows as Claude writes their first entries
+func (m Model) refreshEmptySessions() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, session := range m.allSessions {
+		if !session.NoMessages {
+			continue
+		}
+		path, size, report := session.Path, session.Size, m.errReports
+		cmds = append(cmds, func() tea.Msg {
+			if fi, err := os.Stat(path); err != nil || fi.Size() == size {
+				return nil
+			}
+			return sessionRowMsg{session: readSessionInfo(path, report)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// applySessionRates sets the Rate of the listed sessions from rates
+func (m *Model) applySessionRates(rates map[string]monitor.TokenRate) {
+	for i := range m.allSessions {
+		m.allSessions[i].Rate = rates[m.allSessions[i].Path]
+	}
+	for i := range m.sessions {
+		m.sessions[i].Rate = rates[m.sessions[i].Path]
+	}
+}
+
 // tick sends a periodic timer message
 func (m Model) tick() tea.Cmd {
+	gen := m.tickGen
 	return tea.Tick(m.updateInterval, func(_ time.Time) tea.Msg {
-		return tickMsg(time.Now())
+		return tickMsg{gen: gen}
+	})
+}
+
+// restartTick replaces the running tick chain with a new one using the current interval
+func (m *Model) restartTick() tea.Cmd {
+	m.tickGen++
+	return m.tick()
+}
+
+// stepRefreshInterval moves the refresh interval one step up (dir > 0) or down (dir < 0),
+// staying within refreshIntervalSteps
+func stepRefreshInterval(current time.Duration, dir int) time.Duration {
+	if dir > 0 {
+		for _, step := range refreshIntervalSteps {
+			if step > current {
+				return step
+			}
+		}
+		return refreshIntervalSteps[len(refreshIntervalSteps)-1]
+	}
+	for i := len(refreshIntervalSteps) - 1; i >= 0; i-- {
+		if refreshIntervalSteps[i] < current {
+			return refreshIntervalSteps[i]
+		}
+	}
+	return refreshIntervalSteps[0]
+}
+
+// WithVerboseProcesses lists the processes skipped during discovery below the process table
+func (m Model) WithVerboseProcesses(verbose bool) Model {
+	m.verboseProcesses = verbose
+	return m
+}
+
+// WithStateFile enables persisting UI state such as the refresh interval to path
+func (m Model) WithStateFile(path string) Model {
+	m.statePath = path
+	return m
+}
+
+// WithStartView starts in the named view, one of config.Views; other names keep the
+// processes view, or the projects view when the config turns process discovery off
+func (m Model) WithStartView(view string) Model {
+	switch {
+	case view == "recent":
+		m.viewMode = ViewSessions
+		m.sessionSourceMode = ViewRecent
+	case view == "projects" || m.cfg.NoProcesses:
+		m.viewMode = ViewProjects
+	default:
+		m.viewMode = ViewProcesses
+	}
+	return m
+}
+
+// saveState queues a state save, performed in the background or at the latest by Shutdown
+func (m Model) saveState(save func() error) tea.Cmd {
+	return m.stateWrites.add(save, func(err error) tea.Msg { return stateSavedMsg{err: err} })
+}
+
+// saveView persists the top-level view shown, to start in next time, in the background
+func (m Model) saveView(view string) tea.Cmd {
+	if m.statePath == "" {
+		return nil
+	}
+	path := m.statePath
+	return m.saveState(func() error {
+		return state.Update(path, func(st *state.State) {
+			st.View = view
+		})
+	})
+}
+
+// WithCompactHeader starts with the session detail header collapsed to a single line
+func (m Model) WithCompactHeader(compact bool) Model {
+	m.compactHeader = compact
+	m.resizeMessageViewport()
+	return m
+}
+
+// saveCompactHeader persists whether the session detail header is collapsed in the background
+func (m Model) saveCompactHeader() tea.Cmd {
+	if m.statePath == "" {
+		return nil
+	}
+	path, compact := m.statePath, m.compactHeader
+	return m.saveState(func() error {
+		return state.Update(path, func(st *state.State) {
+			st.CompactHeader = compact
+		})
+	})
+}
+
+// WithColumnShares starts with the given percentages of the table widths given to the
+// adjustable columns, keyed by table (see State.ColumnShares)
+func (m Model) WithColumnShares(shares map[string]int) Model {
+	m.columnShares = make(map[string]int)
+	for name, share := range shares {
+		if _, ok := defaultColumnShares[name]; ok {
+			m.columnShares[name] = min(max(share, minColumnShare), maxColumnShare)
+		}
+	}
+	m.resizeProcessTable()
+	m.resizeProjectsTable()
+	return m
+}
+
+// saveColumnShares persists the adjusted column widths in the background
+func (m Model) saveColumnShares() tea.Cmd {
+	if m.statePath == "" {
+		return nil
+	}
+	path := m.statePath
+	shares := make(map[string]int, len(m.columnShares))
+	for name, share := range m.columnShares {
+		shares[name] = share
+	}
+	return m.saveState(func() error {
+		return state.Update(path, func(st *state.State) {
+			st.ColumnShares = shares
+		})
+	})
+}
+
+// saveRefreshInterval persists the current refresh interval in the background
+func (m Model) saveRefreshInterval() tea.Cmd {
+	if m.statePath == "" {
+		return nil
+	}
+	path, interval := m.statePath, m.updateInterval
+	return m.saveState(func() error {
+		return state.Update(path, func(st *state.State) {
+			st.RefreshInterval = state.Duration(interval)
+		})
 	})
 }
 
@@ -260,198 +1061,651 @@ func (m Model) loadSessions() tea.Cmd {
 		return nil
 	}
 
+	report := m.errReports
+	source := m.selectedProc.WorkingDir
 	return func() tea.Msg {
-		sessions, err := monitor.FindSessionsForDirectory(m.selectedProc.WorkingDir)
+		sessions, err := monitor.FindSessionsForDirectory(source)
 		if err != nil {
 			return sessionsMsg{
-				err: err,
+				err:    err,
+				source: source,
 			}
 		}
 
 		// Convert to SessionInfo for display
 		sessionInfos := make([]SessionInfo, len(sessions))
 		for i, s := range sessions {
-			// Extract metadata from session file
-			metadata, err := monitor.GetSessionMetadata(s.FilePath)
-			var startedStr, durationStr string
-			var userPrompts, interruptions int
-			var gitBranch string
-			var isSidechain bool
-			var version string
-			var firstPrompt string
-			var totalTokens, inputTokens, outputTokens int
-
-			if err == nil {
-				startedStr = metadata.Started.Format("2006-01-02 15:04")
-				// Format duration nicely
-				hours := int(metadata.Duration.Hours())
-				minutes := int(metadata.Duration.Minutes()) % 60
-				if hours > 0 {
-					durationStr = fmt.Sprintf("%dh%dm", hours, minutes)
-				} else {
-					durationStr = fmt.Sprintf("%dm", minutes)
-				}
-				userPrompts = metadata.UserPrompts
-				interruptions = metadata.Interruptions
-				gitBranch = metadata.GitBranch
-				isSidechain = metadata.IsSidechain
-				version = metadata.Version
-				firstPrompt = metadata.FirstPrompt
-				totalTokens = metadata.TotalInputTokens + metadata.TotalOutputTokens
-				inputTokens = metadata.TotalInputTokens
-				outputTokens = metadata.TotalOutputTokens
+			info := readSessionInfo(s.FilePath, report)
+			if s.Err == nil {
+				info.ID = s.ID
+				info.Updated = s.GetSessionTime()
+			} else if info.LoadError == "" {
+				info.LoadError = s.Err.Error()
 			}
+			sessionInfos[i] = info
+		}
 
-			// Extract last message info
-			var lastMessage string
-			var lastMessageTime int64
-			if stats, err := monitor.ParseSessionFile(s.FilePath); err == nil && len(stats.MessageHistory) > 0 {
-				lastMsg := stats.MessageHistory[len(stats.MessageHistory)-1]
-				lastMessageTime = lastMsg.Timestamp.Unix()
-				content := lastMsg.Content
-				if len(content) > 100 {
-					content = content[:97] + "…"
-				}
-				content = strings.Join(strings.Fields(content), " ")
-				lastMessage = content
-			}
+		return sessionsMsg{sessions: sessionInfos, source: source}
+	}
+}
 
-			sessionInfos[i] = SessionInfo{
-				ID:              s.ID,
-				Title:           s.GetSessionInfo(),
-				Updated:         s.GetSessionTime(),
-				Path:            s.FilePath,
-				Started:         startedStr,
-				Duration:        durationStr,
-				UserPrompts:     userPrompts,
-				Interruptions:   interruptions,
-				GitBranch:       gitBranch,
-				IsSidechain:     isSidechain,
-				Version:         version,
-				FirstPrompt:     firstPrompt,
-				TotalTokens:     totalTokens,
-				InputTokens:     inputTokens,
-				OutputTokens:    outputTokens,
-				LastMessage:     lastMessage,
-				LastMessageTime: lastMessageTime,
-			}
+// readSessionInfo builds the session list row for a session file. It always returns a
+// row: what cannot be read is left blank and the first failure is kept in LoadError,
+// with the file's mtime and size standing in for the missing metadata.
+func readSessionInfo(path string, report errorReports) SessionInfo {
+	id := monitor.SessionFileID(path)
+	info := SessionInfo{
+		ID:      id,
+		Title:   id,
+		Path:    path,
+		IsAgent: monitor.IsAgentFile(path),
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		report.report("load sessions", fmt.Errorf("cannot stat session file: %w", err), "path", path)
+		info.LoadError = err.Error()
+		return info
+	}
+	info.Size = fi.Size()
+	if size, compressed, err := monitor.UncompressedSize(path); compressed && err == nil {
+		info.UncompressedSize = size
+	}
+	info.Updated = fi.ModTime().Format("2006-01-02 15:04")
+	info.LastMessageTime = fi.ModTime().Unix()
+	if fi.Size() == 0 {
+		info.LoadError = "empty session file"
+		info.NoMessages = true
+		return info
+	}
+
+	// Extract metadata from session file
+	metadata, err := monitor.GetSessionMetadata(path)
+	if err == nil {
+		info.Started = metadata.Started.Format("2006-01-02 15:04")
+		info.Duration = formatSessionDuration(metadata.Duration)
+		info.UserPrompts = metadata.UserPrompts
+		info.Interruptions = metadata.Interruptions
+		info.GitBranch = metadata.GitBranch
+		info.LastBranch = metadata.LastBranch
+		info.Branches = metadata.Branches
+		info.OutsideWrites = metadata.OutsideWrites
+		info.Languages = metadata.Languages
+		info.DiffStat = metadata.DiffStat
+		info.IsSidechain = metadata.IsSidechain
+		info.Version = metadata.Version
+		info.FirstPrompt = metadata.FirstPrompt
+		info.Summary = metadata.Summary
+		info.Tokens = metadata.Tokens
+		info.Model = monitor.ModelLabel(metadata.Models)
+		info.Models = metadata.Models
+		info.ContextUsage = metadata.LastContextUsage
+		info.PermissionModes = metadata.PermissionModes
+		info.PermissionMode = metadata.PermissionMode
+		info.SessionID = metadata.SessionID
+		info.IsAgent = info.IsAgent || metadata.AgentID != ""
+		info.Tasks = metadata.Tasks
+		info.NoMessages = metadata.MessageCount == 0
+	} else {
+		report.report("load sessions", fmt.Errorf("cannot read session metadata: %w", err), "path", path)
+		info.LoadError = err.Error()
+	}
+
+	// Extract last message info
+	stats, err := monitor.ParseSessionFile(path)
+	if err != nil {
+		if info.LoadError == "" {
+			info.LoadError = err.Error()
+		}
+		return info
+	}
+	if len(stats.MessageHistory) > 0 {
+		lastMsg := stats.MessageHistory[len(stats.MessageHistory)-1]
+		info.LastMessageTime = lastMsg.Timestamp.Unix()
+		info.LastMessage = truncateText(lastMsg.Content, 100)
+	}
+	return info
+}
+
+// noMessagesTitle titles sessions whose file holds no messages yet
+const noMessagesTitle = "no messages yet"
+
+// sessionTitle returns the session's list title from the first of the sources
+// (config.SessionsConfig.TitleFrom) it has, falling back to the session ID. Side-chains
+// started by a Task call of their owner are titled by the task.
+func sessionTitle(s SessionInfo, from []string) string {
+	if label := s.Task.Label(); label != "" {
+		return truncateText(label, 0)
+	}
+	if s.NoMessages && s.Summary == "" {
+		return noMessagesTitle
+	}
+	for _, source := range from {
+		switch {
+		case source == "summary" && s.Summary != "":
+			return truncateText(s.Summary, 0)
+		case source == "prompt" && s.FirstPrompt != "":
+			return truncateText(s.FirstPrompt, 0)
+		case source == "id":
+			return s.ID
 		}
+	}
+	return s.ID
+}
 
-		return sessionsMsg{sessions: sessionInfos}
+// titleSessions sets the list titles of sessions, once linkSidechains has found the
+// tasks that started their side-chains
+func titleSessions(sessions []SessionInfo, from []string) {
+	for i := range sessions {
+		sessions[i].Title = sessionTitle(sessions[i], from)
 	}
 }
 
-// loadSessionDetail loads detailed stats for a session file
-func (m Model) loadSessionDetail() tea.Cmd {
+// formatSessionDuration formats a session's length for the LEN column, e.g. "1h5m"
+func formatSessionDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// refreshSessionRow re-reads a single session file's list row in the background,
+// e.g. after a retry succeeded for a row that previously failed to load
+func (m Model) refreshSessionRow(path string) tea.Cmd {
+	report := m.errReports
+	return func() tea.Msg {
+		return sessionRowMsg{session: readSessionInfo(path, report)}
+	}
+}
+
+// previewShown reports whether the session list is split with the preview pane, which
+// needs the terminal to be large enough for both halves
+func (m Model) previewShown() bool {
+	return m.sessionPreview && m.termWidth >= minPreviewTermWidth && m.termHeight >= minPreviewTermHeight
+}
+
+// sessionTableWidth is the width left to the session table beside the preview pane
+func (m Model) sessionTableWidth() int {
+	if m.previewShown() {
+		return m.termWidth - m.termWidth/2
+	}
+	return m.termWidth
+}
+
+// schedulePreview starts previewing the highlighted session once the selection has
+// rested on it for previewDebounce, unless it is already shown
+func (m *Model) schedulePreview() tea.Cmd {
+	if m.viewMode != ViewSessions || !m.previewShown() || m.selectedSessionIdx < 0 || m.selectedSessionIdx >= len(m.sessions) {
+		return nil
+	}
+	path := m.sessions[m.selectedSessionIdx].Path
+	if path == m.previewPath {
+		return nil
+	}
+	m.previewSeq++
+	m.previewPath = path
+	m.previewMessages = nil
+	m.previewError = ""
+	m.previewLoading = true
+	seq := m.previewSeq
+	return tea.Tick(previewDebounce, func(time.Time) tea.Msg {
+		return previewDebounceMsg{seq: seq}
+	})
+}
+
+// loadPreview reads the latest messages of the previewed session in the background
+func (m Model) loadPreview() tea.Cmd {
+	if m.previewPath == "" {
+		return nil
+	}
+	path, seq := m.previewPath, m.previewSeq
+	return func() tea.Msg {
+		stats, err := monitor.ParseSessionTail(path, previewTailBytes)
+		if err != nil {
+			return previewMsg{seq: seq, err: err}
+		}
+		messages := stats.MessageHistory
+		return previewMsg{seq: seq, messages: messages[max(len(messages)-render.PreviewMessages, 0):]}
+	}
+}
+
+// closePreview forgets the previewed session, e.g. when leaving the session list
+func (m *Model) closePreview() {
+	m.previewSeq++ // Drop results still in flight
+	m.previewPath = ""
+	m.previewMessages = nil
+	m.previewError = ""
+	m.previewLoading = false
+}
+
+// replaceSessionRow swaps in a re-read session row, keeping the ID it was listed with
+func (m *Model) replaceSessionRow(row SessionInfo) {
+	for i := range m.allSessions {
+		if m.allSessions[i].Path == row.Path {
+			row.ID = m.allSessions[i].ID
+			m.allSessions[i] = row
+		}
+	}
+	linkSidechains(m.allSessions)
+	titleSessions(m.allSessions, m.cfg.Sessions.TitleFrom)
+	m.applySessionFilter()
+	m.updateSessionTable()
+}
+
+// loadSessionDetail loads detailed stats for a session file, streaming progress
+// updates back to the UI while the file is parsed
+func (m *Model) loadSessionDetail() tea.Cmd {
 	if m.selectedSessionIdx < 0 || m.selectedSessionIdx >= len(m.sessions) {
 		return nil
 	}
 
-	selectedSession := &m.sessions[m.selectedSessionIdx]
+	path := m.sessions[m.selectedSessionIdx].Path
+
+	// Cancel any parse that is still running for a previously opened session
+	m.cancelSessionLoad()
+	root := m.ctx
+	ctx, cancel := context.WithCancel(root)
+	m.loadCancel = cancel
+	m.loadSeq++
+	m.loadingSession = true
+	m.loadProgress = 0
+	m.waitingSince = time.Time{}
+	seq := m.loadSeq
+
+	updates := make(chan tea.Msg, 1)
+	// send delivers a message unless the load was cancelled, so the parse goroutine
+	// never blocks forever once the UI has stopped listening
+	send := func(msg tea.Msg) bool {
+		select {
+		case updates <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	parse := func() tea.Msg {
+		defer close(updates)
+
+		// Show the newest messages right away, then backfill the full history
+		if tail, err := monitor.ParseSessionTail(path, sessionTailBytes); err == nil {
+			if !tail.Partial {
+				// The whole file fit in the tail window, nothing left to load
+				send(sessionDetailMsg{seq: seq, stats: tail})
+				return nil
+			}
+			if !send(sessionTailMsg{seq: seq, stats: tail, updates: updates}) {
+				return nil
+			}
+		}
 
+		stats, err := monitor.ParseSessionFileWithProgress(ctx, path, func(processed, total int64) {
+			// Drop progress updates if the UI hasn't caught up yet
+			select {
+			case updates <- sessionProgressMsg{seq: seq, processed: processed, total: total, updates: updates}:
+			default:
+			}
+		})
+		// Deliver the final result even after cancellation so partial results can be shown,
+		// but give up if nobody is reading anymore
+		select {
+		case updates <- sessionDetailMsg{seq: seq, stats: stats, err: err}:
+		case <-root.Done():
+		}
+		return nil
+	}
+
+	return tea.Batch(parse, waitForSessionLoad(updates), m.loadSpinner.Tick)
+}
+
+// checkWaiting reads the end of the open session file in the background to see whether
+// Claude has answered its latest entry yet. Compressed sessions are archived, not live.
+func (m Model) checkWaiting() tea.Cmd {
+	if m.selectedSession == nil || m.loadingSession || monitor.IsCompressedSessionFile(m.selectedSession.Path) {
+		return nil
+	}
+	path, seq := m.selectedSession.Path, m.loadSeq
 	return func() tea.Msg {
-		stats, err := monitor.ParseSessionFile(selectedSession.Path)
+		stats, err := monitor.ParseSessionTail(path, waitTailBytes)
 		if err != nil {
-			return sessionDetailMsg{
-				err: err,
+			return nil
+		}
+		since, after := monitor.Waiting(stats.MessageHistory)
+		return waitMsg{seq: seq, since: since, after: after, messages: len(stats.MessageHistory)}
+	}
+}
+
+// setWaiting records whether Claude has yet to answer the latest message of a history
+func (m *Model) setWaiting(history []monitor.Message) {
+	m.waitingSince, m.waitingAfter = monitor.Waiting(history)
+}
+
+// waitingFor returns how long Claude has been waited for, or 0 when it is not, or the
+// wait has lasted so long the session was left
+func (m Model) waitingFor(now time.Time) time.Duration {
+	if m.waitingSince.IsZero() {
+		return 0
+	}
+	wait := now.Sub(m.waitingSince)
+	if wait > maxWaitShown {
+		return 0
+	}
+	return max(wait, time.Second) // Just sent, or a clock a little ahead: still show the ticker
+}
+
+// replaceSessionStats swaps in a more complete copy of the current session, keeping the
+// selected message and its position on screen stable while older messages are added
+func (m *Model) replaceSessionStats(stats *monitor.SessionStats) {
+	var selectedUUID string
+	if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(m.messages) {
+		selectedUUID = m.messages[m.selectedMessageIdx].UUID
+	}
+	oldIdx := m.selectedMessageIdx
+	oldOffset := m.messageViewport.YOffset
+
+	m.sessionStats = stats
+	m.updateMessageTable()
+
+	if selectedUUID != "" {
+		for i, row := range m.messages {
+			if row.UUID == selectedUUID {
+				m.selectedMessageIdx = i
+				break
+			}
+		}
+	}
+
+	// Shift the viewport by however many cards were inserted above the selection
+	m.refreshMessageCards()
+	m.messageViewport.SetYOffset(oldOffset + (m.selectedMessageIdx-oldIdx)*render.CardLines)
+	m.lastMessageIdx = m.selectedMessageIdx
+}
+
+// waitForSessionLoad waits for the next progress or completion message of a session load
+func waitForSessionLoad(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// cancelSessionLoad stops an in-flight session parse, if any
+func (m *Model) cancelSessionLoad() {
+	if m.loadCancel != nil {
+		m.loadCancel()
+		m.loadCancel = nil
+	}
+}
+
+// scanProject sums up all session files of a project in the background for the project
+// stats view. A previous scan of the project stays visible until the new one is done.
+func (m *Model) scanProject(project ProjectDir) tea.Cmd {
+	m.cancelProjectScan()
+	if m.projectStatsCache == nil {
+		m.projectStatsCache = make(map[string]*monitor.ProjectStats)
+	}
+	root := m.ctx
+	ctx, cancel := context.WithCancel(root)
+	m.scanCancel = cancel
+	m.projectStatsPath = project.Path
+	m.projectStatsName = project.DisplayName
+	m.projectStatsOriginal = project.OriginalPath
+	m.projectStatsError = ""
+	m.scanningProject = true
+	m.projectScanDone, m.projectScanTotal = 0, project.Sessions+project.Agents
+	path := project.Path
+
+	updates := make(chan tea.Msg, 1)
+	scan := func() tea.Msg {
+		defer close(updates)
+		stats, err := monitor.ScanProject(ctx, path, pricing.MessageCost, func(done, total int) {
+			// Drop progress updates if the UI hasn't caught up yet
+			select {
+			case updates <- projectScanProgressMsg{path: path, done: done, total: total, updates: updates}:
+			default:
+			}
+		})
+		select {
+		case updates <- projectStatsMsg{path: path, stats: stats, err: err}:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+	return tea.Batch(scan, waitForSessionLoad(updates), m.loadSpinner.Tick)
+}
+
+// cancelProjectScan stops an in-flight project scan, if any
+func (m *Model) cancelProjectScan() {
+	if m.scanCancel != nil {
+		m.scanCancel()
+		m.scanCancel = nil
+	}
+	m.scanningProject = false
+}
+
+// applySessionFilter narrows allSessions down to the sessions matching the model,
+// permission mode and language filters, leaving out subagent sessions unless they are toggled on
+func (m *Model) applySessionFilter() {
+	m.sessions = nil
+	for _, session := range m.allSessions {
+		if session.IsAgent && !m.showAgents {
+			continue
+		}
+		if m.sessionModelFilter != "" && !monitor.MatchesModel(session.Models, m.sessionModelFilter) {
+			continue
+		}
+		if m.sessionModeFilter != "" && !slices.Contains(session.PermissionModes, m.sessionModeFilter) {
+			continue
+		}
+		if m.sessionLangFilter != "" && !monitor.HasLanguage(session.Languages, m.sessionLangFilter) {
+			continue
+		}
+		m.sessions = append(m.sessions, session)
+	}
+}
+
+// linkSidechains resolves the owning session of every side-chain and records per-parent
+// side-chain counts and tokens. A side-chain belongs to the session whose ID matches the
+// session ID recorded in its entries; side-chains whose owner is not in the list stay orphans.
+// Side-chains and subagents also get the owner's Task call whose prompt they start with.
+func linkSidechains(sessions []SessionInfo) {
+	byID := make(map[string]int, len(sessions))
+	for i, session := range sessions {
+		byID[session.ID] = i
+	}
+	for i := range sessions {
+		child := &sessions[i]
+		child.ParentID = ""
+		child.Task = monitor.TaskCall{}
+		if owner, ok := byID[child.SessionID]; ok && (child.IsSidechain || child.IsAgent) && child.SessionID != child.ID {
+			for _, task := range sessions[owner].Tasks {
+				if task.Started(child.FirstPrompt) {
+					child.Task = task
+					break
+				}
+			}
+		}
+		// Subagents are listed in their own group rather than nested
+		if !child.IsSidechain || child.IsAgent || child.SessionID == "" || child.SessionID == child.ID {
+			continue
+		}
+		p, ok := byID[child.SessionID]
+		if !ok || sessions[p].IsSidechain {
+			continue
+		}
+		child.ParentID = sessions[p].ID
+	}
+	for i := range sessions {
+		sessions[i].Sidechains = 0
+		sessions[i].SidechainTokens = monitor.TokenCounts{}
+	}
+	for _, child := range sessions {
+		if child.ParentID == "" {
+			continue
+		}
+		parent := &sessions[byID[child.ParentID]]
+		parent.Sidechains++
+		parent.SidechainTokens.Merge(child.Tokens)
+	}
+}
+
+// nestSidechains orders sessions newest first with each parent's side-chains directly
+// beneath it. Side-chains of collapsed parents are left out, and subagent sessions are
+// grouped at the bottom.
+func nestSidechains(sessions []SessionInfo, expanded map[string]bool) []SessionInfo {
+	byLastMessage := func(list []SessionInfo) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].LastMessageTime > list[j].LastMessageTime
+		})
+	}
+
+	var top, agents []SessionInfo
+	children := make(map[string][]SessionInfo)
+	for _, session := range sessions {
+		if session.IsAgent {
+			agents = append(agents, session)
+		} else if session.ParentID != "" {
+			children[session.ParentID] = append(children[session.ParentID], session)
+		} else {
+			top = append(top, session)
+		}
+	}
+	byLastMessage(top)
+
+	nested := make([]SessionInfo, 0, len(sessions))
+	for _, session := range top {
+		nested = append(nested, session)
+		if kids := children[session.ID]; len(kids) > 0 && expanded[session.ID] {
+			byLastMessage(kids)
+			nested = append(nested, kids...)
+		}
+		delete(children, session.ID)
+	}
+	// Side-chains whose parent was filtered out are shown at the top level
+	var unparented []SessionInfo
+	for _, kids := range children {
+		unparented = append(unparented, kids...)
+	}
+	byLastMessage(unparented)
+	nested = append(nested, unparented...)
+
+	byLastMessage(agents)
+	return append(nested, agents...)
+}
+
+// nextModelFilter cycles through "" (all) and each model family present in the loaded sessions
+func (m *Model) nextModelFilter() string {
+	var families []string
+	seen := make(map[string]bool)
+	for _, session := range m.allSessions {
+		for _, model := range session.Models {
+			name := monitor.ShortModelName(model)
+			if !seen[name] {
+				seen[name] = true
+				families = append(families, name)
 			}
 		}
+	}
+	sort.Strings(families)
+
+	if m.sessionModelFilter == "" {
+		if len(families) == 0 {
+			return ""
+		}
+		return families[0]
+	}
+	for i, family := range families {
+		if family == m.sessionModelFilter && i+1 < len(families) {
+			return families[i+1]
+		}
+	}
+	return ""
+}
+
+// nextModeFilter cycles through "" (all) and each permission mode the loaded sessions
+// ran in, most permissive first
+func (m *Model) nextModeFilter() string {
+	var modes []string
+	for _, session := range m.allSessions {
+		for _, mode := range session.PermissionModes {
+			if !slices.Contains(modes, mode) {
+				modes = append(modes, mode)
+			}
+		}
+	}
+	sort.SliceStable(modes, func(i, j int) bool {
+		return monitor.PermissionRank(modes[i]) > monitor.PermissionRank(modes[j])
+	})
+
+	// With no filter active, Index returns -1 and the first mode comes next
+	if i := slices.Index(modes, m.sessionModeFilter); i+1 < len(modes) {
+		return modes[i+1]
+	}
+	return ""
+}
+
+// nextLangFilter cycles through "" (all) and each language the loaded sessions have
+// code in, the language most sessions touched first
+func (m *Model) nextLangFilter() string {
+	sessions := make(map[string]int)
+	var langs []string
+	for _, session := range m.allSessions {
+		for _, lang := range session.Languages {
+			if sessions[lang.Label] == 0 {
+				langs = append(langs, lang.Label)
+			}
+			sessions[lang.Label]++
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool {
+		if sessions[langs[i]] != sessions[langs[j]] {
+			return sessions[langs[i]] > sessions[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
 
-		return sessionDetailMsg{stats: stats}
+	// With no filter active, Index returns -1 and the first language comes next
+	if i := slices.Index(langs, m.sessionLangFilter); i+1 < len(langs) {
+		return langs[i+1]
 	}
+	return ""
 }
 
 // loadSessionsFromProject loads sessions for a specific project directory
 func (m Model) loadSessionsFromProject(project ProjectDir) tea.Cmd {
+	report := m.errReports
 	return func() tea.Msg {
 		entries, err := os.ReadDir(project.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return sessionsMsg{source: project.Path} // Removed since the projects were listed: no sessions, not a failure
+		}
 		if err != nil {
 			return sessionsMsg{
-				err: fmt.Errorf("cannot read project directory: %w", err),
+				err:    fmt.Errorf("cannot read project directory: %w", err),
+				source: project.Path,
 			}
 		}
 
+		dups, err := monitor.FindDuplicatesOf(project.Path)
+		if err != nil {
+			report.report("load sessions", fmt.Errorf("cannot look for copied sessions: %w", err), "path", project.Path)
+		}
+		home, _ := os.UserHomeDir()
+
 		var sessions []SessionInfo
 
 		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
-				continue
-			}
-
-			// Get file info for modification time
-			info, err := entry.Info()
-			if err != nil {
+			if entry.IsDir() || !monitor.IsSessionFile(entry.Name()) {
 				continue
 			}
-
-			sessionPath := filepath.Join(project.Path, entry.Name())
-			// Use filename without extension as ID
-			sessionID := strings.TrimSuffix(entry.Name(), ".jsonl")
-
-			// Extract metadata from session file
-			metadata, err := monitor.GetSessionMetadata(sessionPath)
-			var startedStr, durationStr string
-			var userPrompts, interruptions int
-			var gitBranch string
-			var isSidechain bool
-			var version string
-			var firstPrompt string
-			var totalTokens, inputTokens, outputTokens int
-
-			if err == nil {
-				startedStr = metadata.Started.Format("2006-01-02 15:04")
-				// Format duration nicely
-				hours := int(metadata.Duration.Hours())
-				minutes := int(metadata.Duration.Minutes()) % 60
-				if hours > 0 {
-					durationStr = fmt.Sprintf("%dh%dm", hours, minutes)
-				} else {
-					durationStr = fmt.Sprintf("%dm", minutes)
-				}
-				userPrompts = metadata.UserPrompts
-				interruptions = metadata.Interruptions
-				gitBranch = metadata.GitBranch
-				isSidechain = metadata.IsSidechain
-				version = metadata.Version
-				firstPrompt = metadata.FirstPrompt
-				totalTokens = metadata.TotalInputTokens + metadata.TotalOutputTokens
-				inputTokens = metadata.TotalInputTokens
-				outputTokens = metadata.TotalOutputTokens
-			}
-
-			// Extract last message info
-			var lastMessage string
-			var lastMessageTime int64
-			if stats, err := monitor.ParseSessionFile(sessionPath); err == nil && len(stats.MessageHistory) > 0 {
-				lastMsg := stats.MessageHistory[len(stats.MessageHistory)-1]
-				lastMessageTime = lastMsg.Timestamp.Unix()
-				content := lastMsg.Content
-				if len(content) > 100 {
-					content = content[:97] + "…"
-				}
-				content = strings.Join(strings.Fields(content), " ")
-				lastMessage = content
-			}
-
-			sessions = append(sessions, SessionInfo{
-				ID:              sessionID,
-				Title:           sessionID, // Use ID as title for project sessions
-				Updated:         info.ModTime().Format("2006-01-02 15:04"),
-				Path:            sessionPath,
-				Started:         startedStr,
-				Duration:        durationStr,
-				UserPrompts:     userPrompts,
-				Interruptions:   interruptions,
-				GitBranch:       gitBranch,
-				IsSidechain:     isSidechain,
-				Version:         version,
-				FirstPrompt:     firstPrompt,
-				TotalTokens:     totalTokens,
-				InputTokens:     inputTokens,
-				OutputTokens:    outputTokens,
-				LastMessage:     lastMessage,
-				LastMessageTime: lastMessageTime,
-			})
+			// The file name (without extension) doubles as ID and title for project sessions
+			info := readSessionInfo(filepath.Join(project.Path, entry.Name()), report)
+			info.Copies = m.copyProjects(dups, info.Path, home)
+			sessions = append(sessions, info)
 		}
 
 		// Sort sessions by modification time (newest first)
@@ -468,19 +1722,191 @@ func (m Model) loadSessionsFromProject(project ProjectDir) tea.Cmd {
 
 		return sessionsMsg{
 			sessions: sessions,
+			source:   project.Path,
 		}
 	}
 }
 
-// loadProjects kicks off an asynchronous project directory loading
+// loadRecentSessions loads the sessions of all projects active in the last
+// cfg.Recent.Days days. Rows come from each project's sessions-index.json where it is up
+// to date with the file, so that only new or changed sessions have to be read.
+func (m Model) loadRecentSessions() tea.Cmd {
+	report := m.errReports
+	days := m.cfg.Recent.Days
+	return func() tea.Msg {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return sessionsMsg{err: fmt.Errorf("cannot get home directory: %w", err), source: "recent"}
+		}
+		files, err := monitor.FindRecentSessionFiles(time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			return sessionsMsg{err: err, source: "recent"}
+		}
+		// A session copied along with its repository is listed once, from the copy
+		// written last
+		dups, err := monitor.FindAllDuplicates()
+		if err != nil {
+			report.report("load sessions", fmt.Errorf("cannot look for copied sessions: %w", err))
+		}
+
+		names := make(map[string]string)
+		indexes := make(map[string]*monitor.SessionIndex)
+		sessions := make([]SessionInfo, 0, len(files))
+		for _, f := range files {
+			if dups.Dropped(f.Path) {
+				continue
+			}
+			if _, ok := names[f.ProjectDir]; !ok {
+				names[f.ProjectDir], _ = m.projectPaths(f.ProjectDir, home)
+				indexes[f.ProjectDir], _ = monitor.ParseSessionIndex(filepath.Join(f.ProjectDir, "sessions-index.json"))
+			}
+			info, ok := indexedSessionInfo(indexes[f.ProjectDir], f)
+			if !ok {
+				info = readSessionInfo(f.Path, report)
+			}
+			info.Project = names[f.ProjectDir]
+			info.Copies = m.copyProjects(dups, f.Path, home)
+			sessions = append(sessions, info)
+		}
+		return sessionsMsg{sessions: sessions, source: "recent"}
+	}
+}
+
+// copyProjects returns the names of the projects holding other copies of the session
+// file at path
+func (m Model) copyProjects(dups monitor.Duplicates, path, home string) []string {
+	var names []string
+	for _, other := range dups.OtherCopies(path) {
+		name, _ := m.projectPaths(filepath.Dir(other), home)
+		names = append(names, name)
+	}
+	return names
+}
+
+// indexedSessionInfo builds the session list row for f from a sessions index, and
+// reports false if the index does not list the file as it is now. Index rows have no
+// token, model or last message data.
+func indexedSessionInfo(index *monitor.SessionIndex, f monitor.RecentSessionFile) (SessionInfo, bool) {
+	if index == nil {
+		return SessionInfo{}, false
+	}
+	id := monitor.SessionFileID(f.Path)
+	for _, e := range index.Entries {
+		if (e.SessionId != id && e.FullPath != f.Path) || e.FileMtime != f.ModTime.UnixMilli() {
+			continue
+		}
+		info := SessionInfo{
+			ID:              id,
+			Title:           id,
+			Path:            f.Path,
+			IsAgent:         monitor.IsAgentFile(f.Path),
+			Updated:         f.ModTime.Format("2006-01-02 15:04"),
+			LastMessageTime: f.ModTime.Unix(),
+			GitBranch:       e.GitBranch,
+			IsSidechain:     e.IsSidechain,
+			FirstPrompt:     e.FirstPrompt,
+			Summary:         e.Summary,
+		}
+		if created, err := time.Parse(time.RFC3339, e.Created); err == nil {
+			info.Started = created.Local().Format("2006-01-02 15:04")
+			if modified, err := time.Parse(time.RFC3339, e.Modified); err == nil {
+				info.Duration = formatSessionDuration(modified.Sub(created))
+			}
+		}
+		return info, true
+	}
+	return SessionInfo{}, false
+}
+
+// loadProjects kicks off an asynchronous project directory loading, along with the
+// discovery of the Claude processes working in them unless the config turns it off
 func (m Model) loadProjects() tea.Cmd {
+	report := m.errReports
 	return func() tea.Msg {
+		dir, _ := monitor.ProjectsDir()
 		projects, err := m.getProjectDirs()
+		missing := errors.Is(err, os.ErrNotExist)
+		if missing {
+			err = nil
+		}
+		var processes []types.ClaudeProcess
+		if !m.cfg.NoProcesses {
+			var procErr error
+			processes, _, procErr = monitor.FindClaudeProcesses(monitor.DiscoveryOptions{
+				ShowHelpers:     m.showHelpers,
+				ShowSessionless: m.showSessionless,
+			})
+			if procErr != nil {
+				report.report("load projects", fmt.Errorf("cannot discover processes: %w", procErr))
+			}
+		}
 		return projectsMsg{
-			projects: projects,
-			err:      err,
+			projects:  projects,
+			processes: processes,
+			dir:       dir,
+			missing:   missing,
+			err:       err,
+		}
+	}
+}
+
+// runningPIDs returns the PIDs of the Claude instances working in the project: those
+// whose working directory is its original path, or whose sessions it holds
+func (m Model) runningPIDs(p ProjectDir) []int32 {
+	var pids []int32
+	for _, proc := range m.processes {
+		if proc.IsHelper || proc.WorkDirGone {
+			continue
+		}
+		if p.OriginalPath != "" && filepath.Clean(proc.WorkingDir) == filepath.Clean(p.OriginalPath) {
+			pids = append(pids, proc.PID)
+		} else if dir, err := monitor.ProjectDirFor(proc.WorkingDir); err == nil && dir == p.Path {
+			pids = append(pids, proc.PID)
 		}
 	}
+	slices.Sort(pids)
+	return pids
+}
+
+// loadProjectPrompts looks up the first prompt of each project's latest session in the
+// background, from the project's sessions-index.json or else the session file itself
+func (m Model) loadProjectPrompts(projects []ProjectDir) tea.Cmd {
+	report := m.errReports
+	return func() tea.Msg {
+		prompts := make(map[string]string, len(projects))
+		for _, p := range projects {
+			if p.LatestSession == "" {
+				continue
+			}
+			if prompt := indexedFirstPrompt(p.Path, p.LatestSession); prompt != "" {
+				prompts[p.Path] = prompt
+				continue
+			}
+			metadata, err := monitor.GetSessionMetadata(p.LatestSession)
+			if err != nil {
+				report.report("load project prompts", fmt.Errorf("cannot read session metadata: %w", err), "path", p.LatestSession)
+				continue
+			}
+			prompts[p.Path] = metadata.FirstPrompt
+		}
+		return projectPromptsMsg{prompts: prompts}
+	}
+}
+
+// indexedFirstPrompt returns the first prompt sessions-index.json in dir records for
+// the session file, or "" if the index does not list it
+func indexedFirstPrompt(dir, sessionPath string) string {
+	index, err := monitor.ParseSessionIndex(filepath.Join(dir, "sessions-index.json"))
+	if err != nil {
+		return ""
+	}
+	id := monitor.SessionFileID(sessionPath)
+	for _, e := range index.Entries {
+		if e.SessionId == id || e.FullPath == sessionPath {
+			return e.FirstPrompt
+		}
+	}
+	return ""
 }
 
 // getProjectDirs returns all project directories sorted by modification time (newest first)
@@ -490,7 +1916,10 @@ func (m Model) getProjectDirs() ([]ProjectDir, error) {
 		return nil, fmt.Errorf("cannot get home directory: %w", err)
 	}
 
-	projectsPath := filepath.Join(home, ".claude", "projects")
+	projectsPath, err := monitor.ProjectsDir()
+	if err != nil {
+		return nil, err
+	}
 	entries, err := os.ReadDir(projectsPath)
 	if err != nil {
 		return nil, fmt.Errorf("cannot read projects directory: %w", err)
@@ -508,36 +1937,42 @@ func (m Model) getProjectDirs() ([]ProjectDir, error) {
 			continue
 		}
 
-		// Count JSONL files in this directory
-		sessionCount := 0
+		// Count JSONL files in this directory, keeping subagent files separate
+		sessionCount, agentCount := 0, 0
+		var latest string
+		var latestModified time.Time
 		dirPath := filepath.Join(projectsPath, entry.Name())
 		sessionEntries, err := os.ReadDir(dirPath)
 		if err == nil {
 			for _, se := range sessionEntries {
-				if !se.IsDir() && strings.HasSuffix(se.Name(), ".jsonl") {
-					sessionCount++
+				if se.IsDir() || !monitor.IsSessionFile(se.Name()) {
+					continue
+				}
+				if monitor.IsAgentFile(se.Name()) {
+					agentCount++
+					continue
+				}
+				sessionCount++
+				if si, err := se.Info(); err == nil && si.ModTime().After(latestModified) {
+					latest, latestModified = filepath.Join(dirPath, se.Name()), si.ModTime()
 				}
 			}
+		} else {
+			m.errReports.report("load projects", fmt.Errorf("cannot read project directory: %w", err), "path", dirPath)
 		}
 
-		// Try to get the original path from sessions-index.json
-		// If not found, decode the directory name (which uses dashes for slashes)
-		displayName := decodeProjectName(entry.Name(), home)
-
-		indexPath := filepath.Join(dirPath, "sessions-index.json")
-		if indexData, err := os.ReadFile(indexPath); err == nil {
-			// Extract originalPath from JSON
-			if origPath := extractOriginalPath(string(indexData)); origPath != "" {
-				displayName = formatProjectPath(origPath, home)
-			}
-		}
+		displayName, originalPath := m.projectPaths(dirPath, home)
 
 		projects = append(projects, ProjectDir{
-			Name:        entry.Name(),
-			Path:        dirPath,
-			DisplayName: displayName,
-			Modified:    info.ModTime(),
-			Sessions:    sessionCount,
+			Name:         entry.Name(),
+			Path:         dirPath,
+			DisplayName:  displayName,
+			OriginalPath: originalPath,
+			Modified:     info.ModTime(),
+			Sessions:     sessionCount,
+			Agents:       agentCount,
+
+			LatestSession: latest,
 		})
 	}
 
@@ -553,6 +1988,26 @@ func (m Model) getProjectDirs() ([]ProjectDir, error) {
 	return projects, nil
 }
 
+// projectPaths returns the human-readable name of a project directory and its original
+// path. The name is the original path from its sessions-index.json, or else the decoded
+// directory name (which uses dashes for slashes); the original path is "" without an index.
+func (m Model) projectPaths(dirPath, home string) (displayName, originalPath string) {
+	displayName = decodeProjectName(filepath.Base(dirPath), home)
+
+	indexPath := filepath.Join(dirPath, "sessions-index.json")
+	if indexData, err := os.ReadFile(indexPath); err == nil {
+		// Extract originalPath from JSON
+		if origPath := extractOriginalPath(string(indexData)); origPath != "" {
+			displayName, originalPath = formatProjectPath(origPath), origPath
+		} else {
+			m.errReports.report("load projects", errors.New("sessions index has no originalPath"), "path", indexPath)
+		}
+	} else if !os.IsNotExist(err) {
+		m.errReports.report("load projects", fmt.Errorf("cannot read sessions index: %w", err), "path", indexPath)
+	}
+	return displayName, originalPath
+}
+
 // extractOriginalPath extracts the originalPath value from a JSON string
 func extractOriginalPath(jsonStr string) string {
 	// Look for "originalPath": "..."
@@ -584,10 +2039,8 @@ func extractOriginalPath(jsonStr string) string {
 }
 
 // formatProjectPath converts an absolute path to a user-friendly display format
-func formatProjectPath(path string, home string) string {
-	// Replace /Users/username with ~/
-	path = strings.ReplaceAll(path, home, "~")
-	return path
+func formatProjectPath(path string) string {
+	return monitor.ShortenHomePath(path)
 }
 
 // decodeProjectName converts an encoded project directory name to a readable path
@@ -0,0 +1,576 @@
+package ui
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evertras/bubble-table/table"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/state"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+	"go.uber.org/goleak"
+)
+
+// writeLargeSession writes a session file big enough that loading it needs a tail pass and a full parse
+func writeLargeSession(t *testing.T) string {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; b.Len() < 2*sessionTailBytes; i++ {
+		fmt.Fprintf(&b, `{"type":"user","uuid":"u%d","timestamp":"2026-01-09T14:00:00.000Z","message":{"role":"user","content":"prompt %d %s"}}`+"\n",
+			i, i, strings.Repeat("x", 200))
+	}
+	path := filepath.Join(t.TempDir(), "large.jsonl")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
+// TestShutdownStopsSessionLoad tests that an abandoned session load does not leak its goroutine
+func TestShutdownStopsSessionLoad(t *testing.T) {
+	// Programs run by earlier tests leave their ticks and signal handlers behind
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	m := NewModel(time.Second, false)
+	m.sessions = []SessionInfo{{Path: writeLargeSession(t)}}
+
+	batch, ok := m.loadSessionDetail()().(tea.BatchMsg)
+	if !ok || len(batch) == 0 {
+		t.Fatal("loadSessionDetail should return a batch of commands")
+	}
+
+	// Run the parse without ever reading its updates, as happens when the UI quits mid-load
+	done := make(chan struct{})
+	go func() {
+		batch[0]()
+		close(done)
+	}()
+
+	m.Shutdown()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("session load did not stop after Shutdown")
+	}
+}
+
+// TestShutdownFlushesSaves tests that state saves and session opens whose commands never
+// ran, as when quitting right after a key press, are written by Shutdown
+func TestShutdownFlushesSaves(t *testing.T) {
+	dir := t.TempDir()
+	statePath, historyPath := filepath.Join(dir, "state.json"), filepath.Join(dir, "history.json")
+	m := NewModel(time.Second, false).WithStateFile(statePath).WithHistoryFile(historyPath)
+	m.compactHeader = true
+	if m.saveView("projects") == nil || m.saveCompactHeader() == nil || m.recordVisit("/p/a.jsonl") == nil {
+		t.Fatal("saves return no commands")
+	}
+
+	m.Shutdown()
+
+	if st, err := state.Load(statePath); err != nil || st.View != "projects" || !st.CompactHeader {
+		t.Errorf("state after Shutdown = %+v, %v; want both saves", st, err)
+	}
+	if visits, err := state.LoadHistory(historyPath); err != nil || len(visits) != 1 {
+		t.Errorf("history after Shutdown = %v, %v; want the session open", visits, err)
+	}
+}
+
+// TestQuitConfirmation tests the "really quit?" prompt while a session is loading
+func TestQuitConfirmation(t *testing.T) {
+	m := NewModel(time.Second, false).WithQuitConfirmation(true)
+	defer m.Shutdown()
+	m.loadingSession = true
+
+	q := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}}
+	n := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}}
+	y := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}}
+
+	updated, cmd := m.Update(q)
+	m = updated.(Model)
+	if m.confirm == nil || m.quitting || cmd != nil {
+		t.Fatal("q while loading should ask for confirmation")
+	}
+	if view := m.View(); !strings.Contains(view, "Really quit? (y/N)") {
+		t.Errorf("prompt not shown:\n%s", view)
+	}
+
+	// Other keys are swallowed until the prompt is answered
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+	m = updated.(Model)
+	if m.confirm == nil || m.showFullHelp {
+		t.Fatal("? should be swallowed while the prompt is showing")
+	}
+
+	updated, _ = m.Update(n)
+	m = updated.(Model)
+	if m.confirm != nil || m.quitting {
+		t.Fatal("n should dismiss the prompt without quitting")
+	}
+
+	updated, _ = m.Update(q)
+	updated, cmd = updated.(Model).Update(y)
+	m = updated.(Model)
+	if !m.quitting || cmd == nil {
+		t.Fatal("y should confirm the quit")
+	}
+
+	// Without background work q quits immediately
+	idle := NewModel(time.Second, false).WithQuitConfirmation(true)
+	updated, _ = idle.Update(q)
+	if !updated.(Model).quitting {
+		t.Error("q with no background work should quit immediately")
+	}
+}
+
+// TestNestSidechains tests linking side-chains to their parent and nesting them beneath it
+func TestNestSidechains(t *testing.T) {
+	sessions := []SessionInfo{
+		{ID: "main", SessionID: "main", LastMessageTime: 10, Tokens: monitor.TokenCounts{Input: 100, Output: 10}},
+		{ID: "agent-a", SessionID: "main", IsSidechain: true, LastMessageTime: 30, Tokens: monitor.TokenCounts{Input: 5, Output: 1, CacheRead: 50}},
+		{ID: "agent-b", SessionID: "main", IsSidechain: true, LastMessageTime: 20, Tokens: monitor.TokenCounts{Input: 7, Output: 2, CacheRead: 70}},
+		{ID: "other", SessionID: "other", LastMessageTime: 15},
+		{ID: "agent-orphan", SessionID: "gone", IsSidechain: true, LastMessageTime: 40},
+	}
+	linkSidechains(sessions)
+
+	if p := sessions[0]; p.Sidechains != 2 || p.SidechainTokens != (monitor.TokenCounts{Input: 12, Output: 3, CacheRead: 120}) {
+		t.Errorf("parent = %+v, want 2 side-chains with 12/3/120 tokens", p)
+	}
+	if sessions[4].ParentID != "" {
+		t.Errorf("orphan side-chain got parent %q", sessions[4].ParentID)
+	}
+
+	ids := func(list []SessionInfo) string {
+		var out []string
+		for _, s := range list {
+			out = append(out, s.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name     string
+		expanded map[string]bool
+		want     string
+	}{
+		{"collapsed", nil, "agent-orphan,other,main"},
+		{"expanded", map[string]bool{"main": true}, "agent-orphan,other,main,agent-a,agent-b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(nestSidechains(sessions, tt.expanded)); got != tt.want {
+				t.Errorf("nestSidechains() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAgentSessionsHiddenByDefault tests that subagent sessions are filtered out until toggled on
+func TestAgentSessionsHiddenByDefault(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.allSessions = []SessionInfo{
+		{ID: "agent-1", IsAgent: true, IsSidechain: true, LastMessageTime: 30},
+		{ID: "main", LastMessageTime: 10},
+		{ID: "other", LastMessageTime: 20},
+	}
+
+	m.applySessionFilter()
+	if len(m.sessions) != 2 {
+		t.Fatalf("default list has %d sessions, want 2", len(m.sessions))
+	}
+
+	m.showAgents = true
+	m.applySessionFilter()
+	nested := nestSidechains(m.sessions, nil)
+	if len(nested) != 3 || nested[2].ID != "agent-1" {
+		t.Errorf("with agents shown, got %v; want agent-1 last", nested)
+	}
+}
+
+func TestPermissionModeFilter(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewSessions
+	m.allSessions = []SessionInfo{
+		{ID: "planned", PermissionModes: []string{"plan"}, PermissionMode: "plan", LastMessageTime: 30},
+		{ID: "mixed", PermissionModes: []string{"plan", "acceptEdits"}, PermissionMode: "acceptEdits", LastMessageTime: 20},
+		{ID: "plain", LastMessageTime: 10},
+	}
+	m.applySessionFilter()
+	m.updateSessionTable()
+	if view := m.View(); !strings.Contains(view, "⏸ plan") || !strings.Contains(view, "⚡ auto") {
+		t.Errorf("session list lacks the mode badges:\n%s", view)
+	}
+
+	// M cycles from the most permissive mode present back to no filter
+	for _, want := range []struct {
+		filter string
+		ids    []string
+	}{
+		{"acceptEdits", []string{"mixed"}},
+		{"plan", []string{"planned", "mixed"}},
+		{"", []string{"planned", "mixed", "plain"}},
+	} {
+		updated, _ := m.Update(key("M"))
+		m = updated.(Model)
+		var ids []string
+		for _, s := range m.sessions {
+			ids = append(ids, s.ID)
+		}
+		if m.sessionModeFilter != want.filter || !slices.Equal(ids, want.ids) {
+			t.Errorf("filter %q lists %v; want %q listing %v", m.sessionModeFilter, ids, want.filter, want.ids)
+		}
+	}
+}
+
+// TestSessionContextColumn tests that the session list shows how full each session's
+// context window was at its last response
+func TestSessionContextColumn(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewSessions
+	m.allSessions = []SessionInfo{
+		{ID: "near-limit", ContextUsage: 0.93, LastMessageTime: 30},
+		{ID: "roomy", ContextUsage: 0.214, LastMessageTime: 20},
+		{ID: "unknown", LastMessageTime: 10},
+	}
+	m.applySessionFilter()
+	m.updateSessionTable()
+
+	if view := m.View(); !strings.Contains(view, render.ContextHeader) {
+		t.Errorf("session list lacks the CTX column:\n%s", view)
+	}
+	want := map[string]string{"near-limit": "93%", "roomy": "21%", "unknown": "-"}
+	rows := m.sessionTable.GetVisibleRows()
+	for i, session := range m.sessions {
+		cell, ok := rows[i].Data["context"].(table.StyledCell)
+		if !ok || cell.Data != want[session.ID] {
+			t.Errorf("%s: CTX cell %v, want %q", session.ID, rows[i].Data["context"], want[session.ID])
+		}
+	}
+}
+
+// TestSessionChangesColumns tests that the FILES and LINES columns are only shown when
+// the config asks for them
+func TestSessionChangesColumns(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 220, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewSessions
+	m.allSessions = []SessionInfo{
+		{ID: "refactor", DiffStat: monitor.DiffStat{Files: 7, Added: 412, Removed: 96}, LastMessageTime: 20},
+		{ID: "chat", LastMessageTime: 10},
+	}
+	m.applySessionFilter()
+	m.updateSessionTable()
+	if view := m.View(); strings.Contains(view, "FILES") {
+		t.Errorf("session list shows changes without sessions.showChanges:\n%s", view)
+	}
+
+	cfg := config.Default()
+	cfg.Sessions.ShowChanges = true
+	m = m.WithConfig(cfg)
+	m.updateSessionTable()
+	if view := m.View(); !strings.Contains(view, "FILES") || !strings.Contains(view, "+412 −96") {
+		t.Errorf("session list lacks the changes columns:\n%s", view)
+	}
+	rows := m.sessionTable.GetVisibleRows()
+	if rows[1].Data["files"] != "-" || rows[1].Data["lines"] != "-" {
+		t.Errorf("session without writes: FILES %v, LINES %v; want -", rows[1].Data["files"], rows[1].Data["lines"])
+	}
+}
+
+// TestLanguageFilter tests that L cycles through the languages of the loaded sessions,
+// the one most sessions touched first, and back to no filter
+func TestLanguageFilter(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewSessions
+	m.allSessions = []SessionInfo{
+		{ID: "api", Languages: []monitor.EntryCount{{Label: "go", Count: 9}, {Label: "sql", Count: 2}}, LastMessageTime: 30},
+		{ID: "migration", Languages: []monitor.EntryCount{{Label: "sql", Count: 4}}, LastMessageTime: 20},
+		{ID: "chat", LastMessageTime: 10},
+	}
+	m.applySessionFilter()
+	m.updateSessionTable()
+
+	for _, want := range []struct {
+		filter string
+		ids    []string
+	}{
+		{"sql", []string{"api", "migration"}},
+		{"go", []string{"api"}},
+		{"", []string{"api", "migration", "chat"}},
+	} {
+		updated, _ := m.Update(key("L"))
+		m = updated.(Model)
+		var ids []string
+		for _, s := range m.sessions {
+			ids = append(ids, s.ID)
+		}
+		if m.sessionLangFilter != want.filter || !slices.Equal(ids, want.ids) {
+			t.Errorf("filter %q lists %v; want %q listing %v", m.sessionLangFilter, ids, want.filter, want.ids)
+		}
+		if want.filter != "" && !strings.Contains(m.View(), "Language: "+want.filter) {
+			t.Errorf("session list does not show the language filter %q", want.filter)
+		}
+	}
+}
+
+// TestReadSessionInfoUnreadableFiles tests that broken session files still produce a row with a load error
+func TestReadSessionInfoUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	empty := filepath.Join(dir, "empty.jsonl")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := filepath.Join(dir, "locked.jsonl")
+	if err := os.WriteFile(locked, []byte(`{"type":"user","timestamp":"2026-01-09T14:00:00Z"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(locked, 0o000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o644) })
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"empty file", empty, "empty session file"},
+		{"unreadable file", locked, "permission denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.path == locked {
+				if f, err := os.Open(locked); err == nil {
+					f.Close()
+					t.Skip("file permissions are not enforced for this user")
+				}
+			}
+			info := readSessionInfo(tt.path, nil)
+			if info.ID != strings.TrimSuffix(filepath.Base(tt.path), ".jsonl") || info.Updated == "" {
+				t.Errorf("row = %+v, want ID and mtime from the file", info)
+			}
+			if !strings.Contains(info.LoadError, tt.want) {
+				t.Errorf("LoadError = %q, want it to mention %q", info.LoadError, tt.want)
+			}
+		})
+	}
+}
+
+// TestSessionWithoutMessages tests that a session file holding only file snapshots is
+// listed as a session with no messages yet, opens to an empty detail view, and turns
+// into a regular row and detail once Claude writes its first messages
+func TestSessionWithoutMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "7c1d.jsonl")
+	snapshot := `{"type":"file-history-snapshot","messageId":"m1","snapshot":{"messageId":"m1","trackedFileBackups":{}},"isSnapshotUpdate":false}` + "\n"
+	if err := os.WriteFile(path, []byte(snapshot), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info := readSessionInfo(path, nil)
+	if info.LoadError != "" || !info.NoMessages || info.Started == "" {
+		t.Fatalf("row = %+v, want a session with no messages and a start time", info)
+	}
+	if title := sessionTitle(info, []string{"summary", "prompt", "id"}); title != noMessagesTitle {
+		t.Errorf("title = %q, want %q", title, noMessagesTitle)
+	}
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+	m.allSessions = []SessionInfo{info}
+	m.sessions = m.allSessions
+	if cmd := m.refreshEmptySessions(); cmd == nil || cmd() != nil {
+		t.Error("an unchanged file was read again")
+	}
+
+	m.viewMode = ViewSessionDetail
+	m.selectedSession = &m.sessions[0]
+	m.sessionStats = &monitor.SessionStats{}
+	m.updateMessageTable()
+	if view := m.View(); !strings.Contains(view, "No messages yet") {
+		t.Errorf("empty detail view does not say so:\n%s", view)
+	}
+
+	prompt := `{"type":"user","timestamp":"2026-01-09T14:00:00Z","message":{"role":"user","content":"hello"}}` + "\n"
+	if err := os.WriteFile(path, []byte(snapshot+prompt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	row, ok := m.refreshEmptySessions()().(sessionRowMsg)
+	if !ok || row.session.NoMessages || row.session.FirstPrompt != "hello" {
+		t.Errorf("grown file re-read as %+v, want a row with the first prompt", row.session)
+	}
+
+	updated, cmd := m.Update(waitMsg{seq: m.loadSeq, messages: 1})
+	m = updated.(Model)
+	if cmd == nil || !m.loadingSession {
+		t.Error("the first messages did not reload the open session")
+	}
+	m.cancelSessionLoad()
+}
+
+// TestReadSessionInfoCompressed tests the row of a gzip-compressed session file
+func TestReadSessionInfoCompressed(t *testing.T) {
+	data := []byte(`{"type":"user","timestamp":"2026-01-09T14:00:00Z","message":{"role":"user","content":"hello"}}` + "\n")
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	path := filepath.Join(t.TempDir(), "3f2a9c1e.jsonl.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info := readSessionInfo(path, nil)
+	if info.LoadError != "" || info.ID != "3f2a9c1e" || info.FirstPrompt != "hello" {
+		t.Errorf("row = %+v, want ID 3f2a9c1e and the first prompt", info)
+	}
+	if info.Size != int64(buf.Len()) || info.UncompressedSize != int64(len(data)) {
+		t.Errorf("sizes = %d/%d, want %d compressed, %d uncompressed", info.Size, info.UncompressedSize, buf.Len(), len(data))
+	}
+}
+
+// TestSessionTitle tests that titles follow the configured sources in order
+func TestSessionTitle(t *testing.T) {
+	full := SessionInfo{ID: "3f2a9c1e", Summary: "Fix the\nlogin test", FirstPrompt: "the login test fails"}
+	tests := []struct {
+		name    string
+		session SessionInfo
+		from    []string
+		want    string
+	}{
+		{"summary first", full, []string{"summary", "prompt", "id"}, "Fix the login test"},
+		{"prompt first", full, []string{"prompt", "summary"}, "the login test fails"},
+		{"no summary", SessionInfo{ID: "3f2a9c1e", FirstPrompt: "hello"}, []string{"summary", "prompt", "id"}, "hello"},
+		{"id before prompt", full, []string{"id", "prompt"}, "3f2a9c1e"},
+		{"nothing available", SessionInfo{ID: "3f2a9c1e"}, []string{"summary", "prompt"}, "3f2a9c1e"},
+		{"started by a task", SessionInfo{ID: "agent-7b", FirstPrompt: "List every handler", Task: monitor.TaskCall{Description: "Find handlers", SubagentType: "Explore"}}, []string{"prompt"}, "Explore: Find handlers"},
+	}
+	for _, tt := range tests {
+		if got := sessionTitle(tt.session, tt.from); got != tt.want {
+			t.Errorf("%s: sessionTitle = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSidechainTasks tests that side-chain and agent sessions are titled by the Task
+// call that started them, and fall back to their own title without one
+func TestSidechainTasks(t *testing.T) {
+	tasks := []monitor.TaskCall{
+		{Description: "Find the auth handlers", Prompt: "List every HTTP handler", SubagentType: "Explore"},
+		{Description: "Check the tests", Prompt: "Run the auth tests"},
+	}
+	sessions := []SessionInfo{
+		{ID: "main", SessionID: "main", FirstPrompt: "review the auth code", Tasks: tasks},
+		{ID: "agent-a", SessionID: "main", IsSidechain: true, FirstPrompt: "Run the auth tests"},
+		{ID: "agent-b", SessionID: "main", IsSidechain: true, IsAgent: true, FirstPrompt: "  List every HTTP handler\n"},
+		{ID: "agent-c", SessionID: "main", IsSidechain: true, FirstPrompt: "something else"},
+		{ID: "agent-orphan", SessionID: "gone", IsSidechain: true, FirstPrompt: "Run the auth tests"},
+		{ID: "other", SessionID: "other", FirstPrompt: "Run the auth tests"},
+	}
+	linkSidechains(sessions)
+	titleSessions(sessions, []string{"summary", "prompt", "id"})
+
+	want := []string{"review the auth code", "Check the tests", "Explore: Find the auth handlers", "something else", "Run the auth tests", "Run the auth tests"}
+	for i, s := range sessions {
+		if s.Title != want[i] {
+			t.Errorf("%s: title = %q, want %q", s.ID, s.Title, want[i])
+		}
+	}
+
+	// Relinking without the owner's tasks falls back to the prompt
+	sessions[0].Tasks = nil
+	linkSidechains(sessions)
+	titleSessions(sessions, []string{"prompt"})
+	if sessions[2].Title != "List every HTTP handler" {
+		t.Errorf("after losing the task: title = %q", sessions[2].Title)
+	}
+}
+
+// TestLoadProjectPrompts tests that a project's last prompt comes from sessions-index.json
+// when it lists the latest session and from the session file otherwise
+func TestLoadProjectPrompts(t *testing.T) {
+	dir := t.TempDir()
+	indexed := filepath.Join(dir, "indexed")
+	plain := filepath.Join(dir, "plain")
+	for _, d := range []string{indexed, plain} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session := `{"type":"user","timestamp":"2026-01-09T14:00:00Z","message":{"role":"user","content":"from the file"}}` + "\n"
+	indexedSession := filepath.Join(indexed, "3f2a9c1e.jsonl")
+	plainSession := filepath.Join(plain, "7b1d0f22.jsonl")
+	for _, p := range []string{indexedSession, plainSession} {
+		if err := os.WriteFile(p, []byte(session), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := `{"version":1,"entries":[{"sessionId":"3f2a9c1e","firstPrompt":"from the index"}]}`
+	if err := os.WriteFile(filepath.Join(indexed, "sessions-index.json"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.projects = []ProjectDir{
+		{Path: indexed, LatestSession: indexedSession},
+		{Path: plain, LatestSession: plainSession},
+		{Path: filepath.Join(dir, "empty")},
+	}
+	msg := m.loadProjectPrompts(m.projects)()
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+
+	want := []string{"from the index", "from the file", ""}
+	for i, p := range m.projects {
+		if p.LastPrompt != want[i] {
+			t.Errorf("project %d LastPrompt = %q, want %q", i, p.LastPrompt, want[i])
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text   string
+		maxLen int
+		want   string
+	}{
+		{"short", 10, "short"},
+		{"fix the\n  login   bug", 40, "fix the login bug"},
+		{"exactly10!", 10, "exactly10!"},
+		{"Größenänderung der Tabelle", 8, "Größenä…"},
+		{"日本語のプロンプト", 5, "日本…"},
+		{"日本語のプロンプト", 18, "日本語のプロンプト"},
+		{"deploy 🚀 now", 10, "deploy 🚀…"},
+		{"deploy 🚀 now", 8, "deploy …"},
+	}
+	for _, tt := range tests {
+		if got := truncateText(tt.text, tt.maxLen); got != tt.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.maxLen, got, tt.want)
+		}
+	}
+}
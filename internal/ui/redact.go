@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"github.com/thieso2/promptwatch/internal/diff"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// WithRedaction starts with message content and paths redacted, for screen sharing
+func (m Model) WithRedaction(on bool) Model {
+	m.redact = on
+	return m
+}
+
+// toggleRedaction switches redaction on or off and redraws the tables and cards, whose
+// cells are rendered ahead of the view
+func (m *Model) toggleRedaction() {
+	m.redact = !m.redact
+	m.updateTable()
+	m.updateProjectsTable()
+	m.updateSessionTable()
+	if m.sessionStats != nil {
+		m.refreshMessageCards()
+	}
+}
+
+// shownText returns text as previews and list cells show it: only its size while
+// redacting
+func (m Model) shownText(text string) string {
+	if m.redact {
+		return render.Redacted(text)
+	}
+	return text
+}
+
+// shownPath returns a working directory or file path as shown: only its last element
+// while redacting
+func (m Model) shownPath(path string) string {
+	if m.redact {
+		return render.RedactPath(path)
+	}
+	return path
+}
+
+// shownPaths applies shownPath to each path, leaving paths alone
+func (m Model) shownPaths(paths []string) []string {
+	if !m.redact || len(paths) == 0 {
+		return paths
+	}
+	shown := make([]string, len(paths))
+	for i, path := range paths {
+		shown[i] = m.shownPath(path)
+	}
+	return shown
+}
+
+// shownMessage returns msg as the detail views show it: while redacting, a copy whose
+// text and tool arguments are masked and whose working directory is cut to its name
+func (m Model) shownMessage(msg monitor.Message) monitor.Message {
+	if !m.redact {
+		return msg
+	}
+	msg.Content = render.Mask(msg.Content)
+	msg.ToolInput = render.Mask(msg.ToolInput)
+	msg.WorkingDir = render.RedactPath(msg.WorkingDir)
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]monitor.ToolCall, len(msg.ToolCalls))
+		for i, call := range msg.ToolCalls {
+			call.Input = render.Mask(call.Input)
+			calls[i] = call
+		}
+		msg.ToolCalls = calls
+	}
+	return msg
+}
+
+// shownMessages applies shownMessage to each message, keeping nil entries
+func (m Model) shownMessages(msgs []*monitor.Message) []*monitor.Message {
+	if !m.redact {
+		return msgs
+	}
+	shown := make([]*monitor.Message, len(msgs))
+	for i, msg := range msgs {
+		if msg != nil {
+			copied := m.shownMessage(*msg)
+			shown[i] = &copied
+		}
+	}
+	return shown
+}
+
+// shownOps returns the diff as shown: while redacting, with the text of every edit
+// masked
+func (m Model) shownOps(ops []diff.Op) []diff.Op {
+	if !m.redact {
+		return ops
+	}
+	shown := make([]diff.Op, len(ops))
+	for i, op := range ops {
+		shown[i] = diff.Op{Kind: op.Kind, Text: render.Mask(op.Text)}
+	}
+	return shown
+}
+
+// shownProjectStats returns the project summary as shown: while redacting, a copy
+// whose largest pastes are masked
+func (m Model) shownProjectStats(stats *monitor.ProjectStats) *monitor.ProjectStats {
+	if !m.redact || stats == nil {
+		return stats
+	}
+	copied := *stats
+	copied.LargestPastes = make([]monitor.Paste, len(stats.LargestPastes))
+	for i, paste := range stats.LargestPastes {
+		paste.Prompt = render.Mask(paste.Prompt)
+		copied.LargestPastes[i] = paste
+	}
+	return &copied
+}
@@ -0,0 +1,370 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// CardLines is the fixed height of a message or turn card (header + content + metrics + separator)
+const CardLines = 4
+
+// cardWidth is the width of cards, rules and separators drawn where the width of the
+// terminal is not known
+const cardWidth = 88
+
+// widthOr returns width, or cardWidth if it is not known (0)
+func widthOr(width int) int {
+	if width <= 0 {
+		return cardWidth
+	}
+	return width
+}
+
+// cardLines joins the lines of a card, cutting off whatever would be wider than width:
+// a line soft-wrapped by the viewport would make the card taller than CardLines
+func cardLines(width int, lines ...string) string {
+	return lipgloss.NewStyle().MaxWidth(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// CardData is everything a message card shows
+type CardData struct {
+	Role            string // "user" or "assistant"
+	Content         string
+	Time            string // Timestamp (ISO8601)
+	Model           string // Claude model used (assistant only)
+	UUID            string
+	InputTokens     int
+	OutputTokens    int
+	CacheWrite      int
+	CacheRead       int
+	EstimatedTokens int     // Approximate prompt size (user prompts only)
+	PastedBytes     int     // Size of the content pasted into the prompt (user prompts only)
+	ContextUsage    float64 // Context window usage (assistant only, 0–1)
+	ContextGrowth   int     // Context tokens added since the previous response (assistant only)
+	// LargeContextGrowth flags ContextGrowth as an unusually large jump
+	LargeContextGrowth bool
+	// Throughput is the output rate in tokens per second (assistant only; 0 if unknown),
+	// marked 🐢 when Slow
+	Throughput   float64
+	Slow         bool
+	Cost         float64
+	RunningTotal float64            // Session cost so far, shown as "Σ $2.31" when > 0 (assistant only)
+	Marked       bool               // Marked with "m" as the old side of a diff
+	Decision     string             // Answer to the tool call's permission prompt, "" if none
+	Refusal      bool               // The response is flagged as a refusal (assistant only)
+	ToolResult   bool               // Content is a tool's output
+	ToolCalls    []monitor.ToolCall // Tool calls the message makes (assistant only)
+	Preview      string             // How the content line is picked, one of config.PreviewModes
+	Width        int                // Width of the card; 0 if unknown
+}
+
+// TurnCardData is everything a turn header card shows
+type TurnCardData struct {
+	Index     int // 1-based turn number
+	Start     time.Time
+	ToolCalls int
+	Tokens    int // TokenCounts.Total of the turn
+	Cost      float64
+	Duration  time.Duration
+	Messages  int
+	Content   string // The prompt that started the turn
+	Expanded  bool
+	Preview   string // How the content line is picked, one of config.PreviewModes
+	Width     int    // Width of the card; 0 if unknown
+}
+
+// ToolGroupCardData is everything a tool section header card shows
+type ToolGroupCardData struct {
+	Tool     string    // Tool the section's calls are made with, e.g. "Bash"
+	Calls    int       // Tool calls in the section
+	Cost     float64   // Cost of the responses making the calls
+	First    time.Time // Earliest call
+	Last     time.Time // Latest call
+	Expanded bool
+	Width    int // Width of the card; 0 if unknown
+}
+
+// BranchCardData is everything a branch switch divider shows
+type BranchCardData struct {
+	Branch string    // Branch switched to
+	From   string    // Branch switched from
+	At     time.Time // First message on Branch
+	Width  int       // Width of the divider; 0 if unknown
+}
+
+// BranchCard renders the divider between messages on different git branches, as tall
+// as a message card
+func BranchCard(d BranchCardData, isSelected bool) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Bold(true)
+	if isSelected {
+		style = style.
+			Foreground(lipgloss.Color("228")).
+			Background(lipgloss.Color("23")).
+			Padding(0, 1)
+	}
+	header := style.Render("⎇ switched to " + d.Branch)
+	detail := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(fmt.Sprintf("from %s at %s", d.From, d.At.Local().Format("15:04")))
+	width := widthOr(d.Width)
+	return cardLines(width, header, detail, "", separator(isSelected, "┄", width))
+}
+
+// ToolGroupCard renders the header of a section of tool calls made with one tool, as
+// tall as a message card
+func ToolGroupCard(d ToolGroupCardData, isSelected bool, costs config.CostConfig) string {
+	marker := "▸"
+	if d.Expanded {
+		marker = "▾"
+	}
+	headerParts := []string{fmt.Sprintf("%s %s (%d)", marker, d.Tool, d.Calls)}
+	if !costs.Hidden {
+		headerParts = append(headerParts, FormatCost(d.Cost, TotalPrecision))
+	}
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	if isSelected {
+		headerStyle = headerStyle.
+			Foreground(lipgloss.Color("228")).
+			Background(lipgloss.Color("23")).
+			Padding(0, 1)
+	}
+	headerLine := headerStyle.Render(strings.Join(headerParts, ", "))
+
+	span := d.First.Local().Format("15:04")
+	if d.Last.Sub(d.First) >= time.Minute {
+		span += "–" + d.Last.Local().Format("15:04")
+	}
+	contentLine := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("250")).
+		Render(plural(d.Calls, "call") + " at " + span)
+
+	action := "expand"
+	if d.Expanded {
+		action = "collapse"
+	}
+	metricLine := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render("enter: " + action)
+
+	width := widthOr(d.Width)
+	return cardLines(width, headerLine, contentLine, metricLine, separator(isSelected, "═", width))
+}
+
+// separator renders the line closing a card, highlighted for the selected card
+func separator(isSelected bool, unselected string, width int) string {
+	if isSelected {
+		return lipgloss.NewStyle().
+			Foreground(lipgloss.Color("226")).
+			Render(strings.Repeat("▬", width))
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("238")).
+		Render(strings.Repeat(unselected, width))
+}
+
+// PermissionBadge labels a tool call's permission decision, e.g. "⛔ denied"; "" if the
+// tool ran without asking
+func PermissionBadge(decision string) string {
+	switch decision {
+	case monitor.DecisionAllowed:
+		return "🔒 allowed"
+	case monitor.DecisionDenied:
+		return "⛔ denied"
+	}
+	return ""
+}
+
+// ToolCallSummary summarizes the tool calls of a message that makes several, e.g.
+// "×4 Edit, Bash"; "" for a single call
+func ToolCallSummary(calls []monitor.ToolCall) string {
+	if len(calls) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("×%d %s", len(calls), toolNames(calls))
+}
+
+// toolNames lists the distinct tool names of calls in order of first use
+func toolNames(calls []monitor.ToolCall) string {
+	var names []string
+	for _, call := range calls {
+		if !slices.Contains(names, call.Name) {
+			names = append(names, call.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// TurnCard renders a turn header as a fixed-height card, matching the message cards
+// Format: ▸ Turn 7 — 14:22, 3 tool calls, 18k tokens (in+cache write+out), $0.41, 38s
+func TurnCard(d TurnCardData, isSelected bool, costs config.CostConfig) string {
+	marker := "▸"
+	if d.Expanded {
+		marker = "▾"
+	}
+	headerParts := []string{
+		fmt.Sprintf("%s Turn %d — %s", marker, d.Index, d.Start.Local().Format("15:04")),
+		fmt.Sprintf("%d tool calls", d.ToolCalls),
+		FormatTokenCount(d.Tokens) + " tokens (" + monitor.TotalLabel + ")",
+	}
+	if !costs.Hidden {
+		headerParts = append(headerParts, FormatCost(d.Cost, TotalPrecision))
+	}
+	headerParts = append(headerParts, d.Duration.Round(time.Second).String())
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("75")).Bold(true)
+	if isSelected {
+		headerStyle = headerStyle.
+			Foreground(lipgloss.Color("228")).
+			Background(lipgloss.Color("23")).
+			Padding(0, 1)
+	}
+	headerLine := headerStyle.Render(strings.Join(headerParts, ", "))
+
+	contentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	if isSelected {
+		contentStyle = contentStyle.Foreground(lipgloss.Color("255")).Bold(true)
+	}
+	width := widthOr(d.Width)
+	contentLine := contentStyle.Render(Snippet(d.Content, d.Preview, false, width))
+
+	action := "expand"
+	if d.Expanded {
+		action = "collapse"
+	}
+	metricLine := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(fmt.Sprintf("%d messages · enter: %s", d.Messages, action))
+
+	return cardLines(width, headerLine, contentLine, metricLine, separator(isSelected, "═", width))
+}
+
+// MessageCard renders a single message as a fixed-height card
+func MessageCard(d CardData, isSelected bool, costs config.CostConfig) string {
+	// Role emoji and label
+	roleEmoji := "👤"
+	roleLabel := "user"
+	if d.Role == "assistant" {
+		roleEmoji = "🤖"
+		roleLabel = "assistant"
+	}
+
+	// Parse timestamp HH:MM
+	headerTime := ""
+	if d.Time != "" {
+		parts := strings.Split(d.Time, "T")
+		if len(parts) >= 2 {
+			timePart := strings.Split(parts[1], "Z")[0]
+			if idx := strings.LastIndex(timePart, ":"); idx > 0 {
+				headerTime = timePart[:idx]
+			}
+		}
+	}
+
+	// Format: [emoji] role · time · model · id
+	headerParts := []string{roleEmoji, roleLabel}
+	if headerTime != "" {
+		headerParts = append(headerParts, "·", headerTime)
+	}
+	if d.Model != "" && d.Role == "assistant" {
+		headerParts = append(headerParts, "·", shortModel(d.Model))
+	}
+	if d.UUID != "" {
+		headerParts = append(headerParts, "·", shortID(d.UUID))
+	}
+	if summary := ToolCallSummary(d.ToolCalls); summary != "" {
+		headerParts = append(headerParts, "·", "🔧 "+summary)
+	}
+	if badge := PermissionBadge(d.Decision); badge != "" {
+		headerParts = append(headerParts, "·", badge)
+	}
+	if d.Refusal {
+		headerParts = append(headerParts, "·", "🚫 refusal")
+	}
+	if d.PastedBytes > 0 {
+		headerParts = append(headerParts, "·", "📋 "+FormatPasteSize(d.PastedBytes)+" pasted")
+	}
+	if d.Marked {
+		headerParts = append(headerParts, "·", "◆ marked")
+	}
+	headerText := strings.Join(headerParts, " ")
+	width := widthOr(d.Width)
+
+	var headerLine, contentLine string
+	if isSelected {
+		// Bright, bold header with background for selected
+		headerLine = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("228")).
+			Background(lipgloss.Color("23")).
+			Bold(true).
+			Padding(0, 1).
+			Render(headerText)
+		contentLine = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("255")).
+			Bold(true).
+			Render(Snippet(d.Content, d.Preview, d.ToolResult, width))
+	} else {
+		headerLine = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("244")).
+			Render(headerText)
+		contentLine = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("250")).
+			Render(Snippet(d.Content, d.Preview, d.ToolResult, width))
+	}
+
+	var metricParts []string
+	if d.Role == "assistant" {
+		if d.InputTokens > 0 || d.OutputTokens > 0 {
+			metricParts = append(metricParts,
+				"in:"+FormatTokenCount(d.InputTokens),
+				"out:"+FormatTokenCount(d.OutputTokens),
+			)
+			if d.CacheWrite > 0 {
+				metricParts = append(metricParts, "cache:+"+FormatTokenCount(d.CacheWrite))
+			}
+			if d.CacheRead > 0 {
+				metricParts = append(metricParts, "cache:↻"+FormatTokenCount(d.CacheRead))
+			}
+			if cost := Cost(costs, d.Cost, costs.Message, "", MessagePrecision); cost != "" {
+				metricParts = append(metricParts, cost)
+			}
+			if d.RunningTotal > 0 {
+				if total := Cost(costs, d.RunningTotal, costs.Session, "Σ ", TotalPrecision); total != "" {
+					metricParts = append(metricParts, total)
+				}
+			}
+		}
+		if d.ContextUsage > 0 {
+			metricParts = append(metricParts, ContextGauge(d.ContextUsage))
+		}
+		if growth := ContextGrowth(d.ContextGrowth, d.LargeContextGrowth); growth != "" {
+			metricParts = append(metricParts, growth)
+		}
+		if d.Slow {
+			metricParts = append(metricParts, "🐢 "+FormatThroughput(d.Throughput, true))
+		}
+	} else {
+		// Prompts carry no usage data, so the size is estimated
+		if d.InputTokens > 0 {
+			metricParts = append(metricParts, "tokens:"+FormatTokenCount(d.InputTokens))
+		} else if d.EstimatedTokens > 0 {
+			metricParts = append(metricParts, "tokens:"+FormatTokenEstimate(d.EstimatedTokens))
+		}
+		if d.Cost > 0 {
+			if cost := Cost(costs, d.Cost, costs.Message, "", ExactPrecision); cost != "" {
+				metricParts = append(metricParts, cost)
+			}
+		}
+	}
+	metricLine := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(strings.Join(metricParts, " "))
+
+	return cardLines(width, headerLine, contentLine, metricLine, separator(isSelected, "─", width))
+}
@@ -0,0 +1,41 @@
+package render
+
+import "strings"
+
+// chartBlocks are the eighths of a chart cell, from lowest to full
+var chartBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Chart renders values as a column chart of height rows, one column per value, scaled
+// from 0 to the largest value. Each row resolves eight levels; values above 0 always
+// show at least the lowest one, so they stand out from missing data.
+func Chart(values []float64, height int) []string {
+	if len(values) == 0 || height <= 0 {
+		return nil
+	}
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	rows := make([]strings.Builder, height)
+	for _, v := range values {
+		eighths := 0
+		if peak > 0 && v > 0 {
+			eighths = max(int(v/peak*float64(height*8)+0.5), 1)
+		}
+		for r := range rows {
+			fill := min(max(eighths-(height-1-r)*8, 0), 8)
+			if fill == 0 {
+				rows[r].WriteRune(' ')
+			} else {
+				rows[r].WriteRune(chartBlocks[fill-1])
+			}
+		}
+	}
+
+	lines := make([]string, height)
+	for r := range rows {
+		lines[r] = rows[r].String()
+	}
+	return lines
+}
@@ -0,0 +1,30 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChart(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		height int
+		want   string // Rows joined with "|"
+	}{
+		{"empty", nil, 2, ""},
+		{"no height", []float64{1}, 0, ""},
+		{"one row", []float64{0, 1, 2, 4, 8}, 1, " ▁▂▄█"},
+		{"two rows", []float64{1, 2, 4}, 2, "  █|▄██"},
+		{"tiny values stay visible", []float64{0.001, 100}, 1, "▁█"},
+		{"all zero", []float64{0, 0}, 1, "  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(Chart(tt.values, tt.height), "|")
+			if got != tt.want {
+				t.Errorf("Chart(%v, %d) = %q, want %q", tt.values, tt.height, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// compositionColors colors the segments of the composition bar by entry kind; user and
+// assistant entries take the theme's colors
+var compositionColors = map[string]lipgloss.Color{
+	"tool results": lipgloss.Color("13"),
+	"progress":     lipgloss.Color("8"),
+	"system":       lipgloss.Color("11"),
+	"snapshots":    lipgloss.Color("14"),
+	"other":        lipgloss.Color("3"),
+	"unknown":      lipgloss.Color("1"),
+}
+
+// compositionColor returns the color of an entry kind in the composition bar
+func compositionColor(label string) lipgloss.Color {
+	switch label {
+	case "user":
+		return theme.User
+	case "assistant":
+		return theme.Assistant
+	}
+	return compositionColors[label]
+}
+
+// Bounds of the composition bar itself; the legend takes the rest of the line
+const (
+	minCompositionBar = 10
+	maxCompositionBar = 40
+)
+
+// Composition renders what a session file is made of as a stacked bar followed by a
+// legend with the count of each kind, e.g. "Entries ███████▒▒▒  ■ user 12  ■ assistant 30".
+// The line fits within width columns (unlimited if width <= 0) as far as the bar's
+// minimum size allows; kinds with no entries are left out.
+func Composition(counts []monitor.EntryCount, width int) string {
+	total := 0
+	var present []monitor.EntryCount
+	for _, c := range counts {
+		if c.Count > 0 {
+			total += c.Count
+			present = append(present, c)
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+
+	prefix := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Entries ")
+	legendWidth := 0
+	for _, c := range present {
+		legendWidth += 2 + lipgloss.Width(legendEntry(c))
+	}
+	barWidth := maxCompositionBar
+	if width > 0 {
+		barWidth = min(max(width-lipgloss.Width(prefix)-legendWidth, minCompositionBar), maxCompositionBar)
+	}
+
+	var bar strings.Builder
+	for i, cells := range compositionCells(present, total, barWidth) {
+		bar.WriteString(lipgloss.NewStyle().
+			Foreground(compositionColor(present[i].Label)).
+			Render(strings.Repeat("█", cells)))
+	}
+
+	line := prefix + bar.String()
+	used := lipgloss.Width(line)
+	fits := width <= 0 || used+legendWidth <= width
+	for _, c := range present {
+		entry := legendEntry(c)
+		if !fits && used+2+lipgloss.Width(entry)+lipgloss.Width(legendCut) > width {
+			// Keep room for the marker that entries were left out
+			if used+lipgloss.Width(legendCut) <= width {
+				line += legendCut
+			}
+			break
+		}
+		line += "  " + lipgloss.NewStyle().Foreground(compositionColor(c.Label)).Render("■") +
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(entry[len("■"):])
+		used += 2 + lipgloss.Width(entry)
+	}
+	return line
+}
+
+// legendCut marks a legend that had to leave out entries
+const legendCut = " …"
+
+// legendEntry is the uncolored legend text for one kind, e.g. "■ user 12"
+func legendEntry(c monitor.EntryCount) string {
+	return fmt.Sprintf("■ %s %d", c.Label, c.Count)
+}
+
+// compositionCells splits width cells among the counts in proportion, giving every
+// count at least one cell when there is room and the remaining cells to the largest
+// remainders
+func compositionCells(counts []monitor.EntryCount, total, width int) []int {
+	cells := make([]int, len(counts))
+	remainders := make([]int, len(counts))
+	used := 0
+	for i, c := range counts {
+		cells[i] = c.Count * width / total
+		remainders[i] = c.Count * width % total
+		if cells[i] == 0 && len(counts) <= width {
+			cells[i] = 1
+			remainders[i] = 0
+		}
+		used += cells[i]
+	}
+	// Hand out cells left over from rounding down, or take back the ones given to tiny counts
+	for used < width {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		cells[best]++
+		remainders[best] = -1
+		used++
+	}
+	for used > width {
+		largest := 0
+		for i := range cells {
+			if cells[i] > cells[largest] {
+				largest = i
+			}
+		}
+		cells[largest]--
+		used--
+	}
+	return cells
+}
+
+// maxLanguages is how many languages LanguageShares names
+const maxLanguages = 3
+
+// LanguageShares names the most frequent languages of a session's or project's code
+// with their share of all counted code blocks and file writes, e.g. "go 62%, sql 20%,
+// yaml 18%"; "" if there is no code
+func LanguageShares(langs []monitor.EntryCount) string {
+	total := 0
+	for _, l := range langs {
+		total += l.Count
+	}
+	if total == 0 {
+		return ""
+	}
+	var parts []string
+	for _, l := range langs[:min(len(langs), maxLanguages)] {
+		parts = append(parts, fmt.Sprintf("%s %.0f%%", l.Label, float64(l.Count)*100/float64(total)))
+	}
+	if rest := len(langs) - maxLanguages; rest > 0 {
+		parts = append(parts, fmt.Sprintf("+%d more", rest))
+	}
+	return strings.Join(parts, ", ")
+}
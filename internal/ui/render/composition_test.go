@@ -0,0 +1,74 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+func TestCompositionCells(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts []int
+		width  int
+		want   []int
+	}{
+		{"even", []int{1, 1}, 10, []int{5, 5}},
+		{"largest remainder gets the spare cell", []int{2, 1}, 10, []int{7, 3}},
+		{"tiny counts keep one cell", []int{1000, 1, 1}, 10, []int{8, 1, 1}},
+		{"more counts than cells", []int{5, 1, 1, 1}, 3, []int{2, 1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var counts []monitor.EntryCount
+			total := 0
+			for _, c := range tt.counts {
+				counts = append(counts, monitor.EntryCount{Count: c})
+				total += c
+			}
+			got := compositionCells(counts, total, tt.width)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compositionCells(%v, %d) = %v, want %v", tt.counts, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposition(t *testing.T) {
+	counts := []monitor.EntryCount{
+		{Label: "user", Count: 12},
+		{Label: "assistant", Count: 30},
+		{Label: "tool results", Count: 20},
+		{Label: "progress", Count: 0},
+		{Label: "unknown", Count: 3},
+	}
+
+	if got := Composition([]monitor.EntryCount{{Label: "user"}}, 80); got != "" {
+		t.Errorf("Composition without entries = %q, want empty", got)
+	}
+
+	got := Composition(counts, 0)
+	want := "Entries " + strings.Repeat("█", maxCompositionBar) + "  ■ user 12  ■ assistant 30  ■ tool results 20  ■ unknown 3"
+	if got != want {
+		t.Errorf("Composition(unlimited) =\n%q\nwant\n%q", got, want)
+	}
+
+	for _, width := range []int{120, 80, 50, 31} {
+		got := Composition(counts, width)
+		if w := lipgloss.Width(got); w > width {
+			t.Errorf("Composition(width %d) is %d columns wide: %q", width, w, got)
+		}
+		if bar := strings.Count(got, "█"); bar < minCompositionBar {
+			t.Errorf("Composition(width %d) bar is %d cells, want at least %d", width, bar, minCompositionBar)
+		}
+		if !strings.Contains(got, "■ user 12") {
+			t.Errorf("Composition(width %d) = %q, lost the first legend entry", width, got)
+		}
+	}
+	if got := Composition(counts, 50); !strings.HasSuffix(got, " …") {
+		t.Errorf("Composition(50) = %q, want the cut legend marked with …", got)
+	}
+}
@@ -0,0 +1,18 @@
+package render
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Confirmation renders a yes/no question below view, e.g. "Really quit? (y/N)", naming
+// the key that confirms; destructive questions are marked ⚠ in alert colors
+func Confirmation(view, message, key string, destructive bool) string {
+	style := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+	if destructive {
+		style = style.Foreground(theme.Alert)
+		message = "⚠ " + message
+	}
+	prompt := style.Render(message + " (" + key + "/N)")
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("n/esc: cancel")
+	return lipgloss.JoinVertical(lipgloss.Left, view, "", prompt+"  "+hint)
+}
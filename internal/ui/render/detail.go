@@ -0,0 +1,489 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// MessageDetailData is everything the message detail view shows
+type MessageDetailData struct {
+	Message      monitor.Message
+	Cost         float64 // Estimated cost of the message
+	Width        int     // Terminal width (0 if unknown)
+	FullWidth    bool    // Wrap to the terminal width instead of at most 80 columns
+	Height       int     // Terminal height
+	ScrollOffset int     // First content line shown
+	Help         string  // Rendered help bar
+
+	// Where the message sits in the card list it was opened from; left out if Total is 0
+	Position int    // 1-based position among the listed messages
+	Total    int    // Number of listed messages
+	Filter   string // Active message filter, e.g. "user filter"; empty for all messages
+	HasPrev  bool   // A previous message can be opened with ←
+	HasNext  bool   // A next message can be opened with →
+
+	Focused bool // Keys scroll this message in the split view's detail pane
+
+	// A tool call opened with enter shows its result below the arguments, for each call
+	// the message makes; arguments and results can be collapsed. Position and Total then
+	// count tool call messages.
+	Paired          bool
+	Results         []*monitor.Message // Result of each of Message.Calls; nil while a call has none
+	ArgsCollapsed   bool
+	ResultCollapsed bool
+}
+
+// MessageDetail displays a message with full text and line wrapping, using
+// type-specific layouts for user prompts, assistant responses and tool calls
+func MessageDetail(d MessageDetailData, costs config.CostConfig) string {
+	headerTitle, metadataSection := detailHeader(d, costs)
+
+	separatorLine := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("238")).
+		Render(strings.Repeat("─", textWidth(d.Width, d.FullWidth)))
+
+	detailsLines := detailMetadata(d, costs)
+	wrappedLines := ContentLines(d)
+
+	// Calculate visible lines based on terminal height
+	pageHeight := DetailPageHeight(d.Height)
+
+	var visibleLines []string
+	if d.ScrollOffset+pageHeight < len(wrappedLines) {
+		visibleLines = wrappedLines[d.ScrollOffset : d.ScrollOffset+pageHeight]
+	} else if d.ScrollOffset < len(wrappedLines) {
+		visibleLines = wrappedLines[d.ScrollOffset:]
+	}
+
+	contentText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("255")).
+		Render(strings.Join(visibleLines, "\n"))
+
+	// Scroll position indicator showing actual line numbers
+	totalLines := len(wrappedLines)
+	scrollInfo := "No content"
+	if totalLines > 0 {
+		endLine := d.ScrollOffset + len(visibleLines)
+		if endLine > totalLines {
+			endLine = totalLines
+		}
+		scrollInfo = fmt.Sprintf("Line %d-%d of %d", d.ScrollOffset+1, endLine, totalLines)
+	}
+
+	output := []string{headerTitle, metadataSection}
+	if position := detailPosition(d); position != "" {
+		output = append(output, position)
+	}
+	output = append(output, separatorLine)
+	if len(detailsLines) > 0 {
+		output = append(output, "")
+		output = append(output, detailsLines...)
+		output = append(output, "")
+	}
+	output = append(output,
+		"",
+		contentText,
+		"",
+		Footer(scrollInfo),
+		d.Help,
+	)
+
+	return lipgloss.JoinVertical(lipgloss.Left, output...)
+}
+
+// MessageDetailPane renders the selected message for the split session detail view: the
+// message header, a page of content wrapped to d.Width and the line indicator, filling
+// exactly d.Height lines. Metadata and help are left to the full message detail view.
+func MessageDetailPane(d MessageDetailData, costs config.CostConfig) string {
+	pane := lipgloss.NewStyle().MaxWidth(d.Width).Height(d.Height).MaxHeight(d.Height)
+	if d.Message.Role == "" {
+		return pane.Render(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render("Select a message to show it here"))
+	}
+
+	headerTitle, metadata := detailHeader(d, costs)
+	lines := DetailLines(d.Message, d.Width, true)
+	pageHeight := DetailPanePageHeight(d.Height)
+	offset := min(max(d.ScrollOffset, 0), max(len(lines)-pageHeight, 0))
+	visible := lines[offset:min(offset+pageHeight, len(lines))]
+
+	scrollInfo := "No content"
+	if len(lines) > 0 {
+		scrollInfo = fmt.Sprintf("Line %d-%d of %d", offset+1, offset+len(visible), len(lines))
+	}
+	if d.Focused {
+		scrollInfo += " · ↑/↓ scroll, tab: back to list"
+	}
+
+	output := []string{
+		headerTitle,
+		metadata, // Cut off at the pane edge
+		lipgloss.NewStyle().
+			Foreground(lipgloss.Color("238")).
+			Render(strings.Repeat("─", textWidth(d.Width, true))),
+		lipgloss.NewStyle().
+			Foreground(lipgloss.Color("255")).
+			Render(strings.Join(visible, "\n")),
+	}
+	// Keep the line indicator at the bottom of the pane
+	if gap := pageHeight - len(visible); gap > 0 {
+		output = append(output, strings.Repeat("\n", gap-1))
+	}
+	output = append(output, "", Footer(scrollInfo))
+	return pane.Render(lipgloss.JoinVertical(lipgloss.Left, output...))
+}
+
+// DetailPanePageHeight returns how many content lines the split view's detail pane
+// shows at a pane height, leaving space for its header and line indicator
+func DetailPanePageHeight(height int) int {
+	return max(height-5, 1)
+}
+
+// textWidth is the width the message detail view wraps text to: at most 80 columns,
+// or the whole terminal width (0 if unknown) with fullWidth
+func textWidth(width int, fullWidth bool) int {
+	if width > 0 && (width < 80 || fullWidth) {
+		return width - 2
+	}
+	return 80
+}
+
+// DetailLines wraps a message's tool call and content as the message detail view shows
+// them: at most 80 columns wide, or the whole terminal width (0 if unknown) with fullWidth
+func DetailLines(msg monitor.Message, width int, fullWidth bool) []string {
+	maxWidth := textWidth(width, fullWidth)
+
+	var wrappedLines []string
+
+	// Add tool info if this is a tool call, a section for each call
+	calls := msg.Calls()
+	for i, call := range calls {
+		if i > 0 {
+			wrappedLines = append(wrappedLines, "")
+		}
+		wrappedLines = append(wrappedLines, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("82")).
+			Bold(true).
+			Render("🔧 "+strings.ToUpper(call.Name)+callNumber(i, len(calls))))
+
+		if call.Input != "" {
+			wrappedLines = append(wrappedLines, "", lipgloss.NewStyle().
+				Foreground(lipgloss.Color("11")).
+				Render("Arguments:"))
+			wrappedLines = append(wrappedLines, wrapWords(call.Input, maxWidth)...)
+		}
+	}
+	// Add separator before content
+	if len(calls) > 0 && msg.Content != "" {
+		wrappedLines = append(wrappedLines, "")
+	}
+
+	// Add regular message content, keeping empty lines
+	for _, paragraph := range strings.Split(msg.Content, "\n") {
+		if paragraph == "" {
+			wrappedLines = append(wrappedLines, "")
+			continue
+		}
+		wrappedLines = append(wrappedLines, wrapWords(paragraph, maxWidth)...)
+	}
+	return wrappedLines
+}
+
+// ContentLines returns the scrollable lines of the message detail view: the tool call
+// and its result for a paired view, else the message as DetailLines wraps it
+func ContentLines(d MessageDetailData) []string {
+	if d.Paired {
+		return PairedLines(d)
+	}
+	return DetailLines(d.Message, d.Width, d.FullWidth)
+}
+
+// PairedLines lays out each tool call of a message with its result: the arguments,
+// pretty-printed when they are JSON, above the result, which is marked as failed or
+// succeeded. Arguments and results can be collapsed to their headings.
+func PairedLines(d MessageDetailData) []string {
+	maxWidth := textWidth(d.Width, d.FullWidth)
+	// Unlike DetailLines, keep each line's indentation so pretty-printed JSON and
+	// command output stay readable
+	wrap := func(text string) []string {
+		var lines []string
+		for _, paragraph := range strings.Split(text, "\n") {
+			body := strings.TrimLeft(paragraph, " \t")
+			if body == "" {
+				lines = append(lines, "")
+				continue
+			}
+			indent := paragraph[:len(paragraph)-len(body)]
+			for _, line := range wrapWords(body, max(maxWidth-len(indent), 20)) {
+				lines = append(lines, indent+line)
+			}
+		}
+		return lines
+	}
+	heading := func(collapsed bool, title, key string, body []string) string {
+		if collapsed {
+			return fmt.Sprintf("▸ %s (%s, %s: expand)", title, plural(len(body), "line"), key)
+		}
+		return fmt.Sprintf("▾ %s (%s: collapse)", title, key)
+	}
+
+	var lines []string
+	calls := d.Message.Calls()
+	for i, call := range calls {
+		if i > 0 {
+			lines = append(lines, "", "")
+		}
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("82")).
+			Bold(true).
+			Render("🔧 "+strings.ToUpper(call.Name)+callNumber(i, len(calls))), "")
+
+		args := wrap(indentJSON(call.Input))
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("11")).
+			Render(heading(d.ArgsCollapsed, "Arguments", "a", args)))
+		if !d.ArgsCollapsed {
+			lines = append(lines, args...)
+		}
+		lines = append(lines, "")
+
+		var res *monitor.Message
+		if i < len(d.Results) {
+			res = d.Results[i]
+		}
+		if res == nil {
+			lines = append(lines, lipgloss.NewStyle().
+				Foreground(lipgloss.Color("8")).
+				Render("… no result yet"))
+			continue
+		}
+		title, color := "✓ Result", theme.Low
+		if res.IsError {
+			title, color = "✗ Result: error", theme.Alert
+		}
+		result := wrap(res.Content)
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(color).
+			Bold(true).
+			Render(heading(d.ResultCollapsed, title, "r", result)))
+		if !d.ResultCollapsed {
+			lines = append(lines, result...)
+		}
+	}
+	return lines
+}
+
+// indentJSON pretty-prints tool arguments that are a JSON object; others are returned as is
+func indentJSON(input string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(input), "", "  "); err != nil {
+		return input
+	}
+	return buf.String()
+}
+
+// DetailPageHeight returns how many content lines the message detail view shows at a
+// terminal height, leaving space for the header, metadata and footer
+func DetailPageHeight(height int) int {
+	return max(height-10, 5)
+}
+
+// detailHeader returns the title and the one-line summary below it for the message type
+func detailHeader(d MessageDetailData, costs config.CostConfig) (title, metadata string) {
+	msg := d.Message
+	metaStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+
+	switch {
+	case msg.Role == "user":
+		title = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("226")).
+			Render("👤 YOUR PROMPT")
+
+		sentAt := fmt.Sprintf("sent at %s", msg.Timestamp.Format("2006-01-02 15:04:05 MST"))
+		if msg.EstimatedTokens > 0 {
+			sentAt += fmt.Sprintf(" · %s tokens (estimated)", FormatTokenEstimate(msg.EstimatedTokens))
+		}
+		return title, metaStyle.Render(sentAt)
+
+	case msg.Role == "assistant" && msg.ToolName != "":
+		titleStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("82"))
+		title = titleStyle.Render(fmt.Sprintf("🔧 TOOL CALL: %s", strings.ToUpper(msg.ToolName)))
+
+		toolDetails := []string{fmt.Sprintf("Tool: %s", msg.ToolName)}
+		if msg.ToolInput != "" {
+			toolDetails = append(toolDetails, fmt.Sprintf("Arguments: %s", msg.ToolInput))
+		}
+		if summary := ToolCallSummary(msg.Calls()); summary != "" {
+			// The arguments of each call are listed below
+			title = titleStyle.Render("🔧 TOOL CALLS: " + strings.ToUpper(summary))
+			toolDetails = []string{"Tools: " + toolNames(msg.Calls())}
+		}
+		if msg.UUID != "" {
+			toolDetails = append(toolDetails, fmt.Sprintf("ID: %s", shortID(msg.UUID)))
+		}
+		return title, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("11")).
+			Render(strings.Join(toolDetails, " • "))
+
+	case msg.Role == "assistant":
+		title = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("51")).
+			Render("🤖 CLAUDE RESPONSE")
+
+		metaParts := []string{msg.Timestamp.Format("15:04:05")}
+		if msg.Model != "" {
+			metaParts = append(metaParts, shortModel(msg.Model))
+		}
+		if msg.InputTokens > 0 || msg.OutputTokens > 0 {
+			metaParts = append(metaParts,
+				"in:"+FormatTokenCount(msg.InputTokens),
+				"out:"+FormatTokenCount(msg.OutputTokens),
+			)
+			if msg.CacheRead > 0 {
+				metaParts = append(metaParts, "cache:↻"+FormatTokenCount(msg.CacheRead))
+			}
+			if cost := Cost(costs, d.Cost, costs.Message, "", MessagePrecision); cost != "" {
+				metaParts = append(metaParts, cost)
+			}
+		}
+		if msg.UUID != "" {
+			metaParts = append(metaParts, fmt.Sprintf("ID:%s", shortID(msg.UUID)))
+		}
+		return title, metaStyle.Render(strings.Join(metaParts, " · "))
+
+	default:
+		title = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("11")).
+			Render("MESSAGE")
+		return title, metaStyle.Render(msg.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// detailPosition renders e.g. "message 12 of 87 (user filter)" or "tool call 3 of 9",
+// with dimmed hints when there is no previous or next one to step to
+func detailPosition(d MessageDetailData) string {
+	if d.Total <= 0 {
+		return ""
+	}
+	unit := "message"
+	if d.Paired {
+		unit = "tool call"
+	}
+	position := fmt.Sprintf("%s %d of %d", unit, d.Position, d.Total)
+	if d.Filter != "" {
+		position += fmt.Sprintf(" (%s)", d.Filter)
+	}
+	position = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(position)
+
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	if !d.HasPrev {
+		position = dim.Render("⇠ no previous") + "  " + position
+	}
+	if !d.HasNext {
+		position += "  " + dim.Render("no next ⇢")
+	}
+	return position
+}
+
+// detailMetadata returns the styled metadata lines: model and token usage for assistant
+// messages, followed by IDs, working directory, branch and version
+func detailMetadata(d MessageDetailData, costs config.CostConfig) []string {
+	msg := d.Message
+	var lines []string
+
+	if msg.Role == "assistant" && (msg.InputTokens > 0 || msg.OutputTokens > 0 || msg.Model != "") {
+		var tokenInfo []string
+		if msg.Model != "" {
+			tokenInfo = append(tokenInfo, fmt.Sprintf("Model: %s", msg.Model))
+		}
+		if msg.InputTokens > 0 {
+			tokenInfo = append(tokenInfo, "Input: "+FormatCount(msg.InputTokens))
+		}
+		if msg.OutputTokens > 0 {
+			tokenInfo = append(tokenInfo, "Output: "+FormatCount(msg.OutputTokens))
+		}
+		if msg.CacheCreation > 0 {
+			tokenInfo = append(tokenInfo, "Cache-Write: "+FormatCount(msg.CacheCreation))
+		}
+		if msg.CacheRead > 0 {
+			tokenInfo = append(tokenInfo, "Cache-Hit: "+FormatCount(msg.CacheRead))
+		}
+		if msg.OutputTokens > 0 {
+			tokenInfo = append(tokenInfo, "Speed: "+FormatThroughput(msg.TokensPerSecond()))
+		}
+		if d.Cost > 0 {
+			if cost := Cost(costs, d.Cost, costs.Message, "Cost: ", ExactPrecision); cost != "" {
+				tokenInfo = append(tokenInfo, cost)
+			}
+		}
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("6")).
+			Render(strings.Join(tokenInfo, " • ")))
+	}
+
+	var details []string
+	if msg.SessionID != "" {
+		details = append(details, fmt.Sprintf("Session: %s", msg.SessionID))
+	}
+	if msg.UUID != "" {
+		details = append(details, fmt.Sprintf("Message ID: %s", msg.UUID))
+	}
+	if msg.ParentUUID != "" {
+		details = append(details, fmt.Sprintf("Parent ID: %s", msg.ParentUUID))
+	}
+	if msg.WorkingDir != "" {
+		details = append(details, fmt.Sprintf("Working Dir: %s", msg.WorkingDir))
+	}
+	if msg.GitBranch != "" {
+		details = append(details, fmt.Sprintf("Git Branch: %s", msg.GitBranch))
+	}
+	if msg.Version != "" {
+		details = append(details, fmt.Sprintf("Claude Version: %s", msg.Version))
+	}
+	if msg.UserType != "" {
+		details = append(details, fmt.Sprintf("User Type: %s", msg.UserType))
+	}
+	if msg.IsSidechain {
+		details = append(details, "Sidechain: yes")
+	}
+	if badge := PermissionBadge(msg.PermissionDecision); badge != "" {
+		details = append(details, "Permission: "+badge)
+	}
+	if msg.Refusal {
+		details = append(details, "Refusal: yes (stop reason or refusal pattern)")
+	}
+
+	detailsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("242"))
+	for _, detail := range details {
+		lines = append(lines, detailsStyle.Render(detail))
+	}
+	return lines
+}
+
+// shortID returns the first 8 characters of an ID
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// callNumber labels call i of n, e.g. " (2 of 4)"; "" for a message with a single call
+func callNumber(i, n int) string {
+	if n < 2 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d of %d)", i+1, n)
+}
@@ -0,0 +1,229 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/diff"
+)
+
+// DiffData is everything the diff view shows
+type DiffData struct {
+	Old          string    // Label of the marked message, e.g. "message 3 · assistant · 09:14:05"
+	New          string    // Label of the message it is compared with
+	Ops          []diff.Op // Edit script from the old to the new content
+	Words        bool      // Ops are words rather than lines
+	Width        int       // Terminal width (0 if unknown)
+	Height       int       // Terminal height
+	ScrollOffset int       // First body line shown
+	Help         string    // Rendered help bar
+}
+
+// diffDeleted styles what only the older message has
+func diffDeleted() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(theme.Deleted)
+}
+
+// diffInserted styles what only the newer message has
+func diffInserted() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(theme.Inserted)
+}
+
+// DiffPageHeight returns how many body lines the diff view shows at a terminal height
+func DiffPageHeight(height int) int {
+	return max(height-9, 5)
+}
+
+// DiffLines renders the body of the diff view, wrapped to a terminal of the given width
+// (0 if unknown). Line diffs get a "-", "+" or " " gutter; word diffs mark changes
+// inline as [-old-] and {+new+}.
+func DiffLines(ops []diff.Op, words bool, width int) []string {
+	width -= 2
+	if width <= 0 {
+		width = 80
+	}
+	if words {
+		var lines []string
+		for _, line := range wordPieces(ops) {
+			lines = append(lines, wrapPieces(line, width)...)
+		}
+		return lines
+	}
+
+	var lines []string
+	for _, op := range ops {
+		gutter, style := " ", lipgloss.NewStyle()
+		switch op.Kind {
+		case diff.Delete:
+			gutter, style = "-", diffDeleted()
+		case diff.Insert:
+			gutter, style = "+", diffInserted()
+		}
+		for i, part := range hardWrap(strings.ReplaceAll(op.Text, "\t", "    "), width-2) {
+			if i > 0 {
+				gutter = " "
+			}
+			lines = append(lines, style.Render(gutter+" "+part))
+		}
+	}
+	return lines
+}
+
+// Diff renders the diff view: the two messages compared, the change count, a page of
+// the diff and the scroll position
+func Diff(d DiffData) string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("Diff")
+	oldLabel := diffDeleted().Render("--- " + d.Old)
+	newLabel := diffInserted().Render("+++ " + d.New)
+
+	unit := "line"
+	deleted, inserted := 0, 0
+	for _, op := range d.Ops {
+		if d.Words && strings.TrimSpace(op.Text) == "" {
+			continue // Whitespace changes are shown but not counted
+		}
+		switch op.Kind {
+		case diff.Delete:
+			deleted++
+		case diff.Insert:
+			inserted++
+		}
+	}
+	if d.Words {
+		unit = "word"
+	}
+	summary := "No differences"
+	if deleted > 0 || inserted > 0 {
+		summary = fmt.Sprintf("%s removed, %s added", plural(deleted, unit), plural(inserted, unit))
+	}
+	summaryText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(summary)
+
+	lines := DiffLines(d.Ops, d.Words, d.Width)
+	pageHeight := DiffPageHeight(d.Height)
+	offset := min(max(d.ScrollOffset, 0), max(len(lines)-pageHeight, 0))
+	visible := lines[offset:min(offset+pageHeight, len(lines))]
+
+	scrollInfo := "No content"
+	if len(lines) > 0 {
+		scrollInfo = fmt.Sprintf("Line %d-%d of %d", offset+1, offset+len(visible), len(lines))
+	}
+
+	separator := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("238")).
+		Render(strings.Repeat("─", widthOr(d.Width)))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		oldLabel,
+		newLabel,
+		summaryText,
+		separator,
+		strings.Join(visible, "\n"),
+		"",
+		Footer(scrollInfo),
+		d.Help,
+	)
+}
+
+// diffPiece is a run of word-diff text drawn in one style
+type diffPiece struct {
+	text string
+	kind diff.Kind
+}
+
+// wordPieces lays out a word diff as lines of pieces, bracketing each run of removed
+// or added words. Line breaks of the new text start a new line; removed ones show as "↵".
+func wordPieces(ops []diff.Op) [][]diffPiece {
+	var lines [][]diffPiece
+	var line []diffPiece
+	open := diff.Equal // Kind of the bracket currently open
+	setOpen := func(kind diff.Kind) {
+		if open == kind {
+			return
+		}
+		switch open {
+		case diff.Delete:
+			line = append(line, diffPiece{"-]", diff.Delete})
+		case diff.Insert:
+			line = append(line, diffPiece{"+}", diff.Insert})
+		}
+		switch kind {
+		case diff.Delete:
+			line = append(line, diffPiece{"[-", diff.Delete})
+		case diff.Insert:
+			line = append(line, diffPiece{"{+", diff.Insert})
+		}
+		open = kind
+	}
+
+	for _, op := range ops {
+		setOpen(op.Kind)
+		if op.Text != "\n" {
+			line = append(line, diffPiece{strings.ReplaceAll(op.Text, "\t", "    "), op.Kind})
+			continue
+		}
+		if op.Kind != diff.Equal {
+			line = append(line, diffPiece{"↵", op.Kind})
+		}
+		if op.Kind != diff.Delete {
+			setOpen(diff.Equal)
+			lines = append(lines, line)
+			line = nil
+		}
+	}
+	setOpen(diff.Equal)
+	return append(lines, line)
+}
+
+// wrapPieces renders a line of word-diff pieces, wrapped to width at piece boundaries
+// (pieces wider than a line are split)
+func wrapPieces(pieces []diffPiece, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, p := range pieces {
+		style := lipgloss.NewStyle()
+		switch p.kind {
+		case diff.Delete:
+			style = diffDeleted().Strikethrough(p.text != "[-" && p.text != "-]")
+		case diff.Insert:
+			style = diffInserted()
+		}
+		for _, part := range hardWrap(p.text, width) {
+			w := lipgloss.Width(part)
+			if lineWidth > 0 && lineWidth+w > width {
+				lines = append(lines, line.String())
+				line.Reset()
+				lineWidth = 0
+			}
+			line.WriteString(style.Render(part))
+			lineWidth += w
+		}
+	}
+	return append(lines, line.String())
+}
+
+// hardWrap splits text into chunks of at most width cells, breaking anywhere
+func hardWrap(text string, width int) []string {
+	width = max(width, 1)
+	var chunks []string
+	var chunk strings.Builder
+	chunkWidth := 0
+	for _, r := range text {
+		w := lipgloss.Width(string(r))
+		if chunkWidth > 0 && chunkWidth+w > width {
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+			chunkWidth = 0
+		}
+		chunk.WriteRune(r)
+		chunkWidth += w
+	}
+	return append(chunks, chunk.String())
+}
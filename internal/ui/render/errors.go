@@ -0,0 +1,114 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ErrorEntry is an error met this run, as listed in the errors view
+type ErrorEntry struct {
+	Time    time.Time
+	Op      string // What was being done, e.g. "load sessions"
+	Message string
+	Context string // Details as key=value pairs, e.g. "path=/tmp/a.jsonl"; "" if none
+}
+
+// ErrorListData is everything the errors view shows
+type ErrorListData struct {
+	Entries      []ErrorEntry // Newest first
+	Dropped      int          // Older errors that were no longer kept
+	LogPath      string       // Where the debug log is written; "" when it is off
+	Width        int          // Terminal width (0 if unknown)
+	Height       int          // Terminal height
+	ScrollOffset int          // First body line shown
+	Help         string       // Rendered help bar
+}
+
+// ErrorPageHeight returns how many body lines the errors view shows at a terminal height
+func ErrorPageHeight(height int) int {
+	return max(height-7, 5)
+}
+
+// ErrorLines renders the body of the errors view, each error as a line with its time
+// and what was being done, then its message and context indented below, wrapped to a
+// terminal of the given width (0 if unknown)
+func ErrorLines(entries []ErrorEntry, width int) []string {
+	width = widthOr(width) - 4
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	op := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1"))
+
+	var lines []string
+	for i, e := range entries {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, dim.Render(e.Time.Local().Format("15:04:05"))+"  "+op.Render(e.Op))
+		for _, part := range hardWrap(e.Message, width) {
+			lines = append(lines, "    "+part)
+		}
+		if e.Context != "" {
+			for _, part := range hardWrap(e.Context, width) {
+				lines = append(lines, "    "+dim.Render(part))
+			}
+		}
+	}
+	return lines
+}
+
+// ErrorBadge counts the errors not yet seen in the errors view, e.g. "[3 new errors: !]"
+func ErrorBadge(unseen int) string {
+	return lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("15")).
+		Background(lipgloss.Color("1")).
+		Render(fmt.Sprintf("[%s: !]", plural(unseen, "new error")))
+}
+
+// ErrorList renders the errors view: the errors met this run, newest first, a page at
+// a time
+func ErrorList(d ErrorListData) string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render(fmt.Sprintf("Errors this run (%d)", len(d.Entries)+d.Dropped))
+
+	summary := "Newest first"
+	if d.Dropped > 0 {
+		summary += fmt.Sprintf("; %s dropped", plural(d.Dropped, "older error"))
+	}
+	if d.LogPath != "" {
+		summary += "; details in " + d.LogPath
+	}
+	summaryText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(summary)
+
+	separator := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("238")).
+		Render(strings.Repeat("─", widthOr(d.Width)))
+
+	if len(d.Entries) == 0 {
+		empty := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("10")).
+			Render("No errors so far")
+		return lipgloss.JoinVertical(lipgloss.Left, title, separator, empty, "", d.Help)
+	}
+
+	lines := ErrorLines(d.Entries, d.Width)
+	pageHeight := ErrorPageHeight(d.Height)
+	offset := min(max(d.ScrollOffset, 0), max(len(lines)-pageHeight, 0))
+	visible := lines[offset:min(offset+pageHeight, len(lines))]
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		summaryText,
+		separator,
+		strings.Join(visible, "\n"),
+		"",
+		Footer(fmt.Sprintf("Line %d-%d of %d", offset+1, offset+len(visible), len(lines))),
+		d.Help,
+	)
+}
@@ -0,0 +1,395 @@
+package render
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/diff"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/")
+
+func TestMain(m *testing.M) {
+	// Golden files hold plain text: no colors or other escape sequences, fixed time zone
+	lipgloss.SetColorProfile(termenv.Ascii)
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+// assertGolden compares got with testdata/<name>.golden, rewriting the file with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./internal/ui/render -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from %s (run with -update to accept)\ngot:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+var (
+	goldenTime  = time.Date(2026, 1, 12, 9, 14, 5, 0, time.UTC)
+	goldenCosts = config.Default().Cost
+)
+
+func goldenAssistant() monitor.Message {
+	return monitor.Message{
+		Role:          "assistant",
+		Content:       "Both call sites now check the slice length before indexing.\n\nThe handler returns 404 for an empty result instead of panicking.",
+		Timestamp:     goldenTime,
+		Model:         "claude-opus-4-5-20251101",
+		InputTokens:   12,
+		OutputTokens:  340,
+		CacheCreation: 1200,
+		CacheRead:     48000,
+		ResponseTime:  8500 * time.Millisecond,
+		UUID:          "a1b2c3d4-0000-4000-8000-000000000002",
+		ParentUUID:    "a1b2c3d4-0000-4000-8000-000000000001",
+		SessionID:     "3f2a9c1e-0b7d-4c55-9a61-2d8e4b7f1a01",
+		WorkingDir:    "/home/demo/acme-api",
+		Version:       "2.1.4",
+		GitBranch:     "main",
+		UserType:      "external",
+	}
+}
+
+func TestGoldenViews(t *testing.T) {
+	user := monitor.Message{
+		Role:            "user",
+		Content:         "GET /orders/42 panics with index out of range when the customer has no orders. Please fix it and add a regression test.",
+		Timestamp:       goldenTime,
+		EstimatedTokens: 31,
+		UUID:            "a1b2c3d4-0000-4000-8000-000000000001",
+		SessionID:       "3f2a9c1e-0b7d-4c55-9a61-2d8e4b7f1a01",
+		WorkingDir:      "/home/demo/acme-api",
+		GitBranch:       "main",
+	}
+	assistant := goldenAssistant()
+	tool := goldenAssistant()
+	tool.Content = ""
+	tool.ToolName = "Bash"
+	tool.ToolInput = `{"command": "go test ./internal/handlers/... -run TestGetOrder", "description": "Run the order handler tests"}`
+	toolResult := monitor.Message{
+		Type:      "tool_result",
+		Role:      "user",
+		Content:   "--- FAIL: TestGetOrder (0.01s)\n    order_test.go:42: got status 500, want 200\nFAIL",
+		IsError:   true,
+		Timestamp: goldenTime.Add(16 * time.Second),
+	}
+	edits := tool
+	edits.ToolName = "Edit"
+	edits.ToolInput = `{"file_path":"internal/handlers/order.go"}`
+	edits.ToolCalls = []monitor.ToolCall{
+		{Name: "Edit", Input: edits.ToolInput, ID: "toolu_1"},
+		{Name: "Edit", Input: `{"file_path":"internal/handlers/order_test.go"}`, ID: "toolu_2"},
+		{Name: "Bash", Input: `{"command":"go test ./internal/handlers/..."}`, ID: "toolu_3"},
+	}
+	editResult := monitor.Message{Type: "tool_result", Role: "user", Content: "The file internal/handlers/order.go has been updated.", Timestamp: goldenTime.Add(9 * time.Second)}
+
+	userCard := CardData{
+		Role:            "user",
+		Content:         user.Content,
+		Time:            "2026-01-12T09:14:05.000Z",
+		UUID:            user.UUID,
+		EstimatedTokens: 31,
+	}
+	pasteCard := userCard
+	pasteCard.Content = "why does the deploy fail?\n2026-01-12T09:02:11Z ERROR migrate: relation \"orders\" already exists"
+	pasteCard.PastedBytes = 48_900
+	assistantCard := CardData{
+		Role:         "assistant",
+		Content:      assistant.Content,
+		Time:         "2026-01-12T09:14:05.000Z",
+		Model:        assistant.Model,
+		UUID:         assistant.UUID,
+		InputTokens:  12,
+		OutputTokens: 340,
+		CacheRead:    48000,
+		ContextUsage: 0.62,
+		Cost:         0.0201,
+	}
+	growthCard := assistantCard
+	growthCard.ContextGrowth = 48_000
+	growthCard.LargeContextGrowth = true
+	callsCard := assistantCard
+	callsCard.Content = "Fixing the handler and adding the regression test."
+	callsCard.ToolCalls = edits.ToolCalls
+	slowCard := assistantCard
+	slowCard.Throughput, slowCard.Slow = 6.4, true
+	totalCard := assistantCard
+	totalCard.RunningTotal = 2.31
+	turnCard := TurnCardData{
+		Index:     3,
+		Start:     goldenTime,
+		ToolCalls: 4,
+		Tokens:    18400,
+		Cost:      0.41,
+		Duration:  38 * time.Second,
+		Messages:  9,
+		Content:   user.Content,
+	}
+
+	processes := []types.ClaudeProcess{
+		{PID: 4242, CPUPercent: 18.5, MemoryMB: 312, Uptime: 2*time.Hour + 14*time.Minute, WorkingDir: "/home/demo/acme-api", Command: "claude --continue"},
+		{PID: 5150, MemoryMB: 1536, Uptime: 26 * time.Hour, WorkingDir: "/home/demo/web-ui", Command: "claude"},
+		{PID: 6161, MemoryMB: 204, Uptime: 45 * time.Minute, WorkingDir: "/home/demo/old-branch", Command: "claude", WorkDirGone: true},
+	}
+	processModel, processColumns := NewProcessTable(120, DefaultWorkdirShare)
+	processTable := processModel.
+		WithRows(ProcessRows(processes, processColumns, map[int32]monitor.TokenRate{4242: {0, 0, 0, 0, 0, 0, 800, 1200, 0, 2400, 5100, 0, 9000, 7600, 3100}})).
+		WithHighlightedRow(0).
+		View()
+
+	help := HelpBar([]KeyHint{
+		{Key: "↑/↓", Desc: "Navigate", Priority: PriorityNormal},
+		{Key: "enter", Desc: "Open", Priority: PriorityHigh},
+		{Key: "+/-", Desc: "Interval", Priority: PriorityLow},
+		{Key: "q", Desc: "Quit", Priority: PriorityEssential},
+	}, 40)
+
+	project := &monitor.ProjectStats{
+		Sessions: 42, Agents: 17, Failed: 1, Bypass: 2,
+		First: goldenTime.Add(-20 * 24 * time.Hour), Last: goldenTime,
+		Tokens:        monitor.TokenCounts{Input: 210_000, CacheWrite: 840_000, Output: 96_000, CacheRead: 31_000_000},
+		Cost:          38.4,
+		TotalDuration: 42 * 38 * time.Minute,
+		Models: []monitor.ModelUsage{
+			{Model: "claude-opus-4-5-20251101", Responses: 1210, Tokens: monitor.TokenCounts{Input: 120_000, CacheWrite: 780_000, Output: 80_000}, Cost: 35.1},
+			{Model: "claude-haiku-4-5-20251001", Responses: 380, Tokens: monitor.TokenCounts{Input: 90_000, CacheWrite: 60_000, Output: 16_000}, Cost: 3.3},
+		},
+		Tools:     []monitor.EntryCount{{Label: "Bash", Count: 412}, {Label: "Read", Count: 388}, {Label: "Edit", Count: 154}},
+		Languages: []monitor.EntryCount{{Label: "go", Count: 186}, {Label: "sql", Count: 60}, {Label: "yaml", Count: 42}, {Label: "shell", Count: 12}},
+		Pastes:    9, PastedBytes: 212_000,
+		LargestPastes: []monitor.Paste{
+			{Session: "3f2a9c1e-0b7d-4c55-9a61-2d8e4b7f1a01", At: goldenTime.Add(-26 * time.Hour), Bytes: 98_300, Prompt: "here is the full build log:\n#12 [build 4/7] RUN go build ./..."},
+			{Session: "77aa01bc-5e2f-4d10-8c3b-6a9e0f1d2c34", At: goldenTime, Bytes: 48_900, Prompt: "why does the deploy fail?\n2026-01-12T09:02:11Z ERROR migrate"},
+		},
+		Days: []monitor.DayActivity{
+			{Day: goldenTime.Add(-48 * time.Hour).Truncate(24 * time.Hour), Sessions: 3, Messages: 310},
+			{Day: goldenTime.Truncate(24 * time.Hour), Sessions: 5, Messages: 540},
+		},
+	}
+	for i := 0; i < 12; i++ {
+		project.Efficiency = append(project.Efficiency, monitor.SessionEfficiency{
+			Prompts:         4,
+			OutputPerPrompt: float64(400 + 150*(i%5)),
+			ContextPerTurn:  float64(20_000 + 6_000*i),
+		})
+	}
+
+	report := &monitor.Report{
+		Range: monitor.DateRange{From: goldenTime.Add(-6 * 24 * time.Hour).Truncate(24 * time.Hour), To: goldenTime.Add(24 * time.Hour).Truncate(24 * time.Hour)},
+		Total: *project,
+		Projects: []monitor.ProjectReport{
+			{Name: "~/acme-api", Sessions: 30, Messages: 610, Tokens: monitor.TokenCounts{Input: 150_000, CacheWrite: 600_000, Output: 70_000}, Cost: 30.2},
+			{Name: "~/work/clients/globex/infrastructure/terraform-modules", Sessions: 12, Messages: 240, Tokens: monitor.TokenCounts{Input: 60_000, CacheWrite: 240_000, Output: 26_000}, Cost: 8.2},
+		},
+	}
+	ranges := []string{"Last 7 days", "Last 30 days", "Custom"}
+
+	planV1 := "Plan:\n1. Check the slice length before indexing\n2. Return 404 for an empty result\n3. Add a regression test"
+	planV2 := "Plan:\n1. Check the slice length before indexing\n2. Return an empty list for an empty result\n3. Add a regression test\n4. Run the handler tests"
+	diffData := DiffData{
+		Old:    "message 2 · assistant · 09:14:05",
+		New:    "message 6 · assistant · 09:17:42",
+		Ops:    diff.Lines(planV1, planV2),
+		Height: 40,
+		Help:   help,
+	}
+	wordDiff := diffData
+	wordDiff.Ops = diff.Words(planV1, planV2)
+	wordDiff.Words = true
+
+	history := []monitor.Message{user, assistant}
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{"empty", Empty(help)},
+		{"process_view", ProcessView(ProcessViewData{
+			Processes:  len(processes),
+			LastUpdate: goldenTime,
+			Interval:   time.Second,
+			Table:      processTable,
+			Help:       help,
+		})},
+		{"process_view_skipped", ProcessView(ProcessViewData{
+			Processes:  len(processes),
+			LastUpdate: goldenTime,
+			Paused:     true,
+			ReadOnly:   true,
+			Discovery: monitor.DiscoveryReport{Skipped: []monitor.SkippedProcess{
+				{PID: 77, Reason: "permission denied", Err: errors.New("open /proc/77/exe: permission denied")},
+			}},
+			Verbose: true,
+			Table:   processTable,
+			Help:    help,
+		})},
+		{"session_header", SessionHeader(SessionHeaderData{
+			Path:          "/home/demo/.claude/projects/-home-demo-acme-api/3f2a9c1e-0b7d-4c55-9a61-2d8e4b7f1a01.jsonl",
+			ID:            "3f2a9c1e-0b7d-4c55-9a61-2d8e4b7f1a01",
+			ResumeDir:     "/home/demo/acme-api",
+			Version:       "2.1.4",
+			GitBranch:     "main",
+			Compressed:    "1.2 MB→4.8 MB",
+			Modes:         []string{"plan", "acceptEdits"},
+			Tokens:        monitor.TokenCounts{Input: 12, CacheWrite: 60_000, Output: 340, CacheRead: 18_000},
+			UserPrompts:   1,
+			FirstPrompt:   user.Content,
+			Title:         "Fix the flaky login test",
+			Summary:       "Started: 2026-01-12 09:14 | Duration: 6m | Messages: 2 (User: 1, AI: 1)",
+			DetailedStats: "Messages: 2 (User: 1, AI: 1) | Errors: 0",
+			Cost:          0.0201,
+			Throughput:    38.2,
+			Rewarms:       2,
+			RewarmCost:    1.2,
+			History:       history,
+			Composition: []monitor.EntryCount{
+				{Label: "user", Count: 1}, {Label: "assistant", Count: 6}, {Label: "tool results", Count: 4},
+				{Label: "progress", Count: 9}, {Label: "system", Count: 2}, {Label: "unknown", Count: 1},
+			},
+			Width: 120,
+			Turns: monitor.TurnStats{
+				Turns: 1, AvgCost: 0.02, MedianCost: 0.02, AvgToolCalls: 4,
+				AvgDuration: 38 * time.Second, MedianDuration: 38 * time.Second,
+				MostExpensive: 0, MaxCost: 0.02,
+			},
+			MostExpensiveTurn: 1,
+		}, goldenCosts)},
+		{"session_header_loading", SessionHeader(SessionHeaderData{
+			Path:     "/tmp/session.jsonl",
+			Summary:  "Started: 2026-01-12 09:14 | Duration: 6m | Messages: 2 (User: 1, AI: 1)",
+			Partial:  true,
+			Loading:  true,
+			Spinner:  "⣾",
+			Progress: 0.4,
+		}, config.CostConfig{Hidden: true})},
+		{"session_header_out_of_order", SessionHeader(SessionHeaderData{
+			Path:          "/tmp/session.jsonl",
+			Summary:       "Started: 2026-01-12 08:30 | Duration: 1h 11m | Messages: 6 (User: 3, AI: 3)",
+			DetailedStats: "Messages: 6 (User: 3, AI: 3) | Errors: 0",
+			OutOfOrder:    2,
+		}, config.CostConfig{Hidden: true})},
+		{"session_header_copied", SessionHeader(SessionHeaderData{
+			Path:          "/tmp/3f2a9c1e.jsonl",
+			ID:            "3f2a9c1e",
+			RecordedID:    "77aa01bc",
+			Summary:       "Started: 2026-01-12 09:00 | Duration: 20m | Messages: 5 (User: 3, AI: 2)",
+			DetailedStats: "Messages: 5 (User: 3, AI: 2) | Errors: 0",
+		}, config.CostConfig{Hidden: true})},
+		{"session_header_waiting", CompactSessionHeader(SessionHeaderData{
+			Path:        "/tmp/session.jsonl",
+			Duration:    20 * time.Minute,
+			Messages:    7,
+			Waiting:     23 * time.Second,
+			TypicalWait: 40 * time.Second,
+		}, config.CostConfig{Hidden: true})},
+		{"session_header_minimap", SessionHeader(SessionHeaderData{
+			Path:          "/tmp/session.jsonl",
+			Summary:       "Started: 2026-01-12 09:00 | Duration: 20m | Messages: 9 (User: 4, AI: 5)",
+			DetailedStats: "Messages: 9 (User: 4, AI: 5) | Errors: 1",
+			Activities:    []monitor.Activity{monitor.ActivityText, monitor.ActivityTools, monitor.ActivityError, monitor.ActivityUser},
+			CurrentTurn:   1,
+		}, config.CostConfig{Hidden: true})},
+		{"session_header_outside_writes", SessionHeader(SessionHeaderData{
+			Path:          "/tmp/session.jsonl",
+			OutsideWrites: []string{"/etc/hosts", "/srv/shared/config.yaml", "/tmp/notes.md", "/opt/tool/settings.json"},
+			DiffStat:      monitor.DiffStat{Files: 7, Added: 412, Removed: 96},
+			Pastes:        2,
+			PastedBytes:   61_000,
+			Summary:       "Started: 2026-01-12 09:00 | Duration: 20m | Messages: 5 (User: 3, AI: 2)",
+			DetailedStats: "Messages: 5 (User: 3, AI: 2) | Errors: 0",
+		}, config.CostConfig{Hidden: true})},
+		{"session_header_branches", SessionHeader(SessionHeaderData{
+			Path:          "/tmp/session.jsonl",
+			GitBranch:     "main",
+			Branches:      []string{"main", "feature/x", "main"},
+			Summary:       "Started: 2026-01-12 09:00 | Duration: 20m | Messages: 5 (User: 3, AI: 2)",
+			DetailedStats: "Messages: 5 (User: 3, AI: 2) | Errors: 0",
+		}, config.CostConfig{Hidden: true})},
+		{"session_header_compact", CompactSessionHeader(SessionHeaderData{
+			Path:     "/home/demo/.claude/projects/-home-demo-acme-api/3f2a9c1e-0b7d-4c55-9a61-2d8e4b7f1a01.jsonl",
+			Duration: 6*time.Minute + 12*time.Second,
+			Messages: 214,
+			Cost:     1.2,
+			Partial:  true,
+			Loading:  true,
+			Spinner:  "⣾",
+			Progress: 0.4,
+		}, goldenCosts)},
+		{"project_stats", ProjectStats(ProjectStatsData{Name: "~/acme-api", Path: "/home/demo/acme-api", Dir: "~/.claude/projects/-home-demo-acme-api", Stats: project, Help: help}, goldenCosts)},
+		{"project_scanning", ProjectStats(ProjectStatsData{Name: "~/acme-api", Loading: true, Spinner: "⣾", Done: 12, Total: 59, Help: help}, goldenCosts)},
+		{"report", Report(ReportData{Ranges: ranges, Report: report, Help: help}, goldenCosts)},
+		{"report_scanning", Report(ReportData{Ranges: ranges, Selected: 2, Note: "Custom range: 2026-01-01 – 2026-01-12", Loading: true, Spinner: "⣾", Done: 12, Total: 59, Help: help}, goldenCosts)},
+		{"session_preview", SessionPreview(PreviewData{Title: "Fix the flaky login test", Messages: []monitor.Message{user, assistant, tool}, Width: 50, Height: 14})},
+		{"session_preview_error", SessionPreview(PreviewData{Title: "3f2a9c1e", Err: "open /tmp/session.jsonl: permission denied", Width: 50, Height: 5})},
+		{"diff_lines", Diff(diffData)},
+		{"diff_words", Diff(wordDiff)},
+		{"errors", ErrorList(ErrorListData{
+			Entries: []ErrorEntry{
+				{Time: goldenTime, Op: "watch", Message: "cannot watch /home/demo/.claude/projects/-home-demo-acme-api: too many open files"},
+				{Time: goldenTime.Add(-time.Minute), Op: "load sessions", Message: "cannot read session metadata: unexpected end of JSON input", Context: "path=/home/demo/.claude/projects/-home-demo-acme-api/3f2a9c1e.jsonl"},
+			},
+			Dropped: 3,
+			LogPath: "~/.cache/promptwatch/debug.log",
+			Width:   80,
+			Height:  20,
+			Help:    help,
+		})},
+		{"errors_empty", ErrorList(ErrorListData{Width: 80, Height: 20, Help: help})},
+		{"session_loading", SessionLoading("/tmp/session.jsonl", "⣾", 0.25, help)},
+		{"card_user", MessageCard(userCard, false, goldenCosts)},
+		{"card_user_selected", MessageCard(userCard, true, goldenCosts)},
+		{"card_user_paste", MessageCard(pasteCard, false, goldenCosts)},
+		{"card_assistant", MessageCard(assistantCard, false, goldenCosts)},
+		{"card_assistant_selected", MessageCard(assistantCard, true, goldenCosts)},
+		{"card_assistant_growth", MessageCard(growthCard, false, goldenCosts)},
+		{"card_assistant_slow", MessageCard(slowCard, false, goldenCosts)},
+		{"card_assistant_tool_calls", MessageCard(callsCard, false, goldenCosts)},
+		{"card_assistant_running_total", MessageCard(totalCard, false, goldenCosts)},
+		{"card_turn", TurnCard(turnCard, false, goldenCosts)},
+		{"card_turn_selected", TurnCard(turnCard, true, goldenCosts)},
+		{"card_tool_group", ToolGroupCard(ToolGroupCardData{Tool: "Bash", Calls: 23, Cost: 0.41, First: goldenTime, Last: goldenTime.Add(38 * time.Minute), Expanded: true}, false, goldenCosts)},
+		{"card_tool_group_selected", ToolGroupCard(ToolGroupCardData{Tool: "Edit", Calls: 1, Cost: 0.02, First: goldenTime, Last: goldenTime}, true, goldenCosts)},
+		{"confirm", Confirmation("Sessions", "A session is still loading. Really quit?", "y", false)},
+		{"confirm_destructive", Confirmation("Sessions", "Overwrite exports/3f2a9c1e.md?", "y", true)},
+		{"card_branch", BranchCard(BranchCardData{Branch: "feature/x", From: "main", At: time.Date(2026, 1, 12, 9, 10, 0, 0, time.UTC)}, false)},
+		{"detail_pane", MessageDetailPane(MessageDetailData{Message: assistant, Cost: 0.0201, Width: 60, Height: 12, ScrollOffset: 1, Focused: true}, goldenCosts)},
+		{"detail_user", MessageDetail(MessageDetailData{Message: user, Height: 40, Help: help}, goldenCosts)},
+		{"detail_assistant", MessageDetail(MessageDetailData{Message: assistant, Cost: 0.0201, Height: 40, Help: help}, goldenCosts)},
+		{"detail_tool", MessageDetail(MessageDetailData{Message: tool, Cost: 0.0201, Width: 60, Height: 40, Help: help}, goldenCosts)},
+		{"detail_tool_pair", MessageDetail(MessageDetailData{Message: tool, Paired: true, Results: []*monitor.Message{&toolResult}, Cost: 0.0201, Width: 60, Height: 40, Help: help, Position: 2, Total: 5, HasPrev: true, HasNext: true}, goldenCosts)},
+		{"detail_tool_pair_collapsed", MessageDetail(MessageDetailData{Message: tool, Paired: true, Results: []*monitor.Message{&toolResult}, ArgsCollapsed: true, ResultCollapsed: true, Cost: 0.0201, Width: 60, Height: 40, Help: help}, goldenCosts)},
+		{"detail_tool_calls", MessageDetail(MessageDetailData{Message: edits, Cost: 0.0201, Width: 60, Height: 40, Help: help}, goldenCosts)},
+		{"detail_tool_calls_pair", MessageDetail(MessageDetailData{Message: edits, Paired: true, Results: []*monitor.Message{&editResult, nil, &toolResult}, ResultCollapsed: true, Cost: 0.0201, Width: 60, Height: 40, Help: help}, goldenCosts)},
+		{"detail_tool_pair_running", MessageDetail(MessageDetailData{Message: tool, Paired: true, Cost: 0.0201, Width: 60, Height: 40, Help: help}, goldenCosts)},
+		{"detail_position", MessageDetail(MessageDetailData{Message: user, Height: 40, Help: help, Position: 12, Total: 87, Filter: "user filter", HasPrev: true, HasNext: true}, goldenCosts)},
+		{"detail_position_last", MessageDetail(MessageDetailData{Message: user, Height: 40, Help: help, Position: 1, Total: 1, HasPrev: false, HasNext: false}, goldenCosts)},
+		{"detail_scrolled", MessageDetail(MessageDetailData{Message: assistant, Cost: 0.0201, Height: 12, ScrollOffset: 1, Help: help}, goldenCosts)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.name, tt.got)
+		})
+	}
+}
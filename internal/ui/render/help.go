@@ -0,0 +1,138 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Priority decides which key hints survive when the help bar is too narrow
+type Priority int
+
+const (
+	PriorityLow       Priority = iota // Dropped first
+	PriorityNormal                    // Dropped when low-priority hints were not enough
+	PriorityHigh                      // The main actions of a view
+	PriorityEssential                 // Navigation out of the view (esc, q); never dropped
+)
+
+// KeyHint is one entry of a help bar, rendered as "key: desc"
+type KeyHint struct {
+	Key      string
+	Desc     string
+	Priority Priority
+}
+
+func (h KeyHint) String() string {
+	return h.Key + ": " + h.Desc
+}
+
+const (
+	hintSeparator = "  |  "
+	// moreHint is appended when hints had to be dropped
+	moreHint = "… ?: More"
+)
+
+// fitHints returns the hints to show within width, in their original order, and
+// whether any had to be dropped. Hints are dropped lowest priority first and, within a
+// priority, from the end; essential hints are always kept. width <= 0 means unlimited.
+func fitHints(hints []KeyHint, width int) (kept []KeyHint, dropped bool) {
+	keep := make([]bool, len(hints))
+	for i := range keep {
+		keep[i] = true
+	}
+	line := func(more bool) string {
+		var parts []string
+		for i, h := range hints {
+			if keep[i] {
+				parts = append(parts, h.String())
+			}
+		}
+		if more {
+			parts = append(parts, moreHint)
+		}
+		return strings.Join(parts, hintSeparator)
+	}
+
+	if width <= 0 || lipgloss.Width(line(false)) <= width {
+		return hints, false
+	}
+drop:
+	for p := PriorityLow; p < PriorityEssential; p++ {
+		for i := len(hints) - 1; i >= 0; i-- {
+			if !keep[i] || hints[i].Priority != p {
+				continue
+			}
+			keep[i] = false
+			if lipgloss.Width(line(true)) <= width {
+				break drop
+			}
+		}
+	}
+
+	for i, h := range hints {
+		if keep[i] {
+			kept = append(kept, h)
+		}
+	}
+	return kept, true
+}
+
+// HelpBar renders key hints on a single line of at most width columns (unlimited if
+// width <= 0). Low-priority hints are dropped first and "… ?: More" marks that some
+// are hidden; the line is cut off only if the essential hints alone don't fit.
+func HelpBar(hints []KeyHint, width int) string {
+	kept, dropped := fitHints(hints, width)
+	parts := make([]string, 0, len(kept)+1)
+	for _, h := range kept {
+		parts = append(parts, h.String())
+	}
+	if dropped {
+		parts = append(parts, moreHint)
+	}
+	text := strings.Join(parts, hintSeparator)
+	if width > 0 && lipgloss.Width(text) > width {
+		text = truncateWidth(text, width)
+	}
+	return Footer(text)
+}
+
+// FullHelp renders all key hints, wrapped onto as many lines as width requires
+func FullHelp(hints []KeyHint, width int) string {
+	var lines []string
+	var current string
+	for _, h := range hints {
+		entry := h.String()
+		switch {
+		case current == "":
+			current = entry
+		case width > 0 && lipgloss.Width(current+hintSeparator+entry) > width:
+			lines = append(lines, current)
+			current = entry
+		default:
+			current += hintSeparator + entry
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return Footer(strings.Join(lines, "\n"))
+}
+
+// truncateWidth cuts text to at most width columns, ending with "…"
+func truncateWidth(text string, width int) string {
+	if width <= 1 {
+		return strings.Repeat("…", max(width, 0))
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range text {
+		w := lipgloss.Width(string(r))
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + "…"
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var testHints = []KeyHint{
+	{Key: "↑/↓", Desc: "Navigate", Priority: PriorityNormal},
+	{Key: "enter", Desc: "View sessions", Priority: PriorityHigh},
+	{Key: "p", Desc: "Projects", Priority: PriorityHigh},
+	{Key: "r", Desc: "Refresh", Priority: PriorityNormal},
+	{Key: "+/-", Desc: "Interval", Priority: PriorityLow},
+	{Key: "space", Desc: "Pause", Priority: PriorityLow},
+	{Key: "q", Desc: "Quit", Priority: PriorityEssential},
+}
+
+func TestHelpBar(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		want  string
+	}{
+		{"unlimited", 0, "↑/↓: Navigate  |  enter: View sessions  |  p: Projects  |  r: Refresh  |  +/-: Interval  |  space: Pause  |  q: Quit"},
+		{"fits exactly", 116, "↑/↓: Navigate  |  enter: View sessions  |  p: Projects  |  r: Refresh  |  +/-: Interval  |  space: Pause  |  q: Quit"},
+		{"drops low priority from the end", 115, "↑/↓: Navigate  |  enter: View sessions  |  p: Projects  |  r: Refresh  |  +/-: Interval  |  q: Quit  |  … ?: More"},
+		{"drops all low priority", 100, "↑/↓: Navigate  |  enter: View sessions  |  p: Projects  |  r: Refresh  |  q: Quit  |  … ?: More"},
+		{"drops normal priority", 70, "enter: View sessions  |  p: Projects  |  q: Quit  |  … ?: More"},
+		{"keeps only essentials", 25, "q: Quit  |  … ?: More"},
+		{"cuts off essentials last", 5, "q: Q…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HelpBar(testHints, tt.width)
+			if got != tt.want {
+				t.Errorf("HelpBar(width %d) =\n%q\nwant\n%q", tt.width, got, tt.want)
+			}
+			if tt.width > 0 && lipgloss.Width(got) > tt.width {
+				t.Errorf("HelpBar(width %d) is %d columns wide", tt.width, lipgloss.Width(got))
+			}
+		})
+	}
+}
+
+func TestFullHelp(t *testing.T) {
+	got := FullHelp(testHints, 50)
+	lines := strings.Split(got, "\n")
+	if len(lines) < 3 {
+		t.Fatalf("FullHelp(50) = %q, want several lines", got)
+	}
+	for _, line := range lines {
+		if lipgloss.Width(line) > 50 {
+			t.Errorf("line %q is wider than 50 columns", line)
+		}
+	}
+	for _, h := range testHints {
+		if !strings.Contains(got, h.String()) {
+			t.Errorf("FullHelp is missing %q", h)
+		}
+	}
+
+	if got := FullHelp(testHints, 0); strings.Contains(got, "\n") {
+		t.Errorf("FullHelp(0) = %q, want a single line", got)
+	}
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// minimapGlyphs tell the activities apart without color: a dot for an unanswered
+// prompt, a low bar for text replies, a tall one for tool work and a cross for failures
+var minimapGlyphs = map[monitor.Activity]string{
+	monitor.ActivityUser:  "·",
+	monitor.ActivityText:  "▃",
+	monitor.ActivityTools: "▇",
+	monitor.ActivityError: "✗",
+}
+
+// minimapColor returns the color of an activity on the minimap
+func minimapColor(a monitor.Activity) lipgloss.Color {
+	switch a {
+	case monitor.ActivityUser:
+		return theme.User
+	case monitor.ActivityText:
+		return theme.Assistant
+	case monitor.ActivityError:
+		return theme.Alert
+	}
+	return "13"
+}
+
+// Minimap renders a session's turns as a line of at most width cells (unlimited if
+// width <= 0), one turn per cell or, with more turns than cells, one bucket of consecutive turns per cell. The
+// cell of turn current (an index into activities, -1 for none) is shown in reverse
+// video to mark where the view is.
+func Minimap(activities []monitor.Activity, current, width int) string {
+	cells := minimapBuckets(activities, width)
+	marked := -1
+	if current >= 0 && current < len(activities) {
+		marked = minimapCell(current, len(activities), len(cells))
+	}
+
+	var b strings.Builder
+	for i, a := range cells {
+		style := lipgloss.NewStyle().Foreground(minimapColor(a))
+		if i == marked {
+			style = style.Reverse(true)
+		}
+		b.WriteString(style.Render(minimapGlyphs[a]))
+	}
+	return b.String()
+}
+
+// minimapBuckets fits the activities of turns into at most width cells (one per turn if
+// width <= 0). A cell covering
+// several turns shows a failure if any of them failed, and their most common activity
+// otherwise, the busier one on a tie.
+func minimapBuckets(activities []monitor.Activity, width int) []monitor.Activity {
+	if len(activities) == 0 {
+		return nil
+	}
+	cells := len(activities)
+	if width > 0 {
+		cells = min(cells, width)
+	}
+	counts := make([][monitor.ActivityError + 1]int, cells)
+	for i, a := range activities {
+		counts[minimapCell(i, len(activities), cells)][a]++
+	}
+
+	buckets := make([]monitor.Activity, cells)
+	for i, c := range counts {
+		if c[monitor.ActivityError] > 0 {
+			buckets[i] = monitor.ActivityError
+			continue
+		}
+		for a := monitor.ActivityUser; a < monitor.ActivityError; a++ {
+			if c[a] >= c[buckets[i]] {
+				buckets[i] = a
+			}
+		}
+	}
+	return buckets
+}
+
+// minimapCell returns the cell showing turn i of n on a minimap of the given number of cells
+func minimapCell(i, n, cells int) int {
+	if n <= 0 || cells <= 0 {
+		return 0
+	}
+	return min(i*cells/n, cells-1)
+}
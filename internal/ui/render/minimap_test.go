@@ -0,0 +1,55 @@
+package render
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// TestMinimapBuckets tests how turns are fitted into the minimap's cells
+func TestMinimapBuckets(t *testing.T) {
+	const (
+		u = monitor.ActivityUser
+		x = monitor.ActivityText
+		o = monitor.ActivityTools
+		e = monitor.ActivityError
+	)
+	tests := []struct {
+		name       string
+		activities []monitor.Activity
+		width      int
+		want       []monitor.Activity
+	}{
+		{"no turns", nil, 10, nil},
+		{"one cell per turn", []monitor.Activity{x, o, u}, 10, []monitor.Activity{x, o, u}},
+		{"unlimited width", []monitor.Activity{x, o, u}, 0, []monitor.Activity{x, o, u}},
+		{"most common wins", []monitor.Activity{x, x, o, o, o, x}, 2, []monitor.Activity{x, o}},
+		{"busier wins a tie", []monitor.Activity{x, o, u, x}, 2, []monitor.Activity{o, x}},
+		{"failures always show", []monitor.Activity{o, o, e, x, x, x}, 2, []monitor.Activity{e, x}},
+	}
+	for _, tt := range tests {
+		if got := minimapBuckets(tt.activities, tt.width); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: minimapBuckets = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestMinimapCell tests which cell shows a turn
+func TestMinimapCell(t *testing.T) {
+	tests := []struct {
+		i, n, cells, want int
+	}{
+		{0, 10, 10, 0},
+		{9, 10, 10, 9},
+		{5, 10, 2, 1},
+		{4, 10, 2, 0},
+		{99, 100, 7, 6},
+		{0, 0, 5, 0},
+	}
+	for _, tt := range tests {
+		if got := minimapCell(tt.i, tt.n, tt.cells); got != tt.want {
+			t.Errorf("minimapCell(%d, %d, %d) = %d, want %d", tt.i, tt.n, tt.cells, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/thieso2/promptwatch/internal/config"
+)
+
+// numberFormat and costFormat are how token counts and costs are written
+var (
+	numberFormat = config.Default().Numbers
+	costFormat   = config.Default().Cost
+)
+
+// SetNumberFormat selects how all later rendering writes token counts, from the
+// config's numbers section, and costs, from its currency and decimals
+func SetNumberFormat(numbers config.NumbersConfig, costs config.CostConfig) {
+	numberFormat = numbers
+	costFormat = costs
+}
+
+// FormatPasteSize formats the size of pasted content roughly, as "~48KB" or "~1.2MB";
+// pastes under a kilobyte, as of a prompt keeping only a paste marker, are "<1KB"
+func FormatPasteSize(bytes int) string {
+	switch {
+	case bytes < 1024:
+		return "<1KB"
+	case bytes < 1024*1024:
+		return fmt.Sprintf("~%dKB", (bytes+512)/1024)
+	default:
+		return fmt.Sprintf("~%.1fMB", float64(bytes)/1024/1024)
+	}
+}
+
+// FormatThroughput formats an output rate in tokens per second, e.g. "38 tok/s" or
+// "4.2 tok/s"; rates not known (ok false) or not finite are "n/a"
+func FormatThroughput(rate float64, ok bool) string {
+	switch {
+	case !ok || math.IsInf(rate, 0) || math.IsNaN(rate) || rate <= 0:
+		return "n/a"
+	case rate < 9.95:
+		return fmt.Sprintf("%.1f tok/s", rate)
+	default:
+		return fmt.Sprintf("%.0f tok/s", rate)
+	}
+}
+
+// FormatTokenCount formats a token count as numbers.tokens says: compactly as "850",
+// "1.2k", "18k" or "31.4M", or in full as "1,432,191"
+func FormatTokenCount(tokens int) string {
+	if numberFormat.Tokens == "grouped" {
+		return FormatCount(tokens)
+	}
+	switch {
+	case tokens < 1000:
+		return fmt.Sprintf("%d", tokens)
+	case tokens < 10000:
+		return fmt.Sprintf("%.1fk", float64(tokens)/1000)
+	case tokens < 999_500:
+		return fmt.Sprintf("%dk", (tokens+500)/1000)
+	default:
+		return fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	}
+}
+
+// FormatTokenEstimate formats an estimated token count as FormatTokenCount does, marked
+// as an estimate, e.g. "~850" or "~1.2k"
+func FormatTokenEstimate(tokens int) string {
+	return "~" + FormatTokenCount(tokens)
+}
+
+// FormatCount formats a count in full, separating groups of three digits with
+// numbers.separator, e.g. "1,432,191"
+func FormatCount(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(numberFormat.Separator)
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// CostPrecision is the number of decimal places a cost gets on top of cost.decimals
+type CostPrecision int
+
+const (
+	TotalPrecision   CostPrecision = 0 // Session, turn, project and day totals
+	MessagePrecision CostPrecision = 2 // Single messages
+	ExactPrecision   CostPrecision = 4 // The message detail view
+)
+
+// FormatCost formats a cost with the currency and decimals of the cost config, e.g.
+// "$1.23", or "$0.0123" with MessagePrecision
+func FormatCost(cost float64, precision CostPrecision) string {
+	return costFormat.Currency + strconv.FormatFloat(cost, 'f', costFormat.Decimals+int(precision), 64)
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	"github.com/thieso2/promptwatch/internal/config"
+)
+
+// TestNumberFormat tests token counts and costs in the default and in other formats
+func TestNumberFormat(t *testing.T) {
+	defaults := config.Default()
+	t.Cleanup(func() { SetNumberFormat(defaults.Numbers, defaults.Cost) })
+
+	euro := defaults.Cost
+	euro.Currency, euro.Decimals = "€", 3
+	tests := []struct {
+		name     string
+		numbers  config.NumbersConfig
+		costs    config.CostConfig
+		tokens   int
+		want     string // FormatTokenCount(tokens)
+		estimate string // FormatTokenEstimate(tokens)
+		exact    string // FormatCount(tokens)
+		total    string // FormatCost(1.2345, TotalPrecision)
+		message  string // FormatCost(1.2345, MessagePrecision)
+	}{
+		{"si small", defaults.Numbers, defaults.Cost, 850, "850", "~850", "850", "$1.23", "$1.2345"},
+		{"si thousands", defaults.Numbers, defaults.Cost, 1234, "1.2k", "~1.2k", "1,234", "$1.23", "$1.2345"},
+		{"si hundreds of thousands", defaults.Numbers, defaults.Cost, 212_400, "212k", "~212k", "212,400", "$1.23", "$1.2345"},
+		{"si millions", defaults.Numbers, defaults.Cost, 1_432_191, "1.4M", "~1.4M", "1,432,191", "$1.23", "$1.2345"},
+		{"grouped", config.NumbersConfig{Tokens: "grouped", Separator: "."}, euro, 1_432_191, "1.432.191", "~1.432.191", "1.432.191", "€1.234", "€1.23450"},
+		{"grouped thin space", config.NumbersConfig{Tokens: "grouped", Separator: " "}, defaults.Cost, 12_000, "12 000", "~12 000", "12 000", "$1.23", "$1.2345"},
+		{"grouped negative", config.NumbersConfig{Tokens: "grouped", Separator: ","}, defaults.Cost, -48_000, "-48,000", "~-48,000", "-48,000", "$1.23", "$1.2345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetNumberFormat(tt.numbers, tt.costs)
+			if got := FormatTokenCount(tt.tokens); got != tt.want {
+				t.Errorf("FormatTokenCount(%d) = %q, want %q", tt.tokens, got, tt.want)
+			}
+			if got := FormatTokenEstimate(tt.tokens); got != tt.estimate {
+				t.Errorf("FormatTokenEstimate(%d) = %q, want %q", tt.tokens, got, tt.estimate)
+			}
+			if got := FormatCount(tt.tokens); got != tt.exact {
+				t.Errorf("FormatCount(%d) = %q, want %q", tt.tokens, got, tt.exact)
+			}
+			if got := FormatCost(1.2345, TotalPrecision); got != tt.total {
+				t.Errorf("FormatCost(total) = %q, want %q", got, tt.total)
+			}
+			if got := FormatCost(1.2345, MessagePrecision); got != tt.message {
+				t.Errorf("FormatCost(message) = %q, want %q", got, tt.message)
+			}
+		})
+	}
+}
+
+// TestFormatThroughput tests output rates, including ones that are unknown or not finite
+func TestFormatThroughput(t *testing.T) {
+	tests := []struct {
+		rate float64
+		ok   bool
+		want string
+	}{
+		{38.4, true, "38 tok/s"},
+		{4.25, true, "4.2 tok/s"},
+		{9.96, true, "10 tok/s"},
+		{0, true, "n/a"},
+		{38, false, "n/a"},
+		{math.Inf(1), true, "n/a"},
+		{math.NaN(), true, "n/a"},
+	}
+	for _, tt := range tests {
+		if got := FormatThroughput(tt.rate, tt.ok); got != tt.want {
+			t.Errorf("FormatThroughput(%g, %v) = %q, want %q", tt.rate, tt.ok, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// PreviewMessages is how many of a session's latest messages the preview pane shows
+const PreviewMessages = 10
+
+// previewContentLines caps the content lines shown per message in the preview pane
+const previewContentLines = 2
+
+// PreviewData is everything the session list's preview pane shows
+type PreviewData struct {
+	Title    string            // Title of the highlighted session
+	Messages []monitor.Message // Its latest messages, oldest first
+	Loading  bool              // Messages are being read
+	Err      string            // Why the session could not be read
+	Width    int               // Pane width, including its left border
+	Height   int               // Pane height
+}
+
+// SessionPreview renders the preview pane: the highlighted session's latest messages,
+// newest at the bottom. Older messages are dropped when they do not fit the height.
+func SessionPreview(d PreviewData) string {
+	inner := max(d.Width-2, 1) // Border and padding
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+
+	lines := []string{lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11")).Render(fitWidth(d.Title, inner)), ""}
+	switch {
+	case d.Err != "":
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(fitWidth("⚠ "+d.Err, inner)))
+	case d.Loading && len(d.Messages) == 0:
+		lines = append(lines, dim.Render("Loading…"))
+	case len(d.Messages) == 0:
+		lines = append(lines, dim.Render("No messages yet"))
+	default:
+		var blocks [][]string
+		for _, msg := range d.Messages {
+			blocks = append(blocks, previewBlock(msg, inner))
+		}
+		// Keep the newest blocks that fit below the title
+		room := d.Height - len(lines)
+		first := len(blocks)
+		for first > 0 && len(blocks[first-1]) <= room {
+			first--
+			room -= len(blocks[first])
+		}
+		for _, block := range blocks[first:] {
+			lines = append(lines, block...)
+		}
+	}
+	if len(lines) > d.Height {
+		lines = lines[:max(d.Height, 0)]
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(lipgloss.Color("238")).
+		PaddingLeft(1).
+		Width(d.Width - 1).
+		Height(d.Height).
+		Render(strings.Join(lines, "\n"))
+}
+
+// previewBlock renders one message of the preview pane: a header line, up to
+// previewContentLines of content and a blank line
+func previewBlock(msg monitor.Message, width int) []string {
+	header := []string{"👤", "user"}
+	switch {
+	case msg.Type == "tool_result":
+		header = []string{"↩", "tool result"}
+	case msg.Role == "assistant":
+		header = []string{"🤖", "assistant"}
+	}
+	if !msg.Timestamp.IsZero() {
+		header = append(header, "·", msg.Timestamp.Local().Format("15:04:05"))
+	}
+	if summary := ToolCallSummary(msg.Calls()); summary != "" {
+		header = append(header, "·", summary)
+	} else if msg.ToolName != "" {
+		header = append(header, "·", msg.ToolName)
+	}
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	if msg.IsError {
+		headerStyle = headerStyle.Foreground(theme.Alert)
+		if theme.Glyphs {
+			header = append([]string{"✗"}, header...)
+		}
+	}
+	block := []string{headerStyle.Render(fitWidth(strings.Join(header, " "), width))}
+
+	content := wrapWords(msg.Content, width)
+	if len(content) > previewContentLines {
+		content = content[:previewContentLines]
+		content[previewContentLines-1] += " …"
+	}
+	for _, line := range content {
+		block = append(block, lipgloss.NewStyle().Foreground(lipgloss.Color("250")).Render(fitWidth(line, width)))
+	}
+	return append(block, "")
+}
+
+// fitWidth returns text, cut off with "…" if it is wider than width columns
+func fitWidth(text string, width int) string {
+	if lipgloss.Width(text) <= width {
+		return text
+	}
+	return truncateWidth(text, width)
+}
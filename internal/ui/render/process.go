@@ -0,0 +1,283 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/types"
+)
+
+// noSessionsMarker follows the WORKDIR of processes without sessions
+const noSessionsMarker = " · no sessions yet"
+
+// ProcessPIDKey is the hidden row data key holding each process row's PID
+const ProcessPIDKey = "pidValue"
+
+// ProcessViewData is everything the process view shows around its table
+type ProcessViewData struct {
+	Processes       int // Number of listed processes
+	ShowHelpers     bool
+	ShowSessionless bool // Processes without sessions are listed too
+	LastUpdate      time.Time
+	Interval        time.Duration
+	Paused          bool
+	ReadOnly        bool   // Read-only mode, marked next to the title
+	Note            string // Transient notice, e.g. "Process 123 exited"
+	Discovery       monitor.DiscoveryReport
+	Verbose         bool   // List skipped processes below the table
+	Table           string // Rendered process table
+	Help            string // Rendered help bar
+}
+
+// Empty displays a message when no processes are found
+func Empty(help string) string {
+	header := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("promptwatch")
+
+	content := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render("No Claude instances found.")
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		content,
+		help,
+	)
+}
+
+// ProcessView renders the process list: status header, table and help footer
+func ProcessView(d ProcessViewData) string {
+	// Header with title and status
+	headerTitle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("promptwatch")
+
+	status := fmt.Sprintf("%d instances", d.Processes)
+	switch {
+	case d.ShowHelpers && d.ShowSessionless:
+		status += " (including helpers and session-less)"
+	case d.ShowHelpers:
+		status += " (including helpers)"
+	case d.ShowSessionless:
+		status += " (including session-less)"
+	}
+	statusText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(status)
+
+	refreshStatus := fmt.Sprintf("Updated: %s  every %s", d.LastUpdate.Format("15:04:05"), d.Interval)
+	if d.Paused {
+		refreshStatus = fmt.Sprintf("Updated: %s  ⏸ paused", d.LastUpdate.Format("15:04:05"))
+	}
+	timestamp := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(refreshStatus)
+
+	if d.ReadOnly {
+		headerTitle += lipgloss.NewStyle().
+			Foreground(lipgloss.Color("3")).
+			Render(" [read-only]")
+	}
+
+	headerLine := lipgloss.JoinHorizontal(
+		lipgloss.Left,
+		headerTitle,
+		"  ",
+		statusText,
+		"  |  ",
+		timestamp,
+	)
+	if d.Note != "" {
+		note := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("3")).
+			Render("  |  " + d.Note)
+		headerLine = lipgloss.JoinHorizontal(lipgloss.Left, headerLine, note)
+	}
+
+	if summary := d.Discovery.Summary(); summary != "" {
+		hint := ""
+		if !d.Verbose {
+			hint = " — run with --verbose-processes for details"
+		}
+		note := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("3")).
+			Render("  |  ⚠ " + summary + hint)
+		headerLine = lipgloss.JoinHorizontal(lipgloss.Left, headerLine, note)
+	}
+
+	tableView := d.Table
+	if d.Verbose && len(d.Discovery.Skipped) > 0 {
+		var skipped []string
+		for _, s := range d.Discovery.Skipped {
+			skipped = append(skipped, fmt.Sprintf("  skipped PID %d: %s (%v)", s.PID, s.Reason, s.Err))
+		}
+		tableView = lipgloss.JoinVertical(lipgloss.Left, tableView, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(strings.Join(skipped, "\n")))
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		headerLine,
+		"",
+		tableView,
+		"",
+		d.Help,
+	)
+}
+
+// DefaultWorkdirShare is the percentage of the process table width given to WORKDIR
+const DefaultWorkdirShare = 30
+
+// ProcessColumns holds the widths of the process table's path columns, which the rows
+// are truncated to
+type ProcessColumns struct {
+	Workdir int
+	Command int
+}
+
+// NewProcessTable creates the process table with columns sized for the given width,
+// giving workdirShare percent of it to WORKDIR and the rest after the fixed columns
+// to COMMAND
+func NewProcessTable(width, workdirShare int) (table.Model, ProcessColumns) {
+	// Calculate responsive column widths
+	// Reserve space for borders and padding (roughly 2 chars per column)
+	availableWidth := width - 14 // Reserve for borders and spacing
+
+	pidWidth := 8
+	cpuWidth := 10
+	memWidth := 12
+	uptimeWidth := 12
+	rateWidth := RateWidth
+	workdirWidth := (availableWidth * workdirShare) / 100
+	cmdWidth := availableWidth - pidWidth - cpuWidth - memWidth - uptimeWidth - rateWidth - workdirWidth
+
+	// Ensure minimum widths
+	if workdirWidth < 20 {
+		workdirWidth = 20
+	}
+	if cmdWidth < 20 {
+		cmdWidth = 20
+	}
+
+	columns := []table.Column{
+		table.NewColumn("pid", "PID", pidWidth),
+		table.NewColumn("cpu", "CPU%", cpuWidth),
+		table.NewColumn("mem", "MEM", memWidth),
+		table.NewColumn("uptime", "UPTIME", uptimeWidth),
+		table.NewColumn("rate", RateHeader, rateWidth),
+		table.NewColumn("workdir", "WORKDIR", workdirWidth),
+		table.NewColumn("cmd", "COMMAND", cmdWidth),
+	}
+
+	t := table.New(columns).
+		WithPageSize(20).
+		WithBaseStyle(
+			lipgloss.NewStyle().
+				Foreground(lipgloss.Color("255")),
+		).
+		Focused(true)
+	return t, ProcessColumns{Workdir: workdirWidth, Command: cmdWidth}
+}
+
+// ProcessRows builds the process table rows, keeping each PID under ProcessPIDKey and
+// truncating the paths to the column widths. rates holds the token rates of the
+// processes' sessions by PID.
+func ProcessRows(processes []types.ClaudeProcess, cols ProcessColumns, rates map[int32]monitor.TokenRate) []table.Row {
+	rows := make([]table.Row, len(processes))
+
+	for i, proc := range processes {
+		cpu := "..."
+		if proc.CPUPercent > 0 {
+			cpu = formatCPU(proc.CPUPercent)
+		}
+
+		var workdir any = monitor.TruncatePath(proc.WorkingDir, cols.Workdir)
+		switch {
+		case proc.WorkDirGone:
+			workdir = table.NewStyledCell(
+				"[gone: "+monitor.TruncatePath(proc.WorkingDir, max(cols.Workdir-len("[gone: ]"), 1))+"]",
+				lipgloss.NewStyle().Foreground(theme.Alert))
+		case proc.NoSessions:
+			workdir = table.NewStyledCell(
+				monitor.TruncatePath(proc.WorkingDir, max(cols.Workdir-lipgloss.Width(noSessionsMarker), 1))+noSessionsMarker,
+				lipgloss.NewStyle().Foreground(lipgloss.Color("8")))
+		}
+
+		rows[i] = table.NewRow(table.RowData{
+			"pid":         fmt.Sprintf("%d", proc.PID),
+			"cpu":         cpu,
+			"mem":         formatMemory(proc.MemoryMB),
+			"uptime":      formatUptime(proc.Uptime),
+			"rate":        RateCell(rates[proc.PID]),
+			"workdir":     workdir,
+			"cmd":         monitor.TruncatePath(proc.Command, cols.Command),
+			ProcessPIDKey: proc.PID,
+		})
+	}
+	return rows
+}
+
+// RateHeader heads the columns showing a monitor.TokenRate with RateCell
+const RateHeader = "RATE 5m"
+
+// RateWidth is the width of the columns showing RateCell
+const RateWidth = 13
+
+// RateCell formats a token rate as the tokens added in the last 5 minutes followed by a
+// sparkline of the last 15 in steps of 3 minutes, e.g. "18k ▁▃▅▇█"; idle sessions show
+// nothing, so that the busy ones stand out
+func RateCell(rate monitor.TokenRate) string {
+	if rate.Idle() {
+		return ""
+	}
+	const step = 3
+	steps := make([]float64, monitor.RateMinutes/step)
+	for i, n := range rate {
+		steps[i/step] += float64(n)
+	}
+	return FormatTokenCount(rate.Last(5)) + " " + Chart(steps, 1)[0]
+}
+
+func formatCPU(percent float64) string {
+	if percent > 99.9 {
+		return ">99%"
+	}
+	return fmt.Sprintf("%.1f%%", percent)
+}
+
+func formatMemory(mb float64) string {
+	if mb > 1024 {
+		gb := mb / 1024
+		return fmt.Sprintf("%.2fG", gb)
+	}
+	return fmt.Sprintf("%.2fM", mb)
+}
+
+func formatUptime(d time.Duration) string {
+	if d < 0 {
+		return "unknown"
+	}
+
+	days := d / (24 * time.Hour)
+	d = d % (24 * time.Hour)
+	hours := d / time.Hour
+	d = d % time.Hour
+	minutes := d / time.Minute
+
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
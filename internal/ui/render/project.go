@@ -0,0 +1,293 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// ProjectStatsData is everything the project detail view shows
+type ProjectStatsData struct {
+	Name  string                // Human-readable project name
+	Path  string                // Original project path; "" if unknown
+	Dir   string                // Encoded directory under ~/.claude/projects
+	Note  string                // Transient notice, e.g. what was copied
+	Stats *monitor.ProjectStats // nil while the first scan is running
+
+	Loading  bool   // Session files are still being scanned
+	Spinner  string // Rendered spinner frame shown while loading
+	Done     int    // Session files scanned so far
+	Total    int    // Session files to scan
+	Error    string // Why the project could not be scanned
+	Help     string // Rendered help bar; "" leaves it out (e.g. on the CLI)
+	TopTools int    // How many tools and busy days to list
+}
+
+const projectBarWidth = 20
+
+// RunningHeader heads the projects column showing RunningCell
+const RunningHeader = "RUNNING"
+
+// RunningWidth is the width of the column showing RunningCell, e.g. "● running (pid 42424 +1)"
+const RunningWidth = 26
+
+// RunningCell shows the Claude instances working in a project, e.g. "● running (pid 4242)"
+// or "● running (pid 4242 +1)" for two; nothing when none is
+func RunningCell(pids []int32) table.StyledCell {
+	if len(pids) == 0 {
+		return table.NewStyledCell("", lipgloss.NewStyle())
+	}
+	text := fmt.Sprintf("● running (pid %d", pids[0])
+	if len(pids) > 1 {
+		text += fmt.Sprintf(" +%d", len(pids)-1)
+	}
+	return table.NewStyledCell(text+")", lipgloss.NewStyle().Foreground(lipgloss.Color("10")))
+}
+
+// EfficiencySessions is how many of a project's latest sessions the efficiency charts cover
+const EfficiencySessions = 30
+
+// efficiencyChartHeight is the height of each efficiency chart in rows
+const efficiencyChartHeight = 3
+
+// ProjectStats renders the project dashboard: overview, per-model split, top tools
+// and busiest days
+func ProjectStats(d ProjectStatsData, costs config.CostConfig) string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("Project: " + d.Name)
+	components := []string{title}
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	if d.Path != "" {
+		components = append(components, dim.Render("Path: "+d.Path))
+	}
+	if d.Dir != "" {
+		components = append(components, dim.Render("Dir:  "+d.Dir))
+	}
+	if d.Note != "" {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("10")).
+			Render(d.Note))
+	}
+
+	switch {
+	case d.Error != "":
+		components = append(components, "", lipgloss.NewStyle().
+			Foreground(lipgloss.Color("1")).
+			Render("Error: "+d.Error))
+	case d.Stats == nil:
+		components = append(components, "", scanProgress(d.Spinner, d.Done, d.Total))
+	default:
+		if d.Loading {
+			components = append(components, scanProgress(d.Spinner, d.Done, d.Total))
+		}
+		components = append(components, projectSections(d, costs)...)
+	}
+
+	if d.Help != "" {
+		components = append(components, "", d.Help)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, components...)
+}
+
+// scanProgress renders the progress of a scan of session files, e.g.
+// "⣾ Scanning sessions ██████░░ 12/42"
+func scanProgress(spinner string, done, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * projectBarWidth / total
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("10")).
+		Render(fmt.Sprintf("%s Scanning sessions %s%s %d/%d",
+			spinner, strings.Repeat("█", filled), strings.Repeat("░", projectBarWidth-filled), done, total))
+}
+
+// projectSections renders the dashboard sections of a scanned project
+func projectSections(d ProjectStatsData, costs config.CostConfig) []string {
+	s := d.Stats
+	heading := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	top := d.TopTools
+	if top <= 0 {
+		top = 5
+	}
+
+	// Overview
+	sessions := fmt.Sprintf("%d", s.Sessions)
+	var extra []string
+	if s.Agents > 0 {
+		extra = append(extra, fmt.Sprintf("+%d agents", s.Agents))
+	}
+	if s.Failed > 0 {
+		extra = append(extra, fmt.Sprintf("%d unreadable", s.Failed))
+	}
+	if s.Bypass > 0 {
+		extra = append(extra, lipgloss.NewStyle().
+			Foreground(theme.Alert).
+			Render(fmt.Sprintf("%d in bypass mode", s.Bypass)))
+	}
+	if s.Outside > 0 {
+		extra = append(extra, lipgloss.NewStyle().
+			Foreground(theme.Alert).
+			Render(fmt.Sprintf("%d wrote outside workdir", s.Outside)))
+	}
+	if len(extra) > 0 {
+		sessions += " (" + strings.Join(extra, ", ") + ")"
+	}
+	overview := []string{
+		"Sessions:    " + sessions,
+	}
+	days := 1 // Days the totals cover, from the first to the last active one
+	if !s.First.IsZero() {
+		days = int(s.Last.Local().Sub(s.First.Local()).Hours()/24) + 1
+		overview = append(overview, fmt.Sprintf("Active:      %s – %s (%s)",
+			s.First.Local().Format("2006-01-02"), s.Last.Local().Format("2006-01-02"), plural(days, "day")))
+	}
+	overview = append(overview,
+		"Avg length:  "+s.AvgDuration().Round(time.Second).String(),
+		"Tokens:      "+TokenBreakdown(s.Tokens),
+	)
+	if cost := Cost(costs, s.Cost, costs.Days(days), "", TotalPrecision); cost != "" {
+		overview = append(overview, "Cost:        "+cost)
+	}
+	if langs := LanguageShares(s.Languages); langs != "" {
+		overview = append(overview, "Languages:   "+langs)
+	}
+	if s.Pastes > 0 {
+		overview = append(overview, fmt.Sprintf("Pasted:      %s in %s", FormatPasteSize(s.PastedBytes), plural(s.Pastes, "prompt")))
+	}
+	sections := []string{"", heading.Render("Overview")}
+	sections = append(sections, indent(overview)...)
+
+	// Per-model split
+	if len(s.Models) > 0 {
+		sections = append(sections, "", heading.Render("Models")+" (tokens: "+monitor.TotalLabel+")")
+		sections = append(sections, indent(modelSplit(s, costs, costs.Days(days)))...)
+	}
+
+	// Top tools
+	if tools := s.TopTools(top); len(tools) > 0 {
+		// Responses that call several tools count each call
+		sections = append(sections, "", heading.Render("Top tools (every call counted)"))
+		width := 0
+		for _, t := range tools {
+			width = max(width, len(t.Label))
+		}
+		var lines []string
+		for _, t := range tools {
+			lines = append(lines, fmt.Sprintf("%-*s  %d", width, t.Label, t.Count))
+		}
+		sections = append(sections, indent(lines)...)
+	}
+
+	// Largest pastes, the prompts most worth replacing with a file reference
+	if len(s.LargestPastes) > 0 {
+		sections = append(sections, "", heading.Render("Largest pastes")+dim.Render(" (reference the file instead: @path)"))
+		var lines []string
+		for _, paste := range s.LargestPastes {
+			lines = append(lines, fmt.Sprintf("%-7s %s  %s  %s", FormatPasteSize(paste.Bytes),
+				paste.At.Local().Format("2006-01-02 15:04"), dim.Render(shortID(paste.Session)), Snippet(paste.Prompt, "first", false, 60)))
+		}
+		sections = append(sections, indent(lines)...)
+	}
+
+	// Tokens per prompt over the latest sessions
+	if recent := s.RecentEfficiency(EfficiencySessions); len(recent) > 1 {
+		sections = append(sections, "", heading.Render(fmt.Sprintf("Efficiency (last %d sessions)", len(recent))))
+		output := make([]float64, len(recent))
+		context := make([]float64, len(recent))
+		for i, e := range recent {
+			output[i], context[i] = e.OutputPerPrompt, e.ContextPerTurn
+		}
+		sections = append(sections, indent(efficiencyTrend("Output tokens per prompt", output))...)
+		sections = append(sections, indent(efficiencyTrend("Context tokens per turn", context))...)
+	}
+
+	// Busiest days
+	if days := busiestDays(s, top); len(days) > 0 {
+		sections = append(sections, "", heading.Render("Busiest days"))
+		sections = append(sections, indent(days)...)
+	}
+	return sections
+}
+
+// modelSplit renders a bar per model with its share of the tokens, most tokens first,
+// coloring the costs with thresholds for the days the stats cover
+func modelSplit(s *monitor.ProjectStats, costs config.CostConfig, thresholds config.Thresholds) []string {
+	width := 0
+	for _, u := range s.Models {
+		width = max(width, len(u.Model))
+	}
+	var lines []string
+	for _, u := range s.Models {
+		share := 0.0
+		if s.Tokens.Total() > 0 {
+			share = float64(u.Tokens.Total()) / float64(s.Tokens.Total())
+		}
+		line := fmt.Sprintf("%-*s  %s %3.0f%%  %s tokens  %d responses",
+			width, u.Model, shareBar(share), share*100, FormatTokenCount(u.Tokens.Total()), u.Responses)
+		if cost := Cost(costs, u.Cost, thresholds, "  ", TotalPrecision); cost != "" {
+			line += cost
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// shareBar renders a share between 0 and 1 as a bar half as wide as the progress bar
+func shareBar(share float64) string {
+	filled := int(share*projectBarWidth/2 + 0.5)
+	return strings.Repeat("█", filled) + strings.Repeat("░", projectBarWidth/2-filled)
+}
+
+// busiestDays lists the n days with the most messages, busiest first
+func busiestDays(s *monitor.ProjectStats, n int) []string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	var lines []string
+	for _, day := range s.BusiestDays(n) {
+		lines = append(lines, fmt.Sprintf("%s  %5d messages  %s",
+			day.Day.Format("2006-01-02 Mon"), day.Messages, dim.Render(plural(day.Sessions, "session"))))
+	}
+	return lines
+}
+
+// efficiencyTrend renders a labeled chart of one efficiency metric, oldest session first
+func efficiencyTrend(label string, values []float64) []string {
+	total, peak := 0.0, 0.0
+	for _, v := range values {
+		total += v
+		peak = max(peak, v)
+	}
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	lines := []string{fmt.Sprintf("%s  %s", label, dim.Render(fmt.Sprintf("avg %s · latest %s · max %s",
+		FormatTokenCount(int(total/float64(len(values)))), FormatTokenCount(int(values[len(values)-1])), FormatTokenCount(int(peak)))))}
+	chart := lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	for _, row := range Chart(values, efficiencyChartHeight) {
+		lines = append(lines, chart.Render(row))
+	}
+	return lines
+}
+
+// plural formats a count with a noun, e.g. "1 day" or "3 days"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// indent prefixes each line with two spaces
+func indent(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = "  " + line
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maskRune stands in for each letter and digit of masked text
+const maskRune = '•'
+
+// Redacted replaces text with its size, for previews and list cells shown while
+// redacting, e.g. "‹redacted: 3 lines, ~2KB›"; empty text stays empty
+func Redacted(text string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Count(strings.TrimRight(text, "\n"), "\n") + 1
+	if lines == 1 {
+		return fmt.Sprintf("‹redacted: %s›", FormatPasteSize(len(text)))
+	}
+	return fmt.Sprintf("‹redacted: %d lines, %s›", lines, FormatPasteSize(len(text)))
+}
+
+// Mask hides the words of text but keeps its shape: letters and digits become "•",
+// while spaces, line breaks and punctuation stay, so indentation, code blocks and
+// JSON structure remain recognizable
+func Mask(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return maskRune
+		}
+		return r
+	}, text)
+}
+
+// RedactPath keeps only the last element of a directory or file path, which names the
+// repository or session, e.g. "/Users/ann/clients/acme/api" becomes "…/api"
+func RedactPath(path string) string {
+	dir, base := filepath.Split(strings.TrimRight(path, "/"))
+	if dir == "" || base == "" {
+		return path
+	}
+	return "…/" + base
+}
+
+// MarkRedacted flags a rendered view as redacted with a badge at the end of its first
+// line, cutting the line short where the badge would not fit the width (0 if unknown)
+func MarkRedacted(view string, width int) string {
+	badge := lipgloss.NewStyle().
+		Bold(true).
+		Reverse(true).
+		Foreground(lipgloss.Color("3")).
+		Render(" REDACTED ")
+	first, rest, multiline := strings.Cut(view, "\n")
+	if width > 0 {
+		first = lipgloss.NewStyle().MaxWidth(max(width-lipgloss.Width(badge)-1, 0)).Render(first)
+	}
+	first += " " + badge
+	if multiline {
+		return first + "\n" + rest
+	}
+	return first
+}
@@ -0,0 +1,51 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TestRedaction tests what is left of text and paths while redacting
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"empty text", Redacted(""), ""},
+		{"one line", Redacted("deploy to acme-prod"), "‹redacted: <1KB›"},
+		{"several lines", Redacted("line one\nline two\n" + strings.Repeat("x", 2048) + "\n"), "‹redacted: 3 lines, ~2KB›"},
+		{"masked prose", Mask("Fix the login, then push."), "••• ••• •••••, •••• ••••."},
+		{"masked json", Mask(`{"file_path": "/srv/app.go", "limit": 20}`), `{"••••_••••": "/•••/•••.••", "•••••": ••}`},
+		{"masked code keeps indentation", Mask("func f() {\n\treturn 1\n}"), "•••• •() {\n\t•••••• •\n}"},
+		{"masked accents", Mask("café"), "••••"},
+		{"workdir", RedactPath("/Users/ann/clients/acme/api"), "…/api"},
+		{"trailing slash", RedactPath("/work/api/"), "…/api"},
+		{"session file", RedactPath("/home/ann/.claude/projects/-work-api/3f2a9c1e.jsonl"), "…/3f2a9c1e.jsonl"},
+		{"bare name", RedactPath("api"), "api"},
+		{"empty path", RedactPath(""), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+// TestMarkRedacted tests that the badge lands on the first line, within the width
+func TestMarkRedacted(t *testing.T) {
+	view := strings.Repeat("x", 80) + "\nsecond line"
+	got := MarkRedacted(view, 60)
+	first, rest, _ := strings.Cut(got, "\n")
+	if !strings.Contains(first, "REDACTED") || lipgloss.Width(first) > 60 {
+		t.Errorf("first line %q (%d columns), want the badge within 60 columns", first, lipgloss.Width(first))
+	}
+	if rest != "second line" {
+		t.Errorf("rest of the view = %q, want it unchanged", rest)
+	}
+	if got := MarkRedacted("title", 0); !strings.HasPrefix(got, "title ") || strings.Contains(got, "\n") {
+		t.Errorf("one-line view = %q, want the badge after the title", got)
+	}
+}
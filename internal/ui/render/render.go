@@ -0,0 +1,194 @@
+// Package render draws promptwatch's views from plain data structs. Renderers never see
+// the UI model, so each view can be rendered and checked in isolation.
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// Cost formats a cost after label with FormatCost and colors it against the given
+// thresholds, marking the currency of costs over a threshold in themes with glyphs,
+// e.g. "Σ $!0.05"
+// Returns "" when cost display is disabled in the config
+func Cost(costs config.CostConfig, cost float64, thresholds config.Thresholds, label string, precision CostPrecision) string {
+	if costs.Hidden {
+		return ""
+	}
+	level := thresholds.Level(cost)
+	text := FormatCost(cost, precision)
+	if glyph := levelGlyph(level); glyph != "" {
+		text = strings.Replace(text, costFormat.Currency, costFormat.Currency+glyph, 1)
+	}
+	text = label + text
+	return lipgloss.NewStyle().
+		Foreground(levelColor(level)).
+		Render(text)
+}
+
+// Footer renders a help line in the dimmed footer style
+func Footer(text string) string {
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(text)
+}
+
+// Context usage above these fractions of the window is shown at the warning and high levels
+const (
+	contextWarnUsage = 0.80
+	contextHighUsage = 0.95
+)
+
+// contextLevel returns the level of a context window usage fraction
+func contextLevel(usage float64) config.Level {
+	switch {
+	case usage >= contextHighUsage:
+		return config.LevelHigh // Should have compacted
+	case usage >= contextWarnUsage:
+		return config.LevelWarn // Getting close
+	default:
+		return config.LevelLow
+	}
+}
+
+// contextColor returns the color for a context window usage fraction
+func contextColor(usage float64) lipgloss.Color {
+	return levelColor(contextLevel(usage))
+}
+
+// ContextGauge renders a small bar showing context window usage, e.g. "ctx:▰▰▰▱▱62%",
+// followed by the level's glyph in themes with glyphs, e.g. "ctx:▰▰▰▰▱82%!"
+func ContextGauge(usage float64) string {
+	const cells = 5
+	filled := int(usage*cells + 0.5)
+	if filled > cells {
+		filled = cells
+	}
+	gauge := strings.Repeat("▰", filled) + strings.Repeat("▱", cells-filled)
+	return lipgloss.NewStyle().
+		Foreground(contextColor(usage)).
+		Render(fmt.Sprintf("ctx:%s%.0f%%%s", gauge, usage*100, levelGlyph(contextLevel(usage))))
+}
+
+// ContextHeader heads the columns showing ContextCell
+const ContextHeader = "CTX"
+
+// ContextWidth is the width of the columns showing ContextCell, e.g. "100%!!"
+const ContextWidth = 8
+
+// ContextCell shows the context window usage of a session's last response in a table
+// column, e.g. "82%!", colored at the levels of ContextGauge; "-" when it is unknown
+func ContextCell(usage float64) table.StyledCell {
+	if usage <= 0 {
+		return table.NewStyledCell("-", lipgloss.NewStyle().Foreground(lipgloss.Color("8")))
+	}
+	return table.NewStyledCell(
+		fmt.Sprintf("%.0f%%%s", usage*100, levelGlyph(contextLevel(usage))),
+		lipgloss.NewStyle().Foreground(contextColor(usage)))
+}
+
+// ContextGrowth renders how much the context grew since the previous response, e.g.
+// "+3.2k ctx", flagged in orange when large. It returns "" when the context is unchanged.
+func ContextGrowth(tokens int, large bool) string {
+	switch {
+	case tokens == 0:
+		return ""
+	case tokens < 0:
+		return lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render("-" + FormatTokenCount(-tokens) + " ctx")
+	case large:
+		return lipgloss.NewStyle().
+			Foreground(lipgloss.Color("208")).
+			Bold(true).
+			Render("⚠ +" + FormatTokenCount(tokens) + " ctx")
+	default:
+		return lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render("+" + FormatTokenCount(tokens) + " ctx")
+	}
+}
+
+// sparkBlocks are the sparkline levels from empty to full
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// ContextSparkline plots context usage of each assistant turn across the session in at most
+// width characters, followed by the latest usage. When there are more turns than characters,
+// each character shows the highest usage among the turns it covers.
+func ContextSparkline(history []monitor.Message, width int) string {
+	var usages []float64
+	for _, msg := range history {
+		if u := msg.ContextUsage(); u > 0 {
+			usages = append(usages, u)
+		}
+	}
+	if len(usages) == 0 || width <= 0 {
+		return ""
+	}
+
+	buckets := len(usages)
+	if buckets > width {
+		buckets = width
+	}
+	var spark strings.Builder
+	for b := 0; b < buckets; b++ {
+		start := b * len(usages) / buckets
+		end := (b + 1) * len(usages) / buckets
+		peak := 0.0
+		for _, u := range usages[start:end] {
+			if u > peak {
+				peak = u
+			}
+		}
+		level := int(peak*float64(len(sparkBlocks)-1) + 0.5)
+		if level >= len(sparkBlocks) {
+			level = len(sparkBlocks) - 1
+		}
+		spark.WriteRune(sparkBlocks[level])
+	}
+
+	latest := usages[len(usages)-1]
+	return lipgloss.NewStyle().
+		Foreground(contextColor(latest)).
+		Render(fmt.Sprintf("%s %.0f%%", spark.String(), latest*100))
+}
+
+// TokenBreakdown formats token counts as their total, labeled with what it includes,
+// followed by each kind, e.g. "1.1M in+cache write+out (in 210k, cache write 840k,
+// out 96k; cache read 31.0M)"
+func TokenBreakdown(t monitor.TokenCounts) string {
+	return fmt.Sprintf("%s %s (in %s, cache write %s, out %s; cache read %s)",
+		FormatTokenCount(t.Total()), monitor.TotalLabel, FormatTokenCount(t.Input),
+		FormatTokenCount(t.CacheWrite), FormatTokenCount(t.Output), FormatTokenCount(t.CacheRead))
+}
+
+// shortModel returns the first dash-separated part of a model ID ("claude" for "claude-opus-4-5")
+func shortModel(model string) string {
+	return strings.Split(model, "-")[0]
+}
+
+// wrapWords word-wraps text to lines of at most width characters. Words longer than
+// width get a line of their own.
+func wrapWords(text string, width int) []string {
+	var lines []string
+	var currentLine string
+	for _, word := range strings.Fields(text) {
+		if currentLine == "" {
+			currentLine = word
+		} else if len(currentLine)+1+len(word) <= width {
+			currentLine += " " + word
+		} else {
+			lines = append(lines, currentLine)
+			currentLine = word
+		}
+	}
+	if currentLine != "" {
+		lines = append(lines, currentLine)
+	}
+	return lines
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// TestContextSparkline tests downsampling and scaling of the context usage trend
+func TestContextSparkline(t *testing.T) {
+	var history []monitor.Message
+	for i := 1; i <= 10; i++ {
+		history = append(history,
+			monitor.Message{Role: "user"},
+			monitor.Message{Role: "assistant", Model: "claude-opus-4-5", CacheRead: i * 20_000})
+	}
+
+	spark := ContextSparkline(history, 5)
+	// Strip styling so only the plotted characters remain
+	plain := lipgloss.NewStyle().UnsetForeground().Render(spark)
+	if !strings.HasPrefix(plain, "▂▄▅▇█") {
+		t.Errorf("sparkline = %q, want peaks of each pair of turns", plain)
+	}
+	if !strings.HasSuffix(plain, " 100%") {
+		t.Errorf("sparkline = %q, want latest usage 100%%", plain)
+	}
+
+	if got := ContextSparkline([]monitor.Message{{Role: "user"}}, 5); got != "" {
+		t.Errorf("sparkline without usage = %q, want empty", got)
+	}
+}
+
+func TestContextGrowth(t *testing.T) {
+	tests := []struct {
+		tokens int
+		large  bool
+		want   string
+	}{
+		{0, false, ""},
+		{3_200, false, "+3.2k ctx"},
+		{48_000, true, "⚠ +48k ctx"},
+		{-150_000, false, "-150k ctx"},
+	}
+	for _, tt := range tests {
+		if got := ContextGrowth(tt.tokens, tt.large); got != tt.want {
+			t.Errorf("ContextGrowth(%d, %v) = %q, want %q", tt.tokens, tt.large, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTokenCount(t *testing.T) {
+	tests := []struct {
+		tokens int
+		want   string
+	}{
+		{850, "850"},
+		{1234, "1.2k"},
+		{18_400, "18k"},
+		{999_499, "999k"},
+		{999_500, "1.0M"},
+		{31_400_000, "31.4M"},
+	}
+	for _, tt := range tests {
+		if got := FormatTokenCount(tt.tokens); got != tt.want {
+			t.Errorf("FormatTokenCount(%d) = %q, want %q", tt.tokens, got, tt.want)
+		}
+	}
+}
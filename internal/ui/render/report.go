@@ -0,0 +1,157 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// ReportData is everything the reports view shows
+type ReportData struct {
+	Ranges   []string        // Labels of the ranges to choose from, e.g. "Last 7 days"
+	Selected int             // Index into Ranges of the range shown
+	Note     string          // Transient notice, e.g. where the report was exported to
+	Report   *monitor.Report // nil while the first scan of the range is running
+
+	Loading  bool   // Session files are still being scanned
+	Spinner  string // Rendered spinner frame shown while loading
+	Done     int    // Session files scanned so far
+	Total    int    // Session files to scan
+	Error    string // Why the range could not be scanned
+	Help     string // Rendered help bar
+	TopTools int    // How many busy days to list
+}
+
+// reportNameWidth caps the width of project names in the per-project bars
+const reportNameWidth = 40
+
+// Report renders the reports dashboard across all projects: the range selector,
+// totals, per-project bars, per-model split and busiest days
+func Report(d ReportData, costs config.CostConfig) string {
+	components := []string{lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("Report: all projects"), rangeSelector(d.Ranges, d.Selected)}
+	if d.Note != "" {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("10")).
+			Render(d.Note))
+	}
+
+	switch {
+	case d.Error != "":
+		components = append(components, "", lipgloss.NewStyle().
+			Foreground(lipgloss.Color("1")).
+			Render("Error: "+d.Error))
+	case d.Report == nil:
+		components = append(components, "", scanProgress(d.Spinner, d.Done, d.Total))
+	default:
+		if d.Loading {
+			components = append(components, scanProgress(d.Spinner, d.Done, d.Total))
+		}
+		components = append(components, reportSections(d, costs)...)
+	}
+
+	if d.Help != "" {
+		components = append(components, "", d.Help)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, components...)
+}
+
+// rangeSelector renders the ranges to choose from with the selected one highlighted
+func rangeSelector(ranges []string, selected int) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	parts := make([]string, len(ranges))
+	for i, label := range ranges {
+		if i == selected {
+			parts[i] = lipgloss.NewStyle().Reverse(true).Render(" " + label + " ")
+		} else {
+			parts[i] = dim.Render(" " + label + " ")
+		}
+	}
+	return "Range: " + strings.Join(parts, " ")
+}
+
+// reportSections renders the dashboard sections of a scanned range
+func reportSections(d ReportData, costs config.CostConfig) []string {
+	r := d.Report
+	s := &r.Total
+	thresholds := costs.Days(r.Range.Days())
+	heading := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	top := d.TopTools
+	if top <= 0 {
+		top = 5
+	}
+
+	// Totals
+	sessions := fmt.Sprintf("%d", s.Sessions)
+	var extra []string
+	if s.Agents > 0 {
+		extra = append(extra, fmt.Sprintf("+%d agents", s.Agents))
+	}
+	if s.Failed > 0 {
+		extra = append(extra, fmt.Sprintf("%d unreadable", s.Failed))
+	}
+	if s.Bypass > 0 {
+		extra = append(extra, lipgloss.NewStyle().
+			Foreground(theme.Alert).
+			Render(fmt.Sprintf("%d in bypass mode", s.Bypass)))
+	}
+	if len(extra) > 0 {
+		sessions += " (" + strings.Join(extra, ", ") + ")"
+	}
+	totals := []string{
+		fmt.Sprintf("Days:        %s (%s, %d active)", r.Range, plural(r.Range.Days(), "day"), len(s.Days)),
+		fmt.Sprintf("Projects:    %d", len(r.Projects)),
+		"Sessions:    " + sessions,
+		"Tokens:      " + TokenBreakdown(s.Tokens),
+	}
+	if cost := Cost(costs, s.Cost, thresholds, "", TotalPrecision); cost != "" {
+		totals = append(totals, "Cost:        "+cost)
+	}
+	sections := []string{"", heading.Render("Totals")}
+	sections = append(sections, indent(totals)...)
+	if len(r.Projects) == 0 {
+		return append(sections, "", lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render("No sessions in this range"))
+	}
+
+	// Per-project bars
+	sections = append(sections, "", heading.Render("Projects")+" (tokens: "+monitor.TotalLabel+")")
+	width := 0
+	for _, p := range r.Projects {
+		width = max(width, min(len([]rune(p.Name)), reportNameWidth))
+	}
+	var lines []string
+	for _, p := range r.Projects {
+		share := 0.0
+		if s.Tokens.Total() > 0 {
+			share = float64(p.Tokens.Total()) / float64(s.Tokens.Total())
+		}
+		line := fmt.Sprintf("%-*s  %s %3.0f%%  %s tokens  %s",
+			width, truncateTail(p.Name, reportNameWidth), shareBar(share), share*100,
+			FormatTokenCount(p.Tokens.Total()), plural(p.Sessions, "session"))
+		if cost := Cost(costs, p.Cost, thresholds, "  ", TotalPrecision); cost != "" {
+			line += cost
+		}
+		lines = append(lines, line)
+	}
+	sections = append(sections, indent(lines)...)
+
+	// Per-model split
+	if len(s.Models) > 0 {
+		sections = append(sections, "", heading.Render("Models")+" (tokens: "+monitor.TotalLabel+")")
+		sections = append(sections, indent(modelSplit(s, costs, thresholds))...)
+	}
+
+	// Busiest days
+	if days := busiestDays(s, top); len(days) > 0 {
+		sections = append(sections, "", heading.Render("Busiest days"))
+		sections = append(sections, indent(days)...)
+	}
+	return sections
+}
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Scrollbar renders a one-column scrollbar of height lines for a viewport showing
+// lines offset..offset+height of total content lines. It returns "" when everything fits.
+func Scrollbar(height, total, offset int) string {
+	if height <= 0 || total <= height {
+		return ""
+	}
+	thumb := max(1, height*height/total)
+	maxOffset := total - height
+	offset = min(max(offset, 0), maxOffset)
+	// Round so the thumb only touches the ends when the viewport does
+	top := (offset*(height-thumb) + maxOffset/2) / maxOffset
+	if offset > 0 && top == 0 {
+		top = 1
+	} else if offset < maxOffset && top == height-thumb {
+		top = height - thumb - 1
+	}
+	top = max(top, 0)
+
+	track := lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	bar := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	lines := make([]string, height)
+	for i := range lines {
+		if i >= top && i < top+thumb {
+			lines[i] = bar.Render("┃")
+		} else {
+			lines[i] = track.Render("│")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ScrollPosition renders where the selection is within a list, e.g. "message 37/214 — 12%";
+// percent (0–1) is how far the viewport is scrolled
+func ScrollPosition(label string, pos, total int, percent float64) string {
+	if total <= 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(fmt.Sprintf("%s %d/%d — %.0f%%", label, pos, total, percent*100))
+}
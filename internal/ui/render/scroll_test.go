@@ -0,0 +1,42 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScrollbar(t *testing.T) {
+	tests := []struct {
+		name   string
+		height int
+		total  int
+		offset int
+		want   string
+	}{
+		{"fits", 4, 4, 0, ""},
+		{"no height", 0, 10, 0, ""},
+		{"top", 4, 16, 0, "┃│││"},
+		{"bottom", 4, 16, 12, "│││┃"},
+		{"scrolled a little is off the top", 4, 16, 1, "│┃││"},
+		{"almost at the bottom is off the bottom", 4, 16, 11, "││┃│"},
+		{"middle", 4, 8, 2, "│┃┃│"},
+		{"offset past the end", 4, 8, 20, "││┃┃"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.ReplaceAll(Scrollbar(tt.height, tt.total, tt.offset), "\n", "")
+			if got != tt.want {
+				t.Errorf("Scrollbar(%d, %d, %d) = %q, want %q", tt.height, tt.total, tt.offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollPosition(t *testing.T) {
+	if got := ScrollPosition("message", 37, 214, 0.124); got != "message 37/214 — 12%" {
+		t.Errorf("ScrollPosition = %q", got)
+	}
+	if got := ScrollPosition("message", 0, 0, 0); got != "" {
+		t.Errorf("ScrollPosition with nothing to show = %q, want empty", got)
+	}
+}
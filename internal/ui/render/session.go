@@ -0,0 +1,378 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+// SessionHeaderData is everything the session detail header shows
+type SessionHeaderData struct {
+	Path string
+
+	// Session ID from the file name, which claude --resume looks up, and the one recorded
+	// in the entries when it differs, as in files copied by hand; ResumeDir is the working
+	// directory to resume in. Subagent files cannot be resumed.
+	ID         string
+	RecordedID string
+	ResumeDir  string
+
+	// From the session list row; zero values are left out
+	Version       string
+	GitBranch     string
+	IsSidechain   bool
+	IsAgent       bool
+	Tokens        monitor.TokenCounts
+	UserPrompts   int
+	Interruptions int
+	FirstPrompt   string
+	Title         string               // Claude's summary of the conversation, from its latest summary entry
+	Compressed    string               // Sizes of a gzip-compressed session file, e.g. "1.2 MB→4.8 MB"
+	Modes         []string             // Permission modes the session ran in, in order of first use
+	Branches      []string             // Git branches the session ran on, one entry per switch
+	OutsideWrites []string             // Files written outside the working directory (SessionStats.OutsideWrites)
+	Pastes        int                  // Prompts with pasted content (SessionStats.Pastes)
+	PastedBytes   int                  // Bytes pasted into them
+	Languages     []monitor.EntryCount // Code by language (SessionStats.Languages)
+	DiffStat      monitor.DiffStat     // Lines tool calls changed, approximately (SessionStats.DiffStat)
+	Throughput    float64              // Average output tokens per second (SessionStats.TokensPerSecond); 0 if unknown
+	Rewarms       int                  // Responses rewriting the prompt cache after gaps (monitor.CacheRewarms)
+	RewarmCost    float64              // What the rewrites cost over reading the cache
+
+	Duration time.Duration // Time from the first to the last entry
+	Messages int           // Messages in the session file
+
+	Summary       string  // SessionStats.GetSummary()
+	DetailedStats string  // SessionStats.GetDetailedStats()
+	Cost          float64 // Estimated cost of the whole session
+	Partial       bool    // Only part of the history is loaded
+	OutOfOrder    int     // Entries timestamped before earlier ones (SessionStats.OutOfOrder)
+	Loading       bool    // Older history is still loading
+	Spinner       string  // Rendered spinner frame shown while loading
+	Progress      float64 // Load progress (0–1)
+
+	// Live sessions: how long Claude has yet to answer the latest prompt or tool result,
+	// and how long it typically takes in this session; zero when unknown
+	Waiting     time.Duration
+	TypicalWait time.Duration
+
+	History           []monitor.Message    // Messages plotted in the context sparkline
+	Composition       []monitor.EntryCount // Entries of the session file by kind
+	Width             int                  // Terminal width the composition bar is fitted to
+	Turns             monitor.TurnStats
+	MostExpensiveTurn int // Turn.Index of the most expensive turn
+
+	// Minimap of the turns (SessionStats.Activities) and the index of the turn the
+	// view is on, -1 for none
+	Activities  []monitor.Activity
+	CurrentTurn int
+}
+
+// SessionHeader renders the top of the session detail view: title, path, metadata,
+// first prompt and the summary, detail and per-turn statistics lines
+func SessionHeader(d SessionHeaderData, costs config.CostConfig) string {
+	headerTitle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("Session Details")
+
+	if d.ID != "" {
+		headerTitle += "  " + lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("14")).
+			Render("ID: "+d.ID)
+	}
+
+	pathText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(fmt.Sprintf("Path: %s", monitor.TruncatePath(d.Path, 60)))
+
+	// Session metadata line (version, git, tokens, etc.)
+	var metadataItems []string
+	if d.Version != "" {
+		metadataItems = append(metadataItems, "v:"+d.Version)
+	}
+	if len(d.Branches) > 1 {
+		metadataItems = append(metadataItems, "branches: "+strings.Join(d.Branches, " → "))
+	} else if d.GitBranch != "" {
+		metadataItems = append(metadataItems, "branch:"+d.GitBranch)
+	}
+	if d.IsSidechain {
+		metadataItems = append(metadataItems, "🔀side-chain")
+	}
+	if d.Compressed != "" {
+		metadataItems = append(metadataItems, "gzip:"+d.Compressed)
+	}
+	if len(d.Modes) > 0 {
+		metadataItems = append(metadataItems, "mode:"+strings.Join(d.Modes, "→"))
+	}
+	if d.UserPrompts > 0 {
+		metadataItems = append(metadataItems, fmt.Sprintf("prompts:%d", d.UserPrompts))
+	}
+	if d.Interruptions > 0 {
+		metadataItems = append(metadataItems, fmt.Sprintf("resumptions:%d", d.Interruptions))
+	}
+
+	components := []string{headerTitle}
+	if d.RecordedID != "" {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("3")).
+			Render(fmt.Sprintf("⚠ the entries name session %s: the file was copied or renamed; claude --resume goes by the file name", d.RecordedID)))
+	}
+	components = append(components, pathText)
+	if d.ID != "" && !d.IsAgent {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render("Resume: "+resumeCommand(d.ResumeDir, d.ID)+"  (y: copy ID)"))
+	}
+	if len(metadataItems) > 0 {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(strings.Join(metadataItems, "  |  ")))
+	}
+	if d.Tokens.Total() > 0 {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render("Tokens: "+TokenBreakdown(d.Tokens)))
+	}
+
+	// Conversation summary, in full
+	if d.Title != "" {
+		components = append(components, lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("14")).
+			Render("Summary: "+d.Title))
+	}
+
+	// First prompt preview
+	if d.FirstPrompt != "" {
+		prompt := truncateHead(d.FirstPrompt, 80)
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("11")).
+			Render("Initial: "+prompt))
+	}
+
+	// Stats section
+	summary := d.Summary
+	if cost := Cost(costs, d.Cost, costs.Session, "  ", TotalPrecision); cost != "" {
+		summary += cost
+	}
+	if d.Partial {
+		summary += " (partial)"
+		if d.Loading {
+			summary += fmt.Sprintf("  %s loading older history… %.0f%%", d.Spinner, d.Progress*100)
+		}
+	}
+	if d.Waiting > 0 {
+		summary += "  " + waitingText(d)
+	}
+	statsText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("10")).
+		Render(summary)
+
+	// Detailed stats
+	detailedStats := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(d.DetailedStats)
+	if spark := ContextSparkline(d.History, 40); spark != "" {
+		detailedStats += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("  |  context ") + spark
+	}
+	if langs := LanguageShares(d.Languages); langs != "" {
+		detailedStats += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("  |  code: " + langs)
+	}
+	if !d.DiffStat.IsZero() {
+		detailedStats += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("  |  changed ≈ " + DiffStatText(d.DiffStat))
+	}
+	if d.Tokens.Output > 0 {
+		detailedStats += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(
+			"  |  avg " + FormatThroughput(d.Throughput, d.Throughput > 0))
+	}
+	if d.Pastes > 0 {
+		detailedStats += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(
+			fmt.Sprintf("  |  📋 %s pasted in %s", FormatPasteSize(d.PastedBytes), plural(d.Pastes, "prompt")))
+	}
+	if d.Rewarms > 0 {
+		detailedStats += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("  |  " + RewarmText(d.Rewarms, d.RewarmCost, costs))
+	}
+
+	components = append(components, "", statsText, detailedStats)
+	if d.OutOfOrder > 0 {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("3")).
+			Render(fmt.Sprintf("⚠ %d entries out of order, as in resumed or compacted sessions; messages sorted by time", d.OutOfOrder)))
+	}
+	if len(d.OutsideWrites) > 0 {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(theme.Alert).
+			Render("⚠ wrote outside workdir: "+outsideWritesList(d.OutsideWrites)))
+	}
+	if composition := Composition(d.Composition, d.Width); composition != "" {
+		components = append(components, composition)
+	}
+
+	// Per-turn stats
+	if d.Turns.Turns > 0 {
+		components = append(components, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(formatTurnStats(d.Turns, d.MostExpensiveTurn, costs)))
+	}
+	if len(d.Activities) > 0 {
+		label := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Map ")
+		hint := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("  <n>%: jump")
+		width := 0
+		if d.Width > 0 {
+			width = max(d.Width-lipgloss.Width(label)-lipgloss.Width(hint), 1)
+		}
+		components = append(components, label+Minimap(d.Activities, d.CurrentTurn, width)+hint)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, components...)
+}
+
+// resumeCommand returns the shell command resuming session id in dir, e.g.
+// "cd /home/demo/api && claude --resume 3f2a9c1e-…"; without a dir only the claude part
+func resumeCommand(dir, id string) string {
+	command := "claude --resume " + shellQuote(id)
+	if dir == "" {
+		return command
+	}
+	return "cd " + shellQuote(dir) + " && " + command
+}
+
+// shellQuote returns s as a single shell word, quoting it only when it needs to be
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@%+=,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CompactSessionHeader renders the session detail header collapsed to a single line:
+// path tail, duration, message count, cost and load state
+func CompactSessionHeader(d SessionHeaderData, costs config.CostConfig) string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("Session")
+
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	parts := []string{
+		dim.Render("…" + string(filepath.Separator) + filepath.Base(d.Path)),
+		dim.Render(d.Duration.Round(time.Second).String()),
+		dim.Render(fmt.Sprintf("%d messages", d.Messages)),
+	}
+	if cost := Cost(costs, d.Cost, costs.Session, "", TotalPrecision); cost != "" {
+		parts = append(parts, cost)
+	}
+	if d.Partial {
+		if d.Loading {
+			parts = append(parts, dim.Render(fmt.Sprintf("%s loading %.0f%%", d.Spinner, d.Progress*100)))
+		} else {
+			parts = append(parts, dim.Render("partial"))
+		}
+	}
+	if d.Waiting > 0 {
+		parts = append(parts, lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(waitingText(d)))
+	}
+	return title + " " + strings.Join(parts, dim.Render(" | "))
+}
+
+// RewarmText describes what gaps cost in cache rewrites, e.g. "interruptions cost ≈
+// $1.20 in cache rewrites (3 gaps)"; with costs hidden only the gaps are counted
+func RewarmText(rewarms int, cost float64, costs config.CostConfig) string {
+	if costs.Hidden {
+		return fmt.Sprintf("cache rewritten after %s", plural(rewarms, "gap"))
+	}
+	return fmt.Sprintf("interruptions cost ≈ %s in cache rewrites (%s)", FormatCost(cost, TotalPrecision), plural(rewarms, "gap"))
+}
+
+// DiffStatText summarizes the lines tool calls changed, e.g. "7 files, +412 −96". The
+// counts are approximate (see monitor.DiffStat), which callers should say.
+func DiffStatText(d monitor.DiffStat) string {
+	return fmt.Sprintf("%s, %s", plural(d.Files, "file"), DiffStatLines(d))
+}
+
+// DiffStatLines formats the lines added and removed, e.g. "+412 −96"
+func DiffStatLines(d monitor.DiffStat) string {
+	return fmt.Sprintf("+%d −%d", d.Added, d.Removed)
+}
+
+// waitingText renders the wait ticker, e.g. "⏳ waiting for Claude… 23s (typically ~40s)"
+func waitingText(d SessionHeaderData) string {
+	text := "⏳ waiting for Claude… " + d.Waiting.Round(time.Second).String()
+	if d.TypicalWait > 0 {
+		text += " (typically ~" + d.TypicalWait.Round(time.Second).String() + ")"
+	}
+	return text
+}
+
+// formatTurnStats renders the per-turn summary line, e.g.
+// "Turns: 12 | per turn: avg $0.41, median $0.22, 2.5 tools, 38s | most expensive: #7 $1.90 ($: jump)"
+func formatTurnStats(ts monitor.TurnStats, mostExpensive int, costs config.CostConfig) string {
+	perTurn := []string{}
+	if !costs.Hidden {
+		perTurn = append(perTurn, "avg "+FormatCost(ts.AvgCost, TotalPrecision), "median "+FormatCost(ts.MedianCost, TotalPrecision))
+	}
+	perTurn = append(perTurn,
+		fmt.Sprintf("%.1f tools", ts.AvgToolCalls),
+		fmt.Sprintf("%s (median %s)", ts.AvgDuration.Round(time.Second), ts.MedianDuration.Round(time.Second)),
+	)
+
+	parts := []string{
+		fmt.Sprintf("Turns: %d", ts.Turns),
+		"per turn: " + strings.Join(perTurn, ", "),
+	}
+	if ts.MostExpensive >= 0 && !costs.Hidden {
+		parts = append(parts, fmt.Sprintf("most expensive: #%d %s ($: jump)", mostExpensive, FormatCost(ts.MaxCost, TotalPrecision)))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// SessionLoading displays a spinner and parse progress while a session file loads
+func SessionLoading(path, spinner string, progress float64, help string) string {
+	headerTitle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("Session Details")
+
+	pathText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Render(fmt.Sprintf("Path: %s", monitor.TruncatePath(path, 60)))
+
+	progressText := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("10")).
+		Render(fmt.Sprintf("%s Loading session… %.0f%%", spinner, progress*100))
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		headerTitle,
+		pathText,
+		"",
+		progressText,
+		"",
+		help,
+	)
+}
+
+// maxOutsideWrites is how many files written outside the working directory the
+// session header names
+const maxOutsideWrites = 3
+
+// outsideWritesList names the first files written outside the working directory,
+// e.g. "~/.bashrc, /etc/hosts +2 more"
+func outsideWritesList(paths []string) string {
+	var names []string
+	for _, p := range paths[:min(len(paths), maxOutsideWrites)] {
+		names = append(names, monitor.ShortenHomePath(p))
+	}
+	list := strings.Join(names, ", ")
+	if more := len(paths) - len(names); more > 0 {
+		list += fmt.Sprintf(" +%d more", more)
+	}
+	return list
+}
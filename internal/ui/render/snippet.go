@@ -0,0 +1,183 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ansiEscape matches terminal escape sequences, e.g. colors in command output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+// listMarker matches the markdown syntax and bullets that open a line: headings,
+// quotes, list items, checkboxes and Claude's "⏺" status bullets
+var listMarker = regexp.MustCompile(`^(#{1,6}\s+|>\s*|[⏺●•·∙◦▪‣]\s*|[-*+]\s+(\[[ xX]\]\s+)?|\d{1,3}[.)]\s+)`)
+
+// sentenceEnd matches the end of a sentence followed by more text
+var sentenceEnd = regexp.MustCompile(`[.!?。](\s)`)
+
+// leadIn matches sentences that only acknowledge the request, such as "Sure!", "Good
+// catch." or "Sure, I'll help you with that.", which say nothing about the answer
+var leadIn = regexp.MustCompile(`(?i)^(` + interjection + `[.!]$|(` + interjection + `[,!]? )?(good (question|catch|idea|point)|you['’]re (absolutely |completely )?right|(i['’]ll|i will|i can|let me) help|i['’]d be (happy|glad) to help|happy to help|(i['’]ll|let me) (take a look|have a look|look into (this|that|it)\b)))`)
+
+// interjection is the one-word acknowledgements leadIn knows
+const interjection = `(sure|certainly|of course|absolutely|great|perfect|excellent|okay|ok|alright|got it|understood|thanks|thank you)`
+
+// maxLeadIn is the longest sentence, in characters, taken for a lead-in
+const maxLeadIn = 80
+
+// Snippet picks the single line of a message's content that its card shows. mode is
+// one of config.PreviewModes: "first" shows the text from its start, "last" shows its
+// end, and "smart" strips markdown syntax and control characters and shows whole
+// sentences from the first one on, or for tool output (toolResult) the last lines,
+// which usually hold the outcome. Sentences that merely acknowledge the request, such
+// as "Sure, I'll help you with that.", are skipped. The line is at most width
+// characters long.
+func Snippet(text, mode string, toolResult bool, width int) string {
+	width = max(width, 2)
+	switch mode {
+	case "first":
+		return truncateHead(collapse(stripControl(text)), width)
+	case "last":
+		return truncateTail(collapse(stripControl(text)), width)
+	}
+
+	var lines, headings []string
+	inFence := false
+	for _, line := range strings.Split(stripControl(text), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence && isRule(line) {
+			continue
+		}
+		heading := strings.HasPrefix(line, "#")
+		if !inFence {
+			line = stripMarkdown(line)
+		}
+		if line = collapse(line); line == "" {
+			continue
+		}
+		if heading && !toolResult {
+			// A heading names what follows; the text below it says more
+			headings = append(headings, line)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = headings
+	}
+	if len(lines) == 0 {
+		return truncateHead(collapse(stripControl(text)), width)
+	}
+
+	if toolResult {
+		// As many of the last lines as fit, marked when earlier ones are left out
+		n, used := 0, 0
+		for i := len(lines) - 1; i >= 0; i-- {
+			w := utf8.RuneCountInString(lines[i]) + 1
+			if n > 0 && used+w > width-2 {
+				break
+			}
+			n, used = n+1, used+w
+		}
+		tail := strings.Join(lines[len(lines)-n:], " ")
+		if n < len(lines) {
+			return truncateTail("… "+tail, width)
+		}
+		return truncateTail(tail, width)
+	}
+	return wholeSentences(skipLeadIn(strings.Join(lines, " ")), width)
+}
+
+// skipLeadIn drops the sentences opening text that only acknowledge the request, as long
+// as a sentence follows them
+func skipLeadIn(text string) string {
+	for {
+		loc := sentenceEnd.FindStringSubmatchIndex(text)
+		if loc == nil || utf8.RuneCountInString(text[:loc[2]]) > maxLeadIn || !leadIn.MatchString(text[:loc[2]]) {
+			return text
+		}
+		text = strings.TrimLeft(text[loc[3]:], " ")
+	}
+}
+
+// stripControl removes terminal escape sequences and control characters, keeping
+// line breaks and turning tabs into spaces
+func stripControl(text string) string {
+	text = ansiEscape.ReplaceAllString(text, "")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r), r == '\ufeff', r == '\u200b':
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// stripMarkdown removes the markdown syntax opening a line, e.g. "## " or "- [x] ",
+// and the emphasis and code markers within it
+func stripMarkdown(line string) string {
+	for {
+		loc := listMarker.FindStringIndex(line)
+		if loc == nil || loc[1] == 0 {
+			break
+		}
+		line = line[loc[1]:]
+	}
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+}
+
+// isRule reports whether a line is a markdown horizontal rule such as "---"
+func isRule(line string) bool {
+	return len(line) >= 3 && strings.Trim(line, "-*_= ") == ""
+}
+
+// wholeSentences cuts text that is too long for a card after its last sentence that
+// fits, so the line ends with the first sentence rather than in the middle of another
+func wholeSentences(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	cut := 0
+	for _, loc := range sentenceEnd.FindAllStringSubmatchIndex(text, -1) {
+		if utf8.RuneCountInString(text[:loc[2]]) > width {
+			break
+		}
+		cut = loc[2]
+	}
+	if cut == 0 {
+		return truncateHead(text, width)
+	}
+	return text[:cut]
+}
+
+// collapse replaces each run of whitespace with a single space
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// truncateHead cuts text to width characters, keeping its start
+func truncateHead(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	return string([]rune(text)[:width-1]) + "…"
+}
+
+// truncateTail cuts text to width characters, keeping its end
+func truncateTail(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return "…" + strings.TrimLeft(string(runes[len(runes)-width+1:]), "… ")
+}
@@ -0,0 +1,166 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// snippetWidth is the content line width TestSnippet's expectations are written for
+const snippetWidth = 150
+
+// TestSnippet tests the card content line against content as it appears in sessions
+func TestSnippet(t *testing.T) {
+	longOutput := strings.Repeat("=== RUN   TestOrders\n--- PASS: TestOrders (0.00s)\n", 20) + "PASS\nok  \tgithub.com/acme/api/orders\t0.412s"
+	longProse := strings.Repeat("The handler now validates the order ID before it queries the store. ", 3)
+
+	tests := []struct {
+		name       string
+		text       string
+		mode       string
+		toolResult bool
+		want       string
+	}{
+		{
+			name: "leading blank lines and status bullet",
+			text: "\n\n\n⏺ Both call sites now check the slice length.",
+			mode: "smart",
+			want: "Both call sites now check the slice length.",
+		},
+		{
+			name: "heading gives way to the text below it",
+			text: "## Summary\n\nAll 12 tests pass. The flaky test was a race in the cache.",
+			mode: "smart",
+			want: "All 12 tests pass. The flaky test was a race in the cache.",
+		},
+		{
+			name: "heading alone",
+			text: "# Plan",
+			mode: "smart",
+			want: "Plan",
+		},
+		{
+			name: "list markers, checkboxes and emphasis",
+			text: "- [x] **Fix** the `nil` map\n- [ ] Add a regression test\n1. Ship it",
+			mode: "smart",
+			want: "Fix the nil map Add a regression test Ship it",
+		},
+		{
+			name: "quote and horizontal rule",
+			text: "> Note\n---\nThe migration is reversible.",
+			mode: "smart",
+			want: "Note The migration is reversible.",
+		},
+		{
+			name: "code fence markers are dropped, code is kept",
+			text: "```go\nfmt.Println(\"hi\")\n```",
+			mode: "smart",
+			want: `fmt.Println("hi")`,
+		},
+		{
+			name: "control characters and colors",
+			text: "\ufeff\x1b[32mok\x1b[0m\tbuild\x07 done",
+			mode: "smart",
+			want: "ok build done",
+		},
+		{
+			name: "long prose is cut after a whole sentence",
+			text: longProse,
+			mode: "smart",
+			want: "The handler now validates the order ID before it queries the store. The handler now validates the order ID before it queries the store.",
+		},
+		{
+			name:       "tool output shows its outcome",
+			text:       longOutput,
+			mode:       "smart",
+			toolResult: true,
+			want:       "… === RUN TestOrders --- PASS: TestOrders (0.00s) === RUN TestOrders --- PASS: TestOrders (0.00s) PASS ok github.com/acme/api/orders 0.412s",
+		},
+		{
+			name:       "short tool output is shown whole",
+			text:       "\n  file written  \n",
+			mode:       "smart",
+			toolResult: true,
+			want:       "file written",
+		},
+		{
+			name: "first keeps the start",
+			text: "⏺ ## Done\n\nok",
+			mode: "first",
+			want: "⏺ ## Done ok",
+		},
+		{
+			name:       "last keeps the end",
+			text:       longOutput,
+			mode:       "last",
+			toolResult: true,
+			want:       "…PASS ok github.com/acme/api/orders 0.412s",
+		},
+		{
+			name: "only markup falls back to the raw text",
+			text: "---\n***",
+			mode: "smart",
+			want: "--- ***",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Snippet(tt.text, tt.mode, tt.toolResult, snippetWidth)
+			if tt.mode == "last" {
+				if !strings.HasSuffix(got, strings.TrimPrefix(tt.want, "…")) || !strings.HasPrefix(got, "…") {
+					t.Errorf("Snippet() = %q, want it to end in %q", got, tt.want)
+				}
+			} else if got != tt.want {
+				t.Errorf("Snippet() = %q, want %q", got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > snippetWidth {
+				t.Errorf("Snippet() is %d characters, want at most %d", n, snippetWidth)
+			}
+		})
+	}
+}
+
+// TestSnippetWidth tests that the content line follows the card width
+func TestSnippetWidth(t *testing.T) {
+	prose := strings.Repeat("The handler now validates the order ID before it queries the store. ", 3)
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{80, "The handler now validates the order ID before it queries the store."},
+		{40, "The handler now validates the order ID …"},
+		{2, "T…"},
+	}
+	for _, tt := range tests {
+		if got := Snippet(prose, "smart", false, tt.width); got != tt.want {
+			t.Errorf("Snippet(width %d) = %q, want %q", tt.width, got, tt.want)
+		}
+	}
+}
+
+// TestSkipLeadIn tests the smart preview against the openings of real replies: those
+// only acknowledging the request are skipped, those saying something are kept
+func TestSkipLeadIn(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"I'll help you with that. The handler indexes an empty slice.", "The handler indexes an empty slice."},
+		{"Sure! I’ll help you fix this. First, the test is racy.", "First, the test is racy."},
+		{"Sure, I can help with that. The config lives in config.go.", "The config lives in config.go."},
+		{"Perfect! Now let me update the tests.", "Now let me update the tests."},
+		{"You're absolutely right. The cache is never cleared.", "The cache is never cleared."},
+		{"Good catch! The loop ends one early.", "The loop ends one early."},
+		{"Let me take a look at the handler. It returns nil on error.", "It returns nil on error."},
+		{"Great, the tests pass. Next is the docs.", "Great, the tests pass. Next is the docs."},
+		{"Let me look into the cache code. It grows without bound.", "Let me look into the cache code. It grows without bound."},
+		{"Sure!", "Sure!"},
+		{"Done. All 12 tests pass.", "Done. All 12 tests pass."},
+		{"Okay. Thanks! Understood.", "Understood."},
+	}
+	for _, tt := range tests {
+		if got := skipLeadIn(tt.text); got != tt.want {
+			t.Errorf("skipLeadIn(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SummaryData is what the session summary template (config summary.template) can use
+type SummaryData struct {
+	Duration   string    // Session length, e.g. "2h13m"
+	Prompts    int       // User prompts, excluding tool results
+	Messages   int       // All messages in the session
+	Tokens     string    // Tokens processed (monitor.TokenCounts.Total), compact, e.g. "1.4M"
+	TokenCount int       // Tokens processed as a plain number
+	Cost       string    // Estimated cost, e.g. "$7.82"
+	CostUSD    float64   // Estimated cost as a plain number
+	Branch     string    // Git branch, "-" if unknown
+	Model      string    // Model label, e.g. "opus" or "opus+haiku"
+	Version    string    // Claude version that wrote the session
+	Started    time.Time // First entry of the session
+	SessionID  string    // Session file name without extension
+	Path       string    // Session file path
+}
+
+// SummaryLine executes a summary template with the session's data, flattening the
+// result onto a single line
+func SummaryLine(tmpl string, d SummaryData) (string, error) {
+	t, err := template.New("summary").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid summary template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("cannot render summary: %w", err)
+	}
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thieso2/promptwatch/internal/config"
+)
+
+func TestSummaryLine(t *testing.T) {
+	d := SummaryData{
+		Duration:   "2h13m",
+		Prompts:    96,
+		Tokens:     FormatTokenCount(1_412_000),
+		TokenCount: 1_412_000,
+		Cost:       "$7.82",
+		CostUSD:    7.82,
+		Branch:     "feature/auth",
+		Model:      "opus",
+		Started:    goldenTime,
+	}
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr string
+	}{
+		{"default template", config.DefaultSummaryTemplate, "Session 2h13m, 96 prompts, 1.4M tokens, $7.82, branch feature/auth", ""},
+		{"custom fields", `{{.Started.Format "2006-01-02"}} {{.Model}} {{printf "%.1f" .CostUSD}}`, "2026-01-12 opus 7.8", ""},
+		{"multi-line output is flattened", "{{.Duration}}\n  {{.Branch}}\n", "2h13m feature/auth", ""},
+		{"unknown field", "{{.Nope}}", "", "cannot render summary"},
+		{"syntax error", "{{.Duration", "", "invalid summary template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SummaryLine(tt.tmpl, d)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SummaryLine(%q) error = %v, want %q", tt.tmpl, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("SummaryLine(%q) = %q, %v; want %q", tt.tmpl, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/config"
+)
+
+// Theme holds the colors that carry meaning in the views. The accessible themes also
+// set Glyphs, so that what a color says can be read without telling the colors apart.
+type Theme struct {
+	Low  lipgloss.Color // Costs and context usage below their warning threshold
+	Warn lipgloss.Color // Above the warning threshold
+	High lipgloss.Color // Above the high threshold
+
+	User      lipgloss.Color // User entries in the composition bar
+	Assistant lipgloss.Color // Assistant entries in the composition bar
+	Deleted   lipgloss.Color // Diff lines only in the older message
+	Inserted  lipgloss.Color // Diff lines only in the newer message
+	Alert     lipgloss.Color // Failed tool results and sessions flagged for review
+
+	// Glyphs marks levels with glyphs as well as color: "$!" and "$!!" for costs over
+	// their warning and high thresholds, "!" and "!!" after context usage, and "✗" on
+	// failed tool results
+	Glyphs bool
+}
+
+// themes are the themes by the names in config.Themes
+var themes = map[string]Theme{
+	"default": {
+		Low: "10", Warn: "3", High: "1",
+		User: "12", Assistant: "10", Deleted: "1", Inserted: "2", Alert: "1",
+	},
+	// Blue, yellow and orange differ in lightness as well as hue, which deuteranopic
+	// and protanopic readers can tell apart where they cannot tell red from green
+	"colorblind": {
+		Low: "39", Warn: "220", High: "202",
+		User: "39", Assistant: "220", Deleted: "202", Inserted: "39", Alert: "202",
+		Glyphs: true,
+	},
+	"high-contrast": {
+		Low: "15", Warn: "11", High: "9",
+		User: "14", Assistant: "11", Deleted: "9", Inserted: "10", Alert: "9",
+		Glyphs: true,
+	},
+}
+
+// theme is the theme the views are drawn in
+var theme = themes["default"]
+
+// SetTheme selects the theme of all later rendering by its name in config.Themes;
+// unknown names keep the current theme
+func SetTheme(name string) {
+	if t, ok := themes[name]; ok {
+		theme = t
+	}
+}
+
+// ActiveTheme returns the theme the views are drawn in
+func ActiveTheme() Theme {
+	return theme
+}
+
+// levelColor returns the theme's color for a cost or context level
+func levelColor(level config.Level) lipgloss.Color {
+	switch level {
+	case config.LevelHigh:
+		return theme.High
+	case config.LevelWarn:
+		return theme.Warn
+	default:
+		return theme.Low
+	}
+}
+
+// levelGlyph returns the glyph marking a level in themes with glyphs: "!" above the
+// warning threshold and "!!" above the high one
+func levelGlyph(level config.Level) string {
+	if !theme.Glyphs {
+		return ""
+	}
+	switch level {
+	case config.LevelHigh:
+		return "!!"
+	case config.LevelWarn:
+		return "!"
+	default:
+		return ""
+	}
+}
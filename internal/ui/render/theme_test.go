@@ -0,0 +1,72 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+)
+
+func TestThemes(t *testing.T) {
+	for _, name := range config.Themes {
+		if _, ok := themes[name]; !ok {
+			t.Errorf("theme %q accepted by the config has no colors", name)
+		}
+	}
+
+	t.Cleanup(func() { SetTheme("default") })
+	SetTheme("colorblind")
+	if theme != themes["colorblind"] {
+		t.Fatal("SetTheme(colorblind) did not select the theme")
+	}
+	SetTheme("no-such-theme")
+	if theme != themes["colorblind"] {
+		t.Error("an unknown theme name should keep the current theme")
+	}
+}
+
+// TestThemeGlyphs tests that themes with glyphs repeat in text what colors say
+func TestThemeGlyphs(t *testing.T) {
+	thresholds := config.Thresholds{Warn: 1, High: 10}
+	costs := config.CostConfig{}
+	failed := monitor.Message{Type: "tool_result", Role: "user", Content: "exit status 1", IsError: true}
+
+	tests := []struct {
+		theme    string
+		low      string
+		warn     string
+		high     string
+		gauge    string
+		cell     string
+		errorTag bool
+	}{
+		{theme: "default", low: "$0.50", warn: "$2.00", high: "$20.00", gauge: "ctx:▰▰▰▰▰96%", cell: "96%"},
+		{theme: "colorblind", low: "$0.50", warn: "$!2.00", high: "$!!20.00", gauge: "ctx:▰▰▰▰▰96%!!", cell: "96%!!", errorTag: true},
+		{theme: "high-contrast", low: "$0.50", warn: "$!2.00", high: "$!!20.00", gauge: "ctx:▰▰▰▰▰96%!!", cell: "96%!!", errorTag: true},
+	}
+	t.Cleanup(func() { SetTheme("default") })
+	for _, tt := range tests {
+		t.Run(tt.theme, func(t *testing.T) {
+			SetTheme(tt.theme)
+			for cost, want := range map[float64]string{0.5: tt.low, 2: tt.warn, 20: tt.high} {
+				if got := Cost(costs, cost, thresholds, "", TotalPrecision); got != want {
+					t.Errorf("Cost(%g) = %q, want %q", cost, got, want)
+				}
+			}
+			if got := ContextGauge(0.96); got != tt.gauge {
+				t.Errorf("ContextGauge(0.96) = %q, want %q", got, tt.gauge)
+			}
+			if got := ContextCell(0.96).Data; got != tt.cell {
+				t.Errorf("ContextCell(0.96) = %q, want %q", got, tt.cell)
+			}
+			if got := ContextCell(0).Data; got != "-" {
+				t.Errorf("ContextCell(0) = %q, want \"-\"", got)
+			}
+			preview := SessionPreview(PreviewData{Title: "t", Messages: []monitor.Message{failed}, Width: 50, Height: 8})
+			if got := strings.Contains(preview, "✗ ↩ tool result"); got != tt.errorTag {
+				t.Errorf("failed tool result marked with ✗: %v, want %v\n%s", got, tt.errorTag, preview)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ViewedData is everything the recently viewed list shows
+type ViewedData struct {
+	Rows     []ViewedRow
+	Selected int       // Index of the highlighted row
+	Now      time.Time // For the age of each open
+	Error    string    // Why the history could not be read
+	Note     string    // Transient notice, e.g. that a session file is gone
+	Width    int       // Terminal width (0 if unknown)
+	Help     string    // Rendered help bar
+}
+
+// ViewedRow is a session opened before
+type ViewedRow struct {
+	At      time.Time // When it was last opened
+	Project string
+	Title   string
+	Missing bool // The session file has been deleted; shown dimmed
+}
+
+// viewedProjectWidth is the width of the project column of the recently viewed list
+const viewedProjectWidth = 28
+
+// Viewed renders the sessions last opened in promptwatch, newest first, with when
+func Viewed(d ViewedData) string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("11")).
+		Render("Recently viewed sessions")
+	components := []string{title}
+	if d.Note != "" {
+		components = append(components, lipgloss.NewStyle().Foreground(theme.Alert).Render(d.Note))
+	}
+
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	switch {
+	case d.Error != "":
+		components = append(components, "", lipgloss.NewStyle().Foreground(theme.Alert).Render("Cannot read the history: "+d.Error))
+	case len(d.Rows) == 0:
+		components = append(components, "", dim.Render("No sessions opened yet; sessions you open are listed here."))
+	default:
+		components = append(components, "")
+		width := widthOr(d.Width)
+		for i, row := range d.Rows {
+			marker := "  "
+			if i == d.Selected {
+				marker = "▶ "
+			}
+			line := fmt.Sprintf("%s%s  %-8s  %-*s  %s", marker, row.At.Local().Format("2006-01-02 15:04"),
+				viewedAge(d.Now.Sub(row.At)), viewedProjectWidth, truncateTail(row.Project, viewedProjectWidth), row.Title)
+			if row.Missing {
+				line += "  (deleted)"
+			}
+			if lipgloss.Width(line) > width {
+				line = truncateWidth(line, width)
+			}
+			style := lipgloss.NewStyle()
+			switch {
+			case row.Missing:
+				style = dim
+			case i == d.Selected:
+				style = style.Bold(true).Foreground(lipgloss.Color("11"))
+			}
+			components = append(components, style.Render(line))
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append(components, "", d.Help)...)
+}
+
+// viewedAge formats how long ago a session was opened, e.g. "5m ago" or "3d ago"
+func viewedAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
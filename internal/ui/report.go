@@ -0,0 +1,286 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/export"
+	"github.com/thieso2/promptwatch/internal/fsutil"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// reportRanges are the ranges the reports view offers: the last days up to today, or
+// with no days the custom range typed into the range prompt
+var reportRanges = []struct {
+	label string
+	days  int
+}{
+	{"Last 7 days", 7},
+	{"Last 30 days", 30},
+	{"Custom", 0},
+}
+
+// reportBusiestDays is how many busy days the reports view and its exports list
+const reportBusiestDays = 5
+
+// reportScanProgressMsg reports how many session files of a report have been scanned
+type reportScanProgressMsg struct {
+	key     string // DateRange.String of the report's range
+	done    int
+	total   int
+	updates <-chan tea.Msg // Channel to keep listening on for further updates
+}
+
+// reportMsg carries the finished scan of a report
+type reportMsg struct {
+	key    string
+	report *monitor.Report
+	err    error
+}
+
+// reportExportedMsg reports the result of writing a report from the reports view
+type reportExportedMsg struct {
+	path string
+	err  error
+}
+
+// openReport switches to the reports view and scans the selected range
+func (m *Model) openReport() tea.Cmd {
+	m.viewMode = ViewReport
+	return m.scanReport()
+}
+
+// reportRangeShown returns the range the selected choice covers as of now
+func (m Model) reportRangeShown() monitor.DateRange {
+	if days := reportRanges[m.reportRange].days; days > 0 {
+		return monitor.LastDays(days, time.Now())
+	}
+	return m.reportCustom
+}
+
+// scanReport sums up the sessions of all projects within the selected range in the
+// background. A previous scan of the range stays visible until the new one is done.
+func (m *Model) scanReport() tea.Cmd {
+	m.cancelReportScan()
+	if m.reportCache == nil {
+		m.reportCache = make(map[string]*monitor.Report)
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.reportCancel = cancel
+	m.reportShown = m.reportRangeShown()
+	m.reportError = ""
+	m.scanningReport = true
+	m.reportScanDone, m.reportScanTotal = 0, 0
+	r, key := m.reportShown, m.reportShown.String()
+
+	updates := make(chan tea.Msg, 1)
+	scan := func() tea.Msg {
+		defer close(updates)
+		report, err := monitor.ScanReport(ctx, r, pricing.MessageCost, func(done, total int) {
+			// Drop progress updates if the UI hasn't caught up yet
+			select {
+			case updates <- reportScanProgressMsg{key: key, done: done, total: total, updates: updates}:
+			default:
+			}
+		})
+		select {
+		case updates <- reportMsg{key: key, report: report, err: err}:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+	return tea.Batch(scan, waitForSessionLoad(updates), m.loadSpinner.Tick)
+}
+
+// cancelReportScan stops an in-flight report scan, if any
+func (m *Model) cancelReportScan() {
+	if m.reportCancel != nil {
+		m.reportCancel()
+		m.reportCancel = nil
+	}
+	m.scanningReport = false
+}
+
+// selectReportRange shows the range choice i, asking for the days first if it is the
+// custom range
+func (m *Model) selectReportRange(i int) tea.Cmd {
+	if i < 0 || i >= len(reportRanges) || i == m.reportRange {
+		return nil
+	}
+	if reportRanges[i].days == 0 {
+		m.openReportPrompt()
+		return nil
+	}
+	m.reportRange = i
+	return m.scanReport()
+}
+
+// openReportPrompt opens the prompt for the custom range, filled in with the range
+// entered last
+func (m *Model) openReportPrompt() {
+	m.reportPrompt = true
+	m.reportInput = ""
+	if !m.reportCustom.From.IsZero() {
+		m.reportInput = m.reportCustom.From.Format("2006-01-02") + ".." + m.reportCustom.To.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+}
+
+// updateReportView handles the keys of the reports view not handled by Update
+func (m *Model) updateReportView(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "left", "h":
+		return m.selectReportRange(m.reportRange - 1)
+	case "right", "l":
+		return m.selectReportRange(m.reportRange + 1)
+	case "c":
+		m.openReportPrompt()
+	case "r":
+		return m.scanReport()
+	case "e", "E":
+		// Export what the view shows as Markdown, or with E as CSV
+		if m.cfg.ReadOnly {
+			m.projectNote = "✗ Read-only mode: exports are disabled"
+			return nil
+		}
+		return m.exportReport(msg.String() == "E")
+	}
+	return nil
+}
+
+// updateReportPrompt handles a key typed into the custom range prompt: days and "..",
+// backspace, enter to scan the range and esc to cancel
+func (m *Model) updateReportPrompt(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.reportPrompt = false
+	case "enter":
+		m.reportPrompt = false
+		r, err := monitor.ParseDateRange(m.reportInput, time.Now())
+		if err != nil {
+			m.projectNote = "✗ " + err.Error()
+			return nil
+		}
+		m.reportCustom = r
+		m.reportRange = len(reportRanges) - 1
+		return m.scanReport()
+	case "backspace":
+		if m.reportInput != "" {
+			m.reportInput = m.reportInput[:len(m.reportInput)-1]
+		}
+	default:
+		if msg.Type == tea.KeyRunes && len(m.reportInput) < len("2006-01-02..2006-01-02") && strings.Trim(string(msg.Runes), "0123456789-.") == "" {
+			m.reportInput += string(msg.Runes)
+		}
+	}
+	return nil
+}
+
+// renderReportPrompt renders the prompt that takes the days of the custom range
+func (m Model) renderReportPrompt() string {
+	prompt := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("11")).
+		Render(fmt.Sprintf("Days (YYYY-MM-DD..YYYY-MM-DD): %s█", m.reportInput))
+	width := 0
+	if m.termWidth > 0 {
+		width = max(m.termWidth-lipgloss.Width(prompt)-2, 1)
+	}
+	return prompt + "  " + render.HelpBar([]render.KeyHint{
+		hint("enter", "Show", render.PriorityEssential),
+		hint("esc", "Cancel", render.PriorityEssential),
+	}, width)
+}
+
+// exportReport writes the report the view shows, as Markdown or with csv as CSV, to a
+// file in the configured export directory named after its days
+func (m *Model) exportReport(csv bool) tea.Cmd {
+	report := m.shownReport(m.reportCache[m.reportShown.String()])
+	if report == nil {
+		m.projectNote = "✗ Nothing to export until the scan is done"
+		return nil
+	}
+	title := reportRanges[m.reportRange].label
+	if reportRanges[m.reportRange].days == 0 {
+		title = report.Range.String()
+	}
+	ext := ".md"
+	if csv {
+		ext = ".csv"
+	}
+	name := fmt.Sprintf("report-%s-%s", report.Range.From.Format("20060102"), report.Range.To.AddDate(0, 0, -1).Format("20060102"))
+	path := filepath.Join(m.cfg.Export.Dir, name+ext)
+	write := func() tea.Msg {
+		var buf bytes.Buffer
+		var err error
+		if csv {
+			err = export.ReportCSV(&buf, report, reportBusiestDays)
+		} else {
+			err = export.ReportMarkdown(&buf, report, title, reportBusiestDays)
+		}
+		if err != nil {
+			return reportExportedMsg{path: path, err: err}
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return reportExportedMsg{path: path, err: err}
+			}
+		}
+		if err := fsutil.WriteAtomic(path, buf.Bytes(), 0644); err != nil {
+			return reportExportedMsg{path: path, err: err}
+		}
+		return reportExportedMsg{path: path}
+	}
+	if _, err := os.Stat(path); err == nil {
+		m.askConfirmation(confirmation{
+			message:     "Overwrite " + path + "?",
+			destructive: true,
+			onConfirm:   func(*Model) tea.Cmd { return write },
+		})
+		return nil
+	}
+	return write
+}
+
+// shownReport returns report as the reports view shows it: a copy with the project
+// paths shortened, and masked while redacting
+func (m Model) shownReport(report *monitor.Report) *monitor.Report {
+	if report == nil {
+		return nil
+	}
+	copied := *report
+	copied.Projects = make([]monitor.ProjectReport, len(report.Projects))
+	for i, p := range report.Projects {
+		p.Name = m.shownPath(monitor.ShortenHomePath(p.Name))
+		copied.Projects[i] = p
+	}
+	return &copied
+}
+
+// renderReportView displays the reports dashboard across all projects
+func (m Model) renderReportView() string {
+	labels := make([]string, len(reportRanges))
+	for i, r := range reportRanges {
+		labels[i] = r.label
+	}
+	return render.Report(render.ReportData{
+		Ranges:   labels,
+		Selected: m.reportRange,
+		Note:     m.projectNote,
+		Report:   m.shownReport(m.reportCache[m.reportShown.String()]),
+		Loading:  m.scanningReport,
+		Spinner:  m.loadSpinner.View(),
+		Done:     m.reportScanDone,
+		Total:    m.reportScanTotal,
+		Error:    m.reportError,
+		Help:     m.renderHelp(),
+		TopTools: reportBusiestDays,
+	}, m.cfg.Cost)
+}
@@ -2,10 +2,13 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evertras/bubble-table/table"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/ui/render"
 )
 
 // createTable initializes the bubble-table model with columns and styling
@@ -30,48 +33,6 @@ func createTable() table.Model {
 	return t
 }
 
-// createTableWithWidth creates a table with columns sized for the given width
-func createTableWithWidth(width int) table.Model {
-	// Calculate responsive column widths
-	// Reserve space for borders and padding (roughly 2 chars per column)
-	availableWidth := width - 14 // Reserve for borders and spacing
-
-	// Proportional distribution: PID(5%) CPU(7%) MEM(8%) UPTIME(8%) WORKDIR(30%) CMD(42%)
-	pidWidth := 8
-	cpuWidth := 10
-	memWidth := 12
-	uptimeWidth := 12
-	workdirWidth := (availableWidth * 30) / 100
-	cmdWidth := availableWidth - pidWidth - cpuWidth - memWidth - uptimeWidth - workdirWidth
-
-	// Ensure minimum widths
-	if workdirWidth < 20 {
-		workdirWidth = 20
-	}
-	if cmdWidth < 20 {
-		cmdWidth = 20
-	}
-
-	columns := []table.Column{
-		table.NewColumn("pid", "PID", pidWidth),
-		table.NewColumn("cpu", "CPU%", cpuWidth),
-		table.NewColumn("mem", "MEM", memWidth),
-		table.NewColumn("uptime", "UPTIME", uptimeWidth),
-		table.NewColumn("workdir", "WORKDIR", workdirWidth),
-		table.NewColumn("cmd", "COMMAND", cmdWidth),
-	}
-
-	t := table.New(columns).
-		WithPageSize(20).
-		WithBaseStyle(
-			lipgloss.NewStyle().
-				Foreground(lipgloss.Color("255")),
-		).
-		Focused(true)
-
-	return t
-}
-
 // styleHighCPU applies red styling to high CPU values
 func styleHighCPU(cpu string) string {
 	// This would be applied in view.go when rendering
@@ -112,7 +73,8 @@ func createSessionTableWithWidth(width int) table.Model {
 	// Version: 8 chars (v2.1.25)
 	// GitBranch: 20 chars (ingress-validation or feature/name)
 	// LastMsg: 16 chars (timestamp)
-	// Tokens: 16 chars (5039568/5211 format)
+	// Tokens: 16 chars (12/840k/96k format)
+	// Model: 12 chars (opus+haiku)
 	// Started: 16 chars (2026-01-30 14:23)
 	// Duration: 7 chars (12h34m or 999m)
 	// Remaining for last message preview
@@ -120,9 +82,10 @@ func createSessionTableWithWidth(width int) table.Model {
 	gitWidth := 20
 	lastMsgTimeWidth := 16
 	tokensWidth := 16
+	modelWidth := 12
 	startedWidth := 16
 	durationWidth := 7
-	lastMsgWidth := availableWidth - versionWidth - gitWidth - lastMsgTimeWidth - tokensWidth - startedWidth - durationWidth
+	lastMsgWidth := availableWidth - versionWidth - gitWidth - lastMsgTimeWidth - tokensWidth - modelWidth - startedWidth - durationWidth
 
 	// Ensure minimum width for last message
 	if lastMsgWidth < 30 {
@@ -133,7 +96,8 @@ func createSessionTableWithWidth(width int) table.Model {
 		table.NewColumn("version", "VER", versionWidth),
 		table.NewColumn("gitbranch", "BRANCH", gitWidth),
 		table.NewColumn("lastmsgtime", "LAST MSG", lastMsgTimeWidth),
-		table.NewColumn("tokens", "TOKENS", tokensWidth),
+		table.NewColumn("tokens", tokensHeader, tokensWidth),
+		table.NewColumn("model", "MODEL", modelWidth),
 		table.NewColumn("started", "START", startedWidth),
 		table.NewColumn("duration", "LEN", durationWidth),
 		table.NewColumn("lastmessage", "PREVIEW", lastMsgWidth),
@@ -201,27 +165,69 @@ func createMessageTableWithWidth(width int) table.Model {
 	return t
 }
 
-// createProjectsTableWithWidth creates a projects directory table with responsive widths
-func createProjectsTableWithWidth(width int) table.Model {
-	// Calculate responsive column widths
-	availableWidth := width - 6
+// Tables whose wide column can be resized with "<" and ">", as keys of the persisted shares
+const (
+	processTableName  = "processes"
+	projectsTableName = "projects"
+)
+
+// Bounds and step of an adjustable column's share of the table width, in percent. Each
+// table has one adjustable column, resized with < and > and saved per table in
+// State.ColumnShares.
+const (
+	minColumnShare  = 10
+	maxColumnShare  = 60
+	columnShareStep = 5
+)
 
-	nameWidth := (availableWidth * 40) / 100
-	modifiedWidth := (availableWidth * 30) / 100
-	sessionsWidth := availableWidth - nameWidth - modifiedWidth
+// defaultColumnShares are the shares of the adjustable columns before any adjustment
+var defaultColumnShares = map[string]int{
+	processTableName:  render.DefaultWorkdirShare,
+	projectsTableName: 30,
+}
+
+// recentProjectWidth caps the PROJECT column of the recent sessions list
+const recentProjectWidth = 30
+
+// sessionTitleWidth caps the TITLE column of the session lists
+const sessionTitleWidth = 40
+
+// createProjectsTableWithWidth creates a projects directory table with responsive widths,
+// giving nameShare percent of the width to PROJECT. It returns the widths of the PROJECT
+// and LAST PROMPT columns with it, which rows are truncated to. Without running, the
+// RUNNING column is left out and its width goes to LAST PROMPT.
+func createProjectsTableWithWidth(width, nameShare int, running bool) (t table.Model, nameWidth, promptWidth int) {
+	// Calculate responsive column widths
+	availableWidth := width - 8
+
+	nameWidth = (availableWidth * nameShare) / 100
+	modifiedWidth := (availableWidth * 20) / 100
+	sessionsWidth := (availableWidth * 15) / 100
+	runningWidth := 0
+	if running {
+		runningWidth = render.RunningWidth
+	}
+	promptWidth = availableWidth - nameWidth - modifiedWidth - sessionsWidth - runningWidth
 
 	// Ensure minimum widths
 	if nameWidth < 25 {
 		nameWidth = 25
 	}
+	if promptWidth < 10 {
+		promptWidth = 10
+	}
 
 	columns := []table.Column{
 		table.NewColumn("name", "PROJECT", nameWidth),
 		table.NewColumn("modified", "MODIFIED", modifiedWidth),
 		table.NewColumn("sessions", "SESSIONS", sessionsWidth),
 	}
+	if running {
+		columns = append(columns, table.NewColumn("running", render.RunningHeader, render.RunningWidth))
+	}
+	columns = append(columns, table.NewColumn("prompt", "LAST PROMPT", promptWidth))
 
-	t := table.New(columns).
+	t = table.New(columns).
 		WithPageSize(20).
 		WithBaseStyle(
 			lipgloss.NewStyle().
@@ -229,29 +235,38 @@ func createProjectsTableWithWidth(width int) table.Model {
 		).
 		Focused(true)
 
-	return t
+	return t, nameWidth, promptWidth
 }
 
 // ColumnWidths holds calculated widths for session table columns
 type ColumnWidths struct {
+	Project     int // 0 when no session has a project, i.e. outside the recent list
+	Title       int // 0 when no session has a title other than its ID
 	Version     int
 	GitBranch   int
 	LastMsgTime int
 	Tokens      int
+	Context     int // Context window usage of the last response
+	Rate        int // 0 when no listed session is live
+	Model       int
 	Started     int
 	Duration    int
+	Files       int // 0 unless sessions.showChanges is set
+	Lines       int // 0 unless sessions.showChanges is set
 	LastMessage int
 }
 
-// CalculateSessionTableWidths calculates optimal column widths based on session data
-func CalculateSessionTableWidths(width int, sessions []SessionInfo) ColumnWidths {
+// CalculateSessionTableWidths calculates optimal column widths based on session data,
+// with the FILES and LINES columns if changes is set
+func CalculateSessionTableWidths(width int, sessions []SessionInfo, changes bool) ColumnWidths {
 	availableWidth := width - 6 // Reserve for borders and spacing
 
 	// Calculate maximum width needed for each column based on actual data
 	maxVersionWidth := len("v2.1.27")              // e.g., "v2.1.27"
 	maxGitWidth := len("main")                     // default minimum
 	maxLastMsgTimeWidth := len("2026-01-30 15:04") // timestamp format
-	maxTokensWidth := len("9999999/9999999")       // very large tokens
+	maxTokensWidth := len("999k/9.9M/999k")        // very large tokens
+	maxModelWidth := len("sonnet")
 	maxStartedWidth := len("2026-01-30 14:23")
 	maxDurationWidth := len("999h59m")
 	maxLastMessageWidth := len("This is a message preview…") // typical message preview
@@ -267,10 +282,7 @@ func CalculateSessionTableWidths(width int, sessions []SessionInfo) ColumnWidths
 		}
 
 		// Check git branch width
-		gitStr := session.GitBranch
-		if gitStr == "" {
-			gitStr = "-"
-		}
+		gitStr := session.BranchLabel()
 		if len(gitStr) > maxGitWidth {
 			maxGitWidth = len(gitStr)
 		}
@@ -284,19 +296,18 @@ func CalculateSessionTableWidths(width int, sessions []SessionInfo) ColumnWidths
 		}
 
 		// Check tokens width
-		if session.TotalTokens > 0 {
-			tokensStr := fmt.Sprintf("%d/%d", session.InputTokens, session.OutputTokens)
-			if len(tokensStr) > maxTokensWidth {
-				maxTokensWidth = len(tokensStr)
-			}
+		if tokensStr := tokensColumn(session.Tokens); len(tokensStr) > maxTokensWidth {
+			maxTokensWidth = len(tokensStr)
+		}
+
+		// Check model width
+		if len(session.Model) > maxModelWidth {
+			maxModelWidth = len(session.Model)
 		}
 
 		// Check last message preview width
 		if session.LastMessage != "" {
-			lastMsgPreview := session.LastMessage
-			if len(lastMsgPreview) > 50 {
-				lastMsgPreview = lastMsgPreview[:47] + "…"
-			}
+			lastMsgPreview := truncateText(session.LastMessage, 50)
 			if len(lastMsgPreview) > maxLastMessageWidth {
 				maxLastMessageWidth = len(lastMsgPreview)
 			}
@@ -308,6 +319,7 @@ func CalculateSessionTableWidths(width int, sessions []SessionInfo) ColumnWidths
 	maxGitWidth += 2
 	maxLastMsgTimeWidth += 2
 	maxTokensWidth += 2
+	maxModelWidth += 2
 	maxStartedWidth += 2
 	maxDurationWidth += 2
 	maxLastMessageWidth += 2
@@ -329,8 +341,13 @@ func CalculateSessionTableWidths(width int, sessions []SessionInfo) ColumnWidths
 	}
 
 	tokensWidth := maxTokensWidth
-	if tokensWidth < len("TOKENS")+2 {
-		tokensWidth = len("TOKENS") + 2
+	if tokensWidth < len(tokensHeader)+2 {
+		tokensWidth = len(tokensHeader) + 2
+	}
+
+	modelWidth := maxModelWidth
+	if modelWidth < len("MODEL")+2 {
+		modelWidth = len("MODEL") + 2
 	}
 
 	startedWidth := maxStartedWidth
@@ -343,8 +360,43 @@ func CalculateSessionTableWidths(width int, sessions []SessionInfo) ColumnWidths
 		durationWidth = len("LEN") + 2
 	}
 
+	// Project column, only for the cross-project recent list
+	projectWidth := 0
+	for _, session := range sessions {
+		if session.Project != "" {
+			projectWidth = max(projectWidth, min(len(session.Project), recentProjectWidth)+2, len("PROJECT")+2)
+		}
+	}
+
+	// Title column, left out while every title is just the session ID
+	titleWidth := 0
+	for _, session := range sessions {
+		if session.Title != "" && session.Title != session.ID {
+			titleWidth = max(titleWidth, min(len([]rune(session.Title)), sessionTitleWidth)+2, len("TITLE")+2)
+		}
+	}
+
+	// Rate column, only while a listed session is being written to
+	rateWidth := 0
+	for _, session := range sessions {
+		if !session.Rate.Idle() {
+			rateWidth = render.RateWidth
+		}
+	}
+
+	// Changes columns, only when asked for in the config
+	filesWidth, linesWidth := 0, 0
+	if changes {
+		filesWidth, linesWidth = len("FILES")+2, len("+9999 −999")+2
+		for _, session := range sessions {
+			files, lines := changesColumns(session.DiffStat)
+			filesWidth = max(filesWidth, len(files)+2)
+			linesWidth = max(linesWidth, lipgloss.Width(lines)+2)
+		}
+	}
+
 	// Fixed columns total
-	fixedWidth := versionWidth + gitWidth + lastMsgTimeWidth + tokensWidth + startedWidth + durationWidth
+	fixedWidth := projectWidth + titleWidth + versionWidth + gitWidth + lastMsgTimeWidth + tokensWidth + render.ContextWidth + rateWidth + modelWidth + startedWidth + durationWidth + filesWidth + linesWidth
 
 	// Last message preview gets remaining space, but ensure minimum
 	lastMessageWidth := availableWidth - fixedWidth
@@ -353,29 +405,57 @@ func CalculateSessionTableWidths(width int, sessions []SessionInfo) ColumnWidths
 	}
 
 	return ColumnWidths{
+		Project:     projectWidth,
+		Title:       titleWidth,
 		Version:     versionWidth,
 		GitBranch:   gitWidth,
 		LastMsgTime: lastMsgTimeWidth,
 		Tokens:      tokensWidth,
+		Context:     render.ContextWidth,
+		Rate:        rateWidth,
+		Model:       modelWidth,
 		Started:     startedWidth,
 		Duration:    durationWidth,
+		Files:       filesWidth,
+		Lines:       linesWidth,
 		LastMessage: lastMessageWidth,
 	}
 }
 
-// CreateSessionTableWithDynamicWidths creates a session table with dynamically calculated column widths
-func CreateSessionTableWithDynamicWidths(width int, sessions []SessionInfo) table.Model {
-	widths := CalculateSessionTableWidths(width, sessions)
+// CreateSessionTableWithDynamicWidths creates a session table with dynamically calculated
+// column widths, with the FILES and LINES columns if changes is set
+func CreateSessionTableWithDynamicWidths(width int, sessions []SessionInfo, changes bool) table.Model {
+	widths := CalculateSessionTableWidths(width, sessions, changes)
 
-	columns := []table.Column{
+	var columns []table.Column
+	if widths.Project > 0 {
+		columns = append(columns, table.NewColumn("project", "PROJECT", widths.Project))
+	}
+	if widths.Title > 0 {
+		columns = append(columns, table.NewColumn("title", "TITLE", widths.Title))
+	}
+	columns = append(columns,
 		table.NewColumn("version", "VER", widths.Version),
 		table.NewColumn("gitbranch", "BRANCH", widths.GitBranch),
 		table.NewColumn("lastmsgtime", "LAST MSG", widths.LastMsgTime),
-		table.NewColumn("tokens", "TOKENS", widths.Tokens),
+		table.NewColumn("tokens", tokensHeader, widths.Tokens),
+		table.NewColumn("context", render.ContextHeader, widths.Context),
+	)
+	if widths.Rate > 0 {
+		columns = append(columns, table.NewColumn("rate", render.RateHeader, widths.Rate))
+	}
+	columns = append(columns,
+		table.NewColumn("model", "MODEL", widths.Model),
 		table.NewColumn("started", "START", widths.Started),
 		table.NewColumn("duration", "LEN", widths.Duration),
-		table.NewColumn("lastmessage", "PREVIEW", widths.LastMessage),
+	)
+	if widths.Files > 0 {
+		columns = append(columns,
+			table.NewColumn("files", "FILES", widths.Files),
+			table.NewColumn("lines", "LINES", widths.Lines),
+		)
 	}
+	columns = append(columns, table.NewColumn("lastmessage", "PREVIEW", widths.LastMessage))
 
 	t := table.New(columns).
 		WithPageSize(20).
@@ -387,3 +467,24 @@ func CreateSessionTableWithDynamicWidths(width int, sessions []SessionInfo) tabl
 
 	return t
 }
+
+// changesColumns formats what a session's tool calls changed for the FILES and LINES
+// columns, e.g. "7" and "+412 −96"; "-" for sessions that wrote no files
+func changesColumns(d monitor.DiffStat) (files, lines string) {
+	if d.IsZero() {
+		return "-", "-"
+	}
+	return strconv.Itoa(d.Files), render.DiffStatLines(d)
+}
+
+// tokensHeader heads the tokens column: fresh input, cache writes and output, the
+// parts of monitor.TokenCounts.Total; cache reads are left out
+const tokensHeader = "IN/CW/OUT"
+
+// tokensColumn formats a session's tokens for the tokens column, e.g. "12/840k/96k"
+func tokensColumn(t monitor.TokenCounts) string {
+	if t.Total() == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s/%s/%s", render.FormatTokenCount(t.Input), render.FormatTokenCount(t.CacheWrite), render.FormatTokenCount(t.Output))
+}
@@ -1,87 +1,320 @@
 package ui
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
-	"sort"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/evertras/bubble-table/table"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/diff"
+	"github.com/thieso2/promptwatch/internal/export"
+	"github.com/thieso2/promptwatch/internal/fsutil"
 	"github.com/thieso2/promptwatch/internal/monitor"
-)
-
-// Cost constants based on Claude API pricing
-const (
-	InputTokenCost         = 3.0 / 1_000_000  // $3 per 1M input tokens
-	CacheCreationTokenCost = 3.0 / 1_000_000  // $3 per 1M cache creation tokens
-	CacheReadTokenCost     = 0.30 / 1_000_000 // $0.30 per 1M cache read tokens
-	OutputTokenCost        = 15.0 / 1_000_000 // $15 per 1M output tokens
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/types"
+	"github.com/thieso2/promptwatch/internal/ui/render"
 )
 
 // Update handles incoming messages and updates the model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	count := 0 // Count prefix of the key being handled, 0 when none was typed
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.processNote = ""
+		m.sessionNote = ""
+		m.projectNote = ""
+		m.viewedNote = ""
+		if m.confirm != nil {
+			cmd := m.updateConfirmation(msg)
+			return m, cmd
+		}
+		if m.jumpPrompt && msg.String() != "ctrl+c" {
+			m.updateJumpPrompt(msg)
+			return m, nil
+		}
+		if m.reportPrompt && msg.String() != "ctrl+c" {
+			cmd := m.updateReportPrompt(msg)
+			return m, cmd
+		}
+		if m.viewMode == ViewSessionDetail && m.sessionStats != nil {
+			// Digits build up a count for the next key, e.g. "5j" or "37G"
+			if d, ok := digitKey(msg); ok && (d > 0 || m.countPrefix > 0) {
+				m.countPrefix = min(m.countPrefix*10+d, maxCountPrefix)
+				return m, nil
+			}
+			count, m.countPrefix = m.countPrefix, 0
+		}
 		switch msg.String() {
+		case "?":
+			// Toggle between the fitted help bar and the full list of key hints
+			m.showFullHelp = !m.showFullHelp
+			return m, nil
+		case "Z":
+			// Mask prompts, replies and paths for screen sharing; metrics stay visible
+			m.toggleRedaction()
+			return m, nil
 		case "q", "ctrl+c":
+			if msg.String() == "q" && m.confirmQuit && m.hasBackgroundWork() {
+				m.askConfirmation(confirmation{
+					message: "A session is still loading. Really quit?",
+					keys:    []string{"y", "q"},
+					onConfirm: func(m *Model) tea.Cmd {
+						m.Shutdown()
+						m.quitting = true
+						return tea.Quit
+					},
+				})
+				return m, nil
+			}
+			m.Shutdown()
 			m.quitting = true
 			return m, tea.Quit
 		case "esc":
 			// Go back to previous view
+			if m.viewMode == ViewSessionDetail && m.loadingSession {
+				// First esc while loading cancels the parse and keeps the partial results
+				m.cancelSessionLoad()
+				return m, nil
+			}
+			if m.viewMode == ViewDiff {
+				m.viewMode = ViewSessionDetail
+				m.diffOps = nil
+				return m, nil
+			}
+			if m.viewMode == ViewErrors {
+				m.toggleErrors()
+				return m, nil
+			}
+			if m.viewMode == ViewProjectStats {
+				m.cancelProjectScan()
+				m.viewMode = ViewProjects
+				return m, nil
+			}
+			if m.viewMode == ViewReport {
+				m.cancelReportScan()
+				m.viewMode = ViewProjects
+				return m, nil
+			}
 			if m.viewMode == ViewMessageDetail {
 				m.viewMode = ViewSessionDetail
 				m.detailMessage = nil
 				m.detailScrollOffset = 0
+				m.detailPaired = false
+				m.detailResults = nil
+				return m, nil
+			} else if m.viewMode == ViewSessionDetail && m.splitShown() && m.splitFocusDetail {
+				// Back from the detail pane to the cards
+				m.splitFocusDetail = false
 				return m, nil
 			} else if m.viewMode == ViewSessionDetail {
 				m.viewMode = ViewSessions
+				if m.sessionSourceMode == ViewViewed {
+					// Back to the recently viewed list the session was opened from
+					m.viewMode = ViewViewed
+				}
+				m.loadingSession = false
 				m.selectedSession = nil
 				m.sessionStats = nil
+				m.sessionTotals = sessionTotals{}
 				m.messages = nil
 				m.messageError = ""
+				m.diffMark = nil
 				m.messageViewport.GotoTop() // Reset viewport scroll
+				if m.viewMode == ViewViewed {
+					return m, m.loadViewed()
+				}
+				return m, nil
+			} else if m.viewMode == ViewViewed {
+				m.viewMode = ViewProjects
+				m.viewed = nil
+				if len(m.projects) == 0 {
+					return m, m.loadProjects()
+				}
 				return m, nil
 			} else if m.viewMode == ViewSessions {
-				// Go back to the source (process or project view)
-				if m.sessionSourceMode == ViewProjects {
+				// Go back to the source (process or project view; the recent list is opened from projects)
+				var cmds []tea.Cmd
+				if m.sessionSourceMode == ViewProjects || m.sessionSourceMode == ViewRecent {
+					if m.sessionSourceMode == ViewRecent {
+						cmds = append(cmds, m.saveView("projects"))
+					}
+					if len(m.projects) == 0 {
+						// Started in the recent list, so the projects are yet to be loaded
+						cmds = append(cmds, m.loadProjects())
+					}
 					m.viewMode = ViewProjects
 				} else {
 					m.viewMode = ViewProcesses
 				}
 				m.selectedProc = nil
 				m.sessions = nil
+				m.allSessions = nil
+				m.sessionModelFilter = ""
+				m.sessionModeFilter = ""
+				m.sessionLangFilter = ""
 				m.sessionError = ""
 				m.selectedSessionIdx = 0
-				return m, nil
+				m.closePreview()
+				m.watcher.SetDirs(nil)
+				return m, tea.Batch(cmds...)
 			}
 		case "r":
 			// Manual refresh (only in process view)
 			if m.viewMode == ViewProcesses {
 				return m, m.refreshProcesses()
 			}
+			if m.viewMode == ViewViewed {
+				return m, m.loadViewed()
+			}
+			// Retry a session that failed to load
+			if m.viewMode == ViewSessionDetail && m.sessionStats == nil && !m.loadingSession {
+				m.messageError = ""
+				return m, m.loadSessionDetail()
+			}
+		case "+", "=", "-":
+			// Diff the marked message against the selected one (in session detail view)
+			if m.viewMode == ViewSessionDetail && msg.String() == "=" {
+				m.openDiff()
+				return m, nil
+			}
+			// Adjust the refresh interval (only in process view)
+			if m.viewMode == ViewProcesses {
+				dir := 1
+				if msg.String() == "-" {
+					dir = -1
+				}
+				next := stepRefreshInterval(m.updateInterval, dir)
+				if next == m.updateInterval {
+					return m, nil
+				}
+				m.updateInterval = next
+				if m.paused {
+					return m, m.saveRefreshInterval()
+				}
+				tick := m.restartTick()
+				return m, tea.Batch(tick, m.saveRefreshInterval())
+			}
+		case "<", ">":
+			// Shrink/grow the wide column of the process or projects table
+			if m.viewMode == ViewProcesses || m.viewMode == ViewProjects {
+				dir := 1
+				if msg.String() == "<" {
+					dir = -1
+				}
+				return m, m.adjustColumn(dir)
+			}
+		case " ":
+			// Pause/resume periodic refresh (only in process view)
+			if m.viewMode == ViewProcesses {
+				m.paused = !m.paused
+				if m.paused {
+					return m, nil
+				}
+				tick := m.restartTick()
+				return m, tea.Batch(m.refreshProcesses(), tick)
+			}
 		case "f":
 			// Toggle helpers filter (only in process view)
 			if m.viewMode == ViewProcesses {
 				m.showHelpers = !m.showHelpers
 				return m, m.refreshProcesses()
 			}
+			// Apply filter preset <n>, or without a count clear it (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.applyFilterPreset(count)
+				return m, nil
+			}
+		case "m":
+			// Cycle the model filter (in session list view)
+			if m.viewMode == ViewSessions {
+				m.sessionModelFilter = m.nextModelFilter()
+				m.applySessionFilter()
+				m.selectedSessionIdx = 0
+				m.updateSessionTable()
+				return m, m.schedulePreview()
+			}
+			// Mark the selected message as the old side of a diff (in session detail view)
+			if m.viewMode == ViewSessionDetail && m.sessionStats != nil {
+				m.toggleDiffMark()
+				return m, nil
+			}
+		case "L":
+			// Cycle the language filter (in session list view)
+			if m.viewMode == ViewSessions {
+				m.sessionLangFilter = m.nextLangFilter()
+				m.applySessionFilter()
+				m.selectedSessionIdx = 0
+				m.updateSessionTable()
+				return m, m.schedulePreview()
+			}
+		case "M":
+			// Cycle the permission mode filter (in session list view)
+			if m.viewMode == ViewSessions {
+				m.sessionModeFilter = m.nextModeFilter()
+				m.applySessionFilter()
+				m.selectedSessionIdx = 0
+				m.updateSessionTable()
+				return m, m.schedulePreview()
+			}
 		case "p":
 			// Toggle between processes and projects view
 			if m.viewMode == ViewProcesses {
 				m.viewMode = ViewProjects
 				m.selectedProjIdx = 0
-				return m, m.loadProjects()
-			} else if m.viewMode == ViewProjects {
+				return m, tea.Batch(m.loadProjects(), m.saveView("projects"))
+			} else if m.viewMode == ViewProjects && !m.cfg.NoProcesses {
 				m.viewMode = ViewProcesses
 				m.selectedProcIdx = 0
-				return m, m.refreshProcesses()
+				return m, tea.Batch(m.refreshProcesses(), m.saveView("processes"))
+			}
+		case "P":
+			// Jump to the process working in the selected project (in projects view)
+			if m.viewMode == ViewProjects && !m.cfg.NoProcesses && m.selectedProjIdx >= 0 && m.selectedProjIdx < len(m.projects) {
+				return m, m.showProjectProcess(m.projects[m.selectedProjIdx])
+			}
+		case "i":
+			// Show the stats of the selected project (in projects view)
+			if m.viewMode == ViewProjects && m.selectedProjIdx >= 0 && m.selectedProjIdx < len(m.projects) {
+				m.viewMode = ViewProjectStats
+				return m, m.scanProject(m.projects[m.selectedProjIdx])
+			}
+		case "D":
+			// Open the reports across all projects (in projects view)
+			if m.viewMode == ViewProjects {
+				return m, m.openReport()
+			}
+		case "H":
+			// Open the sessions last opened in promptwatch (in projects view)
+			if m.viewMode == ViewProjects {
+				m.selectedViewedIdx = 0
+				return m, m.openViewed()
+			}
+		case "R":
+			// Open the sessions of all projects from the last days (in projects view)
+			if m.viewMode == ViewProjects {
+				m.viewMode = ViewSessions
+				m.sessionSourceMode = ViewRecent
+				m.selectedProc = nil
+				m.selectedSessionIdx = 0
+				return m, tea.Batch(m.loadRecentSessions(), m.saveView("recent"))
 			}
 		case "u":
 			// Filter to user messages only (in session detail view)
 			if m.viewMode == ViewSessionDetail {
-				m.messageFilter = FilterUserOnly
-				m.updateMessageTable()
+				m.setMessageFilter(FilterUserOnly)
 				if m.filteredMessageCount == 0 {
 					m.messageError = "No user prompts found in this session"
 				} else {
@@ -92,8 +325,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "a":
 			// Filter to assistant messages only (in session detail view)
 			if m.viewMode == ViewSessionDetail {
-				m.messageFilter = FilterAssistantOnly
-				m.updateMessageTable()
+				m.setMessageFilter(FilterAssistantOnly)
 				if m.filteredMessageCount == 0 {
 					m.messageError = "No Claude responses found in this session"
 				} else {
@@ -101,11 +333,62 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, nil
 			}
+		case "d":
+			// Filter to tool calls denied at a permission prompt (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.setMessageFilter(FilterDenied)
+				if m.filteredMessageCount == 0 {
+					m.messageError = "No tool calls were denied in this session"
+				} else {
+					m.messageError = fmt.Sprintf("Showing %d denied tool calls", m.filteredMessageCount)
+				}
+				return m, nil
+			}
+		case "x":
+			// Filter to responses flagged as refusals (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.setMessageFilter(FilterRefusals)
+				if m.filteredMessageCount == 0 {
+					m.messageError = "No refusals found in this session"
+				} else {
+					m.messageError = fmt.Sprintf("Showing %d refusals", m.filteredMessageCount)
+				}
+				return m, nil
+			}
+		case "t":
+			// Filter to responses calling tools (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.setMessageFilter(FilterTools)
+				if m.filteredMessageCount == 0 {
+					m.messageError = "No tool calls found in this session"
+				} else {
+					m.messageError = fmt.Sprintf("Showing %d tool calls (T: group by tool)", m.filteredMessageCount)
+				}
+				return m, nil
+			}
+		case "T":
+			// Toggle sectioning the tool calls by tool (in session detail view, under the
+			// tool filter)
+			if m.viewMode == ViewSessionDetail {
+				if m.messageFilter != FilterTools {
+					m.messageError = "Grouping by tool needs the tool filter (t)"
+					return m, nil
+				}
+				m.keepSelection(func() {
+					m.groupByTool = !m.groupByTool
+					m.updateMessageTable()
+				})
+				if m.groupByTool {
+					m.messageError = "Grouped by tool (enter: expand/collapse, n/N: next/previous tool)"
+				} else {
+					m.messageError = "Showing tool calls in order"
+				}
+				return m, nil
+			}
 		case "b":
 			// Show both (all messages)
 			if m.viewMode == ViewSessionDetail {
-				m.messageFilter = FilterAll
-				m.updateMessageTable()
+				m.setMessageFilter(FilterAll)
 				if m.filteredMessageCount == 0 {
 					m.messageError = "No messages found in this session"
 				} else {
@@ -113,6 +396,188 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, nil
 			}
+		case "o":
+			// Open the selected session, including parents of side-chains (in sessions view)
+			if m.viewMode == ViewSessions && m.selectedSessionIdx >= 0 && m.selectedSessionIdx < len(m.sessions) {
+				return m, m.openSelectedSession()
+			}
+			// Open the file the message wrote in the editor (in message detail view, or on
+			// the selected card in session detail view)
+			if m.viewMode == ViewMessageDetail {
+				return m, m.openWrittenFile(m.detailMessage)
+			}
+			if m.viewMode == ViewSessionDetail && m.sessionStats != nil {
+				return m, m.openWrittenFile(m.messageAtRow(m.selectedMessageIdx))
+			}
+		case "A":
+			// Toggle listing processes without sessions (in process view)
+			if m.viewMode == ViewProcesses {
+				m.showSessionless = !m.showSessionless
+				return m, m.refreshProcesses()
+			}
+			// Toggle listing subagent sessions (in sessions view)
+			if m.viewMode == ViewSessions {
+				m.showAgents = !m.showAgents
+				m.applySessionFilter()
+				m.selectedSessionIdx = 0
+				m.updateSessionTable()
+				return m, m.schedulePreview()
+			}
+		case "v":
+			// Split the session list with a preview of the highlighted session (in sessions view)
+			if m.viewMode == ViewSessions {
+				m.sessionPreview = !m.sessionPreview
+				m.closePreview()
+				m.updateSessionTable()
+				return m, m.schedulePreview()
+			}
+		case "S":
+			// Toggle counting side-chain tokens in their parent's totals (in sessions view)
+			if m.viewMode == ViewSessions {
+				m.includeSidechains = !m.includeSidechains
+				m.updateSessionTable()
+				return m, nil
+			}
+		case ":":
+			// Open the "go to message" prompt (in session detail view)
+			if m.viewMode == ViewSessionDetail && m.sessionStats != nil {
+				m.jumpPrompt = true
+				m.jumpInput = ""
+				return m, nil
+			}
+		case "c":
+			// Toggle the running cost total on assistant cards (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.runningTotal = !m.runningTotal
+				m.refreshMessageCards()
+				if m.runningTotal {
+					m.sessionNote = "Running total: Σ shows the session cost up to each response"
+				}
+				return m, nil
+			}
+		case "G":
+			// With a count, jump to that message (in session detail view)
+			if m.viewMode == ViewSessionDetail && count > 0 {
+				m.jumpToMessage(count)
+				return m, nil
+			}
+			// Toggle grouping of messages by turn (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.groupByTurn = !m.groupByTurn
+				m.updateMessageTable()
+				m.selectedMessageIdx = 0
+				m.lastMessageIdx = 0
+				m.refreshMessageCards()
+				m.messageViewport.GotoTop()
+				if m.groupByTurn {
+					m.messageError = "Grouped by turn (enter: expand/collapse, n/N: next/previous turn)"
+				} else {
+					m.messageError = "Showing individual messages"
+				}
+				return m, nil
+			}
+		case "|":
+			// Show the selected message beside the cards (in session detail view)
+			if m.viewMode == ViewSessionDetail && m.sessionStats != nil {
+				if !m.splitView && m.termWidth < m.cfg.Detail.SplitMinWidth {
+					m.sessionNote = fmt.Sprintf("Split view needs %d columns, the terminal has %d (detail.splitMinWidth)",
+						m.cfg.Detail.SplitMinWidth, m.termWidth)
+					return m, nil
+				}
+				m.splitView = !m.splitView
+				m.splitFocusDetail = false
+				m.detailScrollOffset = 0
+				m.resizeMessageViewport()
+				m.refreshMessageCards() // Fitted to the new width
+				m.scrollToSelection()
+				return m, nil
+			}
+		case "tab":
+			// Move the keys between the cards and the selected message (in the split view)
+			if m.viewMode == ViewSessionDetail && m.splitShown() {
+				m.splitFocusDetail = !m.splitFocusDetail
+				return m, nil
+			}
+		case "z":
+			// Collapse/expand the session header (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.compactHeader = !m.compactHeader
+				m.resizeMessageViewport()
+				m.scrollToSelection()
+				return m, m.saveCompactHeader()
+			}
+		case "y", "Y":
+			// Copy the session ID, or with Y the summary line (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				if stats, ok := m.sessionStats.(*monitor.SessionStats); ok {
+					if msg.String() == "Y" {
+						m.copySessionSummary(stats)
+					} else {
+						m.sessionNote = m.copyText(monitor.SessionFileID(stats.FilePath))
+					}
+				}
+				return m, nil
+			}
+			// Copy the project's original path, or with Y its encoded directory (in the
+			// projects and project stats views)
+			if m.viewMode == ViewProjects || m.viewMode == ViewProjectStats {
+				m.copyProjectPath(msg.String() == "Y")
+				return m, nil
+			}
+		case "e", "E":
+			// Export the filtered messages, or with E only the selected message, turn or tool
+			// (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				if m.cfg.ReadOnly {
+					m.sessionNote = "✗ Read-only mode: exports are disabled"
+					return m, nil
+				}
+				if stats, ok := m.sessionStats.(*monitor.SessionStats); ok {
+					return m, m.exportMessages(stats, msg.String() == "E")
+				}
+				return m, nil
+			}
+		case "$":
+			// Jump to the most expensive turn (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.jumpToMostExpensiveTurn()
+				return m, nil
+			}
+		case "%":
+			// With a count, jump that far through the session's turns, e.g. "50%" (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.jumpToTurnPercent(count)
+				return m, nil
+			}
+		case "!":
+			// Open or close the errors met this run (in every view)
+			m.toggleErrors()
+			return m, nil
+		case "X":
+			// Jump to the next refusal (in session detail view)
+			if m.viewMode == ViewSessionDetail {
+				m.jumpToNextRefusal()
+				return m, nil
+			}
+		case "n", "N":
+			// Jump to the next/previous turn, or tool when grouped by tool (in session
+			// detail view)
+			if m.viewMode == ViewSessionDetail {
+				dir := 1
+				if msg.String() == "N" {
+					dir = -1
+				}
+				target := m.turnJumpTarget(dir)
+				if m.groupByTool {
+					target = m.toolGroupJumpTarget(dir)
+				}
+				if target != m.selectedMessageIdx {
+					m.selectedMessageIdx = target
+					m.refreshMessageCards()
+					m.scrollToSelection()
+				}
+				return m, nil
+			}
 		case "s":
 			// Toggle sort order (newest/oldest first)
 			if m.viewMode == ViewSessionDetail {
@@ -128,7 +593,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			// Open session view for selected process/project or session detail for selected session
 			if m.viewMode == ViewProcesses && len(m.processes) > 0 && m.selectedProcIdx >= 0 && m.selectedProcIdx < len(m.processes) {
-				m.selectedProc = &m.processes[m.selectedProcIdx]
+				if proc := m.processes[m.selectedProcIdx]; proc.WorkDirGone {
+					// Sessions are found by working directory, which no longer exists
+					m.processNote = fmt.Sprintf("Working directory of process %d is gone; find its sessions with p (Projects)", proc.PID)
+					return m, nil
+				}
+				proc := m.processes[m.selectedProcIdx]
+				m.selectedProc, m.selectedProcExited = &proc, false
 				m.viewMode = ViewSessions
 				m.sessionSourceMode = ViewProcesses
 				m.selectedSessionIdx = 0 // Reset to first session
@@ -137,103 +608,390 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Load sessions for selected project
 				m.viewMode = ViewSessions
 				m.sessionSourceMode = ViewProjects
+				m.sessionsProject = m.projects[m.selectedProjIdx]
 				m.selectedSessionIdx = 0 // Reset to first session
-				return m, m.loadSessionsFromProject(m.projects[m.selectedProjIdx])
+				return m, m.loadSessionsFromProject(m.sessionsProject)
 			} else if m.viewMode == ViewSessions && len(m.sessions) > 0 && m.selectedSessionIdx >= 0 && m.selectedSessionIdx < len(m.sessions) {
-				m.viewMode = ViewSessionDetail
-				m.messageFilter = FilterAll // Reset filter when opening new session
-				return m, m.loadSessionDetail()
+				if m.sessions[m.selectedSessionIdx].Sidechains > 0 {
+					// Parents expand/collapse their side-chains; "o" opens them
+					m.toggleSidechains()
+					return m, nil
+				}
+				return m, m.openSelectedSession()
+			} else if m.viewMode == ViewViewed {
+				return m, m.openSelectedViewed()
 			} else if m.viewMode == ViewSessionDetail {
-				// Open message detail view for selected message
-				stats, ok := m.sessionStats.(*monitor.SessionStats)
-				if ok && len(stats.MessageHistory) > 0 {
-					// Get the filtered messages to find the correct one
-					filteredMessages := m.getFilteredMessages(stats)
-					if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(filteredMessages) {
-						m.detailMessage = &filteredMessages[m.selectedMessageIdx]
-						m.viewMode = ViewMessageDetail
-						m.detailScrollOffset = 0
-						return m, nil
-					}
+				if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(m.messages) && m.messages[m.selectedMessageIdx].IsTurnHeader {
+					// Expand/collapse the selected turn
+					m.toggleTurnExpanded(m.selectedMessageIdx)
+					return m, nil
+				}
+				if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(m.messages) && m.messages[m.selectedMessageIdx].ToolGroup != "" {
+					// Expand/collapse the selected tool's section
+					m.toggleToolGroup(m.selectedMessageIdx)
+					return m, nil
+				}
+				// Open message detail view for selected message; tool calls open with their result
+				if msg := m.messageAtRow(m.selectedMessageIdx); msg != nil {
+					m.detailMessage = msg
+					m.viewMode = ViewMessageDetail
+					m.detailScrollOffset = 0
+					m.detailPaired = msg.ToolName != ""
+					m.detailArgsCollapsed, m.detailResultCollapsed = false, false
+					m.detailResults = m.toolResultsAtRow(m.selectedMessageIdx)
+					return m, nil
 				}
 			}
 		}
 		// Fall through to table handling for navigation and other keys
 
 	case tickMsg:
-		// Periodic refresh (only in process view)
+		if msg.gen != m.tickGen || m.paused {
+			// Superseded chain, or paused: let this chain end
+			return m, nil
+		}
+		// Periodic refresh (only in process view, of the session preview, of whether Claude
+		// is still to answer in session detail view, and in the projects view of which
+		// projects have a process running unless process discovery is off, or until Claude
+		// has created the projects directory and a first project)
 		if m.viewMode == ViewProcesses {
 			return m, tea.Batch(m.refreshProcesses(), m.tick())
+		} else if m.viewMode == ViewSessionDetail && m.sessionStats != nil {
+			return m, tea.Batch(m.checkWaiting(), m.tick())
+		} else if m.viewMode == ViewSessions {
+			cmds := []tea.Cmd{m.sampleSessionRates(), m.refreshEmptySessions(), m.refreshSelectedProcess(), m.tick()}
+			if m.previewShown() && m.previewPath != "" && !m.previewLoading {
+				cmds = append(cmds, m.loadPreview())
+			}
+			return m, tea.Batch(cmds...)
+		} else if m.viewMode == ViewProjects && m.projectsError == "" && len(m.projects) == 0 {
+			return m, tea.Batch(m.loadProjects(), m.tick())
+		} else if m.viewMode == ViewProjects && m.projectsError == "" && !m.cfg.NoProcesses {
+			return m, tea.Batch(m.refreshProcesses(), m.tick())
 		} else {
 			return m, m.tick()
 		}
 
+	case waitMsg:
+		if msg.seq == m.loadSeq {
+			m.waitingSince, m.waitingAfter = msg.since, msg.after
+			// The session had no messages yet when it was opened: load them now they are there
+			if stats, ok := m.sessionStats.(*monitor.SessionStats); ok && len(stats.MessageHistory) == 0 && msg.messages > 0 && !m.loadingSession && m.selectedSession != nil {
+				return m, tea.Batch(m.loadSessionDetail(), m.refreshSessionRow(m.selectedSession.Path))
+			}
+		}
+		return m, nil
+
+	case errMsg:
+		m.recordError(msg.op, msg.err, msg.attrs...)
+		return m, m.waitForErrors()
+
+	case exportedMsg:
+		if msg.err != nil {
+			m.recordError("export", msg.err, "path", msg.path)
+			m.sessionNote = "✗ " + msg.err.Error()
+		} else {
+			m.sessionNote = fmt.Sprintf("✓ Exported %d messages to %s", msg.messages, msg.path)
+		}
+		return m, nil
+
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.recordError("open in editor", msg.err, "path", msg.path)
+		}
+		return m, nil
+
+	case stateSavedMsg:
+		if msg.err != nil {
+			m.recordError("save state", msg.err, "path", m.statePath)
+		}
+		return m, nil
+
+	case openedSessionMsg:
+		if msg.err != nil {
+			m.recordError("record session open", msg.err, "path", m.historyPath)
+		}
+		return m, nil
+
+	case viewedMsg:
+		if m.viewMode != ViewViewed {
+			return m, nil // The list has been left
+		}
+		if msg.err != nil {
+			m.recordError("load history", msg.err, "path", m.historyPath)
+			m.viewedError = msg.err.Error()
+			return m, nil
+		}
+		m.viewedError = ""
+		m.viewed = msg.sessions
+		m.selectedViewedIdx = min(m.selectedViewedIdx, max(len(m.viewed)-1, 0))
+		return m, nil
+
 	case processesMsg:
 		if msg.err != nil {
-			// Error refreshing - log but continue
+			// Error refreshing - log but continue with whatever was found
+			m.recordError("refresh processes", msg.err)
 		}
-		m.processes = msg.processes
+		// The sessions Claude is busiest with come first
+		slices.SortStableFunc(msg.processes, func(a, b types.ClaudeProcess) int {
+			return cmp.Compare(msg.rates[b.PID].Last(5), msg.rates[a.PID].Last(5))
+		})
+		m.processRates = msg.rates
+		m.setProcesses(msg.processes)
+		m.discovery = msg.report
 		m.lastUpdate = time.Now()
 		m.updateTable()
+		if m.viewMode == ViewProjects {
+			m.updateProjectsTable() // Which projects have a process running
+		}
+		return m, nil
+
+	case processMetricsMsg:
+		if m.selectedProc == nil || m.selectedProc.PID != msg.proc.PID {
+			return m, nil // The list was left meanwhile
+		}
+		if errors.Is(msg.err, monitor.ErrProcessGone) {
+			m.selectedProcExited = true
+		} else if msg.err != nil {
+			m.recordError("refresh process", msg.err, "pid", msg.proc.PID)
+		} else {
+			// The listed sessions belong to the directory the process was opened in
+			m.selectedProc.CPUPercent, m.selectedProc.MemoryMB = msg.proc.CPUPercent, msg.proc.MemoryMB
+		}
 		return m, nil
 
 	case sessionsMsg:
+		if source := m.sessionListSource(); msg.source != "" && source != "" && msg.source != source {
+			return m, nil // Loaded for a list that has since been left
+		}
 		if msg.err != nil {
+			m.recordError("load sessions", msg.err)
 			m.sessionError = msg.err.Error()
 		} else {
 			m.sessionError = ""
-			m.sessions = msg.sessions
-			m.updateSessionTable()
+			m.setSessions(msg.sessions)
+			m.watcher.SetDirs(m.sessionDirs())
+			return m, tea.Batch(m.schedulePreview(), m.sampleSessionRates())
 		}
 		return m, nil
 
-	case sessionDetailMsg:
+	case sessionRatesMsg:
+		if msg.source != m.sessionListSource() {
+			return m, nil // Sampled for a list that has since been left
+		}
+		if len(msg.rates) == 0 && !slices.ContainsFunc(m.allSessions, func(s SessionInfo) bool { return !s.Rate.Idle() }) {
+			return m, nil // Nothing live before or now: leave the table alone
+		}
+		m.applySessionRates(msg.rates)
+		m.updateSessionTable()
+		return m, nil
+
+	case watchMsg:
+		cmds := []tea.Cmd{m.waitForWatch()}
+		if (m.viewMode == ViewProjects || len(m.projects) > 0) && (msg.change.Projects || len(msg.change.Dirs) > 0) {
+			// Session counts and modification times change along with the files
+			cmds = append(cmds, m.loadProjects())
+		}
+		if m.watchConcerns(msg.change) {
+			cmds = append(cmds, m.reloadSessions())
+		}
+		return m, tea.Batch(cmds...)
+
+	case previewDebounceMsg:
+		if msg.seq != m.previewSeq {
+			return m, nil // The selection moved on before the debounce ran out
+		}
+		return m, m.loadPreview()
+
+	case previewMsg:
+		if msg.seq != m.previewSeq {
+			return m, nil
+		}
+		m.previewLoading = false
+		m.previewError = ""
 		if msg.err != nil {
-			m.messageError = msg.err.Error()
+			m.previewError = msg.err.Error()
+			m.previewMessages = nil
 		} else {
+			m.previewMessages = msg.messages
+		}
+		return m, nil
+
+	case sessionRowMsg:
+		m.replaceSessionRow(msg.session)
+		return m, nil
+
+	case sessionTailMsg:
+		if msg.seq == m.loadSeq {
 			m.messageError = ""
 			m.sessionStats = msg.stats
+			m.setWaiting(msg.stats.MessageHistory)
+			m.selectedMessageIdx = 0
+			m.lastMessageIdx = 0
+			m.messageViewport.GotoTop()
+			m.updateMessageTable()
+		}
+		return m, waitForSessionLoad(msg.updates)
+
+	case sessionProgressMsg:
+		if msg.seq == m.loadSeq && msg.total > 0 {
+			m.loadProgress = float64(msg.processed) / float64(msg.total)
+		}
+		// Keep draining the channel so the parser never blocks on a stale load
+		return m, waitForSessionLoad(msg.updates)
+
+	case sessionDetailMsg:
+		if msg.seq != m.loadSeq {
+			return m, nil // Result of a load that has since been superseded
+		}
+		m.loadingSession = false
+		m.loadCancel = nil
+		stats, _ := msg.stats.(*monitor.SessionStats)
+		if msg.err != nil && errors.Is(msg.err, context.Canceled) && m.sessionStats != nil {
+			// Backfill cancelled: the newest messages are already on screen, keep them
+			m.messageError = "Loading of older history cancelled — showing the most recent messages only"
+		} else if msg.err == nil && m.sessionStats != nil {
+			// Backfill finished: swap in the full history without moving the viewport
+			m.messageError = ""
+			m.replaceSessionStats(stats)
+			m.setWaiting(stats.MessageHistory)
+		} else if msg.err != nil && errors.Is(msg.err, context.Canceled) && stats != nil {
+			// Cancelled: show what was parsed so far along with the reason
+			m.messageError = fmt.Sprintf("Loading cancelled at %.0f%% — showing %d partial messages", m.loadProgress*100, len(stats.MessageHistory))
+			m.sessionStats = stats
+			m.selectedMessageIdx = 0
+			m.lastMessageIdx = 0
+			m.messageViewport.GotoTop()
+			m.updateMessageTable()
+		} else if msg.err != nil {
+			m.recordError("load session", msg.err)
+			m.messageError = msg.err.Error()
+		} else {
+			m.messageError = ""
+			m.sessionStats = stats
+			m.setWaiting(stats.MessageHistory)
 			m.selectedMessageIdx = 0    // Reset cursor to first message
 			m.lastMessageIdx = 0        // Reset scroll tracking
 			m.messageViewport.GotoTop() // Reset viewport scroll when loading new session
 			m.updateMessageTable()
 		}
+		if msg.err == nil && m.selectedSession != nil && m.selectedSession.LoadError != "" {
+			// The file reads fine now: refresh its row in the session list
+			return m, m.refreshSessionRow(m.selectedSession.Path)
+		}
 		return m, nil
 
+	case spinner.TickMsg:
+		if !m.loadingSession && !m.scanningProject && !m.scanningReport {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.loadSpinner, cmd = m.loadSpinner.Update(msg)
+		return m, cmd
+
 	case projectsMsg:
+		m.projectsDir, m.projectsDirMissing = msg.dir, msg.missing
 		if msg.err != nil {
+			m.recordError("load projects", msg.err)
 			m.projectsError = msg.err.Error()
 		} else {
 			m.projectsError = ""
-			m.projects = msg.projects
+			m.setProjects(msg.projects)
+			if msg.processes != nil {
+				m.setProcesses(msg.processes)
+				m.updateTable()
+			}
 			m.updateProjectsTable()
+			if m.selectedProjIdx < len(m.projects) {
+				m.projectsTable = m.projectsTable.WithHighlightedRow(m.selectedProjIdx)
+			}
+			m.watcher.SetDirs(m.sessionDirs()) // Picks up a projects directory created since
+			return m, m.loadProjectPrompts(m.projects)
 		}
 		return m, nil
 
-	case tea.WindowSizeMsg:
-		// Handle terminal resize
-		m.termWidth = msg.Width
-		m.termHeight = msg.Height
-		// Recreate tables with new responsive widths
-		// Process table: header (1) + blank (1) + blank (1) + footer (1) = 4 lines
-		m.table = createTableWithWidth(msg.Width).WithPageSize(msg.Height - 6)
-		// Projects table: header (2 lines) + blank (2 lines) + blank (1) + footer (1) = 6+ lines
-		// Use aggressive reduction to prevent clipping
-		m.projectsTable = createProjectsTableWithWidth(msg.Width).WithPageSize(msg.Height - 10)
-		// Session table: header info (~2) + blank (1) + blank (1) + footer (1) = ~5 lines
-		m.sessionTable = createSessionTableWithWidth(msg.Width).WithPageSize(msg.Height - 8)
-		// Message table: header (1) + time (1) + tool info (1) + blank (1) + blank (1) + scroll (1) + footer (1) = 7
-		m.messageTable = createMessageTableWithWidth(msg.Width).WithPageSize(msg.Height - 9)
-		// Resize message viewport (header ~8 lines + footer ~1 line = 9 lines reserved)
-		m.messageViewport.Width = msg.Width
-		m.messageViewport.Height = msg.Height - 9
-		// Rebuild tables with current data
-		m.updateTable()
-		m.updateProjectsTable()
-		m.updateSessionTable()
-		m.updateMessageTable()
+	case projectScanProgressMsg:
+		if msg.path == m.projectStatsPath && m.scanningProject {
+			m.projectScanDone, m.projectScanTotal = msg.done, msg.total
+		}
+		return m, waitForSessionLoad(msg.updates)
+
+	case projectStatsMsg:
+		if msg.path != m.projectStatsPath || !m.scanningProject {
+			return m, nil // Result of a scan that has since been cancelled
+		}
+		m.scanningProject = false
+		m.scanCancel = nil
+		if msg.err != nil {
+			m.recordError("scan project", msg.err)
+			m.projectStatsError = msg.err.Error()
+			return m, nil
+		}
+		m.projectStatsCache[msg.path] = msg.stats
 		return m, nil
-	}
+
+	case reportScanProgressMsg:
+		if msg.key == m.reportShown.String() && m.scanningReport {
+			m.reportScanDone, m.reportScanTotal = msg.done, msg.total
+		}
+		return m, waitForSessionLoad(msg.updates)
+
+	case reportMsg:
+		if msg.key != m.reportShown.String() || !m.scanningReport {
+			return m, nil // Result of a scan that has since been cancelled
+		}
+		m.scanningReport = false
+		m.reportCancel = nil
+		if msg.err != nil {
+			m.recordError("scan report", msg.err)
+			m.reportError = msg.err.Error()
+			return m, nil
+		}
+		m.reportCache[msg.key] = msg.report
+		return m, nil
+
+	case reportExportedMsg:
+		if msg.err != nil {
+			m.recordError("export report", msg.err, "path", msg.path)
+			m.projectNote = "✗ " + msg.err.Error()
+		} else {
+			m.projectNote = "✓ Exported report to " + msg.path
+		}
+		return m, nil
+
+	case projectPromptsMsg:
+		for i := range m.projects {
+			if prompt, ok := msg.prompts[m.projects[i].Path]; ok {
+				m.projects[i].LastPrompt = prompt
+			}
+		}
+		m.updateProjectsTable()
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		// Handle terminal resize
+		if m.viewMode == ViewMessageDetail && m.detailMessage != nil {
+			// Re-wrap the open message and keep roughly the same text on screen
+			oldTotal := len(m.detailLines())
+			m.termWidth, m.termHeight = msg.Width, msg.Height
+			m.detailScrollOffset = scaleScrollOffset(m.detailScrollOffset, oldTotal, len(m.detailLines()),
+				render.DetailPageHeight(m.viewHeight()))
+		}
+		m.termWidth = msg.Width
+		m.termHeight = msg.Height
+		// Recreate tables with new responsive widths
+		m.resizeProcessTable()
+		m.resizeProjectsTable()
+		m.sessionTable = createSessionTableWithWidth(msg.Width)
+		m.messageTable = createMessageTableWithWidth(msg.Width)
+		m.resizePages()
+		// Rebuild tables with current data
+		m.updateTable()
+		m.updateProjectsTable()
+		m.updateSessionTable()
+		m.updateMessageTable()
+		return m, m.schedulePreview()
+	}
 
 	// Pass all other messages to the appropriate table
 	var cmd tea.Cmd
@@ -275,6 +1033,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		}
+	} else if m.viewMode == ViewViewed {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			switch keyMsg.String() {
+			case "up", "k":
+				m.moveViewedSelection(-1)
+			case "down", "j":
+				m.moveViewedSelection(1)
+			}
+		}
 	} else if m.viewMode == ViewSessions {
 		m.sessionTable, cmd = m.sessionTable.Update(msg)
 		// Track arrow key presses for session selection with wrapping
@@ -293,27 +1060,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedSessionIdx = 0
 				}
 			}
+			cmd = tea.Batch(cmd, m.schedulePreview())
 		}
 	} else if m.viewMode == ViewSessionDetail {
 		m.messageTable, cmd = m.messageTable.Update(msg)
 		// Handle cursor movement and scrolling in session detail view
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
-			stats, ok := m.sessionStats.(*monitor.SessionStats)
-			if ok {
-				filteredMessages := m.getFilteredMessages(stats)
+			if m.sessionStats != nil && m.splitShown() && m.splitFocusDetail {
+				m.scrollSplitDetail(keyMsg.String())
+			} else if m.sessionStats != nil {
 				needsRender := false
 
 				switch keyMsg.String() {
-				case "up":
-					// Move cursor up
+				case "up", "k":
+					// Move cursor up, by the count prefix if one was typed
 					if m.selectedMessageIdx > 0 {
-						m.selectedMessageIdx--
+						m.selectedMessageIdx = max(m.selectedMessageIdx-max(count, 1), 0)
 						needsRender = true
 					}
-				case "down":
-					// Move cursor down
+				case "down", "j":
+					// Move cursor down, by the count prefix if one was typed
 					if m.selectedMessageIdx < len(m.messages)-1 {
-						m.selectedMessageIdx++
+						m.selectedMessageIdx = min(m.selectedMessageIdx+max(count, 1), len(m.messages)-1)
 						needsRender = true
 					}
 				case "pgup":
@@ -330,19 +1098,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					// Jump to bottom
 					m.selectedMessageIdx = len(m.messages) - 1
 					needsRender = true
-				case "enter":
-					// Open message detail view for selected message
-					if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(filteredMessages) {
-						m.viewMode = ViewMessageDetail
-						m.detailMessage = &filteredMessages[m.selectedMessageIdx]
-						m.detailScrollOffset = 0
-					}
 				}
 
 				// Only re-render viewport content when cursor moves
 				if needsRender {
-					cardsContent := m.renderMessageCards()
-					m.messageViewport.SetContent(cardsContent)
+					m.refreshMessageCards()
 					// Scroll to keep selected message visible
 					m.scrollToSelection()
 				}
@@ -352,13 +1112,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle scrolling and navigation in message detail view
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
 			if m.detailMessage != nil {
-				content := m.detailMessage.Content
-				lines := strings.Split(content, "\n")
-				pageHeight := m.termHeight - 6 // Leave space for header and footer
-				maxScroll := len(lines) - pageHeight
-				if maxScroll < 0 {
-					maxScroll = 0
-				}
+				pageHeight := render.DetailPageHeight(m.viewHeight())
+				maxScroll := max(len(m.detailLines())-pageHeight, 0)
 
 				switch keyMsg.String() {
 				case "up":
@@ -383,71 +1138,380 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if m.detailScrollOffset > maxScroll {
 						m.detailScrollOffset = maxScroll
 					}
-				case "left":
-					// Previous message
-					if m.selectedMessageIdx > 0 {
-						m.selectedMessageIdx--
+				case "left", "right", "shift+left", "shift+right":
+					// Previous/next message, or 10 at a time with shift (skipping turn headers)
+					steps := 1
+					switch keyMsg.String() {
+					case "left":
+						steps = -1
+					case "shift+left":
+						steps = -10
+					case "shift+right":
+						steps = 10
+					}
+					target := m.detailStepTarget(steps)
+					if m.detailPaired {
+						target = m.toolCallStepTarget(steps)
+					}
+					if target != m.selectedMessageIdx {
+						m.selectedMessageIdx = target
+						m.detailMessage = m.messageAtRow(target)
+						m.detailResults = m.toolResultsAtRow(target)
 						m.detailScrollOffset = 0
-						stats, ok := m.sessionStats.(*monitor.SessionStats)
-						if ok {
-							filteredMessages := m.getFilteredMessages(stats)
-							if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(filteredMessages) {
-								m.detailMessage = &filteredMessages[m.selectedMessageIdx]
-							}
-						}
 					}
-				case "right":
-					// Next message
-					stats, ok := m.sessionStats.(*monitor.SessionStats)
-					if ok {
-						filteredMessages := m.getFilteredMessages(stats)
-						if m.selectedMessageIdx < len(filteredMessages)-1 {
-							m.selectedMessageIdx++
-							m.detailScrollOffset = 0
-							if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(filteredMessages) {
-								m.detailMessage = &filteredMessages[m.selectedMessageIdx]
-							}
+				case "a", "r":
+					// Collapse or expand the arguments or the result of a paired tool call
+					if m.detailPaired {
+						if keyMsg.String() == "a" {
+							m.detailArgsCollapsed = !m.detailArgsCollapsed
+						} else {
+							m.detailResultCollapsed = !m.detailResultCollapsed
 						}
+						m.detailScrollOffset = min(m.detailScrollOffset, max(len(m.detailLines())-pageHeight, 0))
 					}
 				}
 			}
 		}
+	} else if m.viewMode == ViewErrors {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			m.updateErrorsView(keyMsg)
+		}
+	} else if m.viewMode == ViewReport {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			cmd = m.updateReportView(keyMsg)
+		}
+	} else if m.viewMode == ViewDiff {
+		// Handle scrolling and the granularity toggle in the diff view
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			pageHeight := render.DiffPageHeight(m.viewHeight())
+			maxScroll := max(len(render.DiffLines(m.diffOps, m.diffWords, m.termWidth))-pageHeight, 0)
+
+			switch keyMsg.String() {
+			case "up", "k":
+				m.diffScrollOffset = max(m.diffScrollOffset-1, 0)
+			case "down", "j":
+				m.diffScrollOffset = min(m.diffScrollOffset+1, maxScroll)
+			case "home":
+				m.diffScrollOffset = 0
+			case "end":
+				m.diffScrollOffset = maxScroll
+			case "pgup":
+				m.diffScrollOffset = max(m.diffScrollOffset-pageHeight, 0)
+			case "pgdn":
+				m.diffScrollOffset = min(m.diffScrollOffset+pageHeight, maxScroll)
+			case "w":
+				m.diffWords = !m.diffWords
+				m.computeDiff()
+			}
+		}
 	}
 	return m, cmd
 }
 
+// scrollSplitDetail scrolls the split view's detail pane for a navigation key
+func (m *Model) scrollSplitDetail(key string) {
+	msg := m.messageAtRow(m.selectedMessageIdx)
+	if msg == nil {
+		return
+	}
+	pageHeight := render.DetailPanePageHeight(m.messageViewport.Height)
+	maxScroll := max(len(render.DetailLines(*msg, m.splitDetailWidth(), true))-pageHeight, 0)
+
+	switch key {
+	case "up", "k":
+		m.detailScrollOffset = max(m.detailScrollOffset-1, 0)
+	case "down", "j":
+		m.detailScrollOffset = min(m.detailScrollOffset+1, maxScroll)
+	case "home":
+		m.detailScrollOffset = 0
+	case "end":
+		m.detailScrollOffset = maxScroll
+	case "pgup":
+		m.detailScrollOffset = max(m.detailScrollOffset-pageHeight, 0)
+	case "pgdn":
+		m.detailScrollOffset = min(m.detailScrollOffset+pageHeight, maxScroll)
+	}
+}
+
+// detailLines returns the open message wrapped as the message detail view shows it
+func (m Model) detailLines() []string {
+	return render.ContentLines(m.messageDetailData())
+}
+
+// scaleScrollOffset moves a scroll offset into content that was re-wrapped from
+// oldTotal to newTotal lines, so that roughly the same text stays on screen, and
+// clamps it to the new bounds
+func scaleScrollOffset(offset, oldTotal, newTotal, pageHeight int) int {
+	if oldTotal > 0 {
+		offset = offset * newTotal / oldTotal
+	}
+	return min(max(offset, 0), max(newTotal-pageHeight, 0))
+}
+
+// toggleDiffMark marks the selected message for diffing, or unmarks it if it already is
+func (m *Model) toggleDiffMark() {
+	msg := m.messageAtRow(m.selectedMessageIdx)
+	switch {
+	case msg == nil:
+		m.sessionNote = "Select a message to mark for diffing"
+		return
+	case msg == m.diffMark:
+		m.diffMark = nil
+		m.sessionNote = "Diff mark cleared"
+	default:
+		m.diffMark = msg
+		m.diffMarkPosition = m.messages[m.selectedMessageIdx].Index
+		m.sessionNote = fmt.Sprintf("Marked message %d — select another and press = to diff", m.diffMarkPosition)
+	}
+	m.refreshMessageCards()
+}
+
+// openDiff opens the diff view comparing the marked message with the selected one
+func (m *Model) openDiff() {
+	msg := m.messageAtRow(m.selectedMessageIdx)
+	switch {
+	case m.diffMark == nil:
+		m.sessionNote = "Mark a message with m first"
+		return
+	case msg == nil || msg == m.diffMark:
+		m.sessionNote = "Select another message to diff against the marked one"
+		return
+	}
+	m.diffOld = diffLabel(m.diffMark, m.diffMarkPosition)
+	m.diffNew = diffLabel(msg, m.messages[m.selectedMessageIdx].Index)
+	m.diffOldContent = m.diffMark.Content
+	m.diffNewContent = msg.Content
+	m.diffScrollOffset = 0
+	m.computeDiff()
+	m.viewMode = ViewDiff
+}
+
+// computeDiff diffs the two compared contents at the current granularity
+func (m *Model) computeDiff() {
+	if m.diffWords {
+		m.diffOps = diff.Words(m.diffOldContent, m.diffNewContent)
+	} else {
+		m.diffOps = diff.Lines(m.diffOldContent, m.diffNewContent)
+	}
+	m.diffScrollOffset = 0
+}
+
+// diffLabel describes a compared message, e.g. "message 3 · assistant · 09:14:05"
+func diffLabel(msg *monitor.Message, position int) string {
+	kind := msg.Role
+	if msg.ToolName != "" {
+		kind = "tool " + msg.ToolName
+	}
+	return fmt.Sprintf("message %d · %s · %s", position, kind, msg.Timestamp.Local().Format("15:04:05"))
+}
+
+// Rows the table views need besides the table rows. Every table takes 6 of them for its
+// frame: top border, column headers, header rule, footer rule, footer and bottom border.
+const (
+	// Title (1) + blank (1) + table frame (6) + blank (1) + help bar (1)
+	processTableChrome = 10
+	// Title (1) + project count (1) + blank (1) + last prompt of the selected project (up
+	// to maxProjectPromptLines, an empty line without one) + table frame (6) + blank (1) +
+	// help bar (1)
+	projectsTableChrome = 11 + maxProjectPromptLines
+	// Title (1) + PID line when opened from a process (1) + blank (1) + table frame (6) +
+	// blank (1) + help bar (1)
+	sessionTableChrome = 11
+	// The message table is not drawn, the cards are (see resizeMessageViewport), so its
+	// page size does not affect the layout; 9 is what it was when the table was drawn
+	messageTableChrome = 9
+)
+
+// resizeProcessTable recreates the process table for the terminal size and WORKDIR share
+func (m *Model) resizeProcessTable() {
+	m.table, m.processColumns = render.NewProcessTable(m.termWidth, m.columnShare(processTableName))
+	m.table = m.table.WithPageSize(m.viewHeight() - processTableChrome)
+}
+
+// resizeProjectsTable recreates the projects table for the terminal size and PROJECT share
+func (m *Model) resizeProjectsTable() {
+	m.projectsTable, m.projectNameWidth, m.projectPromptWidth = createProjectsTableWithWidth(m.termWidth, m.columnShare(projectsTableName), !m.cfg.NoProcesses)
+	m.projectsTable = m.projectsTable.WithPageSize(m.viewHeight() - projectsTableChrome)
+}
+
+// resizePages fits the pages of the tables and the message viewport to the rows the
+// views have, keeping what they show
+func (m *Model) resizePages() {
+	m.table = m.table.WithPageSize(m.viewHeight() - processTableChrome)
+	m.projectsTable = m.projectsTable.WithPageSize(m.viewHeight() - projectsTableChrome)
+	m.sessionTable = m.sessionTable.WithPageSize(m.viewHeight() - sessionTableChrome)
+	m.messageTable = m.messageTable.WithPageSize(m.viewHeight() - messageTableChrome)
+	m.resizeMessageViewport()
+}
+
+// columnShare returns the percentage of a table's width given to its adjustable column
+func (m Model) columnShare(tableName string) int {
+	if share, ok := m.columnShares[tableName]; ok {
+		return share
+	}
+	return defaultColumnShares[tableName]
+}
+
+// adjustColumn grows (dir 1) or shrinks (dir -1) the wide column of the active table
+// and persists the new width
+func (m *Model) adjustColumn(dir int) tea.Cmd {
+	var tableName string
+	switch m.viewMode {
+	case ViewProcesses:
+		tableName = processTableName
+	case ViewProjects:
+		tableName = projectsTableName
+	default:
+		return nil
+	}
+	share := min(max(m.columnShare(tableName)+dir*columnShareStep, minColumnShare), maxColumnShare)
+	if share == m.columnShare(tableName) {
+		return nil
+	}
+	if m.columnShares == nil {
+		m.columnShares = make(map[string]int)
+	}
+	m.columnShares[tableName] = share
+
+	if tableName == processTableName {
+		m.resizeProcessTable()
+		m.updateTable()
+	} else {
+		m.resizeProjectsTable()
+		m.updateProjectsTable()
+		m.projectsTable = m.projectsTable.WithHighlightedRow(m.selectedProjIdx)
+	}
+	return m.saveColumnShares()
+}
+
 // updateTable rebuilds the table with current process data
 func (m *Model) updateTable() {
-	rows := make([]table.Row, len(m.processes))
+	processes := m.processes
+	if m.redact {
+		processes = make([]types.ClaudeProcess, len(m.processes))
+		for i, proc := range m.processes {
+			proc.WorkingDir = render.RedactPath(proc.WorkingDir)
+			processes[i] = proc
+		}
+	}
+	rows := render.ProcessRows(processes, m.processColumns, m.processRates)
+	m.table = m.table.WithRows(rows)
+	if len(rows) > 0 {
+		m.table = m.table.WithHighlightedRow(m.selectedProcIdx)
+	}
+}
+
+// showProjectProcess switches to the process view with the first Claude instance
+// working in the project selected, or notes that none is
+func (m *Model) showProjectProcess(p ProjectDir) tea.Cmd {
+	pids := m.runningPIDs(p)
+	if len(pids) == 0 {
+		m.projectNote = "No Claude process is running in this project"
+		return nil
+	}
+	m.selectedProcIdx = slices.IndexFunc(m.processes, func(proc types.ClaudeProcess) bool { return proc.PID == pids[0] })
+	m.viewMode = ViewProcesses
+	m.updateTable()
+	return tea.Batch(m.refreshProcesses(), m.saveView("processes"))
+}
 
-	for i, proc := range m.processes {
-		cpu := "..."
-		if proc.CPUPercent > 0 {
-			cpu = formatCPU(proc.CPUPercent)
+// setProcesses replaces the process list while keeping the same process selected
+// If the selected process exited, the selection moves to its nearest neighbor
+func (m *Model) setProcesses(processes []types.ClaudeProcess) {
+	var selectedPID int32
+	hadSelection := m.selectedProcIdx >= 0 && m.selectedProcIdx < len(m.processes)
+	if hadSelection {
+		selectedPID = m.processes[m.selectedProcIdx].PID
+	}
+
+	m.processes = processes
+
+	if hadSelection {
+		for i, proc := range processes {
+			if proc.PID == selectedPID {
+				m.selectedProcIdx = i
+				return
+			}
 		}
+		m.processNote = fmt.Sprintf("Process %d exited", selectedPID)
+	}
 
-		rows[i] = table.NewRow(table.RowData{
-			"pid":     formatPID(proc.PID),
-			"cpu":     cpu,
-			"mem":     formatMemory(proc.MemoryMB),
-			"uptime":  formatUptime(proc.Uptime),
-			"workdir": truncatePathForDisplay(proc.WorkingDir),
-			"cmd":     truncateCommand(proc.Command),
-		})
+	// Clamp to the nearest remaining row
+	if m.selectedProcIdx >= len(processes) {
+		m.selectedProcIdx = len(processes) - 1
 	}
+	if m.selectedProcIdx < 0 {
+		m.selectedProcIdx = 0
+	}
+}
 
-	m.table = m.table.WithRows(rows)
+// setSessions replaces the session list while keeping the same session selected, as
+// when the watcher reports sessions created or removed. If the selected session is gone,
+// the selection stays on the same row.
+func (m *Model) setSessions(sessions []SessionInfo) {
+	var selectedPath string
+	if m.selectedSessionIdx >= 0 && m.selectedSessionIdx < len(m.sessions) {
+		selectedPath = m.sessions[m.selectedSessionIdx].Path
+	}
+
+	rates := make(map[string]monitor.TokenRate)
+	for _, session := range m.allSessions {
+		if !session.Rate.Idle() {
+			rates[session.Path] = session.Rate // Kept until the next sample
+		}
+	}
+
+	m.allSessions = sessions
+	for i := range m.allSessions {
+		m.allSessions[i].Rate = rates[m.allSessions[i].Path]
+	}
+	linkSidechains(m.allSessions)
+	titleSessions(m.allSessions, m.cfg.Sessions.TitleFrom)
+	m.applySessionFilter()
+	m.updateSessionTable()
+
+	if i := slices.IndexFunc(m.sessions, func(s SessionInfo) bool { return s.Path == selectedPath }); selectedPath != "" && i >= 0 {
+		m.selectedSessionIdx = i
+	} else if m.selectedSessionIdx >= len(m.sessions) {
+		m.selectedSessionIdx = len(m.sessions) - 1
+	}
+	if m.selectedSessionIdx < 0 {
+		m.selectedSessionIdx = 0
+	}
+	if m.selectedSessionIdx < len(m.sessions) {
+		m.sessionTable = m.sessionTable.WithHighlightedRow(m.selectedSessionIdx)
+	}
+}
+
+// setProjects replaces the project list while keeping the same project selected
+func (m *Model) setProjects(projects []ProjectDir) {
+	var selectedPath string
+	if m.selectedProjIdx >= 0 && m.selectedProjIdx < len(m.projects) {
+		selectedPath = m.projects[m.selectedProjIdx].Path
+	}
+	m.projects = projects
+
+	if i := slices.IndexFunc(projects, func(p ProjectDir) bool { return p.Path == selectedPath }); selectedPath != "" && i >= 0 {
+		m.selectedProjIdx = i
+	} else if m.selectedProjIdx >= len(projects) {
+		m.selectedProjIdx = len(projects) - 1
+	}
+	if m.selectedProjIdx < 0 {
+		m.selectedProjIdx = 0
+	}
 }
 
 // updateSessionTable rebuilds the session table with current session data
 func (m *Model) updateSessionTable() {
-	// Sort sessions by last message time (newest first)
-	sort.Slice(m.sessions, func(i, j int) bool {
-		return m.sessions[i].LastMessageTime > m.sessions[j].LastMessageTime
-	})
+	// Sort sessions by last message time (newest first), nesting side-chains under their parent
+	m.sessions = nestSidechains(m.sessions, m.expandedSessions)
 
 	// Recreate session table with dynamic widths based on current data
-	m.sessionTable = CreateSessionTableWithDynamicWidths(m.termWidth, m.sessions)
+	m.sessionTable = CreateSessionTableWithDynamicWidths(m.termWidth, m.sessions, m.cfg.Sessions.ShowChanges)
+	if m.previewShown() {
+		// The preview pane takes the right half; the columns that do not fit scroll
+		m.sessionTable = m.sessionTable.WithMaxTotalWidth(m.sessionTableWidth())
+	}
 
 	rows := make([]table.Row, len(m.sessions))
 
@@ -458,10 +1522,13 @@ func (m *Model) updateSessionTable() {
 			versionStr = "v" + session.Version
 		}
 
-		// Format git branch (show as "main" or "-" if empty)
-		gitStr := session.GitBranch
-		if gitStr == "" {
-			gitStr = "-"
+		// Format git branch (show as "main", "main +1" after switching or "-" if empty)
+		gitStr := session.BranchLabel()
+
+		// Format model label (show as "opus+haiku" or "-" if unknown)
+		modelStr := session.Model
+		if modelStr == "" {
+			modelStr = "-"
 		}
 
 		// Format last message time (show as "HH:MM" or "-" if empty)
@@ -473,40 +1540,122 @@ func (m *Model) updateSessionTable() {
 		// Format last message preview
 		lastMsgPreview := "-"
 		if session.LastMessage != "" {
-			lastMsgPreview = session.LastMessage
-			if len(lastMsgPreview) > 50 {
-				lastMsgPreview = lastMsgPreview[:47] + "…"
+			lastMsgPreview = truncateText(m.shownText(session.LastMessage), 50)
+		}
+
+		// Badge sessions that ran in plan, auto-accept or bypass mode
+		if badge := monitor.PermissionModeLabel(session.PermissionMode); badge != "" {
+			lastMsgPreview = badge + " · " + lastMsgPreview
+		}
+
+		// Flag sessions whose tool calls wrote outside the repository, for audits
+		if len(session.OutsideWrites) > 0 {
+			lastMsgPreview = "⚠ wrote outside workdir · " + lastMsgPreview
+		}
+
+		// Point to the other projects holding a copy of the session
+		if len(session.Copies) > 0 {
+			dup := "dup ↔ " + m.shownPath(session.Copies[0])
+			if len(session.Copies) > 1 {
+				dup += fmt.Sprintf(" +%d", len(session.Copies)-1)
 			}
+			lastMsgPreview = dup + " · " + lastMsgPreview
+		}
+
+		// Flag sessions that are close to auto-compaction
+		if session.ContextUsage >= m.cfg.Context.WarnAt {
+			lastMsgPreview = fmt.Sprintf("⚠ %.0f%% context · %s", session.ContextUsage*100, lastMsgPreview)
 		}
 
-		// Format tokens (show as "input/output" or "-" if none)
-		tokensStr := "-"
-		if session.TotalTokens > 0 {
-			if session.InputTokens > 0 || session.OutputTokens > 0 {
-				tokensStr = fmt.Sprintf("%d/%d", session.InputTokens, session.OutputTokens)
-			} else {
-				tokensStr = fmt.Sprintf("%d", session.TotalTokens)
+		// Side-chain tokens count toward their parent when side-chains are included
+		tokens := session.Tokens
+		if m.includeSidechains {
+			tokens.Merge(session.SidechainTokens)
+		}
+		tokensStr := tokensColumn(tokens)
+		filesStr, linesStr := changesColumns(session.DiffStat)
+
+		// Mark side-chains: nested ones are indented under their parent, orphans keep the marker
+		switch {
+		case session.LoadError != "":
+			lastMsgPreview = truncateText(fmt.Sprintf("⚠ %s, %s", formatFileSize(session.Size), session.LoadError), 60)
+		case session.IsAgent:
+			lastMsgPreview = "🤖 " + lastMsgPreview
+		case session.ParentID != "":
+			lastMsgPreview = "  └ 🔀 " + lastMsgPreview
+		case session.IsSidechain:
+			lastMsgPreview = "🔀 " + lastMsgPreview
+		case session.Sidechains > 0:
+			marker := "▸"
+			if m.expandedSessions[session.ID] {
+				marker = "▾"
 			}
+			lastMsgPreview = fmt.Sprintf("%s %d side-chains · %s", marker, session.Sidechains, lastMsgPreview)
 		}
 
-		// Mark sidechain with indicator
-		titleStr := truncatePath(session.Title, 36)
-		if session.IsSidechain {
-			titleStr = "🔀 " + titleStr
+		// Sessions that skipped all permission prompts or wrote outside the repository
+		// stand out for review
+		var lastMessage any = lastMsgPreview
+		if monitor.RanInBypassMode(session.PermissionModes) || len(session.OutsideWrites) > 0 {
+			lastMessage = table.NewStyledCell(lastMsgPreview, lipgloss.NewStyle().Foreground(render.ActiveTheme().Alert))
 		}
 
-		rows[i] = table.NewRow(table.RowData{
+		row := table.NewRow(table.RowData{
+			"project":     truncatePath(m.shownPath(session.Project), recentProjectWidth),
+			"title":       truncateText(m.shownText(session.Title), sessionTitleWidth),
 			"version":     versionStr,
 			"gitbranch":   gitStr,
 			"lastmsgtime": lastMsgTimeStr,
 			"tokens":      tokensStr,
+			"context":     render.ContextCell(session.ContextUsage),
+			"rate":        render.RateCell(session.Rate),
+			"model":       modelStr,
 			"started":     session.Started,
 			"duration":    session.Duration,
-			"lastmessage": lastMsgPreview,
+			"files":       filesStr,
+			"lines":       linesStr,
+			"lastmessage": lastMessage,
 		})
+		if session.IsAgent {
+			row = row.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("8")))
+		}
+		rows[i] = row
 	}
 
 	m.sessionTable = m.sessionTable.WithRows(rows)
+	if m.selectedSessionIdx >= 0 && m.selectedSessionIdx < len(rows) {
+		m.sessionTable = m.sessionTable.WithHighlightedRow(m.selectedSessionIdx)
+	}
+}
+
+// openSelectedSession switches to the detail view of the selected session and starts loading it
+func (m *Model) openSelectedSession() tea.Cmd {
+	m.viewMode = ViewSessionDetail
+	m.messageFilter = FilterAll // Reset filter when opening new session
+	m.filterPreset = 0
+	m.selectedSession = &m.sessions[m.selectedSessionIdx]
+	m.sessionStats = nil
+	m.messageError = ""
+	return tea.Batch(m.loadSessionDetail(), m.recordVisit(m.selectedSession.Path))
+}
+
+// toggleSidechains expands or collapses the side-chains of the selected session,
+// keeping the selection on it
+func (m *Model) toggleSidechains() {
+	id := m.sessions[m.selectedSessionIdx].ID
+	if m.expandedSessions == nil {
+		m.expandedSessions = make(map[string]bool)
+	}
+	m.expandedSessions[id] = !m.expandedSessions[id]
+	m.applySessionFilter()
+	m.sessions = nestSidechains(m.sessions, m.expandedSessions)
+	for i, session := range m.sessions {
+		if session.ID == id {
+			m.selectedSessionIdx = i
+			break
+		}
+	}
+	m.updateSessionTable()
 }
 
 // updateProjectsTable rebuilds the projects table with current project data
@@ -516,6 +1665,9 @@ func (m *Model) updateProjectsTable() {
 	for i, proj := range m.projects {
 		modifiedStr := proj.Modified.Format("2006-01-02 15:04")
 		sessionsStr := fmt.Sprintf("%d", proj.Sessions)
+		if proj.Agents > 0 {
+			sessionsStr += fmt.Sprintf(" (+%d agents)", proj.Agents)
+		}
 
 		// Use DisplayName if available, otherwise use Name
 		displayName := proj.DisplayName
@@ -524,9 +1676,11 @@ func (m *Model) updateProjectsTable() {
 		}
 
 		rows[i] = table.NewRow(table.RowData{
-			"name":     truncatePath(displayName, 50),
+			"name":     truncatePath(m.shownPath(displayName), m.projectNameWidth),
 			"modified": modifiedStr,
 			"sessions": sessionsStr,
+			"running":  render.RunningCell(m.runningPIDs(proj)),
+			"prompt":   truncateText(m.shownText(proj.LastPrompt), m.projectPromptWidth),
 		})
 	}
 
@@ -545,39 +1699,439 @@ func (m *Model) updateMessageTable() {
 		return
 	}
 
-	// Filter messages based on current filter
-	var filteredMessages []monitor.Message
-	for _, msg := range stats.MessageHistory {
-		switch m.messageFilter {
-		case FilterUserOnly:
-			if msg.Type == "prompt" {
-				filteredMessages = append(filteredMessages, msg)
+	m.sessionTotals = m.totalsFor(stats)
+
+	// Filter messages based on current filter, keeping their position in the history
+	filtered := m.filteredIndices(stats)
+
+	// Update the filtered message count, tokens and cost
+	m.filteredMessageCount = len(filtered)
+	m.filteredTokens, m.filteredCost = monitor.TokenCounts{}, 0
+	for _, i := range filtered {
+		m.filteredTokens.Add(stats.MessageHistory[i])
+		m.filteredCost += pricing.MessageCost(&stats.MessageHistory[i])
+	}
+	m.resizeMessageViewport()
+
+	// Convert messages to MessageRow with full token/cost data
+	if m.groupByTool {
+		m.messages = m.buildToolRows(stats, filtered)
+	} else if m.groupByTurn {
+		m.messages = m.buildTurnRows(stats, filtered)
+	} else {
+		m.messages = insertBranchSwitches(stats, buildMessageRows(stats, filtered))
+	}
+
+	// Running totals follow the whole session in chronological order, whatever the
+	// filter and sort order show
+	cumulative := pricing.CumulativeCosts(stats)
+	for i, row := range m.messages {
+		switch {
+		case row.IsTurnHeader && row.Turn.End > 0 && row.Turn.End <= len(cumulative):
+			m.messages[i].CumulativeCost = cumulative[row.Turn.End-1]
+		case !row.IsTurnHeader && row.HistoryIdx >= 0 && row.HistoryIdx < len(cumulative):
+			m.messages[i].CumulativeCost = cumulative[row.HistoryIdx]
+		}
+	}
+
+	// Update the table for compatibility (it's used for selection and navigation)
+	rows := make([]table.Row, len(m.messages))
+	for i, row := range m.messages {
+		// Create display text for table (minimal, cards will be rendered separately)
+		roleStr := ""
+		if row.Role == "user" {
+			roleStr = "👤"
+		} else if row.Role == "assistant" {
+			roleStr = "🤖"
+		}
+
+		if row.IsTurnHeader {
+			roleStr = "↳"
+		}
+		if row.BranchSwitch != "" {
+			roleStr = "⎇"
+		}
+		if row.ToolGroup != "" {
+			roleStr = "▤"
+		}
+
+		// Truncate content for list display
+		content := truncateText(row.Content, 70)
+
+		rows[i] = table.NewRow(table.RowData{
+			"role":    roleStr,
+			"content": content,
+			"time":    row.Time,
+		})
+	}
+
+	m.messageTable = m.messageTable.WithRows(rows)
+
+	// Render message cards and set viewport content
+	m.refreshMessageCards()
+}
+
+// copySessionSummary copies the session's summary line, formatted with the configured
+// template, to the clipboard and notes the result in the status line
+func (m *Model) copySessionSummary(stats *monitor.SessionStats) {
+	line, err := render.SummaryLine(m.cfg.Summary.Template, m.sessionSummaryData(stats))
+	if err != nil {
+		m.recordError("copy summary", err)
+		m.sessionNote = "✗ " + err.Error()
+		return
+	}
+	m.sessionNote = m.copyText(line)
+}
+
+// exportMessages writes the messages passing the current filter, or with selected only
+// the selected message (with the tool call or result it pairs with) or the filtered
+// messages of the selected turn or tool section, to a file in the configured directory and format
+func (m *Model) exportMessages(stats *monitor.SessionStats, selected bool) tea.Cmd {
+	format, err := export.ParseFormat(m.cfg.Export.Format)
+	if err != nil {
+		m.sessionNote = "✗ " + err.Error()
+		return nil
+	}
+
+	include := make(map[int]bool)
+	filter := m.filterDescription()
+	preset, hasPreset := m.activePreset()
+	name := monitor.SessionFileID(stats.FilePath) + "-" + time.Now().Format("20060102-150405")
+	switch {
+	case !selected:
+		for _, i := range m.filteredIndices(stats) {
+			include[i] = true
+		}
+	case m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(m.messages) && m.messages[m.selectedMessageIdx].IsTurnHeader:
+		turn := m.messages[m.selectedMessageIdx].Turn
+		for _, i := range m.filteredIndices(stats) {
+			if i >= turn.Start && i < turn.End {
+				include[i] = true
+			}
+		}
+		filter = strings.TrimSuffix(fmt.Sprintf("turn %d · %s", turn.Index, filter), " · ")
+		name += fmt.Sprintf("-turn%d", turn.Index)
+	case m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(m.messages) && m.messages[m.selectedMessageIdx].ToolGroup != "":
+		tool := m.messages[m.selectedMessageIdx].ToolGroup
+		for _, i := range m.filteredIndices(stats) {
+			if stats.MessageHistory[i].ToolName == tool {
+				include[i] = true
 			}
-		case FilterAssistantOnly:
-			if msg.Type == "assistant_response" || msg.Type == "tool_result" {
-				filteredMessages = append(filteredMessages, msg)
+		}
+		filter = fmt.Sprintf("%s calls · %s", tool, filter)
+		name += "-" + strings.ToLower(tool)
+	default:
+		if m.messageAtRow(m.selectedMessageIdx) == nil {
+			m.sessionNote = "Select a message to export"
+			return nil
+		}
+		h := m.messages[m.selectedMessageIdx].HistoryIdx
+		include[h] = true
+		hasPreset = false // The paired message is included whether or not it matches
+		filter = fmt.Sprintf("message #%d", h+1)
+		if paired := pairedToolMessage(stats, h); paired >= 0 {
+			include[paired] = true
+			filter += fmt.Sprintf(" with #%d", paired+1)
+		}
+		name += fmt.Sprintf("-msg%d", h+1)
+	}
+	if len(include) == 0 {
+		m.sessionNote = "✗ No messages to export"
+		return nil
+	}
+
+	opts := export.Options{
+		Cost:    pricing.MessageCost,
+		Include: func(i int) bool { return include[i] },
+		Filter:  filter,
+	}
+	if hasPreset {
+		opts.Preset = &preset
+	}
+	if m.groupByTool && !selected {
+		// Keep the sections of the list
+		opts.Group = func(msg *monitor.Message) string { return msg.ToolName }
+		opts.GroupBy = "tool"
+	}
+	path := filepath.Join(m.cfg.Export.Dir, name+format.Ext)
+	write := func() tea.Msg {
+		doc := export.Build(stats, opts)
+		if len(doc.Messages) == 0 {
+			return exportedMsg{path: path, err: errors.New("no messages to export (only messages from the first prompt on belong to a turn)")}
+		}
+		var buf bytes.Buffer
+		if err := export.Write(&buf, doc, format); err != nil {
+			return exportedMsg{path: path, err: err}
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return exportedMsg{path: path, err: err}
 			}
-		default:
-			filteredMessages = append(filteredMessages, msg)
 		}
+		if err := fsutil.WriteAtomic(path, buf.Bytes(), 0644); err != nil {
+			return exportedMsg{path: path, err: err}
+		}
+		return exportedMsg{path: path, messages: len(doc.Messages)}
+	}
+	if _, err := os.Stat(path); err == nil {
+		m.askConfirmation(confirmation{
+			message:     "Overwrite " + path + "?",
+			destructive: true,
+			onConfirm:   func(*Model) tea.Cmd { return write },
+		})
+		return nil
+	}
+	return write
+}
+
+// filterDescription describes the active message filter and preset for export headers,
+// or returns "" when all messages are shown
+func (m Model) filterDescription() string {
+	var parts []string
+	switch m.messageFilter {
+	case FilterUserOnly:
+		parts = append(parts, "user prompts")
+	case FilterAssistantOnly:
+		parts = append(parts, "Claude responses")
+	case FilterDenied:
+		parts = append(parts, "denied tool calls")
+	case FilterRefusals:
+		parts = append(parts, "refusals")
+	case FilterTools:
+		parts = append(parts, "tool calls")
+	}
+	if preset, ok := m.activePreset(); ok {
+		parts = append(parts, "preset "+preset.Name)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// pairedToolMessage returns the history index of the tool result answering the tool call
+// at h, or of the tool call the result at h answers, or -1. Messages carry no tool use
+// IDs, so the nearest counterpart within the same turn is taken.
+func pairedToolMessage(stats *monitor.SessionStats, h int) int {
+	history := stats.MessageHistory
+	switch msg := &history[h]; {
+	case msg.Type == "assistant_response" && msg.ToolName != "":
+		for i := h + 1; i < len(history) && history[i].Type != "prompt"; i++ {
+			if history[i].Type == "tool_result" {
+				return i
+			}
+		}
+	case msg.Type == "tool_result":
+		for i := h - 1; i >= 0 && history[i].Type != "prompt"; i-- {
+			if history[i].Type == "assistant_response" && history[i].ToolName != "" {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// copyText copies text to the clipboard, returning the confirmation for the status line
+func (m *Model) copyText(text string) string {
+	m.clipboard(text)
+	return "✓ Copied: " + text
+}
+
+// copyProjectPath copies the original path of the selected project, or its encoded
+// directory under ~/.claude/projects, and notes the result in the status line
+func (m *Model) copyProjectPath(encoded bool) {
+	dir, original, name := m.projectStatsPath, m.projectStatsOriginal, m.projectStatsName
+	if m.viewMode == ViewProjects {
+		if m.selectedProjIdx < 0 || m.selectedProjIdx >= len(m.projects) {
+			return
+		}
+		p := m.projects[m.selectedProjIdx]
+		dir, original, name = p.Path, p.OriginalPath, p.DisplayName
+	}
+	switch {
+	case encoded:
+		m.projectNote = m.copyText(dir)
+	case original != "":
+		m.projectNote = m.copyText(original)
+	default:
+		// Without a sessions index only the lossy decoded name is known
+		m.projectNote = m.copyText(name) + " (decoded from the directory name, may be inexact)"
+	}
+}
+
+// sessionSummaryData collects the fields available to the summary template
+func (m Model) sessionSummaryData(stats *monitor.SessionStats) render.SummaryData {
+	cost := pricing.SessionCost(stats)
+	d := render.SummaryData{
+		Duration:  formatSessionDuration(stats.Duration),
+		Messages:  stats.TotalMessages,
+		Cost:      render.FormatCost(cost, render.TotalPrecision),
+		CostUSD:   cost,
+		Branch:    "-",
+		Version:   stats.ClaudeVersion,
+		Started:   stats.CreatedAt,
+		SessionID: monitor.SessionFileID(stats.FilePath),
+		Path:      stats.FilePath,
+	}
+	var models []string
+	seen := make(map[string]bool)
+	for _, msg := range stats.MessageHistory {
+		switch msg.Type {
+		case "prompt":
+			d.Prompts++
+		}
+		if msg.GitBranch != "" {
+			d.Branch = msg.GitBranch // The branch the session ended on
+		}
+		if msg.Model != "" && !strings.HasPrefix(msg.Model, "<") && !seen[msg.Model] {
+			seen[msg.Model] = true
+			models = append(models, msg.Model)
+		}
+	}
+	if d.Branch == "-" && m.selectedSession != nil && m.selectedSession.GitBranch != "" {
+		d.Branch = m.selectedSession.GitBranch
+	}
+	d.TokenCount = stats.Tokens.Total()
+	d.Tokens = render.FormatTokenCount(d.TokenCount)
+	d.Model = monitor.ModelLabel(models)
+	return d
+}
+
+// calculateRatio calculates input/output ratio and output percentage
+func calculateRatio(inputTokens, outputTokens int) (ratio float64, outputPercent int) {
+	total := inputTokens + outputTokens
+	if total == 0 {
+		return 0, 0
+	}
+
+	if outputTokens == 0 {
+		return float64(inputTokens), 0
+	}
+	if inputTokens == 0 {
+		return 0, 100
+	}
+
+	ratio = float64(inputTokens) / float64(outputTokens)
+	outputPercent = (outputTokens * 100) / total
+
+	return ratio, outputPercent
+}
+
+// Helper functions for formatting
+
+// filteredIndices returns the MessageHistory indices that pass the current filter and
+// filter preset, in display order
+func (m *Model) filteredIndices(stats *monitor.SessionStats) []int {
+	var base config.Predicate
+	switch m.messageFilter {
+	case FilterUserOnly:
+		base.Role = "user"
+	case FilterAssistantOnly:
+		base.Role = "assistant"
+	case FilterDenied:
+		base.Permission = monitor.DecisionDenied
+	case FilterRefusals:
+		base.Refusal = true
+	case FilterTools:
+		base.Tool = "*"
+	}
+	preset, hasPreset := m.activePreset()
+
+	var indices []int
+	for i := range stats.MessageHistory {
+		msg := &stats.MessageHistory[i]
+		if !base.Match(msg) {
+			continue
+		}
+		if hasPreset && !preset.Match.Match(msg) {
+			continue
+		}
+		indices = append(indices, i)
 	}
 
 	// Reverse order if sorting newest first
 	if m.messageSortNewestFirst {
-		for i, j := 0, len(filteredMessages)-1; i < j; i, j = i+1, j-1 {
-			filteredMessages[i], filteredMessages[j] = filteredMessages[j], filteredMessages[i]
+		for i, j := 0, len(indices)-1; i < j; i, j = i+1, j-1 {
+			indices[i], indices[j] = indices[j], indices[i]
 		}
 	}
 
-	// Update the filtered message count
-	m.filteredMessageCount = len(filteredMessages)
+	return indices
+}
 
-	// Convert messages to MessageRow with full token/cost data
-	m.messages = make([]MessageRow, len(filteredMessages))
+// activePreset returns the filter preset applied in the session detail view, if any
+func (m Model) activePreset() (config.FilterPreset, bool) {
+	if m.filterPreset < 1 || m.filterPreset > len(m.cfg.Filters.Presets) {
+		return config.FilterPreset{}, false
+	}
+	return m.cfg.Filters.Presets[m.filterPreset-1], true
+}
+
+// applyFilterPreset applies the n-th filter preset on top of the role filter, or with n
+// 0 clears the active one, noting the outcome in the status line
+func (m *Model) applyFilterPreset(n int) {
+	presets := m.cfg.Filters.Presets
+	switch {
+	case n == 0 && m.filterPreset == 0:
+		if len(presets) == 0 {
+			m.sessionNote = "No filter presets; define them under filters.presets in the config"
+			return
+		}
+		names := make([]string, len(presets))
+		for i, p := range presets {
+			names[i] = fmt.Sprintf("%df %s", i+1, p.Name)
+		}
+		m.sessionNote = "Presets: " + strings.Join(names, ", ")
+		return
+	case n > len(presets):
+		m.sessionNote = fmt.Sprintf("✗ No filter preset %d (%d defined)", n, len(presets))
+		return
+	}
+
+	m.keepSelection(func() {
+		m.filterPreset = n
+		m.updateMessageTable()
+	})
+	if preset, ok := m.activePreset(); ok {
+		m.sessionNote = fmt.Sprintf("Preset %s: %d messages", preset.Name, m.filteredMessageCount)
+	} else {
+		m.sessionNote = fmt.Sprintf("Preset cleared: %d messages", m.filteredMessageCount)
+	}
+}
+
+// turnIndexByMessage maps every MessageHistory index to the index of its turn
+func turnIndexByMessage(stats *monitor.SessionStats) []int {
+	turnOf := make([]int, len(stats.MessageHistory))
+	for ti, turn := range stats.Turns {
+		for i := turn.Start; i < turn.End && i < len(turnOf); i++ {
+			turnOf[i] = ti
+		}
+	}
+	return turnOf
+}
+
+// messageContent is the text the card and table row of a message show. Tool calls on a
+// file name it, relative to the session's working directory where possible; the detail
+// view keeps the absolute path.
+func messageContent(msg monitor.Message) string {
+	if msg.ToolName == "" || msg.Content != "Called tool: "+msg.ToolName {
+		return msg.Content
+	}
+	path := monitor.ToolPath(msg.ToolInput)
+	if path == "" {
+		return msg.Content
+	}
+	return msg.Content + " " + monitor.DisplayPath(path, msg.WorkingDir)
+}
+
+// buildMessageRows converts the messages at the given history indices to card rows
+func buildMessageRows(stats *monitor.SessionStats, indices []int) []MessageRow {
+	turnOf := turnIndexByMessage(stats)
+	growth := monitor.ContextGrowth(stats.MessageHistory)
+	rows := make([]MessageRow, len(indices))
 
 	var prevTime time.Time
 
-	for i, msg := range filteredMessages {
+	for i, h := range indices {
+		msg := stats.MessageHistory[h]
+
 		// Calculate relative time
 		relativeTime := ""
 		if i > 0 && !prevTime.IsZero() {
@@ -596,198 +2150,577 @@ func (m *Model) updateMessageTable() {
 		prevTime = msg.Timestamp
 
 		// Calculate costs and efficiency metrics
-		cost, savings := calculateMessageCost(&msg)
+		c := pricing.PriceResponse(&msg)
+		cost, savings := c.Total(), c.Savings
 		ratio, outputPercent := calculateRatio(msg.InputTokens, msg.OutputTokens)
+		throughput, _ := msg.TokensPerSecond()
 
-		m.messages[i] = MessageRow{
+		rows[i] = MessageRow{
 			Index:            i + 1,
 			Role:             msg.Role,
-			Content:          msg.Content,
+			Content:          messageContent(msg),
 			Time:             msg.Timestamp.Format(time.RFC3339Nano),
 			Model:            msg.Model,
 			InputTokens:      msg.InputTokens,
 			OutputTokens:     msg.OutputTokens,
 			CacheCreation:    msg.CacheCreation,
 			CacheRead:        msg.CacheRead,
+			EstimatedTokens:  msg.EstimatedTokens,
+			PastedBytes:      msg.PastedBytes,
+			ContextUsage:     msg.ContextUsage(),
+			ContextGrowth:    growth[h],
+			Throughput:       throughput,
 			Cost:             cost,
 			RelativeTime:     relativeTime,
 			InputOutputRatio: ratio,
 			OutputPercentage: outputPercent,
 			CacheSavings:     savings,
 			UUID:             msg.UUID,
+			HistoryIdx:       h,
+			TurnIdx:          turnOf[h],
+
+			PermissionDecision: msg.PermissionDecision,
+			Refusal:            msg.Refusal,
+			ToolResult:         msg.Type == "tool_result",
+			ToolCalls:          msg.Calls(),
 		}
 	}
 
-	// Update the table for compatibility (it's used for selection and navigation)
-	rows := make([]table.Row, len(m.messages))
-	for i, row := range m.messages {
-		// Create display text for table (minimal, cards will be rendered separately)
-		roleStr := ""
-		if row.Role == "user" {
-			roleStr = "👤"
-		} else if row.Role == "assistant" {
-			roleStr = "🤖"
-		}
+	return rows
+}
 
-		// Truncate content for list display
-		content := strings.ReplaceAll(row.Content, "\n", " ")
-		if len(content) > 70 {
-			content = content[:67] + "..."
+// insertBranchSwitches adds a divider row between neighboring message rows on different
+// git branches, whichever the sort order. Messages without a branch count as being on
+// the branch of the message before them in time.
+func insertBranchSwitches(stats *monitor.SessionStats, rows []MessageRow) []MessageRow {
+	if len(stats.Branches) < 2 {
+		return rows
+	}
+	branchOf := make([]string, len(stats.MessageHistory))
+	branch := ""
+	for i, msg := range stats.MessageHistory {
+		if msg.GitBranch != "" {
+			branch = msg.GitBranch
 		}
-
-		rows[i] = table.NewRow(table.RowData{
-			"role":    roleStr,
-			"content": content,
-			"time":    row.Time,
-		})
+		branchOf[i] = branch
 	}
 
-	m.messageTable = m.messageTable.WithRows(rows)
-
-	// Render message cards and set viewport content
-	cardsContent := m.renderMessageCards()
-	m.messageViewport.SetContent(cardsContent)
+	out := make([]MessageRow, 0, len(rows))
+	prev, prevIdx := "", -1 // Branch of the last row that had one, and its history index
+	for _, row := range rows {
+		branch := branchOf[row.HistoryIdx]
+		if branch == "" {
+			out = append(out, row)
+			continue
+		}
+		if prev != "" && branch != prev {
+			from, to, at := prev, branch, stats.MessageHistory[row.HistoryIdx].Timestamp
+			if prevIdx > row.HistoryIdx { // Newest first: the row above switched from this one
+				from, to, at = to, from, stats.MessageHistory[prevIdx].Timestamp
+			}
+			out = append(out, MessageRow{
+				Index:        row.Index,
+				Time:         at.Format(time.RFC3339Nano),
+				HistoryIdx:   -1,
+				TurnIdx:      row.TurnIdx,
+				BranchSwitch: to,
+				BranchFrom:   from,
+			})
+		}
+		prev, prevIdx = branch, row.HistoryIdx
+		out = append(out, row)
+	}
+	return out
 }
 
-// calculateMessageCost calculates the cost for a single message
-func calculateMessageCost(msg *monitor.Message) (cost float64, savings float64) {
-	if msg.Type != "assistant_response" {
-		return 0, 0
+// buildTurnRows builds grouped rows: a header card per turn, followed by the turn's
+// filtered messages when the turn is expanded. Turns without matching messages are skipped.
+func (m *Model) buildTurnRows(stats *monitor.SessionStats, filtered []int) []MessageRow {
+	turnOf := turnIndexByMessage(stats)
+	members := make(map[int][]int)
+	for _, h := range filtered {
+		members[turnOf[h]] = append(members[turnOf[h]], h)
 	}
 
-	// Input cost
-	inputCost := float64(msg.InputTokens) * InputTokenCost
-	cacheCreationCost := float64(msg.CacheCreation) * CacheCreationTokenCost
-	cacheReadCost := float64(msg.CacheRead) * CacheReadTokenCost
-	outputCost := float64(msg.OutputTokens) * OutputTokenCost
+	order := make([]int, len(stats.Turns))
+	for i := range order {
+		order[i] = i
+		if m.messageSortNewestFirst {
+			order[i] = len(order) - 1 - i
+		}
+	}
+
+	var rows []MessageRow
+	seq := 0 // Messages in the turns so far, to number messages across the whole list
+	for _, ti := range order {
+		if len(members[ti]) == 0 {
+			continue
+		}
+		turn := stats.Turns[ti]
+		turnMessages := turn.Messages(stats.MessageHistory)
 
-	cost = inputCost + cacheCreationCost + cacheReadCost + outputCost
+		var cost float64
+		for i := range turnMessages {
+			cost += pricing.MessageCost(&turnMessages[i])
+		}
+		content := ""
+		if len(turnMessages) > 0 {
+			content = turnMessages[0].Content
+		}
 
-	// Cache savings (what it would have cost without cache hits)
-	if msg.CacheRead > 0 {
-		// Cache hits would have cost regular input rate
-		normalCacheReadCost := float64(msg.CacheRead) * InputTokenCost
-		savings = normalCacheReadCost - cacheReadCost
+		expanded := m.expandedTurns[turn.Index]
+		rows = append(rows, MessageRow{
+			Content:      content,
+			Time:         turn.StartTime.Format(time.RFC3339Nano),
+			Cost:         cost,
+			HistoryIdx:   -1,
+			TurnIdx:      ti,
+			IsTurnHeader: true,
+			Turn:         turn,
+			TurnExpanded: expanded,
+		})
+		if expanded {
+			turnRows := buildMessageRows(stats, members[ti])
+			for i := range turnRows {
+				turnRows[i].Index += seq
+			}
+			rows = append(rows, turnRows...)
+		}
+		seq += len(members[ti])
 	}
+	return rows
+}
 
-	return cost, savings
+// buildToolRows builds rows sectioned by tool: a header card per tool, the tool with
+// the most calls first and ties by name, followed by the tool's filtered calls in list
+// order unless its section is collapsed
+func (m *Model) buildToolRows(stats *monitor.SessionStats, filtered []int) []MessageRow {
+	tools, members := toolSections(stats, filtered)
+
+	var rows []MessageRow
+	seq := 0 // Calls in the sections so far, to number calls across the whole list
+	for _, tool := range tools {
+		var cost float64
+		var first, last time.Time
+		for _, h := range members[tool] {
+			msg := &stats.MessageHistory[h]
+			cost += pricing.MessageCost(msg)
+			if first.IsZero() || msg.Timestamp.Before(first) {
+				first = msg.Timestamp
+			}
+			if msg.Timestamp.After(last) {
+				last = msg.Timestamp
+			}
+		}
+		expanded := !m.collapsedTools[tool]
+		rows = append(rows, MessageRow{
+			Time:              first.Format(time.RFC3339Nano),
+			Cost:              cost,
+			HistoryIdx:        -1,
+			ToolGroup:         tool,
+			ToolGroupCalls:    len(members[tool]),
+			ToolGroupFirst:    first,
+			ToolGroupLast:     last,
+			ToolGroupExpanded: expanded,
+		})
+		if expanded {
+			toolRows := buildMessageRows(stats, members[tool])
+			for i := range toolRows {
+				toolRows[i].Index += seq
+			}
+			rows = append(rows, toolRows...)
+		}
+		seq += len(members[tool])
+	}
+	return rows
 }
 
-// calculateRatio calculates input/output ratio and output percentage
-func calculateRatio(inputTokens, outputTokens int) (ratio float64, outputPercent int) {
-	total := inputTokens + outputTokens
-	if total == 0 {
-		return 0, 0
+// toolSections sections the filtered messages by the tool they call, returning the
+// tools, the one with the most calls first and ties by name, and each tool's messages
+// in list order
+func toolSections(stats *monitor.SessionStats, filtered []int) ([]string, map[string][]int) {
+	members := make(map[string][]int)
+	var tools []string
+	for _, h := range filtered {
+		tool := stats.MessageHistory[h].ToolName
+		if members[tool] == nil {
+			tools = append(tools, tool)
+		}
+		members[tool] = append(members[tool], h)
 	}
+	slices.SortFunc(tools, func(a, b string) int {
+		if c := cmp.Compare(len(members[b]), len(members[a])); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return tools, members
+}
 
-	if outputTokens == 0 {
-		return float64(inputTokens), 0
+// toggleToolGroup expands or collapses the tool section at the given header row,
+// keeping it selected
+func (m *Model) toggleToolGroup(idx int) {
+	tool := m.messages[idx].ToolGroup
+	if m.collapsedTools == nil {
+		m.collapsedTools = make(map[string]bool)
 	}
-	if inputTokens == 0 {
-		return 0, 100
+	m.collapsedTools[tool] = !m.collapsedTools[tool]
+	m.updateMessageTable()
+	for i, row := range m.messages {
+		if row.ToolGroup == tool {
+			m.selectedMessageIdx = i
+			break
+		}
 	}
+	m.refreshMessageCards()
+	m.scrollToSelection()
+}
 
-	ratio = float64(inputTokens) / float64(outputTokens)
-	outputPercent = (outputTokens * 100) / total
+// toolGroupJumpTarget returns the row of the next (dir > 0) or previous (dir < 0) tool
+// section header, or the current row if there is none
+func (m *Model) toolGroupJumpTarget(dir int) int {
+	for j := m.selectedMessageIdx + dir; j >= 0 && j < len(m.messages); j += dir {
+		if m.messages[j].ToolGroup != "" {
+			return j
+		}
+	}
+	return m.selectedMessageIdx
+}
 
-	return ratio, outputPercent
+// messageAtRow returns the session message shown at the given card row, or nil for
+// turn and tool headers, branch dividers and out-of-range rows
+func (m *Model) messageAtRow(idx int) *monitor.Message {
+	stats, ok := m.sessionStats.(*monitor.SessionStats)
+	if !ok || idx < 0 || idx >= len(m.messages) || m.messages[idx].IsTurnHeader {
+		return nil
+	}
+	h := m.messages[idx].HistoryIdx
+	if h < 0 || h >= len(stats.MessageHistory) {
+		return nil
+	}
+	return &stats.MessageHistory[h]
 }
 
-// Helper functions for formatting
+// toggleTurnExpanded expands or collapses the turn at the given header row, keeping it selected
+func (m *Model) toggleTurnExpanded(idx int) {
+	turn := m.messages[idx].Turn
+	if m.expandedTurns == nil {
+		m.expandedTurns = make(map[int]bool)
+	}
+	m.expandedTurns[turn.Index] = !m.expandedTurns[turn.Index]
+	m.updateMessageTable()
+	for i, row := range m.messages {
+		if row.IsTurnHeader && row.Turn.Index == turn.Index {
+			m.selectedMessageIdx = i
+			break
+		}
+	}
+	m.refreshMessageCards()
+	m.scrollToSelection()
+}
 
-// getFilteredMessages returns the messages filtered by current filter
-func (m *Model) getFilteredMessages(stats *monitor.SessionStats) []monitor.Message {
-	var filteredMessages []monitor.Message
-	for _, msg := range stats.MessageHistory {
-		switch m.messageFilter {
-		case FilterUserOnly:
-			if msg.Type == "prompt" {
-				filteredMessages = append(filteredMessages, msg)
-			}
-		case FilterAssistantOnly:
-			if msg.Type == "assistant_response" || msg.Type == "tool_result" {
-				filteredMessages = append(filteredMessages, msg)
-			}
-		default:
-			filteredMessages = append(filteredMessages, msg)
+// jumpToMostExpensiveTurn selects the first visible card of the session's costliest turn
+func (m *Model) jumpToMostExpensiveTurn() {
+	stats, ok := m.sessionStats.(*monitor.SessionStats)
+	if !ok {
+		return
+	}
+	target := stats.TurnStats(pricing.MessageCost).MostExpensive
+	if target < 0 {
+		return
+	}
+	for i, row := range m.messages {
+		if row.TurnIdx == target {
+			m.selectedMessageIdx = i
+			m.refreshMessageCards()
+			m.scrollToSelection()
+			m.messageError = fmt.Sprintf("Most expensive turn: #%d", stats.Turns[target].Index)
+			return
 		}
 	}
+	m.messageError = fmt.Sprintf("Most expensive turn #%d is hidden by the current filter", stats.Turns[target].Index)
+}
 
-	// Apply same sort order as displayed in message list
-	if m.messageSortNewestFirst {
-		// Reverse to show newest first
-		for i, j := 0, len(filteredMessages)-1; i < j; i, j = i+1, j-1 {
-			filteredMessages[i], filteredMessages[j] = filteredMessages[j], filteredMessages[i]
+// jumpToNextRefusal selects the next refusal the filter shows after the selected row,
+// wrapping around to the first
+func (m *Model) jumpToNextRefusal() {
+	var rows []int
+	for i := range m.messages {
+		if msg := m.messageAtRow(i); msg != nil && msg.Refusal {
+			rows = append(rows, i)
+		}
+	}
+	if len(rows) == 0 {
+		m.messageError = "No refusals shown"
+		return
+	}
+	n := 0
+	for n < len(rows) && rows[n] <= m.selectedMessageIdx {
+		n++
+	}
+	n %= len(rows)
+	m.selectedMessageIdx = rows[n]
+	m.refreshMessageCards()
+	m.scrollToSelection()
+	m.messageError = fmt.Sprintf("Refusal %d of %d", n+1, len(rows))
+}
+
+// jumpToTurnPercent selects the first row of the turn pct percent of the way through
+// the session (0 without a count), or of the nearest turn the filter leaves visible
+func (m *Model) jumpToTurnPercent(pct int) {
+	stats, ok := m.sessionStats.(*monitor.SessionStats)
+	if !ok || len(stats.Turns) == 0 || len(m.messages) == 0 {
+		return
+	}
+	target := min(min(pct, 100)*len(stats.Turns)/100, len(stats.Turns)-1)
+	distance := func(row MessageRow) int { return max(row.TurnIdx-target, target-row.TurnIdx) }
+	best := -1
+	for i, row := range m.messages {
+		if best < 0 || distance(row) < distance(m.messages[best]) {
+			best = i
 		}
 	}
+	m.selectedMessageIdx = best
+	m.refreshMessageCards()
+	m.scrollToSelection()
+	m.messageError = fmt.Sprintf("Turn #%d of %d", stats.Turns[m.messages[best].TurnIdx].Index, len(stats.Turns))
+}
 
-	return filteredMessages
+// detailStepTarget returns the row of the message the given number of messages after
+// (steps > 0) or before (steps < 0) the selected one, skipping turn headers and stopping
+// at the first or last message; it returns the selected row if there is none
+func (m *Model) detailStepTarget(steps int) int {
+	return m.stepTarget(steps, func(*monitor.Message) bool { return true })
 }
 
-func formatPID(pid int32) string {
-	return fmt.Sprintf("%d", pid)
+// toolCallStepTarget is detailStepTarget for tool calls, skipping all other messages
+func (m *Model) toolCallStepTarget(steps int) int {
+	return m.stepTarget(steps, func(msg *monitor.Message) bool { return msg.ToolName != "" })
 }
 
-func formatCPU(percent float64) string {
-	if percent > 99.9 {
-		return ">99%"
+// stepTarget returns the row the given number of matching messages after (steps > 0)
+// or before (steps < 0) the selected one, stopping at the first or last match
+func (m *Model) stepTarget(steps int, match func(*monitor.Message) bool) int {
+	dir := 1
+	if steps < 0 {
+		dir, steps = -1, -steps
+	}
+	target := m.selectedMessageIdx
+	for j := m.selectedMessageIdx + dir; j >= 0 && j < len(m.messages) && steps > 0; j += dir {
+		if msg := m.messageAtRow(j); msg != nil && match(msg) {
+			target = j
+			steps--
+		}
 	}
-	return fmt.Sprintf("%.1f%%", percent)
+	return target
 }
 
-func formatMemory(mb float64) string {
-	if mb > 1024 {
-		gb := mb / 1024
-		return fmt.Sprintf("%.2fG", gb)
+// toolResultsAtRow returns the result of each tool call the message at a card row
+// makes, whether or not the filter shows it; nil for calls with no result yet
+func (m *Model) toolResultsAtRow(idx int) []*monitor.Message {
+	stats, ok := m.sessionStats.(*monitor.SessionStats)
+	if !ok || m.messageAtRow(idx) == nil {
+		return nil
+	}
+	indices := stats.ToolResultsOf(m.messages[idx].HistoryIdx)
+	results := make([]*monitor.Message, len(indices))
+	for i, r := range indices {
+		if r >= 0 {
+			results[i] = &stats.MessageHistory[r]
+		}
 	}
-	return fmt.Sprintf("%.2fM", mb)
+	return results
 }
 
-func formatUptime(d time.Duration) string {
-	if d < 0 {
-		return "unknown"
+// maxCountPrefix caps count prefixes so a held-down digit key cannot overflow
+const maxCountPrefix = 999_999
+
+// digitKey reports whether the key is a single digit and which
+func digitKey(msg tea.KeyMsg) (int, bool) {
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 || msg.Runes[0] < '0' || msg.Runes[0] > '9' {
+		return 0, false
 	}
+	return int(msg.Runes[0] - '0'), true
+}
 
-	days := d / (24 * time.Hour)
-	d = d % (24 * time.Hour)
-	hours := d / time.Hour
-	d = d % time.Hour
-	minutes := d / time.Minute
+// updateJumpPrompt handles a key typed into the ":" prompt: digits, backspace, enter
+// to jump and esc to cancel
+func (m *Model) updateJumpPrompt(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "esc":
+		m.jumpPrompt = false
+	case "enter":
+		m.jumpPrompt = false
+		if n, err := strconv.Atoi(m.jumpInput); err == nil {
+			m.jumpToMessage(n)
+		}
+	case "backspace":
+		if m.jumpInput != "" {
+			m.jumpInput = m.jumpInput[:len(m.jumpInput)-1]
+		}
+	default:
+		if d, ok := digitKey(msg); ok && len(m.jumpInput) < len(strconv.Itoa(maxCountPrefix)) {
+			m.jumpInput += strconv.Itoa(d)
+		}
+	}
+}
 
-	if days > 0 {
-		return fmt.Sprintf("%dd %dh", days, hours)
+// jumpToMessage selects message n (1-based) of the current filtered ordering, clamped
+// to the messages there are. In grouped mode the turn or tool section holding it is
+// expanded.
+func (m *Model) jumpToMessage(n int) {
+	stats, ok := m.sessionStats.(*monitor.SessionStats)
+	if !ok || m.filteredMessageCount == 0 {
+		return
+	}
+	n = min(max(n, 1), m.filteredMessageCount)
+	indices := m.filteredIndices(stats)
+	if m.groupByTool {
+		// Messages are numbered section by section
+		tools, members := toolSections(stats, indices)
+		indices = indices[:0]
+		for _, tool := range tools {
+			indices = append(indices, members[tool]...)
+		}
 	}
-	if hours > 0 {
-		return fmt.Sprintf("%dh %dm", hours, minutes)
+	h := indices[n-1]
+	row := m.rowOfMessage(h)
+	if row < 0 && m.groupByTurn && len(stats.Turns) > 0 {
+		if m.expandedTurns == nil {
+			m.expandedTurns = make(map[int]bool)
+		}
+		m.expandedTurns[stats.Turns[turnIndexByMessage(stats)[h]].Index] = true
+		m.updateMessageTable()
+		row = m.rowOfMessage(h)
+	}
+	if row < 0 && m.groupByTool {
+		delete(m.collapsedTools, stats.MessageHistory[h].ToolName)
+		m.updateMessageTable()
+		row = m.rowOfMessage(h)
 	}
-	return fmt.Sprintf("%dm", minutes)
+	if row < 0 {
+		return
+	}
+	m.selectedMessageIdx = row
+	m.refreshMessageCards()
+	m.scrollToSelection()
+}
+
+// setMessageFilter switches the message filter, keeping the selected message selected
+// (see keepSelection)
+func (m *Model) setMessageFilter(f MessageFilter) {
+	m.keepSelection(func() {
+		m.messageFilter = f
+		m.groupByTool = m.groupByTool && f == FilterTools // Only the tool filter groups by tool
+		m.updateMessageTable()
+	})
 }
 
-func truncatePathForDisplay(path string) string {
-	return truncatePath(path, 30)
+// keepSelection runs rebuild, which changes the card rows, then selects the message
+// selected before: the same message if it is still shown (by UUID, or by position in
+// the history for messages without one), else the shown message nearest to it in time.
+// The selection is scrolled into view.
+func (m *Model) keepSelection(rebuild func()) {
+	selected := m.messageAtRow(m.selectedMessageIdx)
+	var h int
+	if selected != nil {
+		h = m.messages[m.selectedMessageIdx].HistoryIdx
+	}
+	rebuild()
+
+	if selected != nil {
+		best, bestDistance := -1, time.Duration(0)
+		for i := range m.messages {
+			msg := m.messageAtRow(i)
+			if msg == nil {
+				continue
+			}
+			if (selected.UUID != "" && msg.UUID == selected.UUID) || (selected.UUID == "" && m.messages[i].HistoryIdx == h) {
+				best = i
+				break
+			}
+			distance := msg.Timestamp.Sub(selected.Timestamp).Abs()
+			if best < 0 || distance < bestDistance {
+				best, bestDistance = i, distance
+			}
+		}
+		if best >= 0 {
+			m.selectedMessageIdx = best
+		}
+	}
+	m.selectedMessageIdx = max(min(m.selectedMessageIdx, len(m.messages)-1), 0)
+	m.refreshMessageCards()
+	m.scrollToSelection()
 }
 
-func truncateCommand(cmd string) string {
-	return truncatePath(cmd, 40)
+// rowOfMessage returns the card row showing MessageHistory[h], or -1 if none does
+func (m *Model) rowOfMessage(h int) int {
+	for i, row := range m.messages {
+		if !row.IsTurnHeader && row.HistoryIdx == h {
+			return i
+		}
+	}
+	return -1
 }
 
-func truncatePath(path string, maxLen int) string {
-	// Replace home directory with ~
-	if strings.HasPrefix(path, "/Users/") {
-		parts := strings.Split(path, "/")
-		if len(parts) > 2 {
-			path = "~" + "/" + strings.Join(parts[3:], "/")
+// turnJumpTarget returns the row of the first card of the next (dir > 0) or previous
+// (dir < 0) turn relative to the selected row, or the current row if there is none
+func (m *Model) turnJumpTarget(dir int) int {
+	idx := m.selectedMessageIdx
+	if idx < 0 || idx >= len(m.messages) {
+		return idx
+	}
+	current := m.messages[idx].TurnIdx
+
+	if dir > 0 {
+		for j := idx + 1; j < len(m.messages); j++ {
+			if m.messages[j].TurnIdx != current {
+				return j
+			}
+		}
+		return idx
+	}
+
+	// Step back to the start of the current turn's group, then to the start of the one before
+	j := idx
+	for j > 0 && m.messages[j-1].TurnIdx == current {
+		j--
+	}
+	if j == idx {
+		if j == 0 {
+			return idx
+		}
+		j--
+		prev := m.messages[j].TurnIdx
+		for j > 0 && m.messages[j-1].TurnIdx == prev {
+			j--
 		}
 	}
+	return j
+}
 
-	if len(path) <= maxLen {
-		return path
+// formatFileSize formats a file size as "0 B", "812 B", "12.4 KB" or "3.1 MB"
+func formatFileSize(bytes int64) string {
+	switch {
+	case bytes < 1024:
+		return fmt.Sprintf("%d B", bytes)
+	case bytes < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/1024/1024)
 	}
-	if maxLen < 10 {
-		return path[:maxLen]
+}
+
+func truncatePath(path string, maxLen int) string {
+	return monitor.TruncatePath(path, maxLen)
+}
+
+// truncateText flattens text to a single line at most maxLen terminal cells wide, ending
+// cut-off text with "…". Wide characters such as CJK and emoji take two cells.
+func truncateText(text string, maxLen int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if maxLen <= 0 {
+		return text
 	}
-	keepChars := maxLen - 3
-	keepLeft := (keepChars + 1) / 2
-	keepRight := keepChars - keepLeft
-	return path[:keepLeft] + "..." + path[len(path)-keepRight:]
+	return ansi.Truncate(text, maxLen, "…")
 }
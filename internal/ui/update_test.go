@@ -0,0 +1,2830 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/thieso2/promptwatch/internal/config"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/state"
+	"github.com/thieso2/promptwatch/internal/types"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+func procs(pids ...int32) []types.ClaudeProcess {
+	out := make([]types.ClaudeProcess, len(pids))
+	for i, pid := range pids {
+		out[i] = types.ClaudeProcess{PID: pid, WorkingDir: "/tmp"}
+	}
+	return out
+}
+
+// highlightedPID returns the PID stored in the process table's highlighted row
+func highlightedPID(m Model) int32 {
+	pid, _ := m.table.HighlightedRow().Data[render.ProcessPIDKey].(int32)
+	return pid
+}
+
+// TestProcessRefreshKeepsSelection tests that refreshes follow the selected PID rather than the row index
+func TestProcessRefreshKeepsSelection(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+
+	updated, _ := m.Update(processesMsg{processes: procs(100, 200, 300)})
+	m = updated.(Model)
+	m.selectedProcIdx = 2
+	m.updateTable()
+
+	// A process above the selection exits
+	updated, _ = m.Update(processesMsg{processes: procs(100, 300)})
+	m = updated.(Model)
+	if m.selectedProcIdx != 1 || highlightedPID(m) != 300 {
+		t.Fatalf("selection should follow PID 300: idx=%d highlighted=%d", m.selectedProcIdx, highlightedPID(m))
+	}
+	if m.processNote != "" {
+		t.Errorf("unexpected note %q while the selected process is still running", m.processNote)
+	}
+
+	// A new process appears above the selection
+	updated, _ = m.Update(processesMsg{processes: procs(50, 100, 300)})
+	m = updated.(Model)
+	if m.selectedProcIdx != 2 || highlightedPID(m) != 300 {
+		t.Fatalf("selection should follow PID 300: idx=%d highlighted=%d", m.selectedProcIdx, highlightedPID(m))
+	}
+
+	// The selected process itself exits: clamp to the nearest neighbor
+	updated, _ = m.Update(processesMsg{processes: procs(50, 100)})
+	m = updated.(Model)
+	if m.selectedProcIdx != 1 || highlightedPID(m) != 100 {
+		t.Fatalf("selection should clamp to PID 100: idx=%d highlighted=%d", m.selectedProcIdx, highlightedPID(m))
+	}
+	if m.processNote == "" {
+		t.Error("expected a status note when the selected process exits")
+	}
+
+	// Everything exits
+	updated, _ = m.Update(processesMsg{})
+	m = updated.(Model)
+	if m.selectedProcIdx != 0 {
+		t.Errorf("selection should reset to 0 for an empty list, got %d", m.selectedProcIdx)
+	}
+}
+
+// TestTokenRates tests that the busiest processes come first, keeping the selection,
+// and that the session list shows a rate column only while a session is live
+func TestTokenRates(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.termWidth, m.termHeight = 200, 40
+
+	updated, _ := m.Update(processesMsg{processes: procs(100, 200, 300)})
+	m = updated.(Model)
+	var hot, warm monitor.TokenRate
+	hot[monitor.RateMinutes-1] = 9000
+	warm[monitor.RateMinutes-2] = 1200
+	updated, _ = m.Update(processesMsg{processes: procs(100, 200, 300), rates: map[int32]monitor.TokenRate{200: warm, 300: hot}})
+	m = updated.(Model)
+	var order []int32
+	for _, proc := range m.processes {
+		order = append(order, proc.PID)
+	}
+	if !slices.Equal(order, []int32{300, 200, 100}) || highlightedPID(m) != 100 {
+		t.Errorf("order %v with PID %d highlighted, want 300, 200, 100 with 100 still selected", order, highlightedPID(m))
+	}
+	if view := m.View(); !strings.Contains(view, render.RateHeader) || !strings.Contains(view, "9.0k") {
+		t.Errorf("process view has no rate:\n%s", view)
+	}
+
+	dir := t.TempDir()
+	now := time.Now()
+	for name, at := range map[string]time.Time{"live.jsonl": now, "old.jsonl": now.Add(-2 * time.Hour)} {
+		line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"message":{"role":"assistant","content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":10,"output_tokens":4990}}}`+"\n", at.UTC().Format(time.RFC3339))
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.viewMode = ViewSessions
+	m.sessionSourceMode = ViewProjects
+	m.sessionsProject = ProjectDir{Path: dir}
+	updated, _ = m.Update(m.reloadSessions()())
+	m = updated.(Model)
+	if strings.Contains(m.View(), render.RateHeader) {
+		t.Error("session list shows a rate column before any session was sampled")
+	}
+	updated, _ = m.Update(m.sampleSessionRates()())
+	m = updated.(Model)
+	for _, session := range m.sessions {
+		want := 0
+		if strings.HasSuffix(session.Path, "live.jsonl") {
+			want = 5000
+		}
+		if got := session.Rate.Last(1); got != want {
+			t.Errorf("%s: %d tokens in the last minute, want %d", session.Path, got, want)
+		}
+	}
+	if view := m.View(); !strings.Contains(view, render.RateHeader) || !strings.Contains(view, "5.0k") {
+		t.Errorf("session list has no rate:\n%s", view)
+	}
+}
+
+// TestEnterOnGoneWorkDir tests that a process whose directory is gone explains why its
+// sessions cannot be opened instead of opening an empty list
+func TestEnterOnGoneWorkDir(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	processes := procs(100, 200)
+	processes[1].WorkingDir, processes[1].WorkDirGone = "/work/removed", true
+	updated, _ = m.Update(processesMsg{processes: processes})
+	m = updated.(Model)
+	if view := m.View(); !strings.Contains(view, "[gone: /work/removed]") {
+		t.Errorf("view does not mark the removed directory:\n%s", view)
+	}
+
+	m.selectedProcIdx = 1
+	updated, cmd := m.Update(key("enter"))
+	m = updated.(Model)
+	if m.viewMode != ViewProcesses || cmd != nil {
+		t.Errorf("enter on a process without a directory switched to view %v", m.viewMode)
+	}
+	if !strings.Contains(m.processNote, "gone") {
+		t.Errorf("note = %q, want an explanation", m.processNote)
+	}
+
+	m.selectedProcIdx = 0
+	updated, _ = m.Update(key("enter"))
+	if updated.(Model).viewMode != ViewSessions {
+		t.Error("enter on a normal process should open its sessions")
+	}
+}
+
+// TestToggleSessionless tests listing processes without sessions, from config and with "A"
+func TestToggleSessionless(t *testing.T) {
+	cfg := config.Default()
+	cfg.Processes.ShowSessionless = true
+	m := NewModel(time.Second, false).WithConfig(cfg)
+	defer m.Shutdown()
+	if !m.showSessionless {
+		t.Fatal("processes.showSessionless should start with session-less processes listed")
+	}
+
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	processes := procs(100, 200)
+	processes[1].NoSessions = true
+	updated, _ = m.Update(processesMsg{processes: processes})
+	m = updated.(Model)
+	if view := m.View(); !strings.Contains(view, "/tmp · no sessions yet") || !strings.Contains(view, "including session-less") {
+		t.Errorf("view does not mark the process without sessions:\n%s", view)
+	}
+
+	updated, cmd := m.Update(key("A"))
+	m = updated.(Model)
+	if m.showSessionless || cmd == nil {
+		t.Errorf("A should hide session-less processes and refresh (shown=%v, cmd=%v)", m.showSessionless, cmd != nil)
+	}
+}
+
+// TestRefreshIntervalControls tests interval stepping, pausing and that stale tick chains are dropped
+func TestRefreshIntervalControls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m := NewModel(time.Second, false).WithStateFile(path)
+	defer m.Shutdown()
+
+	plus := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}}
+	minus := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}}
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+
+	staleTick := tickMsg{gen: m.tickGen}
+	updated, cmd := m.Update(plus)
+	m = updated.(Model)
+	if m.updateInterval != 2*time.Second {
+		t.Fatalf("interval after +: got %v, want 2s", m.updateInterval)
+	}
+	if cmd == nil {
+		t.Fatal("changing the interval should restart the tick and save state")
+	}
+
+	// The tick from the previous chain must not schedule another tick
+	if _, cmd := m.Update(staleTick); cmd != nil {
+		t.Error("stale tick should end its chain")
+	}
+
+	// Bounded at both ends
+	for i := 0; i < 20; i++ {
+		updated, _ = m.Update(minus)
+		m = updated.(Model)
+	}
+	if m.updateInterval != refreshIntervalSteps[0] {
+		t.Errorf("interval should stop at %v, got %v", refreshIntervalSteps[0], m.updateInterval)
+	}
+
+	// Paused: current ticks end without rescheduling
+	updated, _ = m.Update(space)
+	m = updated.(Model)
+	if !m.paused {
+		t.Fatal("space should pause")
+	}
+	if _, cmd := m.Update(tickMsg{gen: m.tickGen}); cmd != nil {
+		t.Error("tick while paused should not reschedule")
+	}
+	updated, cmd = m.Update(space)
+	m = updated.(Model)
+	if m.paused || cmd == nil {
+		t.Error("space should resume and restart the tick")
+	}
+
+	// The chosen interval is persisted
+	if msg := m.saveRefreshInterval()(); msg.(stateSavedMsg).err != nil {
+		t.Fatalf("save failed: %v", msg.(stateSavedMsg).err)
+	}
+	st, err := state.Load(path)
+	if err != nil || time.Duration(st.RefreshInterval) != m.updateInterval {
+		t.Errorf("persisted interval: got %+v (%v), want %v", st, err, m.updateInterval)
+	}
+}
+
+func key(s string) tea.KeyMsg {
+	if s == "enter" {
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// TestTurnGrouping tests the grouped session view: collapsed turn headers, expansion and n/N navigation
+func TestTurnGrouping(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+
+	stats := &monitor.SessionStats{
+		MessageHistory: []monitor.Message{
+			{Type: "prompt", Role: "user", Content: "first"},
+			{Type: "assistant_response", Role: "assistant"},
+			{Type: "tool_result", Role: "user"},
+			{Type: "prompt", Role: "user", Content: "second"},
+			{Type: "assistant_response", Role: "assistant"},
+		},
+		Turns: []monitor.Turn{
+			{Index: 1, Start: 0, End: 3},
+			{Index: 2, Start: 3, End: 5},
+		},
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.messageSortNewestFirst = false
+	m.updateMessageTable()
+
+	press := func(k string) {
+		t.Helper()
+		updated, _ := m.Update(key(k))
+		m = updated.(Model)
+	}
+
+	press("G")
+	if len(m.messages) != 2 || !m.messages[0].IsTurnHeader || m.messages[0].Content != "first" {
+		t.Fatalf("grouped rows = %+v, want two collapsed turn headers", m.messages)
+	}
+
+	// Expanding the first turn shows its three messages below the header
+	press("enter")
+	if len(m.messages) != 5 || m.selectedMessageIdx != 0 || m.messages[3].HistoryIdx != 2 {
+		t.Fatalf("after expand: %d rows, selected %d", len(m.messages), m.selectedMessageIdx)
+	}
+
+	press("n")
+	if m.selectedMessageIdx != 4 {
+		t.Errorf("n: selected %d, want 4 (second turn header)", m.selectedMessageIdx)
+	}
+	press("N")
+	if m.selectedMessageIdx != 0 {
+		t.Errorf("N: selected %d, want 0 (first turn header)", m.selectedMessageIdx)
+	}
+
+	// Enter on a message row opens that message
+	m.selectedMessageIdx = 2
+	press("enter")
+	if m.viewMode != ViewMessageDetail || m.detailMessage != &stats.MessageHistory[1] {
+		t.Errorf("enter on message row did not open message detail")
+	}
+
+	m.viewMode = ViewSessionDetail
+	press("G")
+	if len(m.messages) != 5 || m.messages[0].IsTurnHeader {
+		t.Errorf("ungrouped rows = %d, want 5 plain messages", len(m.messages))
+	}
+}
+
+// TestHelpBarFitsWidth tests that every view's help bar fits the terminal and keeps "q: Quit"
+func TestHelpBarFitsWidth(t *testing.T) {
+	views := []struct {
+		name  string
+		setup func(m *Model)
+	}{
+		{"processes", func(m *Model) { m.processes = procs(100) }},
+		{"no processes", func(m *Model) {}},
+		{"projects", func(m *Model) { m.viewMode = ViewProjects }},
+		{"sessions", func(m *Model) { m.viewMode = ViewSessions }},
+		{"session detail", func(m *Model) {
+			m.viewMode = ViewSessionDetail
+			m.sessionStats = &monitor.SessionStats{}
+		}},
+		{"session loading", func(m *Model) {
+			m.viewMode = ViewSessionDetail
+			m.loadingSession = true
+		}},
+		{"message detail", func(m *Model) { m.viewMode = ViewMessageDetail }},
+	}
+	for _, v := range views {
+		for _, width := range []int{40, 80, 120} {
+			m := NewModel(time.Second, false)
+			updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: 40})
+			m = updated.(Model)
+			v.setup(&m)
+
+			help := m.renderHelp()
+			if w := lipgloss.Width(help); w > width {
+				t.Errorf("%s at %d columns: help bar is %d columns wide: %q", v.name, width, w, help)
+			}
+			if !strings.Contains(help, "q: Quit") {
+				t.Errorf("%s at %d columns: help bar %q lost q: Quit", v.name, width, help)
+			}
+		}
+	}
+
+	// "?" expands the help to every hint of the view, wrapped to the width
+	m := NewModel(time.Second, false)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 40, Height: 40})
+	m = updated.(Model)
+	m.processes = procs(100)
+	if strings.Contains(m.renderHelp(), "f: Toggle helpers") {
+		t.Fatalf("help bar at 40 columns should not fit every hint: %q", m.renderHelp())
+	}
+	updated, _ = m.Update(key("?"))
+	m = updated.(Model)
+	full := m.renderHelp()
+	if !strings.Contains(full, "f: Toggle helpers") || !strings.Contains(full, "?: Less") {
+		t.Errorf("full help = %q, want every hint and ?: Less", full)
+	}
+	for _, line := range strings.Split(full, "\n") {
+		if lipgloss.Width(line) > 40 {
+			t.Errorf("full help line %q is wider than 40 columns", line)
+		}
+	}
+}
+
+// TestScrollPosition tests the "message n/total" indicator and the scrollbar next to the message cards
+func TestScrollPosition(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(Model)
+
+	stats := &monitor.SessionStats{}
+	for i := 0; i < 60; i++ {
+		stats.MessageHistory = append(stats.MessageHistory, monitor.Message{Type: "prompt", Role: "user", Content: fmt.Sprintf("prompt %d", i)})
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.messageSortNewestFirst = false
+	m.updateMessageTable()
+
+	m.selectedMessageIdx = 36
+	m.refreshMessageCards()
+	m.scrollToSelection()
+	if got := m.renderScrollPosition(); !strings.Contains(got, "message 37/60 — ") {
+		t.Errorf("position = %q, want message 37/60", got)
+	}
+	viewport := m.renderMessageViewport()
+	if lines := strings.Count(viewport, "\n") + 1; lines != m.messageViewport.Height {
+		t.Errorf("viewport with scrollbar is %d lines, want %d", lines, m.messageViewport.Height)
+	}
+	if !strings.Contains(viewport, "┃") || lipgloss.Width(viewport) > 100 {
+		t.Errorf("viewport is %d columns wide, want a scrollbar within 100", lipgloss.Width(viewport))
+	}
+
+	m.selectedMessageIdx = len(m.messages) - 1
+	m.refreshMessageCards()
+	m.scrollToSelection()
+	if got := m.renderScrollPosition(); !strings.Contains(got, "message 60/60 — 100%") {
+		t.Errorf("position at the end = %q, want message 60/60 — 100%%", got)
+	}
+
+	// Grouped, a turn header counts turns and messages are numbered across all turns
+	stats.MessageHistory = stats.MessageHistory[:5]
+	stats.Turns = []monitor.Turn{{Index: 1, Start: 0, End: 3}, {Index: 2, Start: 3, End: 5}}
+	m.groupByTurn = true
+	m.expandedTurns = map[int]bool{2: true}
+	m.updateMessageTable()
+	m.selectedMessageIdx = 1
+	if got := m.renderScrollPosition(); !strings.Contains(got, "turn 2/2") {
+		t.Errorf("position on turn header = %q, want turn 2/2", got)
+	}
+	m.selectedMessageIdx = 3
+	if got := m.renderScrollPosition(); !strings.Contains(got, "message 5/5") {
+		t.Errorf("position in second turn = %q, want message 5/5", got)
+	}
+	if bar := m.renderMessageViewport(); strings.Contains(bar, "┃") {
+		t.Errorf("scrollbar shown although every card fits")
+	}
+}
+
+// TestJumpToMessage tests count prefixes ("37G", "5j", "5k") and the ":<n>" prompt
+func TestJumpToMessage(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(Model)
+
+	stats := &monitor.SessionStats{}
+	for i := 0; i < 60; i++ {
+		stats.MessageHistory = append(stats.MessageHistory, monitor.Message{Type: "prompt", Role: "user", Content: fmt.Sprintf("prompt %d", i)})
+	}
+	stats.Turns = []monitor.Turn{{Index: 1, Start: 0, End: 30}, {Index: 2, Start: 30, End: 60}}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.messageSortNewestFirst = false
+	m.updateMessageTable()
+
+	type step struct {
+		keys string // Typed one character at a time; "\r" is enter, "\x1b" is esc
+		want int    // Selected row afterwards
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"count G", []step{{"37G", 36}}},
+		{"count j and k", []step{{"5j", 5}, {"j", 6}, {"3k", 3}, {"10k", 0}}},
+		{"count G clamps", []step{{"999G", 59}}},
+		{"leading zero is not a count", []step{{"05j", 5}}},
+		{"prompt", []step{{":12\r", 11}}},
+		{"prompt clamps", []step{{":0\r", 0}, {":70\r", 59}}},
+		{"prompt backspace", []step{{":123\x7f\r", 11}}},
+		{"prompt esc", []step{{"3j", 3}, {":20\x1b", 3}}},
+		{"percent of turns", []step{{"50%", 30}, {"%", 0}, {"150%", 30}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.selectedMessageIdx = 0
+			for _, s := range tt.steps {
+				for _, r := range s.keys {
+					msg := key(string(r))
+					switch r {
+					case '\r':
+						msg = tea.KeyMsg{Type: tea.KeyEnter}
+					case '\x1b':
+						msg = tea.KeyMsg{Type: tea.KeyEsc}
+					case '\x7f':
+						msg = tea.KeyMsg{Type: tea.KeyBackspace}
+					}
+					updated, _ := m.Update(msg)
+					m = updated.(Model)
+				}
+				if m.selectedMessageIdx != s.want || m.viewMode != ViewSessionDetail {
+					t.Fatalf("after %q: selected row %d in view %d, want row %d", s.keys, m.selectedMessageIdx, m.viewMode, s.want)
+				}
+			}
+			if m.jumpPrompt || m.countPrefix != 0 {
+				t.Errorf("prompt still open (%v) or count left over (%d)", m.jumpPrompt, m.countPrefix)
+			}
+		})
+	}
+
+	// Without a count "G" still toggles grouping; jumping into a collapsed turn expands it
+	updated, _ = m.Update(key("G"))
+	m = updated.(Model)
+	if !m.groupByTurn || len(m.messages) != 2 {
+		t.Fatalf("G without count: grouped %v with %d rows, want 2 turn headers", m.groupByTurn, len(m.messages))
+	}
+	for _, r := range "40G" {
+		updated, _ = m.Update(key(string(r)))
+		m = updated.(Model)
+	}
+	row := m.messages[m.selectedMessageIdx]
+	if !m.groupByTurn || row.IsTurnHeader || row.HistoryIdx != 39 || !m.expandedTurns[2] {
+		t.Errorf("40G in grouped mode selected %+v, want message 40 in expanded turn 2", row)
+	}
+}
+
+// TestMessageDetailStepping tests ←/→ and shift+←/→ in message detail view and the
+// position shown there, which follows the card list's filter and sort order
+func TestMessageDetailStepping(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+
+	stats := &monitor.SessionStats{}
+	for i := 0; i < 30; i++ {
+		stats.MessageHistory = append(stats.MessageHistory,
+			monitor.Message{Type: "prompt", Role: "user", Content: fmt.Sprintf("prompt %d", i)},
+			monitor.Message{Type: "assistant_response", Role: "assistant", Content: fmt.Sprintf("answer %d", i)})
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.messageFilter = FilterUserOnly
+	m.messageSortNewestFirst = true
+	m.updateMessageTable()
+
+	updated, _ := m.Update(key("enter"))
+	m = updated.(Model)
+	if m.viewMode != ViewMessageDetail || m.detailMessage.Content != "prompt 29" {
+		t.Fatalf("enter opened %+v, want the newest prompt", m.detailMessage)
+	}
+	view := m.View()
+	if !strings.Contains(view, "message 1 of 30 (user filter)") || !strings.Contains(view, "⇠ no previous") || strings.Contains(view, "no next ⇢") {
+		t.Errorf("first message: position line missing or wrong in\n%s", view)
+	}
+
+	steps := []struct {
+		key  tea.KeyType
+		want string
+	}{
+		{tea.KeyRight, "prompt 28"},
+		{tea.KeyShiftRight, "prompt 18"},
+		{tea.KeyShiftRight, "prompt 8"},
+		{tea.KeyShiftRight, "prompt 0"}, // Stops at the last message
+		{tea.KeyRight, "prompt 0"},
+		{tea.KeyShiftLeft, "prompt 10"},
+		{tea.KeyLeft, "prompt 11"},
+	}
+	for _, s := range steps {
+		updated, _ = m.Update(tea.KeyMsg{Type: s.key})
+		m = updated.(Model)
+		if m.detailMessage.Content != s.want {
+			t.Fatalf("%s: showing %q, want %q", tea.KeyMsg{Type: s.key}, m.detailMessage.Content, s.want)
+		}
+	}
+	if view := m.View(); !strings.Contains(view, "message 19 of 30 (user filter)") {
+		t.Errorf("position after stepping is wrong in\n%s", view)
+	}
+
+	m.selectedMessageIdx = len(m.messages) - 1
+	m.detailMessage = m.messageAtRow(m.selectedMessageIdx)
+	if view := m.View(); !strings.Contains(view, "no next ⇢") || strings.Contains(view, "⇠ no previous") {
+		t.Errorf("last message: boundary hints wrong in\n%s", view)
+	}
+}
+
+// TestToolCallDetail tests that enter on a tool call shows it with its result, that
+// left/right step between tool calls only and that a/r fold the two sections
+func TestToolCallDetail(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{MessageHistory: []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "run the tests"},
+		{Type: "assistant_response", Role: "assistant", ToolName: "Bash", ToolUseID: "a", ToolInput: `{"command":"go test ./..."}`},
+		{Type: "assistant_response", Role: "assistant", ToolName: "Read", ToolUseID: "b", ToolInput: `{"file_path":"main.go"}`},
+		{Type: "tool_result", Role: "user", ToolUseID: "b", Content: "package main"},
+		{Type: "tool_result", Role: "user", ToolUseID: "a", Content: "FAIL", IsError: true},
+		{Type: "assistant_response", Role: "assistant", Content: "one test fails"},
+		{Type: "assistant_response", Role: "assistant", ToolName: "Grep", ToolUseID: "c", ToolInput: `{"pattern":"TODO"}`},
+	}}
+	m.messageSortNewestFirst = false
+	m.updateMessageTable()
+	m.selectedMessageIdx = 1
+
+	updated, _ := m.Update(key("enter"))
+	m = updated.(Model)
+	if !m.detailPaired || len(m.detailResults) != 1 || m.detailResults[0] == nil || m.detailResults[0].Content != "FAIL" {
+		t.Fatalf("enter on the Bash call: paired %v, results %+v", m.detailPaired, m.detailResults)
+	}
+	if view := m.View(); !strings.Contains(view, "tool call 1 of 3") || !strings.Contains(view, "✗ Result: error") {
+		t.Errorf("paired view missing position or error result in\n%s", view)
+	}
+
+	steps := []struct {
+		key        tea.KeyType
+		tool, want string
+	}{
+		{tea.KeyRight, "Read", "package main"},
+		{tea.KeyRight, "Grep", ""}, // Skips the results and the text reply; no result yet
+		{tea.KeyRight, "Grep", ""},
+		{tea.KeyShiftLeft, "Bash", "FAIL"},
+	}
+	for _, s := range steps {
+		updated, _ = m.Update(tea.KeyMsg{Type: s.key})
+		m = updated.(Model)
+		got := ""
+		if len(m.detailResults) == 1 && m.detailResults[0] != nil {
+			got = m.detailResults[0].Content
+		}
+		if m.detailMessage.ToolName != s.tool || got != s.want {
+			t.Fatalf("%s: showing %s with result %q, want %s with %q", tea.KeyMsg{Type: s.key}, m.detailMessage.ToolName, got, s.tool, s.want)
+		}
+	}
+
+	for _, k := range []string{"a", "r"} {
+		updated, _ = m.Update(key(k))
+		m = updated.(Model)
+	}
+	if !m.detailArgsCollapsed || !m.detailResultCollapsed {
+		t.Fatalf("a/r did not fold: arguments %v, result %v", m.detailArgsCollapsed, m.detailResultCollapsed)
+	}
+	if view := m.View(); !strings.Contains(view, "▸ Arguments") || strings.Contains(view, `"command": "go test`) {
+		t.Errorf("folded arguments still shown in\n%s", view)
+	}
+
+	updated, _ = m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewSessionDetail || m.detailPaired || m.detailResults != nil {
+		t.Errorf("esc left paired state: mode %v, paired %v", m.viewMode, m.detailPaired)
+	}
+
+	m.selectedMessageIdx = 0
+	updated, _ = m.Update(key("enter"))
+	m = updated.(Model)
+	if m.detailPaired {
+		t.Error("enter on a prompt opened the paired tool view")
+	}
+}
+
+// TestSplitView tests that "|" shows the selected message beside the cards on wide
+// terminals only, and that tab moves scrolling between the two panes
+// TestCardWidth tests that cards fit the viewport at any terminal width, so each keeps
+// its nominal height, and follow resizes
+func TestCardWidth(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	stats := &monitor.SessionStats{}
+	for i := 0; i < 5; i++ {
+		stats.MessageHistory = append(stats.MessageHistory,
+			monitor.Message{Type: "prompt", Role: "user", Content: strings.Repeat("why does the deploy fail ", 10), PastedBytes: 48_000},
+			monitor.Message{Type: "assistant_response", Role: "assistant", Model: "claude-opus-4-5", Content: strings.Repeat("word ", 80),
+				InputTokens: 1200, OutputTokens: 800, CacheCreation: 40_000, CacheRead: 900_000})
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+
+	for _, width := range []int{60, 80, 120, 240} {
+		updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: 40})
+		m = updated.(Model)
+		if want := len(m.messages) * render.CardLines; m.messageLines != want {
+			t.Errorf("%d columns: cards take %d lines, want %d", width, m.messageLines, want)
+		}
+		lines := strings.Split(m.renderMessageCards(), "\n")
+		widest := 0
+		for _, line := range lines {
+			widest = max(widest, lipgloss.Width(line))
+		}
+		if widest != m.messageViewport.Width {
+			t.Errorf("%d columns: cards are %d wide, want the viewport's %d", width, widest, m.messageViewport.Width)
+		}
+	}
+}
+
+func TestSplitView(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = updated.(Model)
+
+	stats := &monitor.SessionStats{}
+	for i := 0; i < 10; i++ {
+		var lines []string
+		for j := 0; j < 60; j++ {
+			lines = append(lines, fmt.Sprintf("answer %d line %d", i, j))
+		}
+		stats.MessageHistory = append(stats.MessageHistory,
+			monitor.Message{Type: "prompt", Role: "user", Content: fmt.Sprintf("prompt %d", i)},
+			monitor.Message{Type: "assistant_response", Role: "assistant", Content: strings.Join(lines, "\n")})
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.updateMessageTable()
+	m.refreshMessageCards()
+
+	// Too narrow for the default threshold
+	updated, _ = m.Update(key("|"))
+	m = updated.(Model)
+	if m.splitShown() || !strings.Contains(m.View(), "Split view needs 160 columns") {
+		t.Fatalf("split at 120 columns, or no explanation:\n%s", m.View())
+	}
+
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 180, Height: 30})
+	m = updated.(Model)
+	updated, _ = m.Update(key("|"))
+	m = updated.(Model)
+	if !m.splitShown() || m.messageViewport.Width != 90-scrollbarWidth {
+		t.Fatalf("split = %v, card list %d wide", m.splitShown(), m.messageViewport.Width)
+	}
+	want := m.messageAtRow(m.selectedMessageIdx)
+	view := m.View()
+	if !strings.Contains(view, "CLAUDE RESPONSE") || !strings.Contains(view, want.Content[:len("answer 9 line 0")]) {
+		t.Errorf("selected message not shown beside the cards:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > 180 {
+			t.Fatalf("line %d columns wide: %q", w, line)
+		}
+	}
+
+	// With the detail pane focused, keys scroll the message and leave the cursor alone
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(Model)
+	selected := m.selectedMessageIdx
+	for i := 0; i < 3; i++ {
+		updated, _ = m.Update(key("down"))
+		m = updated.(Model)
+	}
+	if m.selectedMessageIdx != selected || m.detailScrollOffset != 3 {
+		t.Fatalf("cursor %d (was %d), detail offset %d, want 3", m.selectedMessageIdx, selected, m.detailScrollOffset)
+	}
+	if view := m.View(); !strings.Contains(view, "Line 4-") || strings.Contains(view, "answer 9 line 0\n") {
+		t.Errorf("detail pane not scrolled:\n%s", view)
+	}
+	updated, _ = m.Update(key("end"))
+	m = updated.(Model)
+	if maxOffset := 60 - render.DetailPanePageHeight(m.messageViewport.Height); m.detailScrollOffset != maxOffset {
+		t.Errorf("end scrolled to %d, want %d", m.detailScrollOffset, maxOffset)
+	}
+
+	// esc returns to the cards; moving the cursor shows the next message from the top
+	updated, _ = m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewSessionDetail || m.splitFocusDetail {
+		t.Fatalf("esc left view %v, focus on detail %v", m.viewMode, m.splitFocusDetail)
+	}
+	updated, _ = m.Update(key("down"))
+	m = updated.(Model)
+	if m.selectedMessageIdx == selected || m.detailScrollOffset != 0 {
+		t.Errorf("cursor %d (was %d), detail offset %d after moving", m.selectedMessageIdx, selected, m.detailScrollOffset)
+	}
+
+	// A narrower terminal falls back to the single list
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(Model)
+	if m.splitShown() || m.messageViewport.Width != 100-scrollbarWidth {
+		t.Errorf("still split at 100 columns (card list %d wide)", m.messageViewport.Width)
+	}
+}
+
+// TestCompactHeader tests that "z" collapses the session header, gives the rows to the
+// message viewport and persists the choice
+func TestCompactHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m := NewModel(time.Second, false).WithStateFile(path)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+
+	stats := &monitor.SessionStats{FilePath: "/tmp/session.jsonl", Duration: time.Minute, TotalMessages: 60}
+	for i := 0; i < 60; i++ {
+		stats.MessageHistory = append(stats.MessageHistory, monitor.Message{Type: "prompt", Role: "user", Content: fmt.Sprintf("prompt %d", i)})
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.updateMessageTable()
+
+	fullHeight := m.messageViewport.Height
+	if h := lipgloss.Height(m.View()); h > 40 {
+		t.Errorf("full header: view is %d rows, want at most 40", h)
+	}
+
+	updated, cmd := m.Update(key("z"))
+	m = updated.(Model)
+	if !m.compactHeader || m.messageViewport.Height <= fullHeight {
+		t.Fatalf("after z: compact %v, viewport %d rows (was %d)", m.compactHeader, m.messageViewport.Height, fullHeight)
+	}
+	view := m.View()
+	if h := lipgloss.Height(view); h != 40 {
+		t.Errorf("compact header: view is %d rows, want 40", h)
+	}
+	if !strings.HasPrefix(view, "Session …/session.jsonl | 1m0s | 60 messages") || strings.Contains(view, "\nMessages:") {
+		t.Errorf("compact header: unexpected first line in\n%s", view)
+	}
+
+	// Resizing keeps the compact layout
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(Model)
+	if h := lipgloss.Height(m.View()); h != 30 {
+		t.Errorf("compact header after resize: view is %d rows, want 30", h)
+	}
+
+	if msg := cmd(); msg.(stateSavedMsg).err != nil {
+		t.Fatalf("save failed: %v", msg.(stateSavedMsg).err)
+	}
+	st, err := state.Load(path)
+	if err != nil || !st.CompactHeader {
+		t.Errorf("state after z = %+v, %v; want CompactHeader", st, err)
+	}
+}
+
+// TestStartView tests starting in each view, that the recent list started in leads
+// back to the projects view, and that the view is saved for the next run
+func TestStartView(t *testing.T) {
+	tests := []struct {
+		view       string
+		wantMode   ViewMode
+		wantSource ViewMode
+	}{
+		{view: "", wantMode: ViewProcesses},
+		{view: "processes", wantMode: ViewProcesses},
+		{view: "projects", wantMode: ViewProjects},
+		{view: "recent", wantMode: ViewSessions, wantSource: ViewRecent},
+	}
+	for _, tt := range tests {
+		m := NewModel(time.Second, false).WithStartView(tt.view)
+		if m.viewMode != tt.wantMode || m.sessionSourceMode != tt.wantSource {
+			t.Errorf("WithStartView(%q): mode %v, source %v; want %v, %v", tt.view, m.viewMode, m.sessionSourceMode, tt.wantMode, tt.wantSource)
+		}
+		if m.Init() == nil {
+			t.Errorf("WithStartView(%q): Init loads nothing", tt.view)
+		}
+		m.Shutdown()
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	m := NewModel(time.Second, false).WithStateFile(path).WithStartView("recent")
+	defer m.Shutdown()
+	updated, cmd := m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewProjects || cmd == nil {
+		t.Fatalf("esc from the recent list: mode %v, cmd %v; want the projects view, loading", m.viewMode, cmd != nil)
+	}
+
+	if msg := m.saveView("recent")(); msg.(stateSavedMsg).err != nil {
+		t.Fatalf("save failed: %v", msg.(stateSavedMsg).err)
+	}
+	if st, err := state.Load(path); err != nil || st.View != "recent" {
+		t.Errorf("state after saving the view = %+v, %v; want View recent", st, err)
+	}
+}
+
+// TestRecentSessions tests the cross-project recent list: index rows for unchanged
+// files, a PROJECT column, and esc returning through the list to the projects view
+func TestRecentSessions(t *testing.T) {
+	projects := t.TempDir()
+	monitor.SetProjectsDir(projects)
+	t.Cleanup(func() { monitor.SetProjectsDir("") })
+
+	api := filepath.Join(projects, "-work-api")
+	web := filepath.Join(projects, "-work-web")
+	for _, d := range []string{api, web} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().Truncate(time.Millisecond)
+	line := fmt.Sprintf(`{"type":"user","timestamp":%q,"message":{"role":"user","content":"from the file"}}`+"\n",
+		now.Add(-time.Minute).UTC().Format(time.RFC3339))
+	sessions := []struct {
+		path string
+		age  time.Duration
+	}{
+		{filepath.Join(api, "indexed.jsonl"), time.Hour},
+		{filepath.Join(web, "plain.jsonl"), time.Minute},
+		{filepath.Join(web, "old.jsonl"), 30 * 24 * time.Hour},
+	}
+	for _, s := range sessions {
+		if err := os.WriteFile(s.path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(s.path, now.Add(-s.age), now.Add(-s.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := fmt.Sprintf(`{"originalPath":"/work/api","entries":[{"sessionId":"indexed","fileMtime":%d,"firstPrompt":"from the index","gitBranch":"main"}]}`,
+		now.Add(-time.Hour).UnixMilli())
+	if err := os.WriteFile(filepath.Join(api, "sessions-index.json"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.viewMode = ViewProjects
+	updated, cmd := m.Update(key("R"))
+	m = updated.(Model)
+	if m.viewMode != ViewSessions || m.sessionSourceMode != ViewRecent || cmd == nil {
+		t.Fatalf("after R: view %v, source %v", m.viewMode, m.sessionSourceMode)
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+
+	if len(m.sessions) != 2 || m.sessions[0].ID != "plain" || m.sessions[1].ID != "indexed" {
+		t.Fatalf("sessions = %+v, want plain then indexed", m.sessions)
+	}
+	if got := m.sessions[1]; got.FirstPrompt != "from the index" || got.GitBranch != "main" || got.Project != "/work/api" {
+		t.Errorf("indexed row = %+v, want the index entry in project /work/api", got)
+	}
+	if got := m.sessions[0]; got.FirstPrompt != "from the file" || got.Project == "" {
+		t.Errorf("plain row = %+v, want the first prompt read from the file", got)
+	}
+	if view := m.View(); !strings.Contains(view, "PROJECT") || !strings.Contains(view, "/work/api") {
+		t.Errorf("recent list has no PROJECT column:\n%s", view)
+	}
+
+	// esc from a session returns to the recent list, and from there to the projects view
+	m.viewMode = ViewSessionDetail
+	updated, _ = m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewSessions || len(m.sessions) != 2 {
+		t.Fatalf("esc from detail: view %v with %d sessions, want the recent list", m.viewMode, len(m.sessions))
+	}
+	updated, _ = m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewProjects {
+		t.Errorf("esc from recent list: view %v, want ViewProjects", m.viewMode)
+	}
+}
+
+// TestCopiedSessions tests that a session copied along with its repository is listed
+// once in the recent list, from the copy written last, with a badge naming the project
+// holding the other copy
+func TestCopiedSessions(t *testing.T) {
+	projects := t.TempDir()
+	monitor.SetProjectsDir(projects)
+	t.Cleanup(func() { monitor.SetProjectsDir("") })
+
+	now := time.Now()
+	line := fmt.Sprintf(`{"type":"user","timestamp":%q,"message":{"role":"user","content":"refactor the parser"}}`+"\n",
+		now.Add(-2*time.Hour).UTC().Format(time.RFC3339))
+	original := filepath.Join(projects, "-work-api", "abc.jsonl")
+	copied := filepath.Join(projects, "-work-api2", "abc.jsonl")
+	for i, path := range []string{original, copied} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-time.Duration(2-i) * time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.termWidth, m.termHeight = 200, 40
+	m.viewMode = ViewSessions
+	m.sessionSourceMode = ViewRecent
+	updated, _ := m.Update(m.loadRecentSessions()())
+	m = updated.(Model)
+	if len(m.sessions) != 1 || m.sessions[0].Path != copied {
+		t.Fatalf("recent sessions = %+v, want only the copy written last", m.sessions)
+	}
+	if got := m.sessions[0].Copies; len(got) != 1 || !strings.Contains(got[0], "api") {
+		t.Errorf("Copies = %v, want the original project", got)
+	}
+
+	m.sessionSourceMode = ViewProjects
+	m.sessionsProject = ProjectDir{Path: filepath.Dir(original)}
+	updated, _ = m.Update(m.loadSessionsFromProject(m.sessionsProject)())
+	m = updated.(Model)
+	if len(m.sessions) != 1 || len(m.sessions[0].Copies) != 1 {
+		t.Fatalf("project sessions = %+v, want the original pointing to its copy", m.sessions)
+	}
+	if view := m.View(); !strings.Contains(view, "dup ↔") {
+		t.Errorf("session list has no dup badge:\n%s", view)
+	}
+}
+
+// TestRecentlyViewed tests that opening a session records it in the history file, and
+// that the recently viewed list reopens it and dims sessions deleted since
+func TestRecentlyViewed(t *testing.T) {
+	projects := t.TempDir()
+	monitor.SetProjectsDir(projects)
+	t.Cleanup(func() { monitor.SetProjectsDir("") })
+
+	dir := filepath.Join(projects, "-work-api")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"user","timestamp":"2026-01-09T14:00:00Z","message":{"role":"user","content":"fix the login test"}}` + "\n"
+	kept, deleted := filepath.Join(dir, "kept.jsonl"), filepath.Join(dir, "deleted.jsonl")
+	for _, path := range []string{kept, deleted} {
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history := filepath.Join(t.TempDir(), "history.json")
+	m := NewModel(time.Second, false).WithHistoryFile(history)
+	defer m.Shutdown()
+	m.termWidth, m.termHeight = 120, 40
+
+	for _, path := range []string{kept, deleted} {
+		if msg := m.recordVisit(path)().(openedSessionMsg); msg.err != nil {
+			t.Fatal(msg.err)
+		}
+	}
+	if err := os.Remove(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	m.viewMode = ViewProjects
+	updated, cmd := m.Update(key("H"))
+	m = updated.(Model)
+	if m.viewMode != ViewViewed || cmd == nil {
+		t.Fatalf("after H: view %v", m.viewMode)
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if len(m.viewed) != 2 || m.viewed[0].Session.Path != deleted || !m.viewed[0].Missing || m.viewed[1].Missing {
+		t.Fatalf("viewed = %+v, want the deleted session then the kept one", m.viewed)
+	}
+	if got := m.viewed[1].Session; got.Project != "/work/api" || got.Title != "fix the login test" {
+		t.Errorf("kept row = %+v, want its project and title", got)
+	}
+	if view := m.View(); !strings.Contains(view, "Recently viewed") || !strings.Contains(view, "(deleted)") {
+		t.Errorf("recently viewed list does not mark the deleted session:\n%s", view)
+	}
+
+	// A deleted session cannot be reopened
+	updated, _ = m.Update(key("enter"))
+	m = updated.(Model)
+	if m.viewMode != ViewViewed || !strings.Contains(m.viewedNote, "deleted") {
+		t.Errorf("enter on a deleted session: view %v, note %q", m.viewMode, m.viewedNote)
+	}
+
+	updated, _ = m.Update(key("down"))
+	m = updated.(Model)
+	updated, cmd = m.Update(key("enter"))
+	m = updated.(Model)
+	if m.viewMode != ViewSessionDetail || m.selectedSession == nil || m.selectedSession.Path != kept {
+		t.Fatalf("enter on the kept session: view %v", m.viewMode)
+	}
+	// Opening it loads it and records the visit
+	if batch, ok := cmd().(tea.BatchMsg); !ok || len(batch) != 2 {
+		t.Errorf("enter on the kept session returned %T, want loading and recording", cmd())
+	}
+
+	// Once loaded, esc returns to the list, and from there to the projects view
+	m.cancelSessionLoad()
+	m.loadingSession = false
+	updated, _ = m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewViewed {
+		t.Fatalf("esc from detail: view %v, want ViewViewed", m.viewMode)
+	}
+	updated, _ = m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewProjects {
+		t.Errorf("esc from recently viewed: view %v, want ViewProjects", m.viewMode)
+	}
+}
+
+// TestWatchReload tests that sessions reported by the watcher join the list without
+// moving the selection, that removed ones drop out, and that a list loaded for a view
+// since left is ignored
+func TestWatchReload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, minute int) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		line := fmt.Sprintf(`{"type":"user","timestamp":"2026-01-09T14:%02d:00Z","message":{"role":"user","content":"prompt %s"}}`+"\n", minute, name)
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Date(2026, 1, 9, 14, minute, 0, 0, time.UTC)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("a.jsonl", 1)
+	older := write("b.jsonl", 0)
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.termWidth, m.termHeight = 200, 40
+	m.viewMode = ViewSessions
+	m.sessionSourceMode = ViewProjects
+	m.sessionsProject = ProjectDir{Path: dir}
+	updated, _ := m.Update(m.reloadSessions()())
+	m = updated.(Model)
+	m.selectedSessionIdx = 1
+	if m.sessions[1].Path != older {
+		t.Fatalf("sessions = %+v, want %s second", m.sessions, older)
+	}
+
+	write("c.jsonl", 2)
+	if !m.watchConcerns(monitor.WatchChange{Dirs: []string{dir}}) {
+		t.Fatal("a new session in the listed project does not concern the list")
+	}
+	if m.watchConcerns(monitor.WatchChange{Dirs: []string{filepath.Join(dir, "other")}}) {
+		t.Error("a new session in another project concerns the list")
+	}
+	updated, _ = m.Update(m.reloadSessions()())
+	m = updated.(Model)
+	if len(m.sessions) != 3 || !strings.HasSuffix(m.sessions[0].Path, "c.jsonl") {
+		t.Fatalf("sessions = %+v, want the new one on top", m.sessions)
+	}
+	if m.sessions[m.selectedSessionIdx].Path != older {
+		t.Errorf("selection moved to %s, want %s", m.sessions[m.selectedSessionIdx].Path, older)
+	}
+
+	if err := os.Remove(older); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ = m.Update(m.reloadSessions()())
+	m = updated.(Model)
+	if len(m.sessions) != 2 || m.selectedSessionIdx != 1 {
+		t.Errorf("after removing the selected session: %d sessions, selection %d; want 2 and 1", len(m.sessions), m.selectedSessionIdx)
+	}
+
+	updated, _ = m.Update(sessionsMsg{source: "recent"})
+	m = updated.(Model)
+	if len(m.sessions) != 2 {
+		t.Errorf("the recent list replaced the project's: %+v", m.sessions)
+	}
+}
+
+// TestSessionPreview tests the preview pane: debounced loading of the highlighted
+// session, dropping results for a selection that moved on, read errors, and falling back
+// to a single pane in small terminals
+func TestSessionPreview(t *testing.T) {
+	dir := t.TempDir()
+	older := filepath.Join(dir, "older.jsonl")
+	newer := filepath.Join(dir, "newer.jsonl")
+	var lines strings.Builder
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&lines, `{"type":"user","timestamp":"2026-01-09T14:%02d:00Z","message":{"role":"user","content":"prompt %d"}}`+"\n", i, i)
+	}
+	if err := os.WriteFile(older, []byte(lines.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newer, []byte(`{"type":"user","timestamp":"2026-01-09T15:00:00Z","message":{"role":"user","content":"latest question"}}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	os.Chtimes(older, now.Add(-time.Hour), now.Add(-time.Hour))
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.termWidth, m.termHeight = 160, 40
+	m.viewMode = ViewSessions
+	updated, _ := m.Update(m.loadSessionsFromProject(ProjectDir{Path: dir})())
+	m = updated.(Model)
+
+	// preview runs the debounce and the load it triggers
+	preview := func(cmd tea.Cmd) {
+		t.Helper()
+		if cmd == nil {
+			t.Fatal("no preview was scheduled")
+		}
+		updated, cmd := m.Update(cmd())
+		m = updated.(Model)
+		if cmd != nil {
+			updated, _ = m.Update(cmd())
+			m = updated.(Model)
+		}
+	}
+
+	updated, cmd := m.Update(key("v"))
+	m = updated.(Model)
+	preview(cmd)
+	if m.previewPath != newer || len(m.previewMessages) != 1 || m.previewLoading {
+		t.Fatalf("preview of %s: %d messages, loading %v; want the newest session", m.previewPath, len(m.previewMessages), m.previewLoading)
+	}
+	view := m.View()
+	if !strings.Contains(view, "latest question") {
+		t.Errorf("view lacks the previewed message:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > m.termWidth {
+			t.Fatalf("line is %d columns wide, terminal %d:\n%s", w, m.termWidth, line)
+		}
+	}
+
+	// A debounce that fires after the selection moved on is ignored
+	updated, _ = m.Update(key("down"))
+	m = updated.(Model)
+	updated, cmd = m.Update(key("up"))
+	m = updated.(Model)
+	if updated, next := m.Update(previewDebounceMsg{seq: m.previewSeq - 1}); next != nil {
+		t.Error("stale debounce started a load")
+	} else {
+		m = updated.(Model)
+	}
+	preview(cmd)
+	if m.previewPath != newer || len(m.previewMessages) != 1 {
+		t.Errorf("after moving back: preview of %s with %d messages", m.previewPath, len(m.previewMessages))
+	}
+
+	// Only the last messages of a long session are shown
+	updated, cmd = m.Update(key("down"))
+	m = updated.(Model)
+	preview(cmd)
+	if len(m.previewMessages) != render.PreviewMessages || m.previewMessages[0].Content != "prompt 3" {
+		t.Errorf("long session preview = %d messages from %q, want the last %d", len(m.previewMessages), m.previewMessages[0].Content, render.PreviewMessages)
+	}
+
+	// A session that cannot be read shows the error in the pane
+	os.Remove(older)
+	updated, _ = m.Update(m.loadPreview()())
+	m = updated.(Model)
+	if m.previewError == "" || !strings.Contains(m.View(), "⚠") {
+		t.Errorf("preview error = %q, want the read error shown", m.previewError)
+	}
+
+	// Small terminals fall back to the single-pane list
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	m = updated.(Model)
+	if m.previewShown() || strings.Contains(m.View(), "⚠") {
+		t.Error("preview pane shown in an 80-column terminal")
+	}
+}
+
+// TestProjectsDirStates tests the projects view for a missing, an empty and an unreadable
+// projects directory, and that the next tick picks up a directory Claude created meanwhile
+func TestProjectsDirStates(t *testing.T) {
+	root := t.TempDir()
+	missing := filepath.Join(root, "missing")
+	empty := filepath.Join(root, "empty")
+	locked := filepath.Join(root, "locked")
+	for _, d := range []string{empty, locked} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(locked, 0o000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+	t.Cleanup(func() { monitor.SetProjectsDir("") })
+
+	tests := []struct {
+		name     string
+		dir      string
+		wantErr  bool
+		wantView string
+	}{
+		{"missing directory", missing, false, "does not exist yet"},
+		{"empty directory", empty, false, "No projects found in " + empty},
+		{"unreadable directory", locked, true, "permission denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.dir == locked {
+				if _, err := os.ReadDir(locked); err == nil {
+					t.Skip("directory permissions are not enforced for this user")
+				}
+			}
+			monitor.SetProjectsDir(tt.dir)
+			m := NewModel(time.Second, false)
+			defer m.Shutdown()
+			m.viewMode = ViewProjects
+
+			updated, _ := m.Update(m.loadProjects()())
+			m = updated.(Model)
+			if (m.projectsError != "") != tt.wantErr || m.projectsDirMissing != (tt.dir == missing) {
+				t.Errorf("projectsError = %q, missing = %v", m.projectsError, m.projectsDirMissing)
+			}
+			if view := m.View(); !strings.Contains(view, tt.wantView) {
+				t.Errorf("view lacks %q:\n%s", tt.wantView, view)
+			}
+		})
+	}
+
+	// Once Claude creates the directory, the next tick lists its projects
+	monitor.SetProjectsDir(missing)
+	m := NewModel(10*time.Millisecond, false)
+	defer m.Shutdown()
+	m.viewMode = ViewProjects
+	updated, _ := m.Update(m.loadProjects()())
+	m = updated.(Model)
+	if err := os.MkdirAll(filepath.Join(missing, "-work-app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_, cmd := m.Update(tickMsg{gen: m.tickGen})
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatal("tick did not reload the projects")
+	}
+	for _, c := range batch {
+		if msg, ok := c().(projectsMsg); ok {
+			updated, _ = m.Update(msg)
+			m = updated.(Model)
+		}
+	}
+	if m.projectsDirMissing || len(m.projects) != 1 {
+		t.Errorf("after the tick: missing = %v, %d projects; want the new project", m.projectsDirMissing, len(m.projects))
+	}
+}
+
+// TestProjectStatsView tests that i scans the selected project and esc returns to the projects view
+func TestProjectStatsView(t *testing.T) {
+	dir := t.TempDir()
+	line := `{"type":"assistant","timestamp":"2026-01-09T14:00:00Z","message":{"role":"assistant","model":"claude-opus-4-5","content":[{"type":"tool_use","name":"Bash","input":{}}],"usage":{"input_tokens":10,"output_tokens":20}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "a.jsonl"), []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	m.viewMode = ViewProjects
+	m.projects = []ProjectDir{{Path: dir, DisplayName: "~/app", Sessions: 1}}
+
+	updated, cmd := m.Update(key("i"))
+	m = updated.(Model)
+	if m.viewMode != ViewProjectStats || !m.scanningProject {
+		t.Fatalf("after i: view %v, scanning %v", m.viewMode, m.scanningProject)
+	}
+	if view := m.View(); !strings.Contains(view, "Scanning sessions") {
+		t.Errorf("scan in progress not shown:\n%s", view)
+	}
+
+	// Run the scan and feed its updates back until the result arrives
+	batch := cmd().(tea.BatchMsg)
+	go batch[0]()
+	listen := batch[1]
+	for listen != nil {
+		msg := listen()
+		if msg == nil {
+			break
+		}
+		updated, listen = m.Update(msg)
+		m = updated.(Model)
+	}
+
+	if m.scanningProject {
+		t.Fatal("scan did not finish")
+	}
+	stats := m.projectStatsCache[dir]
+	if stats == nil || stats.Sessions != 1 || stats.Tokens.Total() != 30 {
+		t.Fatalf("stats = %+v, want 1 session with 30 tokens", stats)
+	}
+	if view := m.View(); !strings.Contains(view, "Project: ~/app") || !strings.Contains(view, "Bash") {
+		t.Errorf("dashboard is missing the project or its tools:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.viewMode != ViewProjects {
+		t.Errorf("esc: view %v, want ViewProjects", m.viewMode)
+	}
+}
+
+// TestReportView tests the reports view: a scan of the last 7 days across projects,
+// the custom range prompt and an export of the dashboard
+func TestReportView(t *testing.T) {
+	projects := t.TempDir()
+	monitor.SetProjectsDir(projects)
+	t.Cleanup(func() { monitor.SetProjectsDir("") })
+	dir := filepath.Join(projects, "-work-api")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"message":{"role":"assistant","model":"claude-opus-4-5","content":[{"type":"text","text":"done"}],"usage":{"input_tokens":10,"output_tokens":20}}}`+"\n",
+		time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(dir, "a.jsonl"), []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Export = config.ExportConfig{Format: "markdown", Dir: t.TempDir()}
+	m := NewModel(time.Second, false).WithConfig(cfg)
+	defer m.Shutdown()
+	m.viewMode = ViewProjects
+
+	// scan runs a report scan and feeds its updates back until the result arrives
+	scan := func(cmd tea.Cmd) {
+		t.Helper()
+		batch := cmd().(tea.BatchMsg)
+		go batch[0]()
+		listen := batch[1]
+		for listen != nil {
+			msg := listen()
+			if msg == nil {
+				break
+			}
+			var updated tea.Model
+			updated, listen = m.Update(msg)
+			m = updated.(Model)
+		}
+		if m.scanningReport {
+			t.Fatal("scan did not finish")
+		}
+	}
+
+	updated, cmd := m.Update(key("D"))
+	m = updated.(Model)
+	if m.viewMode != ViewReport || !m.scanningReport {
+		t.Fatalf("after D: view %v, scanning %v", m.viewMode, m.scanningReport)
+	}
+	scan(cmd)
+	report := m.reportCache[m.reportShown.String()]
+	if report == nil || report.Total.Sessions != 1 || report.Total.Tokens.Total() != 30 || len(report.Projects) != 1 {
+		t.Fatalf("report = %+v, want 1 session with 30 tokens in 1 project", report)
+	}
+	if view := m.View(); !strings.Contains(view, "Last 7 days") || !strings.Contains(view, "-work-api") {
+		t.Errorf("dashboard is missing the range or the project:\n%s", view)
+	}
+
+	// e exports the dashboard as Markdown
+	updated, cmd = m.Update(key("e"))
+	m = updated.(Model)
+	done, ok := cmd().(reportExportedMsg)
+	if !ok || done.err != nil {
+		t.Fatalf("export failed: %+v", done)
+	}
+	if data, err := os.ReadFile(done.path); err != nil || !strings.Contains(string(data), "# Report: Last 7 days") || !strings.Contains(string(data), "| -work-api |") {
+		t.Errorf("exported report (%v):\n%s", err, data)
+	}
+
+	// A custom range without the day of the session has nothing in it
+	updated, _ = m.Update(key("c"))
+	m = updated.(Model)
+	for _, k := range strings.Split("2025-01-01..2025-01-31", "") {
+		updated, _ = m.Update(key(k))
+		m = updated.(Model)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.reportPrompt || m.reportRange != len(reportRanges)-1 || cmd == nil {
+		t.Fatalf("after the range prompt: prompt %v, range %d, note %q", m.reportPrompt, m.reportRange, m.projectNote)
+	}
+	scan(cmd)
+	if view := m.View(); !strings.Contains(view, "2025-01-01 – 2025-01-31") || !strings.Contains(view, "No sessions in this range") {
+		t.Errorf("custom range:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.viewMode != ViewProjects {
+		t.Errorf("esc: view %v, want ViewProjects", m.viewMode)
+	}
+}
+
+// TestCopySessionSummary tests that Y copies the summary line and confirms it in the status line
+func TestCopySessionSummary(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	var copied []string
+	m.clipboard = func(s string) { copied = append(copied, s) }
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	m = updated.(Model)
+
+	start := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	stats := &monitor.SessionStats{
+		FilePath:  "/tmp/3f2a9c1e.jsonl",
+		CreatedAt: start,
+		Duration:  2*time.Hour + 13*time.Minute,
+		MessageHistory: []monitor.Message{
+			{Type: "prompt", Role: "user", Content: "add login", GitBranch: "main"},
+			{Type: "assistant_response", Role: "assistant", Model: "claude-opus-4-5", InputTokens: 400_000, CacheCreation: 900_000, OutputTokens: 100_000, GitBranch: "feature/auth"},
+			{Type: "prompt", Role: "user", Content: "and logout", GitBranch: "feature/auth"},
+		},
+		Tokens: monitor.TokenCounts{Input: 400_000, CacheWrite: 900_000, Output: 100_000},
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.updateMessageTable()
+
+	updated, _ = m.Update(key("Y"))
+	m = updated.(Model)
+	want := fmt.Sprintf("Session 2h13m, 2 prompts, 1.4M tokens, $%.2f, branch feature/auth", pricing.SessionCost(stats))
+	if len(copied) != 1 || copied[0] != want {
+		t.Fatalf("copied %q, want %q", copied, want)
+	}
+	if view := m.View(); !strings.Contains(view, "✓ Copied: "+want) {
+		t.Errorf("confirmation missing from the view:\n%s", view)
+	}
+
+	// The note goes away with the next key, and a broken template is reported instead of copied
+	updated, _ = m.Update(key("s"))
+	m = updated.(Model)
+	if m.sessionNote != "" {
+		t.Errorf("note %q survived the next key", m.sessionNote)
+	}
+	m.cfg.Summary.Template = "{{.Nope}}"
+	updated, _ = m.Update(key("Y"))
+	m = updated.(Model)
+	if len(copied) != 1 || !strings.HasPrefix(m.sessionNote, "✗") {
+		t.Errorf("broken template: copied %q, note %q", copied, m.sessionNote)
+	}
+}
+
+// TestSessionID tests the session ID in the detail header: y copies it, the resume hint
+// names it, and an ID in the entries that differs from the file name is flagged, except
+// for side-chains, which record their owner's
+func TestSessionID(t *testing.T) {
+	tests := []struct {
+		name      string
+		recorded  string
+		sidechain bool
+		wantFlag  bool
+	}{
+		{"same ID", "3f2a9c1e", false, false},
+		{"no ID recorded", "", false, false},
+		{"copied by hand", "77aa01bc", false, true},
+		{"side-chain", "77aa01bc", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(time.Second, false)
+			defer m.Shutdown()
+			var copied []string
+			m.clipboard = func(s string) { copied = append(copied, s) }
+			updated, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+			m = updated.(Model)
+			m.viewMode = ViewSessionDetail
+			m.sessionStats = &monitor.SessionStats{
+				FilePath: "/tmp/3f2a9c1e.jsonl",
+				MessageHistory: []monitor.Message{
+					{Type: "prompt", Role: "user", Content: "hi", SessionID: tt.recorded, WorkingDir: "/home/demo/my app", IsSidechain: tt.sidechain},
+				},
+			}
+			m.updateMessageTable()
+
+			view := m.View()
+			if !strings.Contains(view, "ID: 3f2a9c1e") || !strings.Contains(view, "cd '/home/demo/my app' && claude --resume 3f2a9c1e") {
+				t.Errorf("ID or resume hint missing from the header:\n%s", view)
+			}
+			if flagged := strings.Contains(view, "entries name session"); flagged != tt.wantFlag {
+				t.Errorf("mismatch flagged = %v, want %v", flagged, tt.wantFlag)
+			}
+
+			updated, _ = m.Update(key("y"))
+			m = updated.(Model)
+			if len(copied) != 1 || copied[0] != "3f2a9c1e" {
+				t.Errorf("copied %q, want the file's ID", copied)
+			}
+		})
+	}
+}
+
+func TestCopyProjectPath(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	var copied []string
+	m.clipboard = func(s string) { copied = append(copied, s) }
+	m.viewMode = ViewProjects
+	m.projects = []ProjectDir{
+		{Name: "-home-demo-my-app", Path: "/home/demo/.claude/projects/-home-demo-my-app", DisplayName: "~/my-app", OriginalPath: "/home/demo/my-app"},
+		{Name: "-home-demo-old", Path: "/home/demo/.claude/projects/-home-demo-old", DisplayName: "~/old"},
+	}
+
+	tests := []struct {
+		name     string
+		view     ViewMode
+		selected int
+		key      string
+		want     string
+	}{
+		{"original path", ViewProjects, 0, "y", "/home/demo/my-app"},
+		{"encoded directory", ViewProjects, 0, "Y", "/home/demo/.claude/projects/-home-demo-my-app"},
+		{"no index falls back to the decoded name", ViewProjects, 1, "y", "~/old"},
+		{"project stats", ViewProjectStats, 0, "Y", "/home/demo/.claude/projects/-home-demo-my-app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copied = nil
+			m.viewMode = tt.view
+			m.selectedProjIdx = tt.selected
+			project := m.projects[tt.selected]
+			m.projectStatsPath, m.projectStatsName, m.projectStatsOriginal = project.Path, project.DisplayName, project.OriginalPath
+
+			updated, _ := m.Update(key(tt.key))
+			got := updated.(Model)
+			if len(copied) != 1 || copied[0] != tt.want {
+				t.Fatalf("copied %q, want %q", copied, tt.want)
+			}
+			if !strings.HasPrefix(got.projectNote, "✓ Copied: "+tt.want) {
+				t.Errorf("note = %q", got.projectNote)
+			}
+			if view := got.View(); !strings.Contains(view, "✓ Copied: "+tt.want) {
+				t.Errorf("confirmation missing from the view:\n%s", view)
+			}
+		})
+	}
+}
+
+// TestProjectProcesses tests that the projects view marks the projects a Claude
+// instance works in and jumps to its process with P
+func TestProjectProcesses(t *testing.T) {
+	projects := t.TempDir()
+	monitor.SetProjectsDir(projects)
+	t.Cleanup(func() { monitor.SetProjectsDir("") })
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewProjects
+	updated, _ = m.Update(projectsMsg{
+		projects: []ProjectDir{
+			{Name: "-work-api", Path: filepath.Join(projects, "-work-api"), OriginalPath: "/work/api"},
+			{Name: "-work-web", Path: filepath.Join(projects, "-work-web")}, // No sessions index
+			{Name: "-work-old", Path: filepath.Join(projects, "-work-old"), OriginalPath: "/work/old"},
+		},
+		processes: []types.ClaudeProcess{
+			{PID: 4310, WorkingDir: "/work/web"},
+			{PID: 4242, WorkingDir: "/work/api/"},
+			{PID: 99, WorkingDir: "/work/api", IsHelper: true},
+			{PID: 4301, WorkingDir: "/work/web"},
+			{PID: 77, WorkingDir: "/work/old", WorkDirGone: true},
+		},
+	})
+	m = updated.(Model)
+
+	rows := m.projectsTable.GetVisibleRows()
+	for i, want := range []string{"● running (pid 4242)", "● running (pid 4301 +1)", ""} {
+		if got := rows[i].Data["running"].(table.StyledCell).Data; got != want {
+			t.Errorf("project %d: RUNNING = %q, want %q", i, got, want)
+		}
+	}
+
+	m.selectedProjIdx = 2
+	updated, _ = m.Update(key("P"))
+	m = updated.(Model)
+	if m.viewMode != ViewProjects || m.projectNote != "No Claude process is running in this project" {
+		t.Errorf("P on a project without a process: view %v, note %q", m.viewMode, m.projectNote)
+	}
+
+	m.selectedProjIdx = 1
+	updated, _ = m.Update(key("P"))
+	m = updated.(Model)
+	if m.viewMode != ViewProcesses || m.processes[m.selectedProcIdx].PID != 4301 {
+		t.Errorf("P jumped to view %v, process %d; want the process view at 4301", m.viewMode, m.processes[m.selectedProcIdx].PID)
+	}
+}
+
+// noDiscovery is a process provider that fails the test when processes are listed
+type noDiscovery struct {
+	monitor.ProcessProvider
+	t *testing.T
+}
+
+func (p noDiscovery) List() ([]int32, error) {
+	p.t.Error("processes were listed with process discovery off")
+	return nil, nil
+}
+
+// TestWithoutProcesses tests that with process discovery off promptwatch starts in the
+// projects view, never lists processes and offers no way into the process view
+func TestWithoutProcesses(t *testing.T) {
+	projects := t.TempDir()
+	monitor.SetProjectsDir(projects)
+	monitor.SetProcessProvider(noDiscovery{t: t})
+	t.Cleanup(func() {
+		monitor.SetProjectsDir("")
+		monitor.SetProcessProvider(nil)
+	})
+	if err := os.MkdirAll(filepath.Join(projects, "-work-api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.NoProcesses = true
+	m := NewModel(time.Second, false).WithConfig(cfg).WithStartView("processes")
+	defer m.Shutdown()
+	if m.viewMode != ViewProjects {
+		t.Fatalf("started in view %v, want the projects view", m.viewMode)
+	}
+	if m.refreshProcesses() != nil {
+		t.Error("refreshProcesses returned a discovery")
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	msg := m.loadProjects()().(projectsMsg)
+	if len(msg.projects) != 1 || msg.processes != nil {
+		t.Fatalf("loadProjects: %d projects, processes %v; want 1 project and no processes", len(msg.projects), msg.processes)
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(Model)
+	if view := m.View(); strings.Contains(view, render.RunningHeader) || strings.Contains(view, "p: Processes") {
+		t.Errorf("projects view offers processes:\n%s", view)
+	}
+
+	for _, k := range []string{"p", "P"} {
+		updated, _ = m.Update(key(k))
+		m = updated.(Model)
+		if m.viewMode != ViewProjects {
+			t.Errorf("%s left the projects view for view %v", k, m.viewMode)
+		}
+	}
+}
+
+func TestRunningTotal(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	history := []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "first"},
+		{Type: "assistant_response", Role: "assistant", Content: "one", OutputTokens: 10_000},
+		{Type: "prompt", Role: "user", Content: "second"},
+		{Type: "assistant_response", Role: "assistant", Content: "two", OutputTokens: 20_000},
+		{Type: "assistant_response", Role: "assistant", Content: "three", OutputTokens: 30_000},
+	}
+	stats := &monitor.SessionStats{MessageHistory: history}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.updateMessageTable()
+
+	var want []float64 // Chronological totals by history index
+	total := 0.0
+	for i := range history {
+		total += pricing.MessageCost(&history[i])
+		want = append(want, total)
+	}
+
+	// Totals stay chronological when reversed and filtered
+	for _, keys := range [][]string{{}, {"s"}, {"a"}} {
+		for _, k := range keys {
+			updated, _ := m.Update(key(k))
+			m = updated.(Model)
+		}
+		for _, row := range m.messages {
+			if row.CumulativeCost != want[row.HistoryIdx] {
+				t.Errorf("after %v: message %d total = %g, want %g", keys, row.HistoryIdx, row.CumulativeCost, want[row.HistoryIdx])
+			}
+		}
+	}
+	if strings.Contains(m.View(), "Σ") {
+		t.Error("running totals shown before c was pressed")
+	}
+	updated, _ = m.Update(key("c"))
+	m = updated.(Model)
+	if view := m.View(); !strings.Contains(view, fmt.Sprintf("Σ $%.2f", want[4])) {
+		t.Errorf("c should show the running total:\n%s", view)
+	}
+}
+
+func TestFilterPresets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Filters.Presets = []config.FilterPreset{
+		{Name: "errors only", Match: config.Predicate{Role: "assistant", IsError: true, Contains: "Error"}},
+		{Name: "bash", Match: config.Predicate{Tool: "Bash"}},
+	}
+	m := NewModel(time.Second, false).WithConfig(cfg)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{
+		MessageHistory: []monitor.Message{
+			{Type: "prompt", Role: "user", Content: "run the tests"},
+			{Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash"},
+			{Type: "tool_result", Role: "user", Content: "Error: 2 tests failed", IsError: true},
+			{Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash"},
+			{Type: "tool_result", Role: "user", Content: "ok"},
+		},
+	}
+	m.updateMessageTable()
+
+	press := func(keys ...string) {
+		t.Helper()
+		for _, k := range keys {
+			updated, _ := m.Update(key(k))
+			m = updated.(Model)
+		}
+	}
+
+	press("1", "f")
+	if m.filteredMessageCount != 1 || !strings.Contains(m.View(), "[All Messages: 1 · preset errors only]") {
+		t.Errorf("preset 1: %d messages, view:\n%s", m.filteredMessageCount, m.View())
+	}
+	// Presets combine with the role filter
+	press("2", "f", "u")
+	if m.filteredMessageCount != 0 {
+		t.Errorf("preset bash with the user filter: %d messages, want 0", m.filteredMessageCount)
+	}
+	press("b", "f")
+	if m.filterPreset != 0 || m.filteredMessageCount != 5 {
+		t.Errorf("f should clear the preset: preset %d, %d messages", m.filterPreset, m.filteredMessageCount)
+	}
+	press("f")
+	if !strings.Contains(m.sessionNote, "1f errors only, 2f bash") {
+		t.Errorf("f without a preset should list them, note %q", m.sessionNote)
+	}
+	press("7", "f")
+	if m.filterPreset != 0 || !strings.Contains(m.sessionNote, "No filter preset 7") {
+		t.Errorf("unknown preset: preset %d, note %q", m.filterPreset, m.sessionNote)
+	}
+}
+
+// TestFilteredCost tests that a narrowing filter shows the messages, tokens and cost of
+// just the messages it shows, whatever the sort order and grouping
+func TestFilteredCost(t *testing.T) {
+	cfg := config.Default()
+	cfg.Filters.Presets = []config.FilterPreset{{Name: "bash", Match: config.Predicate{Tool: "Bash"}}}
+	m := NewModel(time.Second, false).WithConfig(cfg)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewSessionDetail
+	history := []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "refactor"},
+		{Type: "assistant_response", Role: "assistant", Model: "claude-opus-4-5", ToolName: "Bash", InputTokens: 100_000, OutputTokens: 2_000},
+		{Type: "tool_result", Role: "user", Content: "ok"},
+		{Type: "assistant_response", Role: "assistant", Model: "claude-opus-4-5", Content: "done", InputTokens: 110_000, CacheCreation: 10_000, OutputTokens: 1_000},
+	}
+	m.sessionStats = &monitor.SessionStats{MessageHistory: history}
+	cost := func(idx ...int) float64 {
+		total := 0.0
+		for _, i := range idx {
+			total += pricing.MessageCost(&history[i])
+		}
+		return total
+	}
+
+	tests := []struct {
+		name        string
+		filter      MessageFilter
+		preset      int
+		newestFirst bool
+		groupByTurn bool
+		want        string
+	}{
+		{name: "all messages", filter: FilterAll, want: ""},
+		{name: "responses", filter: FilterAssistantOnly, newestFirst: true, want: fmt.Sprintf("filtered: 3 msgs, 223k tokens, $%.2f", cost(1, 2, 3))},
+		{name: "responses oldest first", filter: FilterAssistantOnly, newestFirst: false, want: fmt.Sprintf("filtered: 3 msgs, 223k tokens, $%.2f", cost(1, 2, 3))},
+		{name: "preset", filter: FilterAll, preset: 1, newestFirst: true, want: fmt.Sprintf("filtered: 1 msg, 102k tokens, $%.2f", cost(1))},
+		{name: "preset by turn", filter: FilterAll, preset: 1, groupByTurn: true, want: fmt.Sprintf("filtered: 1 msg, 102k tokens, $%.2f", cost(1))},
+		{name: "preset and prompts", filter: FilterUserOnly, preset: 1, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.messageFilter, m.filterPreset = tt.filter, tt.preset
+			m.messageSortNewestFirst, m.groupByTurn = tt.newestFirst, tt.groupByTurn
+			m.updateMessageTable()
+			view := m.View()
+			if tt.want == "" {
+				if strings.Contains(view, "filtered:") {
+					t.Errorf("unexpected filtered cost in\n%s", view)
+				}
+				return
+			}
+			if !strings.Contains(view, tt.want) {
+				t.Errorf("want %q in\n%s", tt.want, view)
+			}
+		})
+	}
+}
+
+// TestDeniedToolCalls tests that "d" lists the tool calls denied at a permission prompt
+// and that their cards carry the decision
+func TestDeniedToolCalls(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{MessageHistory: []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "clean up"},
+		{Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash", PermissionDecision: monitor.DecisionDenied},
+		{Type: "tool_result", Role: "user", Content: "The user doesn't want to proceed with this tool use.", IsError: true},
+		{Type: "assistant_response", Role: "assistant", Content: "Called tool: Edit", ToolName: "Edit", PermissionDecision: monitor.DecisionAllowed},
+		{Type: "tool_result", Role: "user", Content: "edited"},
+	}}
+	m.updateMessageTable()
+	m.refreshMessageCards()
+	if view := m.View(); !strings.Contains(view, "⛔ denied") || !strings.Contains(view, "🔒 allowed") {
+		t.Errorf("decision badges missing:\n%s", view)
+	}
+
+	updated, _ = m.Update(key("d"))
+	m = updated.(Model)
+	if m.filteredMessageCount != 1 || m.messageAtRow(0).ToolName != "Bash" {
+		t.Fatalf("denied filter lists %d messages, want the Bash call", m.filteredMessageCount)
+	}
+	if view := m.View(); !strings.Contains(view, "[Denied Tool Calls: 1]") {
+		t.Errorf("filter status missing:\n%s", view)
+	}
+}
+
+// TestFilterKeepsSelection tests that switching the role filter keeps the selected
+// message selected when it stays shown, else selects the shown message nearest in time,
+// and scrolls the selection into view
+func TestFilterKeepsSelection(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = updated.(Model)
+
+	start := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	var history []monitor.Message
+	for i := 0; i < 40; i++ {
+		msg := monitor.Message{Type: "prompt", Role: "user", Content: fmt.Sprintf("prompt %d", i)}
+		if i%2 == 1 {
+			msg = monitor.Message{Type: "assistant_response", Role: "assistant", Content: fmt.Sprintf("response %d", i)}
+		}
+		msg.UUID = fmt.Sprintf("uuid-%02d", i)
+		msg.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		history = append(history, msg)
+	}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{MessageHistory: history}
+	m.messageSortNewestFirst = false
+	m.updateMessageTable()
+	m.selectedMessageIdx = m.rowOfMessage(30)
+	m.refreshMessageCards()
+	m.scrollToSelection()
+
+	selectedUUID := func() string {
+		if msg := m.messageAtRow(m.selectedMessageIdx); msg != nil {
+			return msg.UUID
+		}
+		return ""
+	}
+	steps := []struct {
+		key  string
+		want string
+	}{
+		{"u", "uuid-30"}, // A prompt stays shown
+		{"b", "uuid-30"},
+		{"a", "uuid-29"}, // Hidden: the nearest response, the earlier one on a tie
+		{"b", "uuid-29"},
+		{"u", "uuid-28"},
+		{"a", "uuid-27"},
+		{"b", "uuid-27"},
+	}
+	for _, step := range steps {
+		updated, _ = m.Update(key(step.key))
+		m = updated.(Model)
+		if got := selectedUUID(); got != step.want {
+			t.Fatalf("after %q: selected %s, want %s", step.key, got, step.want)
+		}
+		top := m.messageViewport.YOffset / render.CardLines
+		if m.selectedMessageIdx < top || m.selectedMessageIdx >= top+max(m.messageViewport.Height/render.CardLines, 1) {
+			t.Errorf("after %q: selected row %d is off screen (rows from %d shown)", step.key, m.selectedMessageIdx, top)
+		}
+	}
+}
+
+// TestRefusals tests that "X" steps through the refusals, wrapping around, that "x"
+// lists them and that their cards carry a badge
+func TestRefusals(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{MessageHistory: []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "write a keylogger"},
+		{Type: "assistant_response", Role: "assistant", Content: "I can't help with that.", Refusal: true},
+		{Type: "prompt", Role: "user", Content: "fix the build"},
+		{Type: "assistant_response", Role: "assistant", Content: "Fixed."},
+		{Type: "prompt", Role: "user", Content: "now log my keys"},
+		{Type: "assistant_response", Role: "assistant", Content: "(response stopped: refusal)", Refusal: true},
+	}}
+	m.updateMessageTable()
+	m.selectedMessageIdx = 0
+	m.refreshMessageCards()
+	if view := m.View(); !strings.Contains(view, "🚫 refusal") {
+		t.Errorf("refusal badge missing:\n%s", view)
+	}
+
+	for _, want := range []string{"I can't help with that.", "(response stopped: refusal)", "I can't help with that."} {
+		updated, _ = m.Update(key("X"))
+		m = updated.(Model)
+		if msg := m.messageAtRow(m.selectedMessageIdx); msg == nil || msg.Content != want {
+			t.Fatalf("\"!\" selected %+v, want %q", msg, want)
+		}
+	}
+	// Newest first, the first refusal of the session is the second row shown
+	if m.messageError != "Refusal 2 of 2" {
+		t.Errorf("status %q, want \"Refusal 2 of 2\"", m.messageError)
+	}
+
+	updated, _ = m.Update(key("x"))
+	m = updated.(Model)
+	if m.filteredMessageCount != 2 {
+		t.Fatalf("refusal filter lists %d messages, want 2", m.filteredMessageCount)
+	}
+	if view := m.View(); !strings.Contains(view, "[Refusals: 2]") {
+		t.Errorf("filter status missing:\n%s", view)
+	}
+}
+
+// TestToolGrouping tests sectioning the tool filter's calls by tool: the sections'
+// order, their headers' navigation and collapsing, and that only the tool filter groups
+func TestToolGrouping(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+
+	start := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	call := func(tool string, minute int) monitor.Message {
+		return monitor.Message{Type: "assistant_response", Role: "assistant", Content: "Called tool: " + tool, ToolName: tool, Timestamp: start.Add(time.Duration(minute) * time.Minute)}
+	}
+	m.viewMode = ViewSessionDetail
+	m.messageSortNewestFirst = false
+	m.sessionStats = &monitor.SessionStats{MessageHistory: []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "fix the build", Timestamp: start},
+		call("Read", 1), call("Bash", 2), call("Edit", 3), call("Bash", 4),
+		{Type: "assistant_response", Role: "assistant", Content: "Fixed.", Timestamp: start.Add(5 * time.Minute)},
+		call("Bash", 6),
+	}}
+	m.updateMessageTable()
+
+	press := func(k string) {
+		t.Helper()
+		msg := key(k)
+		if k == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	rows := func() string {
+		var parts []string
+		for _, row := range m.messages {
+			if row.ToolGroup != "" {
+				parts = append(parts, fmt.Sprintf("%s(%d)", row.ToolGroup, row.ToolGroupCalls))
+			} else {
+				parts = append(parts, fmt.Sprint(row.HistoryIdx))
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
+	press("T")
+	if m.groupByTool || !strings.Contains(m.messageError, "needs the tool filter") {
+		t.Fatalf("T without the tool filter: grouped %v, status %q", m.groupByTool, m.messageError)
+	}
+
+	press("t")
+	press("T")
+	if got, want := rows(), "Bash(3) 2 4 6 Edit(1) 3 Read(1) 1"; got != want {
+		t.Fatalf("grouped rows %q, want %q", got, want)
+	}
+	if view := m.View(); !strings.Contains(view, "[Tool Calls: 5 · grouped by tool (3 tools)]") || !strings.Contains(view, "▾ Read (1)") {
+		t.Errorf("grouping missing from the view:\n%s", view)
+	}
+
+	m.selectedMessageIdx = 0
+	press("n")
+	press("n")
+	if m.selectedMessageIdx != 6 {
+		t.Errorf("n n selected row %d, want the Read header at 6", m.selectedMessageIdx)
+	}
+	press("N")
+	if m.selectedMessageIdx != 4 {
+		t.Errorf("N selected row %d, want the Edit header at 4", m.selectedMessageIdx)
+	}
+
+	m.selectedMessageIdx = 0
+	press("enter")
+	if got, want := rows(), "Bash(3) Edit(1) 3 Read(1) 1"; got != want || m.selectedMessageIdx != 0 {
+		t.Errorf("after collapsing Bash: rows %q with row %d selected, want %q with the header", got, m.selectedMessageIdx, want)
+	}
+	for _, r := range "3G" {
+		press(string(r))
+	}
+	if row := m.messages[m.selectedMessageIdx]; row.HistoryIdx != 6 || m.collapsedTools["Bash"] {
+		t.Errorf("3G selected %+v, want the third Bash call in its expanded section", row)
+	}
+
+	press("b")
+	if m.groupByTool || strings.Contains(rows(), "(") {
+		t.Errorf("leaving the tool filter kept the grouping: %q", rows())
+	}
+}
+
+func TestDiffView(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+
+	start := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{
+		MessageHistory: []monitor.Message{
+			{Type: "prompt", Role: "user", Content: "write a plan", Timestamp: start},
+			{Type: "assistant_response", Role: "assistant", Content: "1. step one\n2. step two\n3. ship it", Timestamp: start.Add(time.Minute)},
+			{Type: "prompt", Role: "user", Content: "shorter please", Timestamp: start.Add(2 * time.Minute)},
+			{Type: "assistant_response", Role: "assistant", Content: "1. step one\n2. step 2\n3. ship it", Timestamp: start.Add(3 * time.Minute)},
+		},
+	}
+	m.updateMessageTable()
+	press := func(k string) {
+		t.Helper()
+		updated, _ := m.Update(key(k))
+		m = updated.(Model)
+	}
+	selectContent := func(content string) {
+		t.Helper()
+		for i := range m.messages {
+			if msg := m.messageAtRow(i); msg != nil && msg.Content == content {
+				m.selectedMessageIdx = i
+				return
+			}
+		}
+		t.Fatalf("no message row with content %q", content)
+	}
+
+	// "=" needs a marked message, and another one selected
+	selectContent("1. step one\n2. step two\n3. ship it")
+	press("=")
+	if m.viewMode != ViewSessionDetail || m.sessionNote != "Mark a message with m first" {
+		t.Fatalf("= without a mark: view %d, note %q", m.viewMode, m.sessionNote)
+	}
+	press("m")
+	if m.diffMark == nil || !strings.Contains(m.renderMessageCards(), "◆ marked") {
+		t.Fatalf("m did not mark the message (note %q)", m.sessionNote)
+	}
+	press("=")
+	if m.viewMode != ViewSessionDetail {
+		t.Fatalf("= on the marked message itself opened the diff view")
+	}
+
+	selectContent("1. step one\n2. step 2\n3. ship it")
+	press("=")
+	if m.viewMode != ViewDiff {
+		t.Fatalf("= did not open the diff view (note %q)", m.sessionNote)
+	}
+	view := m.View()
+	for _, want := range []string{"- 2. step two", "+ 2. step 2", "  3. ship it", "1 line removed, 1 line added"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("line diff is missing %q:\n%s", want, view)
+		}
+	}
+
+	// w switches to word granularity and back
+	press("w")
+	view = m.View()
+	for _, want := range []string{"2. step [-two-]{+2+}", "1 word removed, 1 word added"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("word diff is missing %q:\n%s", want, view)
+		}
+	}
+	press("w")
+	if m.diffWords {
+		t.Error("second w did not switch back to lines")
+	}
+
+	// esc returns to the session detail view, keeping the mark for the next diff
+	press("esc")
+	if m.viewMode != ViewSessionDetail || m.diffMark == nil {
+		t.Errorf("esc: view %d, mark %v", m.viewMode, m.diffMark)
+	}
+}
+
+func TestScaleScrollOffset(t *testing.T) {
+	tests := []struct {
+		name                                   string
+		offset, oldTotal, newTotal, pageHeight int
+		want                                   int
+	}{
+		{"narrower keeps the position", 50, 100, 200, 20, 100},
+		{"wider keeps the position", 100, 200, 100, 20, 50},
+		{"clamped to the new end", 90, 100, 60, 20, 40},
+		{"everything fits", 10, 100, 15, 20, 0},
+		{"nothing wrapped before", 5, 0, 40, 20, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scaleScrollOffset(tt.offset, tt.oldTotal, tt.newTotal, tt.pageHeight); got != tt.want {
+				t.Errorf("scaleScrollOffset(%d, %d, %d, %d) = %d, want %d",
+					tt.offset, tt.oldTotal, tt.newTotal, tt.pageHeight, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageDetailResize(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 30})
+	m = updated.(Model)
+
+	var paragraphs []string
+	for i := 0; i < 40; i++ {
+		paragraphs = append(paragraphs, fmt.Sprintf("paragraph %d: %s", i, strings.Repeat("lorem ipsum ", 12)))
+	}
+	msg := monitor.Message{Role: "assistant", Content: strings.Join(paragraphs, "\n")}
+	m.viewMode = ViewMessageDetail
+	m.detailMessage = &msg
+
+	// Capped at 80 columns, each paragraph wraps to two lines
+	wide := len(m.detailLines())
+	if wide != 80 {
+		t.Fatalf("%d lines at 200 columns, want 80", wide)
+	}
+	m.detailScrollOffset = 40 // Paragraph 20
+
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 50, Height: 30})
+	m = updated.(Model)
+	narrow := len(m.detailLines())
+	if narrow <= wide {
+		t.Fatalf("%d lines at 50 columns, want more than %d", narrow, wide)
+	}
+	if want := 40 * narrow / wide; m.detailScrollOffset != want {
+		t.Errorf("offset after narrowing = %d, want %d", m.detailScrollOffset, want)
+	}
+	if view := m.View(); !strings.Contains(view, "paragraph 20:") {
+		t.Errorf("paragraph 20 scrolled out of view after resizing:\n%s", view)
+	}
+
+	// Full width wraps to the whole terminal; a shorter terminal clamps the offset
+	m.cfg.Detail.FullWidth = true
+	m.detailScrollOffset = len(m.detailLines()) - render.DetailPageHeight(30)
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 300, Height: 10})
+	m = updated.(Model)
+	if got := len(m.detailLines()); got != 40 {
+		t.Errorf("%d lines at 300 columns with full width, want 40", got)
+	}
+	if maxOffset := 40 - render.DetailPageHeight(10); m.detailScrollOffset > maxOffset {
+		t.Errorf("offset %d beyond the last page (%d)", m.detailScrollOffset, maxOffset)
+	}
+}
+
+func TestAdjustColumnWidth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m := NewModel(time.Second, false).WithStateFile(path)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	m = updated.(Model)
+	m.setProcesses([]types.ClaudeProcess{{PID: 1, WorkingDir: "/srv/" + strings.Repeat("deeply/nested/", 10) + "project"}})
+	m.updateTable()
+
+	before := m.processColumns.Workdir
+	updated, cmd := m.Update(key(">"))
+	m = updated.(Model)
+	if m.processColumns.Workdir <= before || m.columnShare(processTableName) != render.DefaultWorkdirShare+columnShareStep {
+		t.Fatalf("> kept WORKDIR at %d columns (was %d)", m.processColumns.Workdir, before)
+	}
+	if got := m.table.GetVisibleRows()[0].Data["workdir"].(string); len(got) != m.processColumns.Workdir {
+		t.Errorf("workdir %q not truncated to the new column width %d", got, m.processColumns.Workdir)
+	}
+	if cmd == nil {
+		t.Fatal("> did not save the column width")
+	}
+	if msg := cmd().(stateSavedMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	st, err := state.Load(path)
+	if err != nil || st.ColumnShares[processTableName] != render.DefaultWorkdirShare+columnShareStep {
+		t.Errorf("saved state = %+v, %v", st, err)
+	}
+
+	// The share stops at its bounds, and a restarted model picks up the saved share
+	for i := 0; i < 20; i++ {
+		updated, _ = m.Update(key("<"))
+		m = updated.(Model)
+	}
+	if got := m.columnShare(processTableName); got != minColumnShare {
+		t.Errorf("share after shrinking = %d, want %d", got, minColumnShare)
+	}
+	restored := NewModel(time.Second, false).WithColumnShares(st.ColumnShares)
+	defer restored.Shutdown()
+	if got := restored.columnShare(processTableName); got != render.DefaultWorkdirShare+columnShareStep {
+		t.Errorf("restored share = %d", got)
+	}
+
+	// In the projects view the keys resize the PROJECT column
+	m.viewMode = ViewProjects
+	before = m.projectNameWidth
+	updated, _ = m.Update(key(">"))
+	m = updated.(Model)
+	if m.projectNameWidth <= before {
+		t.Errorf("> kept PROJECT at %d columns (was %d)", m.projectNameWidth, before)
+	}
+}
+
+// TestRedaction tests that Z masks content and paths in lists, cards and the detail
+// view, keeps the metrics and leaves the loaded data alone
+func TestRedaction(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+
+	m.viewMode = ViewSessions
+	m.sessionSourceMode = ViewRecent
+	m.sessions = []SessionInfo{{
+		ID: "3f2a9c1e", Path: "/projects/-clients-acme-api/3f2a9c1e.jsonl", Project: "/clients/acme/api",
+		Title: "rotate the acme-prod secrets", LastMessage: "Done rotating acme-prod keys",
+		Tokens: monitor.TokenCounts{Output: 1234},
+	}}
+	m.updateSessionTable()
+	updated, _ = m.Update(key("Z"))
+	m = updated.(Model)
+	view := m.View()
+	if strings.Contains(view, "acme-prod") || strings.Contains(view, "/clients/acme") {
+		t.Errorf("redacted session list shows content or paths:\n%s", view)
+	}
+	if !strings.Contains(view, "REDACTED") || !strings.Contains(view, "…/api") || !strings.Contains(view, "1.2k") {
+		t.Errorf("redacted session list lacks the badge, repository name or tokens:\n%s", view)
+	}
+	if m.sessions[0].Title != "rotate the acme-prod secrets" {
+		t.Errorf("redaction changed the session title to %q", m.sessions[0].Title)
+	}
+
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{
+		FilePath: "/projects/-clients-acme-api/3f2a9c1e.jsonl",
+		MessageHistory: []monitor.Message{
+			{Type: "prompt", Role: "user", Content: "rotate the acme-prod secrets", WorkingDir: "/clients/acme/api"},
+			{Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash",
+				ToolInput: `{"command":"vault rotate acme-prod"}`, OutputTokens: 420, Model: "claude-sonnet-4-5"},
+		},
+		Turns: []monitor.Turn{{Index: 1, Start: 0, End: 2}},
+	}
+	m.updateMessageTable()
+	if view := m.View(); strings.Contains(view, "acme-prod") || !strings.Contains(view, "redacted") || !strings.Contains(view, "REDACTED") {
+		t.Errorf("redacted cards show content or lack the placeholder:\n%s", view)
+	}
+
+	m.selectedMessageIdx = 0 // Newest first: the tool call
+	updated, _ = m.Update(key("enter"))
+	m = updated.(Model)
+	if m.viewMode != ViewMessageDetail {
+		t.Fatalf("enter: view %v, want the message detail", m.viewMode)
+	}
+	view = m.View()
+	if strings.Contains(view, "acme-prod") || strings.Contains(view, "vault") || !strings.Contains(view, "Bash") || !strings.Contains(view, "420") {
+		t.Errorf("redacted detail shows content or lacks the tool name and tokens:\n%s", view)
+	}
+	if got := m.detailMessage.ToolInput; !strings.Contains(got, "acme-prod") {
+		t.Errorf("redaction changed the tool input to %q", got)
+	}
+
+	// Z again shows everything
+	updated, _ = m.Update(key("Z"))
+	m = updated.(Model)
+	if view := m.View(); !strings.Contains(view, "acme-prod") || strings.Contains(view, "REDACTED") {
+		t.Errorf("unredacted detail hides content:\n%s", view)
+	}
+}
+
+func TestExportMessages(t *testing.T) {
+	cfg := config.Default()
+	cfg.Export = config.ExportConfig{Format: "markdown", Dir: t.TempDir()}
+	m := NewModel(time.Second, false).WithConfig(cfg)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{
+		FilePath: "/projects/-app/3f2a9c1e.jsonl",
+		MessageHistory: []monitor.Message{
+			{Type: "prompt", Role: "user", Content: "run the tests"},
+			{Type: "assistant_response", Role: "assistant", Content: "Called tool: Bash", ToolName: "Bash", ToolInput: `{"command":"go test"}`},
+			{Type: "tool_result", Role: "user", Content: "FAIL: TestLoad", IsError: true},
+			{Type: "assistant_response", Role: "assistant", Content: "The config test fails."},
+		},
+		Turns: []monitor.Turn{{Index: 1, Start: 0, End: 4}},
+	}
+	m.updateMessageTable()
+
+	// export presses the keys, runs the export command and returns the file written
+	export := func(keys ...string) string {
+		t.Helper()
+		var cmd tea.Cmd
+		for _, k := range keys {
+			var updated tea.Model
+			updated, cmd = m.Update(key(k))
+			m = updated.(Model)
+		}
+		if cmd == nil {
+			t.Fatalf("%v: no export started (note %q)", keys, m.sessionNote)
+		}
+		done, ok := cmd().(exportedMsg)
+		if !ok || done.err != nil {
+			t.Fatalf("%v: export failed: %+v", keys, done)
+		}
+		updated, _ := m.Update(done)
+		m = updated.(Model)
+		if !strings.Contains(m.sessionNote, done.path) {
+			t.Errorf("note %q does not name %s", m.sessionNote, done.path)
+		}
+		data, err := os.ReadFile(done.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// e exports what the assistant filter shows and says which filter was active
+	out := export("a", "e")
+	if !strings.Contains(out, "- **Messages:** 3 (Claude responses)") || strings.Contains(out, "run the tests") {
+		t.Errorf("filtered export:\n%s", out)
+	}
+
+	// E exports the selected tool call with its result only
+	updated, _ = m.Update(key("b"))
+	m = updated.(Model)
+	for i, row := range m.messages {
+		if row.HistoryIdx == 1 { // The Bash call
+			m.selectedMessageIdx = i
+		}
+	}
+	out = export("E")
+	if !strings.Contains(out, "message #2 with #3") || !strings.Contains(out, "FAIL: TestLoad") || strings.Contains(out, "The config test fails.") {
+		t.Errorf("selected message export:\n%s", out)
+	}
+
+	// Grouped by tool, the export keeps a section per tool (written afresh, as the
+	// filtered export above has the same name within the same second)
+	if err := os.RemoveAll(cfg.Export.Dir); err != nil {
+		t.Fatal(err)
+	}
+	out = export("t", "T", "e")
+	if !strings.Contains(out, "- **Grouped by:** tool") || !strings.Contains(out, "\n## Bash (1)\n") || !strings.Contains(out, "### #2 · assistant") {
+		t.Errorf("grouped export:\n%s", out)
+	}
+
+	// Read-only mode writes nothing
+	m.cfg.ReadOnly = true
+	updated, cmd := m.Update(key("e"))
+	m = updated.(Model)
+	if cmd != nil || !strings.Contains(m.sessionNote, "Read-only") {
+		t.Errorf("read-only export: cmd %v, note %q", cmd != nil, m.sessionNote)
+	}
+}
+
+// TestExportOverwrite tests that an export about to replace a file asks first, and
+// writes nothing when the question is dismissed
+func TestExportOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.Export = config.ExportConfig{Format: "markdown", Dir: dir}
+	m := NewModel(time.Second, false).WithConfig(cfg)
+	defer m.Shutdown()
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = &monitor.SessionStats{
+		FilePath:       "/projects/-app/3f2a9c1e.jsonl",
+		MessageHistory: []monitor.Message{{Type: "prompt", Role: "user", Content: "run the tests"}},
+		Turns:          []monitor.Turn{{Index: 1, Start: 0, End: 1}},
+	}
+	m.updateMessageTable()
+
+	// Exports are named by the second; claim this one and the next
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(time.Second)} {
+		path := filepath.Join(dir, "3f2a9c1e-"+at.Format("20060102-150405")+".md")
+		if err := os.WriteFile(path, []byte("keep me"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	press := func(k string) tea.Cmd {
+		updated, cmd := m.Update(key(k))
+		m = updated.(Model)
+		return cmd
+	}
+	if cmd := press("e"); cmd != nil || m.confirm == nil || !m.confirm.destructive {
+		t.Fatalf("export over an existing file: cmd %v, confirmation %+v", cmd != nil, m.confirm)
+	}
+	if view := m.View(); !strings.Contains(view, "⚠ Overwrite") {
+		t.Errorf("overwrite question not shown:\n%s", view)
+	}
+	if cmd := press("j"); cmd != nil || m.confirm == nil {
+		t.Fatal("j should be swallowed while the question is showing")
+	}
+	if cmd := press("esc"); cmd != nil || m.confirm != nil || m.viewMode != ViewSessionDetail {
+		t.Fatalf("esc should only dismiss the question: cmd %v, view %v", cmd != nil, m.viewMode)
+	}
+
+	press("e")
+	cmd := press("y")
+	if cmd == nil {
+		t.Fatal("y should start the export")
+	}
+	done := cmd().(exportedMsg)
+	data, err := os.ReadFile(done.path)
+	if done.err != nil || err != nil || !strings.Contains(string(data), "run the tests") {
+		t.Errorf("confirmed export: %+v, %v, %q", done, err, data)
+	}
+}
+
+func TestPairedToolMessage(t *testing.T) {
+	stats := &monitor.SessionStats{MessageHistory: []monitor.Message{
+		{Type: "prompt"},
+		{Type: "assistant_response", ToolName: "Read"},
+		{Type: "tool_result"},
+		{Type: "assistant_response"},
+		{Type: "assistant_response", ToolName: "Bash"},
+		{Type: "prompt"},
+		{Type: "tool_result"},
+	}}
+	tests := []struct {
+		h, want int
+	}{
+		{h: 1, want: 2},
+		{h: 2, want: 1},
+		{h: 3, want: -1}, // No tool call
+		{h: 4, want: -1}, // Result not seen before the next prompt
+		{h: 6, want: -1}, // Call belongs to an earlier turn
+	}
+	for _, tt := range tests {
+		if got := pairedToolMessage(stats, tt.h); got != tt.want {
+			t.Errorf("pairedToolMessage(%d) = %d, want %d", tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestBranchSwitches(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+
+	start := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	history := []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "first", GitBranch: "main", Timestamp: start},
+		{Type: "assistant_response", Role: "assistant", Content: "one", GitBranch: "main", Timestamp: start.Add(time.Minute)},
+		{Type: "prompt", Role: "user", Content: "second", GitBranch: "feature/x", Timestamp: start.Add(10 * time.Minute)},
+		{Type: "assistant_response", Role: "assistant", Content: "two", Timestamp: start.Add(11 * time.Minute)},
+		{Type: "prompt", Role: "user", Content: "third", GitBranch: "main", Timestamp: start.Add(20 * time.Minute)},
+	}
+	stats := &monitor.SessionStats{MessageHistory: history}
+	stats.Branches = []monitor.BranchChange{{Branch: "main", Since: start}, {Branch: "feature/x", Since: start.Add(10 * time.Minute)}, {Branch: "main", Since: start.Add(20 * time.Minute)}}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+
+	// Dividers sit between the messages on either side of a switch, in both orders
+	for _, tc := range []struct {
+		keys []string
+		want []string
+	}{
+		{nil, []string{"4", "feature/x→main", "3", "2", "main→feature/x", "1", "0"}},
+		{[]string{"s"}, []string{"0", "1", "main→feature/x", "2", "3", "feature/x→main", "4"}},
+	} {
+		for _, k := range tc.keys {
+			updated, _ := m.Update(key(k))
+			m = updated.(Model)
+		}
+		m.updateMessageTable()
+		var got []string
+		for i, row := range m.messages {
+			if row.BranchSwitch != "" {
+				got = append(got, row.BranchFrom+"→"+row.BranchSwitch)
+				if msg := m.messageAtRow(i); msg != nil {
+					t.Errorf("divider to %q refers to message %q", row.BranchSwitch, msg.Content)
+				}
+			} else {
+				got = append(got, fmt.Sprint(row.HistoryIdx))
+			}
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("after %v: rows %q, want %q", tc.keys, got, tc.want)
+		}
+	}
+
+	m.refreshMessageCards()
+	view := m.View()
+	for _, want := range []string{"⎇ switched to feature/x", "branches: main → feature/x → main"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	session := SessionInfo{GitBranch: "main", LastBranch: "fix", Branches: []string{"main", "feature/x", "fix"}}
+	if got := session.BranchLabel(); got != "fix +2" {
+		t.Errorf("BranchLabel() = %q, want %q", got, "fix +2")
+	}
+	if got := (SessionInfo{}).BranchLabel(); got != "-" {
+		t.Errorf("BranchLabel() without a branch = %q, want %q", got, "-")
+	}
+}
+
+func TestMessageContent(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	tests := []struct {
+		name string
+		msg  monitor.Message
+		want string
+	}{
+		{"inside the workdir", monitor.Message{Content: "Called tool: Edit", ToolName: "Edit", ToolInput: `{"file_path":"/src/app/internal/foo/bar.go"}`, WorkingDir: "/src/app"}, "Called tool: Edit internal/foo/bar.go"},
+		{"outside the workdir", monitor.Message{Content: "Called tool: Read", ToolName: "Read", ToolInput: `{"file_path":"/etc/hosts"}`, WorkingDir: "/src/app"}, "Called tool: Read /etc/hosts"},
+		{"outside in home", monitor.Message{Content: "Called tool: Write", ToolName: "Write", ToolInput: fmt.Sprintf(`{"file_path":%q}`, filepath.Join(home, "notes.md")), WorkingDir: "/src/app"}, "Called tool: Write ~/notes.md"},
+		{"no path", monitor.Message{Content: "Called tool: Bash", ToolName: "Bash", ToolInput: `{"command":"ls"}`, WorkingDir: "/src/app"}, "Called tool: Bash"},
+		{"text response", monitor.Message{Content: "Let me fix that", ToolName: "Edit", ToolInput: `{"file_path":"/src/app/main.go"}`}, "Let me fix that"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageContent(tt.msg); got != tt.want {
+				t.Errorf("messageContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitingTicker(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = updated.(Model)
+
+	sent := time.Now().Add(-23 * time.Second)
+	history := []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "first", Timestamp: sent.Add(-10 * time.Minute)},
+		{Type: "assistant_response", Role: "assistant", Content: "one", Timestamp: sent.Add(-10*time.Minute + 40*time.Second)},
+		{Type: "prompt", Role: "user", Content: "second", Timestamp: sent.Add(-5 * time.Minute)},
+		{Type: "assistant_response", Role: "assistant", Content: "two", Timestamp: sent.Add(-5*time.Minute + 40*time.Second)},
+		{Type: "prompt", Role: "user", Content: "third", Timestamp: sent.Add(-2 * time.Minute)},
+		{Type: "assistant_response", Role: "assistant", Content: "three", Timestamp: sent.Add(-2*time.Minute + 40*time.Second)},
+		{Type: "prompt", Role: "user", Content: "fourth", Timestamp: sent},
+	}
+	path := filepath.Join(t.TempDir(), "live.jsonl")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.viewMode = ViewSessionDetail
+	m.selectedSession = &SessionInfo{Path: path}
+	m.sessionStats = &monitor.SessionStats{MessageHistory: history}
+	m.setWaiting(history)
+	m.updateMessageTable()
+	if view := m.View(); !strings.Contains(view, "⏳ waiting for Claude… 2") || !strings.Contains(view, "(typically ~40s)") {
+		t.Errorf("wait ticker missing:\n%s", view)
+	}
+
+	// The tick checks the file; once Claude has answered, the ticker goes away
+	answer := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"message":{"role":"assistant","content":[{"type":"text","text":"four"}]}}`+"\n", time.Now().UTC().Format(time.RFC3339Nano))
+	if err := os.WriteFile(path, []byte(answer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	updated, cmd := m.Update(tickMsg{gen: m.tickGen})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("tick in session detail view should check for an answer")
+	}
+	updated, _ = m.Update(m.checkWaiting()())
+	m = updated.(Model)
+	if view := m.View(); strings.Contains(view, "waiting for Claude") {
+		t.Errorf("wait ticker still shown after the answer:\n%s", view)
+	}
+
+	// Abandoned sessions show no ticker
+	m.waitingSince = time.Now().Add(-time.Hour)
+	if wait := m.waitingFor(time.Now()); wait != 0 {
+		t.Errorf("waitingFor after an hour = %v, want 0", wait)
+	}
+}
+
+// TestErrorsView tests that errors reported by background work are counted until "!"
+// lists them, and that the list keeps only the latest errorLogSize
+func TestErrorsView(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+	m.viewMode = ViewProjects
+
+	m.errReports.report("load sessions", errors.New("unexpected end of JSON input"), "path", "/tmp/3f2a9c1e.jsonl")
+	msg := m.waitForErrors()()
+	if _, ok := msg.(errMsg); !ok {
+		t.Fatalf("waitForErrors returned %T, want the reported errMsg", msg)
+	}
+	updated, cmd := m.Update(msg)
+	m = updated.(Model)
+	if cmd == nil {
+		t.Error("no command waiting for the next error")
+	}
+	if view := m.View(); !strings.Contains(view, "[1 new error: !]") {
+		t.Errorf("unseen error badge missing:\n%s", view)
+	}
+
+	updated, _ = m.Update(key("!"))
+	m = updated.(Model)
+	view := m.View()
+	if m.viewMode != ViewErrors || m.unseenErrors != 0 {
+		t.Fatalf("after !: view %v, %d unseen errors", m.viewMode, m.unseenErrors)
+	}
+	for _, want := range []string{"Errors this run (1)", "load sessions", "unexpected end of JSON input", "path=/tmp/3f2a9c1e.jsonl"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("errors view lacks %q:\n%s", want, view)
+		}
+	}
+	updated, _ = m.Update(key("esc"))
+	m = updated.(Model)
+	if m.viewMode != ViewProjects {
+		t.Errorf("esc returned to view %v, want the projects view", m.viewMode)
+	}
+
+	for i := range errorLogSize + 5 {
+		m.recordError("refresh processes", fmt.Errorf("error %d", i))
+	}
+	entries := m.errors.newestFirst()
+	if len(entries) != errorLogSize || m.errors.dropped != 6 || entries[0].Message != fmt.Sprintf("error %d", errorLogSize+4) || entries[errorLogSize-1].Message != "error 5" {
+		t.Errorf("kept %d errors, dropped %d, newest %q, oldest %q", len(entries), m.errors.dropped, entries[0].Message, entries[len(entries)-1].Message)
+	}
+}
+
+// TestLastErrorReservesRow tests that the pages of the views shrink by the row of the
+// last error line once it appears, so that it does not push the help bar off screen
+func TestLastErrorReservesRow(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+	pages := func() []int {
+		return []int{m.table.PageSize(), m.projectsTable.PageSize(), m.messageTable.PageSize(), m.messageViewport.Height}
+	}
+	before := pages()
+
+	m.recordError("refresh processes", errors.New("permission denied"))
+	after := pages()
+	for i := range before {
+		if after[i] != before[i]-1 {
+			t.Errorf("page sizes after the first error = %v, want one less than %v", after, before)
+			break
+		}
+	}
+
+	// A later resize keeps the row reserved
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+	if got := pages(); !slices.Equal(got, after) {
+		t.Errorf("page sizes after resizing = %v, want %v", got, after)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		editor string
+		line   int
+		want   []string
+	}{
+		{"vim", 12, []string{"vim", "+12", "/src/main.go"}},
+		{"nvim", 0, []string{"nvim", "/src/main.go"}},
+		{"emacs -nw", 3, []string{"emacs", "-nw", "+3", "/src/main.go"}},
+		{"code -w", 12, []string{"code", "-w", "--goto", "/src/main.go:12"}},
+		{"/usr/local/bin/hx", 12, []string{"/usr/local/bin/hx", "/src/main.go:12"}},
+	}
+	for _, tt := range tests {
+		if got := editorCommand(tt.editor, "/src/main.go", tt.line).Args; !slices.Equal(got, tt.want) {
+			t.Errorf("editorCommand(%q, %d) runs %q, want %q", tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestOpenWrittenFile tests that o opens the file an Edit call wrote, and reports an
+// error instead when the file is gone
+func TestOpenWrittenFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edit := func(file string) *monitor.Message {
+		return &monitor.Message{WorkingDir: dir, ToolName: "Edit", ToolInput: fmt.Sprintf(`{"file_path":%q,"old_string":"a","new_string":"func main() {}"}`, file)}
+	}
+
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	if m.openWrittenFile(&monitor.Message{Type: "prompt", Content: "fix it"}) != nil || m.lastError != "" {
+		t.Errorf("a prompt opened an editor, error %q", m.lastError)
+	}
+	if m.openWrittenFile(edit("main.go")) == nil || m.lastError != "" {
+		t.Errorf("an edit of an existing file opened no editor, error %q", m.lastError)
+	}
+	if m.openWrittenFile(edit("gone.go")) != nil || !strings.Contains(m.lastError, "no longer exists") {
+		t.Errorf("an edit of a deleted file: error %q, want that it no longer exists", m.lastError)
+	}
+
+	// Read-only mode opens no editor, which could change the file
+	cfg := config.Default()
+	cfg.ReadOnly = true
+	m = m.WithConfig(cfg)
+	if m.openWrittenFile(edit("main.go")) != nil || !strings.Contains(m.sessionNote, "Read-only mode") {
+		t.Errorf("read-only mode opened an editor, note %q", m.sessionNote)
+	}
+}
+
+// TestSessionTotalsComputedOnLoad tests that the header figures are computed when a
+// session is shown and reused by the header until other stats are swapped in
+func TestSessionTotalsComputedOnLoad(t *testing.T) {
+	m := NewModel(time.Second, false)
+	defer m.Shutdown()
+	stats := &monitor.SessionStats{MessageHistory: []monitor.Message{
+		{Type: "prompt", Role: "user", Content: "add login"},
+		{Type: "assistant_response", Role: "assistant", Model: "claude-opus-4-5", InputTokens: 400_000, OutputTokens: 100_000},
+	}}
+	m.viewMode = ViewSessionDetail
+	m.sessionStats = stats
+	m.updateMessageTable()
+	if m.sessionTotals.stats != stats || m.sessionTotals.cost != pricing.SessionCost(stats) {
+		t.Fatalf("totals after showing the session = %+v, want those of the session", m.sessionTotals)
+	}
+
+	// A stale cache is never shown: the header of other stats computes their own
+	m.sessionTotals.cost = -1
+	if d := m.sessionHeaderData(stats); d.Cost != -1 {
+		t.Errorf("header cost = %v, want the cached figure", d.Cost)
+	}
+	fuller := &monitor.SessionStats{MessageHistory: append(stats.MessageHistory, stats.MessageHistory[1])}
+	if d := m.sessionHeaderData(fuller); d.Cost != pricing.SessionCost(fuller) {
+		t.Errorf("header cost of other stats = %v, want %v", d.Cost, pricing.SessionCost(fuller))
+	}
+	m.replaceSessionStats(fuller)
+	if m.sessionTotals.stats != fuller {
+		t.Error("totals not recomputed after swapping in the full history")
+	}
+}
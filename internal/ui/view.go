@@ -3,9 +3,13 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/thieso2/promptwatch/internal/config"
 	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/pricing"
+	"github.com/thieso2/promptwatch/internal/ui/render"
 )
 
 // View renders the UI
@@ -14,6 +18,46 @@ func (m Model) View() string {
 		return "Goodbye!\n"
 	}
 
+	view := m.renderCurrentView()
+	if m.redact {
+		view = render.MarkRedacted(view, m.termWidth)
+	}
+	if m.lastError != "" {
+		view = lipgloss.JoinVertical(lipgloss.Left, view, m.renderLastError())
+	}
+	if m.confirm != nil {
+		return m.renderConfirmation(view)
+	}
+	return view
+}
+
+// viewHeight returns the terminal rows left to the current view: all of them, but one
+// while the last error line is shown below it
+func (m Model) viewHeight() int {
+	if m.lastError != "" {
+		return m.termHeight - 1
+	}
+	return m.termHeight
+}
+
+// renderLastError shows the most recent background error and where to find more
+// detail, with a badge counting the errors not yet seen in the errors view
+func (m Model) renderLastError() string {
+	hint := "run with --debug for details"
+	if m.debugLogPath != "" {
+		hint = "details in " + monitor.ShortenHomePath(m.debugLogPath)
+	}
+	line := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("1")).
+		Render(fmt.Sprintf("Last error: %s (%s)", m.lastError, hint))
+	if m.unseenErrors > 0 {
+		line += " " + render.ErrorBadge(m.unseenErrors)
+	}
+	return line
+}
+
+// renderCurrentView renders the active view mode
+func (m Model) renderCurrentView() string {
 	if m.viewMode == ViewMessageDetail {
 		return m.renderMessageDetailView()
 	}
@@ -22,6 +66,26 @@ func (m Model) View() string {
 		return m.renderSessionDetailView()
 	}
 
+	if m.viewMode == ViewProjectStats {
+		return m.renderProjectStatsView()
+	}
+
+	if m.viewMode == ViewReport {
+		return m.renderReportView()
+	}
+
+	if m.viewMode == ViewDiff {
+		return m.renderDiffView()
+	}
+
+	if m.viewMode == ViewErrors {
+		return m.renderErrorsView()
+	}
+
+	if m.viewMode == ViewViewed {
+		return m.renderViewedView()
+	}
+
 	if m.viewMode == ViewSessions {
 		return m.renderSessionView()
 	}
@@ -39,31 +103,32 @@ func (m Model) View() string {
 
 // renderEmpty displays a message when no processes are found
 func (m Model) renderEmpty() string {
-	header := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("11")).
-		Render("promptwatch")
-
-	content := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8")).
-		Render("No Claude instances found.")
-
-	footer := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8")).
-		Render("Press 'r' to refresh or 'q' to quit")
-
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
-		header,
-		content,
-		footer,
-	)
+	return render.Empty(m.renderHelp())
 }
 
 // renderSessionDetailView displays detailed information about a session
 func (m Model) renderSessionDetailView() string {
+	if m.sessionStats == nil && m.loadingSession {
+		return m.renderSessionLoading()
+	}
 	if m.sessionStats == nil {
-		return "Error: No session data loaded\n"
+		errText := "No session data loaded"
+		if m.messageError != "" {
+			errText = m.messageError
+		}
+		lines := []string{"Error: " + errText}
+		if m.selectedSession != nil {
+			lines = append(lines, lipgloss.NewStyle().
+				Foreground(lipgloss.Color("8")).
+				Render(fmt.Sprintf("File: %s (%s)", m.shownPath(m.selectedSession.Path), formatFileSize(m.selectedSession.Size))))
+			if m.selectedSession.LoadError != "" && m.selectedSession.LoadError != m.messageError {
+				lines = append(lines, lipgloss.NewStyle().
+					Foreground(lipgloss.Color("8")).
+					Render("While listing: "+m.selectedSession.LoadError))
+			}
+		}
+		lines = append(lines, "", m.renderHelp())
+		return lipgloss.JoinVertical(lipgloss.Left, lines...) + "\n"
 	}
 
 	// Type assertion
@@ -72,78 +137,16 @@ func (m Model) renderSessionDetailView() string {
 		return "Error: Invalid session data\n"
 	}
 
-	// Header with session title
-	headerTitle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("11")).
-		Render("Session Details")
-
-	sessionPath := fmt.Sprintf("Path: %s", truncatePath(stats.FilePath, 60))
-	pathStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	pathText := pathStyle.Render(sessionPath)
-
-	// Session metadata line (version, git, tokens, etc.)
-	var metadataItems []string
-	if m.selectedSession != nil {
-		if m.selectedSession.Version != "" {
-			metadataItems = append(metadataItems, "v:"+m.selectedSession.Version)
-		}
-		if m.selectedSession.GitBranch != "" {
-			metadataItems = append(metadataItems, "branch:"+m.selectedSession.GitBranch)
-		}
-		if m.selectedSession.IsSidechain {
-			metadataItems = append(metadataItems, "🔀side-chain")
-		}
-		if m.selectedSession.TotalTokens > 0 {
-			if m.selectedSession.InputTokens > 0 && m.selectedSession.OutputTokens > 0 {
-				metadataItems = append(metadataItems, fmt.Sprintf("tokens:%d→%d", m.selectedSession.InputTokens, m.selectedSession.OutputTokens))
-			} else {
-				metadataItems = append(metadataItems, fmt.Sprintf("tokens:%d", m.selectedSession.TotalTokens))
-			}
-		}
-		if m.selectedSession.UserPrompts > 0 {
-			metadataItems = append(metadataItems, fmt.Sprintf("prompts:%d", m.selectedSession.UserPrompts))
-		}
-		if m.selectedSession.Interruptions > 0 {
-			metadataItems = append(metadataItems, fmt.Sprintf("resumptions:%d", m.selectedSession.Interruptions))
-		}
-	}
-
-	metadataText := ""
-	if len(metadataItems) > 0 {
-		metadataStr := strings.Join(metadataItems, "  |  ")
-		metadataStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8"))
-		metadataText = metadataStyle.Render(metadataStr)
-	}
-
-	// First prompt preview
-	firstPromptText := ""
-	if m.selectedSession != nil && m.selectedSession.FirstPrompt != "" {
-		prompt := m.selectedSession.FirstPrompt
-		if len(prompt) > 80 {
-			prompt = prompt[:77] + "..."
-		}
-		promptStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("11"))
-		firstPromptText = promptStyle.Render("Initial: " + prompt)
-	}
-
-	// Stats section
-	statsStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("10"))
-	statsText := statsStyle.Render(stats.GetSummary())
-
-	// Detailed stats
-	detailedStats := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8")).
-		Render(stats.GetDetailedStats())
+	header := m.renderSessionHeader(stats)
 
 	// Messages section - use viewport for scrolling
 	var messagesComponents []string
 
-	if m.filteredMessageCount == 0 {
+	if len(stats.MessageHistory) == 0 && !stats.Partial {
+		messagesComponents = append(messagesComponents, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render("No messages yet: the session file holds no conversation entries so far. They show up here as Claude writes them."))
+	} else if m.filteredMessageCount == 0 {
 		// Show feedback when filter results in no messages
 		feedbackStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("3"))
@@ -152,20 +155,16 @@ func (m Model) renderSessionDetailView() string {
 		} else {
 			messagesComponents = append(messagesComponents, feedbackStyle.Render("No messages to display with current filter"))
 		}
-	} else if m.messageError != "" && m.messageFilter != FilterAll {
+	} else if m.messageError != "" && (m.messageFilter != FilterAll || stats.Partial) {
 		// Show status message for filter mode
 		statusStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("10"))
 		messagesComponents = append(messagesComponents, statusStyle.Render(m.messageError))
 		// Show viewport with message cards
-		messagesComponents = append(messagesComponents, m.messageViewport.View())
-	} else if len(stats.MessageHistory) == 0 {
-		messagesComponents = append(messagesComponents, lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")).
-			Render("No messages in this session"))
+		messagesComponents = append(messagesComponents, m.renderMessageViewport())
 	} else {
 		// Show viewport with message cards
-		messagesComponents = append(messagesComponents, m.messageViewport.View())
+		messagesComponents = append(messagesComponents, m.renderMessageViewport())
 	}
 
 	messagesContent := lipgloss.JoinVertical(lipgloss.Left, messagesComponents...)
@@ -175,48 +174,345 @@ func (m Model) renderSessionDetailView() string {
 	filterColor := lipgloss.Color("11")
 	switch m.messageFilter {
 	case FilterUserOnly:
-		filterStr = fmt.Sprintf(" [User Prompts: %d]", m.filteredMessageCount)
-		if m.filteredMessageCount == 0 {
-			filterColor = lipgloss.Color("1")
-		}
+		filterStr = fmt.Sprintf(" [User Prompts: %d", m.filteredMessageCount)
 	case FilterAssistantOnly:
-		filterStr = fmt.Sprintf(" [Claude Responses: %d]", m.filteredMessageCount)
-		if m.filteredMessageCount == 0 {
-			filterColor = lipgloss.Color("1")
+		filterStr = fmt.Sprintf(" [Claude Responses: %d", m.filteredMessageCount)
+	case FilterDenied:
+		filterStr = fmt.Sprintf(" [Denied Tool Calls: %d", m.filteredMessageCount)
+	case FilterRefusals:
+		filterStr = fmt.Sprintf(" [Refusals: %d", m.filteredMessageCount)
+	case FilterTools:
+		filterStr = fmt.Sprintf(" [Tool Calls: %d", m.filteredMessageCount)
+		if m.groupByTool {
+			tools := 0
+			for _, row := range m.messages {
+				if row.ToolGroup != "" {
+					tools++
+				}
+			}
+			filterStr += fmt.Sprintf(" · grouped by tool (%d tools)", tools)
 		}
 	default:
-		filterStr = fmt.Sprintf(" [All Messages: %d]", m.filteredMessageCount)
+		filterStr = fmt.Sprintf(" [All Messages: %d", m.filteredMessageCount)
+	}
+	if preset, ok := m.activePreset(); ok {
+		filterStr += " · preset " + preset.Name
+	}
+	filterStr += "]"
+	if narrowed := m.messageFilter != FilterAll || m.filterPreset > 0; narrowed && m.filteredMessageCount > 0 {
+		// What the shown messages cost on their own, e.g. one refactor attempt
+		msgs := "msgs"
+		if m.filteredMessageCount == 1 {
+			msgs = "msg"
+		}
+		filterStr += fmt.Sprintf(" filtered: %d %s, %s tokens, %s", m.filteredMessageCount, msgs,
+			render.FormatTokenCount(m.filteredTokens.Total()), render.FormatCost(m.filteredCost, render.TotalPrecision))
+	}
+	if m.filteredMessageCount == 0 && (m.messageFilter != FilterAll || m.filterPreset > 0) {
+		filterColor = lipgloss.Color("1")
 	}
 	filterStyle := lipgloss.NewStyle().
 		Foreground(filterColor)
 	filterText := filterStyle.Render(filterStr)
+	if pos := m.renderScrollPosition(); pos != "" {
+		filterText += "  " + pos
+	}
+	if m.sessionNote != "" {
+		// The note shares the line with the filter status, so it is cut to what is left
+		used := lipgloss.Width(filterText) + 2 + len("Messages:")
+		if m.compactHeader {
+			used += lipgloss.Width(header) - len("Messages:")
+		}
+		filterText += "  " + lipgloss.NewStyle().
+			Foreground(lipgloss.Color("10")).
+			Render(truncateText(m.sessionNote, max(m.termWidth-used, 1)))
+	}
 
-	// Footer with sort order indicator
-	sortIndicator := "oldest→newest"
-	if m.messageSortNewestFirst {
-		sortIndicator = "newest→oldest"
+	if m.compactHeader {
+		// Header, filter status and position share a single line
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			header+filterText,
+			messagesContent,
+			"",
+			m.renderHelp(),
+		)
 	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		"",
+		"Messages:"+filterText,
+		messagesContent,
+		"",
+		m.renderHelp(),
+	)
+}
 
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	helpText := "↑/↓: Scroll  |  PgUp/PgDn: Page  |  Home/End: Jump  |  u: User  |  a: Assistant  |  b: Both  |  s: Sort (" + sortIndicator + ")  |  esc: Back  |  q: Quit"
-	footer := footerStyle.Render(helpText)
+// renderSessionHeader renders the session detail header, collapsed to one line with "z"
+func (m Model) renderSessionHeader(stats *monitor.SessionStats) string {
+	if m.compactHeader {
+		return render.CompactSessionHeader(m.sessionHeaderData(stats), m.cfg.Cost)
+	}
+	return render.SessionHeader(m.sessionHeaderData(stats), m.cfg.Cost)
+}
 
-	headerComponents := []string{headerTitle, pathText}
-	if metadataText != "" {
-		headerComponents = append(headerComponents, metadataText)
+// resizeMessageViewport fits the message viewport between the session header, whose
+// height depends on the session and on whether it is collapsed, and the help bar
+func (m *Model) resizeMessageViewport() {
+	m.messageViewport.Width = m.messageListWidth() - scrollbarWidth
+	reserved := 10 // Full header, before a session is loaded
+	if stats, ok := m.sessionStats.(*monitor.SessionStats); ok {
+		reserved = lipgloss.Height(m.renderSessionHeader(stats)) + 2 // Blank line and help bar
+		if !m.compactHeader {
+			reserved += 2 // Blank line and "Messages:" filter line
+		}
+		if m.messageError != "" && (m.messageFilter != FilterAll || stats.Partial) {
+			reserved++ // Status line above the cards
+		}
 	}
-	if firstPromptText != "" {
-		headerComponents = append(headerComponents, firstPromptText)
+	m.messageViewport.Height = max(m.viewHeight()-reserved, 1)
+}
+
+// scrollbarWidth is the room kept right of the message viewport for its scrollbar
+const scrollbarWidth = 2
+
+// splitShown reports whether the session detail view is split into the card list and
+// the selected message, which needs a terminal of at least detail.splitMinWidth columns
+func (m Model) splitShown() bool {
+	return m.splitView && m.termWidth >= m.cfg.Detail.SplitMinWidth
+}
+
+// messageListWidth is the width of the card list, including its scrollbar: the left
+// half of the terminal in the split view, all of it otherwise
+func (m Model) messageListWidth() int {
+	if m.splitShown() {
+		return m.termWidth / 2
 	}
-	headerComponents = append(headerComponents, "", statsText, detailedStats, "", "Messages:"+filterText)
+	return m.termWidth
+}
 
-	allComponents := append(headerComponents, messagesContent, "", footer)
+// splitDetailWidth is the width of the split view's detail pane, right of the card list
+func (m Model) splitDetailWidth() int {
+	return m.termWidth - m.messageListWidth() - 1 // Gap to the card list
+}
 
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
-		allComponents...,
-	)
+// renderSplitDetail renders the split view's right pane with the selected message,
+// as high as the card list beside it
+func (m Model) renderSplitDetail() string {
+	d := render.MessageDetailData{
+		Width:        m.splitDetailWidth(),
+		Height:       m.messageViewport.Height,
+		ScrollOffset: m.detailScrollOffset,
+		Focused:      m.splitFocusDetail,
+	}
+	if msg := m.messageAtRow(m.selectedMessageIdx); msg != nil {
+		d.Message = m.shownMessage(*msg)
+		d.Cost = pricing.MessageCost(msg)
+	}
+	return render.MessageDetailPane(d, m.cfg.Cost)
+}
+
+// renderMessageViewport renders the message cards with a scrollbar on the right edge.
+// The scrollbar follows the viewport's own line count and offset, so it stays accurate
+// whatever height the cards have.
+func (m Model) renderMessageViewport() string {
+	view := m.messageViewport.View()
+	if m.splitShown() {
+		// Cards are wider than the left half; cut them off at the pane edge
+		width := m.messageViewport.Width
+		view = lipgloss.PlaceHorizontal(width, lipgloss.Left, lipgloss.NewStyle().MaxWidth(width).Render(view))
+	}
+	bar := render.Scrollbar(m.messageViewport.Height, m.messageLines, m.messageViewport.YOffset)
+	if bar != "" {
+		view = lipgloss.JoinHorizontal(lipgloss.Top, view, " ", bar)
+	}
+	if m.splitShown() {
+		view = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.PlaceHorizontal(m.messageListWidth(), lipgloss.Left, view), " ", m.renderSplitDetail())
+	}
+	return view
+}
+
+// renderScrollPosition renders the "message 37/214 — 12%" indicator for the selected
+// card; a selected turn or tool header counts turns or tools instead of messages
+func (m Model) renderScrollPosition() string {
+	if m.selectedMessageIdx < 0 || m.selectedMessageIdx >= len(m.messages) {
+		return ""
+	}
+	row := m.messages[m.selectedMessageIdx]
+	if row.ToolGroup != "" {
+		tool, tools := 0, 0
+		for i, r := range m.messages {
+			if r.ToolGroup != "" {
+				tools++
+				if i <= m.selectedMessageIdx {
+					tool = tools
+				}
+			}
+		}
+		return render.ScrollPosition("tool", tool, tools, m.messageViewport.ScrollPercent())
+	}
+	if !row.IsTurnHeader {
+		return render.ScrollPosition("message", row.Index, m.filteredMessageCount, m.messageViewport.ScrollPercent())
+	}
+	turn, turns := 0, 0
+	for i, r := range m.messages {
+		if r.IsTurnHeader {
+			turns++
+			if i <= m.selectedMessageIdx {
+				turn = turns
+			}
+		}
+	}
+	return render.ScrollPosition("turn", turn, turns, m.messageViewport.ScrollPercent())
+}
+
+// sessionTotals are the figures of the session detail header that take a pass over the
+// whole history, computed once per loaded session rather than on every render
+type sessionTotals struct {
+	stats       *monitor.SessionStats // Session they were computed for
+	messages    int                   // Length of its history at the time
+	cost        float64
+	turns       monitor.TurnStats
+	composition []monitor.EntryCount
+	throughput  float64
+	rewarms     int
+	rewarmCost  float64
+}
+
+// computeSessionTotals sums up the header figures of stats
+func computeSessionTotals(stats *monitor.SessionStats) sessionTotals {
+	t := sessionTotals{
+		stats:       stats,
+		messages:    len(stats.MessageHistory),
+		cost:        pricing.SessionCost(stats),
+		turns:       stats.TurnStats(pricing.MessageCost),
+		composition: stats.Composition(),
+	}
+	t.throughput, _ = stats.TokensPerSecond()
+	for _, r := range monitor.CacheRewarms(stats.MessageHistory) {
+		t.rewarms++
+		t.rewarmCost += pricing.RewarmCost(r)
+	}
+	return t
+}
+
+// totalsFor returns the header figures of stats, from the ones computed on load if they
+// are still current
+func (m Model) totalsFor(stats *monitor.SessionStats) sessionTotals {
+	if m.sessionTotals.stats == stats && m.sessionTotals.messages == len(stats.MessageHistory) {
+		return m.sessionTotals
+	}
+	return computeSessionTotals(stats)
+}
+
+// sessionHeaderData collects what the session detail header shows
+func (m Model) sessionHeaderData(stats *monitor.SessionStats) render.SessionHeaderData {
+	totals := m.totalsFor(stats)
+	d := render.SessionHeaderData{
+		Path:          stats.FilePath,
+		ID:            monitor.SessionFileID(stats.FilePath),
+		IsAgent:       monitor.IsAgentFile(stats.FilePath),
+		Summary:       stats.GetSummary(),
+		DetailedStats: stats.GetDetailedStats(),
+		Duration:      stats.Duration,
+		Messages:      stats.TotalMessages,
+		Cost:          totals.cost,
+		Partial:       stats.Partial,
+		OutOfOrder:    stats.OutOfOrder,
+		Loading:       m.loadingSession,
+		Spinner:       m.loadSpinner.View(),
+		Progress:      m.loadProgress,
+		History:       stats.MessageHistory,
+		Composition:   totals.composition,
+		Modes:         stats.PermissionModes,
+		Branches:      branchNames(stats.Branches),
+		OutsideWrites: stats.OutsideWrites,
+		Languages:     stats.Languages,
+		DiffStat:      stats.DiffStat,
+		Title:         stats.Summary,
+		Width:         m.termWidth,
+		Turns:         totals.turns,
+		Tokens:        stats.Tokens,
+		Pastes:        stats.Pastes,
+		PastedBytes:   stats.PastedBytes,
+		Throughput:    totals.throughput,
+		Rewarms:       totals.rewarms,
+		RewarmCost:    totals.rewarmCost,
+	}
+	d.Path = m.shownPath(d.Path)
+	d.OutsideWrites = m.shownPaths(d.OutsideWrites)
+	recorded := ""
+	for _, msg := range stats.MessageHistory {
+		if d.ResumeDir == "" {
+			d.ResumeDir = m.shownPath(msg.WorkingDir)
+		}
+		if recorded == "" {
+			recorded = msg.SessionID
+		}
+		d.IsSidechain = d.IsSidechain || msg.IsSidechain
+	}
+	if d.Turns.MostExpensive >= 0 {
+		d.MostExpensiveTurn = stats.Turns[d.Turns.MostExpensive].Index
+	}
+	if len(stats.Turns) > 0 {
+		d.Activities = stats.Activities()
+		d.CurrentTurn = -1
+		if m.selectedMessageIdx >= 0 && m.selectedMessageIdx < len(m.messages) {
+			d.CurrentTurn = m.messages[m.selectedMessageIdx].TurnIdx
+		}
+	}
+	if wait := m.waitingFor(time.Now()); wait > 0 {
+		d.Waiting = wait
+		d.TypicalWait, _ = monitor.TypicalLatency(stats.MessageHistory, m.waitingAfter)
+	}
+	if s := m.selectedSession; s != nil {
+		d.Version = s.Version
+		d.GitBranch = s.GitBranch
+		d.IsSidechain = d.IsSidechain || s.IsSidechain
+		d.IsAgent = d.IsAgent || s.IsAgent
+		if s.SessionID != "" {
+			recorded = s.SessionID // Read from the top of the file, which a tail load misses
+		}
+		if stats.Partial && s.Tokens.Total() > 0 {
+			d.Tokens = s.Tokens // The metadata scan read the whole file
+		}
+		d.UserPrompts = s.UserPrompts
+		d.Interruptions = s.Interruptions
+		d.FirstPrompt = s.FirstPrompt
+		if d.Title == "" {
+			d.Title = s.Summary // A tail load can miss summary entries near the top of the file
+		}
+		if s.UncompressedSize > 0 {
+			d.Compressed = formatFileSize(s.Size) + "→" + formatFileSize(s.UncompressedSize)
+		}
+	}
+	// Side-chains and subagents record the ID of the session they belong to
+	if recorded != d.ID && !d.IsSidechain && !d.IsAgent {
+		d.RecordedID = recorded
+	}
+	d.Title = m.shownText(d.Title)
+	d.FirstPrompt = m.shownText(d.FirstPrompt)
+	return d
+}
+
+// branchNames lists the branches of a session's branch changes
+func branchNames(changes []monitor.BranchChange) []string {
+	names := make([]string, len(changes))
+	for i, c := range changes {
+		names[i] = c.Branch
+	}
+	return names
+}
+
+// renderSessionLoading displays a spinner and parse progress while a session file loads
+func (m Model) renderSessionLoading() string {
+	path := ""
+	if m.selectedSession != nil {
+		path = m.shownPath(m.selectedSession.Path)
+	}
+	return render.SessionLoading(path, m.loadSpinner.View(), m.loadProgress, m.renderHelp())
 }
 
 // renderSessionView displays the session list for a selected process or project
@@ -228,10 +524,13 @@ func (m Model) renderSessionView() string {
 		headerTitle := lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("11")).
-			Render("Sessions for: " + truncatePath(m.selectedProc.WorkingDir, 50))
+			Render("Sessions for: " + truncatePath(m.shownPath(m.selectedProc.WorkingDir), 50))
 
 		processInfo := fmt.Sprintf("PID: %d | CPU: %.1f%% | MEM: %.2f MB",
 			m.selectedProc.PID, m.selectedProc.CPUPercent, m.selectedProc.MemoryMB)
+		if m.selectedProcExited {
+			processInfo = fmt.Sprintf("PID: %d | exited", m.selectedProc.PID)
+		}
 		processStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8"))
 		processText := processStyle.Render(processInfo)
@@ -241,6 +540,12 @@ func (m Model) renderSessionView() string {
 			headerTitle,
 			processText,
 		)
+	} else if m.sessionSourceMode == ViewRecent {
+		// Viewing the sessions of all projects
+		headerLine = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("11")).
+			Render(fmt.Sprintf("Recent sessions: all projects, last %d days", m.cfg.Recent.Days))
 	} else {
 		// Viewing sessions from a project
 		var projName string
@@ -253,57 +558,133 @@ func (m Model) renderSessionView() string {
 		headerTitle := lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("11")).
-			Render("Sessions for: " + truncatePath(projName, 50))
+			Render("Sessions for: " + truncatePath(m.shownPath(projName), 50))
 
 		headerLine = headerTitle
 	}
 
+	// Show the active model filter
+	if m.sessionModelFilter != "" {
+		filterText := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(fmt.Sprintf("Model: %s (%d of %d sessions)", m.sessionModelFilter, len(m.sessions), len(m.allSessions)))
+		headerLine = lipgloss.JoinVertical(lipgloss.Left, headerLine, filterText)
+	}
+	if m.sessionModeFilter != "" {
+		filterText := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(fmt.Sprintf("Permission mode: %s (%d of %d sessions)", m.sessionModeFilter, len(m.sessions), len(m.allSessions)))
+		headerLine = lipgloss.JoinVertical(lipgloss.Left, headerLine, filterText)
+	}
+	if m.sessionLangFilter != "" {
+		filterText := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(fmt.Sprintf("Language: %s (%d of %d sessions)", m.sessionLangFilter, len(m.sessions), len(m.allSessions)))
+		headerLine = lipgloss.JoinVertical(lipgloss.Left, headerLine, filterText)
+	}
+
+	// Mention subagent sessions so the counts add up
+	agents := 0
+	for _, session := range m.allSessions {
+		if session.IsAgent {
+			agents++
+		}
+	}
+	if agents > 0 {
+		agentNote := fmt.Sprintf("%d subagent sessions hidden", agents)
+		if m.showAgents {
+			agentNote = fmt.Sprintf("%d subagent sessions shown at the bottom", agents)
+		}
+		headerLine = lipgloss.JoinVertical(lipgloss.Left, headerLine, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8")).
+			Render(agentNote))
+	}
+
 	// Check for errors
 	if m.sessionError != "" {
 		errorStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("1"))
 		errorText := errorStyle.Render("Error: " + m.sessionError)
-		return lipgloss.JoinVertical(lipgloss.Left, headerLine, "", errorText, "", footerHint())
+		return lipgloss.JoinVertical(lipgloss.Left, headerLine, "", errorText, "", m.renderHelp())
 	}
 
 	// Show table or empty message
 	var content string
 	if len(m.sessions) == 0 {
 		// Show empty message when no sessions found
+		emptyText := "No sessions found for this directory"
+		if m.sessionSourceMode == ViewRecent {
+			emptyText = fmt.Sprintf("No sessions in the last %d days", m.cfg.Recent.Days)
+		}
+		if m.sessionModelFilter != "" {
+			emptyText = "No sessions match model filter: " + m.sessionModelFilter
+		}
+		if m.sessionModeFilter != "" {
+			emptyText = "No sessions ran in permission mode: " + m.sessionModeFilter
+		}
+		if m.sessionLangFilter != "" {
+			emptyText = "No sessions have code in: " + m.sessionLangFilter
+		}
 		content = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8")).
-			Render("No sessions found for this directory")
+			Render(emptyText)
 	} else {
 		content = m.sessionTable.View()
+		if m.previewShown() {
+			content = lipgloss.JoinHorizontal(lipgloss.Top, content, " ", m.renderSessionPreview(lipgloss.Height(headerLine)))
+		}
 	}
 
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	helpText := "↑/↓: Navigate  |  enter: Open  |  esc: Back  |  q: Quit"
-	footer := footerStyle.Render(helpText)
-
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		headerLine,
 		"",
 		content,
 		"",
-		footer,
+		m.renderHelp(),
 	)
 }
 
+// renderSessionPreview renders the preview pane beside the session table, filling the
+// height left below a header of headerHeight lines
+func (m Model) renderSessionPreview(headerHeight int) string {
+	messages := m.previewMessages
+	if m.redact {
+		messages = make([]monitor.Message, len(m.previewMessages))
+		for i, msg := range m.previewMessages {
+			messages[i] = m.shownMessage(msg)
+		}
+	}
+	d := render.PreviewData{
+		Messages: messages,
+		Loading:  m.previewLoading,
+		Err:      m.previewError,
+		Width:    m.termWidth/2 - 1,
+		Height:   max(m.viewHeight()-headerHeight-4, 1), // Blank lines around the content and the help bar
+	}
+	if m.selectedSessionIdx >= 0 && m.selectedSessionIdx < len(m.sessions) {
+		d.Title = m.shownText(m.sessions[m.selectedSessionIdx].Title)
+	}
+	return render.SessionPreview(d)
+}
+
 // renderProjectsView displays all project directories sorted by modification time
 func (m Model) renderProjectsView() string {
 	// Header with title
 	headerTitle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("11")).
-		Render("Claude Projects (~/.claude/projects)")
+		Render("Claude Projects (" + monitor.ShortenHomePath(m.projectsDir) + ")")
 
 	projectCount := fmt.Sprintf("%d projects", len(m.projects))
 	countStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("8"))
 	countText := countStyle.Render(projectCount)
+	if m.projectNote != "" {
+		countText += "  " + lipgloss.NewStyle().
+			Foreground(lipgloss.Color("10")).
+			Render(m.projectNote)
+	}
 
 	headerLine := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -316,439 +697,177 @@ func (m Model) renderProjectsView() string {
 		errorStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("1"))
 		errorText := errorStyle.Render("Error: " + m.projectsError)
-		return lipgloss.JoinVertical(lipgloss.Left, headerLine, "", errorText, "", footerHint())
+		return lipgloss.JoinVertical(lipgloss.Left, headerLine, "", errorText, "", m.renderHelp())
 	}
 
 	// Show table or empty message
 	var content string
 	if len(m.projects) == 0 {
-		// Show empty message when no projects found
-		content = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8")).
-			Render("No projects found in ~/.claude/projects")
+		content = m.renderNoProjects()
 	} else {
 		content = m.projectsTable.View()
 	}
 
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	helpText := "↑/↓: Navigate  |  enter: View sessions  |  p: Processes  |  q: Quit"
-	footer := footerStyle.Render(helpText)
-
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		headerLine,
 		"",
-		"",
+		m.renderProjectPrompt(),
 		content,
 		"",
-		footer,
+		m.renderHelp(),
 	)
 }
 
-// renderWithTable displays the full UI with the process table
-func (m Model) renderWithTable() string {
-	// Header with title and status
-	headerTitle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("11")).
-		Render("promptwatch")
-
-	status := fmt.Sprintf("%d instances", len(m.processes))
-	if m.showHelpers {
-		status += " (including helpers)"
-	}
-	statusStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	statusText := statusStyle.Render(status)
-
-	timestamp := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8")).
-		Render(fmt.Sprintf("Updated: %s", m.lastUpdate.Format("15:04:05")))
-
-	headerLine := lipgloss.JoinHorizontal(
-		lipgloss.Left,
-		headerTitle,
-		"  ",
-		statusText,
-		"  |  ",
-		timestamp,
-	)
-
-	// Table
-	tableView := m.table.View()
-
-	// Footer with help text
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-
-	helpText := "↑/↓: Navigate  |  enter: View sessions  |  p: Projects  |  r: Refresh  |  f: Toggle helpers  |  q: Quit"
-	footer := footerStyle.Render(helpText)
-
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
-		headerLine,
-		"",
-		tableView,
+// renderNoProjects explains an empty projects view: where promptwatch looked, how to
+// look elsewhere, and that the view fills in by itself
+func (m Model) renderNoProjects() string {
+	dir := monitor.ShortenHomePath(m.projectsDir)
+	title := "No projects found in " + dir
+	explanation := "Claude Code adds a project here when you first run claude in a directory."
+	if m.projectsDirMissing {
+		title = "No Claude projects yet"
+		explanation = "promptwatch looks for sessions in " + dir + ", which does not exist yet.\n" +
+			"Claude Code creates it when you first run claude."
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.NewStyle().Bold(true).Render(title),
 		"",
-		footer,
+		lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(explanation+"\n"+
+			"To look elsewhere, start promptwatch with -projects-dir <dir> or set CLAUDE_CONFIG_DIR.\n"+
+			"This view updates as soon as a project appears."),
 	)
 }
 
-// footerHint returns a generic footer hint
-func footerHint() string {
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	return footerStyle.Render("Press 'esc' to go back")
-}
+// maxProjectPromptLines caps the selected project's prompt above the projects table
+const maxProjectPromptLines = 3
 
-// renderMessageDetailView displays a message with full text and line wrapping
-// Creates type-specific beautiful layouts for user messages, assistant responses, tool calls, etc.
-func (m Model) renderMessageDetailView() string {
-	if m.detailMessage == nil {
-		return "Error: No message to display\n"
+// renderProjectPrompt shows the full last prompt of the selected project, wrapped to
+// the terminal width and cut off after maxProjectPromptLines lines
+func (m Model) renderProjectPrompt() string {
+	if m.selectedProjIdx < 0 || m.selectedProjIdx >= len(m.projects) {
+		return ""
 	}
-
-	msg := m.detailMessage
-
-	// Build header based on message type
-	var headerTitle, metadataSection string
-
-	if msg.Role == "user" {
-		// User message style
-		headerTitle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("226")).
-			Render("👤 YOUR PROMPT")
-
-		timeStr := msg.Timestamp.Format("2006-01-02 15:04:05 MST")
-		metadataSection = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("244")).
-			Render(fmt.Sprintf("sent at %s", timeStr))
-
-	} else if msg.Role == "assistant" {
-		if msg.ToolName != "" {
-			// Tool call style
-			headerTitle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("82")).
-				Render(fmt.Sprintf("🔧 TOOL CALL: %s", strings.ToUpper(msg.ToolName)))
-
-			var toolDetails []string
-			toolDetails = append(toolDetails, fmt.Sprintf("Tool: %s", msg.ToolName))
-
-			if msg.ToolInput != "" {
-				toolDetails = append(toolDetails, fmt.Sprintf("Arguments: %s", msg.ToolInput))
-			}
-
-			// Add UUID if available
-			if msg.UUID != "" {
-				toolDetails = append(toolDetails, fmt.Sprintf("ID: %s", msg.UUID[:8]))
-			}
-
-			metadataSection = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("11")).
-				Render(strings.Join(toolDetails, " • "))
-		} else {
-			// Regular assistant response
-			headerTitle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("51")).
-				Render("🤖 CLAUDE RESPONSE")
-
-			// Build metadata for assistant message
-			var metaParts []string
-			timeStr := msg.Timestamp.Format("15:04:05")
-			metaParts = append(metaParts, timeStr)
-
-			if msg.Model != "" {
-				modelParts := strings.Split(msg.Model, "-")
-				if len(modelParts) > 0 {
-					metaParts = append(metaParts, modelParts[0])
-				}
-			}
-
-			if msg.InputTokens > 0 || msg.OutputTokens > 0 {
-				metaParts = append(metaParts,
-					fmt.Sprintf("in:%d", msg.InputTokens),
-					fmt.Sprintf("out:%d", msg.OutputTokens),
-				)
-
-				if msg.CacheRead > 0 {
-					metaParts = append(metaParts, fmt.Sprintf("cache:↻%d", msg.CacheRead))
-				}
-
-				// Cost calculation
-				inputCost := float64(msg.InputTokens+msg.CacheCreation) * 0.000003
-				cacheReadCost := float64(msg.CacheRead) * 0.0000003
-				outputCost := float64(msg.OutputTokens) * 0.000015
-				totalCost := inputCost + cacheReadCost + outputCost
-
-				costColor := "10" // Green
-				if totalCost > 0.10 {
-					costColor = "1" // Red
-				} else if totalCost > 0.01 {
-					costColor = "3" // Yellow
-				}
-				costStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(costColor))
-				metaParts = append(metaParts, costStyle.Render(fmt.Sprintf("$%.4f", totalCost)))
-			}
-
-			// Add UUID if available
-			if msg.UUID != "" {
-				metaParts = append(metaParts, fmt.Sprintf("ID:%s", msg.UUID[:8]))
-			}
-
-			metadataSection = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("244")).
-				Render(strings.Join(metaParts, " · "))
-		}
-	} else {
-		// Fallback for other types
-		headerTitle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("11")).
-			Render("MESSAGE")
-		metadataSection = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("244")).
-			Render(msg.Timestamp.Format("2006-01-02 15:04:05"))
-	}
-
-	// Separator line
-	separator := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("238")).
-		Render(strings.Repeat("─", 88))
-
-	// Build detailed metadata section with all available fields
-	var detailsLines []string
-	var details []string
-
-	// Model and tokens (for assistant messages)
-	if msg.Role == "assistant" && (msg.InputTokens > 0 || msg.OutputTokens > 0 || msg.Model != "") {
-		var tokenInfo []string
-
-		if msg.Model != "" {
-			tokenInfo = append(tokenInfo, fmt.Sprintf("Model: %s", msg.Model))
-		}
-
-		if msg.InputTokens > 0 {
-			tokenInfo = append(tokenInfo, fmt.Sprintf("Input: %d", msg.InputTokens))
-		}
-		if msg.OutputTokens > 0 {
-			tokenInfo = append(tokenInfo, fmt.Sprintf("Output: %d", msg.OutputTokens))
-		}
-		if msg.CacheCreation > 0 {
-			tokenInfo = append(tokenInfo, fmt.Sprintf("Cache-Write: %d", msg.CacheCreation))
-		}
-		if msg.CacheRead > 0 {
-			tokenInfo = append(tokenInfo, fmt.Sprintf("Cache-Hit: %d", msg.CacheRead))
-		}
-
-		// Calculate cost
-		inputCost := float64(msg.InputTokens+msg.CacheCreation) * 0.000003
-		cacheReadCost := float64(msg.CacheRead) * 0.0000003
-		outputCost := float64(msg.OutputTokens) * 0.000015
-		totalCost := inputCost + cacheReadCost + outputCost
-
-		if totalCost > 0 {
-			costColor := "10" // Green
-			if totalCost > 0.10 {
-				costColor = "1" // Red
-			} else if totalCost > 0.01 {
-				costColor = "3" // Yellow
-			}
-			costStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(costColor))
-			tokenInfo = append(tokenInfo, costStyle.Render(fmt.Sprintf("Cost: $%.6f", totalCost)))
-		}
-
-		details = append(details, strings.Join(tokenInfo, " • "))
-	}
-
-	// Session and context info
-	if msg.SessionID != "" {
-		details = append(details, fmt.Sprintf("Session: %s", msg.SessionID))
+	prompt := strings.Join(strings.Fields(m.shownText(m.projects[m.selectedProjIdx].LastPrompt)), " ")
+	if prompt == "" {
+		return ""
 	}
-	if msg.UUID != "" {
-		details = append(details, fmt.Sprintf("Message ID: %s", msg.UUID))
-	}
-	if msg.ParentUUID != "" {
-		details = append(details, fmt.Sprintf("Parent ID: %s", msg.ParentUUID))
+	lines := strings.Split(lipgloss.NewStyle().Width(max(m.termWidth-2, 20)).Render("Last prompt: "+prompt), "\n")
+	if len(lines) > maxProjectPromptLines {
+		lines = lines[:maxProjectPromptLines]
+		lines[len(lines)-1] = strings.TrimRight(lines[len(lines)-1], " ") + "…"
 	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("11")).
+		Render(strings.Join(lines, "\n"))
+}
 
-	// Code context
-	if msg.WorkingDir != "" {
-		details = append(details, fmt.Sprintf("Working Dir: %s", msg.WorkingDir))
-	}
-	if msg.GitBranch != "" {
-		details = append(details, fmt.Sprintf("Git Branch: %s", msg.GitBranch))
-	}
+// renderProjectStatsView displays the dashboard of the selected project
+func (m Model) renderProjectStatsView() string {
+	dir := monitor.ShortenHomePath(m.projectStatsPath)
+	if m.redact {
+		dir = "" // The encoded directory name spells out the whole project path
+	}
+	return render.ProjectStats(render.ProjectStatsData{
+		Name:    m.shownPath(m.projectStatsName),
+		Path:    m.shownPath(m.projectStatsOriginal),
+		Dir:     dir,
+		Note:    m.projectNote,
+		Stats:   m.shownProjectStats(m.projectStatsCache[m.projectStatsPath]),
+		Loading: m.scanningProject,
+		Spinner: m.loadSpinner.View(),
+		Done:    m.projectScanDone,
+		Total:   m.projectScanTotal,
+		Error:   m.projectStatsError,
+		Help:    m.renderHelp(),
+	}, m.cfg.Cost)
+}
 
-	// Version and user info
-	if msg.Version != "" {
-		details = append(details, fmt.Sprintf("Claude Version: %s", msg.Version))
-	}
-	if msg.UserType != "" {
-		details = append(details, fmt.Sprintf("User Type: %s", msg.UserType))
-	}
-	if msg.IsSidechain {
-		details = append(details, "Sidechain: yes")
-	}
+// renderDiffView displays the diff between the marked and the selected message
+func (m Model) renderDiffView() string {
+	return render.Diff(render.DiffData{
+		Old:          m.diffOld,
+		New:          m.diffNew,
+		Ops:          m.shownOps(m.diffOps),
+		Words:        m.diffWords,
+		Width:        m.termWidth,
+		Height:       m.viewHeight(),
+		ScrollOffset: m.diffScrollOffset,
+		Help:         m.renderHelp(),
+	})
+}
 
-	// Format details
-	if len(details) > 0 {
-		detailsStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("242"))
-		for i, detail := range details {
-			// First line (tokens/model) with different style
-			if i == 0 && msg.Role == "assistant" && (msg.InputTokens > 0 || msg.OutputTokens > 0 || msg.Model != "") {
-				tokenStyle := lipgloss.NewStyle().
-					Foreground(lipgloss.Color("6"))
-				detailsLines = append(detailsLines, tokenStyle.Render(detail))
-			} else {
-				detailsLines = append(detailsLines, detailsStyle.Render(detail))
-			}
-		}
-	}
+// renderWithTable displays the full UI with the process table
+func (m Model) renderWithTable() string {
+	return render.ProcessView(render.ProcessViewData{
+		Processes:       len(m.processes),
+		ShowHelpers:     m.showHelpers,
+		ShowSessionless: m.showSessionless,
+		LastUpdate:      m.lastUpdate,
+		Interval:        m.updateInterval,
+		Paused:          m.paused,
+		ReadOnly:        m.cfg.ReadOnly,
+		Note:            m.processNote,
+		Discovery:       m.discovery,
+		Verbose:         m.verboseProcesses,
+		Table:           m.table.View(),
+		Help:            m.renderHelp(),
+	})
+}
 
-	// Content display with word wrapping
-	content := msg.Content
+// renderCost formats a cost after label and colors it against the given thresholds
+// Returns "" when cost display is disabled in the config
+func (m Model) renderCost(cost float64, thresholds config.Thresholds, label string, precision render.CostPrecision) string {
+	return render.Cost(m.cfg.Cost, cost, thresholds, label, precision)
+}
 
-	// Set max width for wrapping (use 80 chars or terminal width, whichever is smaller)
-	maxWidth := 80
-	if m.termWidth > 0 && m.termWidth < 80 {
-		maxWidth = m.termWidth - 2
+// renderMessageDetailView displays a message with full text and line wrapping
+func (m Model) renderMessageDetailView() string {
+	if m.detailMessage == nil {
+		return "Error: No message to display\n"
 	}
+	d := m.messageDetailData()
+	d.Help = m.renderHelp()
+	return render.MessageDetail(d, m.cfg.Cost)
+}
 
-	// Word-wrap the content
-	var wrappedLines []string
-
-	// Add tool info if this is a tool call
-	if msg.ToolName != "" {
-		toolHeader := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("82")).
-			Bold(true).
-			Render("🔧 " + strings.ToUpper(msg.ToolName))
-		wrappedLines = append(wrappedLines, toolHeader)
-
-		if msg.ToolInput != "" {
-			wrappedLines = append(wrappedLines, "")
-			wrappedLines = append(wrappedLines, lipgloss.NewStyle().
-				Foreground(lipgloss.Color("11")).
-				Render("Arguments:"))
-
-			// Wrap tool input
-			words := strings.Fields(msg.ToolInput)
-			var currentLine string
-			for _, word := range words {
-				if currentLine == "" {
-					currentLine = word
-				} else if len(currentLine)+1+len(word) <= maxWidth {
-					currentLine += " " + word
-				} else {
-					wrappedLines = append(wrappedLines, currentLine)
-					currentLine = word
+// messageDetailData collects what the message detail view shows of the open message
+func (m Model) messageDetailData() render.MessageDetailData {
+	cost := pricing.MessageCost(m.detailMessage)
+	d := render.MessageDetailData{
+		Message:         m.shownMessage(*m.detailMessage),
+		Cost:            cost,
+		Width:           m.termWidth,
+		FullWidth:       m.cfg.Detail.FullWidth,
+		Height:          m.viewHeight(),
+		ScrollOffset:    m.detailScrollOffset,
+		Paired:          m.detailPaired,
+		Results:         m.shownMessages(m.detailResults),
+		ArgsCollapsed:   m.detailArgsCollapsed,
+		ResultCollapsed: m.detailResultCollapsed,
+	}
+	if m.selectedMessageIdx < 0 || m.selectedMessageIdx >= len(m.messages) {
+		return d
+	}
+	if m.detailPaired {
+		for i := range m.messages {
+			if msg := m.messageAtRow(i); msg != nil && msg.ToolName != "" {
+				d.Total++
+				if i <= m.selectedMessageIdx {
+					d.Position++
 				}
 			}
-			if currentLine != "" {
-				wrappedLines = append(wrappedLines, currentLine)
-			}
-		}
-
-		// Add separator before content
-		if content != "" {
-			wrappedLines = append(wrappedLines, "")
-		}
-	}
-
-	// Add regular message content
-	for _, paragraph := range strings.Split(content, "\n") {
-		// Handle empty lines
-		if paragraph == "" {
-			wrappedLines = append(wrappedLines, "")
-			continue
-		}
-
-		// Word-wrap long lines
-		words := strings.Fields(paragraph)
-		var currentLine string
-		for _, word := range words {
-			if currentLine == "" {
-				currentLine = word
-			} else if len(currentLine)+1+len(word) <= maxWidth {
-				currentLine += " " + word
-			} else {
-				wrappedLines = append(wrappedLines, currentLine)
-				currentLine = word
-			}
 		}
-		if currentLine != "" {
-			wrappedLines = append(wrappedLines, currentLine)
-		}
-	}
-
-	// Calculate visible lines based on terminal height
-	pageHeight := m.termHeight - 10 // Leave space for header, footer, metadata
-	if pageHeight < 5 {
-		pageHeight = 5 // Minimum
-	}
-
-	// Get the visible portion of wrapped lines
-	var visibleLines []string
-	if m.detailScrollOffset+pageHeight < len(wrappedLines) {
-		visibleLines = wrappedLines[m.detailScrollOffset : m.detailScrollOffset+pageHeight]
-	} else if m.detailScrollOffset < len(wrappedLines) {
-		visibleLines = wrappedLines[m.detailScrollOffset:]
-	}
-
-	// Display the visible content
-	contentStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("255"))
-
-	contentText := contentStyle.Render(strings.Join(visibleLines, "\n"))
-
-	// Scroll position indicator showing actual line numbers
-	totalLines := len(wrappedLines)
-	var scrollInfo string
-	if totalLines == 0 {
-		scrollInfo = "No content"
+		d.HasPrev = m.toolCallStepTarget(-1) != m.selectedMessageIdx
+		d.HasNext = m.toolCallStepTarget(1) != m.selectedMessageIdx
 	} else {
-		endLine := m.detailScrollOffset + len(visibleLines)
-		if endLine > totalLines {
-			endLine = totalLines
-		}
-		scrollInfo = fmt.Sprintf("Line %d-%d of %d", m.detailScrollOffset+1, endLine, totalLines)
+		d.Position = m.messages[m.selectedMessageIdx].Index
+		d.Total = m.filteredMessageCount
+		d.HasPrev = m.detailStepTarget(-1) != m.selectedMessageIdx
+		d.HasNext = m.detailStepTarget(1) != m.selectedMessageIdx
 	}
-	scrollStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	scrollText := scrollStyle.Render(scrollInfo)
-
-	// Footer with help
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8"))
-	helpText := "↑/↓: Scroll  |  ←/→: Prev/Next  |  PgUp/PgDn: Page  |  Home/End: Jump  |  esc: Back  |  q: Quit"
-	footer := footerStyle.Render(helpText)
-
-	// Build output
-	output := []string{
-		headerTitle,
-		metadataSection,
-		separator,
-	}
-
-	// Add detailed metadata if available
-	if len(detailsLines) > 0 {
-		output = append(output, "")
-		output = append(output, detailsLines...)
-		output = append(output, "")
-	}
-
-	// Add content and navigation info
-	output = append(output, "", contentText, "", scrollText, footer)
-
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
-		output...,
-	)
+	d.Filter = m.messageFilter.label()
+	return d
 }
 
 // renderMessageCards renders all messages as cards for the viewport with cursor
@@ -758,167 +877,102 @@ func (m *Model) renderMessageCards() string {
 	}
 
 	var cards []string
+	width := m.messageViewport.Width // Cards wider than the viewport would wrap
 
 	// Render all cards with cursor indicator
 	for i := range m.messages {
 		isSelected := (i == m.selectedMessageIdx)
-		card := renderMessageCard(m.messages[i], isSelected)
-		cards = append(cards, card)
-	}
-
-	return lipgloss.JoinVertical(lipgloss.Left, cards...)
-}
-
-// renderMessageCard renders a single message as a fixed-height card (4 lines)
-// Beautiful format with proper left alignment
-func renderMessageCard(msg MessageRow, isSelected bool) string {
-	// Role emoji and label
-	roleEmoji := "👤"
-	roleLabel := "user"
-	if msg.Role == "assistant" {
-		roleEmoji = "🤖"
-		roleLabel = "assistant"
-	}
-
-	// Parse timestamp HH:MM
-	headerTime := ""
-	if msg.Time != "" {
-		parts := strings.Split(msg.Time, "T")
-		if len(parts) >= 2 {
-			timePart := strings.Split(parts[1], "Z")[0]
-			if idx := strings.LastIndex(timePart, ":"); idx > 0 {
-				headerTime = timePart[:idx]
-			}
+		if m.messages[i].IsTurnHeader {
+			d := turnCardData(m.messages[i])
+			d.Content = m.shownText(d.Content)
+			d.Preview = m.cfg.Cards.Preview
+			d.Width = width
+			cards = append(cards, render.TurnCard(d, isSelected, m.cfg.Cost))
+			continue
 		}
-	}
-
-	// Build header with left-aligned icon
-	// Format: [emoji] role · time · model · id
-	headerParts := []string{roleEmoji, roleLabel}
-
-	if headerTime != "" {
-		headerParts = append(headerParts, "·", headerTime)
-	}
-
-	if msg.Model != "" && msg.Role == "assistant" {
-		// Extract model name
-		modelParts := strings.Split(msg.Model, "-")
-		if len(modelParts) > 0 {
-			headerParts = append(headerParts, "·", modelParts[0])
+		if row := m.messages[i]; row.ToolGroup != "" {
+			d := toolGroupCardData(m.messages[i])
+			d.Width = width
+			cards = append(cards, render.ToolGroupCard(d, isSelected, m.cfg.Cost))
+			continue
 		}
-	}
-
-	// Add short message ID
-	if msg.UUID != "" {
-		shortID := msg.UUID
-		if len(shortID) > 8 {
-			shortID = shortID[:8]
+		if row := m.messages[i]; row.BranchSwitch != "" {
+			at, _ := time.Parse(time.RFC3339Nano, row.Time)
+			cards = append(cards, render.BranchCard(render.BranchCardData{Branch: row.BranchSwitch, From: row.BranchFrom, At: at, Width: width}, isSelected))
+			continue
+		}
+		d := cardData(m.messages[i])
+		d.Content = m.shownText(d.Content)
+		d.Width = width
+		d.Marked = m.diffMark != nil && m.messageAtRow(i) == m.diffMark
+		d.LargeContextGrowth = d.ContextGrowth >= m.cfg.Context.GrowthWarnAt
+		d.Slow = d.Throughput > 0 && d.Throughput < m.cfg.Cards.SlowBelow
+		d.Preview = m.cfg.Cards.Preview
+		if m.runningTotal {
+			d.RunningTotal = m.messages[i].CumulativeCost
 		}
-		headerParts = append(headerParts, "·", shortID)
+		cards = append(cards, render.MessageCard(d, isSelected, m.cfg.Cost))
 	}
 
-	headerText := strings.Join(headerParts, " ")
+	return lipgloss.JoinVertical(lipgloss.Left, cards...)
+}
 
-	var headerLine string
-	if isSelected {
-		// Bright, bold header with background for selected
-		headerStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("228")).
-			Background(lipgloss.Color("23")).
-			Bold(true).
-			Padding(0, 1)
-		headerLine = headerStyle.Render(headerText)
-	} else {
-		// Subtle styling for non-selected
-		headerStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("244"))
-		headerLine = headerStyle.Render(headerText)
-	}
+// refreshMessageCards re-renders the message cards into the viewport, remembering how
+// many lines they take for the scrollbar
+func (m *Model) refreshMessageCards() {
+	content := m.renderMessageCards()
+	m.messageLines = strings.Count(content, "\n") + 1
+	m.messageViewport.SetContent(content)
+}
 
-	// Message content - single line, truncated
-	contentCompact := strings.ReplaceAll(msg.Content, "\n", " ")
-	contentCompact = strings.Join(strings.Fields(contentCompact), " ")
-	if len(contentCompact) > 150 {
-		contentCompact = contentCompact[:147] + "…"
+// turnCardData collects what a turn header card shows
+func turnCardData(row MessageRow) render.TurnCardData {
+	return render.TurnCardData{
+		Index:     row.Turn.Index,
+		Start:     row.Turn.StartTime,
+		ToolCalls: row.Turn.ToolCalls,
+		Tokens:    row.Turn.Tokens.Total(),
+		Cost:      row.Cost,
+		Duration:  row.Turn.Duration(),
+		Messages:  row.Turn.End - row.Turn.Start,
+		Content:   row.Content,
+		Expanded:  row.TurnExpanded,
 	}
+}
 
-	var contentLine string
-	if isSelected {
-		// Bright text for selected content
-		contentLine = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("255")).
-			Bold(true).
-			Render(contentCompact)
-	} else {
-		// Regular text for non-selected
-		contentLine = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("250")).
-			Render(contentCompact)
-	}
-
-	// Build metrics line with proper left alignment
-	var metricParts []string
-	if msg.Role == "assistant" {
-		if msg.InputTokens > 0 || msg.OutputTokens > 0 {
-			metricParts = append(metricParts,
-				fmt.Sprintf("in:%d", msg.InputTokens),
-				fmt.Sprintf("out:%d", msg.OutputTokens),
-			)
-
-			// Add cache info
-			if msg.CacheRead > 0 {
-				metricParts = append(metricParts, fmt.Sprintf("cache:↻%d", msg.CacheRead))
-			}
-
-			// Cost with color
-			costColor := "10" // Green
-			if msg.Cost > 0.10 {
-				costColor = "1" // Red
-			} else if msg.Cost > 0.01 {
-				costColor = "3" // Yellow
-			}
-			costStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(costColor))
-			metricParts = append(metricParts, costStyle.Render(fmt.Sprintf("$%.4f", msg.Cost)))
-		}
-	} else {
-		// User message metrics
-		metricParts = append(metricParts, fmt.Sprintf("tokens:%d", msg.InputTokens))
-
-		if msg.Cost > 0 {
-			costColor := "10"
-			if msg.Cost > 0.01 {
-				costColor = "3"
-			}
-			costStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(costColor))
-			metricParts = append(metricParts, costStyle.Render(fmt.Sprintf("$%.6f", msg.Cost)))
-		}
+// toolGroupCardData collects what a tool section header card shows
+func toolGroupCardData(row MessageRow) render.ToolGroupCardData {
+	return render.ToolGroupCardData{
+		Tool:     row.ToolGroup,
+		Calls:    row.ToolGroupCalls,
+		Cost:     row.Cost,
+		First:    row.ToolGroupFirst,
+		Last:     row.ToolGroupLast,
+		Expanded: row.ToolGroupExpanded,
 	}
+}
 
-	metricStr := strings.Join(metricParts, " ")
-	metricLine := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8")).
-		Render(metricStr)
-
-	// Separator - no leading spaces, just use full width up to reasonable length
-	var separatorLine string
-	if isSelected {
-		// Bright separator for selected
-		separatorLine = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("226")).
-			Render(strings.Repeat("▬", 88))
-	} else {
-		// Subtle separator for non-selected
-		separatorLine = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("238")).
-			Render(strings.Repeat("─", 88))
+// cardData collects what a message card shows
+func cardData(row MessageRow) render.CardData {
+	return render.CardData{
+		Role:            row.Role,
+		Content:         row.Content,
+		Time:            row.Time,
+		Model:           row.Model,
+		UUID:            row.UUID,
+		InputTokens:     row.InputTokens,
+		OutputTokens:    row.OutputTokens,
+		CacheWrite:      row.CacheCreation,
+		CacheRead:       row.CacheRead,
+		EstimatedTokens: row.EstimatedTokens,
+		PastedBytes:     row.PastedBytes,
+		ContextUsage:    row.ContextUsage,
+		ContextGrowth:   row.ContextGrowth,
+		Throughput:      row.Throughput,
+		Cost:            row.Cost,
+		Decision:        row.PermissionDecision,
+		Refusal:         row.Refusal,
+		ToolResult:      row.ToolResult,
+		ToolCalls:       row.ToolCalls,
 	}
-
-	// Build card: always 4 lines (left-aligned)
-	var lines []string
-	lines = append(lines, headerLine)
-	lines = append(lines, contentLine)
-	lines = append(lines, metricLine)
-	lines = append(lines, separatorLine)
-
-	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
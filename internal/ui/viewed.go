@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thieso2/promptwatch/internal/monitor"
+	"github.com/thieso2/promptwatch/internal/state"
+	"github.com/thieso2/promptwatch/internal/ui/render"
+)
+
+// viewedLimit is how many sessions the recently viewed list shows
+const viewedLimit = 20
+
+// viewedSession is a row of the recently viewed list
+type viewedSession struct {
+	Session SessionInfo
+	At      time.Time // When it was last opened
+	Missing bool      // The session file has been deleted since
+}
+
+// viewedMsg carries the recently viewed sessions, newest first
+type viewedMsg struct {
+	sessions []viewedSession
+	err      error
+}
+
+// openedSessionMsg reports a recorded session open
+type openedSessionMsg struct {
+	err error
+}
+
+// WithHistoryFile records every session opened to path, for the recently viewed list
+func (m Model) WithHistoryFile(path string) Model {
+	m.historyPath = path
+	return m
+}
+
+// recordVisit appends the opening of the session file at path to the history file in
+// the background
+func (m Model) recordVisit(path string) tea.Cmd {
+	if m.historyPath == "" {
+		return nil
+	}
+	history, at := m.historyPath, time.Now()
+	return m.historyWrites.add(func() error {
+		return state.RecordVisit(history, state.Visit{Path: path, At: at})
+	}, func(err error) tea.Msg { return openedSessionMsg{err: err} })
+}
+
+// loadViewed reads the last viewedLimit distinct sessions opened from the history file
+func (m Model) loadViewed() tea.Cmd {
+	report, history, titleFrom := m.errReports, m.historyPath, m.cfg.Sessions.TitleFrom
+	return func() tea.Msg {
+		if history == "" {
+			return viewedMsg{}
+		}
+		visits, err := state.LoadHistory(history)
+		if err != nil {
+			return viewedMsg{err: err}
+		}
+		home, _ := os.UserHomeDir()
+		var sessions []viewedSession
+		for _, v := range state.RecentVisits(visits, viewedLimit) {
+			row := viewedSession{At: v.At}
+			if _, err := os.Stat(v.Path); err != nil {
+				id := monitor.SessionFileID(v.Path)
+				row.Session = SessionInfo{ID: id, Title: id, Path: v.Path}
+				row.Missing = true
+			} else {
+				row.Session = readSessionInfo(v.Path, report)
+				row.Session.Title = sessionTitle(row.Session, titleFrom)
+			}
+			row.Session.Project, _ = m.projectPaths(filepath.Dir(v.Path), home)
+			sessions = append(sessions, row)
+		}
+		return viewedMsg{sessions: sessions}
+	}
+}
+
+// openViewed opens the recently viewed list
+func (m *Model) openViewed() tea.Cmd {
+	m.viewMode = ViewViewed
+	m.viewedError = ""
+	return m.loadViewed()
+}
+
+// openSelectedViewed opens the session selected in the recently viewed list; the list
+// stands in as its session list, so esc comes back to it
+func (m *Model) openSelectedViewed() tea.Cmd {
+	if m.selectedViewedIdx < 0 || m.selectedViewedIdx >= len(m.viewed) {
+		return nil
+	}
+	row := m.viewed[m.selectedViewedIdx]
+	if row.Missing {
+		m.viewedNote = "✗ " + monitor.ShortenHomePath(row.Session.Path) + " has been deleted"
+		return nil
+	}
+	m.sessionSourceMode = ViewViewed
+	m.sessions = []SessionInfo{row.Session}
+	m.selectedSessionIdx = 0
+	return m.openSelectedSession()
+}
+
+// moveViewedSelection moves the selection of the recently viewed list, wrapping around
+func (m *Model) moveViewedSelection(dir int) {
+	if n := len(m.viewed); n > 0 {
+		m.selectedViewedIdx = (m.selectedViewedIdx + dir + n) % n
+	}
+}
+
+// renderViewedView displays the recently viewed sessions
+func (m Model) renderViewedView() string {
+	rows := make([]render.ViewedRow, len(m.viewed))
+	for i, v := range m.viewed {
+		rows[i] = render.ViewedRow{At: v.At, Project: m.shownPath(v.Session.Project), Title: m.shownText(v.Session.Title), Missing: v.Missing}
+	}
+	return render.Viewed(render.ViewedData{
+		Rows:     rows,
+		Selected: m.selectedViewedIdx,
+		Now:      time.Now(),
+		Error:    m.viewedError,
+		Note:     m.viewedNote,
+		Width:    m.termWidth,
+		Help:     m.renderHelp(),
+	})
+}